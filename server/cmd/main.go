@@ -5,23 +5,36 @@ import (
 	"flag"
 	"fmt"
 	golog "log"
+	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/hnnsly/library-console/internal/attachments"
+	"github.com/hnnsly/library-console/internal/cataloging"
+	"github.com/hnnsly/library-console/internal/circulation"
 	"github.com/hnnsly/library-console/internal/config"
+	"github.com/hnnsly/library-console/internal/copycode"
 	"github.com/hnnsly/library-console/internal/handler"
+	"github.com/hnnsly/library-console/internal/jobs"
 	"github.com/hnnsly/library-console/internal/logger"
+	"github.com/hnnsly/library-console/internal/notify"
 	"github.com/hnnsly/library-console/internal/repository"
 	"github.com/hnnsly/library-console/internal/repository/postgres"
 	"github.com/hnnsly/library-console/internal/repository/redis"
+	"github.com/hnnsly/library-console/internal/sip2"
+	"github.com/hnnsly/library-console/internal/storage"
+	"github.com/hnnsly/library-console/internal/ticket"
+	"github.com/hnnsly/library-console/internal/webhook"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rs/zerolog/log"
 )
 
 func main() {
 	cfgPath := flag.String("c", "config.yml", "path to config")
+	marcDump := flag.String("import-marc", "", "load a MARC dump (ISO 2709 or MARCXML) into the copy cataloguing table and exit")
 	flag.Parse()
 
 	cfg, err := config.Load(*cfgPath)
@@ -41,15 +54,92 @@ func main() {
 	rd := mustOpenRedis(ctx, *cfg.Rd)
 	defer rd.Close()
 
-	repo := repository.New(postgres.New(pgPool), rd)
+	repo := repository.New(pgPool, rd,
+		copycode.New(*cfg.Library.CopyCodes),
+		ticket.New(*cfg.Library.Tickets),
+	)
+
+	if *marcDump != "" {
+		importMARC(ctx, repo, *marcDump)
+		return
+	}
+
+	cataloguer, err := newCataloguer(*cfg.Library.Cataloging, repo)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid cataloging configuration")
+	}
+
+	// Covers and tables of contents live outside the database, on disk or in an S3 bucket
+	attachmentStore, err := storage.New(*cfg.Library.Attachments)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid attachment storage configuration")
+	}
+	files := attachments.New(*cfg.Library.Attachments, repo, attachmentStore)
+
+	// Notification channels; without any only the queue tables are used
+	notifier := notify.NewDispatcher(repo, cfg.Library.Notifications.MaxAttempts)
+	if smtpCfg := cfg.Library.Notifications.SMTP; smtpCfg != nil {
+		transport, err := notify.NewSMTPTransport(*smtpCfg)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid SMTP configuration")
+		}
+		notifier.Register(postgres.NotificationChannelEmail, transport, nil)
+	}
+	if smsCfg := cfg.Library.Notifications.SMS; smsCfg != nil {
+		transport, quiet, err := newSMSTransport(*smsCfg)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid SMS configuration")
+		}
+		notifier.Register(postgres.NotificationChannelSms, transport, quiet)
+	}
+
+	// Start background jobs
+	backgroundJobs := []jobs.Job{
+		jobs.ReleaseNoShowBookings(repo),
+		jobs.RefreshVisitRollups(repo),
+	}
+	if months := cfg.Library.Retention.InactiveMonths; months > 0 {
+		backgroundJobs = append(backgroundJobs, jobs.AnonymizeInactiveReaders(repo, months))
+	}
+	if len(notifier.Channels()) > 0 {
+		backgroundJobs = append(backgroundJobs,
+			jobs.ScheduleLoanReminders(repo, notifier, cfg.Library.Notifications.DueSoonDays, cfg.Library.Notifications.OverdueRepeatDays),
+			jobs.DeliverNotifications(notifier),
+		)
+	}
+	// Webhook events are written to the outbox in the same transaction as the change
+	// and delivered from there; without subscriptions both jobs are no-ops
+	backgroundJobs = append(backgroundJobs,
+		jobs.EmitOverdueWebhooks(repo),
+		jobs.DeliverWebhooks(webhook.NewDeliverer(repo, cfg.Library.Webhooks.MaxAttempts, cfg.Library.Webhooks.Timeout)),
+	)
+	jobs.Start(ctx, backgroundJobs...)
+
+	// Circulation rules shared by the REST API and self-checkout kiosks
+	desk := circulation.New(repo, cfg.Library.Memberships)
 
 	// Create API handler and Fiber app
-	h := handler.NewHandler(repo, cfg.Library)
+	h := handler.NewHandler(repo, desk, notifier, cataloguer, files, cfg.Library)
 	app := h.Router()
+	// Live dashboard events; streams end when ctx is cancelled, before the server shuts down
+	go h.RunEvents(ctx)
 
 	// Start server
 	go startServer(app, cfg.Library.Port)
 
+	// SIP2 listener for self-checkout kiosks and RFID gates
+	sipDone := make(chan struct{})
+	if sipCfg := cfg.Library.SIP2; sipCfg != nil {
+		go func() {
+			defer close(sipDone)
+			if err := sip2.NewServer(*sipCfg, repo, desk).ListenAndServe(ctx); err != nil {
+				log.Fatal().Err(err).Msg("SIP2 server crashed")
+			}
+		}()
+	} else {
+		close(sipDone)
+	}
+
 	<-ctx.Done()
 	log.Info().Msg("Shutdown initiated")
 
@@ -60,6 +150,12 @@ func main() {
 	} else {
 		log.Info().Msg("Library server gracefully stopped")
 	}
+
+	select {
+	case <-sipDone:
+	case <-shutdownCtx.Done():
+		log.Warn().Msg("SIP2 connections did not close in time")
+	}
 }
 
 func mustOpenPg(ctx context.Context, dsn string) *pgxpool.Pool {
@@ -80,6 +176,61 @@ func mustOpenRedis(ctx context.Context, rc config.Redis) *redis.Redis {
 	return r
 }
 
+func newSMSTransport(cfg config.SMSConfig) (*notify.SMSTransport, *notify.QuietHours, error) {
+	var provider notify.SMSProvider
+	if cfg.Provider == "http" {
+		httpProvider, err := notify.NewHTTPSMSProvider(*cfg.HTTP, cfg.Sender)
+		if err != nil {
+			return nil, nil, err
+		}
+		provider = httpProvider
+	} else {
+		provider = notify.NewFileSMSProvider(cfg.FilePath)
+	}
+
+	quiet, err := notify.ParseQuietHours(cfg.QuietHours.Start, cfg.QuietHours.End, cfg.QuietHours.Timezone)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return notify.NewSMSTransport(provider, cfg.CountryCode, cfg.MaxSegments), quiet, nil
+}
+
+func newCataloguer(cfg config.CatalogingConfig, repo *repository.LibraryRepository) (*cataloging.Cataloguer, error) {
+	sources := make([]cataloging.Source, 0, len(cfg.Sources))
+	for _, sourceCfg := range cfg.Sources {
+		if sourceCfg.Type == "http" {
+			source, err := cataloging.NewHTTPSource(sourceCfg)
+			if err != nil {
+				return nil, err
+			}
+			sources = append(sources, source)
+		} else {
+			sources = append(sources, cataloging.NewTableSource(repo))
+		}
+	}
+	return cataloging.New(sources...), nil
+}
+
+func importMARC(ctx context.Context, repo *repository.LibraryRepository, path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Can't open MARC dump")
+	}
+	defer f.Close()
+
+	stats, err := cataloging.ImportMARC(ctx, repo, f, filepath.Base(path))
+	event := log.Info()
+	if err != nil {
+		event = log.Error().Err(err)
+	}
+	event.Int("records", stats.Records).
+		Int("rows", stats.Rows).
+		Int("withoutISBN", stats.WithoutISBN).
+		Int("invalid", stats.Invalid).
+		Msg("MARC dump imported")
+}
+
 func startServer(app *fiber.App, port int) {
 	addr := fmt.Sprintf(":%d", port)
 	log.Info().Msgf("Identity starting on %s", addr)
@@ -0,0 +1,195 @@
+package attachments
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/hnnsly/library-console/internal/config"
+	"github.com/hnnsly/library-console/internal/repository"
+	"github.com/hnnsly/library-console/internal/repository/postgres"
+	"github.com/hnnsly/library-console/internal/storage"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrUnsupportedType содержимое файла не подходит для вложения этого вида
+	ErrUnsupportedType = errors.New("unsupported file type")
+	// ErrTooLarge файл больше разрешенного размера
+	ErrTooLarge = errors.New("file is too large")
+	// ErrInvalidImage изображение не удается прочитать
+	ErrInvalidImage = errors.New("invalid image")
+	// ErrImageDimensions изображение пустое или слишком большое в пикселях
+	ErrImageDimensions = errors.New("image dimensions out of range")
+	// ErrUnknownWidth запрошена ширина, для которой уменьшенные копии не делаются
+	ErrUnknownWidth = errors.New("unknown thumbnail width")
+)
+
+// coverTypes форматы обложек; тип определяется по содержимому, а не по заголовку запроса
+var coverTypes = []string{"image/jpeg", "image/png", "image/gif"}
+
+// Service принимает обложки и оглавления книг: проверяет файлы, делает уменьшенные копии
+// обложек и хранит файлы в хранилище, а сведения о них — в book_attachments
+type Service struct {
+	cfg   config.AttachmentsConfig
+	repo  *repository.LibraryRepository
+	store storage.Storage
+}
+
+// New создает сервис вложений поверх выбранного хранилища
+func New(cfg config.AttachmentsConfig, repo *repository.LibraryRepository, store storage.Storage) *Service {
+	return &Service{cfg: cfg, repo: repo, store: store}
+}
+
+// Upload загружаемый файл
+type Upload struct {
+	BookID     uuid.UUID
+	Kind       postgres.AttachmentKind
+	FileName   *string
+	Data       []byte
+	UploadedBy *uuid.UUID
+}
+
+// MaxSize разрешенный размер файла для вложения этого вида
+func (s *Service) MaxSize(kind postgres.AttachmentKind) int64 {
+	if kind == postgres.AttachmentKindCover {
+		return s.cfg.MaxImageSize
+	}
+	return s.cfg.MaxDocumentSize
+}
+
+// ThumbnailWidths ширины уменьшенных копий обложек
+func (s *Service) ThumbnailWidths() []int {
+	return s.cfg.ThumbnailWidths
+}
+
+// Upload проверяет файл, записывает его и уменьшенные копии в хранилище и сохраняет запись о вложении.
+// Новая обложка заменяет прежнюю, файлы прежней удаляются
+func (s *Service) Upload(ctx context.Context, u Upload) (*postgres.BookAttachment, error) {
+	if int64(len(u.Data)) > s.MaxSize(u.Kind) {
+		return nil, ErrTooLarge
+	}
+
+	contentType := http.DetectContentType(u.Data)
+	id := uuid.New()
+	key := fmt.Sprintf("books/%s/%s", u.BookID, id)
+	sum := sha256.Sum256(u.Data)
+	arg := postgres.CreateBookAttachmentParams{
+		ID:              id,
+		BookID:          u.BookID,
+		Kind:            u.Kind,
+		StorageKey:      key,
+		ContentType:     contentType,
+		SizeBytes:       int64(len(u.Data)),
+		Sha256:          hex.EncodeToString(sum[:]),
+		ThumbnailWidths: []int{},
+		FileName:        u.FileName,
+		UploadedBy:      u.UploadedBy,
+	}
+
+	// Файлы пишутся до записи в базе: запись без файла отдавала бы 404 вместо обложки
+	thumbnails := map[int][]byte{}
+	switch u.Kind {
+	case postgres.AttachmentKindCover:
+		if !slices.Contains(coverTypes, contentType) {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, contentType)
+		}
+		img, err := decodeImage(u.Data)
+		if err != nil {
+			return nil, err
+		}
+		width, height := img.Bounds().Dx(), img.Bounds().Dy()
+		arg.Width, arg.Height = &width, &height
+		for _, w := range s.cfg.ThumbnailWidths {
+			// Копии шире оригинала не делаются, вместо них отдается сам оригинал
+			if w >= width {
+				continue
+			}
+			data, err := encodeJPEG(thumbnail(img, w))
+			if err != nil {
+				return nil, fmt.Errorf("уменьшенная копия %d: %w", w, err)
+			}
+			thumbnails[w] = data
+			arg.ThumbnailWidths = append(arg.ThumbnailWidths, w)
+		}
+		slices.Sort(arg.ThumbnailWidths)
+	case postgres.AttachmentKindToc:
+		if contentType != "application/pdf" {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, contentType)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, u.Kind)
+	}
+
+	if err := s.store.Put(ctx, key, u.Data, contentType); err != nil {
+		return nil, fmt.Errorf("запись файла: %w", err)
+	}
+	for w, data := range thumbnails {
+		if err := s.store.Put(ctx, thumbnailKey(key, w), data, "image/jpeg"); err != nil {
+			s.removeFiles(ctx, key, arg.ThumbnailWidths)
+			return nil, fmt.Errorf("запись уменьшенной копии: %w", err)
+		}
+	}
+
+	created, replaced, err := s.repo.AddBookAttachment(ctx, arg)
+	if err != nil {
+		s.removeFiles(ctx, key, arg.ThumbnailWidths)
+		return nil, err
+	}
+	for _, old := range replaced {
+		s.removeFiles(ctx, old.StorageKey, old.ThumbnailWidths)
+	}
+
+	return created, nil
+}
+
+// Delete удаляет запись о вложении и его файлы
+func (s *Service) Delete(ctx context.Context, bookID, id uuid.UUID) (*postgres.BookAttachment, error) {
+	deleted, err := s.repo.DeleteBookAttachment(ctx, postgres.DeleteBookAttachmentParams{ID: id, BookID: bookID})
+	if err != nil {
+		return nil, err
+	}
+	s.removeFiles(ctx, deleted.StorageKey, deleted.ThumbnailWidths)
+	return deleted, nil
+}
+
+// Open открывает файл вложения. При width > 0 открывается уменьшенная копия этой ширины;
+// если изображение уже этой ширины, отдается оригинал. Возвращает тип содержимого открытого файла
+func (s *Service) Open(ctx context.Context, a *postgres.BookAttachment, width int) (io.ReadCloser, string, error) {
+	if width == 0 {
+		r, err := s.store.Get(ctx, a.StorageKey)
+		return r, a.ContentType, err
+	}
+	if !slices.Contains(s.cfg.ThumbnailWidths, width) {
+		return nil, "", ErrUnknownWidth
+	}
+	if !slices.Contains(a.ThumbnailWidths, width) {
+		r, err := s.store.Get(ctx, a.StorageKey)
+		return r, a.ContentType, err
+	}
+	r, err := s.store.Get(ctx, thumbnailKey(a.StorageKey, width))
+	return r, "image/jpeg", err
+}
+
+// removeFiles удаляет файлы вложения; сбой только записывается в журнал, запись в базе уже согласована
+func (s *Service) removeFiles(ctx context.Context, key string, widths []int) {
+	keys := []string{key}
+	for _, w := range widths {
+		keys = append(keys, thumbnailKey(key, w))
+	}
+	for _, k := range keys {
+		if err := s.store.Delete(ctx, k); err != nil {
+			log.Warn().Err(err).Str("key", k).Msg("Failed to delete attachment file")
+		}
+	}
+}
+
+func thumbnailKey(key string, width int) string {
+	return fmt.Sprintf("%s-w%d.jpg", key, width)
+}
@@ -0,0 +1,150 @@
+package biblio
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// MARCXMLNamespace пространство имен MARC 21 XML
+	MARCXMLNamespace = "http://www.loc.gov/MARC21/slim"
+	// OAIDCNamespace контейнер Dublin Core для OAI-PMH
+	OAIDCNamespace = "http://www.openarchives.org/OAI/2.0/oai_dc/"
+	// SRWDCNamespace контейнер Dublin Core для SRU
+	SRWDCNamespace = "info:srw/schema/1/dc-schema"
+	// DCNamespace элементы Dublin Core
+	DCNamespace = "http://purl.org/dc/elements/1.1/"
+)
+
+// Record библиографическая запись книги из каталога
+type Record struct {
+	ID              uuid.UUID
+	Title           string
+	Authors         []string
+	Isbn            *string
+	Publisher       *string
+	PublicationYear *int
+}
+
+// MARCXML формирует запись MARC 21 в формате MARCXML: 001 — идентификатор книги,
+// 020 ISBN, 100/700 авторы, 245 заглавие, 264 издатель и год
+func MARCXML(r Record) string {
+	var b strings.Builder
+	b.WriteString(`<record xmlns="` + MARCXMLNamespace + `">`)
+	// Длина записи и базовый адрес в MARCXML не используются и заполняются нулями
+	b.WriteString("<leader>00000nam a2200000 u 4500</leader>")
+	controlField(&b, "001", r.ID.String())
+	controlField(&b, "008", field008(r))
+
+	if r.Isbn != nil && *r.Isbn != "" {
+		dataField(&b, "020", ' ', ' ', 'a', *r.Isbn)
+	}
+	titleIndicator := '0'
+	if len(r.Authors) > 0 {
+		dataField(&b, "100", '1', ' ', 'a', r.Authors[0])
+		titleIndicator = '1'
+	}
+	dataField(&b, "245", titleIndicator, '0', 'a', r.Title)
+	if r.Publisher != nil || r.PublicationYear != nil {
+		var subfields []string
+		if r.Publisher != nil && *r.Publisher != "" {
+			subfields = append(subfields, "b", *r.Publisher)
+		}
+		if r.PublicationYear != nil {
+			subfields = append(subfields, "c", strconv.Itoa(*r.PublicationYear))
+		}
+		if len(subfields) > 0 {
+			dataFields(&b, "264", ' ', '1', subfields...)
+		}
+	}
+	for _, author := range r.Authors[min(1, len(r.Authors)):] {
+		dataField(&b, "700", '1', ' ', 'a', author)
+	}
+
+	b.WriteString("</record>")
+	return b.String()
+}
+
+// field008 поле фиксированной длины: тип даты, год издания и неизвестный язык
+func field008(r Record) string {
+	year := "uuuu"
+	dateType := 'n'
+	if r.PublicationYear != nil && *r.PublicationYear > 0 && *r.PublicationYear <= 9999 {
+		year, dateType = fmt.Sprintf("%04d", *r.PublicationYear), 's'
+	}
+	return fmt.Sprintf("%6s%c%s%4s%-3s%17s%s%c%c", "", dateType, year, "", "xx", "", "und", ' ', 'd')
+}
+
+func controlField(b *strings.Builder, tag, value string) {
+	b.WriteString(`<controlfield tag="` + tag + `">`)
+	escape(b, value)
+	b.WriteString("</controlfield>")
+}
+
+func dataField(b *strings.Builder, tag string, ind1, ind2 rune, code rune, value string) {
+	dataFields(b, tag, ind1, ind2, string(code), value)
+}
+
+// dataFields пишет поле с подполями, заданными парами код, значение
+func dataFields(b *strings.Builder, tag string, ind1, ind2 rune, subfields ...string) {
+	fmt.Fprintf(b, `<datafield tag="%s" ind1="%c" ind2="%c">`, tag, ind1, ind2)
+	for i := 0; i+1 < len(subfields); i += 2 {
+		b.WriteString(`<subfield code="` + subfields[i] + `">`)
+		escape(b, subfields[i+1])
+		b.WriteString("</subfield>")
+	}
+	b.WriteString("</datafield>")
+}
+
+// OAIDublinCore формирует простой Dublin Core в контейнере oai_dc
+func OAIDublinCore(r Record) string {
+	return dublinCore(r, "oai_dc", OAIDCNamespace, ` xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"`+
+		` xsi:schemaLocation="http://www.openarchives.org/OAI/2.0/oai_dc/ http://www.openarchives.org/OAI/2.0/oai_dc.xsd"`)
+}
+
+// SRWDublinCore формирует простой Dublin Core в контейнере SRU (info:srw/schema/1/dc-v1.1)
+func SRWDublinCore(r Record) string {
+	return dublinCore(r, "srw_dc", SRWDCNamespace, "")
+}
+
+func dublinCore(r Record, prefix, namespace, extra string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<%s:dc xmlns:%s="%s" xmlns:dc="%s"%s>`, prefix, prefix, namespace, DCNamespace, extra)
+	dcElement(&b, "title", r.Title)
+	for _, author := range r.Authors {
+		dcElement(&b, "creator", author)
+	}
+	if r.Publisher != nil && *r.Publisher != "" {
+		dcElement(&b, "publisher", *r.Publisher)
+	}
+	if r.PublicationYear != nil {
+		dcElement(&b, "date", strconv.Itoa(*r.PublicationYear))
+	}
+	dcElement(&b, "type", "Text")
+	if r.Isbn != nil && *r.Isbn != "" {
+		dcElement(&b, "identifier", "urn:isbn:"+*r.Isbn)
+	}
+	fmt.Fprintf(&b, "</%s:dc>", prefix)
+	return b.String()
+}
+
+func dcElement(b *strings.Builder, name, value string) {
+	b.WriteString("<dc:" + name + ">")
+	escape(b, value)
+	b.WriteString("</dc:" + name + ">")
+}
+
+// escape экранирует текст и выбрасывает символы, недопустимые в XML 1.0
+func escape(b *strings.Builder, s string) {
+	s = strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' || (r >= 0x20 && r != 0xFFFE && r != 0xFFFF) {
+			return r
+		}
+		return -1
+	}, s)
+	xml.EscapeText(b, []byte(s))
+}
@@ -0,0 +1,82 @@
+package cataloging
+
+import (
+	"context"
+	"errors"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrInvalidISBN номер не похож на ISBN-10 или ISBN-13 либо не сходится контрольная цифра
+	ErrInvalidISBN = errors.New("invalid ISBN")
+	// ErrNotFound ни один источник не знает книгу с этим ISBN
+	ErrNotFound = errors.New("no metadata found for the ISBN")
+)
+
+// Description описание книги из источника метаданных
+type Description struct {
+	// ISBNs номера ISBN-13 без дефисов, которые указаны в записи
+	ISBNs           []string
+	Title           string
+	Authors         []string
+	Publisher       *string
+	PublicationYear *int
+}
+
+// Source источник описаний по ISBN. Если книги нет, возвращает ErrNotFound
+type Source interface {
+	Name() string
+	Lookup(ctx context.Context, isbn string) (*Description, error)
+}
+
+// Proposal предложенное описание книги и источник, из которого оно взято
+type Proposal struct {
+	ISBN string
+	Description
+	Source string
+}
+
+// Cataloguer опрашивает источники по порядку и берет первое найденное описание
+type Cataloguer struct {
+	sources []Source
+}
+
+// New создает заимствование описаний из источников в порядке приоритета
+func New(sources ...Source) *Cataloguer {
+	return &Cataloguer{sources: sources}
+}
+
+// Lookup ищет описание по ISBN-10 или ISBN-13. Сбой источника не прерывает поиск:
+// он записывается в журнал и опрашивается следующий источник
+func (c *Cataloguer) Lookup(ctx context.Context, isbn string) (*Proposal, error) {
+	normalized, ok := NormalizeISBN(isbn)
+	if !ok {
+		return nil, ErrInvalidISBN
+	}
+
+	for _, source := range c.sources {
+		d, err := source.Lookup(ctx, normalized)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			log.Warn().Err(err).Str("source", source.Name()).Str("isbn", normalized).Msg("Metadata source failed")
+			continue
+		}
+		return &Proposal{ISBN: normalized, Description: *d, Source: source.Name()}, nil
+	}
+	return nil, ErrNotFound
+}
+
+// ISBNForms варианты записи номера, под которыми книга может уже стоять в каталоге
+func ISBNForms(isbn string) []string {
+	normalized, ok := NormalizeISBN(isbn)
+	if !ok {
+		return nil
+	}
+	return isbnForms(normalized)
+}
@@ -0,0 +1,139 @@
+package cataloging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+
+	"github.com/hnnsly/library-console/internal/config"
+)
+
+// maxResponseSize ограничивает ответ внешнего сервиса
+const maxResponseSize = 4 << 20
+
+// HTTPSource запрашивает описание у внешнего сервиса. Адрес задается шаблоном с .ISBN;
+// ответ — JSON с полями title, authors, publisher, publication_year или MARCXML,
+// в том числе внутри ответа SRU. Ответ 404 и пустой ответ означают, что книги нет
+type HTTPSource struct {
+	cfg    config.MetadataSourceConfig
+	url    *template.Template
+	client *http.Client
+}
+
+type lookupRequest struct {
+	ISBN string
+}
+
+type jsonDescription struct {
+	Title           string   `json:"title"`
+	Authors         []string `json:"authors"`
+	Publisher       *string  `json:"publisher"`
+	PublicationYear *int     `json:"publication_year"`
+}
+
+// NewHTTPSource разбирает шаблон адреса из конфигурации
+func NewHTTPSource(cfg config.MetadataSourceConfig) (*HTTPSource, error) {
+	tmpl, err := template.New("url").Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("шаблон адреса источника %s: %w", cfg.Name, err)
+	}
+	return &HTTPSource{
+		cfg:    cfg,
+		url:    tmpl,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}, nil
+}
+
+func (s *HTTPSource) Name() string {
+	return s.cfg.Name
+}
+
+func (s *HTTPSource) Lookup(ctx context.Context, isbn string) (*Description, error) {
+	var target bytes.Buffer
+	if err := s.url.Execute(&target, lookupRequest{ISBN: isbn}); err != nil {
+		return nil, fmt.Errorf("адрес источника: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("запрос к источнику: %w", err)
+	}
+	if s.cfg.Format == "marcxml" {
+		req.Header.Set("Accept", "application/marcxml+xml, application/xml, text/xml")
+	} else {
+		req.Header.Set("Accept", "application/json")
+	}
+	for name, value := range s.cfg.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("источник %s: %w", s.cfg.Name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusNoContent {
+		return nil, ErrNotFound
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("источник %s: %w", s.cfg.Name, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("источник %s ответил %s: %s", s.cfg.Name, resp.Status, bytes.TrimSpace(body[:min(len(body), 512)]))
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, ErrNotFound
+	}
+
+	d, err := s.decode(body)
+	if err != nil {
+		return nil, err
+	}
+	if len(d.ISBNs) == 0 {
+		d.ISBNs = []string{isbn}
+	}
+	return d, nil
+}
+
+func (s *HTTPSource) decode(body []byte) (*Description, error) {
+	if s.cfg.Format == "marcxml" {
+		reader, err := NewMARCReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		d, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, ErrNotFound
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ответ источника %s: %w", s.cfg.Name, err)
+		}
+		return d, nil
+	}
+
+	var j jsonDescription
+	if err := json.Unmarshal(body, &j); err != nil {
+		return nil, fmt.Errorf("ответ источника %s: %w", s.cfg.Name, err)
+	}
+	if strings.TrimSpace(j.Title) == "" {
+		return nil, ErrNotFound
+	}
+	d := &Description{
+		Title:           strings.TrimSpace(j.Title),
+		Publisher:       j.Publisher,
+		PublicationYear: j.PublicationYear,
+	}
+	for _, author := range j.Authors {
+		if author = strings.TrimSpace(author); author != "" {
+			d.Authors = append(d.Authors, author)
+		}
+	}
+	return d, nil
+}
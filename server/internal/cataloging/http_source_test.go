@@ -0,0 +1,143 @@
+package cataloging
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hnnsly/library-console/internal/config"
+)
+
+const sruResponse = `<?xml version="1.0"?>
+<searchRetrieveResponse xmlns="http://www.loc.gov/zing/srw/">
+  <numberOfRecords>1</numberOfRecords>
+  <records><record>
+    <recordSchema>marcxml</recordSchema>
+    <recordData>
+      <record xmlns="http://www.loc.gov/MARC21/slim">
+        <datafield tag="020" ind1=" " ind2=" "><subfield code="a">9780306406157</subfield></datafield>
+        <datafield tag="245" ind1="1" ind2="0"><subfield code="a">Идиот /</subfield></datafield>
+        <datafield tag="100" ind1="1" ind2=" "><subfield code="a">Достоевский, Ф. М.</subfield></datafield>
+        <datafield tag="260" ind1=" " ind2=" "><subfield code="b">Наука,</subfield><subfield code="c">1989.</subfield></datafield>
+      </record>
+    </recordData>
+  </record></records>
+</searchRetrieveResponse>`
+
+func TestHTTPSourceLookup(t *testing.T) {
+	const isbn = "9785170906307"
+
+	tests := []struct {
+		name    string
+		format  string
+		status  int
+		body    string
+		want    *Description
+		wantErr error // nil при want != nil; ErrNotFound или errAny для прочих ошибок
+	}{
+		{
+			name:   "JSON",
+			status: http.StatusOK,
+			body:   `{"title": " Преступление и наказание ", "authors": ["Достоевский, Ф. М.", " "], "publisher": "АСТ", "publication_year": 2015}`,
+			want: &Description{
+				ISBNs:           []string{isbn},
+				Title:           "Преступление и наказание",
+				Authors:         []string{"Достоевский, Ф. М."},
+				Publisher:       ptr("АСТ"),
+				PublicationYear: ptr(2015),
+			},
+		},
+		{name: "not found", status: http.StatusNotFound, body: `{"error": "not found"}`, wantErr: ErrNotFound},
+		{name: "no content", status: http.StatusNoContent, wantErr: ErrNotFound},
+		{name: "empty body", status: http.StatusOK, body: "  \n", wantErr: ErrNotFound},
+		{name: "JSON without title", status: http.StatusOK, body: `{"title": "", "authors": ["Кто-то"]}`, wantErr: ErrNotFound},
+		{name: "server error", status: http.StatusBadGateway, body: "upstream timeout", wantErr: errAny},
+		{name: "invalid JSON", status: http.StatusOK, body: "<html>", wantErr: errAny},
+		{
+			name:   "MARCXML inside SRU response",
+			format: "marcxml",
+			status: http.StatusOK,
+			body:   sruResponse,
+			want: &Description{
+				ISBNs:           []string{"9780306406157"},
+				Title:           "Идиот",
+				Authors:         []string{"Достоевский, Ф. М."},
+				Publisher:       ptr("Наука"),
+				PublicationYear: ptr(1989),
+			},
+		},
+		{
+			name:    "SRU response without records",
+			format:  "marcxml",
+			status:  http.StatusOK,
+			body:    `<searchRetrieveResponse xmlns="http://www.loc.gov/zing/srw/"><numberOfRecords>0</numberOfRecords></searchRetrieveResponse>`,
+			wantErr: ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/isbn/"+isbn {
+					t.Errorf("request path = %q, want the ISBN from the template", r.URL.Path)
+				}
+				if got := r.Header.Get("X-Api-Key"); got != "secret" {
+					t.Errorf("X-Api-Key = %q, want the configured header", got)
+				}
+				wantAccept := "application/json"
+				if tt.format == "marcxml" {
+					wantAccept = "application/marcxml+xml, application/xml, text/xml"
+				}
+				if got := r.Header.Get("Accept"); got != wantAccept {
+					t.Errorf("Accept = %q, want %q", got, wantAccept)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			source, err := NewHTTPSource(config.MetadataSourceConfig{
+				Type:    "http",
+				Name:    "stub",
+				URL:     srv.URL + "/isbn/{{.ISBN}}",
+				Format:  tt.format,
+				Headers: map[string]string{"X-Api-Key": "secret"},
+				Timeout: 5 * time.Second,
+			})
+			if err != nil {
+				t.Fatalf("NewHTTPSource() error = %v", err)
+			}
+
+			got, err := source.Lookup(context.Background(), isbn)
+			switch {
+			case tt.want != nil:
+				if err != nil {
+					t.Fatalf("Lookup() error = %v", err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("Lookup():\n got %+v\nwant %+v", got, tt.want)
+				}
+			case tt.wantErr == errAny:
+				if err == nil || errors.Is(err, ErrNotFound) {
+					t.Errorf("Lookup() error = %v, want a source failure", err)
+				}
+			default:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Lookup() error = %v, want %v", err, tt.wantErr)
+				}
+			}
+		})
+	}
+}
+
+// errAny ожидается любая ошибка, кроме ErrNotFound: сбой источника
+var errAny = errors.New("any source failure")
+
+func TestNewHTTPSourceInvalidTemplate(t *testing.T) {
+	if _, err := NewHTTPSource(config.MetadataSourceConfig{Name: "broken", URL: "http://example/{{.ISBN"}); err == nil {
+		t.Error("NewHTTPSource() error = nil for an unterminated template")
+	}
+}
@@ -0,0 +1,82 @@
+package cataloging
+
+import "strings"
+
+// NormalizeISBN приводит ISBN-10 или ISBN-13 к ISBN-13 без дефисов. Уточнения после номера,
+// например «(pbk.)» в подполе 020$a, отбрасываются; номер с неверной контрольной цифрой не принимается
+func NormalizeISBN(raw string) (string, bool) {
+	var digits []byte
+	for _, r := range raw {
+		switch {
+		case r >= '0' && r <= '9':
+			digits = append(digits, byte(r))
+		case r == 'X' || r == 'x':
+			digits = append(digits, 'X')
+		case r == '-' || r == ' ':
+		case len(digits) == 0:
+			// Подпись «ISBN» или другая приставка перед номером
+		default:
+			return toISBN13(digits)
+		}
+	}
+	return toISBN13(digits)
+}
+
+func toISBN13(digits []byte) (string, bool) {
+	if i := strings.IndexByte(string(digits), 'X'); i >= 0 && i != len(digits)-1 {
+		return "", false
+	}
+	switch len(digits) {
+	case 10:
+		sum := 0
+		for i, d := range digits {
+			v := int(d - '0')
+			if d == 'X' {
+				v = 10
+			}
+			sum += (10 - i) * v
+		}
+		if sum%11 != 0 {
+			return "", false
+		}
+		isbn := append([]byte("978"), digits[:9]...)
+		return string(append(isbn, checkDigit13(isbn))), true
+	case 13:
+		if digits[12] == 'X' || checkDigit13(digits[:12]) != digits[12] {
+			return "", false
+		}
+		return string(digits), true
+	}
+	return "", false
+}
+
+func checkDigit13(digits []byte) byte {
+	sum := 0
+	for i, d := range digits[:12] {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += weight * int(d-'0')
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+// isbnForms варианты записи ISBN в каталоге: ISBN-13 и, для префикса 978, ISBN-10
+func isbnForms(isbn13 string) []string {
+	forms := []string{isbn13}
+	if strings.HasPrefix(isbn13, "978") {
+		body := isbn13[3:12]
+		sum := 0
+		for i := range 9 {
+			sum += (10 - i) * int(body[i]-'0')
+		}
+		check := (11 - sum%11) % 11
+		digit := byte('0' + check)
+		if check == 10 {
+			digit = 'X'
+		}
+		forms = append(forms, body+string(digit))
+	}
+	return forms
+}
@@ -0,0 +1,386 @@
+package cataloging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Разделители ISO 2709
+const (
+	fieldTerminator    = 0x1E
+	recordTerminator   = 0x1D
+	subfieldDelimiter  = 0x1F
+	leaderLength       = 24
+	directoryEntrySize = 12
+)
+
+// field поле записи MARC: для управляющих полей заполнено value, для полей данных — subfields
+type field struct {
+	tag       string
+	value     string
+	subfields [][2]string
+}
+
+func (f field) subfield(code string) string {
+	for _, sf := range f.subfields {
+		if sf[0] == code {
+			return sf[1]
+		}
+	}
+	return ""
+}
+
+// marcRecord запись MARC в виде списка полей
+type marcRecord []field
+
+func (r marcRecord) fields(tag string) []field {
+	var out []field
+	for _, f := range r {
+		if f.tag == tag {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func (r marcRecord) first(tag, code string) string {
+	for _, f := range r.fields(tag) {
+		if v := f.subfield(code); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// MARCReader читает записи из дампа в ISO 2709 (MARC 21 или RUSMARC в UTF-8) либо в MARCXML;
+// формат определяется по первому значащему байту
+type MARCReader struct {
+	next func() (marcRecord, error)
+}
+
+// NewMARCReader создает чтение дампа
+func NewMARCReader(r io.Reader) (*MARCReader, error) {
+	br := bufio.NewReader(r)
+	// Метка порядка байтов UTF-8 перед первой записью
+	if bom, _ := br.Peek(3); bytes.Equal(bom, []byte{0xEF, 0xBB, 0xBF}) {
+		br.Discard(3)
+	}
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return &MARCReader{next: func() (marcRecord, error) { return nil, io.EOF }}, nil
+			}
+			return nil, err
+		}
+		if b[0] == ' ' || b[0] == '\n' || b[0] == '\r' || b[0] == '\t' {
+			// Пробелы перед первой записью
+			br.ReadByte()
+			continue
+		}
+		if b[0] == '<' {
+			return &MARCReader{next: xmlRecords(xml.NewDecoder(br))}, nil
+		}
+		return &MARCReader{next: isoRecords(br)}, nil
+	}
+}
+
+// Next возвращает следующую запись в виде описания книги. Ошибка *RecordError означает, что
+// испорчена только эта запись и чтение можно продолжать; io.EOF — конец дампа
+func (m *MARCReader) Next() (*Description, error) {
+	rec, err := m.next()
+	if err != nil {
+		return nil, err
+	}
+	d, ok := describe(rec)
+	if !ok {
+		return nil, &RecordError{Reason: "no title"}
+	}
+	return d, nil
+}
+
+// RecordError запись дампа, которую нельзя разобрать
+type RecordError struct {
+	Reason string
+}
+
+func (e *RecordError) Error() string {
+	return "invalid MARC record: " + e.Reason
+}
+
+// isoRecords читает записи ISO 2709: маркер, справочник и поля с разделителями
+func isoRecords(br *bufio.Reader) func() (marcRecord, error) {
+	return func() (marcRecord, error) {
+		raw, err := br.ReadBytes(recordTerminator)
+		raw = bytes.TrimLeft(raw, "\r\n")
+		if len(raw) == 0 && err != nil {
+			return nil, err
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		return parseISO(raw)
+	}
+}
+
+func parseISO(raw []byte) (marcRecord, error) {
+	if len(raw) < leaderLength+1 {
+		return nil, &RecordError{Reason: "record is shorter than the leader"}
+	}
+	if !utf8.Valid(raw) {
+		return nil, &RecordError{Reason: "only UTF-8 records are supported"}
+	}
+	base, ok := marcNumber(raw[12:17])
+	if !ok || base <= leaderLength || base > len(raw) {
+		return nil, &RecordError{Reason: "invalid base address"}
+	}
+	directory := raw[leaderLength : base-1]
+	if len(directory)%directoryEntrySize != 0 {
+		return nil, &RecordError{Reason: "invalid directory"}
+	}
+
+	data := raw[base:]
+	var rec marcRecord
+	for i := 0; i < len(directory); i += directoryEntrySize {
+		entry := directory[i : i+directoryEntrySize]
+		length, ok1 := marcNumber(entry[3:7])
+		start, ok2 := marcNumber(entry[7:12])
+		if !ok1 || !ok2 || start < 0 || length <= 0 || start+length > len(data) {
+			return nil, &RecordError{Reason: "invalid directory entry"}
+		}
+		tag := string(entry[:3])
+		value := bytes.TrimRight(data[start:start+length], string([]byte{fieldTerminator, recordTerminator}))
+		if strings.HasPrefix(tag, "00") {
+			rec = append(rec, field{tag: tag, value: string(value)})
+			continue
+		}
+
+		f := field{tag: tag}
+		parts := bytes.Split(value, []byte{subfieldDelimiter})
+		// parts[0] — индикаторы
+		for _, part := range parts[1:] {
+			if len(part) == 0 {
+				continue
+			}
+			f.subfields = append(f.subfields, [2]string{string(part[:1]), string(part[1:])})
+		}
+		rec = append(rec, f)
+	}
+	return rec, nil
+}
+
+// marcNumber разбирает числовое поле маркера или справочника. В ISO 2709 это только цифры,
+// strconv.Atoi принял бы еще знак и отрицательное смещение
+func marcNumber(b []byte) (int, bool) {
+	n := 0
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		n = n*10 + int(c-'0')
+	}
+	return n, len(b) > 0
+}
+
+type xmlSubfield struct {
+	Code  string `xml:"code,attr"`
+	Value string `xml:",chardata"`
+}
+
+type xmlRecord struct {
+	ControlFields []struct {
+		Tag   string `xml:"tag,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"controlfield"`
+	DataFields []struct {
+		Tag       string        `xml:"tag,attr"`
+		Subfields []xmlSubfield `xml:"subfield"`
+	} `xml:"datafield"`
+}
+
+// xmlRecords находит элементы record в любом месте документа, поэтому читает
+// и голый MARCXML, и записи внутри ответов SRU или OAI-PMH
+func xmlRecords(dec *xml.Decoder) func() (marcRecord, error) {
+	return func() (marcRecord, error) {
+		for {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			start, ok := tok.(xml.StartElement)
+			if !ok || start.Name.Local != "record" || (start.Name.Space != "" && !strings.Contains(start.Name.Space, "MARC21")) {
+				continue
+			}
+
+			var x xmlRecord
+			if err := dec.DecodeElement(&x, &start); err != nil {
+				return nil, fmt.Errorf("MARCXML: %w", err)
+			}
+			var rec marcRecord
+			for _, cf := range x.ControlFields {
+				rec = append(rec, field{tag: cf.Tag, value: cf.Value})
+			}
+			for _, df := range x.DataFields {
+				f := field{tag: df.Tag}
+				for _, sf := range df.Subfields {
+					f.subfields = append(f.subfields, [2]string{sf.Code, sf.Value})
+				}
+				rec = append(rec, f)
+			}
+			if len(rec) == 0 {
+				// Элемент record другой схемы, например запись SRU без namespace
+				continue
+			}
+			return rec, nil
+		}
+	}
+}
+
+// describe извлекает описание книги. MARC 21 узнается по полю 245, RUSMARC/UNIMARC — по полю 200
+func describe(rec marcRecord) (*Description, bool) {
+	d := &Description{}
+	if len(rec.fields("245")) > 0 {
+		describeMARC21(rec, d)
+	} else {
+		describeUNIMARC(rec, d)
+	}
+	if d.Title == "" {
+		return nil, false
+	}
+	return d, true
+}
+
+func describeMARC21(rec marcRecord, d *Description) {
+	for _, f := range rec.fields("020") {
+		if isbn, ok := NormalizeISBN(f.subfield("a")); ok {
+			d.ISBNs = append(d.ISBNs, isbn)
+		}
+	}
+
+	title := rec.fields("245")[0]
+	d.Title = joinTitle(title.subfield("a"), title.subfield("b"))
+
+	for _, tag := range []string{"100", "700"} {
+		for _, f := range rec.fields(tag) {
+			if name := trimPunctuation(f.subfield("a")); name != "" {
+				d.Authors = append(d.Authors, name)
+			}
+		}
+	}
+
+	// 264 с RDA, 260 в старых записях
+	for _, tag := range []string{"264", "260"} {
+		if d.Publisher == nil {
+			if p := trimPunctuation(rec.first(tag, "b")); p != "" {
+				d.Publisher = &p
+			}
+		}
+		if d.PublicationYear == nil {
+			d.PublicationYear = parseYear(rec.first(tag, "c"))
+		}
+	}
+	if d.PublicationYear == nil {
+		for _, f := range rec.fields("008") {
+			if len(f.value) >= 11 {
+				d.PublicationYear = parseYear(f.value[7:11])
+			}
+		}
+	}
+}
+
+func describeUNIMARC(rec marcRecord, d *Description) {
+	for _, f := range rec.fields("010") {
+		if isbn, ok := NormalizeISBN(f.subfield("a")); ok {
+			d.ISBNs = append(d.ISBNs, isbn)
+		}
+	}
+
+	for _, f := range rec.fields("200") {
+		d.Title = joinTitle(f.subfield("a"), f.subfield("e"))
+		break
+	}
+
+	// 700 — первый автор, 701 — другие; $a фамилия, $b инициалы или $g полное имя
+	for _, tag := range []string{"700", "701"} {
+		for _, f := range rec.fields(tag) {
+			name := trimPunctuation(f.subfield("a"))
+			given := f.subfield("g")
+			if given == "" {
+				given = f.subfield("b")
+			}
+			if given = trimPunctuation(given); given != "" && name != "" {
+				name += ", " + given
+			}
+			if name != "" {
+				d.Authors = append(d.Authors, name)
+			}
+		}
+	}
+
+	// 210 в RUSMARC, 214 в UNIMARC с 2012 года
+	for _, tag := range []string{"210", "214"} {
+		if d.Publisher == nil {
+			if p := trimPunctuation(rec.first(tag, "c")); p != "" {
+				d.Publisher = &p
+			}
+		}
+		if d.PublicationYear == nil {
+			d.PublicationYear = parseYear(rec.first(tag, "d"))
+		}
+	}
+	if d.PublicationYear == nil {
+		for _, f := range rec.fields("100") {
+			if v := f.subfield("a"); len(v) >= 13 {
+				d.PublicationYear = parseYear(v[9:13])
+			}
+		}
+	}
+}
+
+func joinTitle(main, rest string) string {
+	main, rest = trimPunctuation(main), trimPunctuation(rest)
+	if rest == "" {
+		return main
+	}
+	return main + ": " + rest
+}
+
+// trimPunctuation убирает знаки ISBD, которыми MARC отделяет подполя
+func trimPunctuation(s string) string {
+	s = strings.TrimSpace(s)
+	s = strings.TrimRight(s, " /:;,=")
+	if strings.HasSuffix(s, ".") && !strings.HasSuffix(s, "..") {
+		// Точку после инициала («Толстой, Л. Н.») оставляем
+		if i := strings.LastIndexAny(s, " ,"); i < 0 || utf8.RuneCountInString(s[i+1:]) > 2 {
+			s = strings.TrimSuffix(s, ".")
+		}
+	}
+	return strings.TrimSpace(strings.Trim(s, "[]"))
+}
+
+// parseYear берет первые четыре цифры подряд: «c2019.», «[1998]», «2005-»
+func parseYear(s string) *int {
+	run := 0
+	for i, r := range s {
+		if r >= '0' && r <= '9' {
+			run++
+			if run == 4 {
+				year, _ := strconv.Atoi(s[i-3 : i+1])
+				if year > 0 {
+					return &year
+				}
+				return nil
+			}
+			continue
+		}
+		run = 0
+	}
+	return nil
+}
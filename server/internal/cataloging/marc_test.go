@@ -0,0 +1,204 @@
+package cataloging
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// isoField поле для сборки записи ISO 2709: тег и содержимое без разделителя поля
+type isoField struct {
+	tag, body string
+}
+
+// dataField собирает содержимое поля данных из индикаторов и пар «код, значение»
+func dataField(tag string, subfields ...string) isoField {
+	var b strings.Builder
+	b.WriteString("  ")
+	for i := 0; i+1 < len(subfields); i += 2 {
+		b.WriteByte(subfieldDelimiter)
+		b.WriteString(subfields[i])
+		b.WriteString(subfields[i+1])
+	}
+	return isoField{tag: tag, body: b.String()}
+}
+
+// isoRecord собирает запись ISO 2709 с маркером и справочником
+func isoRecord(fields ...isoField) string {
+	var directory, data strings.Builder
+	for _, f := range fields {
+		body := f.body + string(rune(fieldTerminator))
+		fmt.Fprintf(&directory, "%s%04d%05d", f.tag, len(body), data.Len())
+		data.WriteString(body)
+	}
+	base := leaderLength + directory.Len() + 1
+	length := base + data.Len() + 1
+	leader := fmt.Sprintf("%05dnam a22%05d   4500", length, base)
+	return leader + directory.String() + string(rune(fieldTerminator)) + data.String() + string(rune(recordTerminator))
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+var (
+	marc21Record = isoRecord(
+		isoField{tag: "008", body: "190101s2019    ru            000 1 rus d"},
+		dataField("020", "a", "0-306-40615-2"),
+		dataField("100", "a", "Толстой, Л. Н."),
+		dataField("245", "a", "Война и мир :", "b", "роман /"),
+		dataField("264", "b", "Эксмо,", "c", "[2019]."),
+		dataField("700", "a", "Иванов, И."),
+	)
+	rusmarcRecord = isoRecord(
+		dataField("010", "a", "978-0-306-40615-7"),
+		dataField("100", "a", "20200101d2020    k  y0rusy0189    ca"),
+		dataField("200", "a", "Анна Каренина", "e", "роман"),
+		dataField("700", "a", "Толстой", "g", "Лев Николаевич"),
+		dataField("701", "a", "Петров", "b", "П. П."),
+		dataField("210", "c", "АСТ"),
+	)
+	untitledRecord = isoRecord(dataField("100", "a", "Без названия"))
+	marcXML        = `<?xml version="1.0" encoding="UTF-8"?>
+<collection xmlns="http://www.loc.gov/MARC21/slim">
+  <record>
+    <controlfield tag="008">190101s2005    ru            000 1 rus d</controlfield>
+    <datafield tag="245" ind1="1" ind2="0"><subfield code="a">Мастер и Маргарита</subfield></datafield>
+    <datafield tag="100" ind1="1" ind2=" "><subfield code="a">Булгаков, М. А.</subfield></datafield>
+  </record>
+</collection>`
+)
+
+func TestMARCReader(t *testing.T) {
+	warAndPeace := &Description{
+		ISBNs:           []string{"9780306406157"},
+		Title:           "Война и мир: роман",
+		Authors:         []string{"Толстой, Л. Н.", "Иванов, И."},
+		Publisher:       ptr("Эксмо"),
+		PublicationYear: ptr(2019),
+	}
+	annaKarenina := &Description{
+		ISBNs:           []string{"9780306406157"},
+		Title:           "Анна Каренина: роман",
+		Authors:         []string{"Толстой, Лев Николаевич", "Петров, П. П."},
+		Publisher:       ptr("АСТ"),
+		PublicationYear: ptr(2020),
+	}
+	masterAndMargarita := &Description{
+		Title:           "Мастер и Маргарита",
+		Authors:         []string{"Булгаков, М. А."},
+		PublicationYear: ptr(2005),
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  []*Description // nil в списке — запись, которая должна вернуть *RecordError
+	}{
+		{"empty dump", "", nil},
+		{"MARC 21", marc21Record, []*Description{warAndPeace}},
+		{"RUSMARC", rusmarcRecord, []*Description{annaKarenina}},
+		{"several records with line breaks", marc21Record + "\r\n" + rusmarcRecord + "\n", []*Description{warAndPeace, annaKarenina}},
+		{"byte order mark", "\xEF\xBB\xBF" + marc21Record, []*Description{warAndPeace}},
+		{"record without title is skipped", untitledRecord + marc21Record, []*Description{nil, warAndPeace}},
+		{"MARCXML", "\n  " + marcXML, []*Description{masterAndMargarita}},
+		{"MARCXML with byte order mark", "\xEF\xBB\xBF" + marcXML, []*Description{masterAndMargarita}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := NewMARCReader(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("NewMARCReader() error = %v", err)
+			}
+			for i, want := range tt.want {
+				got, err := reader.Next()
+				if want == nil {
+					var recErr *RecordError
+					if !errors.As(err, &recErr) {
+						t.Fatalf("record %d: error = %v, want *RecordError", i, err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("record %d: error = %v", i, err)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("record %d:\n got %+v\nwant %+v", i, got, want)
+				}
+			}
+			if _, err := reader.Next(); !errors.Is(err, io.EOF) {
+				t.Errorf("after the last record error = %v, want io.EOF", err)
+			}
+		})
+	}
+}
+
+func TestParseISOInvalid(t *testing.T) {
+	valid := marc21Record[:len(marc21Record)-1]
+	// Смещение первого поля в справочнике: маркер, тег и длина поля
+	offset := leaderLength + 3 + 4
+
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"shorter than leader", "00010nam"},
+		{"non-numeric base address", valid[:12] + "abcde" + valid[17:]},
+		{"base address past the end", valid[:12] + "99999" + valid[17:]},
+		{"signed field offset", valid[:offset] + "-0001" + valid[offset+5:]},
+		{"field past the end", valid[:offset] + "99999" + valid[offset+5:]},
+		{"truncated directory", valid[:12] + fmt.Sprintf("%05d", leaderLength+5) + valid[17:]},
+		{"not UTF-8", valid[:len(valid)-3] + "\xff\xfe" + valid[len(valid)-1:]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseISO([]byte(tt.raw))
+			var recErr *RecordError
+			if !errors.As(err, &recErr) {
+				t.Errorf("parseISO() error = %v, want *RecordError", err)
+			}
+		})
+	}
+}
+
+func TestTrimPunctuation(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Война и мир :", "Война и мир"},
+		{"роман /", "роман"},
+		{"Толстой, Л. Н.", "Толстой, Л. Н."},
+		{"Эксмо,", "Эксмо"},
+		{"[Москва]", "Москва"},
+		{"Конец.", "Конец"},
+		{"И так далее...", "И так далее..."},
+	}
+	for _, tt := range tests {
+		if got := trimPunctuation(tt.in); got != tt.want {
+			t.Errorf("trimPunctuation(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseYear(t *testing.T) {
+	tests := []struct {
+		in   string
+		want *int
+	}{
+		{"c2019.", ptr(2019)},
+		{"[1998]", ptr(1998)},
+		{"2005-", ptr(2005)},
+		{"19 век", nil},
+		{"0000", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		got := parseYear(tt.in)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseYear(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
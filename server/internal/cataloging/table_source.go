@@ -0,0 +1,134 @@
+package cataloging
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/hnnsly/library-console/internal/repository"
+	"github.com/hnnsly/library-console/internal/repository/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+// importBatch сколько строк таблицы записывается одной транзакцией
+const importBatch = 500
+
+// TableSource ищет описания в таблице marc_records, загруженной из дампов MARC
+type TableSource struct {
+	repo *repository.LibraryRepository
+}
+
+// NewTableSource создает источник по загруженным дампам
+func NewTableSource(repo *repository.LibraryRepository) *TableSource {
+	return &TableSource{repo: repo}
+}
+
+func (s *TableSource) Name() string {
+	return "marc"
+}
+
+func (s *TableSource) Lookup(ctx context.Context, isbn string) (*Description, error) {
+	rec, err := s.repo.GetMarcRecordByIsbn(ctx, isbn)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Description{
+		ISBNs:           []string{rec.Isbn},
+		Title:           rec.Title,
+		Authors:         rec.Authors,
+		Publisher:       rec.Publisher,
+		PublicationYear: rec.PublicationYear,
+	}, nil
+}
+
+// ImportStats итог загрузки дампа
+type ImportStats struct {
+	Records int
+	// Rows строк таблицы: запись с несколькими ISBN дает несколько строк
+	Rows int
+	// WithoutISBN записи без действительного ISBN, найти их по номеру нельзя
+	WithoutISBN int
+	// Invalid записи, которые не удалось разобрать
+	Invalid int
+}
+
+// ImportMARC загружает дамп в таблицу marc_records; source — имя дампа, оно сохраняется
+// в строках. Испорченные записи пропускаются, ошибка чтения или базы прерывает загрузку,
+// уже записанные пачки при этом остаются
+func ImportMARC(ctx context.Context, repo *repository.LibraryRepository, r io.Reader, source string) (ImportStats, error) {
+	var stats ImportStats
+	reader, err := NewMARCReader(r)
+	if err != nil {
+		return stats, err
+	}
+
+	batch := make([]postgres.UpsertMarcRecordParams, 0, importBatch)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := repo.ImportMarcRecords(ctx, batch); err != nil {
+			return err
+		}
+		stats.Rows += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		d, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var recErr *RecordError
+		if errors.As(err, &recErr) {
+			stats.Invalid++
+			continue
+		}
+		if err != nil {
+			return stats, err
+		}
+
+		stats.Records++
+		if len(d.ISBNs) == 0 {
+			stats.WithoutISBN++
+			continue
+		}
+		for _, isbn := range d.ISBNs {
+			batch = append(batch, postgres.UpsertMarcRecordParams{
+				Isbn:            isbn,
+				Title:           truncate(d.Title, 500),
+				Authors:         append([]string{}, d.Authors...),
+				Publisher:       truncatePtr(d.Publisher, 200),
+				PublicationYear: d.PublicationYear,
+				Source:          truncate(source, 200),
+			})
+		}
+		if len(batch) >= importBatch {
+			if err := flush(); err != nil {
+				return stats, err
+			}
+		}
+	}
+	return stats, flush()
+}
+
+// truncate обрезает строку до размера столбца в символах
+func truncate(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit])
+}
+
+func truncatePtr(s *string, limit int) *string {
+	if s == nil {
+		return nil
+	}
+	v := truncate(*s, limit)
+	return &v
+}
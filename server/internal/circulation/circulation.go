@@ -0,0 +1,313 @@
+package circulation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hnnsly/library-console/internal/config"
+	"github.com/hnnsly/library-console/internal/repository"
+	"github.com/hnnsly/library-console/internal/repository/postgres"
+	"github.com/hnnsly/library-console/internal/repository/redis"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrReaderNotFound читатель не найден
+	ErrReaderNotFound = errors.New("читатель не найден")
+	// ErrReaderInactive читатель деактивирован
+	ErrReaderInactive = errors.New("читатель деактивирован")
+	// ErrMembershipExpired срок членства читателя истёк
+	ErrMembershipExpired = errors.New("срок членства истёк")
+	// ErrPolicyMissing для категории читателя нет правил выдачи
+	ErrPolicyMissing = errors.New("категория читателя не настроена")
+	// ErrLoanLimit читатель достиг лимита одновременных выдач
+	ErrLoanLimit = errors.New("достигнут лимит выдач")
+	// ErrLoanPeriod запрошенный срок выдачи больше разрешенного категорией
+	ErrLoanPeriod = errors.New("срок выдачи превышает лимит категории")
+	// ErrReaderOverdue у читателя есть просроченные книги
+	ErrReaderOverdue = errors.New("у читателя есть просроченные книги")
+	// ErrCopyNotFound экземпляр с таким шифром не найден
+	ErrCopyNotFound = errors.New("экземпляр не найден")
+	// ErrCopyUnavailable экземпляр сейчас нельзя выдать
+	ErrCopyUnavailable = errors.New("экземпляр недоступен для выдачи")
+	// ErrCopyReserved экземпляр отложен для другого читателя или партнера по МБА
+	ErrCopyReserved = fmt.Errorf("%w: экземпляр отложен для другого читателя", ErrCopyUnavailable)
+	// ErrNotCheckedOut у экземпляра нет открытой выдачи
+	ErrNotCheckedOut = errors.New("экземпляр не выдан")
+	// ErrIssuedToOther экземпляр выдан другому читателю
+	ErrIssuedToOther = errors.New("экземпляр выдан другому читателю")
+)
+
+// Desk правила выдачи и возврата, общие для REST API и киосков самообслуживания
+type Desk struct {
+	repo        *repository.LibraryRepository
+	memberships map[string]config.MembershipCategoryConfig
+}
+
+// New создает Desk с правилами категорий читателей
+func New(repo *repository.LibraryRepository, memberships map[string]config.MembershipCategoryConfig) *Desk {
+	return &Desk{repo: repo, memberships: memberships}
+}
+
+// Policy возвращает правила выдачи для категории читателя
+func (d *Desk) Policy(category postgres.ReaderCategory) (config.MembershipCategoryConfig, bool) {
+	policy, ok := d.memberships[string(category)]
+	return policy, ok
+}
+
+// Checkout параметры выдачи экземпляра
+type Checkout struct {
+	ReaderID    uuid.UUID
+	CopyCode    string
+	DueDays     int        // 0 — срок выдачи категории читателя
+	LibrarianID *uuid.UUID // nil для выдач по запросу партнера через NCIP
+	Reason      string
+
+	// Reserved экземпляр выдается из статуса «зарезервирован», отложенный по запросу партнера
+	// через NCIP. Экземпляр, отложенный для читателя, выдается ему и без этого флага
+	Reserved bool
+	// SourceLibrary и ExternalUserID отмечают межбиблиотечную выдачу: агентство партнера и его читатель
+	SourceLibrary  string
+	ExternalUserID string
+}
+
+// Loan оформленная выдача вместе с экземпляром и примененными правилами
+type Loan struct {
+	Issue  *postgres.IssueBookRow
+	Copy   *postgres.GetAvailableBookCopyRow
+	Policy config.MembershipCategoryConfig
+}
+
+// Return закрытая выдача и возвращенный экземпляр
+type Return struct {
+	Issue *postgres.ReturnBookRow
+	Copy  *postgres.GetBookCopyByCodeRow
+}
+
+// IssueEvent событие выдачи или возврата для клиентов реального времени
+type IssueEvent struct {
+	IssueID    uuid.UUID  `json:"issue_id"`
+	ReaderID   uuid.UUID  `json:"reader_id"`
+	CopyCode   string     `json:"copy_code"`
+	Title      string     `json:"title"`
+	DueDate    time.Time  `json:"due_date"`
+	ReturnDate *time.Time `json:"return_date,omitempty"`
+}
+
+// LimitError нарушено ограничение категории читателя; Limit — величина ограничения
+type LimitError struct {
+	Err   error
+	Limit int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s (%d)", e.Err, e.Limit)
+}
+
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
+
+// Eligibility проверяет, может ли читатель брать книги: активен, членство действует,
+// лимит выдач не исчерпан и нет просрочек. Возвращает правила его категории
+func (d *Desk) Eligibility(ctx context.Context, readerID uuid.UUID) (config.MembershipCategoryConfig, error) {
+	reader, err := d.repo.GetReaderLoanStatus(ctx, readerID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return config.MembershipCategoryConfig{}, ErrReaderNotFound
+		}
+		return config.MembershipCategoryConfig{}, err
+	}
+	if reader.IsActive != nil && !*reader.IsActive {
+		return config.MembershipCategoryConfig{}, ErrReaderInactive
+	}
+	if repository.MembershipExpired(reader.MembershipExpiresAt, time.Now()) {
+		return config.MembershipCategoryConfig{}, ErrMembershipExpired
+	}
+	policy, ok := d.Policy(reader.Category)
+	if !ok {
+		return config.MembershipCategoryConfig{}, fmt.Errorf("%w: %s", ErrPolicyMissing, reader.Category)
+	}
+	if reader.ActiveLoans >= int64(policy.MaxLoans) {
+		return policy, &LimitError{Err: ErrLoanLimit, Limit: policy.MaxLoans}
+	}
+
+	overdue, err := d.repo.CheckReaderOverdueBooks(ctx, readerID)
+	if err != nil {
+		return policy, err
+	}
+	if overdue > 0 {
+		return policy, ErrReaderOverdue
+	}
+
+	return policy, nil
+}
+
+// Checkout проверяет читателя и выдает ему доступный экземпляр
+func (d *Desk) Checkout(ctx context.Context, c Checkout) (*Loan, error) {
+	policy, err := d.Eligibility(ctx, c.ReaderID)
+	if err != nil {
+		return nil, err
+	}
+
+	dueDays := c.DueDays
+	if dueDays == 0 {
+		dueDays = policy.LoanDays
+	}
+	if dueDays < 1 || dueDays > policy.LoanDays {
+		return nil, &LimitError{Err: ErrLoanPeriod, Limit: policy.LoanDays}
+	}
+
+	bookCopy, err := d.issuableCopy(ctx, c)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrCopyUnavailable
+		}
+		return nil, err
+	}
+
+	issue, err := d.repo.IssueBookCopy(ctx, postgres.IssueBookParams{
+		ReaderID:       c.ReaderID,
+		BookCopyID:     bookCopy.ID,
+		DueDate:        time.Now().AddDate(0, 0, dueDays),
+		LibrarianID:    c.LibrarianID,
+		SourceLibrary:  nilIfEmpty(c.SourceLibrary),
+		ExternalUserID: nilIfEmpty(c.ExternalUserID),
+	}, c.Reason)
+	if err != nil {
+		// Экземпляр успели выдать или перевести в другой статус между проверкой и выдачей
+		if errors.Is(err, repository.ErrInvalidCopyTransition) {
+			return nil, ErrCopyUnavailable
+		}
+		return nil, err
+	}
+
+	d.afterChange(ctx, redis.EventBookIssued, IssueEvent{
+		IssueID:  issue.ID,
+		ReaderID: c.ReaderID,
+		CopyCode: bookCopy.CopyCode,
+		Title:    bookCopy.Title,
+		DueDate:  issue.DueDate,
+	})
+
+	return &Loan{Issue: issue, Copy: bookCopy, Policy: policy}, nil
+}
+
+// issuableCopy находит экземпляр в статусе, из которого его можно выдать по этому запросу:
+// доступный или отложенный именно для этого читателя, а по запросу партнера — отложенный для МБА
+func (d *Desk) issuableCopy(ctx context.Context, c Checkout) (*postgres.GetAvailableBookCopyRow, error) {
+	if !c.Reserved {
+		available, err := d.repo.GetAvailableBookCopy(ctx, c.CopyCode)
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return available, err
+		}
+	}
+	reserved, err := d.repo.GetReservedBookCopy(ctx, c.CopyCode)
+	if err != nil {
+		return nil, err
+	}
+
+	holder := reserved.HoldReaderID
+	if (holder == nil && !c.Reserved) || (holder != nil && *holder != c.ReaderID) {
+		return nil, ErrCopyReserved
+	}
+	return &postgres.GetAvailableBookCopyRow{
+		ID:           reserved.ID,
+		CopyCode:     reserved.CopyCode,
+		Status:       reserved.Status,
+		Title:        reserved.Title,
+		LocationInfo: reserved.LocationInfo,
+	}, nil
+}
+
+// Checkin закрывает открытую выдачу экземпляра и возвращает его в фонд
+func (d *Desk) Checkin(ctx context.Context, copyCode string, librarianID *uuid.UUID, reason string) (*Return, error) {
+	bookCopy, err := d.repo.GetBookCopyByCode(ctx, copyCode)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrCopyNotFound
+		}
+		return nil, err
+	}
+
+	returned, err := d.repo.ReturnBookCopy(ctx, bookCopy.ID, librarianID, reason)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotCheckedOut
+		}
+		return nil, err
+	}
+
+	d.afterChange(ctx, redis.EventBookReturned, IssueEvent{
+		IssueID:    returned.ID,
+		ReaderID:   returned.ReaderID,
+		CopyCode:   bookCopy.CopyCode,
+		Title:      bookCopy.Title,
+		DueDate:    returned.DueDate,
+		ReturnDate: returned.ReturnDate,
+	})
+
+	return &Return{Issue: returned, Copy: bookCopy}, nil
+}
+
+// Renew продлевает выдачу экземпляра читателем по правилам его категории,
+// как при продлении через портал
+func (d *Desk) Renew(ctx context.Context, readerID uuid.UUID, copyCode string) (*postgres.RenewBookIssueRow, error) {
+	issue, err := d.repo.GetActiveIssueByCopyCode(ctx, copyCode)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotCheckedOut
+		}
+		return nil, err
+	}
+	if issue.ReaderID != readerID {
+		return nil, ErrIssuedToOther
+	}
+
+	reader, err := d.repo.GetReaderLoanStatus(ctx, readerID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrReaderNotFound
+		}
+		return nil, err
+	}
+	if reader.IsActive != nil && !*reader.IsActive {
+		return nil, ErrReaderInactive
+	}
+	if repository.MembershipExpired(reader.MembershipExpiresAt, time.Now()) {
+		return nil, ErrMembershipExpired
+	}
+	policy, ok := d.Policy(reader.Category)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrPolicyMissing, reader.Category)
+	}
+
+	return d.repo.RenewLoan(ctx, repository.LoanRenewal{
+		ReaderID:            readerID,
+		IssueID:             issue.ID,
+		LoanDays:            policy.LoanDays,
+		MaxRenewals:         policy.MaxRenewals,
+		MembershipExpiresAt: reader.MembershipExpiresAt,
+	})
+}
+
+// afterChange оповещает реплики сервера и сбрасывает кэш каталога; сбои не отменяют операцию
+func (d *Desk) afterChange(ctx context.Context, eventType string, event IssueEvent) {
+	if err := d.repo.PublishEvent(ctx, eventType, event); err != nil {
+		log.Warn().Err(err).Str("eventType", eventType).Msg("Failed to publish event")
+	}
+	if err := d.repo.InvalidateCatalog(ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to invalidate catalogue cache")
+	}
+}
+
+func nilIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
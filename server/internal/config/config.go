@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"time"
 
@@ -46,11 +47,358 @@ type SessionConfig struct {
 	SameSite   string        `yaml:"sameSite"`
 }
 
+// CopyCodeConfig describes how copy codes are generated:
+// <prefix><sep><hall code><sep><zero-padded sequence><check digit>
+type CopyCodeConfig struct {
+	Prefix     string `yaml:"prefix,omitempty"`
+	HallCode   bool   `yaml:"hallCode"`
+	Digits     int    `yaml:"digits"`
+	CheckDigit bool   `yaml:"checkDigit"`
+	Separator  string `yaml:"separator,omitempty"`
+}
+
+// TicketConfig describes reader ticket numbers: <prefix><zero-padded sequence><Luhn check digit>.
+// The check digit is verified only when Prefix is set, since without it generated numbers
+// cannot be told apart from manually entered ones
+type TicketConfig struct {
+	Prefix         string `yaml:"prefix,omitempty"`
+	Digits         int    `yaml:"digits"`
+	ValidityMonths int    `yaml:"validityMonths"`
+}
+
+// MembershipCategoryConfig holds loan limits and membership duration of a reader category
+type MembershipCategoryConfig struct {
+	MaxLoans    int `yaml:"maxLoans"`
+	LoanDays    int `yaml:"loanDays"`
+	MaxRenewals int `yaml:"maxRenewals"`
+	Months      int `yaml:"months"`
+}
+
+// DefaultMemberships are used for categories missing from the configuration
+var DefaultMemberships = map[string]MembershipCategoryConfig{
+	"student": {MaxLoans: 5, LoanDays: 14, MaxRenewals: 2, Months: 12},
+	"staff":   {MaxLoans: 10, LoanDays: 30, MaxRenewals: 3, Months: 12},
+	"guest":   {MaxLoans: 2, LoanDays: 7, MaxRenewals: 0, Months: 1},
+	"child":   {MaxLoans: 3, LoanDays: 14, MaxRenewals: 2, Months: 12},
+	// general is the default category; its limits match loans before membership categories existed
+	"general": {MaxLoans: 100, LoanDays: 365, MaxRenewals: 10, Months: 12},
+}
+
+// RetentionConfig controls automatic anonymization of inactive readers
+type RetentionConfig struct {
+	// InactiveMonths without loans, fines or visits before a reader is anonymized; 0 disables the job
+	InactiveMonths int `yaml:"inactiveMonths"`
+}
+
+// PortalConfig configures the reader self-service portal
+type PortalConfig struct {
+	SessionTTL   time.Duration `yaml:"sessionTTL"`
+	MagicLinkTTL time.Duration `yaml:"magicLinkTTL"`
+	// MagicLinkURL is the portal page that receives the token, e.g. https://library.example/portal/login
+	MagicLinkURL     string `yaml:"magicLinkURL,omitempty"`
+	MaxLoginAttempts int    `yaml:"maxLoginAttempts"`
+	CookieSecure     bool   `yaml:"cookieSecure"`
+	// AuthRateLimit is the number of login and magic link requests a client IP may make per AuthRateWindow
+	AuthRateLimit  int           `yaml:"authRateLimit"`
+	AuthRateWindow time.Duration `yaml:"authRateWindow"`
+}
+
+// OpacConfig configures the public catalogue API
+type OpacConfig struct {
+	// CacheTTL is how long responses stay in the Redis cache
+	CacheTTL time.Duration `yaml:"cacheTTL"`
+	// MaxAge is sent to browsers and proxies in Cache-Control
+	MaxAge time.Duration `yaml:"maxAge"`
+	// RateLimit is the number of requests a client IP may make per RateWindow
+	RateLimit  int           `yaml:"rateLimit"`
+	RateWindow time.Duration `yaml:"rateWindow"`
+}
+
+// SRUConfig configures the SRU searchRetrieve and explain endpoint
+type SRUConfig struct {
+	// Title and Description are published in the explain record
+	Title       string `yaml:"title"`
+	Description string `yaml:"description,omitempty"`
+	// DefaultRecords is the page size when maximumRecords is not given; larger requests are cut to MaxRecords
+	DefaultRecords int `yaml:"defaultRecords"`
+	MaxRecords     int `yaml:"maxRecords"`
+}
+
+// OAIConfig configures the OAI-PMH provider used by metadata aggregators
+type OAIConfig struct {
+	RepositoryName string `yaml:"repositoryName"`
+	// RepositoryIdentifier is the namespace part of item identifiers, oai:<repositoryIdentifier>:<book id>;
+	// it is usually the domain name of the library and must not change once records are harvested
+	RepositoryIdentifier string   `yaml:"repositoryIdentifier"`
+	AdminEmails          []string `yaml:"adminEmails"`
+	// PageSize is the number of headers or records returned before a resumption token
+	PageSize int `yaml:"pageSize"`
+}
+
+// CatalogingConfig configures copy cataloguing: when a book is created with only an ISBN,
+// the sources are asked in order and the first record found is proposed
+type CatalogingConfig struct {
+	Sources []MetadataSourceConfig `yaml:"sources"`
+}
+
+// MetadataSourceConfig describes a metadata source. Type marc reads the table loaded from MARC dumps,
+// http asks a remote service
+type MetadataSourceConfig struct {
+	Type string `yaml:"type"`
+	Name string `yaml:"name,omitempty"`
+	// URL is a Go template with .ISBN, the ISBN-13 without hyphens
+	URL string `yaml:"url,omitempty"`
+	// Format is json (title, authors, publisher, publication_year) or marcxml, which also accepts SRU responses
+	Format  string            `yaml:"format,omitempty"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+	Timeout time.Duration     `yaml:"timeout,omitempty"`
+}
+
+// AttachmentsConfig configures book covers and table of contents files
+type AttachmentsConfig struct {
+	// Storage is local (files under Path) or s3, which also works with S3-compatible services such as MinIO
+	Storage string    `yaml:"storage"`
+	Path    string    `yaml:"path,omitempty"`
+	S3      *S3Config `yaml:"s3,omitempty"`
+	// MaxImageSize and MaxDocumentSize limit uploaded covers and PDFs, in bytes
+	MaxImageSize    int64 `yaml:"maxImageSize"`
+	MaxDocumentSize int64 `yaml:"maxDocumentSize"`
+	// ThumbnailWidths are the widths in pixels of the cover thumbnails made on upload
+	ThumbnailWidths []int `yaml:"thumbnailWidths"`
+	// CoverMaxAge is sent in Cache-Control for covers addressed by book, which change when a cover is replaced;
+	// files addressed by attachment ID never change and are cached for a year
+	CoverMaxAge time.Duration `yaml:"coverMaxAge"`
+}
+
+// S3Config describes a bucket in S3 or an S3-compatible storage
+type S3Config struct {
+	// Endpoint is the service URL, e.g. https://s3.eu-central-1.amazonaws.com or http://minio:9000
+	Endpoint  string `yaml:"endpoint"`
+	Region    string `yaml:"region"`
+	Bucket    string `yaml:"bucket"`
+	AccessKey string `yaml:"accessKey"`
+	SecretKey string `yaml:"secretKey"`
+	// VirtualHosted puts the bucket into the host name; by default the bucket is the first path segment,
+	// which MinIO and most compatible services expect
+	VirtualHosted bool          `yaml:"virtualHosted"`
+	Timeout       time.Duration `yaml:"timeout,omitempty"`
+}
+
+// SMTPConfig describes the outgoing mail server.
+// Security is starttls (default), tls or none; none is meant for local fake servers such as Mailpit
+type SMTPConfig struct {
+	Host     string        `yaml:"host"`
+	Port     int           `yaml:"port"`
+	Username string        `yaml:"username,omitempty"`
+	Password string        `yaml:"password,omitempty"`
+	From     string        `yaml:"from"`
+	Security string        `yaml:"security,omitempty"`
+	Timeout  time.Duration `yaml:"timeout,omitempty"`
+}
+
+// SMSConfig configures the SMS notification channel
+type SMSConfig struct {
+	// Provider is http or file; file appends messages to FilePath or writes them to the log when it is empty
+	Provider string `yaml:"provider"`
+	Sender   string `yaml:"sender,omitempty"`
+	// CountryCode is prepended to national numbers when they are normalized to E.164
+	CountryCode string `yaml:"countryCode"`
+	// MaxSegments limits how many SMS parts one message may take; longer texts are truncated
+	MaxSegments int               `yaml:"maxSegments"`
+	QuietHours  *QuietHoursConfig `yaml:"quietHours,omitempty"`
+	HTTP        *SMSHTTPConfig    `yaml:"http,omitempty"`
+	FilePath    string            `yaml:"filePath,omitempty"`
+}
+
+// QuietHoursConfig is a local time interval (HH:MM) when no SMS is sent; it may span midnight
+type QuietHoursConfig struct {
+	Start    string `yaml:"start"`
+	End      string `yaml:"end"`
+	Timezone string `yaml:"timezone,omitempty"`
+}
+
+// SMSHTTPConfig describes a generic HTTP SMS gateway.
+// URL and Body are Go templates with .To (E.164), .Text and .Sender; json and urlquery escape values
+type SMSHTTPConfig struct {
+	URL         string            `yaml:"url"`
+	Method      string            `yaml:"method,omitempty"`
+	ContentType string            `yaml:"contentType,omitempty"`
+	Headers     map[string]string `yaml:"headers,omitempty"`
+	Body        string            `yaml:"body,omitempty"`
+	Timeout     time.Duration     `yaml:"timeout,omitempty"`
+}
+
+// NotificationsConfig configures reader notifications; without a transport nothing is scheduled or sent
+type NotificationsConfig struct {
+	// DueSoonDays is how many days before the due date the reminder is sent
+	DueSoonDays int `yaml:"dueSoonDays"`
+	// OverdueRepeatDays is the interval between overdue reminders for the same loan
+	OverdueRepeatDays int `yaml:"overdueRepeatDays"`
+	// HoldPickupDays is how long a reserved copy waits for the reader
+	HoldPickupDays int `yaml:"holdPickupDays"`
+	// MaxAttempts is the number of delivery attempts before a notification is marked failed
+	MaxAttempts int         `yaml:"maxAttempts"`
+	SMTP        *SMTPConfig `yaml:"smtp,omitempty"`
+	SMS         *SMSConfig  `yaml:"sms,omitempty"`
+}
+
+// WebhooksConfig configures delivery of outgoing webhooks
+type WebhooksConfig struct {
+	// MaxAttempts is the number of delivery attempts before an event is marked failed
+	MaxAttempts int `yaml:"maxAttempts"`
+	// Timeout limits a single delivery request including reading the response
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
+
+// SIP2Config configures the SIP2 listener used by self-checkout kiosks and RFID gates
+type SIP2Config struct {
+	Port int `yaml:"port"`
+	// InstitutionID is sent in the AO field and must match the kiosk configuration
+	InstitutionID string `yaml:"institutionID"`
+	LibraryName   string `yaml:"libraryName,omitempty"`
+	// Currency is the ISO 4217 code reported in BH and accepted in fee paid messages
+	Currency string `yaml:"currency"`
+	// IdleTimeout closes connections that send nothing, kiosks normally poll with SC status
+	IdleTimeout time.Duration `yaml:"idleTimeout"`
+	// RequireChecksum rejects messages without AY/AZ error detection
+	RequireChecksum bool `yaml:"requireChecksum"`
+	// RequirePatronPin makes the portal PIN in the AD field mandatory for patron transactions; defaults to true
+	RequirePatronPin *bool `yaml:"requirePatronPin,omitempty"`
+	// MaxLoginAttempts is the number of failed logins per kiosk account within 15 minutes, counted
+	// across connections; further logins of the account are refused and the connection is closed
+	MaxLoginAttempts int `yaml:"maxLoginAttempts"`
+	// MaxPinAttempts shares the failure counter with portal logins
+	MaxPinAttempts int `yaml:"maxPinAttempts"`
+}
+
+// NCIPConfig configures the NCIP responder used by interlibrary loan systems
+type NCIPConfig struct {
+	// AgencyID identifies this library in NCIP messages
+	AgencyID string              `yaml:"agencyID"`
+	Partners []NCIPPartnerConfig `yaml:"partners"`
+}
+
+// NCIPPartnerConfig describes a partner library allowed to call the NCIP endpoint
+type NCIPPartnerConfig struct {
+	AgencyID string `yaml:"agencyID"`
+	Name     string `yaml:"name,omitempty"`
+	// Token is sent by the partner as a bearer token
+	Token string `yaml:"token"`
+	// ReaderTicket is the institutional reader that items lent to the partner are issued to;
+	// its category sets loan limits, loan period and renewals
+	ReaderTicket string `yaml:"readerTicket"`
+}
+
 type LibraryServiceConfig struct {
-	Port          int            `yaml:"port"`
-	DevMode       bool           `yaml:"devMode"`
-	AllowedOrigin string         `yaml:"allowedOrigin,omitempty"`
-	Session       *SessionConfig `yaml:"session,omitempty"`
+	Port          int    `yaml:"port"`
+	DevMode       bool   `yaml:"devMode"`
+	AllowedOrigin string `yaml:"allowedOrigin,omitempty"`
+	// ProxyHeader carries the client IP set by a reverse proxy, e.g. X-Forwarded-For. It is honoured
+	// only for requests from TrustedProxies; otherwise rate limits key on the connection address
+	ProxyHeader    string          `yaml:"proxyHeader,omitempty"`
+	TrustedProxies []string        `yaml:"trustedProxies,omitempty"`
+	Session        *SessionConfig  `yaml:"session,omitempty"`
+	CopyCodes      *CopyCodeConfig `yaml:"copyCodes,omitempty"`
+	Tickets        *TicketConfig   `yaml:"tickets,omitempty"`
+
+	Memberships map[string]MembershipCategoryConfig `yaml:"memberships,omitempty"`
+	Retention   RetentionConfig                     `yaml:"retention,omitempty"`
+	Portal      *PortalConfig                       `yaml:"portal,omitempty"`
+	Opac        *OpacConfig                         `yaml:"opac,omitempty"`
+	SRU         *SRUConfig                          `yaml:"sru,omitempty"`
+
+	Notifications *NotificationsConfig `yaml:"notifications,omitempty"`
+	Webhooks      *WebhooksConfig      `yaml:"webhooks,omitempty"`
+	SIP2          *SIP2Config          `yaml:"sip2,omitempty"`
+	NCIP          *NCIPConfig          `yaml:"ncip,omitempty"`
+	OAI           *OAIConfig           `yaml:"oai,omitempty"`
+	Cataloging    *CatalogingConfig    `yaml:"cataloging,omitempty"`
+	Attachments   *AttachmentsConfig   `yaml:"attachments,omitempty"`
+}
+
+func (sms *SMSConfig) setDefaults() error {
+	switch sms.Provider {
+	case "http":
+		if sms.HTTP == nil || sms.HTTP.URL == "" {
+			return fmt.Errorf("sms http provider requires a url")
+		}
+		if sms.HTTP.Method == "" {
+			sms.HTTP.Method = "POST"
+		}
+		if sms.HTTP.ContentType == "" {
+			sms.HTTP.ContentType = "application/json"
+		}
+		if sms.HTTP.Timeout == 0 {
+			sms.HTTP.Timeout = 10 * time.Second
+		}
+	case "file":
+	default:
+		return fmt.Errorf("sms provider must be http or file")
+	}
+	if sms.CountryCode == "" {
+		sms.CountryCode = "7"
+	}
+	if sms.MaxSegments == 0 {
+		sms.MaxSegments = 3
+	}
+	if sms.MaxSegments < 1 {
+		return fmt.Errorf("sms max segments must be positive")
+	}
+	if sms.QuietHours == nil {
+		sms.QuietHours = &QuietHoursConfig{Start: "21:00", End: "09:00"}
+	}
+	for _, v := range []string{sms.QuietHours.Start, sms.QuietHours.End} {
+		if _, err := time.Parse("15:04", v); err != nil {
+			return fmt.Errorf("sms quiet hours must use HH:MM: %q", v)
+		}
+	}
+	if _, err := time.LoadLocation(sms.QuietHours.Timezone); err != nil {
+		return fmt.Errorf("sms quiet hours timezone: %w", err)
+	}
+	return nil
+}
+
+func (a *AttachmentsConfig) setDefaults() error {
+	switch a.Storage {
+	case "", "local":
+		a.Storage = "local"
+		if a.Path == "" {
+			a.Path = "attachments"
+		}
+	case "s3":
+		if a.S3 == nil || a.S3.Endpoint == "" || a.S3.Bucket == "" || a.S3.AccessKey == "" || a.S3.SecretKey == "" {
+			return fmt.Errorf("s3 attachment storage requires endpoint, bucket and credentials")
+		}
+		if a.S3.Region == "" {
+			a.S3.Region = "us-east-1"
+		}
+		if a.S3.Timeout == 0 {
+			a.S3.Timeout = 30 * time.Second
+		}
+	default:
+		return fmt.Errorf("attachment storage must be local or s3")
+	}
+	if a.MaxImageSize == 0 {
+		a.MaxImageSize = 5 << 20
+	}
+	if a.MaxDocumentSize == 0 {
+		a.MaxDocumentSize = 20 << 20
+	}
+	if a.MaxImageSize < 0 || a.MaxDocumentSize < 0 {
+		return fmt.Errorf("attachment size limits must not be negative")
+	}
+	if len(a.ThumbnailWidths) == 0 {
+		a.ThumbnailWidths = []int{160, 320, 640}
+	}
+	for _, w := range a.ThumbnailWidths {
+		if w < 16 || w > 2048 {
+			return fmt.Errorf("thumbnail widths must be between 16 and 2048 pixels")
+		}
+	}
+	if a.CoverMaxAge == 0 {
+		a.CoverMaxAge = time.Hour
+	}
+	return nil
 }
 
 type Config struct {
@@ -94,6 +442,252 @@ func Load(path string) (*Config, error) {
 				return nil, fmt.Errorf("library session TTL is required and must be a valid duration string (e.g., '24h', '30m')")
 			}
 		}
+		if cfg.Library.CopyCodes == nil {
+			cfg.Library.CopyCodes = &CopyCodeConfig{Digits: 8, CheckDigit: true}
+		}
+		if cfg.Library.CopyCodes.Digits == 0 {
+			cfg.Library.CopyCodes.Digits = 8
+		}
+		if cfg.Library.CopyCodes.Digits < 1 || cfg.Library.CopyCodes.Digits > 18 {
+			return nil, fmt.Errorf("copy code digits must be between 1 and 18")
+		}
+		if cfg.Library.Tickets == nil {
+			cfg.Library.Tickets = &TicketConfig{}
+		}
+		if cfg.Library.Tickets.Digits == 0 {
+			cfg.Library.Tickets.Digits = 8
+		}
+		if cfg.Library.Tickets.ValidityMonths == 0 {
+			cfg.Library.Tickets.ValidityMonths = 12
+		}
+		// ticket_number is VARCHAR(20), one character is the check digit
+		if cfg.Library.Tickets.Digits < 1 || len(cfg.Library.Tickets.Prefix)+cfg.Library.Tickets.Digits+1 > 20 {
+			return nil, fmt.Errorf("ticket number must fit into 20 characters")
+		}
+		if cfg.Library.Portal == nil {
+			cfg.Library.Portal = &PortalConfig{}
+		}
+		if cfg.Library.Portal.SessionTTL == 0 {
+			cfg.Library.Portal.SessionTTL = 2 * time.Hour
+		}
+		if cfg.Library.Portal.MagicLinkTTL == 0 {
+			cfg.Library.Portal.MagicLinkTTL = 15 * time.Minute
+		}
+		if cfg.Library.Portal.MaxLoginAttempts == 0 {
+			cfg.Library.Portal.MaxLoginAttempts = 5
+		}
+		if cfg.Library.Portal.AuthRateLimit == 0 {
+			cfg.Library.Portal.AuthRateLimit = 20
+		}
+		if cfg.Library.Portal.AuthRateWindow == 0 {
+			cfg.Library.Portal.AuthRateWindow = time.Minute
+		}
+		if cfg.Library.Opac == nil {
+			cfg.Library.Opac = &OpacConfig{}
+		}
+		if cfg.Library.Opac.CacheTTL == 0 {
+			cfg.Library.Opac.CacheTTL = 5 * time.Minute
+		}
+		if cfg.Library.Opac.MaxAge == 0 {
+			cfg.Library.Opac.MaxAge = time.Minute
+		}
+		if cfg.Library.Opac.RateLimit == 0 {
+			cfg.Library.Opac.RateLimit = 120
+		}
+		if cfg.Library.Opac.RateWindow == 0 {
+			cfg.Library.Opac.RateWindow = time.Minute
+		}
+		if cfg.Library.SRU == nil {
+			cfg.Library.SRU = &SRUConfig{}
+		}
+		if cfg.Library.SRU.Title == "" {
+			cfg.Library.SRU.Title = "Library catalogue"
+		}
+		if cfg.Library.SRU.DefaultRecords == 0 {
+			cfg.Library.SRU.DefaultRecords = 10
+		}
+		if cfg.Library.SRU.MaxRecords == 0 {
+			cfg.Library.SRU.MaxRecords = 50
+		}
+		if cfg.Library.SRU.DefaultRecords > cfg.Library.SRU.MaxRecords {
+			cfg.Library.SRU.DefaultRecords = cfg.Library.SRU.MaxRecords
+		}
+		if cfg.Library.Notifications == nil {
+			cfg.Library.Notifications = &NotificationsConfig{}
+		}
+		if cfg.Library.Notifications.DueSoonDays == 0 {
+			cfg.Library.Notifications.DueSoonDays = 3
+		}
+		if cfg.Library.Notifications.OverdueRepeatDays == 0 {
+			cfg.Library.Notifications.OverdueRepeatDays = 7
+		}
+		if cfg.Library.Notifications.HoldPickupDays == 0 {
+			cfg.Library.Notifications.HoldPickupDays = 3
+		}
+		if cfg.Library.Notifications.MaxAttempts == 0 {
+			cfg.Library.Notifications.MaxAttempts = 5
+		}
+		if cfg.Library.Notifications.DueSoonDays < 0 || cfg.Library.Notifications.OverdueRepeatDays < 0 ||
+			cfg.Library.Notifications.HoldPickupDays < 0 || cfg.Library.Notifications.MaxAttempts < 0 {
+			return nil, fmt.Errorf("notification settings must not be negative")
+		}
+		if smtp := cfg.Library.Notifications.SMTP; smtp != nil {
+			if smtp.Host == "" || smtp.From == "" {
+				return nil, fmt.Errorf("smtp host and from address are required")
+			}
+			if smtp.Security == "" {
+				smtp.Security = "starttls"
+			}
+			if smtp.Security != "starttls" && smtp.Security != "tls" && smtp.Security != "none" {
+				return nil, fmt.Errorf("smtp security must be starttls, tls or none")
+			}
+			if smtp.Port == 0 {
+				smtp.Port = 587
+				if smtp.Security == "tls" {
+					smtp.Port = 465
+				}
+			}
+			if smtp.Timeout == 0 {
+				smtp.Timeout = 30 * time.Second
+			}
+		}
+		if sms := cfg.Library.Notifications.SMS; sms != nil {
+			if err := sms.setDefaults(); err != nil {
+				return nil, err
+			}
+		}
+		if cfg.Library.Webhooks == nil {
+			cfg.Library.Webhooks = &WebhooksConfig{}
+		}
+		if cfg.Library.Webhooks.MaxAttempts == 0 {
+			cfg.Library.Webhooks.MaxAttempts = 10
+		}
+		if cfg.Library.Webhooks.Timeout == 0 {
+			cfg.Library.Webhooks.Timeout = 10 * time.Second
+		}
+		if cfg.Library.Webhooks.MaxAttempts < 0 || cfg.Library.Webhooks.Timeout < 0 {
+			return nil, fmt.Errorf("webhook settings must not be negative")
+		}
+		if sip := cfg.Library.SIP2; sip != nil {
+			if sip.Port == 0 {
+				sip.Port = 6001
+			}
+			if sip.InstitutionID == "" {
+				return nil, fmt.Errorf("sip2 institution id is required")
+			}
+			if sip.Currency == "" {
+				sip.Currency = "RUB"
+			}
+			if len(sip.Currency) != 3 {
+				return nil, fmt.Errorf("sip2 currency must be a three-letter ISO 4217 code")
+			}
+			if sip.IdleTimeout == 0 {
+				sip.IdleTimeout = 10 * time.Minute
+			}
+			if sip.MaxLoginAttempts == 0 {
+				sip.MaxLoginAttempts = 3
+			}
+			if sip.MaxPinAttempts == 0 {
+				sip.MaxPinAttempts = 5
+			}
+			if sip.RequirePatronPin == nil {
+				requirePin := true
+				sip.RequirePatronPin = &requirePin
+			}
+		}
+		if ncip := cfg.Library.NCIP; ncip != nil {
+			if ncip.AgencyID == "" {
+				return nil, fmt.Errorf("ncip agency id is required")
+			}
+			agencies := map[string]bool{}
+			for _, p := range ncip.Partners {
+				if p.AgencyID == "" || p.Token == "" || p.ReaderTicket == "" {
+					return nil, fmt.Errorf("ncip partners require agency id, token and reader ticket")
+				}
+				if p.AgencyID == ncip.AgencyID || agencies[p.AgencyID] {
+					return nil, fmt.Errorf("ncip partner agency %q is not unique", p.AgencyID)
+				}
+				agencies[p.AgencyID] = true
+			}
+		}
+		if cfg.Library.Cataloging == nil {
+			cfg.Library.Cataloging = &CatalogingConfig{Sources: []MetadataSourceConfig{{Type: "marc"}}}
+		}
+		for i := range cfg.Library.Cataloging.Sources {
+			source := &cfg.Library.Cataloging.Sources[i]
+			switch source.Type {
+			case "marc":
+			case "http":
+				if source.URL == "" {
+					return nil, fmt.Errorf("http metadata source requires a url")
+				}
+				if source.Format == "" {
+					source.Format = "json"
+				}
+				if source.Format != "json" && source.Format != "marcxml" {
+					return nil, fmt.Errorf("unknown metadata source format %q", source.Format)
+				}
+				if source.Timeout == 0 {
+					source.Timeout = 10 * time.Second
+				}
+			default:
+				return nil, fmt.Errorf("unknown metadata source type %q", source.Type)
+			}
+			if source.Name == "" {
+				source.Name = source.Type
+			}
+		}
+		if cfg.Library.Attachments == nil {
+			cfg.Library.Attachments = &AttachmentsConfig{}
+		}
+		if err := cfg.Library.Attachments.setDefaults(); err != nil {
+			return nil, err
+		}
+		if oai := cfg.Library.OAI; oai != nil {
+			if oai.RepositoryIdentifier == "" || len(oai.AdminEmails) == 0 {
+				return nil, fmt.Errorf("oai repository identifier and admin emails are required")
+			}
+			if oai.RepositoryName == "" {
+				oai.RepositoryName = cfg.Library.SRU.Title
+			}
+			if oai.PageSize == 0 {
+				oai.PageSize = 100
+			}
+			if oai.PageSize < 0 {
+				return nil, fmt.Errorf("oai page size must not be negative")
+			}
+		}
+		if cfg.Library.ProxyHeader != "" && len(cfg.Library.TrustedProxies) == 0 {
+			return nil, fmt.Errorf("proxy header requires trusted proxies")
+		}
+		for _, proxy := range cfg.Library.TrustedProxies {
+			if net.ParseIP(proxy) == nil {
+				if _, _, err := net.ParseCIDR(proxy); err != nil {
+					return nil, fmt.Errorf("trusted proxy must be an IP address or CIDR range: %q", proxy)
+				}
+			}
+		}
+		if cfg.Library.Retention.InactiveMonths < 0 {
+			return nil, fmt.Errorf("retention inactive months must not be negative")
+		}
+		if cfg.Library.Memberships == nil {
+			cfg.Library.Memberships = map[string]MembershipCategoryConfig{}
+		}
+		for category := range cfg.Library.Memberships {
+			if _, ok := DefaultMemberships[category]; !ok {
+				return nil, fmt.Errorf("unknown membership category %q", category)
+			}
+		}
+		for category, defaults := range DefaultMemberships {
+			m, ok := cfg.Library.Memberships[category]
+			if !ok {
+				m = defaults
+			}
+			if m.MaxLoans < 0 || m.MaxRenewals < 0 || m.LoanDays < 1 || m.Months < 1 {
+				return nil, fmt.Errorf("membership category %s: loan days and months must be positive", category)
+			}
+			cfg.Library.Memberships[category] = m
+		}
 	} else {
 		return nil, fmt.Errorf("library service configuration is missing")
 	}
@@ -0,0 +1,54 @@
+package copycode
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hnnsly/library-console/internal/config"
+)
+
+// Generator собирает шифры экземпляров по настройкам из конфигурации
+type Generator struct {
+	cfg config.CopyCodeConfig
+}
+
+// New создает генератор шифров экземпляров
+func New(cfg config.CopyCodeConfig) *Generator {
+	return &Generator{cfg: cfg}
+}
+
+// Format формирует шифр из номера последовательности и кода зала.
+// Код зала используется, только если он включен в настройках и задан у зала
+func (g *Generator) Format(sequence int64, hallCode string) string {
+	parts := make([]string, 0, 3)
+	if g.cfg.Prefix != "" {
+		parts = append(parts, g.cfg.Prefix)
+	}
+	if g.cfg.HallCode && hallCode != "" {
+		parts = append(parts, hallCode)
+	}
+	parts = append(parts, fmt.Sprintf("%0*d", g.cfg.Digits, sequence))
+
+	code := strings.Join(parts, g.cfg.Separator)
+	if g.cfg.CheckDigit {
+		code += string(CheckDigit(code))
+	}
+	return code
+}
+
+// CheckDigit считает контрольную цифру по модулю 10 с весами 3 и 1 справа налево,
+// как в GS1. Нецифровые символы пропускаются, поэтому 12-значный числовой шифр
+// с контрольной цифрой является корректным EAN-13
+func CheckDigit(code string) byte {
+	sum := 0
+	weight := 3
+	for i := len(code) - 1; i >= 0; i-- {
+		c := code[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		sum += int(c-'0') * weight
+		weight = 4 - weight
+	}
+	return byte('0' + (10-sum%10)%10)
+}
@@ -0,0 +1,50 @@
+package copycode
+
+import (
+	"testing"
+
+	"github.com/hnnsly/library-console/internal/config"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      config.CopyCodeConfig
+		sequence int64
+		hallCode string
+		want     string
+	}{
+		{"digits only", config.CopyCodeConfig{Digits: 6}, 42, "", "000042"},
+		{"prefix and separator", config.CopyCodeConfig{Prefix: "LIB", Digits: 5, Separator: "-"}, 7, "", "LIB-00007"},
+		{"hall code", config.CopyCodeConfig{Prefix: "LIB", HallCode: true, Digits: 4, Separator: "-"}, 12, "A", "LIB-A-0012"},
+		{"hall code disabled", config.CopyCodeConfig{Prefix: "LIB", Digits: 4, Separator: "-"}, 12, "A", "LIB-0012"},
+		{"hall without code", config.CopyCodeConfig{Prefix: "LIB", HallCode: true, Digits: 4, Separator: "-"}, 12, "", "LIB-0012"},
+		{"sequence longer than digits", config.CopyCodeConfig{Digits: 3}, 12345, "", "12345"},
+		{"EAN-13 check digit", config.CopyCodeConfig{Prefix: "460", Digits: 9, CheckDigit: true}, 12345678, "", "4600123456782"},
+		{"check digit skips letters", config.CopyCodeConfig{Prefix: "LIB", Digits: 4, Separator: "-", CheckDigit: true}, 1, "", "LIB-00017"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := New(tt.cfg).Format(tt.sequence, tt.hallCode); got != tt.want {
+				t.Errorf("Format(%d, %q) = %q, want %q", tt.sequence, tt.hallCode, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckDigit(t *testing.T) {
+	tests := []struct {
+		code string
+		want byte
+	}{
+		{"400638133393", '1'},
+		{"590123412345", '7'},
+		{"0", '0'},
+		{"LIB-0001", '7'},
+	}
+	for _, tt := range tests {
+		if got := CheckDigit(tt.code); got != tt.want {
+			t.Errorf("CheckDigit(%q) = %c, want %c", tt.code, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package handler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/govalues/decimal"
+	"github.com/hnnsly/library-console/internal/repository/postgres"
+	httperr "github.com/hnnsly/library-console/pkg/error"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultAnalyticsDays   = 30
+	maxAnalyticsDays       = 366
+	maxOccupancySeriesDays = 31
+)
+
+type HeatmapCell struct {
+	Weekday          int             `json:"weekday"` // 1 = Monday ... 7 = Sunday
+	Hour             int             `json:"hour"`
+	TotalEntries     int             `json:"total_entries"`
+	AvgEntries       decimal.Decimal `json:"avg_entries"`
+	AvgPeakOccupancy decimal.Decimal `json:"avg_peak_occupancy"`
+}
+
+type HeatmapResponse struct {
+	HallID    uuid.UUID     `json:"hall_id"`
+	StartDate string        `json:"start_date"`
+	EndDate   string        `json:"end_date"`
+	Cells     []HeatmapCell `json:"cells"`
+}
+
+type OccupancyPoint struct {
+	Time             time.Time `json:"time"`
+	Entries          int       `json:"entries"`
+	Exits            int       `json:"exits"`
+	PeakOccupancy    int       `json:"peak_occupancy"`
+	ClosingOccupancy int       `json:"closing_occupancy"`
+}
+
+type VisitorSplitResponse struct {
+	HallID            uuid.UUID                          `json:"hall_id"`
+	StartDate         string                             `json:"start_date"`
+	EndDate           string                             `json:"end_date"`
+	NewVisitors       int                                `json:"new_visitors"`
+	ReturningVisitors int                                `json:"returning_visitors"`
+	Days              []*postgres.GetHallVisitorSplitRow `json:"days"`
+}
+
+// parseDateRange reads start_date/end_date query params, defaulting to the last days.
+// Ranges longer than a year are rejected: the series and heatmaps walk the range day by day
+func parseDateRange(c *fiber.Ctx, days int) (time.Time, time.Time, error) {
+	now := time.Now()
+	endDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	startDate := endDate.AddDate(0, 0, -days+1)
+
+	if startDateStr := c.Query("start_date"); startDateStr != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", startDateStr, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, httperr.New(fiber.StatusBadRequest, "Invalid start_date format, use YYYY-MM-DD")
+		}
+		startDate = parsed
+	}
+
+	if endDateStr := c.Query("end_date"); endDateStr != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", endDateStr, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, httperr.New(fiber.StatusBadRequest, "Invalid end_date format, use YYYY-MM-DD")
+		}
+		endDate = parsed
+	}
+
+	if endDate.Before(startDate) {
+		return time.Time{}, time.Time{}, httperr.New(fiber.StatusBadRequest, "end_date must not be before start_date")
+	}
+	if endDate.After(startDate.AddDate(0, 0, maxAnalyticsDays-1)) {
+		return time.Time{}, time.Time{}, httperr.New(fiber.StatusBadRequest, fmt.Sprintf("Date range must not exceed %d days", maxAnalyticsDays))
+	}
+
+	return startDate, endDate, nil
+}
+
+func (h *Handler) getHallDwellStats(c *fiber.Ctx) error {
+	hallIdStr := c.Params("id")
+	hallId, err := uuid.Parse(hallIdStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid hall ID format")
+	}
+
+	startDate, endDate, err := parseDateRange(c, defaultAnalyticsDays)
+	if err != nil {
+		return err
+	}
+
+	stats, err := h.repo.GetHallDwellStats(c.Context(), postgres.GetHallDwellStatsParams{
+		HallID:    hallId,
+		StartDate: startDate,
+		EndDate:   endDate,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("hallID", hallIdStr).Msg("Failed to get hall dwell stats")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve dwell time stats")
+	}
+
+	return c.JSON(stats)
+}
+
+func (h *Handler) getHallVisitHeatmap(c *fiber.Ctx) error {
+	hallIdStr := c.Params("id")
+	hallId, err := uuid.Parse(hallIdStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid hall ID format")
+	}
+
+	startDate, endDate, err := parseDateRange(c, defaultAnalyticsDays)
+	if err != nil {
+		return err
+	}
+
+	rows, err := h.repo.GetHallVisitHeatmap(c.Context(), postgres.GetHallVisitHeatmapParams{
+		HallID:    hallId,
+		StartDate: startDate,
+		EndDate:   endDate,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("hallID", hallIdStr).Msg("Failed to get hall visit heatmap")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve visit heatmap")
+	}
+
+	// Averages are taken over every such weekday in the range, not only days with visits
+	var weekdays [8]int
+	for d := startDate; !d.After(endDate); d = d.AddDate(0, 0, 1) {
+		weekdays[isoWeekday(d)]++
+	}
+
+	cells := make([]HeatmapCell, 0, 7*24)
+	index := make(map[[2]int]*postgres.GetHallVisitHeatmapRow, len(rows))
+	for _, row := range rows {
+		index[[2]int{row.Weekday, row.Hour}] = row
+	}
+	for weekday := 1; weekday <= 7; weekday++ {
+		for hour := 0; hour < 24; hour++ {
+			cell := HeatmapCell{Weekday: weekday, Hour: hour}
+			if row, ok := index[[2]int{weekday, hour}]; ok && weekdays[weekday] > 0 {
+				cell.TotalEntries = row.TotalEntries
+				cell.AvgEntries = averageOf(row.TotalEntries, weekdays[weekday])
+				cell.AvgPeakOccupancy = averageOf(row.TotalPeakOccupancy, weekdays[weekday])
+			}
+			cells = append(cells, cell)
+		}
+	}
+
+	return c.JSON(HeatmapResponse{
+		HallID:    hallId,
+		StartDate: startDate.Format("2006-01-02"),
+		EndDate:   endDate.Format("2006-01-02"),
+		Cells:     cells,
+	})
+}
+
+func (h *Handler) getHallOccupancySeries(c *fiber.Ctx) error {
+	hallIdStr := c.Params("id")
+	hallId, err := uuid.Parse(hallIdStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid hall ID format")
+	}
+
+	startDate, endDate, err := parseDateRange(c, 1)
+	if err != nil {
+		return err
+	}
+	if endDate.Sub(startDate) >= maxOccupancySeriesDays*24*time.Hour {
+		return httperr.New(fiber.StatusBadRequest, "Date range is too long for an hourly series")
+	}
+
+	buckets, err := h.repo.GetHallOccupancySeries(c.Context(), postgres.GetHallOccupancySeriesParams{
+		HallID:    hallId,
+		StartDate: startDate,
+		EndDate:   endDate,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("hallID", hallIdStr).Msg("Failed to get hall occupancy series")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve occupancy series")
+	}
+
+	index := make(map[time.Time]*postgres.GetHallOccupancySeriesRow, len(buckets))
+	for _, b := range buckets {
+		index[b.BucketStart] = b
+	}
+
+	// Hours without events keep the occupancy left by the previous hour of the same day
+	end := endDate.AddDate(0, 0, 1)
+	if now := time.Now().Truncate(time.Hour); now.Before(end) {
+		end = now.Add(time.Hour)
+	}
+	series := make([]OccupancyPoint, 0)
+	carried := 0
+	for t := startDate; t.Before(end); t = t.Add(time.Hour) {
+		if t.Hour() == 0 {
+			carried = 0
+		}
+		point := OccupancyPoint{Time: t, PeakOccupancy: carried, ClosingOccupancy: carried}
+		if b, ok := index[wallClock(t)]; ok {
+			point.Entries = b.Entries
+			point.Exits = b.Exits
+			point.PeakOccupancy = b.PeakOccupancy
+			point.ClosingOccupancy = b.ClosingOccupancy
+			carried = b.ClosingOccupancy
+		}
+		series = append(series, point)
+	}
+
+	return c.JSON(series)
+}
+
+func (h *Handler) getHallVisitorSplit(c *fiber.Ctx) error {
+	hallIdStr := c.Params("id")
+	hallId, err := uuid.Parse(hallIdStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid hall ID format")
+	}
+
+	startDate, endDate, err := parseDateRange(c, defaultAnalyticsDays)
+	if err != nil {
+		return err
+	}
+
+	days, err := h.repo.GetHallVisitorSplit(c.Context(), postgres.GetHallVisitorSplitParams{
+		HallID:    hallId,
+		StartDate: startDate,
+		EndDate:   endDate,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("hallID", hallIdStr).Msg("Failed to get hall visitor split")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve visitor split")
+	}
+
+	response := VisitorSplitResponse{
+		HallID:    hallId,
+		StartDate: startDate.Format("2006-01-02"),
+		EndDate:   endDate.Format("2006-01-02"),
+		Days:      days,
+	}
+	for _, day := range days {
+		response.NewVisitors += day.NewVisitors
+		response.ReturningVisitors += day.ReturningVisitors
+	}
+
+	return c.JSON(response)
+}
+
+func (h *Handler) compareHallsVisits(c *fiber.Ctx) error {
+	startDate, endDate, err := parseDateRange(c, defaultAnalyticsDays)
+	if err != nil {
+		return err
+	}
+
+	halls, err := h.repo.CompareHallsVisits(c.Context(), postgres.CompareHallsVisitsParams{
+		StartDate: startDate,
+		EndDate:   endDate,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to compare halls visits")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to compare halls")
+	}
+
+	return c.JSON(halls)
+}
+
+func (h *Handler) refreshVisitAnalytics(c *fiber.Ctx) error {
+	if err := h.repo.RefreshVisitRollups(c.Context()); err != nil {
+		log.Error().Err(err).Msg("Failed to refresh visit rollups")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to refresh visit analytics")
+	}
+
+	return c.JSON(fiber.Map{"message": "Visit analytics refreshed successfully"})
+}
+
+// isoWeekday returns 1 for Monday through 7 for Sunday, matching EXTRACT(ISODOW)
+func isoWeekday(t time.Time) int {
+	if t.Weekday() == time.Sunday {
+		return 7
+	}
+	return int(t.Weekday())
+}
+
+// wallClock drops the time zone, as TIMESTAMP values are scanned in UTC
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+func averageOf(total, count int) decimal.Decimal {
+	avg, err := decimal.MustNew(int64(total), 0).Quo(decimal.MustNew(int64(count), 0))
+	if err != nil {
+		return decimal.Zero
+	}
+	return avg.Round(2)
+}
@@ -0,0 +1,239 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hnnsly/library-console/internal/attachments"
+	"github.com/hnnsly/library-console/internal/repository/postgres"
+	"github.com/hnnsly/library-console/internal/storage"
+	httperr "github.com/hnnsly/library-console/pkg/error"
+	"github.com/rs/zerolog/log"
+)
+
+// immutableCacheControl is sent for files addressed by attachment ID: a new upload always gets a new ID
+const immutableCacheControl = "public, max-age=31536000, immutable"
+
+func parseAttachmentKind(kind string) (postgres.AttachmentKind, error) {
+	switch postgres.AttachmentKind(kind) {
+	case "":
+		return postgres.AttachmentKindCover, nil
+	case postgres.AttachmentKindCover, postgres.AttachmentKindToc:
+		return postgres.AttachmentKind(kind), nil
+	}
+	return "", httperr.New(fiber.StatusBadRequest, "Invalid attachment kind, expected cover or toc")
+}
+
+// parseThumbnailWidth reads the optional width query parameter; 0 means the original file
+func parseThumbnailWidth(c *fiber.Ctx) (int, error) {
+	widthStr := c.Query("width")
+	if widthStr == "" {
+		return 0, nil
+	}
+	width, err := strconv.Atoi(widthStr)
+	if err != nil || width <= 0 {
+		return 0, httperr.New(fiber.StatusBadRequest, "Invalid width parameter")
+	}
+	return width, nil
+}
+
+func (h *Handler) getBookAttachments(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid book ID format")
+	}
+
+	list, err := h.repo.GetBookAttachments(c.Context(), id)
+	if err != nil {
+		log.Error().Err(err).Str("bookID", idStr).Msg("Failed to get book attachments")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve book attachments")
+	}
+
+	return c.JSON(list)
+}
+
+func (h *Handler) uploadBookAttachment(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	bookID, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid book ID format")
+	}
+	kind, err := parseAttachmentKind(c.FormValue("kind"))
+	if err != nil {
+		return err
+	}
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "File is required", err.Error())
+	}
+	maxSize := h.attachments.MaxSize(kind)
+	if fh.Size > maxSize {
+		return httperr.New(fiber.StatusRequestEntityTooLarge, fmt.Sprintf("File must not exceed %d bytes", maxSize))
+	}
+	f, err := fh.Open()
+	if err != nil {
+		log.Error().Err(err).Str("bookID", idStr).Msg("Failed to open uploaded file")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to read uploaded file")
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxSize+1))
+	if err != nil {
+		log.Error().Err(err).Str("bookID", idStr).Msg("Failed to read uploaded file")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to read uploaded file")
+	}
+
+	uploadedBy, err := sessionUserID(c)
+	if err != nil {
+		return err
+	}
+
+	attachment, err := h.attachments.Upload(c.Context(), attachments.Upload{
+		BookID:     bookID,
+		Kind:       kind,
+		FileName:   trimToNil(&fh.Filename),
+		Data:       data,
+		UploadedBy: &uploadedBy,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, attachments.ErrTooLarge):
+			return httperr.New(fiber.StatusRequestEntityTooLarge, fmt.Sprintf("File must not exceed %d bytes", maxSize))
+		case errors.Is(err, attachments.ErrUnsupportedType):
+			if kind == postgres.AttachmentKindCover {
+				return httperr.New(fiber.StatusUnsupportedMediaType, "Cover must be a JPEG, PNG or GIF image", err.Error())
+			}
+			return httperr.New(fiber.StatusUnsupportedMediaType, "Table of contents must be a PDF file", err.Error())
+		case errors.Is(err, attachments.ErrInvalidImage), errors.Is(err, attachments.ErrImageDimensions):
+			return httperr.New(fiber.StatusUnprocessableEntity, "Image cannot be used as a cover", err.Error())
+		case strings.Contains(err.Error(), "violates foreign key"):
+			return httperr.New(fiber.StatusNotFound, "Book not found")
+		}
+		log.Error().Err(err).Str("bookID", idStr).Msg("Failed to upload book attachment")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to upload book attachment")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(attachment)
+}
+
+func (h *Handler) deleteBookAttachment(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	bookID, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid book ID format")
+	}
+	attachmentID, err := uuid.Parse(c.Params("attachmentId"))
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid attachment ID format")
+	}
+
+	if _, err := h.attachments.Delete(c.Context(), bookID, attachmentID); err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return httperr.New(fiber.StatusNotFound, "Attachment not found")
+		}
+		log.Error().Err(err).Str("bookID", idStr).Msg("Failed to delete book attachment")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to delete book attachment")
+	}
+
+	return c.JSON(fiber.Map{"message": "Attachment deleted successfully"})
+}
+
+func (h *Handler) getBookAttachmentContent(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	bookID, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid book ID format")
+	}
+	attachmentID, err := uuid.Parse(c.Params("attachmentId"))
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid attachment ID format")
+	}
+	width, err := parseThumbnailWidth(c)
+	if err != nil {
+		return err
+	}
+
+	attachment, err := h.repo.GetBookAttachment(c.Context(), postgres.GetBookAttachmentParams{
+		ID:     attachmentID,
+		BookID: bookID,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return httperr.New(fiber.StatusNotFound, "Attachment not found")
+		}
+		log.Error().Err(err).Str("bookID", idStr).Msg("Failed to get book attachment")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve book attachment")
+	}
+
+	return h.sendAttachment(c, attachment, width, immutableCacheControl)
+}
+
+// getBookCover serves the current cover of a book. The URL stays the same when the cover is replaced,
+// so it is cached for the configured time and revalidated by ETag
+func (h *Handler) getBookCover(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	bookID, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid book ID format")
+	}
+	width, err := parseThumbnailWidth(c)
+	if err != nil {
+		return err
+	}
+
+	cover, err := h.repo.GetBookCover(c.Context(), bookID)
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return httperr.New(fiber.StatusNotFound, "Book has no cover")
+		}
+		log.Error().Err(err).Str("bookID", idStr).Msg("Failed to get book cover")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve book cover")
+	}
+
+	cacheControl := fmt.Sprintf("public, max-age=%d", int(h.cfg.Attachments.CoverMaxAge.Seconds()))
+	return h.sendAttachment(c, cover, width, cacheControl)
+}
+
+// sendAttachment streams an attachment file or its thumbnail with caching headers.
+// The ETag is derived from the file hash, so a revalidation does not touch the storage
+func (h *Handler) sendAttachment(c *fiber.Ctx, attachment *postgres.BookAttachment, width int, cacheControl string) error {
+	if width != 0 && !slices.Contains(h.attachments.ThumbnailWidths(), width) {
+		return httperr.New(fiber.StatusBadRequest, fmt.Sprintf("Width must be one of %v", h.attachments.ThumbnailWidths()))
+	}
+
+	etag := fmt.Sprintf(`"%s-%d"`, attachment.Sha256[:32], width)
+	c.Set(fiber.HeaderETag, etag)
+	c.Set(fiber.HeaderCacheControl, cacheControl)
+	if etagMatches(c.Get(fiber.HeaderIfNoneMatch), etag) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	file, contentType, err := h.attachments.Open(c.Context(), attachment, width)
+	if err != nil {
+		c.Set(fiber.HeaderCacheControl, "no-store")
+		if errors.Is(err, storage.ErrNotFound) {
+			log.Error().Str("key", attachment.StorageKey).Msg("Attachment file is missing from storage")
+			return httperr.New(fiber.StatusNotFound, "Attachment file not found")
+		}
+		log.Error().Err(err).Str("key", attachment.StorageKey).Msg("Failed to open attachment file")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to read attachment file")
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set("X-Content-Type-Options", "nosniff")
+	if attachment.FileName != nil && width == 0 {
+		c.Set(fiber.HeaderContentDisposition, mime.FormatMediaType("inline", map[string]string{"filename": *attachment.FileName}))
+	}
+	if width == 0 {
+		return c.SendStream(file, int(attachment.SizeBytes))
+	}
+	return c.SendStream(file)
+}
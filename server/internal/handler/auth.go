@@ -5,6 +5,7 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/hnnsly/library-console/internal/repository/postgres"
 	httperr "github.com/hnnsly/library-console/pkg/error"
 	"github.com/rs/zerolog/log"
 	"golang.org/x/crypto/bcrypt"
@@ -44,6 +45,12 @@ func (h *Handler) login(c *fiber.Ctx) error {
 		return httperr.New(fiber.StatusUnauthorized, "Invalid credentials")
 	}
 
+	// Kiosk accounts authenticate over SIP2 and have no access to the staff API
+	if user.Role == postgres.UserRoleKiosk {
+		log.Warn().Str("username", req.Username).Msg("Kiosk account attempted staff login")
+		return httperr.New(fiber.StatusForbidden, "Kiosk accounts can only sign in over SIP2")
+	}
+
 	// Create session
 	sessionToken, err := h.repo.CreateSession(c.Context(), user.ID, user.Role, 24*time.Hour)
 	if err != nil {
@@ -106,28 +113,38 @@ func (h *Handler) logout(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"message": "Logged out successfully"})
 }
 
-func (h *Handler) me(c *fiber.Ctx) error {
-	// Get user ID from context (set by auth middleware)
+// sessionUserID returns the staff user authenticated by the auth middleware
+func sessionUserID(c *fiber.Ctx) (uuid.UUID, error) {
 	userIDStr, ok := c.Locals("userID").(string)
 	if !ok {
-		return httperr.New(fiber.StatusUnauthorized, "User ID not found in context")
+		return uuid.Nil, httperr.New(fiber.StatusUnauthorized, "User ID not found in context")
+	}
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return uuid.Nil, httperr.New(fiber.StatusInternalServerError, "Invalid user ID format")
 	}
+	return userID, nil
+}
 
-	_, ok = c.Locals("userRole").(string)
-	if !ok {
-		return httperr.New(fiber.StatusUnauthorized, "User role not found in context")
+// requireAdmin is route middleware that lets only administrators through; it runs after the auth middleware
+func requireAdmin(c *fiber.Ctx) error {
+	role, _ := c.Locals("userRole").(string)
+	if role != string(postgres.UserRoleAdministrator) {
+		return httperr.New(fiber.StatusForbidden, "Only administrators can perform this action")
 	}
+	return c.Next()
+}
 
-	// Parse user ID
-	userID, err := uuid.Parse(userIDStr)
+func (h *Handler) me(c *fiber.Ctx) error {
+	userID, err := sessionUserID(c)
 	if err != nil {
-		return httperr.New(fiber.StatusInternalServerError, "Invalid user ID format")
+		return err
 	}
 
 	// Get user details
 	user, err := h.repo.GetUserById(c.Context(), userID)
 	if err != nil {
-		log.Error().Err(err).Str("userID", userIDStr).Msg("Failed to get user for /me endpoint")
+		log.Error().Err(err).Str("userID", userID.String()).Msg("Failed to get user for /me endpoint")
 		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve user data")
 	}
 
@@ -1,16 +1,65 @@
 package handler
 
 import (
+	"errors"
+	"strconv"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/hnnsly/library-console/internal/repository"
+	"github.com/hnnsly/library-console/internal/repository/postgres"
 	httperr "github.com/hnnsly/library-console/pkg/error"
 	"github.com/rs/zerolog/log"
 )
 
-type CreateAuthorRequest struct {
-	FullName string `json:"full_name" validate:"required"`
+type AuthorRequest struct {
+	FullName  string  `json:"full_name" validate:"required,max=200"`
+	SortName  *string `json:"sort_name" validate:"omitempty,max=200"`
+	BirthYear *int    `json:"birth_year"`
+	DeathYear *int    `json:"death_year"`
+}
+
+type AuthorNameVariantRequest struct {
+	Name string `json:"name" validate:"required,max=200"`
+	Kind string `json:"kind"`
+}
+
+type MergeAuthorsRequest struct {
+	DuplicateID string `json:"duplicate_id" validate:"required"`
+}
+
+type AuthorDetailResponse struct {
+	*postgres.Author
+	Variants []*postgres.AuthorNameVariant `json:"variants"`
+}
+
+// parse checks the authority fields and returns the params shared by create and update
+func (r *AuthorRequest) parse() (postgres.CreateAuthorParams, error) {
+	fullName := strings.TrimSpace(r.FullName)
+	if fullName == "" {
+		return postgres.CreateAuthorParams{}, httperr.New(fiber.StatusBadRequest, "Author full name is required")
+	}
+	if r.BirthYear != nil && r.DeathYear != nil && *r.DeathYear < *r.BirthYear {
+		return postgres.CreateAuthorParams{}, httperr.New(fiber.StatusBadRequest, "Death year cannot precede birth year")
+	}
+
+	return postgres.CreateAuthorParams{
+		FullName:  fullName,
+		SortName:  trimToNil(r.SortName),
+		BirthYear: r.BirthYear,
+		DeathYear: r.DeathYear,
+	}, nil
+}
+
+func parseAuthorNameKind(kind string) (postgres.AuthorNameKind, error) {
+	switch postgres.AuthorNameKind(kind) {
+	case "":
+		return postgres.AuthorNameKindVariant, nil
+	case postgres.AuthorNameKindVariant, postgres.AuthorNameKindTransliteration:
+		return postgres.AuthorNameKind(kind), nil
+	}
+	return "", httperr.New(fiber.StatusBadRequest, "Invalid name variant kind")
 }
 
 func (h *Handler) getAllAuthors(c *fiber.Ctx) error {
@@ -54,16 +103,26 @@ func (h *Handler) getAuthorById(c *fiber.Ctx) error {
 		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve author")
 	}
 
-	return c.JSON(author)
+	variants, err := h.repo.GetAuthorNameVariants(c.Context(), id)
+	if err != nil {
+		log.Error().Err(err).Str("authorID", idStr).Msg("Failed to get author name variants")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve author name variants")
+	}
+
+	return c.JSON(AuthorDetailResponse{Author: author, Variants: variants})
 }
 
 func (h *Handler) createAuthor(c *fiber.Ctx) error {
-	var req CreateAuthorRequest
+	var req AuthorRequest
 	if err := c.BodyParser(&req); err != nil {
 		return httperr.New(fiber.StatusBadRequest, "Invalid request body", err.Error())
 	}
+	params, err := req.parse()
+	if err != nil {
+		return err
+	}
 
-	author, err := h.repo.CreateAuthor(c.Context(), req.FullName)
+	author, err := h.repo.CreateAuthor(c.Context(), params)
 	if err != nil {
 		log.Error().Err(err).Str("fullName", req.FullName).Msg("Failed to create author")
 		return httperr.New(fiber.StatusInternalServerError, "Failed to create author")
@@ -72,6 +131,207 @@ func (h *Handler) createAuthor(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusCreated).JSON(author)
 }
 
+func (h *Handler) updateAuthor(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid author ID format")
+	}
+
+	var req AuthorRequest
+	if err := c.BodyParser(&req); err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid request body", err.Error())
+	}
+	params, err := req.parse()
+	if err != nil {
+		return err
+	}
+
+	author, err := h.repo.UpdateAuthor(c.Context(), postgres.UpdateAuthorParams{
+		FullName:  params.FullName,
+		SortName:  params.SortName,
+		BirthYear: params.BirthYear,
+		DeathYear: params.DeathYear,
+		ID:        id,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return httperr.New(fiber.StatusNotFound, "Author not found")
+		}
+		log.Error().Err(err).Str("authorID", idStr).Msg("Failed to update author")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to update author")
+	}
+
+	h.invalidateCatalog(c)
+
+	return c.JSON(author)
+}
+
+// deleteAuthor removes an author no book refers to; linked authors are merged instead
+func (h *Handler) deleteAuthor(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid author ID format")
+	}
+
+	deleted, err := h.repo.DeleteAuthor(c.Context(), id)
+	if err != nil {
+		log.Error().Err(err).Str("authorID", idStr).Msg("Failed to delete author")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to delete author")
+	}
+	if deleted == 0 {
+		if _, err := h.repo.GetAuthorById(c.Context(), id); err != nil {
+			if strings.Contains(err.Error(), "no rows in result set") {
+				return httperr.New(fiber.StatusNotFound, "Author not found")
+			}
+			log.Error().Err(err).Str("authorID", idStr).Msg("Failed to get author")
+			return httperr.New(fiber.StatusInternalServerError, "Failed to delete author")
+		}
+		return httperr.New(fiber.StatusConflict, "Author is linked to books")
+	}
+
+	return c.JSON(fiber.Map{"message": "Author deleted successfully"})
+}
+
+func (h *Handler) addAuthorNameVariant(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid author ID format")
+	}
+
+	var req AuthorNameVariantRequest
+	if err := c.BodyParser(&req); err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid request body", err.Error())
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return httperr.New(fiber.StatusBadRequest, "Name variant is required")
+	}
+	kind, err := parseAuthorNameKind(req.Kind)
+	if err != nil {
+		return err
+	}
+
+	variant, err := h.repo.AddAuthorNameVariant(c.Context(), postgres.AddAuthorNameVariantParams{
+		AuthorID: id,
+		Name:     name,
+		Kind:     kind,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "violates foreign key") {
+			return httperr.New(fiber.StatusNotFound, "Author not found")
+		}
+		if strings.Contains(err.Error(), "duplicate") {
+			return httperr.New(fiber.StatusConflict, "Author already has this name variant")
+		}
+		log.Error().Err(err).Str("authorID", idStr).Msg("Failed to add author name variant")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to add author name variant")
+	}
+
+	// OPAC author search matches name variants
+	h.invalidateCatalog(c)
+
+	return c.Status(fiber.StatusCreated).JSON(variant)
+}
+
+func (h *Handler) removeAuthorNameVariant(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid author ID format")
+	}
+	variantID, err := uuid.Parse(c.Params("variantId"))
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid name variant ID format")
+	}
+
+	deleted, err := h.repo.DeleteAuthorNameVariant(c.Context(), postgres.DeleteAuthorNameVariantParams{
+		ID:       variantID,
+		AuthorID: id,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("authorID", idStr).Msg("Failed to remove author name variant")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to remove author name variant")
+	}
+	if deleted == 0 {
+		return httperr.New(fiber.StatusNotFound, "Name variant not found")
+	}
+
+	h.invalidateCatalog(c)
+
+	return c.JSON(fiber.Map{"message": "Name variant removed successfully"})
+}
+
+func (h *Handler) findDuplicateAuthors(c *fiber.Ctx) error {
+	var authorID *uuid.UUID
+	if idStr := c.Query("author_id"); idStr != "" {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			return httperr.New(fiber.StatusBadRequest, "Invalid author ID format")
+		}
+		authorID = &id
+	}
+
+	minScore, err := strconv.ParseFloat(c.Query("min_score", "0.6"), 64)
+	if err != nil || minScore < 0 || minScore > 1 {
+		return httperr.New(fiber.StatusBadRequest, "Invalid min_score parameter, expected a value between 0 and 1")
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", "50"))
+	if err != nil || limit <= 0 || limit > 500 {
+		return httperr.New(fiber.StatusBadRequest, "Invalid limit parameter")
+	}
+
+	duplicates, err := h.repo.FindDuplicateAuthors(c.Context(), postgres.FindDuplicateAuthorsParams{
+		AuthorID:   authorID,
+		MinScore:   minScore,
+		LimitCount: int32(limit),
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to find duplicate authors")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to find duplicate authors")
+	}
+
+	return c.JSON(duplicates)
+}
+
+func (h *Handler) mergeAuthors(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	survivingID, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid author ID format")
+	}
+
+	var req MergeAuthorsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid request body", err.Error())
+	}
+	duplicateID, err := uuid.Parse(req.DuplicateID)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid duplicate author ID format")
+	}
+
+	merge, err := h.repo.MergeAuthors(c.Context(), survivingID, duplicateID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrMergeSameAuthor):
+			return httperr.New(fiber.StatusBadRequest, "Cannot merge an author with itself")
+		case errors.Is(err, repository.ErrAuthorYearsConflict):
+			return httperr.New(fiber.StatusConflict, "Authors have different birth or death years")
+		case strings.Contains(err.Error(), "no rows in result set"):
+			return httperr.New(fiber.StatusNotFound, "Author not found")
+		}
+		log.Error().Err(err).Str("authorID", idStr).Str("duplicateID", req.DuplicateID).Msg("Failed to merge authors")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to merge authors")
+	}
+
+	h.invalidateCatalog(c)
+
+	return c.JSON(merge)
+}
+
 func (h *Handler) getAuthorBooks(c *fiber.Ctx) error {
 	idStr := c.Params("id")
 	id, err := uuid.Parse(idStr)
@@ -11,13 +11,35 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+const maxCopiesPerBook = 500
+
+// BookEditionFields are the edition details shared by create and update requests
+type BookEditionFields struct {
+	WorkID           *string `json:"work_id"`
+	SeriesID         *string `json:"series_id"`
+	SeriesVolume     *int    `json:"series_volume" validate:"omitempty,min=1"`
+	Language         *string `json:"language" validate:"omitempty,len=3"`
+	OriginalLanguage *string `json:"original_language" validate:"omitempty,len=3"`
+	PageCount        *int    `json:"page_count" validate:"omitempty,min=1"`
+	Description      *string `json:"description"`
+}
+
+type BookContributorRequest struct {
+	Name string `json:"name" validate:"required"`
+	Role string `json:"role"`
+}
+
 type CreateBookRequest struct {
 	Title           string   `json:"title" validate:"required"`
 	ISBN            *string  `json:"isbn"`
 	PublicationYear *int     `json:"publication_year"`
 	Publisher       *string  `json:"publisher"`
 	TotalCopies     int      `json:"total_copies" validate:"required,min=1"`
+	HallID          *string  `json:"hall_id"`
 	Authors         []string `json:"authors"`
+	// Contributors adds editors, translators and illustrators; Authors is a shorthand for the author role
+	Contributors []BookContributorRequest `json:"contributors"`
+	BookEditionFields
 }
 
 type UpdateBookRequest struct {
@@ -25,43 +47,243 @@ type UpdateBookRequest struct {
 	ISBN            *string `json:"isbn"`
 	PublicationYear *int    `json:"publication_year"`
 	Publisher       *string `json:"publisher"`
+	BookEditionFields
+}
+
+type BookContributorResponse struct {
+	ID       uuid.UUID                `json:"id"`
+	FullName string                   `json:"full_name"`
+	Role     postgres.ContributorRole `json:"role"`
+}
+
+type BookSeriesResponse struct {
+	ID     uuid.UUID `json:"id"`
+	Title  string    `json:"title"`
+	Volume *int      `json:"volume"`
 }
 
 type BookWithAuthorsResponse struct {
-	ID              uuid.UUID `json:"id"`
-	Title           string    `json:"title"`
-	ISBN            *string   `json:"isbn"`
-	PublicationYear *int      `json:"publication_year"`
-	Publisher       *string   `json:"publisher"`
-	TotalCopies     int       `json:"total_copies"`
-	AvailableCopies int       `json:"available_copies"`
-	Authors         string    `json:"authors"`
+	ID               uuid.UUID                 `json:"id"`
+	Title            string                    `json:"title"`
+	ISBN             *string                   `json:"isbn"`
+	PublicationYear  *int                      `json:"publication_year"`
+	Publisher        *string                   `json:"publisher"`
+	TotalCopies      int                       `json:"total_copies"`
+	AvailableCopies  int                       `json:"available_copies"`
+	WorkID           *uuid.UUID                `json:"work_id"`
+	Series           *BookSeriesResponse       `json:"series"`
+	Language         *string                   `json:"language"`
+	OriginalLanguage *string                   `json:"original_language"`
+	PageCount        *int                      `json:"page_count"`
+	Authors          string                    `json:"authors"`
+	Contributors     []BookContributorResponse `json:"contributors"`
 }
 
-func (h *Handler) getAllBooks(c *fiber.Ctx) error {
-	books, err := h.repo.GetAllBooks(c.Context())
+type BookWorkResponse struct {
+	ID    uuid.UUID `json:"id"`
+	Title string    `json:"title"`
+	// Editions are the other editions of the work, translations included
+	Editions []*postgres.GetWorkEditionsRow `json:"editions"`
+}
+
+type BookDetailResponse struct {
+	ID               uuid.UUID                 `json:"id"`
+	Title            string                    `json:"title"`
+	ISBN             *string                   `json:"isbn"`
+	PublicationYear  *int                      `json:"publication_year"`
+	Publisher        *string                   `json:"publisher"`
+	TotalCopies      int                       `json:"total_copies"`
+	AvailableCopies  int                       `json:"available_copies"`
+	Work             *BookWorkResponse         `json:"work"`
+	Series           *BookSeriesResponse       `json:"series"`
+	Language         *string                   `json:"language"`
+	OriginalLanguage *string                   `json:"original_language"`
+	PageCount        *int                      `json:"page_count"`
+	Description      *string                   `json:"description"`
+	Contributors     []BookContributorResponse `json:"contributors"`
+}
+
+// bookEdition holds validated edition fields
+type bookEdition struct {
+	workID           *uuid.UUID
+	seriesID         *uuid.UUID
+	seriesVolume     *int
+	language         *string
+	originalLanguage *string
+	pageCount        *int
+	description      *string
+}
+
+// parse validates identifiers, language codes and numbers of the edition fields
+func (f BookEditionFields) parse() (bookEdition, error) {
+	var e bookEdition
+	var err error
+	if e.workID, err = parseOptionalID(f.WorkID); err != nil {
+		return e, httperr.New(fiber.StatusBadRequest, "Invalid work ID format")
+	}
+	if e.seriesID, err = parseOptionalID(f.SeriesID); err != nil {
+		return e, httperr.New(fiber.StatusBadRequest, "Invalid series ID format")
+	}
+	if f.SeriesVolume != nil {
+		if *f.SeriesVolume < 1 {
+			return e, httperr.New(fiber.StatusBadRequest, "series_volume must be positive")
+		}
+		if e.seriesID == nil {
+			return e, httperr.New(fiber.StatusBadRequest, "series_volume requires series_id")
+		}
+		e.seriesVolume = f.SeriesVolume
+	}
+	if e.language, err = normalizeLanguage(f.Language); err != nil {
+		return e, err
+	}
+	if e.originalLanguage, err = normalizeLanguage(f.OriginalLanguage); err != nil {
+		return e, err
+	}
+	if f.PageCount != nil {
+		if *f.PageCount < 1 {
+			return e, httperr.New(fiber.StatusBadRequest, "page_count must be positive")
+		}
+		e.pageCount = f.PageCount
+	}
+	e.description = trimToNil(f.Description)
+	return e, nil
+}
+
+func parseOptionalID(s *string) (*uuid.UUID, error) {
+	if s == nil || strings.TrimSpace(*s) == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(strings.TrimSpace(*s))
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to get all books")
-		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve books")
+		return nil, err
+	}
+	return &id, nil
+}
+
+// parseIDFilter validates an optional identifier query parameter used to filter searches
+func parseIDFilter(c *fiber.Ctx, name string) (string, error) {
+	value := strings.TrimSpace(c.Query(name))
+	if value == "" {
+		return "", nil
+	}
+	if _, err := uuid.Parse(value); err != nil {
+		return "", httperr.New(fiber.StatusBadRequest, "Invalid "+name+" parameter")
+	}
+	return value, nil
+}
+
+// parseLanguageFilter validates the optional language query parameter
+func parseLanguageFilter(c *fiber.Ctx) (string, error) {
+	language := c.Query("language")
+	normalized, err := normalizeLanguage(&language)
+	if err != nil || normalized == nil {
+		return "", err
+	}
+	return *normalized, nil
+}
+
+// normalizeLanguage lower-cases an ISO 639-2 language code such as rus or eng
+func normalizeLanguage(code *string) (*string, error) {
+	if code == nil || strings.TrimSpace(*code) == "" {
+		return nil, nil
+	}
+	normalized := strings.ToLower(strings.TrimSpace(*code))
+	if len(normalized) != 3 || strings.Trim(normalized, "abcdefghijklmnopqrstuvwxyz") != "" {
+		return nil, httperr.New(fiber.StatusBadRequest, "Language must be a three-letter ISO 639-2 code")
+	}
+	return &normalized, nil
+}
+
+// parseContributorRole checks the role against the contributor_role enum; empty means author
+func parseContributorRole(role string) (postgres.ContributorRole, error) {
+	switch r := postgres.ContributorRole(strings.ToLower(strings.TrimSpace(role))); r {
+	case "":
+		return postgres.ContributorRoleAuthor, nil
+	case postgres.ContributorRoleAuthor, postgres.ContributorRoleEditor,
+		postgres.ContributorRoleTranslator, postgres.ContributorRoleIllustrator:
+		return r, nil
+	}
+	return "", httperr.New(fiber.StatusBadRequest, "role must be one of author, editor, translator, illustrator")
+}
+
+func bookSeries(id *uuid.UUID, title *string, volume *int) *BookSeriesResponse {
+	if id == nil || title == nil {
+		return nil
+	}
+	return &BookSeriesResponse{ID: *id, Title: *title, Volume: volume}
+}
+
+// bookContributors loads the contributors of several books at once, grouped by book
+func (h *Handler) bookContributors(c *fiber.Ctx, bookIDs []uuid.UUID) (map[uuid.UUID][]BookContributorResponse, error) {
+	rows, err := h.repo.GetBooksContributors(c.Context(), bookIDs)
+	if err != nil {
+		return nil, err
+	}
+	contributors := make(map[uuid.UUID][]BookContributorResponse, len(bookIDs))
+	for _, id := range bookIDs {
+		contributors[id] = []BookContributorResponse{}
+	}
+	for _, row := range rows {
+		contributors[row.BookID] = append(contributors[row.BookID], BookContributorResponse{
+			ID:       row.ID,
+			FullName: row.FullName,
+			Role:     row.Role,
+		})
+	}
+	return contributors, nil
+}
+
+// bookList responds with the books together with their series and contributors
+func (h *Handler) bookList(c *fiber.Ctx, books []*postgres.SearchBooksRow) error {
+	ids := make([]uuid.UUID, len(books))
+	for i, book := range books {
+		ids[i] = book.ID
+	}
+	contributors, err := h.bookContributors(c, ids)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get book contributors")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve book contributors")
 	}
 
 	response := make([]BookWithAuthorsResponse, len(books))
 	for i, book := range books {
 		response[i] = BookWithAuthorsResponse{
-			ID:              book.ID,
-			Title:           book.Title,
-			ISBN:            book.Isbn,
-			PublicationYear: book.PublicationYear,
-			Publisher:       book.Publisher,
-			TotalCopies:     book.TotalCopies,
-			AvailableCopies: book.AvailableCopies,
-			Authors:         string(book.Authors),
+			ID:               book.ID,
+			Title:            book.Title,
+			ISBN:             book.Isbn,
+			PublicationYear:  book.PublicationYear,
+			Publisher:        book.Publisher,
+			TotalCopies:      book.TotalCopies,
+			AvailableCopies:  book.AvailableCopies,
+			WorkID:           book.WorkID,
+			Series:           bookSeries(book.SeriesID, book.SeriesTitle, book.SeriesVolume),
+			Language:         book.Language,
+			OriginalLanguage: book.OriginalLanguage,
+			PageCount:        book.PageCount,
+			Authors:          string(book.Authors),
+			Contributors:     contributors[book.ID],
 		}
 	}
 
 	return c.JSON(response)
 }
 
+func (h *Handler) getAllBooks(c *fiber.Ctx) error {
+	books, err := h.repo.GetAllBooks(c.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get all books")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve books")
+	}
+
+	rows := make([]*postgres.SearchBooksRow, len(books))
+	for i, book := range books {
+		row := postgres.SearchBooksRow(*book)
+		rows[i] = &row
+	}
+
+	return h.bookList(c, rows)
+}
+
 func (h *Handler) searchBooks(c *fiber.Ctx) error {
 	title := c.Query("title")
 	author := c.Query("author")
@@ -75,32 +297,38 @@ func (h *Handler) searchBooks(c *fiber.Ctx) error {
 			return httperr.New(fiber.StatusBadRequest, "Invalid year parameter")
 		}
 	}
+	subjectID, err := parseIDFilter(c, "subject_id")
+	if err != nil {
+		return err
+	}
+	seriesID, err := parseIDFilter(c, "series_id")
+	if err != nil {
+		return err
+	}
+	workID, err := parseIDFilter(c, "work_id")
+	if err != nil {
+		return err
+	}
+	language, err := parseLanguageFilter(c)
+	if err != nil {
+		return err
+	}
 
 	books, err := h.repo.SearchBooks(c.Context(), postgres.SearchBooksParams{
 		Title:           title,
 		Author:          author,
 		PublicationYear: year,
+		SubjectID:       subjectID,
+		Language:        language,
+		SeriesID:        seriesID,
+		WorkID:          workID,
 	})
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to search books")
 		return httperr.New(fiber.StatusInternalServerError, "Failed to search books")
 	}
 
-	response := make([]BookWithAuthorsResponse, len(books))
-	for i, book := range books {
-		response[i] = BookWithAuthorsResponse{
-			ID:              book.ID,
-			Title:           book.Title,
-			ISBN:            book.Isbn,
-			PublicationYear: book.PublicationYear,
-			Publisher:       book.Publisher,
-			TotalCopies:     book.TotalCopies,
-			AvailableCopies: book.AvailableCopies,
-			Authors:         string(book.Authors),
-		}
-	}
-
-	return c.JSON(response)
+	return h.bookList(c, books)
 }
 
 func (h *Handler) getBookById(c *fiber.Ctx) error {
@@ -119,7 +347,44 @@ func (h *Handler) getBookById(c *fiber.Ctx) error {
 		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve book")
 	}
 
-	return c.JSON(book)
+	contributors, err := h.bookContributors(c, []uuid.UUID{id})
+	if err != nil {
+		log.Error().Err(err).Str("bookID", idStr).Msg("Failed to get book contributors")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve book contributors")
+	}
+
+	response := BookDetailResponse{
+		ID:               book.ID,
+		Title:            book.Title,
+		ISBN:             book.Isbn,
+		PublicationYear:  book.PublicationYear,
+		Publisher:        book.Publisher,
+		TotalCopies:      book.TotalCopies,
+		AvailableCopies:  book.AvailableCopies,
+		Series:           bookSeries(book.SeriesID, book.SeriesTitle, book.SeriesVolume),
+		Language:         book.Language,
+		OriginalLanguage: book.OriginalLanguage,
+		PageCount:        book.PageCount,
+		Description:      book.Description,
+		Contributors:     contributors[id],
+	}
+
+	if book.WorkID != nil && book.WorkTitle != nil {
+		editions, err := h.repo.GetWorkEditions(c.Context(), *book.WorkID)
+		if err != nil {
+			log.Error().Err(err).Str("bookID", idStr).Msg("Failed to get work editions")
+			return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve work editions")
+		}
+		work := &BookWorkResponse{ID: *book.WorkID, Title: *book.WorkTitle, Editions: []*postgres.GetWorkEditionsRow{}}
+		for _, edition := range editions {
+			if edition.ID != id {
+				work.Editions = append(work.Editions, edition)
+			}
+		}
+		response.Work = work
+	}
+
+	return c.JSON(response)
 }
 
 func (h *Handler) createBook(c *fiber.Ctx) error {
@@ -128,40 +393,94 @@ func (h *Handler) createBook(c *fiber.Ctx) error {
 		return httperr.New(fiber.StatusBadRequest, "Invalid request body", err.Error())
 	}
 
-	// Create book
-	book, err := h.repo.CreateBook(c.Context(), postgres.CreateBookParams{
-		Title:           req.Title,
-		Isbn:            req.ISBN,
-		PublicationYear: req.PublicationYear,
-		Publisher:       req.Publisher,
-		TotalCopies:     req.TotalCopies,
-	})
+	// With only an ISBN the description is looked up and proposed for confirmation;
+	// the book is created when the cataloguer sends the confirmed record back
+	if strings.TrimSpace(req.Title) == "" {
+		if req.ISBN == nil || strings.TrimSpace(*req.ISBN) == "" {
+			return httperr.New(fiber.StatusBadRequest, "Title or ISBN is required")
+		}
+		return h.proposeBook(c, req)
+	}
+
+	if req.TotalCopies < 1 || req.TotalCopies > maxCopiesPerBook {
+		return httperr.New(fiber.StatusBadRequest, "Invalid total_copies value")
+	}
+
+	var hallId *uuid.UUID
+	if req.HallID != nil && *req.HallID != "" {
+		id, err := uuid.Parse(*req.HallID)
+		if err != nil {
+			return httperr.New(fiber.StatusBadRequest, "Invalid hall ID format")
+		}
+		hallId = &id
+	}
+
+	edition, err := req.BookEditionFields.parse()
 	if err != nil {
+		return err
+	}
+
+	contributors := make([]BookContributorRequest, 0, len(req.Authors)+len(req.Contributors))
+	for _, authorName := range req.Authors {
+		contributors = append(contributors, BookContributorRequest{Name: authorName})
+	}
+	contributors = append(contributors, req.Contributors...)
+	roles := make([]postgres.ContributorRole, len(contributors))
+	for i, contributor := range contributors {
+		if roles[i], err = parseContributorRole(contributor.Role); err != nil {
+			return err
+		}
+	}
+
+	// Create book together with its copies
+	book, err := h.repo.CreateBookWithCopies(c.Context(), postgres.CreateBookParams{
+		Title:            req.Title,
+		Isbn:             req.ISBN,
+		PublicationYear:  req.PublicationYear,
+		Publisher:        req.Publisher,
+		WorkID:           edition.workID,
+		SeriesID:         edition.seriesID,
+		SeriesVolume:     edition.seriesVolume,
+		Language:         edition.language,
+		OriginalLanguage: edition.originalLanguage,
+		PageCount:        edition.pageCount,
+		Description:      edition.description,
+	}, req.TotalCopies, hallId)
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return httperr.New(fiber.StatusBadRequest, "Reading hall not found")
+		}
+		if strings.Contains(err.Error(), "violates foreign key") {
+			return httperr.New(fiber.StatusBadRequest, "Work or series not found")
+		}
 		log.Error().Err(err).Msg("Failed to create book")
 		return httperr.New(fiber.StatusInternalServerError, "Failed to create book")
 	}
 
-	// Add authors if provided
-	for _, authorName := range req.Authors {
-		if authorName == "" {
+	// Add authors and other contributors if provided
+	for i, contributor := range contributors {
+		if contributor.Name == "" {
 			continue
 		}
 
-		author, err := h.repo.GetOrCreateAuthor(c.Context(), authorName)
+		author, err := h.repo.GetOrCreateAuthor(c.Context(), contributor.Name)
 		if err != nil {
-			log.Warn().Err(err).Str("authorName", authorName).Msg("Failed to create author")
+			log.Warn().Err(err).Str("authorName", contributor.Name).Msg("Failed to create author")
 			continue
 		}
 
 		err = h.repo.AddBookAuthor(c.Context(), postgres.AddBookAuthorParams{
 			BookID:   book.ID,
 			AuthorID: author.ID,
+			Role:     roles[i],
 		})
 		if err != nil {
 			log.Warn().Err(err).Str("authorID", author.ID.String()).Msg("Failed to add book author")
 		}
 	}
 
+	h.invalidateCatalog(c)
+
 	return c.Status(fiber.StatusCreated).JSON(book)
 }
 
@@ -177,21 +496,38 @@ func (h *Handler) updateBook(c *fiber.Ctx) error {
 		return httperr.New(fiber.StatusBadRequest, "Invalid request body", err.Error())
 	}
 
+	edition, err := req.BookEditionFields.parse()
+	if err != nil {
+		return err
+	}
+
 	book, err := h.repo.UpdateBook(c.Context(), postgres.UpdateBookParams{
-		ID:              id,
-		Title:           req.Title,
-		Isbn:            req.ISBN,
-		PublicationYear: req.PublicationYear,
-		Publisher:       req.Publisher,
+		ID:               id,
+		Title:            req.Title,
+		Isbn:             req.ISBN,
+		PublicationYear:  req.PublicationYear,
+		Publisher:        req.Publisher,
+		WorkID:           edition.workID,
+		SeriesID:         edition.seriesID,
+		SeriesVolume:     edition.seriesVolume,
+		Language:         edition.language,
+		OriginalLanguage: edition.originalLanguage,
+		PageCount:        edition.pageCount,
+		Description:      edition.description,
 	})
 	if err != nil {
 		if strings.Contains(err.Error(), "no rows in result set") {
 			return httperr.New(fiber.StatusNotFound, "Book not found")
 		}
+		if strings.Contains(err.Error(), "violates foreign key") {
+			return httperr.New(fiber.StatusBadRequest, "Work or series not found")
+		}
 		log.Error().Err(err).Str("bookID", idStr).Msg("Failed to update book")
 		return httperr.New(fiber.StatusInternalServerError, "Failed to update book")
 	}
 
+	h.invalidateCatalog(c)
+
 	return c.JSON(book)
 }
 
@@ -213,6 +549,7 @@ func (h *Handler) getBookAuthors(c *fiber.Ctx) error {
 
 type AddBookAuthorRequest struct {
 	AuthorID string `json:"author_id" validate:"required"`
+	Role     string `json:"role"`
 }
 
 func (h *Handler) addBookAuthor(c *fiber.Ctx) error {
@@ -232,15 +569,26 @@ func (h *Handler) addBookAuthor(c *fiber.Ctx) error {
 		return httperr.New(fiber.StatusBadRequest, "Invalid author ID format")
 	}
 
+	role, err := parseContributorRole(req.Role)
+	if err != nil {
+		return err
+	}
+
 	err = h.repo.AddBookAuthor(c.Context(), postgres.AddBookAuthorParams{
 		BookID:   bookId,
 		AuthorID: authorId,
+		Role:     role,
 	})
 	if err != nil {
+		if strings.Contains(err.Error(), "duplicate") {
+			return httperr.New(fiber.StatusConflict, "Author already has this role in the book")
+		}
 		log.Error().Err(err).Str("bookID", bookIdStr).Str("authorID", req.AuthorID).Msg("Failed to add book author")
 		return httperr.New(fiber.StatusInternalServerError, "Failed to add book author")
 	}
 
+	h.invalidateCatalog(c)
+
 	return c.JSON(fiber.Map{"message": "Author added to book successfully"})
 }
 
@@ -257,14 +605,55 @@ func (h *Handler) removeBookAuthor(c *fiber.Ctx) error {
 		return httperr.New(fiber.StatusBadRequest, "Invalid author ID format")
 	}
 
+	// Without a role query parameter the person is removed from every role in the book
+	var role string
+	if roleStr := c.Query("role"); roleStr != "" {
+		parsed, err := parseContributorRole(roleStr)
+		if err != nil {
+			return err
+		}
+		role = string(parsed)
+	}
+
 	err = h.repo.RemoveBookAuthor(c.Context(), postgres.RemoveBookAuthorParams{
 		BookID:   bookId,
 		AuthorID: authorId,
+		Role:     role,
 	})
 	if err != nil {
 		log.Error().Err(err).Str("bookID", bookIdStr).Str("authorID", authorIdStr).Msg("Failed to remove book author")
 		return httperr.New(fiber.StatusInternalServerError, "Failed to remove book author")
 	}
 
+	h.invalidateCatalog(c)
+
 	return c.JSON(fiber.Map{"message": "Author removed from book successfully"})
 }
+
+func (h *Handler) auditBookCopyCounts(c *fiber.Ctx) error {
+	mismatches, err := h.repo.GetBookCopyCountMismatches(c.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to audit book copy counts")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to audit book copy counts")
+	}
+
+	return c.JSON(fiber.Map{
+		"consistent": len(mismatches) == 0,
+		"mismatches": mismatches,
+	})
+}
+
+func (h *Handler) repairBookCopyCounts(c *fiber.Ctx) error {
+	fixed, err := h.repo.RecountAllBookCopies(c.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to recount book copies")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to repair book copy counts")
+	}
+
+	h.invalidateCatalog(c)
+
+	return c.JSON(fiber.Map{
+		"message":     "Book copy counts repaired successfully",
+		"books_fixed": fixed,
+	})
+}
@@ -0,0 +1,345 @@
+package handler
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hnnsly/library-console/internal/repository"
+	"github.com/hnnsly/library-console/internal/repository/postgres"
+	httperr "github.com/hnnsly/library-console/pkg/error"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	bookingTimeLayout  = "2006-01-02T15:04"
+	maxSlotsPerBooking = 12
+)
+
+type CreateHallSeatsRequest struct {
+	SeatNumbers []string `json:"seat_numbers" validate:"required,min=1"`
+}
+
+type UpdateHallSeatRequest struct {
+	IsActive bool `json:"is_active"`
+}
+
+type CreateSeatBookingRequest struct {
+	TicketNumber string  `json:"ticket_number" validate:"required"`
+	HallID       string  `json:"hall_id" validate:"required"`
+	StartTime    string  `json:"start_time" validate:"required"`
+	Slots        int     `json:"slots" validate:"omitempty,min=1"`
+	SeatNumber   *string `json:"seat_number"`
+}
+
+func (h *Handler) getHallSeats(c *fiber.Ctx) error {
+	hallIdStr := c.Params("id")
+	hallId, err := uuid.Parse(hallIdStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid hall ID format")
+	}
+
+	seats, err := h.repo.GetHallSeats(c.Context(), hallId)
+	if err != nil {
+		log.Error().Err(err).Str("hallID", hallIdStr).Msg("Failed to get hall seats")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve hall seats")
+	}
+
+	return c.JSON(seats)
+}
+
+func (h *Handler) createHallSeats(c *fiber.Ctx) error {
+	hallIdStr := c.Params("id")
+	hallId, err := uuid.Parse(hallIdStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid hall ID format")
+	}
+
+	var req CreateHallSeatsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid request body", err.Error())
+	}
+	seatNumbers := make([]string, 0, len(req.SeatNumbers))
+	for _, seatNumber := range req.SeatNumbers {
+		if seatNumber = strings.TrimSpace(seatNumber); seatNumber != "" {
+			seatNumbers = append(seatNumbers, seatNumber)
+		}
+	}
+	if len(seatNumbers) == 0 {
+		return httperr.New(fiber.StatusBadRequest, "At least one seat number is required")
+	}
+
+	seats, err := h.repo.CreateHallSeats(c.Context(), hallId, seatNumbers)
+	if err != nil {
+		var duplicate *repository.DuplicateSeatError
+		switch {
+		case errors.As(err, &duplicate):
+			return httperr.New(fiber.StatusConflict, "Seat already exists in this hall", duplicate.SeatNumber)
+		case errors.Is(err, repository.ErrSeatMapTooLarge):
+			return httperr.New(fiber.StatusBadRequest, "Seat map cannot exceed the total number of seats in the hall")
+		case strings.Contains(err.Error(), "no rows in result set"):
+			return httperr.New(fiber.StatusNotFound, "Reading hall not found")
+		}
+		log.Error().Err(err).Str("hallID", hallIdStr).Msg("Failed to create hall seats")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to create hall seats")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(seats)
+}
+
+func (h *Handler) updateHallSeat(c *fiber.Ctx) error {
+	hallId, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid hall ID format")
+	}
+	idStr := c.Params("seatId")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid seat ID format")
+	}
+
+	var req UpdateHallSeatRequest
+	if err := c.BodyParser(&req); err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid request body", err.Error())
+	}
+
+	seat, err := h.repo.SetHallSeatActive(c.Context(), postgres.SetHallSeatActiveParams{
+		IsActive: req.IsActive,
+		ID:       id,
+		HallID:   hallId,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return httperr.New(fiber.StatusNotFound, "Seat not found in this hall")
+		}
+		log.Error().Err(err).Str("seatID", idStr).Msg("Failed to update hall seat")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to update hall seat")
+	}
+
+	return c.JSON(seat)
+}
+
+func (h *Handler) getHallBookings(c *fiber.Ctx) error {
+	hallIdStr := c.Params("id")
+	hallId, err := uuid.Parse(hallIdStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid hall ID format")
+	}
+
+	now := time.Now()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	if dateStr := c.Query("date"); dateStr != "" {
+		dayStart, err = time.ParseInLocation("2006-01-02", dateStr, time.Local)
+		if err != nil {
+			return httperr.New(fiber.StatusBadRequest, "Invalid date format, use YYYY-MM-DD")
+		}
+	}
+
+	bookings, err := h.repo.GetHallBookings(c.Context(), postgres.GetHallBookingsParams{
+		HallID:   hallId,
+		DayStart: dayStart,
+		DayEnd:   dayStart.AddDate(0, 0, 1),
+	})
+	if err != nil {
+		log.Error().Err(err).Str("hallID", hallIdStr).Msg("Failed to get hall bookings")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve hall bookings")
+	}
+
+	return c.JSON(bookings)
+}
+
+func (h *Handler) getReaderBookings(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid reader ID format")
+	}
+
+	bookings, err := h.repo.GetReaderBookings(c.Context(), id)
+	if err != nil {
+		log.Error().Err(err).Str("readerID", idStr).Msg("Failed to get reader bookings")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve reader bookings")
+	}
+
+	return c.JSON(bookings)
+}
+
+func (h *Handler) getSeatBookingById(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid booking ID format")
+	}
+
+	booking, err := h.repo.GetSeatBookingById(c.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return httperr.New(fiber.StatusNotFound, "Booking not found")
+		}
+		log.Error().Err(err).Str("bookingID", idStr).Msg("Failed to get booking")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve booking")
+	}
+
+	return c.JSON(booking)
+}
+
+func (h *Handler) createSeatBooking(c *fiber.Ctx) error {
+	var req CreateSeatBookingRequest
+	if err := c.BodyParser(&req); err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid request body", err.Error())
+	}
+
+	hallID, err := uuid.Parse(req.HallID)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid hall ID format")
+	}
+
+	if req.Slots == 0 {
+		req.Slots = 1
+	}
+	if req.Slots < 0 || req.Slots > maxSlotsPerBooking {
+		return httperr.New(fiber.StatusBadRequest, "Invalid slots parameter")
+	}
+
+	startTime, err := time.ParseInLocation(bookingTimeLayout, req.StartTime, time.Local)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid start_time format, use YYYY-MM-DDTHH:MM")
+	}
+
+	hall, err := h.repo.GetReadingHallById(c.Context(), hallID)
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return httperr.New(fiber.StatusNotFound, "Reading hall not found")
+		}
+		log.Error().Err(err).Str("hallID", req.HallID).Msg("Failed to get reading hall")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve reading hall")
+	}
+
+	// Bookings must start on a slot boundary counted from midnight
+	if (startTime.Hour()*60+startTime.Minute())%hall.BookingSlotMinutes != 0 {
+		return httperr.New(fiber.StatusBadRequest, "start_time must be aligned to the hall booking slot", fiber.Map{
+			"booking_slot_minutes": hall.BookingSlotMinutes,
+		})
+	}
+	endTime := startTime.Add(time.Duration(req.Slots*hall.BookingSlotMinutes) * time.Minute)
+	if !endTime.After(time.Now()) {
+		return httperr.New(fiber.StatusBadRequest, "Cannot book a slot in the past")
+	}
+
+	reader, err := h.readerByTicket(c, req.TicketNumber)
+	if err != nil {
+		return err
+	}
+	if reader.IsActive != nil && !*reader.IsActive {
+		return httperr.New(fiber.StatusForbidden, "Reader is deactivated")
+	}
+
+	readerBookings, err := h.repo.CountReaderOverlappingBookings(c.Context(), postgres.CountReaderOverlappingBookingsParams{
+		ReaderID:  reader.ID,
+		StartTime: startTime,
+		EndTime:   endTime,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("readerID", reader.ID.String()).Msg("Failed to check reader bookings")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to check reader bookings")
+	}
+	if readerBookings > 0 {
+		return httperr.New(fiber.StatusConflict, "Reader already has a booking for this time")
+	}
+
+	// Halls with a seat map book a concrete seat, the others are limited by total seats
+	seatsCount, err := h.repo.CountActiveHallSeats(c.Context(), hallID)
+	if err != nil {
+		log.Error().Err(err).Str("hallID", req.HallID).Msg("Failed to count hall seats")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to check hall seats")
+	}
+
+	var seatID *uuid.UUID
+	if seatsCount > 0 {
+		if req.SeatNumber != nil && *req.SeatNumber != "" {
+			found, err := h.repo.GetHallSeatByNumber(c.Context(), postgres.GetHallSeatByNumberParams{
+				HallID:     hallID,
+				SeatNumber: *req.SeatNumber,
+			})
+			if err != nil {
+				if strings.Contains(err.Error(), "no rows in result set") {
+					return httperr.New(fiber.StatusNotFound, "Seat not found in this hall")
+				}
+				log.Error().Err(err).Str("seatNumber", *req.SeatNumber).Msg("Failed to get hall seat")
+				return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve hall seat")
+			}
+			if !found.IsActive {
+				return httperr.New(fiber.StatusConflict, "Seat is not available for booking")
+			}
+			seatID = &found.ID
+		} else {
+			free, err := h.repo.GetFreeHallSeat(c.Context(), postgres.GetFreeHallSeatParams{
+				HallID:    hallID,
+				StartTime: startTime,
+				EndTime:   endTime,
+			})
+			if err != nil {
+				if strings.Contains(err.Error(), "no rows in result set") {
+					return httperr.New(fiber.StatusConflict, "No free seats for this time")
+				}
+				log.Error().Err(err).Str("hallID", req.HallID).Msg("Failed to find free hall seat")
+				return httperr.New(fiber.StatusInternalServerError, "Failed to find free seat")
+			}
+			seatID = &free.ID
+		}
+	}
+
+	librarianID, err := sessionUserID(c)
+	if err != nil {
+		return err
+	}
+
+	arg := postgres.CreateSeatBookingParams{
+		ReaderID:    reader.ID,
+		HallID:      hallID,
+		SeatID:      seatID,
+		StartTime:   startTime,
+		EndTime:     endTime,
+		LibrarianID: &librarianID,
+	}
+	// Seats are protected by the exclusion constraint, halls without a seat map by a capacity check under the hall lock
+	var booking *postgres.CreateSeatBookingRow
+	if seatID != nil {
+		booking, err = h.repo.CreateSeatBooking(c.Context(), arg)
+	} else {
+		booking, err = h.repo.CreateHallBooking(c.Context(), arg)
+	}
+	if err != nil {
+		if strings.Contains(err.Error(), "excl_seat_bookings_overlap") {
+			return httperr.New(fiber.StatusConflict, "Seat is already booked for this time")
+		}
+		if errors.Is(err, repository.ErrHallFull) {
+			return httperr.New(fiber.StatusConflict, "No free seats for this time")
+		}
+		log.Error().Err(err).Str("ticketNumber", req.TicketNumber).Msg("Failed to create seat booking")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to create seat booking")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(booking)
+}
+
+func (h *Handler) cancelSeatBooking(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid booking ID format")
+	}
+
+	booking, err := h.repo.CancelSeatBooking(c.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return httperr.New(fiber.StatusNotFound, "Active booking not found")
+		}
+		log.Error().Err(err).Str("bookingID", idStr).Msg("Failed to cancel seat booking")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to cancel seat booking")
+	}
+
+	return c.JSON(booking)
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hnnsly/library-console/internal/labels"
+	httperr "github.com/hnnsly/library-console/pkg/error"
+	"github.com/rs/zerolog/log"
+)
+
+func (h *Handler) printReaderCard(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid reader ID format")
+	}
+
+	code := labels.CardCode(c.Query("code", string(labels.CardCodeQR)))
+	if code != labels.CardCodeQR && code != labels.CardCodeBarcode {
+		return httperr.New(fiber.StatusBadRequest, "Invalid code type, use qr or barcode")
+	}
+
+	reader, err := h.repo.GetReaderById(c.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return httperr.New(fiber.StatusNotFound, "Reader not found")
+		}
+		log.Error().Err(err).Str("readerID", idStr).Msg("Failed to get reader")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve reader")
+	}
+
+	var buf bytes.Buffer
+	err = labels.RenderReaderCard(&buf, labels.ReaderCard{
+		FullName:     reader.FullName,
+		TicketNumber: reader.TicketNumber,
+		ExpiresAt:    reader.CardExpiresAt,
+	}, code)
+	if err != nil {
+		log.Error().Err(err).Str("readerID", idStr).Msg("Failed to render reader card")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to render reader card")
+	}
+
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, `inline; filename="card-`+reader.TicketNumber+`.pdf"`)
+
+	return c.Send(buf.Bytes())
+}
+
+func (h *Handler) reissueReaderCard(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid reader ID format")
+	}
+
+	var req ReissueTicketRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return httperr.New(fiber.StatusBadRequest, "Invalid request body", err.Error())
+		}
+	}
+
+	librarianID, err := sessionUserID(c)
+	if err != nil {
+		return err
+	}
+
+	reissued, err := h.repo.ReissueReaderTicket(c.Context(), id, req.Reason, &librarianID)
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return httperr.New(fiber.StatusNotFound, "Reader not found")
+		}
+		log.Error().Err(err).Str("readerID", idStr).Msg("Failed to reissue reader card")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to reissue reader card")
+	}
+
+	return c.JSON(reissued)
+}
+
+func (h *Handler) getReaderOldTickets(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid reader ID format")
+	}
+
+	tickets, err := h.repo.GetReaderOldTicketNumbers(c.Context(), id)
+	if err != nil {
+		log.Error().Err(err).Str("readerID", idStr).Msg("Failed to get old ticket numbers")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve old ticket numbers")
+	}
+
+	return c.JSON(tickets)
+}
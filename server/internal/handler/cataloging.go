@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hnnsly/library-console/internal/cataloging"
+	httperr "github.com/hnnsly/library-console/pkg/error"
+	"github.com/rs/zerolog/log"
+)
+
+// BookProposalResponse is a record found by ISBN. Proposed can be edited and sent back
+// to createBook as is; nothing is saved until then
+type BookProposalResponse struct {
+	Proposed CreateBookRequest `json:"proposed"`
+	Source   string            `json:"source"`
+	// ExistingBookID is set when the catalogue already has a book with this ISBN,
+	// so the cataloguer can add copies to it instead of creating a duplicate
+	ExistingBookID    *uuid.UUID `json:"existing_book_id,omitempty"`
+	ExistingBookTitle *string    `json:"existing_book_title,omitempty"`
+}
+
+// proposeBook fills in a book description from the metadata sources for a request that only has an ISBN
+func (h *Handler) proposeBook(c *fiber.Ctx, req CreateBookRequest) error {
+	proposal, err := h.cataloguer.Lookup(c.Context(), *req.ISBN)
+	if err != nil {
+		switch {
+		case errors.Is(err, cataloging.ErrInvalidISBN):
+			return httperr.New(fiber.StatusBadRequest, "Invalid ISBN")
+		case errors.Is(err, cataloging.ErrNotFound):
+			return httperr.New(fiber.StatusNotFound, "No record found for the ISBN")
+		}
+		log.Error().Err(err).Str("isbn", *req.ISBN).Msg("Failed to look up book metadata")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to look up book metadata")
+	}
+
+	proposed := req
+	proposed.ISBN = &proposal.ISBN
+	proposed.Title = proposal.Title
+	proposed.Authors = proposal.Authors
+	proposed.Publisher = proposal.Publisher
+	proposed.PublicationYear = proposal.PublicationYear
+	if proposed.TotalCopies == 0 {
+		proposed.TotalCopies = 1
+	}
+	response := BookProposalResponse{Proposed: proposed, Source: proposal.Source}
+
+	existing, err := h.repo.GetBookByIsbns(c.Context(), cataloging.ISBNForms(proposal.ISBN))
+	if err == nil {
+		response.ExistingBookID = &existing.ID
+		response.ExistingBookTitle = &existing.Title
+	} else if !strings.Contains(err.Error(), "no rows in result set") {
+		log.Warn().Err(err).Str("isbn", proposal.ISBN).Msg("Failed to check for an existing book")
+	}
+
+	return c.JSON(response)
+}
@@ -1,10 +1,12 @@
 package handler
 
 import (
+	"errors"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/hnnsly/library-console/internal/repository"
 	"github.com/hnnsly/library-console/internal/repository/postgres"
 	httperr "github.com/hnnsly/library-console/pkg/error"
 	"github.com/rs/zerolog/log"
@@ -12,13 +14,16 @@ import (
 
 type CreateBookCopyRequest struct {
 	BookID       string  `json:"book_id" validate:"required"`
-	CopyCode     string  `json:"copy_code" validate:"required"`
+	CopyCode     string  `json:"copy_code"` // generated when empty
 	HallID       *string `json:"hall_id"`
 	LocationInfo *string `json:"location_info"`
 }
 
 type UpdateBookCopyStatusRequest struct {
 	Status string `json:"status" validate:"required"`
+	Reason string `json:"reason" validate:"required"`
+	// ReaderID is the reader a reserved copy is held for; only they can borrow it, and they are notified that it is ready
+	ReaderID *string `json:"reader_id"`
 }
 
 func (h *Handler) getBookCopiesByBookId(c *fiber.Ctx) error {
@@ -115,17 +120,34 @@ func (h *Handler) createBookCopy(c *fiber.Ctx) error {
 		hallId = &id
 	}
 
+	copyCode := strings.TrimSpace(req.CopyCode)
+	if copyCode == "" {
+		copyCode, err = h.repo.GenerateCopyCode(c.Context(), hallId)
+		if err != nil {
+			if strings.Contains(err.Error(), "no rows in result set") {
+				return httperr.New(fiber.StatusBadRequest, "Reading hall not found")
+			}
+			log.Error().Err(err).Msg("Failed to generate copy code")
+			return httperr.New(fiber.StatusInternalServerError, "Failed to generate copy code")
+		}
+	}
+
 	copy, err := h.repo.CreateBookCopy(c.Context(), postgres.CreateBookCopyParams{
 		BookID:       bookId,
-		CopyCode:     req.CopyCode,
+		CopyCode:     copyCode,
 		HallID:       hallId,
 		LocationInfo: req.LocationInfo,
 	})
 	if err != nil {
+		if strings.Contains(err.Error(), "duplicate") {
+			return httperr.New(fiber.StatusConflict, "Book copy with this code already exists")
+		}
 		log.Error().Err(err).Msg("Failed to create book copy")
 		return httperr.New(fiber.StatusInternalServerError, "Failed to create book copy")
 	}
 
+	h.invalidateCatalog(c)
+
 	return c.Status(fiber.StatusCreated).JSON(copy)
 }
 
@@ -141,29 +163,77 @@ func (h *Handler) updateBookCopyStatus(c *fiber.Ctx) error {
 		return httperr.New(fiber.StatusBadRequest, "Invalid request body", err.Error())
 	}
 
-	// Validate status
-	validStatuses := map[string]bool{
-		"available": true,
-		"issued":    true,
-		"reserved":  true,
-		"lost":      true,
-		"damaged":   true,
-	}
-	if !validStatuses[req.Status] {
+	status := postgres.BookStatus(req.Status)
+	if !repository.IsCopyStatus(status) {
 		return httperr.New(fiber.StatusBadRequest, "Invalid status value")
 	}
+	if strings.TrimSpace(req.Reason) == "" {
+		return httperr.New(fiber.StatusBadRequest, "Reason is required")
+	}
 
-	err = h.repo.UpdateBookCopyStatus(c.Context(), postgres.UpdateBookCopyStatusParams{
-		CopyID: id,
-		Status: postgres.NullBookStatus{
-			BookStatus: postgres.BookStatus(req.Status),
-			Valid:      true,
-		},
+	var holdReaderID *uuid.UUID
+	if req.ReaderID != nil {
+		if status != postgres.BookStatusReserved {
+			return httperr.New(fiber.StatusBadRequest, "Reader can only be given for reserved status")
+		}
+		readerID, err := uuid.Parse(*req.ReaderID)
+		if err != nil {
+			return httperr.New(fiber.StatusBadRequest, "Invalid reader ID format")
+		}
+		holdReaderID = &readerID
+	}
+
+	librarianID, err := sessionUserID(c)
+	if err != nil {
+		return err
+	}
+
+	err = h.repo.TransitionCopyStatus(c.Context(), repository.CopyTransition{
+		CopyID:     id,
+		To:         status,
+		Reason:     strings.TrimSpace(req.Reason),
+		ChangedBy:  &librarianID,
+		HoldReader: holdReaderID,
 	})
 	if err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return httperr.New(fiber.StatusNotFound, "Book copy not found")
+		}
+		if strings.Contains(err.Error(), "violates foreign key") {
+			return httperr.New(fiber.StatusBadRequest, "Reader not found")
+		}
+		if errors.Is(err, repository.ErrCirculationOnly) {
+			return httperr.New(fiber.StatusConflict, "Issued status can only be changed by issuing, returning or losing the book")
+		}
+		if errors.Is(err, repository.ErrInvalidCopyTransition) {
+			return httperr.New(fiber.StatusConflict, "Status transition is not allowed", err.Error())
+		}
 		log.Error().Err(err).Str("copyID", idStr).Msg("Failed to update book copy status")
 		return httperr.New(fiber.StatusInternalServerError, "Failed to update book copy status")
 	}
 
-	return c.JSON(fiber.Map{"message": "Book copy status updated successfully"})
+	h.invalidateCatalog(c)
+
+	response := fiber.Map{"message": "Book copy status updated successfully"}
+	if holdReaderID != nil {
+		response["notification_queued"] = h.notifyHoldAvailable(c, *holdReaderID, id)
+	}
+
+	return c.JSON(response)
+}
+
+func (h *Handler) getBookCopyHistory(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid copy ID format")
+	}
+
+	history, err := h.repo.GetCopyStatusHistory(c.Context(), id)
+	if err != nil {
+		log.Error().Err(err).Str("copyID", idStr).Msg("Failed to get book copy history")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve book copy history")
+	}
+
+	return c.JSON(history)
 }
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hnnsly/library-console/internal/repository"
+	"github.com/hnnsly/library-console/internal/repository/postgres"
+	httperr "github.com/hnnsly/library-console/pkg/error"
+	"github.com/rs/zerolog/log"
+)
+
+type MergeReadersRequest struct {
+	DuplicateID string  `json:"duplicate_id" validate:"required"`
+	Reason      *string `json:"reason"`
+}
+
+func (h *Handler) findDuplicateReaders(c *fiber.Ctx) error {
+	var readerID *uuid.UUID
+	if idStr := c.Query("reader_id"); idStr != "" {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			return httperr.New(fiber.StatusBadRequest, "Invalid reader ID format")
+		}
+		readerID = &id
+	}
+
+	minScore, err := strconv.ParseFloat(c.Query("min_score", "0.5"), 64)
+	if err != nil || minScore < 0 || minScore > 1 {
+		return httperr.New(fiber.StatusBadRequest, "Invalid min_score parameter, expected a value between 0 and 1")
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", "50"))
+	if err != nil || limit <= 0 || limit > 500 {
+		return httperr.New(fiber.StatusBadRequest, "Invalid limit parameter")
+	}
+
+	duplicates, err := h.repo.FindDuplicateReaders(c.Context(), postgres.FindDuplicateReadersParams{
+		ReaderID:   readerID,
+		MinScore:   minScore,
+		LimitCount: int32(limit),
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to find duplicate readers")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to find duplicate readers")
+	}
+
+	return c.JSON(duplicates)
+}
+
+func (h *Handler) mergeReaders(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	survivingID, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid reader ID format")
+	}
+
+	var req MergeReadersRequest
+	if err := c.BodyParser(&req); err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid request body", err.Error())
+	}
+	duplicateID, err := uuid.Parse(req.DuplicateID)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid duplicate reader ID format")
+	}
+
+	librarianID, err := sessionUserID(c)
+	if err != nil {
+		return err
+	}
+
+	merge, err := h.repo.MergeReaders(c.Context(), repository.ReaderMerge{
+		SurvivingID: survivingID,
+		DuplicateID: duplicateID,
+		Reason:      req.Reason,
+		LibrarianID: &librarianID,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrMergeSameReader):
+			return httperr.New(fiber.StatusBadRequest, "Cannot merge a reader with itself")
+		case errors.Is(err, repository.ErrReaderInactive):
+			return httperr.New(fiber.StatusConflict, "Both readers must be active to be merged", err.Error())
+		case strings.Contains(err.Error(), "no rows in result set"):
+			return httperr.New(fiber.StatusNotFound, "Reader not found")
+		}
+		log.Error().Err(err).Str("readerID", idStr).Str("duplicateID", req.DuplicateID).Msg("Failed to merge readers")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to merge readers")
+	}
+
+	return c.JSON(merge)
+}
+
+func (h *Handler) getReaderMerges(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid reader ID format")
+	}
+
+	merges, err := h.repo.GetReaderMerges(c.Context(), id)
+	if err != nil {
+		log.Error().Err(err).Str("readerID", idStr).Msg("Failed to get reader merges")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve reader merges")
+	}
+
+	return c.JSON(merges)
+}
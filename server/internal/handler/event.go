@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hnnsly/library-console/internal/repository/redis"
+	httperr "github.com/hnnsly/library-console/pkg/error"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	eventsHeartbeatInterval = 15 * time.Second
+	// eventsRetryInterval is the pause before subscribing again after Redis was unreachable
+	eventsRetryInterval = 5 * time.Second
+	// eventsClientBuffer events queued per stream; a slow client misses events instead of blocking others
+	eventsClientBuffer = 64
+)
+
+// eventHub fans events of a single Redis subscription out to the SSE streams of this replica
+type eventHub struct {
+	mu      sync.Mutex
+	clients map[chan redis.Event]struct{}
+	// done is closed on shutdown so open streams end and the server can stop without waiting for them
+	done chan struct{}
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{
+		clients: make(map[chan redis.Event]struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// subscribe registers a stream; the returned function removes it
+func (hub *eventHub) subscribe() (<-chan redis.Event, func()) {
+	ch := make(chan redis.Event, eventsClientBuffer)
+	hub.mu.Lock()
+	hub.clients[ch] = struct{}{}
+	hub.mu.Unlock()
+
+	return ch, func() {
+		hub.mu.Lock()
+		delete(hub.clients, ch)
+		hub.mu.Unlock()
+	}
+}
+
+func (hub *eventHub) broadcast(event redis.Event) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	for ch := range hub.clients {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// RunEvents keeps one Redis subscription for all SSE streams of this replica until ctx is done,
+// then ends the open streams
+func (h *Handler) RunEvents(ctx context.Context) {
+	defer close(h.events.done)
+
+	for {
+		sub, err := h.repo.SubscribeEvents(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to subscribe to events")
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(eventsRetryInterval):
+				continue
+			}
+		}
+
+		h.forwardEvents(ctx, sub)
+		sub.Close()
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// forwardEvents passes events of sub to the streams until ctx is done or the subscription ends
+func (h *Handler) forwardEvents(ctx context.Context, sub *redis.EventSubscription) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			h.events.broadcast(event)
+		}
+	}
+}
+
+type HallOccupancyEvent struct {
+	HallID          uuid.UUID `json:"hall_id"`
+	HallName        string    `json:"hall_name"`
+	TotalSeats      int       `json:"total_seats"`
+	CurrentVisitors int       `json:"current_visitors"`
+}
+
+type VisitEvent struct {
+	VisitID      uuid.UUID  `json:"visit_id"`
+	HallID       uuid.UUID  `json:"hall_id"`
+	TicketNumber string     `json:"ticket_number"`
+	VisitTime    *time.Time `json:"visit_time"`
+}
+
+// publishEvent sends an event to every server replica; failures never break the request
+func (h *Handler) publishEvent(c *fiber.Ctx, eventType string, payload any) {
+	if err := h.repo.PublishEvent(c.Context(), eventType, payload); err != nil {
+		log.Warn().Err(err).Str("eventType", eventType).Msg("Failed to publish event")
+	}
+}
+
+func (h *Handler) publishHallOccupancy(c *fiber.Ctx, hallID uuid.UUID) {
+	hall, err := h.repo.GetReadingHallById(c.Context(), hallID)
+	if err != nil {
+		log.Warn().Err(err).Str("hallID", hallID.String()).Msg("Failed to get hall for occupancy event")
+		return
+	}
+
+	currentVisitors := 0
+	if hall.CurrentVisitors != nil {
+		currentVisitors = *hall.CurrentVisitors
+	}
+
+	h.publishEvent(c, redis.EventHallOccupancy, HallOccupancyEvent{
+		HallID:          hall.ID,
+		HallName:        hall.HallName,
+		TotalSeats:      hall.TotalSeats,
+		CurrentVisitors: currentVisitors,
+	})
+}
+
+func (h *Handler) streamEvents(c *fiber.Ctx) error {
+	// Optional comma separated filter, e.g. ?types=hall.occupancy,visit.entry
+	var types map[string]bool
+	if typesStr := c.Query("types"); typesStr != "" {
+		types = make(map[string]bool)
+		for _, t := range strings.Split(typesStr, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				types[t] = true
+			}
+		}
+	}
+
+	sessionID, _ := c.Locals("sessionID").(string)
+
+	select {
+	case <-h.events.done:
+		return httperr.New(fiber.StatusServiceUnavailable, "Server is shutting down")
+	default:
+	}
+	events, unsubscribe := h.events.subscribe()
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+
+		heartbeat := time.NewTicker(eventsHeartbeatInterval)
+		defer heartbeat.Stop()
+
+		fmt.Fprint(w, "retry: 3000\n\n")
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		for {
+			select {
+			case <-h.events.done:
+				return
+
+			case event := <-events:
+				if types != nil && !types[event.Type] {
+					continue
+				}
+
+				data, err := json.Marshal(event)
+				if err != nil {
+					continue
+				}
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+				if err := w.Flush(); err != nil {
+					return
+				}
+
+			case <-heartbeat.C:
+				// Session may have expired or been revoked since the stream was opened
+				if _, err := h.repo.GetSession(context.Background(), sessionID); err != nil {
+					fmt.Fprint(w, "event: session.expired\ndata: {}\n\n")
+					w.Flush()
+					return
+				}
+
+				fmt.Fprint(w, ": ping\n\n")
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+		}
+	})
+
+	return nil
+}
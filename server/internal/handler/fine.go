@@ -53,7 +53,7 @@ func (h *Handler) createFine(c *fiber.Ctx) error {
 		return httperr.New(fiber.StatusBadRequest, "Invalid amount format")
 	}
 
-	fine, err := h.repo.CreateFine(c.Context(), postgres.CreateFineParams{
+	fine, err := h.repo.ChargeFine(c.Context(), postgres.CreateFineParams{
 		ReaderID:    readerID,
 		BookIssueID: bookIssueID,
 		Amount:      amount,
@@ -74,7 +74,7 @@ func (h *Handler) payFine(c *fiber.Ctx) error {
 		return httperr.New(fiber.StatusBadRequest, "Invalid fine ID format")
 	}
 
-	fine, err := h.repo.PayFine(c.Context(), id)
+	fine, err := h.repo.SettleFine(c.Context(), id)
 	if err != nil {
 		if strings.Contains(err.Error(), "no rows in result set") {
 			return httperr.New(fiber.StatusNotFound, "Fine not found")
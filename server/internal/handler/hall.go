@@ -11,16 +11,61 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+const (
+	defaultBookingSlotMinutes = 60
+	defaultNoShowMinutes      = 15
+)
+
 type CreateReadingHallRequest struct {
-	HallName       string  `json:"hall_name" validate:"required"`
-	Specialization *string `json:"specialization"`
-	TotalSeats     int     `json:"total_seats" validate:"required,min=1"`
+	HallName           string  `json:"hall_name" validate:"required"`
+	HallCode           *string `json:"hall_code" validate:"omitempty,max=10"`
+	Specialization     *string `json:"specialization"`
+	TotalSeats         int     `json:"total_seats" validate:"required,min=1"`
+	BookingSlotMinutes *int    `json:"booking_slot_minutes" validate:"omitempty,min=1"`
+	NoShowMinutes      *int    `json:"no_show_minutes" validate:"omitempty,min=0"`
 }
 
 type UpdateReadingHallRequest struct {
-	HallName       string  `json:"hall_name" validate:"required"`
-	Specialization *string `json:"specialization"`
-	TotalSeats     int     `json:"total_seats" validate:"required,min=1"`
+	HallName           string  `json:"hall_name" validate:"required"`
+	HallCode           *string `json:"hall_code" validate:"omitempty,max=10"`
+	Specialization     *string `json:"specialization"`
+	TotalSeats         int     `json:"total_seats" validate:"required,min=1"`
+	BookingSlotMinutes *int    `json:"booking_slot_minutes" validate:"omitempty,min=1"`
+	NoShowMinutes      *int    `json:"no_show_minutes" validate:"omitempty,min=0"`
+}
+
+// normalizeHallCode upper-cases the hall code and checks it only contains letters and digits
+func normalizeHallCode(code *string) (*string, error) {
+	if code == nil || strings.TrimSpace(*code) == "" {
+		return nil, nil
+	}
+	normalized := strings.ToUpper(strings.TrimSpace(*code))
+	if len(normalized) > 10 {
+		return nil, httperr.New(fiber.StatusBadRequest, "hall_code must be at most 10 characters")
+	}
+	for _, r := range normalized {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return nil, httperr.New(fiber.StatusBadRequest, "hall_code may contain only latin letters and digits")
+		}
+	}
+	return &normalized, nil
+}
+
+// bookingSettings returns slot length and no-show timeout, falling back to the given values
+func bookingSettings(slotMinutes, noShowMinutes *int, slot, noShow int) (int, int, error) {
+	if slotMinutes != nil {
+		if *slotMinutes <= 0 || 24*60%*slotMinutes != 0 {
+			return 0, 0, httperr.New(fiber.StatusBadRequest, "booking_slot_minutes must be a positive divisor of 1440")
+		}
+		slot = *slotMinutes
+	}
+	if noShowMinutes != nil {
+		if *noShowMinutes < 0 {
+			return 0, 0, httperr.New(fiber.StatusBadRequest, "no_show_minutes must not be negative")
+		}
+		noShow = *noShowMinutes
+	}
+	return slot, noShow, nil
 }
 
 func (h *Handler) getAllReadingHalls(c *fiber.Ctx) error {
@@ -68,14 +113,28 @@ func (h *Handler) createReadingHall(c *fiber.Ctx) error {
 		return httperr.New(fiber.StatusBadRequest, "Invalid request body", err.Error())
 	}
 
+	hallCode, err := normalizeHallCode(req.HallCode)
+	if err != nil {
+		return err
+	}
+
+	slotMinutes, noShowMinutes, err := bookingSettings(req.BookingSlotMinutes, req.NoShowMinutes,
+		defaultBookingSlotMinutes, defaultNoShowMinutes)
+	if err != nil {
+		return err
+	}
+
 	hall, err := h.repo.CreateReadingHall(c.Context(), postgres.CreateReadingHallParams{
-		HallName:       req.HallName,
-		Specialization: req.Specialization,
-		TotalSeats:     req.TotalSeats,
+		HallName:           req.HallName,
+		HallCode:           hallCode,
+		Specialization:     req.Specialization,
+		TotalSeats:         req.TotalSeats,
+		BookingSlotMinutes: slotMinutes,
+		NoShowMinutes:      noShowMinutes,
 	})
 	if err != nil {
 		if strings.Contains(err.Error(), "duplicate") {
-			return httperr.New(fiber.StatusConflict, "Reading hall with this name already exists")
+			return httperr.New(fiber.StatusConflict, "Reading hall with this code already exists")
 		}
 		log.Error().Err(err).Msg("Failed to create reading hall")
 		return httperr.New(fiber.StatusInternalServerError, "Failed to create reading hall")
@@ -96,20 +155,51 @@ func (h *Handler) updateReadingHall(c *fiber.Ctx) error {
 		return httperr.New(fiber.StatusBadRequest, "Invalid request body", err.Error())
 	}
 
+	current, err := h.repo.GetReadingHallById(c.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return httperr.New(fiber.StatusNotFound, "Reading hall not found")
+		}
+		log.Error().Err(err).Str("hallID", idStr).Msg("Failed to get reading hall")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to update reading hall")
+	}
+
+	// An omitted code keeps the one already printed on labels; an empty string clears it
+	hallCode := current.HallCode
+	if req.HallCode != nil {
+		if hallCode, err = normalizeHallCode(req.HallCode); err != nil {
+			return err
+		}
+	}
+
+	slotMinutes, noShowMinutes, err := bookingSettings(req.BookingSlotMinutes, req.NoShowMinutes,
+		current.BookingSlotMinutes, current.NoShowMinutes)
+	if err != nil {
+		return err
+	}
+
 	hall, err := h.repo.UpdateReadingHall(c.Context(), postgres.UpdateReadingHallParams{
-		ID:             id,
-		HallName:       req.HallName,
-		Specialization: req.Specialization,
-		TotalSeats:     req.TotalSeats,
+		ID:                 id,
+		HallName:           req.HallName,
+		HallCode:           hallCode,
+		Specialization:     req.Specialization,
+		TotalSeats:         req.TotalSeats,
+		BookingSlotMinutes: slotMinutes,
+		NoShowMinutes:      noShowMinutes,
 	})
 	if err != nil {
 		if strings.Contains(err.Error(), "no rows in result set") {
 			return httperr.New(fiber.StatusNotFound, "Reading hall not found")
 		}
+		if strings.Contains(err.Error(), "duplicate") {
+			return httperr.New(fiber.StatusConflict, "Reading hall with this code already exists")
+		}
 		log.Error().Err(err).Str("hallID", idStr).Msg("Failed to update reading hall")
 		return httperr.New(fiber.StatusInternalServerError, "Failed to update reading hall")
 	}
 
+	h.invalidateCatalog(c)
+
 	return c.JSON(hall)
 }
 
@@ -5,78 +5,170 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/hnnsly/library-console/internal/attachments"
+	"github.com/hnnsly/library-console/internal/cataloging"
+	"github.com/hnnsly/library-console/internal/circulation"
 	"github.com/hnnsly/library-console/internal/config"
 	"github.com/hnnsly/library-console/internal/middleware"
+	"github.com/hnnsly/library-console/internal/ncip"
+	"github.com/hnnsly/library-console/internal/notify"
+	"github.com/hnnsly/library-console/internal/oai"
 	"github.com/hnnsly/library-console/internal/repository"
+	"github.com/hnnsly/library-console/internal/sru"
 	httperr "github.com/hnnsly/library-console/pkg/error"
 )
 
 type Handler struct {
-	repo *repository.LibraryRepository
-	cfg  *config.LibraryServiceConfig
+	repo        *repository.LibraryRepository
+	desk        *circulation.Desk
+	notifier    *notify.Dispatcher
+	cataloguer  *cataloging.Cataloguer
+	attachments *attachments.Service
+	ncip        *ncip.Responder // nil when NCIP is not configured
+	sru         *sru.Service
+	oai         *oai.Provider // nil when OAI-PMH is not configured
+	events      *eventHub
+	cfg         *config.LibraryServiceConfig
 }
 
-func NewHandler(repo *repository.LibraryRepository, cfg *config.LibraryServiceConfig) *Handler {
-	return &Handler{
-		repo: repo,
-		cfg:  cfg,
+func NewHandler(repo *repository.LibraryRepository, desk *circulation.Desk, notifier *notify.Dispatcher, cataloguer *cataloging.Cataloguer, files *attachments.Service, cfg *config.LibraryServiceConfig) *Handler {
+	h := &Handler{
+		repo:        repo,
+		desk:        desk,
+		notifier:    notifier,
+		cataloguer:  cataloguer,
+		attachments: files,
+		sru:         sru.NewService(*cfg.SRU, repo),
+		events:      newEventHub(),
+		cfg:         cfg,
 	}
+	if cfg.NCIP != nil {
+		h.ncip = ncip.NewResponder(*cfg.NCIP, cfg.Portal.MaxLoginAttempts, repo, desk)
+	}
+	if cfg.OAI != nil {
+		h.oai = oai.NewProvider(*cfg.OAI, repo)
+	}
+	return h
 }
 
 func (h *Handler) Router() *fiber.App {
 	app := fiber.New(fiber.Config{
 		ErrorHandler: httperr.GlobalErrorHandler,
+		// Bodies are streamed and size-checked by the body limit middleware, so only
+		// attachment uploads may exceed the default limit
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
+		// Client IPs from the proxy header are trusted only from configured proxies,
+		// so rate limits cannot be dodged by sending a forged header
+		ProxyHeader:             h.cfg.ProxyHeader,
+		EnableTrustedProxyCheck: len(h.cfg.TrustedProxies) > 0,
+		TrustedProxies:          h.cfg.TrustedProxies,
 	})
 
 	// Middleware
 	app.Use(recover.New())
 
-	// Health check
-	app.Get("/health", h.healthCheck)
-
 	// API routes
 	api := app.Group("/api/library")
 
 	// Auth middleware for protected routes
 	authMiddleware := middleware.NewAuthMiddleware(h.repo, 24*time.Hour)
 
+	// Attachment uploads are read into memory with room for the multipart envelope above the file
+	// size limit. Fiber runs handlers in registration order, so this route is registered before
+	// the default body limit and is the only one that accepts larger bodies. The caller is
+	// authenticated before the body is read; a rejected upload closes the connection instead
+	uploadLimit := int(max(h.cfg.Attachments.MaxImageSize, h.cfg.Attachments.MaxDocumentSize)) + 1<<20
+	api.Post("/books/:id/attachments", middleware.NewUnreadBodyMiddleware(), authMiddleware,
+		middleware.NewBodyLimitMiddleware(uploadLimit), h.uploadBookAttachment)
+	app.Use(middleware.NewBodyLimitMiddleware(fiber.DefaultBodyLimit))
+
+	// Health check
+	app.Get("/health", h.healthCheck)
+
 	authGroup := api.Group("/auth")
 	authGroup.Post("/login", h.login)
 	authGroup.Post("/logout", h.logout)
 	authGroup.Get("/me", authMiddleware, h.me)
 
-	// Books
+	// Books. Staff catalogue reads, like the copies, authors and works below, return internal fields;
+	// the public catalogue is served by the OPAC group below
 	booksGroup := api.Group("/books")
-	booksGroup.Get("/", h.getAllBooks)
-	booksGroup.Get("/search", h.searchBooks)
-	booksGroup.Get("/:id", h.getBookById)
+	booksGroup.Get("/", authMiddleware, h.getAllBooks)
+	booksGroup.Get("/search", authMiddleware, h.searchBooks)
+	booksGroup.Get("/audit/copies", authMiddleware, h.auditBookCopyCounts)
+	booksGroup.Post("/audit/copies/repair", authMiddleware, requireAdmin, h.repairBookCopyCounts)
+	booksGroup.Get("/:id", authMiddleware, h.getBookById)
 	booksGroup.Post("/", authMiddleware, h.createBook)
 	booksGroup.Put("/:id", authMiddleware, h.updateBook)
-	booksGroup.Get("/:id/authors", h.getBookAuthors)
+	booksGroup.Get("/:id/authors", authMiddleware, h.getBookAuthors)
 	booksGroup.Post("/:id/authors", authMiddleware, h.addBookAuthor)
 	booksGroup.Delete("/:id/authors/:authorId", authMiddleware, h.removeBookAuthor)
+	booksGroup.Get("/:id/subjects", authMiddleware, h.getBookSubjects)
+	booksGroup.Post("/:id/subjects", authMiddleware, h.addBookSubject)
+	booksGroup.Delete("/:id/subjects/:subjectId", authMiddleware, h.removeBookSubject)
+	booksGroup.Get("/:id/hall-suggestions", authMiddleware, h.suggestHallsForBook)
+	booksGroup.Get("/:id/cover", h.getBookCover)
+	booksGroup.Get("/:id/attachments", h.getBookAttachments)
+	booksGroup.Get("/:id/attachments/:attachmentId/content", h.getBookAttachmentContent)
+	booksGroup.Delete("/:id/attachments/:attachmentId", authMiddleware, h.deleteBookAttachment)
 
 	// Book copies
 	copiesGroup := api.Group("/copies")
-	copiesGroup.Get("/book/:bookId", h.getBookCopiesByBookId)
-	copiesGroup.Get("/hall/:hallId", h.getBookCopiesByHall)
-	copiesGroup.Get("/code/:copyCode", h.getBookCopyByCode)
-	copiesGroup.Get("/:id", h.getBookCopyById)
+	copiesGroup.Get("/book/:bookId", authMiddleware, h.getBookCopiesByBookId)
+	copiesGroup.Get("/hall/:hallId", authMiddleware, h.getBookCopiesByHall)
+	copiesGroup.Get("/code/:copyCode", authMiddleware, h.getBookCopyByCode)
+	copiesGroup.Get("/:id", authMiddleware, h.getBookCopyById)
 	copiesGroup.Post("/", authMiddleware, h.createBookCopy)
+	copiesGroup.Post("/labels", authMiddleware, h.printCopyLabels)
 	copiesGroup.Put("/:id/status", authMiddleware, h.updateBookCopyStatus)
+	copiesGroup.Get("/:id/history", authMiddleware, h.getBookCopyHistory)
 
 	// Authors
 	authorsGroup := api.Group("/authors")
-	authorsGroup.Get("/", h.getAllAuthors)
-	authorsGroup.Get("/search", h.searchAuthors)
-	authorsGroup.Get("/:id", h.getAuthorById)
+	authorsGroup.Get("/", authMiddleware, h.getAllAuthors)
+	authorsGroup.Get("/search", authMiddleware, h.searchAuthors)
+	authorsGroup.Get("/duplicates", authMiddleware, h.findDuplicateAuthors)
+	authorsGroup.Get("/:id", authMiddleware, h.getAuthorById)
 	authorsGroup.Post("/", authMiddleware, h.createAuthor)
-	authorsGroup.Get("/:id/books", h.getAuthorBooks)
+	authorsGroup.Put("/:id", authMiddleware, h.updateAuthor)
+	authorsGroup.Delete("/:id", authMiddleware, h.deleteAuthor)
+	authorsGroup.Get("/:id/books", authMiddleware, h.getAuthorBooks)
+	authorsGroup.Post("/:id/variants", authMiddleware, h.addAuthorNameVariant)
+	authorsGroup.Delete("/:id/variants/:variantId", authMiddleware, h.removeAuthorNameVariant)
+	authorsGroup.Post("/:id/merge", authMiddleware, requireAdmin, h.mergeAuthors)
+
+	// Works group editions and translations of one text; series hold numbered volumes
+	worksGroup := api.Group("/works")
+	worksGroup.Get("/", authMiddleware, h.searchWorks)
+	worksGroup.Get("/:id", authMiddleware, h.getWorkById)
+	worksGroup.Post("/", authMiddleware, h.createWork)
+	worksGroup.Put("/:id", authMiddleware, h.updateWork)
+	worksGroup.Delete("/:id", authMiddleware, h.deleteWork)
+
+	seriesGroup := api.Group("/series")
+	seriesGroup.Get("/", h.searchSeries)
+	seriesGroup.Get("/:id", h.getSeriesById)
+	seriesGroup.Post("/", authMiddleware, h.createSeries)
+	seriesGroup.Put("/:id", authMiddleware, h.updateSeries)
+	seriesGroup.Delete("/:id", authMiddleware, h.deleteSeries)
+
+	// Subjects and classification schemes
+	subjectsGroup := api.Group("/subjects")
+	subjectsGroup.Get("/", h.searchSubjects)
+	subjectsGroup.Get("/tree", h.getSubjectTree)
+	subjectsGroup.Get("/:id", h.getSubjectById)
+	subjectsGroup.Post("/", authMiddleware, h.createSubject)
+	subjectsGroup.Put("/:id", authMiddleware, h.updateSubject)
+	subjectsGroup.Delete("/:id", authMiddleware, h.deleteSubject)
 
 	// Readers
 	readersGroup := api.Group("/readers")
 	readersGroup.Get("/", authMiddleware, h.getActiveReaders)
 	readersGroup.Get("/search", authMiddleware, h.searchReaders)
+	readersGroup.Get("/memberships/categories", authMiddleware, h.getMembershipCategories)
+	readersGroup.Get("/memberships/expiring", authMiddleware, h.getExpiringMemberships)
+	readersGroup.Get("/duplicates", authMiddleware, h.findDuplicateReaders)
 	readersGroup.Get("/:id", authMiddleware, h.getReaderById)
 	readersGroup.Get("/ticket/:ticketNumber", authMiddleware, h.getReaderByTicketNumber)
 	readersGroup.Post("/", authMiddleware, h.createReader)
@@ -85,6 +177,19 @@ func (h *Handler) Router() *fiber.App {
 	readersGroup.Get("/:id/books", authMiddleware, h.getReaderActiveBooks)
 	readersGroup.Get("/:id/fines", authMiddleware, h.getReaderFines)
 	readersGroup.Get("/:id/visits", authMiddleware, h.getReaderVisitHistory)
+	readersGroup.Get("/:id/bookings", authMiddleware, h.getReaderBookings)
+	readersGroup.Get("/:id/card", authMiddleware, h.printReaderCard)
+	readersGroup.Post("/:id/card/reissue", authMiddleware, h.reissueReaderCard)
+	readersGroup.Get("/:id/old-tickets", authMiddleware, h.getReaderOldTickets)
+	readersGroup.Post("/:id/membership/renew", authMiddleware, h.renewMembership)
+	readersGroup.Post("/:id/merge", authMiddleware, requireAdmin, h.mergeReaders)
+	readersGroup.Get("/:id/merges", authMiddleware, h.getReaderMerges)
+	readersGroup.Get("/:id/export", authMiddleware, h.exportReader)
+	readersGroup.Post("/:id/anonymize", authMiddleware, requireAdmin, h.anonymizeReader)
+	readersGroup.Post("/:id/portal-pin", authMiddleware, h.setReaderPin)
+	readersGroup.Get("/:id/notifications", authMiddleware, h.getReaderNotifications)
+	readersGroup.Get("/:id/notification-preferences", authMiddleware, h.getNotificationPreferences)
+	readersGroup.Put("/:id/notification-preferences", authMiddleware, h.updateNotificationPreferences)
 
 	// Book issues
 	issuesGroup := api.Group("/issues")
@@ -103,6 +208,27 @@ func (h *Handler) Router() *fiber.App {
 	hallsGroup.Put("/:id", authMiddleware, h.updateReadingHall)
 	hallsGroup.Get("/:id/visits/stats/daily", authMiddleware, h.getDailyVisitStats)
 	hallsGroup.Get("/:id/visits/stats/hourly", authMiddleware, h.getHourlyVisitStats)
+	hallsGroup.Get("/:id/seats", h.getHallSeats)
+	hallsGroup.Post("/:id/seats", authMiddleware, h.createHallSeats)
+	hallsGroup.Put("/:id/seats/:seatId", authMiddleware, h.updateHallSeat)
+	hallsGroup.Get("/:id/bookings", authMiddleware, h.getHallBookings)
+
+	// Hall inventories: staff scan shelves, administrators start, close and settle them
+	inventoryGroup := api.Group("/inventory", authMiddleware)
+	inventoryGroup.Get("/", h.getInventorySessions)
+	inventoryGroup.Post("/", requireAdmin, h.startInventorySession)
+	inventoryGroup.Get("/:id", h.getInventorySessionById)
+	inventoryGroup.Post("/:id/scans", h.recordInventoryScans)
+	inventoryGroup.Get("/:id/report", h.getInventoryReport)
+	inventoryGroup.Post("/:id/close", requireAdmin, h.closeInventorySession)
+	inventoryGroup.Post("/:id/cancel", requireAdmin, h.cancelInventorySession)
+	inventoryGroup.Post("/:id/mark-lost", requireAdmin, h.markInventoryMissingLost)
+
+	// Seat bookings
+	bookingsGroup := api.Group("/bookings")
+	bookingsGroup.Get("/:id", authMiddleware, h.getSeatBookingById)
+	bookingsGroup.Post("/", authMiddleware, h.createSeatBooking)
+	bookingsGroup.Post("/:id/cancel", authMiddleware, h.cancelSeatBooking)
 
 	// Hall visits
 	visitsGroup := api.Group("/visits")
@@ -110,12 +236,44 @@ func (h *Handler) Router() *fiber.App {
 	visitsGroup.Post("/entry", authMiddleware, h.registerHallEntry)
 	visitsGroup.Post("/exit", authMiddleware, h.registerHallExit)
 
+	// Visit analytics
+	analyticsGroup := api.Group("/analytics")
+	analyticsGroup.Get("/halls/compare", authMiddleware, h.compareHallsVisits)
+	analyticsGroup.Get("/halls/:id/dwell", authMiddleware, h.getHallDwellStats)
+	analyticsGroup.Get("/halls/:id/heatmap", authMiddleware, h.getHallVisitHeatmap)
+	analyticsGroup.Get("/halls/:id/occupancy", authMiddleware, h.getHallOccupancySeries)
+	analyticsGroup.Get("/halls/:id/visitors", authMiddleware, h.getHallVisitorSplit)
+	analyticsGroup.Post("/refresh", authMiddleware, requireAdmin, h.refreshVisitAnalytics)
+
+	// Real-time events
+	eventsGroup := api.Group("/events")
+	eventsGroup.Get("/stream", authMiddleware, h.streamEvents)
+
 	// Fines
 	finesGroup := api.Group("/fines")
 	finesGroup.Get("/unpaid", authMiddleware, h.getUnpaidFines)
 	finesGroup.Post("/", authMiddleware, h.createFine)
 	finesGroup.Post("/:id/pay", authMiddleware, h.payFine)
 
+	// Outgoing webhooks: subscriptions carry signing secrets and delivery logs contain reader data,
+	// so everything but the event list is for administrators
+	webhooksGroup := api.Group("/webhooks", authMiddleware)
+	webhooksGroup.Get("/events", h.getWebhookEvents)
+	webhooksGroup.Get("/", requireAdmin, h.getWebhooks)
+	webhooksGroup.Post("/", requireAdmin, h.createWebhook)
+	webhooksGroup.Get("/deliveries/:deliveryId", requireAdmin, h.getWebhookDelivery)
+	webhooksGroup.Post("/deliveries/:deliveryId/retry", requireAdmin, h.retryWebhookDelivery)
+	webhooksGroup.Get("/:id", requireAdmin, h.getWebhook)
+	webhooksGroup.Put("/:id", requireAdmin, h.updateWebhook)
+	webhooksGroup.Delete("/:id", requireAdmin, h.deleteWebhook)
+	webhooksGroup.Post("/:id/rotate-secret", requireAdmin, h.rotateWebhookSecret)
+	webhooksGroup.Get("/:id/deliveries", requireAdmin, h.getWebhookDeliveries)
+
+	// Interlibrary loan partners, authenticated by their own bearer tokens
+	if h.ncip != nil {
+		api.Post("/ncip", h.ncipMessage)
+	}
+
 	// Users
 	usersGroup := api.Group("/users")
 	usersGroup.Get("/", authMiddleware, h.getAllUsers)
@@ -124,6 +282,40 @@ func (h *Handler) Router() *fiber.App {
 	usersGroup.Put("/:id", authMiddleware, h.updateUser)
 	usersGroup.Delete("/:id", authMiddleware, h.deactivateUser)
 
+	// Public catalogue: public fields only, cached and rate limited per client IP
+	opacGroup := api.Group("/opac", middleware.NewRateLimitMiddleware(h.repo, "opac", h.cfg.Opac.RateLimit, h.cfg.Opac.RateWindow))
+	opacGroup.Get("/books", h.opacSearchBooks)
+	opacGroup.Get("/books/:id", h.opacGetBook)
+	opacGroup.Get("/books/:id/cover", h.getBookCover)
+	opacGroup.Get("/authors", h.opacSearchAuthors)
+	opacGroup.Get("/authors/:id/books", h.opacGetAuthorBooks)
+	opacGroup.Get("/subjects/tree", h.opacGetSubjectTree)
+	opacGroup.Get("/sru", h.sruRequest)
+	if h.oai != nil {
+		opacGroup.Get("/oai", h.oaiRequest)
+		opacGroup.Post("/oai", h.oaiRequest)
+	}
+
+	// Reader self-service portal, authenticated separately from staff
+	readerAuthMiddleware := middleware.NewReaderAuthMiddleware(h.repo, h.cfg.Portal.SessionTTL)
+
+	// Sign-in endpoints are public, so guessing PINs and magic link tokens is limited per client IP
+	portalAuthLimit := middleware.NewRateLimitMiddleware(h.repo, "portal-auth", h.cfg.Portal.AuthRateLimit, h.cfg.Portal.AuthRateWindow)
+
+	portalGroup := api.Group("/portal")
+	portalGroup.Post("/auth/login", portalAuthLimit, h.portalLogin)
+	portalGroup.Post("/auth/magic-link", portalAuthLimit, h.portalRequestMagicLink)
+	portalGroup.Post("/auth/magic-link/verify", portalAuthLimit, h.portalVerifyMagicLink)
+	portalGroup.Post("/auth/logout", readerAuthMiddleware, h.portalLogout)
+	portalGroup.Get("/me", readerAuthMiddleware, h.portalMe)
+	portalGroup.Put("/me/contacts", readerAuthMiddleware, h.portalUpdateContacts)
+	portalGroup.Get("/loans", readerAuthMiddleware, h.portalLoans)
+	portalGroup.Post("/loans/:id/renew", readerAuthMiddleware, h.portalRenewLoan)
+	portalGroup.Get("/fines", readerAuthMiddleware, h.portalFines)
+	portalGroup.Get("/visits", readerAuthMiddleware, h.portalVisits)
+	portalGroup.Get("/notification-preferences", readerAuthMiddleware, h.portalGetNotificationPreferences)
+	portalGroup.Put("/notification-preferences", readerAuthMiddleware, h.portalUpdateNotificationPreferences)
+
 	return app
 }
 
@@ -0,0 +1,271 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hnnsly/library-console/internal/repository"
+	"github.com/hnnsly/library-console/internal/repository/postgres"
+	httperr "github.com/hnnsly/library-console/pkg/error"
+	"github.com/rs/zerolog/log"
+)
+
+// maxScansPerRequest caps one scanner batch; handheld scanners flush far fewer codes at a time
+const maxScansPerRequest = 500
+
+type StartInventoryRequest struct {
+	HallID string  `json:"hall_id" validate:"required"`
+	Note   *string `json:"note" validate:"omitempty,max=500"`
+}
+
+type InventoryScansRequest struct {
+	CopyCodes []string `json:"copy_codes" validate:"required,min=1"`
+}
+
+type InventoryScansResponse struct {
+	Scans []repository.InventoryScan `json:"scans"`
+	// Counts per outcome, so a scanner client can beep on anything but found without walking the list
+	Counts map[repository.ScanOutcome]int `json:"counts"`
+}
+
+type MarkInventoryLostRequest struct {
+	// CopyIDs limits the marking to these missing copies; empty marks every missing copy not marked yet
+	CopyIDs []string `json:"copy_ids"`
+}
+
+func parseInventoryID(c *fiber.Ctx) (uuid.UUID, error) {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return uuid.Nil, httperr.New(fiber.StatusBadRequest, "Invalid inventory ID format")
+	}
+	return id, nil
+}
+
+func (h *Handler) getInventorySessions(c *fiber.Ctx) error {
+	hallID, err := parseIDFilter(c, "hall_id")
+	if err != nil {
+		return err
+	}
+	status := c.Query("status")
+	switch postgres.InventorySessionStatus(status) {
+	case "", postgres.InventorySessionStatusOpen, postgres.InventorySessionStatusClosed, postgres.InventorySessionStatusCancelled:
+	default:
+		return httperr.New(fiber.StatusBadRequest, "Invalid status, expected open, closed or cancelled")
+	}
+
+	sessions, err := h.repo.GetInventorySessions(c.Context(), postgres.GetInventorySessionsParams{
+		HallID: hallID,
+		Status: status,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get inventory sessions")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve inventories")
+	}
+
+	return c.JSON(sessions)
+}
+
+func (h *Handler) startInventorySession(c *fiber.Ctx) error {
+	var req StartInventoryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid request body", err.Error())
+	}
+	hallID, err := uuid.Parse(req.HallID)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid hall ID format")
+	}
+
+	startedBy, err := sessionUserID(c)
+	if err != nil {
+		return err
+	}
+
+	session, err := h.repo.CreateInventorySession(c.Context(), postgres.CreateInventorySessionParams{
+		HallID:    hallID,
+		Note:      trimToNil(req.Note),
+		StartedBy: &startedBy,
+	})
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "duplicate"):
+			return httperr.New(fiber.StatusConflict, "Hall already has an open inventory")
+		case strings.Contains(err.Error(), "violates foreign key"):
+			return httperr.New(fiber.StatusNotFound, "Reading hall not found")
+		}
+		log.Error().Err(err).Str("hallID", req.HallID).Msg("Failed to start inventory")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to start inventory")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(session)
+}
+
+func (h *Handler) getInventorySessionById(c *fiber.Ctx) error {
+	id, err := parseInventoryID(c)
+	if err != nil {
+		return err
+	}
+
+	session, err := h.repo.GetInventorySessionById(c.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return httperr.New(fiber.StatusNotFound, "Inventory not found")
+		}
+		log.Error().Err(err).Str("inventoryID", id.String()).Msg("Failed to get inventory")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve inventory")
+	}
+
+	return c.JSON(session)
+}
+
+// recordInventoryScans accepts a batch of scanned copy codes and reports for each whether it belongs on this shelf
+func (h *Handler) recordInventoryScans(c *fiber.Ctx) error {
+	id, err := parseInventoryID(c)
+	if err != nil {
+		return err
+	}
+
+	var req InventoryScansRequest
+	if err := c.BodyParser(&req); err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid request body", err.Error())
+	}
+	codes := make([]string, 0, len(req.CopyCodes))
+	for _, code := range req.CopyCodes {
+		if code = strings.TrimSpace(code); code != "" {
+			codes = append(codes, code)
+		}
+	}
+	if len(codes) == 0 {
+		return httperr.New(fiber.StatusBadRequest, "At least one copy code is required")
+	}
+	if len(codes) > maxScansPerRequest {
+		return httperr.New(fiber.StatusBadRequest, "Too many copy codes in one request")
+	}
+
+	scannedBy, err := sessionUserID(c)
+	if err != nil {
+		return err
+	}
+
+	scans, err := h.repo.RecordInventoryScans(c.Context(), id, codes, &scannedBy)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrInventoryNotOpen):
+			return httperr.New(fiber.StatusConflict, "Inventory is not open")
+		case strings.Contains(err.Error(), "no rows in result set"):
+			return httperr.New(fiber.StatusNotFound, "Inventory not found")
+		}
+		log.Error().Err(err).Str("inventoryID", id.String()).Msg("Failed to record inventory scans")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to record inventory scans")
+	}
+
+	counts := map[repository.ScanOutcome]int{}
+	for _, scan := range scans {
+		counts[scan.Outcome]++
+	}
+
+	return c.JSON(InventoryScansResponse{Scans: scans, Counts: counts})
+}
+
+// getInventoryReport lists missing, unexpected and loan-mismatched copies; while the inventory is open
+// the missing list is provisional and follows the scans
+func (h *Handler) getInventoryReport(c *fiber.Ctx) error {
+	id, err := parseInventoryID(c)
+	if err != nil {
+		return err
+	}
+
+	report, err := h.repo.InventoryReport(c.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return httperr.New(fiber.StatusNotFound, "Inventory not found")
+		}
+		log.Error().Err(err).Str("inventoryID", id.String()).Msg("Failed to build inventory report")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to build inventory report")
+	}
+
+	return c.JSON(report)
+}
+
+func (h *Handler) closeInventorySession(c *fiber.Ctx) error {
+	return h.finishInventorySession(c, h.repo.CloseInventory, "close")
+}
+
+func (h *Handler) cancelInventorySession(c *fiber.Ctx) error {
+	return h.finishInventorySession(c, h.repo.CancelInventory, "cancel")
+}
+
+func (h *Handler) finishInventorySession(c *fiber.Ctx, finish func(ctx context.Context, id uuid.UUID, closedBy *uuid.UUID) (*postgres.InventorySession, error), action string) error {
+	id, err := parseInventoryID(c)
+	if err != nil {
+		return err
+	}
+
+	closedBy, err := sessionUserID(c)
+	if err != nil {
+		return err
+	}
+
+	session, err := finish(c.Context(), id, &closedBy)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrInventoryNotOpen):
+			return httperr.New(fiber.StatusConflict, "Inventory is not open")
+		case strings.Contains(err.Error(), "no rows in result set"):
+			return httperr.New(fiber.StatusNotFound, "Inventory not found")
+		}
+		log.Error().Err(err).Str("inventoryID", id.String()).Msg("Failed to " + action + " inventory")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to "+action+" inventory")
+	}
+
+	return c.JSON(session)
+}
+
+// markInventoryMissingLost marks copies missing at close as lost; copies whose status changed since then
+// in a way that forbids the transition are reported as skipped
+func (h *Handler) markInventoryMissingLost(c *fiber.Ctx) error {
+	id, err := parseInventoryID(c)
+	if err != nil {
+		return err
+	}
+
+	var req MarkInventoryLostRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return httperr.New(fiber.StatusBadRequest, "Invalid request body", err.Error())
+		}
+	}
+	copyIDs := make([]uuid.UUID, 0, len(req.CopyIDs))
+	for _, idStr := range req.CopyIDs {
+		copyID, err := uuid.Parse(idStr)
+		if err != nil {
+			return httperr.New(fiber.StatusBadRequest, "Invalid copy ID format", idStr)
+		}
+		copyIDs = append(copyIDs, copyID)
+	}
+
+	markedBy, err := sessionUserID(c)
+	if err != nil {
+		return err
+	}
+
+	result, err := h.repo.MarkInventoryMissingLost(c.Context(), id, copyIDs, &markedBy)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrInventoryNotClosed):
+			return httperr.New(fiber.StatusConflict, "Copies can be marked lost only after the inventory is closed")
+		case strings.Contains(err.Error(), "no rows in result set"):
+			return httperr.New(fiber.StatusNotFound, "Inventory not found")
+		}
+		log.Error().Err(err).Str("inventoryID", id.String()).Msg("Failed to mark missing copies lost")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to mark missing copies lost")
+	}
+
+	if len(result.Marked) > 0 {
+		h.invalidateCatalog(c)
+	}
+
+	return c.JSON(result)
+}
@@ -1,12 +1,14 @@
 package handler
 
 import (
+	"errors"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/hnnsly/library-console/internal/circulation"
+	"github.com/hnnsly/library-console/internal/repository"
 	"github.com/hnnsly/library-console/internal/repository/postgres"
 	httperr "github.com/hnnsly/library-console/pkg/error"
 	"github.com/rs/zerolog/log"
@@ -15,7 +17,7 @@ import (
 type IssueBookRequest struct {
 	ReaderID string `json:"reader_id" validate:"required"`
 	CopyCode string `json:"copy_code" validate:"required"`
-	DueDays  int    `json:"due_days" validate:"required,min=1,max=365"`
+	DueDays  int    `json:"due_days" validate:"omitempty,min=1"` // defaults to the category loan period
 }
 
 type ReturnBookRequest struct {
@@ -82,64 +84,48 @@ func (h *Handler) issueBook(c *fiber.Ctx) error {
 		return httperr.New(fiber.StatusBadRequest, "Invalid reader ID format")
 	}
 
-	// Get book copy by code
-	bookCopy, err := h.repo.GetAvailableBookCopy(c.Context(), req.CopyCode)
+	librarianID, err := sessionUserID(c)
 	if err != nil {
-		if strings.Contains(err.Error(), "no rows in result set") {
-			return httperr.New(fiber.StatusNotFound, "Available book copy not found")
-		}
-		log.Error().Err(err).Str("copyCode", req.CopyCode).Msg("Failed to get available book copy")
-		return httperr.New(fiber.StatusInternalServerError, "Failed to check book availability")
-	}
-
-	// Check if reader has overdue books
-	overdueCount, err := h.repo.CheckReaderOverdueBooks(c.Context(), readerID)
-	if err != nil {
-		log.Error().Err(err).Str("readerID", req.ReaderID).Msg("Failed to check reader overdue books")
-		return httperr.New(fiber.StatusInternalServerError, "Failed to check reader status")
-	}
-	if overdueCount > 0 {
-		return httperr.New(fiber.StatusForbidden, "Reader has overdue books")
+		return err
 	}
 
-	// Get librarian ID from context
-	userIDStr, ok := c.Locals("userID").(string)
-	if !ok {
-		return httperr.New(fiber.StatusUnauthorized, "User ID not found in context")
-	}
-	librarianID, err := uuid.Parse(userIDStr)
-	if err != nil {
-		return httperr.New(fiber.StatusInternalServerError, "Invalid user ID format")
-	}
-
-	dueDate := time.Now().AddDate(0, 0, req.DueDays)
-
-	// Issue book
-	issue, err := h.repo.IssueBook(c.Context(), postgres.IssueBookParams{
+	// Membership, loan limits and overdue checks are shared with self-checkout kiosks
+	loan, err := h.desk.Checkout(c.Context(), circulation.Checkout{
 		ReaderID:    readerID,
-		BookCopyID:  bookCopy.ID,
-		DueDate:     dueDate,
+		CopyCode:    req.CopyCode,
+		DueDays:     req.DueDays,
 		LibrarianID: &librarianID,
+		Reason:      "Book issued to reader",
 	})
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to issue book")
+		var limit *circulation.LimitError
+		switch {
+		case errors.Is(err, circulation.ErrReaderNotFound):
+			return httperr.New(fiber.StatusNotFound, "Reader not found")
+		case errors.Is(err, circulation.ErrReaderInactive):
+			return httperr.New(fiber.StatusForbidden, "Reader is deactivated")
+		case errors.Is(err, circulation.ErrMembershipExpired):
+			return httperr.New(fiber.StatusForbidden, "Reader membership has expired")
+		case errors.As(err, &limit) && errors.Is(err, circulation.ErrLoanLimit):
+			return httperr.New(fiber.StatusForbidden, "Reader has reached the loan limit", fiber.Map{
+				"max_loans": limit.Limit,
+			})
+		case errors.As(err, &limit) && errors.Is(err, circulation.ErrLoanPeriod):
+			return httperr.New(fiber.StatusBadRequest, "Loan period exceeds the limit for the reader category", fiber.Map{
+				"max_due_days": limit.Limit,
+			})
+		case errors.Is(err, circulation.ErrReaderOverdue):
+			return httperr.New(fiber.StatusForbidden, "Reader has overdue books")
+		case errors.Is(err, circulation.ErrCopyReserved):
+			return httperr.New(fiber.StatusConflict, "Book copy is reserved for another reader")
+		case errors.Is(err, circulation.ErrCopyUnavailable):
+			return httperr.New(fiber.StatusNotFound, "Available book copy not found")
+		}
+		log.Error().Err(err).Str("readerID", req.ReaderID).Str("copyCode", req.CopyCode).Msg("Failed to issue book")
 		return httperr.New(fiber.StatusInternalServerError, "Failed to issue book")
 	}
 
-	// Update book copy status to issued
-	err = h.repo.UpdateBookCopyStatus(c.Context(), postgres.UpdateBookCopyStatusParams{
-		CopyID: bookCopy.ID,
-		Status: postgres.NullBookStatus{
-			BookStatus: postgres.BookStatusIssued,
-			Valid:      true,
-		},
-	})
-	if err != nil {
-		log.Error().Err(err).Str("copyID", bookCopy.ID.String()).Msg("Failed to update book copy status")
-		// Don't return error as the book was already issued
-	}
-
-	return c.Status(fiber.StatusCreated).JSON(issue)
+	return c.Status(fiber.StatusCreated).JSON(loan.Issue)
 }
 
 func (h *Handler) returnBook(c *fiber.Ctx) error {
@@ -148,38 +134,25 @@ func (h *Handler) returnBook(c *fiber.Ctx) error {
 		return httperr.New(fiber.StatusBadRequest, "Invalid request body", err.Error())
 	}
 
-	// Get book copy by code
-	bookCopy, err := h.repo.GetBookCopyByCode(c.Context(), req.CopyCode)
+	librarianID, err := sessionUserID(c)
 	if err != nil {
-		if strings.Contains(err.Error(), "no rows in result set") {
-			return httperr.New(fiber.StatusNotFound, "Book copy not found")
-		}
-		log.Error().Err(err).Str("copyCode", req.CopyCode).Msg("Failed to get book copy")
-		return httperr.New(fiber.StatusInternalServerError, "Failed to get book copy")
+		return err
 	}
 
-	// Return book
-	returnData, err := h.repo.ReturnBook(c.Context(), bookCopy.ID)
+	// Return book and make the copy available again
+	returned, err := h.desk.Checkin(c.Context(), req.CopyCode, &librarianID, "Book returned by reader")
 	if err != nil {
-		if strings.Contains(err.Error(), "no rows in result set") {
+		switch {
+		case errors.Is(err, circulation.ErrCopyNotFound):
+			return httperr.New(fiber.StatusNotFound, "Book copy not found")
+		case errors.Is(err, circulation.ErrNotCheckedOut):
 			return httperr.New(fiber.StatusNotFound, "No active issue found for this book copy")
+		case errors.Is(err, repository.ErrInvalidCopyTransition):
+			return httperr.New(fiber.StatusConflict, "Book copy status does not allow return", err.Error())
 		}
 		log.Error().Err(err).Str("copyCode", req.CopyCode).Msg("Failed to return book")
 		return httperr.New(fiber.StatusInternalServerError, "Failed to return book")
 	}
 
-	// Update book copy status to available
-	err = h.repo.UpdateBookCopyStatus(c.Context(), postgres.UpdateBookCopyStatusParams{
-		CopyID: bookCopy.ID,
-		Status: postgres.NullBookStatus{
-			BookStatus: postgres.BookStatusAvailable,
-			Valid:      true,
-		},
-	})
-	if err != nil {
-		log.Error().Err(err).Str("copyID", bookCopy.ID.String()).Msg("Failed to update book copy status")
-		// Don't return error as the book was already returned
-	}
-
-	return c.JSON(returnData)
+	return c.JSON(returned.Issue)
 }
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hnnsly/library-console/internal/labels"
+	"github.com/hnnsly/library-console/pkg/barcode"
+	httperr "github.com/hnnsly/library-console/pkg/error"
+	"github.com/rs/zerolog/log"
+)
+
+const maxLabelsPerRequest = 500
+
+type PrintLabelsRequest struct {
+	CopyIDs []string `json:"copy_ids" validate:"required,min=1"`
+	Type    string   `json:"type"`    // barcode (default) or spine
+	Format  string   `json:"format"`  // pdf (default) or svg
+	Barcode string   `json:"barcode"` // code128 (default) or ean13
+}
+
+func (h *Handler) printCopyLabels(c *fiber.Ctx) error {
+	var req PrintLabelsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid request body", err.Error())
+	}
+
+	if len(req.CopyIDs) == 0 {
+		return httperr.New(fiber.StatusBadRequest, "At least one copy ID is required")
+	}
+	if len(req.CopyIDs) > maxLabelsPerRequest {
+		return httperr.New(fiber.StatusBadRequest, "Too many copies in one request")
+	}
+
+	copyIds := make([]uuid.UUID, 0, len(req.CopyIDs))
+	for _, idStr := range req.CopyIDs {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			return httperr.New(fiber.StatusBadRequest, "Invalid copy ID format", idStr)
+		}
+		copyIds = append(copyIds, id)
+	}
+
+	kind := labels.Kind(valueOr(req.Type, string(labels.KindBarcode)))
+	if kind != labels.KindBarcode && kind != labels.KindSpine {
+		return httperr.New(fiber.StatusBadRequest, "Invalid label type, use barcode or spine")
+	}
+	format := labels.Format(valueOr(req.Format, string(labels.FormatPDF)))
+	if format != labels.FormatPDF && format != labels.FormatSVG {
+		return httperr.New(fiber.StatusBadRequest, "Invalid label format, use pdf or svg")
+	}
+	symbology := barcode.Symbology(valueOr(req.Barcode, string(barcode.Code128)))
+	if symbology != barcode.Code128 && symbology != barcode.EAN13 {
+		return httperr.New(fiber.StatusBadRequest, "Invalid barcode type, use code128 or ean13")
+	}
+
+	copies, err := h.repo.GetBookCopiesForLabels(c.Context(), copyIds)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get book copies for labels")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve book copies")
+	}
+	if len(copies) == 0 {
+		return httperr.New(fiber.StatusNotFound, "Book copies not found")
+	}
+
+	items := make([]labels.Label, len(copies))
+	for i, copy := range copies {
+		items[i] = labels.Label{
+			Code:       copy.CopyCode,
+			Title:      copy.Title,
+			CallNumber: derefString(copy.LocationInfo),
+			Hall:       derefString(copy.HallName),
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := labels.Render(&buf, format, kind, symbology, items); err != nil {
+		if errors.Is(err, barcode.ErrInvalidData) {
+			return httperr.New(fiber.StatusUnprocessableEntity, "Copy code cannot be encoded with this barcode type", err.Error())
+		}
+		log.Error().Err(err).Msg("Failed to render copy labels")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to render labels")
+	}
+
+	if format == labels.FormatSVG {
+		c.Set(fiber.HeaderContentType, "image/svg+xml")
+	} else {
+		c.Set(fiber.HeaderContentType, "application/pdf")
+	}
+	c.Set(fiber.HeaderContentDisposition, `inline; filename="labels.`+string(format)+`"`)
+
+	return c.Send(buf.Bytes())
+}
+
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hnnsly/library-console/internal/repository/postgres"
+	httperr "github.com/hnnsly/library-console/pkg/error"
+	"github.com/rs/zerolog/log"
+)
+
+type RenewMembershipRequest struct {
+	Category string `json:"category"` // keeps the current category when empty
+	Months   int    `json:"months"`   // defaults to the category membership duration
+}
+
+// membershipExpiry returns the expiry date of a membership started today
+func (h *Handler) membershipExpiry(category postgres.ReaderCategory) *time.Time {
+	policy, _ := h.desk.Policy(category)
+	expires := time.Now().AddDate(0, policy.Months, 0)
+	return &expires
+}
+
+func (h *Handler) getMembershipCategories(c *fiber.Ctx) error {
+	return c.JSON(h.cfg.Memberships)
+}
+
+func (h *Handler) renewMembership(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid reader ID format")
+	}
+
+	var req RenewMembershipRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return httperr.New(fiber.StatusBadRequest, "Invalid request body", err.Error())
+		}
+	}
+
+	reader, err := h.repo.GetReaderById(c.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return httperr.New(fiber.StatusNotFound, "Reader not found")
+		}
+		log.Error().Err(err).Str("readerID", idStr).Msg("Failed to get reader")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve reader")
+	}
+	if reader.IsActive != nil && !*reader.IsActive {
+		return httperr.New(fiber.StatusForbidden, "Reader is deactivated")
+	}
+
+	category := reader.Category
+	if req.Category != "" {
+		category = postgres.ReaderCategory(req.Category)
+	}
+	policy, ok := h.desk.Policy(category)
+	if !ok {
+		return httperr.New(fiber.StatusBadRequest, "Invalid membership category")
+	}
+
+	months := req.Months
+	if months == 0 {
+		months = policy.Months
+	}
+	if months < 1 || months > 60 {
+		return httperr.New(fiber.StatusBadRequest, "Months must be between 1 and 60")
+	}
+
+	// The new term starts at the current expiry date, so early renewals do not lose days
+	renewed, err := h.repo.RenewReaderMembership(c.Context(), postgres.RenewReaderMembershipParams{
+		Category: category,
+		Months:   months,
+		ID:       id,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("readerID", idStr).Msg("Failed to renew membership")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to renew membership")
+	}
+
+	return c.JSON(renewed)
+}
+
+func (h *Handler) getExpiringMemberships(c *fiber.Ctx) error {
+	days, err := strconv.Atoi(c.Query("days", "30"))
+	if err != nil || days < 0 || days > 366 {
+		return httperr.New(fiber.StatusBadRequest, "Invalid days parameter")
+	}
+
+	readers, err := h.repo.GetExpiringMemberships(c.Context(), time.Now().AddDate(0, 0, days))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get expiring memberships")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve expiring memberships")
+	}
+
+	return c.JSON(readers)
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hnnsly/library-console/internal/ncip"
+	"github.com/rs/zerolog/log"
+)
+
+// ncipMessage handles NCIP requests from partner libraries. Partners authenticate with a bearer
+// token from the configuration; NCIP problems are returned in the XML body, so every parsed
+// request is answered with 200 as the protocol expects
+func (h *Handler) ncipMessage(c *fiber.Ctx) error {
+	token, _ := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+	partner, ok := h.ncip.Partner(strings.TrimSpace(token))
+	if !ok {
+		return h.ncipReply(c, fiber.StatusUnauthorized, ncip.UnauthorizedMessage())
+	}
+
+	req, err := ncip.Decode(c.Body())
+	if err != nil {
+		return h.ncipReply(c, fiber.StatusBadRequest, ncip.InvalidMessage(err))
+	}
+
+	return h.ncipReply(c, fiber.StatusOK, h.ncip.Respond(c.Context(), partner, req))
+}
+
+func (h *Handler) ncipReply(c *fiber.Ctx, status int, m *ncip.Message) error {
+	body, err := ncip.Encode(m)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to encode NCIP response")
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationXMLCharsetUTF8)
+	return c.Status(status).Send(body)
+}
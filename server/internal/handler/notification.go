@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hnnsly/library-console/internal/notify"
+	"github.com/hnnsly/library-console/internal/repository"
+	"github.com/hnnsly/library-console/internal/repository/postgres"
+	httperr "github.com/hnnsly/library-console/pkg/error"
+	"github.com/rs/zerolog/log"
+)
+
+type NotificationPreferencesRequest struct {
+	Language string   `json:"language"` // ru or en, unchanged when empty
+	Channel  string   `json:"channel"`  // email, sms or empty for email with SMS fallback
+	OptOuts  []string `json:"opt_outs"` // kinds the reader does not want: due_soon, overdue, hold_available
+}
+
+func (h *Handler) getReaderNotifications(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid reader ID format")
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", "50"))
+	if err != nil || limit <= 0 || limit > 500 {
+		return httperr.New(fiber.StatusBadRequest, "Invalid limit parameter")
+	}
+
+	notifications, err := h.repo.GetReaderNotifications(c.Context(), postgres.GetReaderNotificationsParams{
+		ReaderID:   id,
+		LimitCount: int32(limit),
+	})
+	if err != nil {
+		log.Error().Err(err).Str("readerID", idStr).Msg("Failed to get reader notifications")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve notifications")
+	}
+
+	return c.JSON(notifications)
+}
+
+func (h *Handler) getNotificationPreferences(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid reader ID format")
+	}
+
+	return h.notificationPreferences(c, id)
+}
+
+func (h *Handler) updateNotificationPreferences(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid reader ID format")
+	}
+
+	return h.saveNotificationPreferences(c, id)
+}
+
+func (h *Handler) portalGetNotificationPreferences(c *fiber.Ctx) error {
+	readerID, err := portalReaderID(c)
+	if err != nil {
+		return err
+	}
+
+	return h.notificationPreferences(c, readerID)
+}
+
+func (h *Handler) portalUpdateNotificationPreferences(c *fiber.Ctx) error {
+	readerID, err := portalReaderID(c)
+	if err != nil {
+		return err
+	}
+
+	return h.saveNotificationPreferences(c, readerID)
+}
+
+func (h *Handler) notificationPreferences(c *fiber.Ctx, readerID uuid.UUID) error {
+	prefs, err := h.repo.GetNotificationPreferences(c.Context(), readerID)
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return httperr.New(fiber.StatusNotFound, "Reader not found")
+		}
+		log.Error().Err(err).Str("readerID", readerID.String()).Msg("Failed to get notification preferences")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve notification preferences")
+	}
+
+	return c.JSON(prefs)
+}
+
+// saveNotificationPreferences replaces the reader's language and opt-out list
+func (h *Handler) saveNotificationPreferences(c *fiber.Ctx, readerID uuid.UUID) error {
+	var req NotificationPreferencesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid request body", err.Error())
+	}
+
+	current, err := h.repo.GetNotificationPreferences(c.Context(), readerID)
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return httperr.New(fiber.StatusNotFound, "Reader not found")
+		}
+		log.Error().Err(err).Str("readerID", readerID.String()).Msg("Failed to get notification preferences")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to update notification preferences")
+	}
+
+	prefs := repository.NotificationPreferences{
+		Language: valueOr(req.Language, current.Language),
+		OptOuts:  make([]postgres.NotificationKind, 0, len(req.OptOuts)),
+	}
+	if !notify.SupportedLanguage(prefs.Language) {
+		return httperr.New(fiber.StatusBadRequest, "Unsupported language, use ru or en")
+	}
+	if req.Channel != "" {
+		channel := postgres.NotificationChannel(req.Channel)
+		if channel != postgres.NotificationChannelEmail && channel != postgres.NotificationChannelSms {
+			return httperr.New(fiber.StatusBadRequest, "Invalid notification channel, use email or sms")
+		}
+		prefs.Channel = &channel
+	}
+	for _, kind := range req.OptOuts {
+		k := postgres.NotificationKind(kind)
+		if k != postgres.NotificationKindDueSoon && k != postgres.NotificationKindOverdue && k != postgres.NotificationKindHoldAvailable {
+			return httperr.New(fiber.StatusBadRequest, "Invalid notification kind", kind)
+		}
+		prefs.OptOuts = append(prefs.OptOuts, k)
+	}
+
+	if err := h.repo.SetNotificationPreferences(c.Context(), readerID, prefs); err != nil {
+		log.Error().Err(err).Str("readerID", readerID.String()).Msg("Failed to update notification preferences")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to update notification preferences")
+	}
+
+	return c.JSON(prefs)
+}
+
+// notifyHoldAvailable queues the "ready for pickup" message for a copy reserved for a reader.
+// It reports false when nothing was queued: no email or phone, an opt-out or the event was already sent.
+func (h *Handler) notifyHoldAvailable(c *fiber.Ctx, readerID, copyID uuid.UUID) bool {
+	pickupUntil := time.Now().AddDate(0, 0, h.cfg.Notifications.HoldPickupDays)
+
+	_, err := h.repo.CreateHoldAvailableNotification(c.Context(), postgres.CreateHoldAvailableNotificationParams{
+		PickupUntil: pickupUntil,
+		ReaderID:    readerID,
+		CopyID:      copyID,
+		Channels:    h.notifier.Channels(),
+	})
+	if err != nil {
+		if !strings.Contains(err.Error(), "no rows in result set") {
+			log.Error().Err(err).Str("readerID", readerID.String()).Str("copyID", copyID.String()).
+				Msg("Failed to queue hold available notification")
+		}
+		return false
+	}
+
+	return true
+}
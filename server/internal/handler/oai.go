@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"net/url"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+)
+
+// oaiRequest handles OAI-PMH requests sent as GET query strings or url-encoded POST bodies.
+// Protocol errors are part of the XML body, so every answered request gets 200
+func (h *Handler) oaiRequest(c *fiber.Ctx) error {
+	source := c.Context().QueryArgs()
+	if c.Method() == fiber.MethodPost {
+		source = c.Context().PostArgs()
+	}
+	args := url.Values{}
+	source.VisitAll(func(key, value []byte) {
+		args.Add(string(key), string(value))
+	})
+
+	body, err := h.oai.Handle(c.Context(), args, c.BaseURL()+c.Path())
+	if err != nil {
+		log.Error().Err(err).Str("verb", args.Get("verb")).Msg("Failed to answer OAI-PMH request")
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMETextXMLCharsetUTF8)
+	return c.Send(body)
+}
@@ -0,0 +1,275 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hnnsly/library-console/internal/repository/postgres"
+	httperr "github.com/hnnsly/library-console/pkg/error"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	opacDefaultPageSize = 20
+	opacMaxPageSize     = 100
+)
+
+type OpacPage struct {
+	Items    any   `json:"items"`
+	Total    int64 `json:"total"`
+	Page     int   `json:"page"`
+	PageSize int   `json:"page_size"`
+}
+
+type OpacBook struct {
+	*postgres.GetPublicBookRow
+	Contributors []BookContributorResponse                `json:"contributors"`
+	Editions     []*postgres.GetPublicWorkEditionsRow     `json:"editions"`
+	Availability []*postgres.GetPublicBookAvailabilityRow `json:"availability"`
+}
+
+type OpacBookSummary struct {
+	*postgres.SearchPublicBooksRow
+	Contributors []BookContributorResponse `json:"contributors"`
+}
+
+// opacPaging parses page and page_size query parameters
+func opacPaging(c *fiber.Ctx) (page, pageSize int, err error) {
+	page, err = strconv.Atoi(c.Query("page", "1"))
+	if err != nil || page < 1 {
+		return 0, 0, httperr.New(fiber.StatusBadRequest, "Invalid page parameter")
+	}
+	pageSize, err = strconv.Atoi(c.Query("page_size", strconv.Itoa(opacDefaultPageSize)))
+	if err != nil || pageSize < 1 || pageSize > opacMaxPageSize {
+		return 0, 0, httperr.New(fiber.StatusBadRequest, fmt.Sprintf("Page size must be between 1 and %d", opacMaxPageSize))
+	}
+	return page, pageSize, nil
+}
+
+// cachedJSON serves a public catalogue response from the Redis cache, building it on a miss.
+// Responses carry an ETag, so clients revalidating an unchanged result get 304 without a body.
+func (h *Handler) cachedJSON(c *fiber.Ctx, build func() (any, error)) error {
+	generation, err := h.repo.CatalogGeneration(c.Context())
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to get catalogue cache generation")
+	}
+
+	// Query parameters are re-encoded in sorted order, so their order does not split the cache
+	query, _ := url.ParseQuery(string(c.Request().URI().QueryString()))
+	key := fmt.Sprintf("%d:%s?%s", generation, c.Path(), query.Encode())
+
+	body, found, err := h.repo.GetCachedResponse(c.Context(), key)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to read catalogue response cache")
+	}
+	if !found {
+		data, err := build()
+		if err != nil {
+			return err
+		}
+		if body, err = json.Marshal(data); err != nil {
+			log.Error().Err(err).Msg("Failed to encode catalogue response")
+			return httperr.New(fiber.StatusInternalServerError, "Failed to encode response")
+		}
+		if err := h.repo.SetCachedResponse(c.Context(), key, body, h.cfg.Opac.CacheTTL); err != nil {
+			log.Warn().Err(err).Msg("Failed to store catalogue response in cache")
+		}
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Set(fiber.HeaderETag, etag)
+	c.Set(fiber.HeaderCacheControl, fmt.Sprintf("public, max-age=%d", int(h.cfg.Opac.MaxAge.Seconds())))
+	c.Set(fiber.HeaderVary, fiber.HeaderAcceptEncoding)
+
+	if etagMatches(c.Get(fiber.HeaderIfNoneMatch), etag) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(body)
+}
+
+// etagMatches reports whether an If-None-Match header value matches etag, ignoring weak prefixes
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// invalidateCatalog drops cached public catalogue responses after a write
+func (h *Handler) invalidateCatalog(c *fiber.Ctx) {
+	if err := h.repo.InvalidateCatalog(c.Context()); err != nil {
+		log.Warn().Err(err).Msg("Failed to invalidate catalogue cache")
+	}
+}
+
+func (h *Handler) opacSearchBooks(c *fiber.Ctx) error {
+	page, pageSize, err := opacPaging(c)
+	if err != nil {
+		return err
+	}
+
+	year := 0
+	if yearStr := c.Query("year"); yearStr != "" {
+		if year, err = strconv.Atoi(yearStr); err != nil {
+			return httperr.New(fiber.StatusBadRequest, "Invalid year parameter")
+		}
+	}
+
+	subjectID, err := parseIDFilter(c, "subject_id")
+	if err != nil {
+		return err
+	}
+	seriesID, err := parseIDFilter(c, "series_id")
+	if err != nil {
+		return err
+	}
+	language, err := parseLanguageFilter(c)
+	if err != nil {
+		return err
+	}
+
+	params := postgres.SearchPublicBooksParams{
+		Query:           strings.TrimSpace(c.Query("q")),
+		Author:          strings.TrimSpace(c.Query("author")),
+		PublicationYear: year,
+		AvailableOnly:   c.QueryBool("available"),
+		SubjectID:       subjectID,
+		Language:        language,
+		SeriesID:        seriesID,
+		LimitCount:      int32(pageSize),
+		OffsetCount:     int32((page - 1) * pageSize),
+	}
+
+	return h.cachedJSON(c, func() (any, error) {
+		books, err := h.repo.SearchPublicBooks(c.Context(), params)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to search public catalogue")
+			return nil, httperr.New(fiber.StatusInternalServerError, "Failed to search catalogue")
+		}
+
+		ids := make([]uuid.UUID, len(books))
+		for i, book := range books {
+			ids[i] = book.ID
+		}
+		contributors, err := h.bookContributors(c, ids)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to get public book contributors")
+			return nil, httperr.New(fiber.StatusInternalServerError, "Failed to search catalogue")
+		}
+
+		items := make([]OpacBookSummary, len(books))
+		var total int64
+		for i, book := range books {
+			items[i] = OpacBookSummary{SearchPublicBooksRow: book, Contributors: contributors[book.ID]}
+			total = book.TotalCount
+		}
+		return OpacPage{Items: items, Total: total, Page: page, PageSize: pageSize}, nil
+	})
+}
+
+func (h *Handler) opacGetBook(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid book ID format")
+	}
+
+	return h.cachedJSON(c, func() (any, error) {
+		book, err := h.repo.GetPublicBook(c.Context(), id)
+		if err != nil {
+			if strings.Contains(err.Error(), "no rows in result set") {
+				return nil, httperr.New(fiber.StatusNotFound, "Book not found")
+			}
+			log.Error().Err(err).Str("bookID", idStr).Msg("Failed to get public book")
+			return nil, httperr.New(fiber.StatusInternalServerError, "Failed to retrieve book")
+		}
+
+		availability, err := h.repo.GetPublicBookAvailability(c.Context(), id)
+		if err != nil {
+			log.Error().Err(err).Str("bookID", idStr).Msg("Failed to get book availability")
+			return nil, httperr.New(fiber.StatusInternalServerError, "Failed to retrieve book availability")
+		}
+
+		contributors, err := h.bookContributors(c, []uuid.UUID{id})
+		if err != nil {
+			log.Error().Err(err).Str("bookID", idStr).Msg("Failed to get public book contributors")
+			return nil, httperr.New(fiber.StatusInternalServerError, "Failed to retrieve book")
+		}
+
+		editions := []*postgres.GetPublicWorkEditionsRow{}
+		if book.WorkID != nil {
+			editions, err = h.repo.GetPublicWorkEditions(c.Context(), postgres.GetPublicWorkEditionsParams{
+				WorkID: *book.WorkID,
+				BookID: id,
+			})
+			if err != nil {
+				log.Error().Err(err).Str("bookID", idStr).Msg("Failed to get public work editions")
+				return nil, httperr.New(fiber.StatusInternalServerError, "Failed to retrieve book")
+			}
+		}
+
+		return OpacBook{
+			GetPublicBookRow: book,
+			Contributors:     contributors[id],
+			Editions:         editions,
+			Availability:     availability,
+		}, nil
+	})
+}
+
+func (h *Handler) opacSearchAuthors(c *fiber.Ctx) error {
+	page, pageSize, err := opacPaging(c)
+	if err != nil {
+		return err
+	}
+
+	params := postgres.SearchPublicAuthorsParams{
+		Query:       strings.TrimSpace(c.Query("q")),
+		LimitCount:  int32(pageSize),
+		OffsetCount: int32((page - 1) * pageSize),
+	}
+
+	return h.cachedJSON(c, func() (any, error) {
+		authors, err := h.repo.SearchPublicAuthors(c.Context(), params)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to search public authors")
+			return nil, httperr.New(fiber.StatusInternalServerError, "Failed to search authors")
+		}
+
+		var total int64
+		if len(authors) > 0 {
+			total = authors[0].TotalCount
+		}
+		return OpacPage{Items: authors, Total: total, Page: page, PageSize: pageSize}, nil
+	})
+}
+
+func (h *Handler) opacGetAuthorBooks(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid author ID format")
+	}
+
+	return h.cachedJSON(c, func() (any, error) {
+		books, err := h.repo.GetPublicAuthorBooks(c.Context(), id)
+		if err != nil {
+			log.Error().Err(err).Str("authorID", idStr).Msg("Failed to get public author books")
+			return nil, httperr.New(fiber.StatusInternalServerError, "Failed to retrieve author books")
+		}
+		return books, nil
+	})
+}
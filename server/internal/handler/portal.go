@@ -0,0 +1,474 @@
+package handler
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hnnsly/library-console/internal/circulation"
+	"github.com/hnnsly/library-console/internal/middleware"
+	"github.com/hnnsly/library-console/internal/repository"
+	"github.com/hnnsly/library-console/internal/repository/postgres"
+	httperr "github.com/hnnsly/library-console/pkg/error"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type PortalLoginRequest struct {
+	TicketNumber string `json:"ticket_number" validate:"required"`
+	Pin          string `json:"pin" validate:"required"`
+}
+
+type MagicLinkRequest struct {
+	TicketNumber string `json:"ticket_number" validate:"required"`
+}
+
+type MagicLinkVerifyRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type UpdateContactsRequest struct {
+	Email *string `json:"email"`
+	Phone *string `json:"phone"`
+}
+
+type SetReaderPinRequest struct {
+	Pin string `json:"pin"` // generated when empty
+}
+
+const (
+	minPinLength = 4
+	maxPinLength = 8
+	// loginFailureWindow is how long failed portal logins are remembered
+	loginFailureWindow = 15 * time.Minute
+)
+
+// portalReaderID returns the reader authenticated by the portal middleware
+func portalReaderID(c *fiber.Ctx) (uuid.UUID, error) {
+	readerIDStr, ok := c.Locals("readerID").(string)
+	if !ok {
+		return uuid.Nil, httperr.New(fiber.StatusUnauthorized, "Reader ID not found in context")
+	}
+	readerID, err := uuid.Parse(readerIDStr)
+	if err != nil {
+		return uuid.Nil, httperr.New(fiber.StatusInternalServerError, "Invalid reader ID format")
+	}
+	return readerID, nil
+}
+
+// startReaderSession creates a portal session and sets its cookie
+func (h *Handler) startReaderSession(c *fiber.Ctx, readerID uuid.UUID) error {
+	sessionToken, err := h.repo.CreateReaderSession(c.Context(), readerID, h.cfg.Portal.SessionTTL)
+	if err != nil {
+		log.Error().Err(err).Str("readerID", readerID.String()).Msg("Failed to create reader session")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to create session")
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     middleware.ReaderSessionCookie,
+		Value:    sessionToken,
+		Path:     "/api/library/portal",
+		Expires:  time.Now().Add(h.cfg.Portal.SessionTTL),
+		HTTPOnly: true,
+		Secure:   h.cfg.Portal.CookieSecure,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+
+	return c.JSON(fiber.Map{"token": sessionToken})
+}
+
+func (h *Handler) portalLogin(c *fiber.Ctx) error {
+	var req PortalLoginRequest
+	if err := c.BodyParser(&req); err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid request body", err.Error())
+	}
+	ticketNumber := strings.TrimSpace(req.TicketNumber)
+
+	failures, err := h.repo.PortalLoginFailures(c.Context(), ticketNumber)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to check portal login attempts")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to log in")
+	}
+	if failures >= int64(h.cfg.Portal.MaxLoginAttempts) {
+		return httperr.New(fiber.StatusTooManyRequests, "Too many failed login attempts, try again later")
+	}
+
+	creds, err := h.repo.GetReaderPortalCredentials(c.Context(), ticketNumber)
+	if err == nil && creds.PinHash != nil {
+		err = bcrypt.CompareHashAndPassword([]byte(*creds.PinHash), []byte(req.Pin))
+	} else if err == nil {
+		err = errors.New("portal PIN is not set")
+	}
+	if err != nil {
+		if _, ferr := h.repo.RegisterPortalLoginFailure(c.Context(), ticketNumber, loginFailureWindow); ferr != nil {
+			log.Warn().Err(ferr).Msg("Failed to register portal login failure")
+		}
+		log.Warn().Str("ticketNumber", ticketNumber).Msg("Failed portal login attempt")
+		return httperr.New(fiber.StatusUnauthorized, "Invalid ticket number or PIN")
+	}
+	if creds.IsActive != nil && !*creds.IsActive {
+		return httperr.New(fiber.StatusUnauthorized, "Reader account is deactivated")
+	}
+
+	if err := h.repo.ResetPortalLoginFailures(c.Context(), ticketNumber); err != nil {
+		log.Warn().Err(err).Msg("Failed to reset portal login failures")
+	}
+
+	return h.startReaderSession(c, creds.ID)
+}
+
+func (h *Handler) portalRequestMagicLink(c *fiber.Ctx) error {
+	var req MagicLinkRequest
+	if err := c.BodyParser(&req); err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid request body", err.Error())
+	}
+
+	// The response is the same whether or not the reader exists, so tickets cannot be probed
+	response := fiber.Map{"message": "If the reader has an email address on file, a login link has been sent"}
+
+	reader, err := h.repo.GetReaderByTicketNumber(c.Context(), strings.TrimSpace(req.TicketNumber))
+	if err != nil {
+		if !strings.Contains(err.Error(), "no rows in result set") {
+			log.Error().Err(err).Msg("Failed to get reader for magic link")
+		}
+		return c.Status(fiber.StatusAccepted).JSON(response)
+	}
+	if reader.Email == nil || (reader.IsActive != nil && !*reader.IsActive) {
+		return c.Status(fiber.StatusAccepted).JSON(response)
+	}
+
+	token, err := h.repo.CreateMagicLinkToken(c.Context(), reader.ID, h.cfg.Portal.MagicLinkTTL)
+	if err != nil {
+		log.Error().Err(err).Str("readerID", reader.ID.String()).Msg("Failed to create magic link token")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to create login link")
+	}
+
+	h.deliverMagicLink(c, reader, h.magicLinkURL(token))
+
+	return c.Status(fiber.StatusAccepted).JSON(response)
+}
+
+func (h *Handler) magicLinkURL(token string) string {
+	base := h.cfg.Portal.MagicLinkURL
+	if base == "" {
+		return token
+	}
+	sep := "?"
+	if strings.Contains(base, "?") {
+		sep = "&"
+	}
+	return base + sep + "token=" + url.QueryEscape(token)
+}
+
+// deliverMagicLink emails the login link to the reader. Without a mail transport the link
+// is written to the log in dev mode so the flow stays testable.
+func (h *Handler) deliverMagicLink(c *fiber.Ctx, reader *postgres.GetReaderByTicketNumberRow, link string) {
+	readerID := reader.ID.String()
+
+	if !h.notifier.Enabled(postgres.NotificationChannelEmail) {
+		if h.cfg.DevMode {
+			log.Info().Str("readerID", readerID).Str("email", *reader.Email).Str("link", link).Msg("Portal magic link")
+			return
+		}
+		log.Warn().Str("readerID", readerID).Msg("Portal magic link requested but no delivery channel is configured")
+		return
+	}
+
+	err := h.notifier.Send(c.Context(), postgres.NotificationChannelEmail, *reader.Email, "portal_login", reader.Language, map[string]any{
+		"reader_name":   reader.FullName,
+		"link":          link,
+		"valid_minutes": int(h.cfg.Portal.MagicLinkTTL.Minutes()),
+	})
+	if err != nil {
+		// The response does not reveal whether the reader exists, so the failure is only logged
+		log.Error().Err(err).Str("readerID", readerID).Msg("Failed to send portal magic link")
+	}
+}
+
+func (h *Handler) portalVerifyMagicLink(c *fiber.Ctx) error {
+	var req MagicLinkVerifyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid request body", err.Error())
+	}
+	if req.Token == "" {
+		return httperr.New(fiber.StatusBadRequest, "Token is required")
+	}
+
+	readerID, err := h.repo.ConsumeMagicLinkToken(c.Context(), req.Token)
+	if err != nil {
+		return httperr.New(fiber.StatusUnauthorized, "Login link is invalid or has expired")
+	}
+
+	reader, err := h.repo.GetReaderById(c.Context(), readerID)
+	if err != nil {
+		log.Error().Err(err).Str("readerID", readerID.String()).Msg("Failed to get reader for magic link")
+		return httperr.New(fiber.StatusUnauthorized, "Login link is invalid or has expired")
+	}
+	if reader.IsActive != nil && !*reader.IsActive {
+		return httperr.New(fiber.StatusUnauthorized, "Reader account is deactivated")
+	}
+
+	return h.startReaderSession(c, readerID)
+}
+
+func (h *Handler) portalLogout(c *fiber.Ctx) error {
+	if sid, ok := c.Locals("readerSessionID").(string); ok {
+		if err := h.repo.DeleteReaderSession(c.Context(), sid); err != nil {
+			log.Warn().Err(err).Msg("Failed to delete reader session during logout")
+		}
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     middleware.ReaderSessionCookie,
+		Value:    "",
+		Path:     "/api/library/portal",
+		Expires:  time.Now().Add(-time.Hour),
+		HTTPOnly: true,
+		Secure:   h.cfg.Portal.CookieSecure,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+
+	return c.JSON(fiber.Map{"message": "Logged out successfully"})
+}
+
+func (h *Handler) portalMe(c *fiber.Ctx) error {
+	readerID, err := portalReaderID(c)
+	if err != nil {
+		return err
+	}
+
+	reader, err := h.repo.GetReaderById(c.Context(), readerID)
+	if err != nil {
+		log.Error().Err(err).Str("readerID", readerID.String()).Msg("Failed to get reader for portal")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve reader data")
+	}
+
+	policy, _ := h.desk.Policy(reader.Category)
+
+	return c.JSON(fiber.Map{
+		"reader":     reader,
+		"membership": policy,
+	})
+}
+
+func (h *Handler) portalUpdateContacts(c *fiber.Ctx) error {
+	readerID, err := portalReaderID(c)
+	if err != nil {
+		return err
+	}
+
+	var req UpdateContactsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid request body", err.Error())
+	}
+	req.Email = trimToNil(req.Email)
+	req.Phone = trimToNil(req.Phone)
+	if req.Email != nil && (len(*req.Email) > 256 || !strings.Contains(*req.Email, "@")) {
+		return httperr.New(fiber.StatusBadRequest, "Invalid email address")
+	}
+	if req.Phone != nil && len(*req.Phone) > 20 {
+		return httperr.New(fiber.StatusBadRequest, "Phone number is too long")
+	}
+
+	reader, err := h.repo.UpdateReaderContacts(c.Context(), postgres.UpdateReaderContactsParams{
+		Email: req.Email,
+		Phone: req.Phone,
+		ID:    readerID,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("readerID", readerID.String()).Msg("Failed to update reader contacts")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to update contact details")
+	}
+
+	return c.JSON(reader)
+}
+
+func (h *Handler) portalLoans(c *fiber.Ctx) error {
+	readerID, err := portalReaderID(c)
+	if err != nil {
+		return err
+	}
+
+	loans, err := h.repo.GetReaderActiveBooks(c.Context(), readerID)
+	if err != nil {
+		log.Error().Err(err).Str("readerID", readerID.String()).Msg("Failed to get reader loans for portal")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve loans")
+	}
+
+	return c.JSON(loans)
+}
+
+func (h *Handler) portalRenewLoan(c *fiber.Ctx) error {
+	readerID, err := portalReaderID(c)
+	if err != nil {
+		return err
+	}
+
+	issueIDStr := c.Params("id")
+	issueID, err := uuid.Parse(issueIDStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid loan ID format")
+	}
+
+	issue, err := h.repo.GetReaderIssueCopy(c.Context(), postgres.GetReaderIssueCopyParams{
+		ID:       issueID,
+		ReaderID: readerID,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return httperr.New(fiber.StatusNotFound, "Loan not found")
+		}
+		log.Error().Err(err).Str("issueID", issueIDStr).Msg("Failed to get loan")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to renew loan")
+	}
+	if issue.ReturnDate != nil {
+		return httperr.New(fiber.StatusConflict, "The book has already been returned")
+	}
+
+	// The desk applies the same rules as renewals at the library and self-service kiosks
+	renewed, err := h.desk.Renew(c.Context(), readerID, issue.CopyCode)
+	if err != nil {
+		switch {
+		case errors.Is(err, circulation.ErrNotCheckedOut):
+			return httperr.New(fiber.StatusConflict, "The book has already been returned")
+		case errors.Is(err, circulation.ErrReaderInactive):
+			return httperr.New(fiber.StatusForbidden, "Your reader account is deactivated")
+		case errors.Is(err, circulation.ErrMembershipExpired):
+			return httperr.New(fiber.StatusForbidden, "Your membership has expired")
+		case errors.Is(err, repository.ErrLoanOverdue):
+			return httperr.New(fiber.StatusConflict, "Overdue loans can only be renewed at the library")
+		case errors.Is(err, repository.ErrRenewalLimit):
+			return h.portalRenewalLimit(c, readerID)
+		case errors.Is(err, repository.ErrRenewalPastMembership):
+			return httperr.New(fiber.StatusConflict, "Renew your membership before renewing this loan")
+		}
+		log.Error().Err(err).Str("issueID", issueIDStr).Msg("Failed to renew loan")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to renew loan")
+	}
+
+	return c.JSON(renewed)
+}
+
+// portalRenewalLimit reports the renewal limit of the reader's category
+func (h *Handler) portalRenewalLimit(c *fiber.Ctx, readerID uuid.UUID) error {
+	reader, err := h.repo.GetReaderLoanStatus(c.Context(), readerID)
+	if err != nil {
+		return httperr.New(fiber.StatusConflict, "Renewal limit reached")
+	}
+	policy, _ := h.desk.Policy(reader.Category)
+	return httperr.New(fiber.StatusConflict, "Renewal limit reached", fiber.Map{"max_renewals": policy.MaxRenewals})
+}
+
+func (h *Handler) portalFines(c *fiber.Ctx) error {
+	readerID, err := portalReaderID(c)
+	if err != nil {
+		return err
+	}
+
+	fines, err := h.repo.GetReaderFines(c.Context(), readerID)
+	if err != nil {
+		log.Error().Err(err).Str("readerID", readerID.String()).Msg("Failed to get reader fines for portal")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve fines")
+	}
+
+	return c.JSON(fines)
+}
+
+func (h *Handler) portalVisits(c *fiber.Ctx) error {
+	readerID, err := portalReaderID(c)
+	if err != nil {
+		return err
+	}
+
+	visits, err := h.repo.GetReaderVisitHistory(c.Context(), readerID)
+	if err != nil {
+		log.Error().Err(err).Str("readerID", readerID.String()).Msg("Failed to get reader visits for portal")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve visit history")
+	}
+
+	return c.JSON(visits)
+}
+
+// setReaderPin is a staff endpoint that sets or resets a reader's portal PIN
+func (h *Handler) setReaderPin(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid reader ID format")
+	}
+
+	var req SetReaderPinRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return httperr.New(fiber.StatusBadRequest, "Invalid request body", err.Error())
+		}
+	}
+
+	pin := req.Pin
+	if pin == "" {
+		n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to generate PIN")
+			return httperr.New(fiber.StatusInternalServerError, "Failed to generate PIN")
+		}
+		pin = fmt.Sprintf("%06d", n.Int64())
+	}
+	if !validPin(pin) {
+		return httperr.New(fiber.StatusBadRequest, fmt.Sprintf("PIN must be %d to %d digits", minPinLength, maxPinLength))
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to hash PIN")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to set PIN")
+	}
+	pinHash := string(hash)
+
+	if _, err := h.repo.GetReaderById(c.Context(), id); err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return httperr.New(fiber.StatusNotFound, "Reader not found")
+		}
+		log.Error().Err(err).Str("readerID", idStr).Msg("Failed to get reader")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to set PIN")
+	}
+
+	if err := h.repo.SetReaderPin(c.Context(), postgres.SetReaderPinParams{PinHash: &pinHash, ID: id}); err != nil {
+		log.Error().Err(err).Str("readerID", idStr).Msg("Failed to set reader PIN")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to set PIN")
+	}
+
+	// The generated PIN is shown once so the librarian can hand it to the reader
+	if req.Pin == "" {
+		return c.JSON(fiber.Map{"message": "PIN set", "pin": pin})
+	}
+	return c.JSON(fiber.Map{"message": "PIN set"})
+}
+
+func validPin(pin string) bool {
+	if len(pin) < minPinLength || len(pin) > maxPinLength {
+		return false
+	}
+	for _, r := range pin {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func trimToNil(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*s)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
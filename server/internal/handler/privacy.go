@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hnnsly/library-console/internal/repository"
+	httperr "github.com/hnnsly/library-console/pkg/error"
+	"github.com/rs/zerolog/log"
+)
+
+func (h *Handler) exportReader(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid reader ID format")
+	}
+
+	format := c.Query("format", "json")
+	if format != "json" && format != "zip" {
+		return httperr.New(fiber.StatusBadRequest, "Invalid export format, use json or zip")
+	}
+
+	export, err := h.repo.ExportReader(c.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return httperr.New(fiber.StatusNotFound, "Reader not found")
+		}
+		log.Error().Err(err).Str("readerID", idStr).Msg("Failed to export reader data")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to export reader data")
+	}
+
+	filename := "reader-" + export.Profile.TicketNumber
+	if format == "json" {
+		c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+filename+`.json"`)
+		return c.JSON(export)
+	}
+
+	// One JSON file per section, so the bundle can be read without special tools
+	sections := []struct {
+		name string
+		data any
+	}{
+		{"profile.json", export.Profile},
+		{"loans.json", export.Loans},
+		{"fines.json", export.Fines},
+		{"hall_visits.json", export.Visits},
+		{"seat_bookings.json", export.Bookings},
+		{"old_ticket_numbers.json", export.OldTickets},
+		{"notifications.json", export.Notifications},
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, section := range sections {
+		w, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     section.name,
+			Method:   zip.Deflate,
+			Modified: export.ExportedAt,
+		})
+		if err == nil {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(section.data)
+		}
+		if err != nil {
+			log.Error().Err(err).Str("readerID", idStr).Msg("Failed to write reader export archive")
+			return httperr.New(fiber.StatusInternalServerError, "Failed to export reader data")
+		}
+	}
+	if err := zw.Close(); err != nil {
+		log.Error().Err(err).Str("readerID", idStr).Msg("Failed to write reader export archive")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to export reader data")
+	}
+
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+filename+`.zip"`)
+
+	return c.Send(buf.Bytes())
+}
+
+func (h *Handler) anonymizeReader(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid reader ID format")
+	}
+
+	anonymized, err := h.repo.AnonymizeReader(c.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrReaderHasObligations):
+			return httperr.New(fiber.StatusConflict, "Reader has unreturned books or unpaid fines")
+		case errors.Is(err, repository.ErrReaderAnonymized):
+			return httperr.New(fiber.StatusConflict, "Reader is already anonymized")
+		case strings.Contains(err.Error(), "no rows in result set"):
+			return httperr.New(fiber.StatusNotFound, "Reader not found")
+		}
+		log.Error().Err(err).Str("readerID", idStr).Msg("Failed to anonymize reader")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to anonymize reader")
+	}
+
+	log.Info().Str("readerID", idStr).Msg("Reader anonymized")
+
+	return c.JSON(anonymized)
+}
@@ -3,6 +3,7 @@ package handler
 import (
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -12,10 +13,15 @@ import (
 )
 
 type CreateReaderRequest struct {
-	TicketNumber string  `json:"ticket_number" validate:"required"`
+	TicketNumber string  `json:"ticket_number"` // generated when empty
 	FullName     string  `json:"full_name" validate:"required"`
 	Email        *string `json:"email"`
 	Phone        *string `json:"phone"`
+	Category     string  `json:"category"` // student, staff, guest, child or general (default)
+}
+
+type ReissueTicketRequest struct {
+	Reason *string `json:"reason"`
 }
 
 type UpdateReaderRequest struct {
@@ -80,16 +86,44 @@ func (h *Handler) getReaderByTicketNumber(c *fiber.Ctx) error {
 		return httperr.New(fiber.StatusBadRequest, "Ticket number is required")
 	}
 
-	reader, err := h.repo.GetReaderByTicketNumber(c.Context(), ticketNumber)
+	reader, err := h.readerByTicket(c, ticketNumber)
 	if err != nil {
-		if strings.Contains(err.Error(), "no rows in result set") {
-			return httperr.New(fiber.StatusNotFound, "Reader not found")
+		return err
+	}
+
+	return c.JSON(reader)
+}
+
+// readerByTicket finds a reader by a scanned ticket number and reports replaced cards explicitly
+func (h *Handler) readerByTicket(c *fiber.Ctx, ticketNumber string) (*postgres.GetReaderByTicketNumberRow, error) {
+	if err := h.repo.CheckTicketNumber(ticketNumber); err != nil {
+		return nil, httperr.New(fiber.StatusBadRequest, "Invalid ticket number checksum")
+	}
+
+	reader, err := h.repo.GetReaderByTicketNumber(c.Context(), ticketNumber)
+	if err == nil {
+		if reader.IsActive != nil && !*reader.IsActive {
+			return nil, httperr.New(fiber.StatusForbidden, "Reader is deactivated")
 		}
+		return reader, nil
+	}
+	if !strings.Contains(err.Error(), "no rows in result set") {
 		log.Error().Err(err).Str("ticketNumber", ticketNumber).Msg("Failed to get reader")
-		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve reader")
+		return nil, httperr.New(fiber.StatusInternalServerError, "Failed to retrieve reader")
 	}
 
-	return c.JSON(reader)
+	old, err := h.repo.GetOldTicketNumber(c.Context(), ticketNumber)
+	if err == nil {
+		return nil, httperr.New(fiber.StatusGone, "Card replaced: this ticket number is no longer valid", fiber.Map{
+			"reader_id":   old.ReaderID,
+			"replaced_at": old.ReplacedAt,
+		})
+	}
+	if !strings.Contains(err.Error(), "no rows in result set") {
+		log.Warn().Err(err).Str("ticketNumber", ticketNumber).Msg("Failed to check old ticket numbers")
+	}
+
+	return nil, httperr.New(fiber.StatusNotFound, "Reader not found")
 }
 
 func (h *Handler) createReader(c *fiber.Ctx) error {
@@ -98,11 +132,46 @@ func (h *Handler) createReader(c *fiber.Ctx) error {
 		return httperr.New(fiber.StatusBadRequest, "Invalid request body", err.Error())
 	}
 
+	category := postgres.ReaderCategory(valueOr(req.Category, string(postgres.ReaderCategoryGeneral)))
+	if _, ok := h.desk.Policy(category); !ok {
+		return httperr.New(fiber.StatusBadRequest, "Invalid membership category")
+	}
+
+	ticketNumber := strings.TrimSpace(req.TicketNumber)
+	if ticketNumber == "" {
+		var err error
+		ticketNumber, err = h.repo.GenerateTicketNumber(c.Context())
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to generate ticket number")
+			return httperr.New(fiber.StatusInternalServerError, "Failed to generate ticket number")
+		}
+	} else {
+		// A manual number in the generated format must carry a valid check digit,
+		// otherwise scanning it later would fail the checksum
+		if err := h.repo.CheckTicketNumber(ticketNumber); err != nil {
+			return httperr.New(fiber.StatusBadRequest, "Invalid ticket number checksum")
+		}
+		// Numbers of replaced cards must not be handed out again
+		_, err := h.repo.GetOldTicketNumber(c.Context(), ticketNumber)
+		if err == nil {
+			return httperr.New(fiber.StatusConflict, "This ticket number belonged to a replaced card")
+		}
+		if !strings.Contains(err.Error(), "no rows in result set") {
+			log.Error().Err(err).Str("ticketNumber", ticketNumber).Msg("Failed to check old ticket numbers")
+			return httperr.New(fiber.StatusInternalServerError, "Failed to create reader")
+		}
+	}
+
+	cardExpiresAt := h.repo.CardExpiry(time.Now())
+
 	reader, err := h.repo.CreateReader(c.Context(), postgres.CreateReaderParams{
-		TicketNumber: req.TicketNumber,
-		FullName:     req.FullName,
-		Email:        req.Email,
-		Phone:        req.Phone,
+		TicketNumber:        ticketNumber,
+		FullName:            req.FullName,
+		Email:               req.Email,
+		Phone:               req.Phone,
+		CardExpiresAt:       &cardExpiresAt,
+		Category:            category,
+		MembershipExpiresAt: h.membershipExpiry(category),
 	})
 	if err != nil {
 		if strings.Contains(err.Error(), "duplicate") {
@@ -151,8 +220,11 @@ func (h *Handler) deactivateReader(c *fiber.Ctx) error {
 		return httperr.New(fiber.StatusBadRequest, "Invalid reader ID format")
 	}
 
-	err = h.repo.DeactivateReader(c.Context(), id)
+	err = h.repo.CloseReaderAccount(c.Context(), id)
 	if err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return httperr.New(fiber.StatusNotFound, "Reader not found")
+		}
 		log.Error().Err(err).Str("readerID", idStr).Msg("Failed to deactivate reader")
 		return httperr.New(fiber.StatusInternalServerError, "Failed to deactivate reader")
 	}
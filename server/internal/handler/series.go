@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hnnsly/library-console/internal/repository/postgres"
+	httperr "github.com/hnnsly/library-console/pkg/error"
+	"github.com/rs/zerolog/log"
+)
+
+type SeriesRequest struct {
+	Title string  `json:"title" validate:"required,max=500"`
+	ISSN  *string `json:"issn" validate:"omitempty,max=9"`
+}
+
+type SeriesResponse struct {
+	*postgres.Series
+	Books []*postgres.GetSeriesBooksRow `json:"books"`
+}
+
+// normalizeISSN accepts an ISSN with or without the hyphen and stores it as NNNN-NNNC
+func normalizeISSN(issn *string) (*string, error) {
+	if issn == nil || strings.TrimSpace(*issn) == "" {
+		return nil, nil
+	}
+	digits := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(*issn))
+	valid := len(digits) == 8
+	sum := 0
+	for i := 0; valid && i < 8; i++ {
+		switch {
+		case digits[i] >= '0' && digits[i] <= '9':
+			sum += (8 - i) * int(digits[i]-'0')
+		case digits[i] == 'X' && i == 7:
+			sum += 10
+		default:
+			valid = false
+		}
+	}
+	if !valid || sum%11 != 0 {
+		return nil, httperr.New(fiber.StatusBadRequest, "Invalid ISSN")
+	}
+	normalized := digits[:4] + "-" + digits[4:]
+	return &normalized, nil
+}
+
+func (h *Handler) searchSeries(c *fiber.Ctx) error {
+	series, err := h.repo.SearchSeries(c.Context(), strings.TrimSpace(c.Query("q")))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to search series")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to search series")
+	}
+
+	return c.JSON(series)
+}
+
+func (h *Handler) getSeriesById(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid series ID format")
+	}
+
+	series, err := h.repo.GetSeriesById(c.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return httperr.New(fiber.StatusNotFound, "Series not found")
+		}
+		log.Error().Err(err).Str("seriesID", idStr).Msg("Failed to get series")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve series")
+	}
+
+	books, err := h.repo.GetSeriesBooks(c.Context(), id)
+	if err != nil {
+		log.Error().Err(err).Str("seriesID", idStr).Msg("Failed to get series books")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve series books")
+	}
+
+	return c.JSON(SeriesResponse{Series: series, Books: books})
+}
+
+func (h *Handler) createSeries(c *fiber.Ctx) error {
+	var req SeriesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid request body", err.Error())
+	}
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		return httperr.New(fiber.StatusBadRequest, "Series title is required")
+	}
+	issn, err := normalizeISSN(req.ISSN)
+	if err != nil {
+		return err
+	}
+
+	series, err := h.repo.CreateSeries(c.Context(), postgres.CreateSeriesParams{
+		Title: title,
+		Issn:  issn,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create series")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to create series")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(series)
+}
+
+func (h *Handler) updateSeries(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid series ID format")
+	}
+
+	var req SeriesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid request body", err.Error())
+	}
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		return httperr.New(fiber.StatusBadRequest, "Series title is required")
+	}
+	issn, err := normalizeISSN(req.ISSN)
+	if err != nil {
+		return err
+	}
+
+	series, err := h.repo.UpdateSeries(c.Context(), postgres.UpdateSeriesParams{
+		Title: title,
+		Issn:  issn,
+		ID:    id,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return httperr.New(fiber.StatusNotFound, "Series not found")
+		}
+		log.Error().Err(err).Str("seriesID", idStr).Msg("Failed to update series")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to update series")
+	}
+
+	h.invalidateCatalog(c)
+
+	return c.JSON(series)
+}
+
+// deleteSeries detaches the volumes from the series; the books stay in the catalogue
+func (h *Handler) deleteSeries(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid series ID format")
+	}
+
+	deleted, err := h.repo.DeleteSeries(c.Context(), id)
+	if err != nil {
+		log.Error().Err(err).Str("seriesID", idStr).Msg("Failed to delete series")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to delete series")
+	}
+	if deleted == 0 {
+		return httperr.New(fiber.StatusNotFound, "Series not found")
+	}
+
+	h.invalidateCatalog(c)
+
+	return c.JSON(fiber.Map{"message": "Series deleted successfully"})
+}
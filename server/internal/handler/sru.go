@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"net"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hnnsly/library-console/internal/sru"
+	"github.com/rs/zerolog/log"
+)
+
+// sruRequest handles SRU 1.2 and 2.0 searchRetrieve and explain requests. Protocol errors are
+// returned as diagnostics in the XML body, so the status is 200 for every answered request
+func (h *Handler) sruRequest(c *fiber.Ctx) error {
+	params := make(map[string]string)
+	c.Context().QueryArgs().VisitAll(func(key, value []byte) {
+		params[string(key)] = string(value)
+	})
+
+	body, err := h.sru.Handle(c.Context(), params, sruEndpoint(c))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to encode SRU response")
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationXMLCharsetUTF8)
+	return c.Send(body)
+}
+
+// sruEndpoint describes the address the client used, as explain records are expected to
+func sruEndpoint(c *fiber.Ctx) sru.Endpoint {
+	host, port := c.Hostname(), 80
+	if c.Protocol() == "https" {
+		port = 443
+	}
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		host = h
+		if n, err := strconv.Atoi(p); err == nil {
+			port = n
+		}
+	}
+	return sru.Endpoint{Host: host, Port: port, Path: c.Path()}
+}
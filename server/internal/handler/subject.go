@@ -0,0 +1,423 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hnnsly/library-console/internal/repository/postgres"
+	httperr "github.com/hnnsly/library-console/pkg/error"
+	"github.com/rs/zerolog/log"
+)
+
+// defaultHallSuggestionScore is the minimal trigram similarity between a subject and a hall specialization
+const defaultHallSuggestionScore = 0.3
+
+type CreateSubjectRequest struct {
+	Scheme   string  `json:"scheme" validate:"required"`
+	Code     *string `json:"code" validate:"omitempty,max=50"`
+	Name     string  `json:"name" validate:"required,max=300"`
+	ParentID *string `json:"parent_id"`
+}
+
+type UpdateSubjectRequest struct {
+	Code     *string `json:"code" validate:"omitempty,max=50"`
+	Name     string  `json:"name" validate:"required,max=300"`
+	ParentID *string `json:"parent_id"`
+}
+
+type AddBookSubjectRequest struct {
+	SubjectID string `json:"subject_id" validate:"required"`
+}
+
+// SubjectNode is a subject with its counts and nested children
+type SubjectNode struct {
+	ID               uuid.UUID      `json:"id"`
+	Code             *string        `json:"code"`
+	Name             string         `json:"name"`
+	BookCount        int64          `json:"book_count"`
+	SubtreeBookCount int64          `json:"subtree_book_count"`
+	Children         []*SubjectNode `json:"children"`
+}
+
+// parseSubjectScheme checks the scheme against the subject_scheme enum
+func parseSubjectScheme(scheme string) (postgres.SubjectScheme, error) {
+	switch s := postgres.SubjectScheme(strings.ToLower(strings.TrimSpace(scheme))); s {
+	case postgres.SubjectSchemeUdc, postgres.SubjectSchemeBbk, postgres.SubjectSchemeDdc,
+		postgres.SubjectSchemeTopic, postgres.SubjectSchemeGenre:
+		return s, nil
+	}
+	return "", httperr.New(fiber.StatusBadRequest, "scheme must be one of udc, bbk, ddc, topic, genre")
+}
+
+// subjectParent resolves the requested parent and checks it belongs to the same scheme
+// and, when a subject is moved, is not the subject itself or one of its descendants
+func (h *Handler) subjectParent(c *fiber.Ctx, parentID *string, scheme postgres.SubjectScheme, self *uuid.UUID) (*uuid.UUID, error) {
+	if parentID == nil || strings.TrimSpace(*parentID) == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(strings.TrimSpace(*parentID))
+	if err != nil {
+		return nil, httperr.New(fiber.StatusBadRequest, "Invalid parent ID format")
+	}
+
+	parent, err := h.repo.GetSubjectById(c.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return nil, httperr.New(fiber.StatusBadRequest, "Parent subject not found")
+		}
+		log.Error().Err(err).Str("parentID", id.String()).Msg("Failed to get parent subject")
+		return nil, httperr.New(fiber.StatusInternalServerError, "Failed to retrieve parent subject")
+	}
+	if parent.Scheme != scheme {
+		return nil, httperr.New(fiber.StatusBadRequest, "Parent subject belongs to another scheme")
+	}
+
+	if self != nil {
+		cycle, err := h.repo.IsSubjectInSubtree(c.Context(), postgres.IsSubjectInSubtreeParams{
+			RootID:    *self,
+			SubjectID: id,
+		})
+		if err != nil {
+			log.Error().Err(err).Str("subjectID", self.String()).Msg("Failed to check subject hierarchy")
+			return nil, httperr.New(fiber.StatusInternalServerError, "Failed to check subject hierarchy")
+		}
+		if cycle {
+			return nil, httperr.New(fiber.StatusBadRequest, "Subject cannot be moved under itself or its descendant")
+		}
+	}
+	return &id, nil
+}
+
+func (h *Handler) searchSubjects(c *fiber.Ctx) error {
+	scheme := c.Query("scheme")
+	if scheme != "" {
+		parsed, err := parseSubjectScheme(scheme)
+		if err != nil {
+			return err
+		}
+		scheme = string(parsed)
+	}
+
+	subjects, err := h.repo.SearchSubjects(c.Context(), postgres.SearchSubjectsParams{
+		Scheme: scheme,
+		Query:  strings.TrimSpace(c.Query("q")),
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to search subjects")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to search subjects")
+	}
+
+	return c.JSON(subjects)
+}
+
+// subjectTree loads a scheme and nests it; with root set only that subtree is returned
+func (h *Handler) subjectTree(c *fiber.Ctx, heldOnly bool) (any, error) {
+	scheme, err := parseSubjectScheme(c.Query("scheme"))
+	if err != nil {
+		return nil, err
+	}
+	var root *uuid.UUID
+	if rootStr := c.Query("root"); rootStr != "" {
+		id, err := uuid.Parse(rootStr)
+		if err != nil {
+			return nil, httperr.New(fiber.StatusBadRequest, "Invalid root parameter")
+		}
+		root = &id
+	}
+
+	rows, err := h.repo.GetSubjectTree(c.Context(), postgres.GetSubjectTreeParams{
+		Scheme:   scheme,
+		HeldOnly: heldOnly,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("scheme", string(scheme)).Msg("Failed to get subject tree")
+		return nil, httperr.New(fiber.StatusInternalServerError, "Failed to retrieve subject tree")
+	}
+
+	nodes := make(map[uuid.UUID]*SubjectNode, len(rows))
+	for _, row := range rows {
+		nodes[row.ID] = &SubjectNode{
+			ID:               row.ID,
+			Code:             row.Code,
+			Name:             row.Name,
+			BookCount:        row.BookCount,
+			SubtreeBookCount: row.SubtreeBookCount,
+			Children:         []*SubjectNode{},
+		}
+	}
+
+	roots := []*SubjectNode{}
+	for _, row := range rows {
+		node := nodes[row.ID]
+		if root != nil && row.ID == *root {
+			roots = append(roots, node)
+		}
+		if row.ParentID != nil {
+			if parent, ok := nodes[*row.ParentID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		if root == nil {
+			roots = append(roots, node)
+		}
+	}
+	if root != nil && len(roots) == 0 {
+		return nil, httperr.New(fiber.StatusNotFound, "Subject not found in the scheme")
+	}
+
+	return roots, nil
+}
+
+func (h *Handler) getSubjectTree(c *fiber.Ctx) error {
+	tree, err := h.subjectTree(c, false)
+	if err != nil {
+		return err
+	}
+	return c.JSON(tree)
+}
+
+func (h *Handler) opacGetSubjectTree(c *fiber.Ctx) error {
+	return h.cachedJSON(c, func() (any, error) {
+		return h.subjectTree(c, true)
+	})
+}
+
+func (h *Handler) getSubjectById(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid subject ID format")
+	}
+
+	subject, err := h.repo.GetSubjectById(c.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return httperr.New(fiber.StatusNotFound, "Subject not found")
+		}
+		log.Error().Err(err).Str("subjectID", idStr).Msg("Failed to get subject")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve subject")
+	}
+
+	return c.JSON(subject)
+}
+
+func (h *Handler) createSubject(c *fiber.Ctx) error {
+	var req CreateSubjectRequest
+	if err := c.BodyParser(&req); err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid request body", err.Error())
+	}
+
+	scheme, err := parseSubjectScheme(req.Scheme)
+	if err != nil {
+		return err
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return httperr.New(fiber.StatusBadRequest, "Subject name is required")
+	}
+	parentID, err := h.subjectParent(c, req.ParentID, scheme, nil)
+	if err != nil {
+		return err
+	}
+
+	subject, err := h.repo.CreateSubject(c.Context(), postgres.CreateSubjectParams{
+		Scheme:   scheme,
+		Code:     trimToNil(req.Code),
+		Name:     name,
+		ParentID: parentID,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate") {
+			return httperr.New(fiber.StatusConflict, "Subject with this code already exists in the scheme")
+		}
+		log.Error().Err(err).Msg("Failed to create subject")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to create subject")
+	}
+
+	h.invalidateCatalog(c)
+
+	return c.Status(fiber.StatusCreated).JSON(subject)
+}
+
+func (h *Handler) updateSubject(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid subject ID format")
+	}
+
+	var req UpdateSubjectRequest
+	if err := c.BodyParser(&req); err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid request body", err.Error())
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return httperr.New(fiber.StatusBadRequest, "Subject name is required")
+	}
+
+	current, err := h.repo.GetSubjectById(c.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return httperr.New(fiber.StatusNotFound, "Subject not found")
+		}
+		log.Error().Err(err).Str("subjectID", idStr).Msg("Failed to get subject")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve subject")
+	}
+	parentID, err := h.subjectParent(c, req.ParentID, current.Scheme, &id)
+	if err != nil {
+		return err
+	}
+
+	subject, err := h.repo.UpdateSubject(c.Context(), postgres.UpdateSubjectParams{
+		Code:     trimToNil(req.Code),
+		Name:     name,
+		ParentID: parentID,
+		ID:       id,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return httperr.New(fiber.StatusNotFound, "Subject not found")
+		}
+		if strings.Contains(err.Error(), "duplicate") {
+			return httperr.New(fiber.StatusConflict, "Subject with this code already exists in the scheme")
+		}
+		log.Error().Err(err).Str("subjectID", idStr).Msg("Failed to update subject")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to update subject")
+	}
+
+	h.invalidateCatalog(c)
+
+	return c.JSON(subject)
+}
+
+func (h *Handler) deleteSubject(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid subject ID format")
+	}
+
+	deleted, err := h.repo.DeleteSubject(c.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "violates foreign key") {
+			return httperr.New(fiber.StatusConflict, "Subject has child subjects")
+		}
+		log.Error().Err(err).Str("subjectID", idStr).Msg("Failed to delete subject")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to delete subject")
+	}
+	if deleted == 0 {
+		return httperr.New(fiber.StatusNotFound, "Subject not found")
+	}
+
+	h.invalidateCatalog(c)
+
+	return c.JSON(fiber.Map{"message": "Subject deleted successfully"})
+}
+
+func (h *Handler) getBookSubjects(c *fiber.Ctx) error {
+	bookIdStr := c.Params("id")
+	bookId, err := uuid.Parse(bookIdStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid book ID format")
+	}
+
+	subjects, err := h.repo.GetBookSubjects(c.Context(), bookId)
+	if err != nil {
+		log.Error().Err(err).Str("bookID", bookIdStr).Msg("Failed to get book subjects")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve book subjects")
+	}
+
+	return c.JSON(subjects)
+}
+
+func (h *Handler) addBookSubject(c *fiber.Ctx) error {
+	bookIdStr := c.Params("id")
+	bookId, err := uuid.Parse(bookIdStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid book ID format")
+	}
+
+	var req AddBookSubjectRequest
+	if err := c.BodyParser(&req); err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid request body", err.Error())
+	}
+
+	subjectId, err := uuid.Parse(req.SubjectID)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid subject ID format")
+	}
+
+	err = h.repo.AddBookSubject(c.Context(), postgres.AddBookSubjectParams{
+		BookID:    bookId,
+		SubjectID: subjectId,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "violates foreign key") {
+			return httperr.New(fiber.StatusNotFound, "Book or subject not found")
+		}
+		log.Error().Err(err).Str("bookID", bookIdStr).Str("subjectID", req.SubjectID).Msg("Failed to add book subject")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to add book subject")
+	}
+
+	h.invalidateCatalog(c)
+
+	return c.JSON(fiber.Map{"message": "Subject added to book successfully"})
+}
+
+func (h *Handler) removeBookSubject(c *fiber.Ctx) error {
+	bookIdStr := c.Params("id")
+	bookId, err := uuid.Parse(bookIdStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid book ID format")
+	}
+
+	subjectIdStr := c.Params("subjectId")
+	subjectId, err := uuid.Parse(subjectIdStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid subject ID format")
+	}
+
+	err = h.repo.RemoveBookSubject(c.Context(), postgres.RemoveBookSubjectParams{
+		BookID:    bookId,
+		SubjectID: subjectId,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("bookID", bookIdStr).Str("subjectID", subjectIdStr).Msg("Failed to remove book subject")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to remove book subject")
+	}
+
+	h.invalidateCatalog(c)
+
+	return c.JSON(fiber.Map{"message": "Subject removed from book successfully"})
+}
+
+// suggestHallsForBook ranks halls whose specialization resembles the book's subjects or their broader terms
+func (h *Handler) suggestHallsForBook(c *fiber.Ctx) error {
+	bookIdStr := c.Params("id")
+	bookId, err := uuid.Parse(bookIdStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid book ID format")
+	}
+
+	minScore := defaultHallSuggestionScore
+	if scoreStr := c.Query("min_score"); scoreStr != "" {
+		minScore, err = strconv.ParseFloat(scoreStr, 64)
+		if err != nil || minScore < 0 || minScore > 1 {
+			return httperr.New(fiber.StatusBadRequest, "min_score must be between 0 and 1")
+		}
+	}
+
+	suggestions, err := h.repo.SuggestHallsForBook(c.Context(), postgres.SuggestHallsForBookParams{
+		BookID:   bookId,
+		MinScore: minScore,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("bookID", bookIdStr).Msg("Failed to suggest halls for book")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to suggest halls")
+	}
+
+	return c.JSON(suggestions)
+}
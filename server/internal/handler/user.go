@@ -104,6 +104,7 @@ func (h *Handler) createUser(c *fiber.Ctx) error {
 	validRoles := map[string]bool{
 		"administrator": true,
 		"librarian":     true,
+		"kiosk":         true, // self-checkout service account, SIP2 only
 	}
 	if !validRoles[req.Role] {
 		return httperr.New(fiber.StatusBadRequest, "Invalid role value")
@@ -164,6 +165,7 @@ func (h *Handler) updateUser(c *fiber.Ctx) error {
 	validRoles := map[string]bool{
 		"administrator": true,
 		"librarian":     true,
+		"kiosk":         true, // self-checkout service account, SIP2 only
 	}
 	if !validRoles[req.Role] {
 		return httperr.New(fiber.StatusBadRequest, "Invalid role value")
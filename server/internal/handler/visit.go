@@ -6,7 +6,9 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/hnnsly/library-console/internal/repository"
 	"github.com/hnnsly/library-console/internal/repository/postgres"
+	"github.com/hnnsly/library-console/internal/repository/redis"
 	httperr "github.com/hnnsly/library-console/pkg/error"
 	"github.com/rs/zerolog/log"
 )
@@ -60,17 +62,24 @@ func (h *Handler) registerHallEntry(c *fiber.Ctx) error {
 		return httperr.New(fiber.StatusBadRequest, "Invalid hall ID format")
 	}
 
-	// Get librarian ID from context
-	userIDStr, ok := c.Locals("userID").(string)
-	if !ok {
-		return httperr.New(fiber.StatusUnauthorized, "User ID not found in context")
+	librarianID, err := sessionUserID(c)
+	if err != nil {
+		return err
 	}
-	librarianID, err := uuid.Parse(userIDStr)
+
+	// Replaced cards and mistyped numbers are rejected before touching the journal
+	reader, err := h.readerByTicket(c, req.TicketNumber)
 	if err != nil {
-		return httperr.New(fiber.StatusInternalServerError, "Invalid user ID format")
+		return err
+	}
+	if repository.MembershipExpired(reader.MembershipExpiresAt, time.Now()) {
+		return httperr.New(fiber.StatusForbidden, "Reader membership has expired", fiber.Map{
+			"membership_expires_at": reader.MembershipExpiresAt,
+		})
 	}
 
-	entry, err := h.repo.RegisterHallEntry(c.Context(), postgres.RegisterHallEntryParams{
+	// The reader's current seat booking, if any, is checked in by the same transaction
+	entry, booking, err := h.repo.EnterHall(c.Context(), postgres.RegisterHallEntryParams{
 		TicketNumber: req.TicketNumber,
 		HallID:       hallID,
 		LibrarianID:  &librarianID,
@@ -89,7 +98,19 @@ func (h *Handler) registerHallEntry(c *fiber.Ctx) error {
 		log.Warn().Err(err).Str("hallID", req.HallID).Msg("Failed to update hall visitor count")
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(entry)
+	h.publishEvent(c, redis.EventVisitEntry, VisitEvent{
+		VisitID:      entry.ID,
+		HallID:       hallID,
+		TicketNumber: req.TicketNumber,
+		VisitTime:    entry.VisitTime,
+	})
+	h.publishHallOccupancy(c, hallID)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"id":         entry.ID,
+		"visit_time": entry.VisitTime,
+		"booking":    booking,
+	})
 }
 
 func (h *Handler) registerHallExit(c *fiber.Ctx) error {
@@ -103,14 +124,14 @@ func (h *Handler) registerHallExit(c *fiber.Ctx) error {
 		return httperr.New(fiber.StatusBadRequest, "Invalid hall ID format")
 	}
 
-	// Get librarian ID from context
-	userIDStr, ok := c.Locals("userID").(string)
-	if !ok {
-		return httperr.New(fiber.StatusUnauthorized, "User ID not found in context")
-	}
-	librarianID, err := uuid.Parse(userIDStr)
+	librarianID, err := sessionUserID(c)
 	if err != nil {
-		return httperr.New(fiber.StatusInternalServerError, "Invalid user ID format")
+		return err
+	}
+
+	// Replaced cards and mistyped numbers are rejected before touching the journal
+	if _, err := h.readerByTicket(c, req.TicketNumber); err != nil {
+		return err
 	}
 
 	exit, err := h.repo.RegisterHallExit(c.Context(), postgres.RegisterHallExitParams{
@@ -132,5 +153,13 @@ func (h *Handler) registerHallExit(c *fiber.Ctx) error {
 		log.Warn().Err(err).Str("hallID", req.HallID).Msg("Failed to update hall visitor count")
 	}
 
+	h.publishEvent(c, redis.EventVisitExit, VisitEvent{
+		VisitID:      exit.ID,
+		HallID:       hallID,
+		TicketNumber: req.TicketNumber,
+		VisitTime:    exit.VisitTime,
+	})
+	h.publishHallOccupancy(c, hallID)
+
 	return c.Status(fiber.StatusCreated).JSON(exit)
 }
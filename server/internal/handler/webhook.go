@@ -0,0 +1,326 @@
+package handler
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hnnsly/library-console/internal/repository"
+	"github.com/hnnsly/library-console/internal/repository/postgres"
+	"github.com/hnnsly/library-console/internal/webhook"
+	httperr "github.com/hnnsly/library-console/pkg/error"
+	"github.com/rs/zerolog/log"
+)
+
+type CreateWebhookRequest struct {
+	URL         string   `json:"url"`
+	Events      []string `json:"events"`
+	Description *string  `json:"description"`
+	Secret      string   `json:"secret"` // generated when empty
+}
+
+type UpdateWebhookRequest struct {
+	URL         *string   `json:"url"`
+	Events      *[]string `json:"events"`
+	Description *string   `json:"description"`
+	IsActive    *bool     `json:"is_active"`
+}
+
+// minWebhookSecretLength keeps caller-supplied secrets from being trivially guessable
+const minWebhookSecretLength = 16
+
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return httperr.New(fiber.StatusBadRequest, "Webhook URL must be an absolute http or https URL")
+	}
+	return nil
+}
+
+// normalizeWebhookEvents validates event types and drops duplicates, keeping the caller's order
+func normalizeWebhookEvents(events []string) ([]string, error) {
+	if len(events) == 0 {
+		return nil, httperr.New(fiber.StatusBadRequest, "At least one event is required", repository.WebhookEvents)
+	}
+	seen := make(map[string]bool, len(events))
+	normalized := make([]string, 0, len(events))
+	for _, e := range events {
+		e = strings.TrimSpace(e)
+		if !repository.IsWebhookEvent(e) {
+			return nil, httperr.New(fiber.StatusBadRequest, "Unknown webhook event: "+e, repository.WebhookEvents)
+		}
+		if !seen[e] {
+			seen[e] = true
+			normalized = append(normalized, e)
+		}
+	}
+	return normalized, nil
+}
+
+func (h *Handler) getWebhookEvents(c *fiber.Ctx) error {
+	return c.JSON(repository.WebhookEvents)
+}
+
+func (h *Handler) getWebhooks(c *fiber.Ctx) error {
+	subscriptions, err := h.repo.GetWebhookSubscriptions(c.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get webhook subscriptions")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve webhooks")
+	}
+
+	return c.JSON(subscriptions)
+}
+
+func (h *Handler) getWebhook(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid webhook ID format")
+	}
+
+	subscription, err := h.repo.GetWebhookSubscription(c.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return httperr.New(fiber.StatusNotFound, "Webhook not found")
+		}
+		log.Error().Err(err).Str("webhookID", idStr).Msg("Failed to get webhook subscription")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve webhook")
+	}
+
+	return c.JSON(subscription)
+}
+
+func (h *Handler) createWebhook(c *fiber.Ctx) error {
+	var req CreateWebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid request body", err.Error())
+	}
+	if err := validateWebhookURL(req.URL); err != nil {
+		return err
+	}
+	events, err := normalizeWebhookEvents(req.Events)
+	if err != nil {
+		return err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		secret, err = webhook.NewSecret()
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to generate webhook secret")
+			return httperr.New(fiber.StatusInternalServerError, "Failed to create webhook")
+		}
+	} else if len(secret) < minWebhookSecretLength {
+		return httperr.New(fiber.StatusBadRequest, "Webhook secret must be at least 16 characters")
+	}
+
+	createdBy, err := sessionUserID(c)
+	if err != nil {
+		return err
+	}
+
+	// The secret is only returned here and on rotation
+	subscription, err := h.repo.CreateWebhookSubscription(c.Context(), postgres.CreateWebhookSubscriptionParams{
+		Url:         req.URL,
+		Secret:      secret,
+		Events:      events,
+		Description: trimToNil(req.Description),
+		CreatedBy:   &createdBy,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create webhook subscription")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to create webhook")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(subscription)
+}
+
+func (h *Handler) updateWebhook(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid webhook ID format")
+	}
+
+	var req UpdateWebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid request body", err.Error())
+	}
+
+	current, err := h.repo.GetWebhookSubscription(c.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return httperr.New(fiber.StatusNotFound, "Webhook not found")
+		}
+		log.Error().Err(err).Str("webhookID", idStr).Msg("Failed to get webhook subscription")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to update webhook")
+	}
+
+	params := postgres.UpdateWebhookSubscriptionParams{
+		Url:         current.Url,
+		Events:      current.Events,
+		Description: current.Description,
+		IsActive:    current.IsActive,
+		ID:          id,
+	}
+	if req.URL != nil {
+		if err := validateWebhookURL(*req.URL); err != nil {
+			return err
+		}
+		params.Url = *req.URL
+	}
+	if req.Events != nil {
+		if params.Events, err = normalizeWebhookEvents(*req.Events); err != nil {
+			return err
+		}
+	}
+	if req.Description != nil {
+		params.Description = trimToNil(req.Description)
+	}
+	if req.IsActive != nil {
+		params.IsActive = *req.IsActive
+	}
+
+	subscription, err := h.repo.UpdateWebhookSubscription(c.Context(), params)
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return httperr.New(fiber.StatusNotFound, "Webhook not found")
+		}
+		log.Error().Err(err).Str("webhookID", idStr).Msg("Failed to update webhook subscription")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to update webhook")
+	}
+
+	return c.JSON(subscription)
+}
+
+func (h *Handler) rotateWebhookSecret(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid webhook ID format")
+	}
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to generate webhook secret")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to rotate webhook secret")
+	}
+
+	rotated, err := h.repo.RotateWebhookSecret(c.Context(), postgres.RotateWebhookSecretParams{
+		Secret: secret,
+		ID:     id,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return httperr.New(fiber.StatusNotFound, "Webhook not found")
+		}
+		log.Error().Err(err).Str("webhookID", idStr).Msg("Failed to rotate webhook secret")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to rotate webhook secret")
+	}
+
+	return c.JSON(rotated)
+}
+
+func (h *Handler) deleteWebhook(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid webhook ID format")
+	}
+
+	deleted, err := h.repo.DeleteWebhookSubscription(c.Context(), id)
+	if err != nil {
+		log.Error().Err(err).Str("webhookID", idStr).Msg("Failed to delete webhook subscription")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to delete webhook")
+	}
+	if deleted == 0 {
+		return httperr.New(fiber.StatusNotFound, "Webhook not found")
+	}
+
+	return c.JSON(fiber.Map{"message": "Webhook deleted successfully"})
+}
+
+func (h *Handler) getWebhookDeliveries(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid webhook ID format")
+	}
+
+	status := c.Query("status")
+	switch postgres.WebhookDeliveryStatus(status) {
+	case "", postgres.WebhookDeliveryStatusPending, postgres.WebhookDeliveryStatusDelivered, postgres.WebhookDeliveryStatusFailed:
+	default:
+		return httperr.New(fiber.StatusBadRequest, "Invalid status parameter")
+	}
+	limit, err := strconv.Atoi(c.Query("limit", "50"))
+	if err != nil || limit <= 0 || limit > 500 {
+		return httperr.New(fiber.StatusBadRequest, "Invalid limit parameter")
+	}
+	offset, err := strconv.Atoi(c.Query("offset", "0"))
+	if err != nil || offset < 0 {
+		return httperr.New(fiber.StatusBadRequest, "Invalid offset parameter")
+	}
+
+	deliveries, err := h.repo.GetWebhookDeliveries(c.Context(), postgres.GetWebhookDeliveriesParams{
+		SubscriptionID: id,
+		Status:         status,
+		LimitCount:     int32(limit),
+		OffsetCount:    int32(offset),
+	})
+	if err != nil {
+		log.Error().Err(err).Str("webhookID", idStr).Msg("Failed to get webhook deliveries")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve webhook deliveries")
+	}
+
+	return c.JSON(deliveries)
+}
+
+func (h *Handler) getWebhookDelivery(c *fiber.Ctx) error {
+	idStr := c.Params("deliveryId")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid delivery ID format")
+	}
+
+	delivery, err := h.repo.GetWebhookDelivery(c.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return httperr.New(fiber.StatusNotFound, "Delivery not found")
+		}
+		log.Error().Err(err).Str("deliveryID", idStr).Msg("Failed to get webhook delivery")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve webhook delivery")
+	}
+
+	attempts, err := h.repo.GetWebhookDeliveryAttempts(c.Context(), id)
+	if err != nil {
+		log.Error().Err(err).Str("deliveryID", idStr).Msg("Failed to get webhook delivery attempts")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve webhook delivery")
+	}
+
+	return c.JSON(fiber.Map{
+		"delivery": delivery,
+		"attempts": attempts,
+	})
+}
+
+func (h *Handler) retryWebhookDelivery(c *fiber.Ctx) error {
+	idStr := c.Params("deliveryId")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid delivery ID format")
+	}
+
+	delivery, err := h.repo.RetryWebhookDelivery(c.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return httperr.New(fiber.StatusConflict, "Delivery not found or already pending")
+		}
+		log.Error().Err(err).Str("deliveryID", idStr).Msg("Failed to retry webhook delivery")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retry webhook delivery")
+	}
+
+	return c.JSON(delivery)
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hnnsly/library-console/internal/repository/postgres"
+	httperr "github.com/hnnsly/library-console/pkg/error"
+	"github.com/rs/zerolog/log"
+)
+
+type WorkRequest struct {
+	Title string `json:"title" validate:"required,max=500"`
+}
+
+type WorkResponse struct {
+	*postgres.Work
+	Editions []*postgres.GetWorkEditionsRow `json:"editions"`
+}
+
+func (h *Handler) searchWorks(c *fiber.Ctx) error {
+	works, err := h.repo.SearchWorks(c.Context(), strings.TrimSpace(c.Query("q")))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to search works")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to search works")
+	}
+
+	return c.JSON(works)
+}
+
+func (h *Handler) getWorkById(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid work ID format")
+	}
+
+	work, err := h.repo.GetWorkById(c.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return httperr.New(fiber.StatusNotFound, "Work not found")
+		}
+		log.Error().Err(err).Str("workID", idStr).Msg("Failed to get work")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve work")
+	}
+
+	editions, err := h.repo.GetWorkEditions(c.Context(), id)
+	if err != nil {
+		log.Error().Err(err).Str("workID", idStr).Msg("Failed to get work editions")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to retrieve work editions")
+	}
+
+	return c.JSON(WorkResponse{Work: work, Editions: editions})
+}
+
+func (h *Handler) createWork(c *fiber.Ctx) error {
+	var req WorkRequest
+	if err := c.BodyParser(&req); err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid request body", err.Error())
+	}
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		return httperr.New(fiber.StatusBadRequest, "Work title is required")
+	}
+
+	work, err := h.repo.CreateWork(c.Context(), title)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create work")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to create work")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(work)
+}
+
+func (h *Handler) updateWork(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid work ID format")
+	}
+
+	var req WorkRequest
+	if err := c.BodyParser(&req); err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid request body", err.Error())
+	}
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		return httperr.New(fiber.StatusBadRequest, "Work title is required")
+	}
+
+	work, err := h.repo.UpdateWork(c.Context(), postgres.UpdateWorkParams{
+		Title: title,
+		ID:    id,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows in result set") {
+			return httperr.New(fiber.StatusNotFound, "Work not found")
+		}
+		log.Error().Err(err).Str("workID", idStr).Msg("Failed to update work")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to update work")
+	}
+
+	h.invalidateCatalog(c)
+
+	return c.JSON(work)
+}
+
+// deleteWork removes the grouping only; its editions stay in the catalogue
+func (h *Handler) deleteWork(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return httperr.New(fiber.StatusBadRequest, "Invalid work ID format")
+	}
+
+	deleted, err := h.repo.DeleteWork(c.Context(), id)
+	if err != nil {
+		log.Error().Err(err).Str("workID", idStr).Msg("Failed to delete work")
+		return httperr.New(fiber.StatusInternalServerError, "Failed to delete work")
+	}
+	if deleted == 0 {
+		return httperr.New(fiber.StatusNotFound, "Work not found")
+	}
+
+	h.invalidateCatalog(c)
+
+	return c.JSON(fiber.Map{"message": "Work deleted successfully"})
+}
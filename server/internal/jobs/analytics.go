@@ -0,0 +1,16 @@
+package jobs
+
+import (
+	"time"
+
+	"github.com/hnnsly/library-console/internal/repository"
+)
+
+// RefreshVisitRollups обновляет агрегаты для аналитики посещений залов
+func RefreshVisitRollups(repo *repository.LibraryRepository) Job {
+	return Job{
+		Name:     "refresh-visit-rollups",
+		Interval: 10 * time.Minute,
+		Run:      repo.RefreshVisitRollups,
+	}
+}
@@ -0,0 +1,27 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/hnnsly/library-console/internal/repository"
+	"github.com/rs/zerolog/log"
+)
+
+// ReleaseNoShowBookings освобождает места по броням, на которые читатель не пришел
+func ReleaseNoShowBookings(repo *repository.LibraryRepository) Job {
+	return Job{
+		Name:     "release-no-show-bookings",
+		Interval: time.Minute,
+		Run: func(ctx context.Context) error {
+			released, err := repo.ReleaseNoShowBookings(ctx)
+			if err != nil {
+				return err
+			}
+			if released > 0 {
+				log.Info().Int64("released", released).Msg("No-show seat bookings released")
+			}
+			return nil
+		},
+	}
+}
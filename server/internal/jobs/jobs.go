@@ -0,0 +1,42 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Job периодическая фоновая задача сервера
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Start запускает каждую задачу в отдельной горутине: сразу и далее с ее интервалом до отмены ctx
+func Start(ctx context.Context, jobs ...Job) {
+	for _, job := range jobs {
+		go run(ctx, job)
+	}
+}
+
+func run(ctx context.Context, job Job) {
+	log.Info().Str("job", job.Name).Dur("interval", job.Interval).Msg("Background job started")
+
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := job.Run(ctx); err != nil && ctx.Err() == nil {
+			log.Error().Err(err).Str("job", job.Name).Msg("Background job failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Info().Str("job", job.Name).Msg("Background job stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/hnnsly/library-console/internal/notify"
+	"github.com/hnnsly/library-console/internal/repository"
+	"github.com/rs/zerolog/log"
+)
+
+// ScheduleLoanReminders ставит в очередь напоминания о сроке возврата и о просрочке по подключенным каналам
+func ScheduleLoanReminders(repo *repository.LibraryRepository, dispatcher *notify.Dispatcher, dueSoonDays, overdueRepeatDays int) Job {
+	return Job{
+		Name:     "schedule-loan-reminders",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			queued, err := repo.ScheduleLoanReminders(ctx, dueSoonDays, overdueRepeatDays, dispatcher.Channels())
+			if queued > 0 {
+				log.Info().Int64("queued", queued).Msg("Loan reminders queued")
+			}
+			return err
+		},
+	}
+}
+
+// DeliverNotifications отправляет накопившиеся уведомления и повторяет неудачные
+func DeliverNotifications(dispatcher *notify.Dispatcher) Job {
+	return Job{
+		Name:     "deliver-notifications",
+		Interval: 30 * time.Second,
+		Run: func(ctx context.Context) error {
+			sent, failed, err := dispatcher.DeliverPending(ctx)
+			if sent > 0 || failed > 0 {
+				log.Info().Int("sent", sent).Int("failed", failed).Msg("Notifications delivered")
+			}
+			return err
+		},
+	}
+}
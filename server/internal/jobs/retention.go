@@ -0,0 +1,25 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/hnnsly/library-console/internal/repository"
+	"github.com/rs/zerolog/log"
+)
+
+// AnonymizeInactiveReaders удаляет персональные данные читателей, неактивных дольше inactiveMonths
+func AnonymizeInactiveReaders(repo *repository.LibraryRepository, inactiveMonths int) Job {
+	return Job{
+		Name:     "anonymize-inactive-readers",
+		Interval: 24 * time.Hour,
+		Run: func(ctx context.Context) error {
+			cutoff := time.Now().AddDate(0, -inactiveMonths, 0)
+			anonymized, err := repo.AnonymizeInactiveReaders(ctx, cutoff)
+			if anonymized > 0 {
+				log.Info().Int("anonymized", anonymized).Time("cutoff", cutoff).Msg("Inactive readers anonymized")
+			}
+			return err
+		},
+	}
+}
@@ -0,0 +1,40 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/hnnsly/library-console/internal/repository"
+	"github.com/hnnsly/library-console/internal/webhook"
+	"github.com/rs/zerolog/log"
+)
+
+// EmitOverdueWebhooks публикует loan.overdue по выдачам, которые просрочены с прошлого прохода
+func EmitOverdueWebhooks(repo *repository.LibraryRepository) Job {
+	return Job{
+		Name:     "emit-overdue-webhooks",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			emitted, err := repo.EmitOverdueLoanWebhooks(ctx)
+			if emitted > 0 {
+				log.Info().Int("loans", emitted).Msg("Overdue webhooks queued")
+			}
+			return err
+		},
+	}
+}
+
+// DeliverWebhooks отправляет события подписчикам и повторяет неудачные
+func DeliverWebhooks(deliverer *webhook.Deliverer) Job {
+	return Job{
+		Name:     "deliver-webhooks",
+		Interval: 15 * time.Second,
+		Run: func(ctx context.Context) error {
+			delivered, failed, err := deliverer.DeliverPending(ctx)
+			if delivered > 0 || failed > 0 {
+				log.Info().Int("delivered", delivered).Int("failed", failed).Msg("Webhooks delivered")
+			}
+			return err
+		},
+	}
+}
@@ -0,0 +1,92 @@
+package labels
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/hnnsly/library-console/pkg/barcode"
+)
+
+// CardCode способ кодирования номера билета на карточке читателя
+type CardCode string
+
+const (
+	CardCodeQR      CardCode = "qr"
+	CardCodeBarcode CardCode = "barcode"
+)
+
+// ReaderCard данные карточки читателя
+type ReaderCard struct {
+	FullName     string
+	TicketNumber string
+	ExpiresAt    *time.Time
+}
+
+// Размер карточки соответствует банковской карте ID-1
+const (
+	cardWidth  = 85.6
+	cardHeight = 54.0
+)
+
+// RenderReaderCard рисует PDF-карточку читателя с номером билета в виде QR-кода или штрихкода Code 128
+func RenderReaderCard(w io.Writer, card ReaderCard, code CardCode) error {
+	cv := newPDFCanvas(cardWidth, cardHeight)
+	cv.beginPage()
+
+	const padding = 4.0
+	inner := cardWidth - 2*padding
+
+	// Рамка карточки для вырезания
+	cv.rect(0, 0, cardWidth, 0.2)
+	cv.rect(0, cardHeight-0.2, cardWidth, 0.2)
+	cv.rect(0, 0, 0.2, cardHeight)
+	cv.rect(cardWidth-0.2, 0, 0.2, cardHeight)
+
+	cv.text(cardWidth/2, padding+4, 4, inner, "LIBRARY CARD")
+	cv.rect(padding, padding+6, inner, 0.3)
+
+	validity := "Valid until further notice"
+	if card.ExpiresAt != nil {
+		validity = "Valid until " + card.ExpiresAt.Format("02.01.2006")
+	}
+
+	switch code {
+	case CardCodeQR:
+		qr, err := barcode.EncodeQR(card.TicketNumber)
+		if err != nil {
+			return fmt.Errorf("номер билета %s: %w", card.TicketNumber, err)
+		}
+		// QR-код справа, текст слева от него
+		const side = 26.0
+		module := side / float64(qr.Size+8)
+		qrX := cardWidth - padding - side
+		qrY := cardHeight - padding - side
+		for y, row := range qr.Modules {
+			drawBars(cv, qrX+4*module, qrY+float64(4+y)*module, module, module, row)
+		}
+
+		textCenter := padding + (qrX-padding)/2
+		textArea := qrX - padding - 2
+		cv.text(textCenter, padding+17, 3.6, textArea, card.FullName)
+		cv.text(textCenter, padding+27, 4.4, textArea, card.TicketNumber)
+		cv.text(textCenter, cardHeight-padding-2, 2.6, textArea, validity)
+	case CardCodeBarcode:
+		bc, err := barcode.EncodeCode128(card.TicketNumber)
+		if err != nil {
+			return fmt.Errorf("номер билета %s: %w", card.TicketNumber, err)
+		}
+		cv.text(cardWidth/2, padding+13, 3.6, inner, card.FullName)
+
+		module := inner / float64(len(bc.Modules)+2*quietZone)
+		barTop := padding + 17
+		drawBars(cv, padding+quietZone*module, barTop, module, 14, bc.Modules)
+
+		cv.text(cardWidth/2, barTop+18, 3.4, inner, card.TicketNumber)
+		cv.text(cardWidth/2, cardHeight-padding, 2.6, inner, validity)
+	default:
+		return fmt.Errorf("неизвестный тип кода карточки: %s", code)
+	}
+
+	return cv.finish(w)
+}
@@ -0,0 +1,24 @@
+package labels
+
+import _ "embed"
+
+// Open Sans (Apache License 2.0, см. fonts/OpenSans-LICENSE.txt) содержит кириллицу,
+// поэтому имена читателей и названия книг печатаются как есть
+//
+//go:embed fonts/OpenSans-Regular.ttf
+var openSansRegular []byte
+
+// labelFont шрифт этикеток и карточек; по его метрикам текст обрезается и в PDF, и в SVG
+var labelFont = mustParseTrueType(openSansRegular)
+
+// labelFontFamily семейство шрифта для SVG, метрики которого использует textWidth
+const labelFontFamily = "'Open Sans', Helvetica, Arial, sans-serif"
+
+// textWidth ширина строки в мм для кегля size в мм
+func textWidth(s string, size float64) float64 {
+	total := 0
+	for _, r := range s {
+		total += labelFont.advance(labelFont.glyph(r))
+	}
+	return float64(total) / 1000 * size
+}
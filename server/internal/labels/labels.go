@@ -0,0 +1,189 @@
+package labels
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hnnsly/library-console/pkg/barcode"
+)
+
+// Kind тип этикетки
+type Kind string
+
+const (
+	// KindBarcode этикетка со штрихкодом, названием, шифром и залом
+	KindBarcode Kind = "barcode"
+	// KindSpine корешковая этикетка с шифром хранения
+	KindSpine Kind = "spine"
+)
+
+// Format формат выходного файла
+type Format string
+
+const (
+	FormatPDF Format = "pdf"
+	FormatSVG Format = "svg"
+)
+
+// Label данные одной этикетки
+type Label struct {
+	Code       string
+	Title      string
+	CallNumber string
+	Hall       string
+}
+
+// layout раскладка этикеток на листе A4, размеры в миллиметрах
+type layout struct {
+	Width, Height    float64
+	Cols, Rows       int
+	MarginX, MarginY float64
+}
+
+var layouts = map[Kind]layout{
+	KindBarcode: {Width: 70, Height: 37, Cols: 3, Rows: 8, MarginX: 0, MarginY: 0.5},
+	KindSpine:   {Width: 30, Height: 40, Cols: 6, Rows: 7, MarginX: 15, MarginY: 8.5},
+}
+
+const (
+	pageWidth  = 210.0
+	pageHeight = 297.0
+	// quietZone ширина пустых полей штрихкода в модулях
+	quietZone = 10
+)
+
+// canvas примитивы рисования, общие для SVG и PDF; координаты в мм от левого верхнего угла
+type canvas interface {
+	beginPage()
+	rect(x, y, w, h float64)
+	// text выводит строку с центром в x, при необходимости обрезая ее до maxWidth
+	text(x, y, size, maxWidth float64, s string)
+	finish(w io.Writer) error
+}
+
+// Render рисует этикетки и записывает результат в w
+func Render(w io.Writer, format Format, kind Kind, symbology barcode.Symbology, items []Label) error {
+	l, ok := layouts[kind]
+	if !ok {
+		return fmt.Errorf("неизвестный тип этикетки: %s", kind)
+	}
+
+	// Штрихкоды кодируются заранее, чтобы не выпускать частично сформированный файл
+	codes := make([]*barcode.Barcode, len(items))
+	if kind == KindBarcode {
+		for i, item := range items {
+			code, err := barcode.Encode(symbology, item.Code)
+			if err != nil {
+				return fmt.Errorf("шифр %s: %w", item.Code, err)
+			}
+			codes[i] = code
+		}
+	}
+
+	var cv canvas
+	pages := (len(items) + l.Cols*l.Rows - 1) / (l.Cols * l.Rows)
+	switch format {
+	case FormatSVG:
+		cv = newSVGCanvas(pages)
+	case FormatPDF:
+		cv = newPDFCanvas(pageWidth, pageHeight)
+	default:
+		return fmt.Errorf("неизвестный формат этикеток: %s", format)
+	}
+
+	perPage := l.Cols * l.Rows
+	for i, item := range items {
+		if i%perPage == 0 {
+			cv.beginPage()
+		}
+		cell := i % perPage
+		x := l.MarginX + float64(cell%l.Cols)*l.Width
+		y := l.MarginY + float64(cell/l.Cols)*l.Height
+
+		if kind == KindBarcode {
+			drawBarcodeLabel(cv, l, x, y, item, codes[i])
+		} else {
+			drawSpineLabel(cv, l, x, y, item)
+		}
+	}
+
+	return cv.finish(w)
+}
+
+func drawBarcodeLabel(cv canvas, l layout, x, y float64, item Label, code *barcode.Barcode) {
+	const padding = 3.0
+	inner := l.Width - 2*padding
+
+	cv.text(x+l.Width/2, y+padding+3, 3.2, inner, item.Title)
+
+	// Ширина модуля подбирается под этикетку с учетом тихих зон
+	total := len(code.Modules) + 2*quietZone
+	module := inner / float64(total)
+	barTop := y + padding + 5.5
+	barHeight := l.Height - 2*padding - 15
+	drawBars(cv, x+padding+quietZone*module, barTop, module, barHeight, code.Modules)
+
+	cv.text(x+l.Width/2, barTop+barHeight+3.5, 3, inner, code.Text)
+
+	footer := joinNonEmpty(" · ", item.CallNumber, item.Hall)
+	cv.text(x+l.Width/2, y+l.Height-padding-0.5, 2.6, inner, footer)
+}
+
+func drawSpineLabel(cv canvas, l layout, x, y float64, item Label) {
+	const padding = 2.5
+	inner := l.Width - 2*padding
+
+	// Шифр хранения печатается крупно, по строке на каждую часть
+	lines := strings.FieldsFunc(item.CallNumber, func(r rune) bool { return r == ' ' || r == '/' })
+	if len(lines) == 0 {
+		lines = []string{item.Code}
+	}
+	if len(lines) > 4 {
+		lines = append(lines[:3], strings.Join(lines[3:], " "))
+	}
+	for i, line := range lines {
+		cv.text(x+l.Width/2, y+padding+6+float64(i)*6, 5, inner, line)
+	}
+
+	cv.text(x+l.Width/2, y+l.Height-padding-4, 2.4, inner, item.Hall)
+	cv.text(x+l.Width/2, y+l.Height-padding, 2.4, inner, item.Code)
+}
+
+// drawBars рисует соседние темные модули одним прямоугольником
+func drawBars(cv canvas, x, y, module, height float64, modules []bool) {
+	for i := 0; i < len(modules); {
+		if !modules[i] {
+			i++
+			continue
+		}
+		j := i
+		for j < len(modules) && modules[j] {
+			j++
+		}
+		cv.rect(x+float64(i)*module, y, float64(j-i)*module, height)
+		i = j
+	}
+}
+
+// fitText обрезает строку с многоточием, чтобы она поместилась в ширину в мм
+func fitText(s string, size, width float64) string {
+	if textWidth(s, size) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && textWidth(string(runes)+"…", size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimSpace(string(runes)) + "…"
+}
+
+func joinNonEmpty(sep string, parts ...string) string {
+	nonEmpty := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p != "" {
+			nonEmpty = append(nonEmpty, p)
+		}
+	}
+	return strings.Join(nonEmpty, sep)
+}
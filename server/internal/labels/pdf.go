@@ -0,0 +1,191 @@
+package labels
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf16"
+)
+
+// mmToPt перевод миллиметров в пункты PDF
+const mmToPt = 72 / 25.4
+
+// pdfCanvas минимальный генератор PDF 1.4. Текст набирается встроенным подмножеством шрифта
+// TrueType в кодировке Identity-H, поэтому кириллица печатается без транслитерации
+type pdfCanvas struct {
+	pages         []*bytes.Buffer
+	width, height float64
+	// glyphs использованные глифы и символы, которым они соответствуют, для подмножества и ToUnicode
+	glyphs map[uint16]rune
+}
+
+func newPDFCanvas(width, height float64) *pdfCanvas {
+	return &pdfCanvas{width: width, height: height, glyphs: map[uint16]rune{}}
+}
+
+func (cv *pdfCanvas) beginPage() {
+	cv.pages = append(cv.pages, &bytes.Buffer{})
+}
+
+func (cv *pdfCanvas) current() *bytes.Buffer {
+	if len(cv.pages) == 0 {
+		cv.beginPage()
+	}
+	return cv.pages[len(cv.pages)-1]
+}
+
+func (cv *pdfCanvas) rect(x, y, w, h float64) {
+	fmt.Fprintf(cv.current(), "%.3f %.3f %.3f %.3f re f\n",
+		x*mmToPt, (cv.height-y-h)*mmToPt, w*mmToPt, h*mmToPt)
+}
+
+func (cv *pdfCanvas) text(x, y, size, maxWidth float64, s string) {
+	s = fitText(s, size, maxWidth)
+	if s == "" {
+		return
+	}
+	left := x - textWidth(s, size)/2
+	fmt.Fprintf(cv.current(), "BT /F1 %.2f Tf %.3f %.3f Td <%s> Tj ET\n",
+		size*mmToPt, left*mmToPt, (cv.height-y)*mmToPt, cv.encode(s))
+}
+
+// encode записывает строку номерами глифов в шестнадцатеричном виде и запоминает глифы для подмножества
+func (cv *pdfCanvas) encode(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		glyph := labelFont.glyph(r)
+		if _, ok := cv.glyphs[glyph]; !ok && glyph != 0 {
+			cv.glyphs[glyph] = r
+		}
+		fmt.Fprintf(&b, "%04X", glyph)
+	}
+	return b.String()
+}
+
+func (cv *pdfCanvas) finish(w io.Writer) error {
+	if len(cv.pages) == 0 {
+		cv.beginPage()
+	}
+
+	// Объекты: 1 — каталог, 2 — дерево страниц, 3–7 — шрифт, далее пары страница/содержимое
+	const firstPage = 8
+	var out bytes.Buffer
+	offsets := []int{}
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+	stream := func(dict string, data []byte) {
+		object(fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(data), data))
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	kids := &bytes.Buffer{}
+	for i := range cv.pages {
+		fmt.Fprintf(kids, "%d 0 R ", firstPage+i*2)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", bytes.TrimSpace(kids.Bytes()), len(cv.pages)))
+
+	glyphs := make([]uint16, 0, len(cv.glyphs))
+	for glyph := range cv.glyphs {
+		glyphs = append(glyphs, glyph)
+	}
+	slices.Sort(glyphs)
+	fontName := subsetTag(glyphs) + "+" + labelFont.postScriptName
+	fontFile, err := deflate(labelFont.subset(glyphs))
+	if err != nil {
+		return fmt.Errorf("сжатие шрифта: %w", err)
+	}
+
+	f := labelFont
+	object(fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [4 0 R] /ToUnicode 7 0 R >>", fontName))
+	object(fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor 5 0 R /DW %d /W [%s] /CIDToGIDMap /Identity >>",
+		fontName, f.advance(0), glyphWidths(glyphs)))
+	object(fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 32 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 6 0 R >>",
+		fontName, f.scale(f.bbox[0]), f.scale(f.bbox[1]), f.scale(f.bbox[2]), f.scale(f.bbox[3]),
+		f.scale(f.ascent), f.scale(f.descent), f.scale(f.capHeight)))
+	stream("/Filter /FlateDecode", fontFile)
+	stream("", toUnicodeCMap(glyphs, cv.glyphs))
+
+	for i, content := range cv.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			cv.width*mmToPt, cv.height*mmToPt, firstPage+1+i*2))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.Bytes()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%EOF\n", len(offsets)+1, xref)
+
+	_, err = out.WriteTo(w)
+	return err
+}
+
+// subsetTag префикс имени подмножества шрифта: шесть заглавных букв, зависящих от набора глифов
+func subsetTag(glyphs []uint16) string {
+	h := fnv.New32a()
+	for _, glyph := range glyphs {
+		h.Write([]byte{byte(glyph >> 8), byte(glyph)})
+	}
+	sum := h.Sum32()
+	tag := make([]byte, 6)
+	for i := range tag {
+		tag[i] = 'A' + byte(sum%26)
+		sum /= 26
+	}
+	return string(tag)
+}
+
+// glyphWidths массив /W с ширинами использованных глифов
+func glyphWidths(glyphs []uint16) string {
+	var b strings.Builder
+	for _, glyph := range glyphs {
+		fmt.Fprintf(&b, "%d [%d] ", glyph, labelFont.advance(glyph))
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// toUnicodeCMap сопоставляет глифы символам, чтобы текст из PDF можно было копировать и искать
+func toUnicodeCMap(glyphs []uint16, runes map[uint16]rune) []byte {
+	var b bytes.Buffer
+	b.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	// В одном блоке bfchar допускается не больше 100 записей
+	for start := 0; start < len(glyphs); start += 100 {
+		chunk := glyphs[start:min(start+100, len(glyphs))]
+		fmt.Fprintf(&b, "%d beginbfchar\n", len(chunk))
+		for _, glyph := range chunk {
+			fmt.Fprintf(&b, "<%04X> <", glyph)
+			for _, unit := range utf16.Encode([]rune{runes[glyph]}) {
+				fmt.Fprintf(&b, "%04X", unit)
+			}
+			b.WriteString(">\n")
+		}
+		b.WriteString("endbfchar\n")
+	}
+	b.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend")
+	return b.Bytes()
+}
+
+func deflate(data []byte) ([]byte, error) {
+	var b bytes.Buffer
+	zw := zlib.NewWriter(&b)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
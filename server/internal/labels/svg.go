@@ -0,0 +1,51 @@
+package labels
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// svgCanvas выводит все листы одним SVG, располагая страницы друг под другом
+type svgCanvas struct {
+	buf  bytes.Buffer
+	page int
+}
+
+func newSVGCanvas(pages int) *svgCanvas {
+	cv := &svgCanvas{page: -1}
+	height := pageHeight * float64(max(pages, 1))
+	fmt.Fprintf(&cv.buf, `<?xml version="1.0" encoding="UTF-8"?>`+"\n")
+	fmt.Fprintf(&cv.buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%gmm" height="%gmm" viewBox="0 0 %g %g">`+"\n",
+		pageWidth, height, pageWidth, height)
+	fmt.Fprintf(&cv.buf, `<g font-family="%s" text-anchor="middle" fill="#000">`+"\n", labelFontFamily)
+	return cv
+}
+
+func (cv *svgCanvas) beginPage() {
+	cv.page++
+}
+
+func (cv *svgCanvas) offset() float64 {
+	return float64(cv.page) * pageHeight
+}
+
+func (cv *svgCanvas) rect(x, y, w, h float64) {
+	fmt.Fprintf(&cv.buf, `<rect x="%.3f" y="%.3f" width="%.3f" height="%.3f"/>`+"\n", x, y+cv.offset(), w, h)
+}
+
+func (cv *svgCanvas) text(x, y, size, maxWidth float64, s string) {
+	if s == "" {
+		return
+	}
+	fmt.Fprintf(&cv.buf, `<text x="%.3f" y="%.3f" font-size="%.2f">`, x, y+cv.offset(), size)
+	xml.EscapeText(&cv.buf, []byte(fitText(s, size, maxWidth)))
+	cv.buf.WriteString("</text>\n")
+}
+
+func (cv *svgCanvas) finish(w io.Writer) error {
+	cv.buf.WriteString("</g>\n</svg>\n")
+	_, err := cv.buf.WriteTo(w)
+	return err
+}
@@ -0,0 +1,384 @@
+package labels
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// trueTypeFont разобранный шрифт TrueType: метрики, таблица символов и контуры для подмножества
+type trueTypeFont struct {
+	tables     map[string][]byte
+	unitsPerEm int
+	numGlyphs  int
+	advances   []int
+	cmap       map[rune]uint16
+	loca       []uint32
+
+	bbox           [4]int
+	ascent         int
+	descent        int
+	capHeight      int
+	postScriptName string
+}
+
+// requiredTables таблицы, без которых шрифт нельзя ни измерить, ни встроить в PDF
+var requiredTables = []string{"head", "hhea", "maxp", "hmtx", "cmap", "loca", "glyf"}
+
+// subsetTables таблицы встраиваемого подмножества; cmap не нужна, глифы адресуются номерами
+var subsetTables = []string{"cvt ", "fpgm", "glyf", "head", "hhea", "hmtx", "loca", "maxp", "prep"}
+
+func mustParseTrueType(data []byte) *trueTypeFont {
+	f, err := parseTrueType(data)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+func parseTrueType(data []byte) (*trueTypeFont, error) {
+	if len(data) < 12 {
+		return nil, errors.New("файл шрифта слишком короткий")
+	}
+	if v := binary.BigEndian.Uint32(data); v != 0x00010000 && v != 0x74727565 {
+		return nil, fmt.Errorf("неподдерживаемый формат шрифта: %08x", v)
+	}
+
+	f := &trueTypeFont{tables: map[string][]byte{}}
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	for i := 0; i < numTables; i++ {
+		rec := 12 + 16*i
+		if rec+16 > len(data) {
+			return nil, errors.New("оглавление шрифта обрезано")
+		}
+		tag := string(data[rec : rec+4])
+		offset := int(binary.BigEndian.Uint32(data[rec+8:]))
+		length := int(binary.BigEndian.Uint32(data[rec+12:]))
+		if offset+length > len(data) {
+			return nil, fmt.Errorf("таблица %s выходит за пределы файла", tag)
+		}
+		f.tables[tag] = data[offset : offset+length]
+	}
+	for _, tag := range requiredTables {
+		if _, ok := f.tables[tag]; !ok {
+			return nil, fmt.Errorf("в шрифте нет таблицы %s", tag)
+		}
+	}
+
+	head := f.tables["head"]
+	hhea := f.tables["hhea"]
+	f.unitsPerEm = int(binary.BigEndian.Uint16(head[18:]))
+	for i := range f.bbox {
+		f.bbox[i] = int(int16(binary.BigEndian.Uint16(head[36+2*i:])))
+	}
+	f.numGlyphs = int(binary.BigEndian.Uint16(f.tables["maxp"][4:]))
+	f.ascent = int(int16(binary.BigEndian.Uint16(hhea[4:])))
+	f.descent = int(int16(binary.BigEndian.Uint16(hhea[6:])))
+	f.capHeight = f.ascent
+	if os2 := f.tables["OS/2"]; len(os2) >= 90 && binary.BigEndian.Uint16(os2) >= 2 {
+		f.capHeight = int(int16(binary.BigEndian.Uint16(os2[88:])))
+	}
+
+	numHMetrics := int(binary.BigEndian.Uint16(hhea[34:]))
+	hmtx := f.tables["hmtx"]
+	if numHMetrics == 0 || len(hmtx) < 4*numHMetrics {
+		return nil, errors.New("таблица hmtx повреждена")
+	}
+	f.advances = make([]int, f.numGlyphs)
+	for g := range f.advances {
+		f.advances[g] = int(binary.BigEndian.Uint16(hmtx[4*min(g, numHMetrics-1):]))
+	}
+
+	loca := f.tables["loca"]
+	f.loca = make([]uint32, f.numGlyphs+1)
+	long := binary.BigEndian.Uint16(head[50:]) == 1
+	for g := range f.loca {
+		switch {
+		case long && len(loca) >= 4*(g+1):
+			f.loca[g] = binary.BigEndian.Uint32(loca[4*g:])
+		case !long && len(loca) >= 2*(g+1):
+			f.loca[g] = uint32(binary.BigEndian.Uint16(loca[2*g:])) * 2
+		default:
+			return nil, errors.New("таблица loca повреждена")
+		}
+	}
+	if int(f.loca[f.numGlyphs]) > len(f.tables["glyf"]) {
+		return nil, errors.New("таблица glyf короче, чем указано в loca")
+	}
+
+	var err error
+	if f.cmap, err = parseCmap(f.tables["cmap"]); err != nil {
+		return nil, err
+	}
+	f.postScriptName = postScriptName(f.tables["name"])
+
+	return f, nil
+}
+
+// parseCmap читает юникодную таблицу символов: формат 12 для всего Юникода или формат 4 для BMP
+func parseCmap(data []byte) (map[rune]uint16, error) {
+	if len(data) < 4 {
+		return nil, errors.New("таблица cmap повреждена")
+	}
+	var bmp, full []byte
+	for i := 0; i < int(binary.BigEndian.Uint16(data[2:])); i++ {
+		rec := 4 + 8*i
+		if rec+8 > len(data) {
+			break
+		}
+		platform := binary.BigEndian.Uint16(data[rec:])
+		encoding := binary.BigEndian.Uint16(data[rec+2:])
+		offset := int(binary.BigEndian.Uint32(data[rec+4:]))
+		if offset+4 > len(data) {
+			continue
+		}
+		sub := data[offset:]
+		switch format := binary.BigEndian.Uint16(sub); {
+		case format == 12 && (platform == 3 && encoding == 10 || platform == 0):
+			full = sub
+		case format == 4 && (platform == 3 && encoding == 1 || platform == 0):
+			bmp = sub
+		}
+	}
+
+	cmap := map[rune]uint16{}
+	switch {
+	case full != nil:
+		if len(full) < 16 {
+			return nil, errors.New("таблица cmap формата 12 повреждена")
+		}
+		groups := int(binary.BigEndian.Uint32(full[12:]))
+		if len(full) < 16+12*groups {
+			return nil, errors.New("таблица cmap формата 12 повреждена")
+		}
+		for i := 0; i < groups; i++ {
+			g := full[16+12*i:]
+			start := rune(binary.BigEndian.Uint32(g))
+			end := rune(binary.BigEndian.Uint32(g[4:]))
+			glyph := binary.BigEndian.Uint32(g[8:])
+			for r := start; r <= end && r-start < 0x10000; r++ {
+				cmap[r] = uint16(glyph + uint32(r-start))
+			}
+		}
+	case bmp != nil:
+		if len(bmp) < 14 {
+			return nil, errors.New("таблица cmap формата 4 повреждена")
+		}
+		segX2 := int(binary.BigEndian.Uint16(bmp[6:]))
+		if len(bmp) < 16+4*segX2 {
+			return nil, errors.New("таблица cmap формата 4 повреждена")
+		}
+		ends := bmp[14:]
+		starts := bmp[16+segX2:]
+		deltas := bmp[16+2*segX2:]
+		rangeOffsets := bmp[16+3*segX2:]
+		for i := 0; i < segX2/2; i++ {
+			start := int(binary.BigEndian.Uint16(starts[2*i:]))
+			end := int(binary.BigEndian.Uint16(ends[2*i:]))
+			delta := int(binary.BigEndian.Uint16(deltas[2*i:]))
+			rangeOffset := int(binary.BigEndian.Uint16(rangeOffsets[2*i:]))
+			for c := start; c <= end && c != 0xFFFF; c++ {
+				glyph := (c + delta) & 0xFFFF
+				if rangeOffset != 0 {
+					// Смещение отсчитывается от самого поля idRangeOffset
+					pos := 16 + 3*segX2 + 2*i + rangeOffset + 2*(c-start)
+					if pos+2 > len(bmp) {
+						continue
+					}
+					if glyph = int(binary.BigEndian.Uint16(bmp[pos:])); glyph != 0 {
+						glyph = (glyph + delta) & 0xFFFF
+					}
+				}
+				if glyph != 0 {
+					cmap[rune(c)] = uint16(glyph)
+				}
+			}
+		}
+	default:
+		return nil, errors.New("в шрифте нет юникодной таблицы символов")
+	}
+	return cmap, nil
+}
+
+// postScriptName берет имя шрифта из записи 6 таблицы name, иначе возвращает запасное
+func postScriptName(data []byte) string {
+	if len(data) >= 6 {
+		count := int(binary.BigEndian.Uint16(data[2:]))
+		storage := int(binary.BigEndian.Uint16(data[4:]))
+		for i := 0; i < count && 6+12*(i+1) <= len(data); i++ {
+			rec := data[6+12*i:]
+			platform := binary.BigEndian.Uint16(rec)
+			nameID := binary.BigEndian.Uint16(rec[6:])
+			length := int(binary.BigEndian.Uint16(rec[8:]))
+			offset := storage + int(binary.BigEndian.Uint16(rec[10:]))
+			if nameID != 6 || offset+length > len(data) {
+				continue
+			}
+			raw := data[offset : offset+length]
+			var name []byte
+			if platform == 3 || platform == 0 {
+				// UTF-16BE; имя PostScript состоит только из ASCII
+				for j := 1; j < len(raw); j += 2 {
+					name = append(name, raw[j])
+				}
+			} else {
+				name = raw
+			}
+			if len(name) > 0 {
+				return string(name)
+			}
+		}
+	}
+	return "EmbeddedFont"
+}
+
+// glyph номер глифа символа; 0 — глиф .notdef для символов, которых нет в шрифте
+func (f *trueTypeFont) glyph(r rune) uint16 {
+	return f.cmap[r]
+}
+
+// advance ширина глифа в тысячных долях кегля, как ее ожидает PDF
+func (f *trueTypeFont) advance(glyph uint16) int {
+	if int(glyph) >= len(f.advances) {
+		return 0
+	}
+	return f.advances[glyph] * 1000 / f.unitsPerEm
+}
+
+// scale переводит величину из единиц шрифта в тысячные доли кегля
+func (f *trueTypeFont) scale(v int) int {
+	return v * 1000 / f.unitsPerEm
+}
+
+func (f *trueTypeFont) glyphData(glyph uint16) []byte {
+	return f.tables["glyf"][f.loca[glyph]:f.loca[glyph+1]]
+}
+
+// subset собирает шрифт только с использованными глифами и глифами, из которых они составлены.
+// Номера глифов сохраняются, остальные глифы остаются пустыми, поэтому в PDF хватает CIDToGIDMap /Identity
+func (f *trueTypeFont) subset(used []uint16) []byte {
+	keep := map[uint16]bool{}
+	queue := append([]uint16{0}, used...)
+	for len(queue) > 0 {
+		g := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		if keep[g] || int(g) >= f.numGlyphs {
+			continue
+		}
+		keep[g] = true
+		queue = append(queue, compositeComponents(f.glyphData(g))...)
+	}
+
+	var glyf bytes.Buffer
+	loca := make([]byte, 0, 4*(f.numGlyphs+1))
+	for g := 0; g < f.numGlyphs; g++ {
+		loca = binary.BigEndian.AppendUint32(loca, uint32(glyf.Len()))
+		if keep[uint16(g)] {
+			glyf.Write(f.glyphData(uint16(g)))
+			for glyf.Len()%4 != 0 {
+				glyf.WriteByte(0)
+			}
+		}
+	}
+	loca = binary.BigEndian.AppendUint32(loca, uint32(glyf.Len()))
+
+	head := slices.Clone(f.tables["head"])
+	binary.BigEndian.PutUint32(head[8:], 0)
+	binary.BigEndian.PutUint16(head[50:], 1)
+
+	tables := map[string][]byte{"glyf": glyf.Bytes(), "loca": loca, "head": head}
+	for _, tag := range subsetTables {
+		if _, ok := tables[tag]; !ok && f.tables[tag] != nil {
+			tables[tag] = f.tables[tag]
+		}
+	}
+	return writeTrueType(tables)
+}
+
+// compositeComponents номера глифов, из которых составлен составной глиф
+func compositeComponents(data []byte) []uint16 {
+	if len(data) < 10 || int16(binary.BigEndian.Uint16(data)) >= 0 {
+		return nil
+	}
+	var components []uint16
+	for pos := 10; pos+4 <= len(data); {
+		flags := binary.BigEndian.Uint16(data[pos:])
+		components = append(components, binary.BigEndian.Uint16(data[pos+2:]))
+		pos += 4
+		if flags&0x0001 != 0 {
+			pos += 4
+		} else {
+			pos += 2
+		}
+		switch {
+		case flags&0x0008 != 0:
+			pos += 2
+		case flags&0x0040 != 0:
+			pos += 4
+		case flags&0x0080 != 0:
+			pos += 8
+		}
+		if flags&0x0020 == 0 {
+			break
+		}
+	}
+	return components
+}
+
+// writeTrueType собирает файл шрифта из таблиц с оглавлением и контрольными суммами
+func writeTrueType(tables map[string][]byte) []byte {
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	n := len(tags)
+	entrySelector := 0
+	for 1<<(entrySelector+1) <= n {
+		entrySelector++
+	}
+	searchRange := 16 << entrySelector
+
+	var out bytes.Buffer
+	header := []uint16{uint16(n), uint16(searchRange), uint16(entrySelector), uint16(16*n - searchRange)}
+	binary.Write(&out, binary.BigEndian, uint32(0x00010000))
+	binary.Write(&out, binary.BigEndian, header)
+
+	offset := 12 + 16*n
+	headOffset := -1
+	for _, tag := range tags {
+		data := tables[tag]
+		out.WriteString(tag)
+		binary.Write(&out, binary.BigEndian, []uint32{checksum(data), uint32(offset), uint32(len(data))})
+		if tag == "head" {
+			headOffset = offset
+		}
+		offset += (len(data) + 3) &^ 3
+	}
+	for _, tag := range tags {
+		out.Write(tables[tag])
+		for out.Len()%4 != 0 {
+			out.WriteByte(0)
+		}
+	}
+
+	font := out.Bytes()
+	if headOffset >= 0 {
+		binary.BigEndian.PutUint32(font[headOffset+8:], 0xB1B0AFBA-checksum(font))
+	}
+	return font
+}
+
+func checksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
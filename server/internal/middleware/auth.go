@@ -31,6 +31,7 @@ func NewAuthMiddleware(repo *repository.LibraryRepository, ttl time.Duration) fi
 			})
 		}
 
+		c.Locals("sessionID", sid)
 		c.Locals("userID", session.UserID.String())
 		c.Locals("userRole", string(session.Role))
 
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"io"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// NewBodyLimitMiddleware reads the streamed request body into memory and rejects bodies
+// larger than limit bytes. The server streams request bodies, so this middleware is what
+// enforces the size limit; chunked bodies without Content-Length are limited as well.
+func NewBodyLimitMiddleware(limit int) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		tooLarge := func() error {
+			// The rest of the body is left unread, so the connection cannot be reused
+			c.Context().SetConnectionClose()
+			return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+				"error": "request body too large",
+			})
+		}
+
+		if c.Request().Header.ContentLength() > limit {
+			return tooLarge()
+		}
+		stream := c.Context().RequestBodyStream()
+		if stream == nil {
+			return c.Next()
+		}
+
+		body, err := io.ReadAll(io.LimitReader(stream, int64(limit)+1))
+		if err != nil {
+			c.Context().SetConnectionClose()
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "failed to read request body",
+			})
+		}
+		if len(body) > limit {
+			return tooLarge()
+		}
+		c.Request().SetBody(body)
+
+		return c.Next()
+	}
+}
+
+// NewUnreadBodyMiddleware closes the connection when the handlers after it return without
+// reading the streamed request body, e.g. when authentication rejects a request before its
+// body limit runs. The unread rest of the body would otherwise be parsed as the next request.
+func NewUnreadBodyMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+		if c.Context().RequestBodyStream() != nil {
+			c.Context().SetConnectionClose()
+		}
+		return err
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hnnsly/library-console/internal/repository"
+	"github.com/rs/zerolog/log"
+)
+
+// NewRateLimitMiddleware limits each client IP to limit requests per window.
+// Behind a reverse proxy the client IP comes from the proxy header only when the proxy
+// is listed in the trustedProxies setting; otherwise all clients share the proxy address.
+// Counters live in Redis, so the limit holds across server instances.
+// If Redis is unavailable, requests are let through rather than failing the API.
+func NewRateLimitMiddleware(repo *repository.LibraryRepository, name string, limit int, window time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		count, reset, err := repo.RateLimit(c.Context(), name+":"+c.IP(), window)
+		if err != nil {
+			log.Warn().Err(err).Str("limiter", name).Msg("Rate limiter unavailable")
+			return c.Next()
+		}
+
+		remaining := max(limit-int(count), 0)
+		resetSeconds := int(math.Ceil(reset.Seconds()))
+		c.Set("X-RateLimit-Limit", strconv.Itoa(limit))
+		c.Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		c.Set("X-RateLimit-Reset", strconv.Itoa(resetSeconds))
+
+		if count > int64(limit) {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(resetSeconds))
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error": "too many requests",
+			})
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hnnsly/library-console/internal/repository"
+)
+
+// ReaderSessionCookie is the portal session cookie, distinct from the staff session cookie
+const ReaderSessionCookie = "reader_session"
+
+// NewReaderAuthMiddleware authenticates readers of the self-service portal.
+// Staff sessions live in another Redis namespace and are not accepted here.
+func NewReaderAuthMiddleware(repo *repository.LibraryRepository, ttl time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		sid := c.Cookies(ReaderSessionCookie)
+		if sid == "" {
+			if ah := c.Get("Authorization"); strings.HasPrefix(ah, "Bearer ") {
+				sid = strings.TrimPrefix(ah, "Bearer ")
+			}
+		}
+		if sid == "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "unauthorized: empty session",
+			})
+		}
+
+		readerID, err := repo.GetReaderSession(c.Context(), sid, ttl)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "unauthorized: " + err.Error(),
+			})
+		}
+
+		c.Locals("readerSessionID", sid)
+		c.Locals("readerID", readerID.String())
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,315 @@
+package ncip
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+const (
+	// Namespace пространство имен сообщений NCIP 2
+	Namespace = "http://www.niso.org/2008/ncip"
+	// Version схема, на которую ссылаются ответы
+	Version = "http://www.niso.org/schemas/ncip/v2_02/ncip_v2_02.xsd"
+)
+
+// Message корневой элемент NCIPMessage: в запросе заполнена одна услуга, в ответе — один ответ
+type Message struct {
+	XMLName xml.Name `xml:"NCIPMessage"`
+	Xmlns   string   `xml:"xmlns,attr,omitempty"`
+	Version string   `xml:"version,attr,omitempty"`
+
+	LookupUser   *LookupUser   `xml:"LookupUser,omitempty"`
+	LookupItem   *LookupItem   `xml:"LookupItem,omitempty"`
+	CheckOutItem *CheckOutItem `xml:"CheckOutItem,omitempty"`
+	CheckInItem  *CheckInItem  `xml:"CheckInItem,omitempty"`
+	RequestItem  *RequestItem  `xml:"RequestItem,omitempty"`
+	RenewItem    *RenewItem    `xml:"RenewItem,omitempty"`
+
+	LookupUserResponse   *LookupUserResponse   `xml:"LookupUserResponse,omitempty"`
+	LookupItemResponse   *LookupItemResponse   `xml:"LookupItemResponse,omitempty"`
+	CheckOutItemResponse *CheckOutItemResponse `xml:"CheckOutItemResponse,omitempty"`
+	CheckInItemResponse  *CheckInItemResponse  `xml:"CheckInItemResponse,omitempty"`
+	RequestItemResponse  *RequestItemResponse  `xml:"RequestItemResponse,omitempty"`
+	RenewItemResponse    *RenewItemResponse    `xml:"RenewItemResponse,omitempty"`
+
+	// Problem ответ на сообщение, которое не удалось разобрать или отнести к услуге
+	Problem *Problem `xml:"Problem,omitempty"`
+}
+
+// InitiationHeader заголовок запроса
+type InitiationHeader struct {
+	FromAgencyID *AgencyRef `xml:"FromAgencyId,omitempty"`
+	ToAgencyID   *AgencyRef `xml:"ToAgencyId,omitempty"`
+}
+
+// ResponseHeader заголовок ответа: агентства запроса меняются местами
+type ResponseHeader struct {
+	FromAgencyID *AgencyRef `xml:"FromAgencyId,omitempty"`
+	ToAgencyID   *AgencyRef `xml:"ToAgencyId,omitempty"`
+}
+
+// AgencyRef ссылка на агентство в заголовках
+type AgencyRef struct {
+	AgencyID string `xml:"AgencyId"`
+}
+
+// UserID идентификатор читателя; AgencyId — библиотека, которая его выдала
+type UserID struct {
+	AgencyID            string `xml:"AgencyId,omitempty"`
+	UserIdentifierValue string `xml:"UserIdentifierValue"`
+}
+
+// ItemID идентификатор экземпляра; AgencyId — библиотека-владелец
+type ItemID struct {
+	AgencyID            string `xml:"AgencyId,omitempty"`
+	ItemIdentifierValue string `xml:"ItemIdentifierValue"`
+}
+
+// RequestID идентификатор запроса в системе партнера
+type RequestID struct {
+	AgencyID               string `xml:"AgencyId,omitempty"`
+	RequestIdentifierValue string `xml:"RequestIdentifierValue"`
+}
+
+// AuthenticationInput данные для проверки читателя, например номер билета и PIN
+type AuthenticationInput struct {
+	AuthenticationInputData      string `xml:"AuthenticationInputData"`
+	AuthenticationDataFormatType string `xml:"AuthenticationDataFormatType,omitempty"`
+	AuthenticationInputType      string `xml:"AuthenticationInputType"`
+}
+
+// Problem описание отказа; ответ с Problem не содержит остальных полей
+type Problem struct {
+	ProblemType    string `xml:"ProblemType"`
+	ProblemDetail  string `xml:"ProblemDetail,omitempty"`
+	ProblemElement string `xml:"ProblemElement,omitempty"`
+	ProblemValue   string `xml:"ProblemValue,omitempty"`
+}
+
+// BibliographicDescription библиографическое описание экземпляра
+type BibliographicDescription struct {
+	Author              string               `xml:"Author,omitempty"`
+	BibliographicItemID *BibliographicItemID `xml:"BibliographicItemId,omitempty"`
+	PublicationDate     string               `xml:"PublicationDate,omitempty"`
+	Publisher           string               `xml:"Publisher,omitempty"`
+	Title               string               `xml:"Title,omitempty"`
+}
+
+// BibliographicItemID идентификатор издания, у нас — ISBN
+type BibliographicItemID struct {
+	BibliographicItemIdentifier     string `xml:"BibliographicItemIdentifier"`
+	BibliographicItemIdentifierCode string `xml:"BibliographicItemIdentifierCode,omitempty"`
+}
+
+// BibliographicID издание в RequestItem, когда партнеру подходит любой экземпляр
+type BibliographicID struct {
+	BibliographicItemID *BibliographicItemID `xml:"BibliographicItemId,omitempty"`
+}
+
+// ItemOptionalFields сведения об экземпляре
+type ItemOptionalFields struct {
+	BibliographicDescription *BibliographicDescription `xml:"BibliographicDescription,omitempty"`
+	CirculationStatus        string                    `xml:"CirculationStatus,omitempty"`
+	Location                 *Location                 `xml:"Location,omitempty"`
+	DateDue                  string                    `xml:"DateDue,omitempty"`
+}
+
+// Location местонахождение экземпляра — читальный зал
+type Location struct {
+	LocationType string       `xml:"LocationType"`
+	LocationName LocationName `xml:"LocationName"`
+}
+
+// LocationName название места с одним уровнем
+type LocationName struct {
+	LocationNameInstance []LocationNameInstance `xml:"LocationNameInstance"`
+}
+
+// LocationNameInstance уровень названия места
+type LocationNameInstance struct {
+	LocationNameLevel int    `xml:"LocationNameLevel"`
+	LocationNameValue string `xml:"LocationNameValue"`
+}
+
+// UserOptionalFields сведения о читателе
+type UserOptionalFields struct {
+	NameInformation        *NameInformation         `xml:"NameInformation,omitempty"`
+	UserAddressInformation []UserAddressInformation `xml:"UserAddressInformation,omitempty"`
+	UserPrivilege          []UserPrivilege          `xml:"UserPrivilege,omitempty"`
+	BlockOrTrap            []BlockOrTrap            `xml:"BlockOrTrap,omitempty"`
+	UserLanguage           string                   `xml:"UserLanguage,omitempty"`
+}
+
+// NameInformation имя читателя одной строкой
+type NameInformation struct {
+	PersonalNameInformation PersonalNameInformation `xml:"PersonalNameInformation"`
+}
+
+// PersonalNameInformation имя в свободной форме
+type PersonalNameInformation struct {
+	UnstructuredPersonalUserName string `xml:"UnstructuredPersonalUserName"`
+}
+
+// UserAddressInformation электронный адрес читателя
+type UserAddressInformation struct {
+	UserAddressRoleType string            `xml:"UserAddressRoleType"`
+	ElectronicAddress   ElectronicAddress `xml:"ElectronicAddress"`
+}
+
+// ElectronicAddress адрес электронной почты или телефон
+type ElectronicAddress struct {
+	ElectronicAddressType string `xml:"ElectronicAddressType"`
+	ElectronicAddressData string `xml:"ElectronicAddressData"`
+}
+
+// UserPrivilege категория читателя и срок ее действия
+type UserPrivilege struct {
+	AgencyID                string               `xml:"AgencyId"`
+	AgencyUserPrivilegeType string               `xml:"AgencyUserPrivilegeType"`
+	ValidToDate             string               `xml:"ValidToDate,omitempty"`
+	UserPrivilegeStatus     *UserPrivilegeStatus `xml:"UserPrivilegeStatus,omitempty"`
+}
+
+// UserPrivilegeStatus состояние категории
+type UserPrivilegeStatus struct {
+	UserPrivilegeStatusType string `xml:"UserPrivilegeStatusType"`
+}
+
+// BlockOrTrap ограничение, из-за которого читателю сейчас нельзя выдавать книги
+type BlockOrTrap struct {
+	AgencyID        string `xml:"AgencyId"`
+	BlockOrTrapType string `xml:"BlockOrTrapType"`
+}
+
+// LookupUser запрос сведений о нашем читателе
+type LookupUser struct {
+	InitiationHeader    *InitiationHeader     `xml:"InitiationHeader"`
+	UserID              *UserID               `xml:"UserId"`
+	AuthenticationInput []AuthenticationInput `xml:"AuthenticationInput"`
+}
+
+// LookupUserResponse ответ на LookupUser
+type LookupUserResponse struct {
+	ResponseHeader     *ResponseHeader     `xml:"ResponseHeader,omitempty"`
+	Problem            *Problem            `xml:"Problem,omitempty"`
+	UserID             *UserID             `xml:"UserId,omitempty"`
+	UserOptionalFields *UserOptionalFields `xml:"UserOptionalFields,omitempty"`
+}
+
+// LookupItem запрос сведений об экземпляре
+type LookupItem struct {
+	InitiationHeader *InitiationHeader `xml:"InitiationHeader"`
+	ItemID           *ItemID           `xml:"ItemId"`
+}
+
+// LookupItemResponse ответ на LookupItem
+type LookupItemResponse struct {
+	ResponseHeader     *ResponseHeader     `xml:"ResponseHeader,omitempty"`
+	Problem            *Problem            `xml:"Problem,omitempty"`
+	ItemID             *ItemID             `xml:"ItemId,omitempty"`
+	ItemOptionalFields *ItemOptionalFields `xml:"ItemOptionalFields,omitempty"`
+}
+
+// CheckOutItem выдача экземпляра. ItemOptionalFields передаются, когда партнер
+// выдает свой экземпляр нашему читателю: по ним экземпляр заводится в фонде
+type CheckOutItem struct {
+	InitiationHeader   *InitiationHeader   `xml:"InitiationHeader"`
+	UserID             *UserID             `xml:"UserId"`
+	ItemID             *ItemID             `xml:"ItemId"`
+	RequestID          *RequestID          `xml:"RequestId"`
+	ItemOptionalFields *ItemOptionalFields `xml:"ItemOptionalFields"`
+}
+
+// CheckOutItemResponse ответ на CheckOutItem
+type CheckOutItemResponse struct {
+	ResponseHeader *ResponseHeader `xml:"ResponseHeader,omitempty"`
+	Problem        *Problem        `xml:"Problem,omitempty"`
+	ItemID         *ItemID         `xml:"ItemId,omitempty"`
+	UserID         *UserID         `xml:"UserId,omitempty"`
+	DateDue        string          `xml:"DateDue,omitempty"`
+}
+
+// CheckInItem возврат экземпляра
+type CheckInItem struct {
+	InitiationHeader *InitiationHeader `xml:"InitiationHeader"`
+	ItemID           *ItemID           `xml:"ItemId"`
+}
+
+// CheckInItemResponse ответ на CheckInItem
+type CheckInItemResponse struct {
+	ResponseHeader *ResponseHeader `xml:"ResponseHeader,omitempty"`
+	Problem        *Problem        `xml:"Problem,omitempty"`
+	ItemID         *ItemID         `xml:"ItemId,omitempty"`
+	UserID         *UserID         `xml:"UserId,omitempty"`
+}
+
+// RequestItem просьба партнера отложить для него экземпляр или любой экземпляр издания
+type RequestItem struct {
+	InitiationHeader *InitiationHeader `xml:"InitiationHeader"`
+	UserID           *UserID           `xml:"UserId"`
+	ItemID           *ItemID           `xml:"ItemId"`
+	BibliographicID  *BibliographicID  `xml:"BibliographicId"`
+	RequestID        *RequestID        `xml:"RequestId"`
+	RequestType      string            `xml:"RequestType"`
+	RequestScopeType string            `xml:"RequestScopeType"`
+}
+
+// RequestItemResponse ответ на RequestItem
+type RequestItemResponse struct {
+	ResponseHeader   *ResponseHeader `xml:"ResponseHeader,omitempty"`
+	Problem          *Problem        `xml:"Problem,omitempty"`
+	RequestID        *RequestID      `xml:"RequestId,omitempty"`
+	ItemID           *ItemID         `xml:"ItemId,omitempty"`
+	UserID           *UserID         `xml:"UserId,omitempty"`
+	RequestType      string          `xml:"RequestType,omitempty"`
+	RequestScopeType string          `xml:"RequestScopeType,omitempty"`
+}
+
+// RenewItem продление выдачи
+type RenewItem struct {
+	InitiationHeader *InitiationHeader `xml:"InitiationHeader"`
+	UserID           *UserID           `xml:"UserId"`
+	ItemID           *ItemID           `xml:"ItemId"`
+}
+
+// RenewItemResponse ответ на RenewItem
+type RenewItemResponse struct {
+	ResponseHeader *ResponseHeader `xml:"ResponseHeader,omitempty"`
+	Problem        *Problem        `xml:"Problem,omitempty"`
+	ItemID         *ItemID         `xml:"ItemId,omitempty"`
+	DateDue        string          `xml:"DateDue,omitempty"`
+}
+
+// Decode разбирает сообщение партнера
+func Decode(body []byte) (*Message, error) {
+	var m Message
+	if err := xml.Unmarshal(body, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Encode сериализует ответ с XML-заголовком
+func Encode(m *Message) ([]byte, error) {
+	m.Xmlns, m.Version = Namespace, Version
+	body, err := xml.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+// value обрезает пробелы вокруг идентификатора; nil дает пустую строку
+func (u *UserID) value() string {
+	if u == nil {
+		return ""
+	}
+	return strings.TrimSpace(u.UserIdentifierValue)
+}
+
+func (i *ItemID) value() string {
+	if i == nil {
+		return ""
+	}
+	return strings.TrimSpace(i.ItemIdentifierValue)
+}
@@ -0,0 +1,216 @@
+package ncip
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"time"
+
+	"github.com/hnnsly/library-console/internal/circulation"
+	"github.com/hnnsly/library-console/internal/config"
+	"github.com/hnnsly/library-console/internal/repository"
+	"github.com/hnnsly/library-console/internal/repository/postgres"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// Типы проблем NCIP, которые возвращает сервер
+const (
+	problemUnknownUser         = "Unknown User"
+	problemAuthFailed          = "User Authentication Failed"
+	problemUserBlocked         = "User Blocked"
+	problemMaxCheckOuts        = "Maximum Check Outs Exceeded"
+	problemUnknownItem         = "Unknown Item"
+	problemUnknownAgency       = "Unknown Agency"
+	problemItemNotAvailable    = "Resource Cannot Be Provided"
+	problemNotCheckedOut       = "Item Not Checked Out"
+	problemMaxRenewals         = "Maximum Renewals Exceeded"
+	problemNotRenewable        = "Item Not Renewable"
+	problemNeededDataMissing   = "Needed Data Missing"
+	problemUnsupportedService  = "Unsupported Service"
+	problemInvalidMessage      = "Invalid Message Syntax Error"
+	problemTemporaryFailure    = "Temporary Processing Failure"
+	problemUnauthorizedService = "Unauthorized Service"
+)
+
+// pinFailureWindow столько помнятся неудачные попытки PIN; счетчик общий с порталом
+const pinFailureWindow = 15 * time.Minute
+
+// Responder отвечает на сообщения NCIP библиотек-партнеров. Выдачи партнерам оформляются
+// на служебного читателя партнера, выдачи наших читателей из фонда партнера — на самих читателей;
+// в обоих случаях выдача помечается агентством партнера в book_issues.source_library
+type Responder struct {
+	cfg            config.NCIPConfig
+	maxPinAttempts int
+	repo           *repository.LibraryRepository
+	desk           *circulation.Desk
+}
+
+// NewResponder создает обработчик; проверка PIN использует тот же лимит попыток, что и портал
+func NewResponder(cfg config.NCIPConfig, maxPinAttempts int, repo *repository.LibraryRepository, desk *circulation.Desk) *Responder {
+	return &Responder{cfg: cfg, maxPinAttempts: maxPinAttempts, repo: repo, desk: desk}
+}
+
+// Partner находит партнера по токену доступа; токены сравниваются за постоянное время
+func (r *Responder) Partner(token string) (config.NCIPPartnerConfig, bool) {
+	var found config.NCIPPartnerConfig
+	ok := false
+	for _, p := range r.cfg.Partners {
+		if subtle.ConstantTimeCompare([]byte(p.Token), []byte(token)) == 1 {
+			found, ok = p, true
+		}
+	}
+	return found, ok
+}
+
+// Respond выполняет услугу из запроса партнера. Отказы и сбои возвращаются элементом Problem в ответе
+func (r *Responder) Respond(ctx context.Context, p config.NCIPPartnerConfig, req *Message) *Message {
+	h := r.header(p)
+	if from := fromAgency(req); from != "" && from != p.AgencyID {
+		return &Message{Problem: &Problem{
+			ProblemType:    problemUnauthorizedService,
+			ProblemDetail:  "FromAgencyId does not match the access token",
+			ProblemElement: "FromAgencyId",
+			ProblemValue:   from,
+		}}
+	}
+
+	switch {
+	case req.LookupUser != nil:
+		resp := r.lookupUser(ctx, p, req.LookupUser)
+		resp.ResponseHeader = h
+		return &Message{LookupUserResponse: resp}
+	case req.LookupItem != nil:
+		resp := r.lookupItem(ctx, p, req.LookupItem)
+		resp.ResponseHeader = h
+		return &Message{LookupItemResponse: resp}
+	case req.CheckOutItem != nil:
+		resp := r.checkOutItem(ctx, p, req.CheckOutItem)
+		resp.ResponseHeader = h
+		return &Message{CheckOutItemResponse: resp}
+	case req.CheckInItem != nil:
+		resp := r.checkInItem(ctx, p, req.CheckInItem)
+		resp.ResponseHeader = h
+		return &Message{CheckInItemResponse: resp}
+	case req.RequestItem != nil:
+		resp := r.requestItem(ctx, p, req.RequestItem)
+		resp.ResponseHeader = h
+		return &Message{RequestItemResponse: resp}
+	case req.RenewItem != nil:
+		resp := r.renewItem(ctx, p, req.RenewItem)
+		resp.ResponseHeader = h
+		return &Message{RenewItemResponse: resp}
+	}
+
+	return &Message{Problem: &Problem{
+		ProblemType:   problemUnsupportedService,
+		ProblemDetail: "Supported services: LookupUser, LookupItem, CheckOutItem, CheckInItem, RequestItem, RenewItem",
+	}}
+}
+
+// InvalidMessage ответ на тело, которое не разбирается как NCIPMessage
+func InvalidMessage(err error) *Message {
+	return &Message{Problem: &Problem{ProblemType: problemInvalidMessage, ProblemDetail: err.Error()}}
+}
+
+// UnauthorizedMessage ответ на запрос без действующего токена партнера
+func UnauthorizedMessage() *Message {
+	return &Message{Problem: &Problem{ProblemType: problemUnauthorizedService, ProblemDetail: "Invalid or missing access token"}}
+}
+
+func (r *Responder) header(p config.NCIPPartnerConfig) *ResponseHeader {
+	return &ResponseHeader{
+		FromAgencyID: &AgencyRef{AgencyID: r.cfg.AgencyID},
+		ToAgencyID:   &AgencyRef{AgencyID: p.AgencyID},
+	}
+}
+
+// fromAgency агентство-отправитель из заголовка запроса, если оно указано
+func fromAgency(req *Message) string {
+	var h *InitiationHeader
+	switch {
+	case req.LookupUser != nil:
+		h = req.LookupUser.InitiationHeader
+	case req.LookupItem != nil:
+		h = req.LookupItem.InitiationHeader
+	case req.CheckOutItem != nil:
+		h = req.CheckOutItem.InitiationHeader
+	case req.CheckInItem != nil:
+		h = req.CheckInItem.InitiationHeader
+	case req.RequestItem != nil:
+		h = req.RequestItem.InitiationHeader
+	case req.RenewItem != nil:
+		h = req.RenewItem.InitiationHeader
+	}
+	if h == nil || h.FromAgencyID == nil {
+		return ""
+	}
+	return h.FromAgencyID.AgencyID
+}
+
+// ours экземпляр или читатель принадлежит нашей библиотеке; агентство можно не указывать
+func (r *Responder) ours(agencyID string) bool {
+	return agencyID == "" || agencyID == r.cfg.AgencyID
+}
+
+// institutionalReader служебный читатель партнера, на которого оформляются выдачи ему
+func (r *Responder) institutionalReader(ctx context.Context, p config.NCIPPartnerConfig) (*postgres.GetReaderByTicketNumberRow, error) {
+	return r.repo.GetReaderByTicketNumber(ctx, p.ReaderTicket)
+}
+
+// invalidateCatalog сбрасывает кэш публичного каталога после смены статуса экземпляра в обход Desk;
+// сбой не отменяет операцию
+func (r *Responder) invalidateCatalog(ctx context.Context) {
+	if err := r.repo.InvalidateCatalog(ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to invalidate catalogue cache")
+	}
+}
+
+// problem переводит ошибку выдачи в элемент Problem; неизвестные ошибки записываются в лог
+func problem(err error, service, element, value string) *Problem {
+	p := &Problem{ProblemElement: element, ProblemValue: value, ProblemDetail: err.Error()}
+	switch {
+	case errors.Is(err, circulation.ErrReaderNotFound):
+		p.ProblemType = problemUnknownUser
+	case errors.Is(err, circulation.ErrReaderInactive),
+		errors.Is(err, circulation.ErrMembershipExpired),
+		errors.Is(err, circulation.ErrReaderOverdue),
+		errors.Is(err, circulation.ErrPolicyMissing):
+		p.ProblemType = problemUserBlocked
+	case errors.Is(err, circulation.ErrLoanLimit):
+		p.ProblemType = problemMaxCheckOuts
+	case errors.Is(err, circulation.ErrCopyNotFound):
+		p.ProblemType = problemUnknownItem
+	case errors.Is(err, circulation.ErrCopyUnavailable),
+		errors.Is(err, repository.ErrInvalidCopyTransition):
+		p.ProblemType = problemItemNotAvailable
+	case errors.Is(err, circulation.ErrNotCheckedOut),
+		errors.Is(err, circulation.ErrIssuedToOther),
+		errors.Is(err, repository.ErrLoanReturned):
+		p.ProblemType = problemNotCheckedOut
+	case errors.Is(err, repository.ErrRenewalLimit):
+		p.ProblemType = problemMaxRenewals
+	case errors.Is(err, repository.ErrLoanOverdue),
+		errors.Is(err, repository.ErrRenewalPastMembership):
+		p.ProblemType = problemNotRenewable
+	case errors.Is(err, pgx.ErrNoRows):
+		p.ProblemType = problemUnknownItem
+		p.ProblemDetail = ""
+	default:
+		log.Error().Err(err).Str("service", service).Str(element, value).Msg("NCIP request failed")
+		p.ProblemType, p.ProblemDetail = problemTemporaryFailure, ""
+	}
+	return p
+}
+
+// formatDateDue переводит дату возврата в xs:dateTime на конец дня
+func formatDateDue(d time.Time) string {
+	return time.Date(d.Year(), d.Month(), d.Day(), 23, 59, 59, 0, time.Local).Format(time.RFC3339)
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
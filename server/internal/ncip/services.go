@@ -0,0 +1,486 @@
+package ncip
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hnnsly/library-console/internal/circulation"
+	"github.com/hnnsly/library-console/internal/config"
+	"github.com/hnnsly/library-console/internal/repository"
+	"github.com/hnnsly/library-console/internal/repository/postgres"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// lookupUser (LookupUser) сообщает партнеру о нашем читателе категорию и блокировки, а после проверки
+// PIN из AuthenticationInput — также имя и контакты. Читатель задается UserId или AuthenticationInput
+func (r *Responder) lookupUser(ctx context.Context, p config.NCIPPartnerConfig, s *LookupUser) *LookupUserResponse {
+	ticket, pin := s.UserID.value(), ""
+	for _, in := range s.AuthenticationInput {
+		switch strings.ToLower(in.AuthenticationInputType) {
+		case "pin", "password":
+			pin = in.AuthenticationInputData
+		case "barcode id", "user id", "username":
+			if ticket == "" {
+				ticket = strings.TrimSpace(in.AuthenticationInputData)
+			}
+		}
+	}
+	if ticket == "" {
+		return &LookupUserResponse{Problem: &Problem{ProblemType: problemNeededDataMissing, ProblemElement: "UserIdentifierValue"}}
+	}
+	if s.UserID != nil && !r.ours(s.UserID.AgencyID) {
+		return &LookupUserResponse{Problem: &Problem{ProblemType: problemUnknownAgency, ProblemElement: "AgencyId", ProblemValue: s.UserID.AgencyID}}
+	}
+
+	reader, err := r.repo.GetReaderByTicketNumber(ctx, ticket)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &LookupUserResponse{Problem: &Problem{ProblemType: problemUnknownUser, ProblemElement: "UserIdentifierValue", ProblemValue: ticket}}
+		}
+		return &LookupUserResponse{Problem: problem(err, "LookupUser", "UserIdentifierValue", ticket)}
+	}
+
+	if pin != "" {
+		if prob := r.checkPin(ctx, ticket, pin); prob != nil {
+			return &LookupUserResponse{Problem: prob}
+		}
+	}
+
+	// Без проверенного PIN партнер узнает только, есть ли у читателя право на выдачу:
+	// имя и контакты по одному номеру билета не раскрываются
+	fields := &UserOptionalFields{}
+	if pin != "" {
+		fields.NameInformation = &NameInformation{PersonalNameInformation{UnstructuredPersonalUserName: reader.FullName}}
+		fields.UserLanguage = userLanguage(reader.Language)
+		if reader.Email != nil && *reader.Email != "" {
+			fields.UserAddressInformation = append(fields.UserAddressInformation, UserAddressInformation{
+				UserAddressRoleType: "Home",
+				ElectronicAddress:   ElectronicAddress{ElectronicAddressType: "mailto", ElectronicAddressData: *reader.Email},
+			})
+		}
+		if reader.Phone != nil && *reader.Phone != "" {
+			fields.UserAddressInformation = append(fields.UserAddressInformation, UserAddressInformation{
+				UserAddressRoleType: "Home",
+				ElectronicAddress:   ElectronicAddress{ElectronicAddressType: "tel", ElectronicAddressData: *reader.Phone},
+			})
+		}
+	}
+
+	privilege := UserPrivilege{
+		AgencyID:                r.cfg.AgencyID,
+		AgencyUserPrivilegeType: string(reader.Category),
+		UserPrivilegeStatus:     &UserPrivilegeStatus{UserPrivilegeStatusType: "Active"},
+	}
+	if reader.MembershipExpiresAt != nil {
+		privilege.ValidToDate = reader.MembershipExpiresAt.Format(time.RFC3339)
+	}
+
+	_, err = r.desk.Eligibility(ctx, reader.ID)
+	switch {
+	case err == nil:
+	case errors.Is(err, circulation.ErrReaderInactive):
+		privilege.UserPrivilegeStatus.UserPrivilegeStatusType = "Inactive"
+		fields.BlockOrTrap = append(fields.BlockOrTrap, BlockOrTrap{AgencyID: r.cfg.AgencyID, BlockOrTrapType: "Block Check Out"})
+	case errors.Is(err, circulation.ErrMembershipExpired):
+		privilege.UserPrivilegeStatus.UserPrivilegeStatusType = "Expired"
+		fields.BlockOrTrap = append(fields.BlockOrTrap, BlockOrTrap{AgencyID: r.cfg.AgencyID, BlockOrTrapType: "Block Check Out"})
+	case errors.Is(err, circulation.ErrLoanLimit),
+		errors.Is(err, circulation.ErrReaderOverdue),
+		errors.Is(err, circulation.ErrPolicyMissing):
+		fields.BlockOrTrap = append(fields.BlockOrTrap, BlockOrTrap{AgencyID: r.cfg.AgencyID, BlockOrTrapType: "Block Check Out"})
+	default:
+		return &LookupUserResponse{Problem: problem(err, "LookupUser", "UserIdentifierValue", ticket)}
+	}
+	fields.UserPrivilege = []UserPrivilege{privilege}
+
+	return &LookupUserResponse{
+		UserID:             &UserID{AgencyID: r.cfg.AgencyID, UserIdentifierValue: reader.TicketNumber},
+		UserOptionalFields: fields,
+	}
+}
+
+// checkPin проверяет PIN портала тем же счетчиком неудач, что и портал
+func (r *Responder) checkPin(ctx context.Context, ticket, pin string) *Problem {
+	failed := &Problem{ProblemType: problemAuthFailed, ProblemElement: "AuthenticationInputData"}
+
+	failures, err := r.repo.PortalLoginFailures(ctx, ticket)
+	if err != nil {
+		return problem(err, "LookupUser", "UserIdentifierValue", ticket)
+	}
+	if failures >= int64(r.maxPinAttempts) {
+		failed.ProblemDetail = "Too many failed attempts"
+		return failed
+	}
+
+	creds, err := r.repo.GetReaderPortalCredentials(ctx, ticket)
+	if err != nil {
+		return problem(err, "LookupUser", "UserIdentifierValue", ticket)
+	}
+	if creds.PinHash == nil || bcrypt.CompareHashAndPassword([]byte(*creds.PinHash), []byte(pin)) != nil {
+		if _, err := r.repo.RegisterPortalLoginFailure(ctx, ticket, pinFailureWindow); err != nil {
+			log.Warn().Err(err).Msg("Failed to register NCIP PIN failure")
+		}
+		return failed
+	}
+	if err := r.repo.ResetPortalLoginFailures(ctx, ticket); err != nil {
+		log.Warn().Err(err).Msg("Failed to reset NCIP PIN failures")
+	}
+	return nil
+}
+
+// lookupItem (LookupItem) описывает наш экземпляр или экземпляр партнера, полученный по МБА
+func (r *Responder) lookupItem(ctx context.Context, p config.NCIPPartnerConfig, s *LookupItem) *LookupItemResponse {
+	copyCode, prob := r.resolveItem(ctx, p, s.ItemID, "LookupItem")
+	if prob != nil {
+		return &LookupItemResponse{Problem: prob}
+	}
+
+	item, err := r.repo.GetIllItem(ctx, copyCode)
+	if err != nil {
+		return &LookupItemResponse{Problem: problem(err, "LookupItem", "ItemIdentifierValue", s.ItemID.value())}
+	}
+
+	description := &BibliographicDescription{
+		Author:    item.Authors,
+		Title:     item.Title,
+		Publisher: derefString(item.Publisher),
+	}
+	if item.Isbn != nil && *item.Isbn != "" {
+		description.BibliographicItemID = &BibliographicItemID{BibliographicItemIdentifier: *item.Isbn, BibliographicItemIdentifierCode: "ISBN"}
+	}
+	if item.PublicationYear != nil {
+		description.PublicationDate = strconv.Itoa(*item.PublicationYear)
+	}
+
+	fields := &ItemOptionalFields{
+		BibliographicDescription: description,
+		CirculationStatus:        circulationStatus(item.Status),
+	}
+	if item.HallName != nil {
+		fields.Location = &Location{
+			LocationType: "Permanent Location",
+			LocationName: LocationName{LocationNameInstance: []LocationNameInstance{{LocationNameLevel: 1, LocationNameValue: *item.HallName}}},
+		}
+	}
+	if item.DueDate != nil {
+		fields.DateDue = formatDateDue(*item.DueDate)
+	}
+
+	return &LookupItemResponse{ItemID: s.ItemID, ItemOptionalFields: fields}
+}
+
+// checkOutItem (CheckOutItem) выдает наш экземпляр партнеру или экземпляр партнера нашему читателю.
+// Направление определяется агентством экземпляра: свой экземпляр выдается служебному читателю
+// партнера, чужой заводится в фонде по описанию из ItemOptionalFields и выдается читателю из UserId
+func (r *Responder) checkOutItem(ctx context.Context, p config.NCIPPartnerConfig, s *CheckOutItem) *CheckOutItemResponse {
+	itemValue := s.ItemID.value()
+	if itemValue == "" {
+		return &CheckOutItemResponse{Problem: &Problem{ProblemType: problemNeededDataMissing, ProblemElement: "ItemIdentifierValue"}}
+	}
+
+	checkout := circulation.Checkout{SourceLibrary: p.AgencyID}
+	switch {
+	case r.ours(s.ItemID.AgencyID):
+		reader, err := r.institutionalReader(ctx, p)
+		if err != nil {
+			return &CheckOutItemResponse{Problem: problem(err, "CheckOutItem", "ItemIdentifierValue", itemValue)}
+		}
+		bookCopy, err := r.repo.GetBookCopyByCode(ctx, itemValue)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return &CheckOutItemResponse{Problem: &Problem{ProblemType: problemUnknownItem, ProblemElement: "ItemIdentifierValue", ProblemValue: itemValue}}
+			}
+			return &CheckOutItemResponse{Problem: problem(err, "CheckOutItem", "ItemIdentifierValue", itemValue)}
+		}
+
+		// Экземпляр, отложенный по RequestItem, выдается только тому партнеру, который его запросил
+		request, err := r.repo.GetActiveIllRequestForCopy(ctx, bookCopy.ID)
+		switch {
+		case err == nil && request.SourceLibrary != p.AgencyID:
+			return &CheckOutItemResponse{Problem: problem(circulation.ErrCopyUnavailable, "CheckOutItem", "ItemIdentifierValue", itemValue)}
+		case err == nil:
+			checkout.Reserved = true
+			checkout.ExternalUserID = derefString(request.ExternalUserID)
+		case !errors.Is(err, pgx.ErrNoRows):
+			return &CheckOutItemResponse{Problem: problem(err, "CheckOutItem", "ItemIdentifierValue", itemValue)}
+		}
+		if userID := s.UserID.value(); userID != "" {
+			checkout.ExternalUserID = userID
+		}
+
+		checkout.ReaderID, checkout.CopyCode = reader.ID, bookCopy.CopyCode
+		checkout.Reason = "Выдано по МБА " + p.AgencyID
+
+		loan, err := r.desk.Checkout(ctx, checkout)
+		if err != nil {
+			return &CheckOutItemResponse{Problem: problem(err, "CheckOutItem", "ItemIdentifierValue", itemValue)}
+		}
+		if request != nil {
+			if err := r.repo.SetIllRequestStatus(ctx, postgres.SetIllRequestStatusParams{
+				Status: postgres.IllRequestStatusFulfilled,
+				ID:     request.ID,
+			}); err != nil {
+				log.Warn().Err(err).Str("requestID", request.ID.String()).Msg("Failed to mark ILL request fulfilled")
+			}
+		}
+
+		return &CheckOutItemResponse{ItemID: s.ItemID, UserID: s.UserID, DateDue: formatDateDue(loan.Issue.DueDate)}
+
+	case s.ItemID.AgencyID == p.AgencyID:
+		ticket := s.UserID.value()
+		if ticket == "" {
+			return &CheckOutItemResponse{Problem: &Problem{ProblemType: problemNeededDataMissing, ProblemElement: "UserIdentifierValue"}}
+		}
+		reader, err := r.repo.GetReaderByTicketNumber(ctx, ticket)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return &CheckOutItemResponse{Problem: &Problem{ProblemType: problemUnknownUser, ProblemElement: "UserIdentifierValue", ProblemValue: ticket}}
+			}
+			return &CheckOutItemResponse{Problem: problem(err, "CheckOutItem", "UserIdentifierValue", ticket)}
+		}
+
+		illCopy := repository.IllCopy{OwnerLibrary: p.AgencyID, ExternalItemID: itemValue}
+		if s.ItemOptionalFields != nil && s.ItemOptionalFields.BibliographicDescription != nil {
+			illCopy = describe(illCopy, s.ItemOptionalFields.BibliographicDescription)
+		}
+		if illCopy.Title == "" {
+			illCopy.Title = p.AgencyID + " " + itemValue
+		}
+		copyCode, err := r.repo.ReceiveIllCopy(ctx, illCopy)
+		if err != nil {
+			return &CheckOutItemResponse{Problem: problem(err, "CheckOutItem", "ItemIdentifierValue", itemValue)}
+		}
+
+		checkout.ReaderID, checkout.CopyCode = reader.ID, copyCode
+		checkout.Reason = "Получено по МБА из " + p.AgencyID
+		loan, err := r.desk.Checkout(ctx, checkout)
+		if err != nil {
+			return &CheckOutItemResponse{Problem: problem(err, "CheckOutItem", "UserIdentifierValue", ticket)}
+		}
+
+		return &CheckOutItemResponse{ItemID: s.ItemID, UserID: s.UserID, DateDue: formatDateDue(loan.Issue.DueDate)}
+	}
+
+	return &CheckOutItemResponse{Problem: &Problem{ProblemType: problemUnknownAgency, ProblemElement: "AgencyId", ProblemValue: s.ItemID.AgencyID}}
+}
+
+// checkInItem (CheckInItem) закрывает межбиблиотечную выдачу. Наш экземпляр возвращается в фонд;
+// экземпляр партнера после возврата читателем переводится в статус «в пути» для отправки владельцу
+func (r *Responder) checkInItem(ctx context.Context, p config.NCIPPartnerConfig, s *CheckInItem) *CheckInItemResponse {
+	copyCode, prob := r.resolveItem(ctx, p, s.ItemID, "CheckInItem")
+	if prob != nil {
+		return &CheckInItemResponse{Problem: prob}
+	}
+	itemValue := s.ItemID.value()
+
+	issue, err := r.repo.GetActiveIssueByCopyCode(ctx, copyCode)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = circulation.ErrNotCheckedOut
+		}
+		return &CheckInItemResponse{Problem: problem(err, "CheckInItem", "ItemIdentifierValue", itemValue)}
+	}
+	// Партнер закрывает только выдачи, оформленные по его запросам
+	if issue.SourceLibrary == nil || *issue.SourceLibrary != p.AgencyID {
+		return &CheckInItemResponse{Problem: problem(circulation.ErrNotCheckedOut, "CheckInItem", "ItemIdentifierValue", itemValue)}
+	}
+
+	returned, err := r.desk.Checkin(ctx, copyCode, nil, "Возвращено по МБА "+p.AgencyID)
+	if err != nil {
+		return &CheckInItemResponse{Problem: problem(err, "CheckInItem", "ItemIdentifierValue", itemValue)}
+	}
+
+	if !r.ours(s.ItemID.AgencyID) {
+		err := r.repo.TransitionCopyStatus(ctx, repository.CopyTransition{
+			CopyID: returned.Copy.ID,
+			To:     postgres.BookStatusInTransit,
+			Reason: "Возврат владельцу по МБА " + p.AgencyID,
+		})
+		if err != nil {
+			log.Warn().Err(err).Str("copyCode", copyCode).Msg("Failed to mark ILL copy in transit")
+		} else {
+			r.invalidateCatalog(ctx)
+		}
+	}
+
+	return &CheckInItemResponse{ItemID: s.ItemID}
+}
+
+// requestItem (RequestItem) откладывает для партнера наш экземпляр — указанный или любой
+// доступный экземпляр издания по ISBN. Повтор запроса с тем же RequestId возвращает прежний ответ
+func (r *Responder) requestItem(ctx context.Context, p config.NCIPPartnerConfig, s *RequestItem) *RequestItemResponse {
+	if s.RequestID == nil || strings.TrimSpace(s.RequestID.RequestIdentifierValue) == "" {
+		return &RequestItemResponse{Problem: &Problem{ProblemType: problemNeededDataMissing, ProblemElement: "RequestIdentifierValue"}}
+	}
+	requestID := strings.TrimSpace(s.RequestID.RequestIdentifierValue)
+	reply := func(copyCode string) *RequestItemResponse {
+		return &RequestItemResponse{
+			RequestID:        s.RequestID,
+			ItemID:           &ItemID{AgencyID: r.cfg.AgencyID, ItemIdentifierValue: copyCode},
+			UserID:           s.UserID,
+			RequestType:      s.RequestType,
+			RequestScopeType: s.RequestScopeType,
+		}
+	}
+
+	existing, err := r.repo.GetIllRequest(ctx, postgres.GetIllRequestParams{SourceLibrary: p.AgencyID, ExternalRequestID: requestID})
+	if err == nil {
+		bookCopy, err := r.repo.GetBookCopyById(ctx, existing.BookCopyID)
+		if err != nil {
+			return &RequestItemResponse{Problem: problem(err, "RequestItem", "RequestIdentifierValue", requestID)}
+		}
+		return reply(bookCopy.CopyCode)
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return &RequestItemResponse{Problem: problem(err, "RequestItem", "RequestIdentifierValue", requestID)}
+	}
+
+	copyCode := s.ItemID.value()
+	switch {
+	case copyCode != "" && !r.ours(s.ItemID.AgencyID):
+		return &RequestItemResponse{Problem: &Problem{ProblemType: problemUnknownAgency, ProblemElement: "AgencyId", ProblemValue: s.ItemID.AgencyID}}
+	case copyCode == "" && s.BibliographicID != nil && s.BibliographicID.BibliographicItemID != nil:
+		isbn := strings.TrimSpace(s.BibliographicID.BibliographicItemID.BibliographicItemIdentifier)
+		copyCode, err = r.repo.GetAvailableCopyByIsbn(ctx, &isbn)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return &RequestItemResponse{Problem: &Problem{ProblemType: problemItemNotAvailable, ProblemElement: "BibliographicItemIdentifier", ProblemValue: isbn}}
+			}
+			return &RequestItemResponse{Problem: problem(err, "RequestItem", "BibliographicItemIdentifier", isbn)}
+		}
+	case copyCode == "":
+		return &RequestItemResponse{Problem: &Problem{ProblemType: problemNeededDataMissing, ProblemElement: "ItemId"}}
+	}
+
+	bookCopy, err := r.repo.GetBookCopyByCode(ctx, copyCode)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &RequestItemResponse{Problem: &Problem{ProblemType: problemUnknownItem, ProblemElement: "ItemIdentifierValue", ProblemValue: copyCode}}
+		}
+		return &RequestItemResponse{Problem: problem(err, "RequestItem", "ItemIdentifierValue", copyCode)}
+	}
+
+	var externalUserID *string
+	if userID := s.UserID.value(); userID != "" {
+		externalUserID = &userID
+	}
+	_, err = r.repo.ReserveIllCopy(ctx, repository.IllReservation{
+		SourceLibrary:     p.AgencyID,
+		ExternalRequestID: requestID,
+		ExternalUserID:    externalUserID,
+		CopyID:            bookCopy.ID,
+	})
+	if err != nil {
+		// Тот же запрос успели записать параллельно
+		if strings.Contains(err.Error(), "duplicate") {
+			return &RequestItemResponse{Problem: &Problem{ProblemType: "Duplicate Request", ProblemElement: "RequestIdentifierValue", ProblemValue: requestID}}
+		}
+		return &RequestItemResponse{Problem: problem(err, "RequestItem", "ItemIdentifierValue", copyCode)}
+	}
+	r.invalidateCatalog(ctx)
+
+	return reply(bookCopy.CopyCode)
+}
+
+// renewItem (RenewItem) продлевает выдачу партнеру по правилам категории его служебного читателя
+func (r *Responder) renewItem(ctx context.Context, p config.NCIPPartnerConfig, s *RenewItem) *RenewItemResponse {
+	itemValue := s.ItemID.value()
+	if itemValue == "" {
+		return &RenewItemResponse{Problem: &Problem{ProblemType: problemNeededDataMissing, ProblemElement: "ItemIdentifierValue"}}
+	}
+	if !r.ours(s.ItemID.AgencyID) {
+		return &RenewItemResponse{Problem: &Problem{ProblemType: problemUnknownAgency, ProblemElement: "AgencyId", ProblemValue: s.ItemID.AgencyID}}
+	}
+
+	reader, err := r.institutionalReader(ctx, p)
+	if err != nil {
+		return &RenewItemResponse{Problem: problem(err, "RenewItem", "ItemIdentifierValue", itemValue)}
+	}
+	renewed, err := r.desk.Renew(ctx, reader.ID, itemValue)
+	if err != nil {
+		return &RenewItemResponse{Problem: problem(err, "RenewItem", "ItemIdentifierValue", itemValue)}
+	}
+
+	return &RenewItemResponse{ItemID: s.ItemID, DateDue: formatDateDue(renewed.DueDate)}
+}
+
+// resolveItem находит шифр экземпляра: наш — по шифру, партнера — по его штрихкоду
+func (r *Responder) resolveItem(ctx context.Context, p config.NCIPPartnerConfig, item *ItemID, service string) (string, *Problem) {
+	value := item.value()
+	if value == "" {
+		return "", &Problem{ProblemType: problemNeededDataMissing, ProblemElement: "ItemIdentifierValue"}
+	}
+	if r.ours(item.AgencyID) {
+		return value, nil
+	}
+	if item.AgencyID != p.AgencyID {
+		return "", &Problem{ProblemType: problemUnknownAgency, ProblemElement: "AgencyId", ProblemValue: item.AgencyID}
+	}
+
+	copyCode, err := r.repo.GetIllCopyByExternalId(ctx, postgres.GetIllCopyByExternalIdParams{
+		OwnerLibrary:   &p.AgencyID,
+		ExternalItemID: &value,
+	})
+	if err != nil {
+		return "", problem(err, service, "ItemIdentifierValue", value)
+	}
+	return copyCode, nil
+}
+
+// describe переносит описание партнера в карточку экземпляра
+func describe(c repository.IllCopy, d *BibliographicDescription) repository.IllCopy {
+	c.Title = strings.TrimSpace(d.Title)
+	for _, name := range strings.Split(d.Author, ";") {
+		if name = strings.TrimSpace(name); name != "" {
+			c.Authors = append(c.Authors, name)
+		}
+	}
+	if d.BibliographicItemID != nil && d.BibliographicItemID.BibliographicItemIdentifier != "" {
+		isbn := strings.TrimSpace(d.BibliographicItemID.BibliographicItemIdentifier)
+		c.Isbn = &isbn
+	}
+	if d.Publisher != "" {
+		publisher := strings.TrimSpace(d.Publisher)
+		c.Publisher = &publisher
+	}
+	if year, err := strconv.Atoi(strings.TrimSpace(d.PublicationDate)); err == nil {
+		c.PublicationYear = &year
+	}
+	return c
+}
+
+// circulationStatus переводит статус экземпляра в значение схемы NCIP
+func circulationStatus(status postgres.NullBookStatus) string {
+	if !status.Valid {
+		return "Available On Shelf"
+	}
+	switch status.BookStatus {
+	case postgres.BookStatusAvailable:
+		return "Available On Shelf"
+	case postgres.BookStatusIssued:
+		return "On Loan"
+	case postgres.BookStatusReserved:
+		return "Available For Pickup"
+	case postgres.BookStatusInTransit:
+		return "In Transit Between Library Locations"
+	case postgres.BookStatusLost:
+		return "Lost"
+	}
+	return "Not Available"
+}
+
+// userLanguage код языка ISO 639-2 для UserLanguage
+func userLanguage(lang string) string {
+	switch lang {
+	case "en":
+		return "eng"
+	case "ru":
+		return "rus"
+	}
+	return ""
+}
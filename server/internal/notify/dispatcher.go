@@ -0,0 +1,171 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hnnsly/library-console/internal/repository"
+	"github.com/hnnsly/library-console/internal/repository/postgres"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// deliveryBatch сколько уведомлений одного канала отправляется за проход
+	deliveryBatch = 50
+	// deliveryLease на это время взятые в работу уведомления скрыты от других экземпляров сервера
+	deliveryLease = 15 * time.Minute
+	// maxRetryDelay верхняя граница экспоненциальной паузы между попытками
+	maxRetryDelay = 6 * time.Hour
+)
+
+// Dispatcher рассылает уведомления из очереди через зарегистрированные каналы
+type Dispatcher struct {
+	repo        *repository.LibraryRepository
+	channels    map[postgres.NotificationChannel]channel
+	maxAttempts int
+}
+
+type channel struct {
+	transport Transport
+	quiet     *QuietHours
+}
+
+// NewDispatcher создает диспетчер без каналов доставки
+func NewDispatcher(repo *repository.LibraryRepository, maxAttempts int) *Dispatcher {
+	return &Dispatcher{
+		repo:        repo,
+		channels:    map[postgres.NotificationChannel]channel{},
+		maxAttempts: maxAttempts,
+	}
+}
+
+// Register подключает канал доставки. В тихие часы quiet уведомления канала ждут в очереди
+func (d *Dispatcher) Register(name postgres.NotificationChannel, transport Transport, quiet *QuietHours) {
+	d.channels[name] = channel{transport: transport, quiet: quiet}
+}
+
+// Enabled сообщает, подключен ли канал
+func (d *Dispatcher) Enabled(name postgres.NotificationChannel) bool {
+	_, ok := d.channels[name]
+	return ok
+}
+
+// Channels возвращает подключенные каналы; уведомления ставятся в очередь только по ним
+func (d *Dispatcher) Channels() []string {
+	names := make([]string, 0, len(d.channels))
+	for name := range d.channels {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Send сразу отправляет сообщение по шаблону, минуя очередь. Используется для ссылок входа,
+// которые бессмысленно доставлять с опозданием и нельзя хранить в журнале
+func (d *Dispatcher) Send(ctx context.Context, name postgres.NotificationChannel, to, template, lang string, data map[string]any) error {
+	ch, ok := d.channels[name]
+	if !ok {
+		return fmt.Errorf("канал %s не настроен", name)
+	}
+	msg, err := render(name, template, lang, data)
+	if err != nil {
+		return err
+	}
+	msg.To = to
+	return ch.transport.Send(ctx, msg)
+}
+
+// DeliverPending отменяет неактуальные уведомления (по возвращенным книгам, о прежнем сроке
+// после продления, о видах, от которых читатель отказался) и отправляет очередную порцию по каждому каналу,
+// кроме тех, у которых сейчас тихие часы
+func (d *Dispatcher) DeliverPending(ctx context.Context) (sent, failed int, err error) {
+	if _, err := d.repo.CancelStaleNotifications(ctx); err != nil {
+		return 0, 0, fmt.Errorf("отмена неактуальных уведомлений: %w", err)
+	}
+
+	now := time.Now()
+	for name, ch := range d.channels {
+		if ch.quiet != nil && ch.quiet.Contains(now) {
+			continue
+		}
+
+		batch, err := d.repo.ClaimPendingNotifications(ctx, postgres.ClaimPendingNotificationsParams{
+			LeaseSeconds: int(deliveryLease.Seconds()),
+			Channel:      name,
+			LimitCount:   deliveryBatch,
+		})
+		if err != nil {
+			return sent, failed, fmt.Errorf("выборка уведомлений %s: %w", name, err)
+		}
+
+		for _, n := range batch {
+			if ctx.Err() != nil {
+				return sent, failed, ctx.Err()
+			}
+			if err := d.deliver(ctx, ch.transport, n); err != nil {
+				failed++
+				log.Warn().Err(err).Str("notificationID", n.ID.String()).Str("channel", string(name)).
+					Int("attempt", n.Attempts+1).Msg("Notification delivery failed")
+				if err := d.markFailed(ctx, n, err); err != nil {
+					return sent, failed, err
+				}
+				continue
+			}
+			sent++
+			if err := d.repo.MarkNotificationSent(ctx, n.ID); err != nil {
+				return sent, failed, fmt.Errorf("уведомление %s: %w", n.ID, err)
+			}
+		}
+	}
+
+	return sent, failed, nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, transport Transport, n *postgres.Notification) error {
+	var data map[string]any
+	if err := json.Unmarshal(n.Payload, &data); err != nil {
+		return fmt.Errorf("данные шаблона: %w", err)
+	}
+	msg, err := render(n.Channel, string(n.Kind), n.Language, data)
+	if err != nil {
+		return err
+	}
+	msg.To = n.Recipient
+	return transport.Send(ctx, msg)
+}
+
+// render выбирает форму сообщения по каналу: письмо с темой или короткий текст SMS
+func render(name postgres.NotificationChannel, template, lang string, data map[string]any) (Message, error) {
+	if name == postgres.NotificationChannelSms {
+		text, err := RenderSMS(template, lang, data)
+		return Message{Body: text}, err
+	}
+	subject, body, err := Render(template, lang, data)
+	return Message{Subject: subject, Body: body}, err
+}
+
+// markFailed записывает ошибку и назначает следующую попытку через 1, 2, 4... минуты
+func (d *Dispatcher) markFailed(ctx context.Context, n *postgres.Notification, cause error) error {
+	delay := min(time.Minute<<min(n.Attempts, 20), maxRetryDelay)
+	lastError := cause.Error()
+	maxAttempts := d.maxAttempts
+	// Повтор не поможет, если адрес заведомо некорректен
+	if errors.Is(cause, ErrInvalidRecipient) {
+		maxAttempts = 1
+	}
+
+	err := d.repo.MarkNotificationFailed(ctx, postgres.MarkNotificationFailedParams{
+		LastError:    &lastError,
+		MaxAttempts:  maxAttempts,
+		RetrySeconds: int(delay.Seconds()),
+		ID:           n.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("уведомление %s: %w", n.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package notify
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrInvalidRecipient адрес получателя не подходит для канала доставки
+var ErrInvalidRecipient = errors.New("некорректный адрес получателя")
+
+// Message готовое к отправке сообщение
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Transport канал доставки сообщений читателям
+type Transport interface {
+	Send(ctx context.Context, msg Message) error
+}
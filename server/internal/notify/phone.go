@@ -0,0 +1,43 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NormalizePhone приводит номер к E.164 (+79161234567). Национальные номера дополняются
+// кодом страны countryCode; российский префикс 8 заменяется на 7
+func NormalizePhone(raw, countryCode string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	international := strings.HasPrefix(raw, "+")
+
+	var digits strings.Builder
+	for _, r := range raw {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '+' || r == ' ' || r == '-' || r == '(' || r == ')' || r == '.':
+		default:
+			return "", fmt.Errorf("недопустимый символ %q в номере", r)
+		}
+	}
+	number := digits.String()
+
+	switch {
+	case international:
+	case strings.HasPrefix(number, "00"):
+		number = number[2:]
+	case countryCode == "7" && len(number) == 11 && number[0] == '8':
+		number = "7" + number[1:]
+	case countryCode == "7" && len(number) == 11 && number[0] == '7':
+	default:
+		// Национальный номер: ведущий 0 многих стран - префикс междугородней связи
+		number = countryCode + strings.TrimLeft(number, "0")
+	}
+
+	// E.164: до 15 цифр, код страны не начинается с нуля
+	if len(number) < 8 || len(number) > 15 || number[0] == '0' {
+		return "", fmt.Errorf("номер %q не приводится к E.164", raw)
+	}
+	return "+" + number, nil
+}
@@ -0,0 +1,42 @@
+package notify
+
+import (
+	"fmt"
+	"time"
+)
+
+// QuietHours интервал местного времени, когда канал молчит; может переходить через полночь
+type QuietHours struct {
+	start, end int // минуты от начала суток
+	loc        *time.Location
+}
+
+// ParseQuietHours разбирает границы в формате HH:MM; пустой timezone - часовой пояс сервера
+func ParseQuietHours(start, end, timezone string) (*QuietHours, error) {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, err
+	}
+	q := &QuietHours{loc: loc}
+	for _, bound := range []struct {
+		value string
+		dst   *int
+	}{{start, &q.start}, {end, &q.end}} {
+		t, err := time.Parse("15:04", bound.value)
+		if err != nil {
+			return nil, fmt.Errorf("время %q: %w", bound.value, err)
+		}
+		*bound.dst = t.Hour()*60 + t.Minute()
+	}
+	return q, nil
+}
+
+// Contains сообщает, попадает ли момент t в тихие часы
+func (q *QuietHours) Contains(t time.Time) bool {
+	local := t.In(q.loc)
+	minute := local.Hour()*60 + local.Minute()
+	if q.start <= q.end {
+		return minute >= q.start && minute < q.end
+	}
+	return minute >= q.start || minute < q.end
+}
@@ -0,0 +1,90 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// SMSProvider шлюз, принимающий номер в формате E.164 и готовый текст
+type SMSProvider interface {
+	SendSMS(ctx context.Context, to, text string) error
+}
+
+// SMSTransport нормализует номер, укладывает текст в допустимое число сегментов и передает провайдеру
+type SMSTransport struct {
+	provider    SMSProvider
+	countryCode string
+	maxSegments int
+}
+
+// NewSMSTransport создает SMS-канал поверх провайдера
+func NewSMSTransport(provider SMSProvider, countryCode string, maxSegments int) *SMSTransport {
+	return &SMSTransport{provider: provider, countryCode: countryCode, maxSegments: maxSegments}
+}
+
+// Send отправляет текст сообщения; тема в SMS не используется
+func (t *SMSTransport) Send(ctx context.Context, msg Message) error {
+	to, err := NormalizePhone(msg.To, t.countryCode)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRecipient, err)
+	}
+	return t.provider.SendSMS(ctx, to, FitSMS(msg.Body, t.maxSegments))
+}
+
+// Базовый алфавит GSM 03.38 и символы расширения, которые занимают по два септета
+const (
+	gsmBasic = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+		"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+	gsmExtended = "^{}\\[~]|€\f"
+)
+
+// SMSSegments считает, сколько частей займет текст: GSM-7 вмещает 160 символов
+// (153 в составном сообщении), UCS-2 для кириллицы — 70 (67)
+func SMSSegments(text string) int {
+	units, single, multi := smsLength(text)
+	if units <= single {
+		return 1
+	}
+	return (units + multi - 1) / multi
+}
+
+func smsLength(text string) (units, single, multi int) {
+	septets := 0
+	for _, r := range text {
+		switch {
+		case strings.ContainsRune(gsmBasic, r):
+			septets++
+		case strings.ContainsRune(gsmExtended, r):
+			septets += 2
+		default:
+			return len(utf16.Encode([]rune(text))), 70, 67
+		}
+	}
+	return septets, 160, 153
+}
+
+// FitSMS обрезает текст с многоточием так, чтобы он уложился в maxSegments частей
+func FitSMS(text string, maxSegments int) string {
+	text = strings.TrimSpace(text)
+	if SMSSegments(text) <= maxSegments {
+		return text
+	}
+
+	ellipsis := "…"
+	if _, single, _ := smsLength(text); single == 160 {
+		// Многоточие не входит в GSM-7 и перевело бы все сообщение в UCS-2
+		ellipsis = "..."
+	}
+
+	runes := []rune(text)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		candidate := strings.TrimRight(string(runes), " \n") + ellipsis
+		if SMSSegments(candidate) <= maxSegments {
+			return candidate
+		}
+	}
+	return ""
+}
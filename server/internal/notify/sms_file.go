@@ -0,0 +1,54 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// FileSMSProvider провайдер для разработки: дописывает сообщения в файл построчно в JSON
+// или, если путь не задан, выводит их в журнал
+type FileSMSProvider struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileSMSProvider создает провайдер; пустой path означает вывод в журнал
+func NewFileSMSProvider(path string) *FileSMSProvider {
+	return &FileSMSProvider{path: path}
+}
+
+// SendSMS записывает сообщение вместо отправки
+func (p *FileSMSProvider) SendSMS(_ context.Context, to, text string) error {
+	if p.path == "" {
+		log.Info().Str("to", to).Int("segments", SMSSegments(text)).Str("text", text).Msg("SMS")
+		return nil
+	}
+
+	line, err := json.Marshal(map[string]any{
+		"time":     time.Now().Format(time.RFC3339),
+		"to":       to,
+		"text":     text,
+		"segments": SMSSegments(text),
+	})
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	f, err := os.OpenFile(p.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("файл SMS: %w", err)
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
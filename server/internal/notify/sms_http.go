@@ -0,0 +1,91 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"text/template"
+
+	"github.com/hnnsly/library-console/internal/config"
+)
+
+// HTTPSMSProvider отправляет SMS через произвольный HTTP-шлюз, адрес и тело запроса задаются шаблонами
+type HTTPSMSProvider struct {
+	cfg    config.SMSHTTPConfig
+	sender string
+	url    *template.Template
+	body   *template.Template
+	client *http.Client
+}
+
+type smsRequest struct {
+	To     string
+	Text   string
+	Sender string
+}
+
+// NewHTTPSMSProvider разбирает шаблоны запроса из конфигурации
+func NewHTTPSMSProvider(cfg config.SMSHTTPConfig, sender string) (*HTTPSMSProvider, error) {
+	funcs := template.FuncMap{
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}
+	url, err := template.New("url").Funcs(funcs).Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("шаблон адреса SMS-шлюза: %w", err)
+	}
+	body, err := template.New("body").Funcs(funcs).Parse(cfg.Body)
+	if err != nil {
+		return nil, fmt.Errorf("шаблон запроса SMS-шлюза: %w", err)
+	}
+
+	return &HTTPSMSProvider{
+		cfg:    cfg,
+		sender: sender,
+		url:    url,
+		body:   body,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}, nil
+}
+
+// SendSMS считает отправку успешной при любом ответе 2xx
+func (p *HTTPSMSProvider) SendSMS(ctx context.Context, to, text string) error {
+	data := smsRequest{To: to, Text: text, Sender: p.sender}
+
+	var url, body bytes.Buffer
+	if err := p.url.Execute(&url, data); err != nil {
+		return fmt.Errorf("адрес SMS-шлюза: %w", err)
+	}
+	if err := p.body.Execute(&body, data); err != nil {
+		return fmt.Errorf("запрос SMS-шлюза: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, p.cfg.Method, url.String(), &body)
+	if err != nil {
+		return fmt.Errorf("запрос SMS-шлюза: %w", err)
+	}
+	if body.Len() > 0 {
+		req.Header.Set("Content-Type", p.cfg.ContentType)
+	}
+	for name, value := range p.cfg.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("SMS-шлюз: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		reply, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("SMS-шлюз ответил %s: %s", resp.Status, bytes.TrimSpace(reply))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
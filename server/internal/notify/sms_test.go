@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		name        string
+		raw         string
+		countryCode string
+		want        string
+		wantErr     bool
+	}{
+		{"international with punctuation", "+7 (916) 123-45-67", "7", "+79161234567", false},
+		{"russian trunk prefix 8", "8 916 123 45 67", "7", "+79161234567", false},
+		{"russian number without plus", "79161234567", "7", "+79161234567", false},
+		{"national number", "916.123.45.67", "7", "+79161234567", false},
+		{"international prefix 00", "0044 20 7946 0958", "7", "+442079460958", false},
+		{"national trunk prefix 0", "020 7946 0958", "44", "+442079460958", false},
+		{"surrounding spaces", "  +49 30 901820  ", "7", "+4930901820", false},
+		{"letters", "+7 916 CALL-NOW", "7", "", true},
+		{"too short", "123", "7", "", true},
+		{"too long", "+1234567890123456", "7", "", true},
+		{"country code starting with zero", "+0123456789", "7", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizePhone(tt.raw, tt.countryCode)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizePhone(%q, %q) error = %v, wantErr %v", tt.raw, tt.countryCode, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizePhone(%q, %q) = %q, want %q", tt.raw, tt.countryCode, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSMSSegments(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{"empty", "", 1},
+		{"single GSM-7 part", strings.Repeat("a", 160), 1},
+		{"two GSM-7 parts", strings.Repeat("a", 161), 2},
+		{"full two GSM-7 parts", strings.Repeat("a", 306), 2},
+		{"three GSM-7 parts", strings.Repeat("a", 307), 3},
+		{"extended characters take two septets", strings.Repeat("€", 80), 1},
+		{"extended characters overflow", strings.Repeat("€", 81), 2},
+		{"single UCS-2 part", strings.Repeat("я", 70), 1},
+		{"two UCS-2 parts", strings.Repeat("я", 71), 2},
+		{"three UCS-2 parts", strings.Repeat("я", 135), 3},
+		{"one Cyrillic letter switches encoding", strings.Repeat("a", 70) + "я", 2},
+		{"surrogate pairs take two units", strings.Repeat("😀", 35), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SMSSegments(tt.text); got != tt.want {
+				t.Errorf("SMSSegments() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFitSMS(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		maxSegments int
+		want        string
+	}{
+		{"fits", "Книга готова к выдаче", 1, "Книга готова к выдаче"},
+		{"trims spaces", "  ready  ", 1, "ready"},
+		{"GSM-7 uses ASCII ellipsis", strings.Repeat("a", 161), 1, strings.Repeat("a", 157) + "..."},
+		{"UCS-2 uses ellipsis character", strings.Repeat("я", 71), 1, strings.Repeat("я", 69) + "…"},
+		{"several parts allowed", strings.Repeat("a", 300), 2, strings.Repeat("a", 300)},
+		{"no space before ellipsis", strings.Repeat("a", 156) + " bbbb", 1, strings.Repeat("a", 156) + "..."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FitSMS(tt.text, tt.maxSegments)
+			if got != tt.want {
+				t.Errorf("FitSMS() = %q, want %q", got, tt.want)
+			}
+			if SMSSegments(got) > tt.maxSegments {
+				t.Errorf("FitSMS() result takes %d parts, limit %d", SMSSegments(got), tt.maxSegments)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package notify
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// DefaultLanguage язык сообщений для читателей без выбранного языка
+const DefaultLanguage = "ru"
+
+// Шаблоны лежат в templates/<язык>/<вид>.tmpl и определяют блоки subject, body и, для SMS, sms
+//
+//go:embed templates
+var templateFS embed.FS
+
+var templates = mustParseTemplates()
+
+// SupportedLanguage проверяет, что для языка есть шаблоны
+func SupportedLanguage(lang string) bool {
+	_, ok := templates[lang]
+	return ok
+}
+
+// Render заполняет письмо по шаблону name на языке lang. Для неизвестного языка используется язык по умолчанию
+func Render(name, lang string, data map[string]any) (subject, body string, err error) {
+	if subject, err = execute(name, lang, "subject", data); err != nil {
+		return "", "", err
+	}
+	if body, err = execute(name, lang, "body", data); err != nil {
+		return "", "", err
+	}
+	return subject, body + "\n", nil
+}
+
+// RenderSMS заполняет короткий текст для SMS из блока sms шаблона name
+func RenderSMS(name, lang string, data map[string]any) (string, error) {
+	return execute(name, lang, "sms", data)
+}
+
+func execute(name, lang, block string, data map[string]any) (string, error) {
+	set, ok := templates[lang]
+	if !ok {
+		lang = DefaultLanguage
+		set = templates[lang]
+	}
+	tmpl, ok := set[name]
+	if !ok {
+		return "", fmt.Errorf("нет шаблона %s", name)
+	}
+	if tmpl.Lookup(block) == nil {
+		return "", fmt.Errorf("в шаблоне %s/%s нет блока %s", lang, name, block)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, block, data); err != nil {
+		return "", fmt.Errorf("шаблон %s/%s: %w", lang, name, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+func mustParseTemplates() map[string]map[string]*template.Template {
+	languages, err := templateFS.ReadDir("templates")
+	if err != nil {
+		panic(err)
+	}
+
+	result := make(map[string]map[string]*template.Template, len(languages))
+	for _, dir := range languages {
+		lang := dir.Name()
+		files, err := templateFS.ReadDir(path.Join("templates", lang))
+		if err != nil {
+			panic(err)
+		}
+
+		result[lang] = make(map[string]*template.Template, len(files))
+		for _, file := range files {
+			name := strings.TrimSuffix(file.Name(), ".tmpl")
+			tmpl := template.New(name).Funcs(templateFuncs(lang)).Option("missingkey=zero")
+			result[lang][name] = template.Must(tmpl.ParseFS(templateFS, path.Join("templates", lang, file.Name())))
+		}
+	}
+	return result
+}
+
+func templateFuncs(lang string) template.FuncMap {
+	return template.FuncMap{
+		"int": toInt,
+		"date": func(v any) string {
+			s := fmt.Sprint(v)
+			t, err := time.Parse(time.DateOnly, s)
+			if err != nil {
+				return s
+			}
+			if lang == "en" {
+				return t.Format("January 2, 2006")
+			}
+			return t.Format("02.01.2006")
+		},
+		"days": func(v any) string {
+			n := toInt(v)
+			if lang == "en" {
+				if n == 1 {
+					return "1 day"
+				}
+				return fmt.Sprintf("%d days", n)
+			}
+			return fmt.Sprintf("%d %s", n, russianPlural(n, "день", "дня", "дней"))
+		},
+	}
+}
+
+// toInt приводит число из JSON-данных шаблона к int
+func toInt(v any) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case int64:
+		return int(n)
+	case float64:
+		return int(n)
+	default:
+		return 0
+	}
+}
+
+func russianPlural(n int, one, few, many string) string {
+	n %= 100
+	if n < 0 {
+		n = -n
+	}
+	switch {
+	case n >= 11 && n <= 14:
+		return many
+	case n%10 == 1:
+		return one
+	case n%10 >= 2 && n%10 <= 4:
+		return few
+	default:
+		return many
+	}
+}
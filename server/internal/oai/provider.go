@@ -0,0 +1,414 @@
+package oai
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hnnsly/library-console/internal/biblio"
+	"github.com/hnnsly/library-console/internal/config"
+	"github.com/hnnsly/library-console/internal/repository"
+	"github.com/hnnsly/library-console/internal/repository/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+// format формат метаданных, который отдает провайдер
+type format struct {
+	prefix    string
+	schema    string
+	namespace string
+	render    func(biblio.Record) string
+}
+
+var formats = []format{
+	{
+		prefix:    "oai_dc",
+		schema:    "http://www.openarchives.org/OAI/2.0/oai_dc.xsd",
+		namespace: biblio.OAIDCNamespace,
+		render:    biblio.OAIDublinCore,
+	},
+	{
+		prefix:    "marc21",
+		schema:    "http://www.loc.gov/standards/marcxml/schema/MARC21slim.xsd",
+		namespace: biblio.MARCXMLNamespace,
+		render:    biblio.MARCXML,
+	},
+}
+
+func findFormat(prefix string) (format, bool) {
+	for _, f := range formats {
+		if f.prefix == prefix {
+			return f, true
+		}
+	}
+	return format{}, false
+}
+
+// arguments допустимые аргументы глаголов; true — обязательный
+var arguments = map[string]map[string]bool{
+	"Identify":            {},
+	"ListMetadataFormats": {"identifier": false},
+	"ListSets":            {"resumptionToken": false},
+	"GetRecord":           {"identifier": true, "metadataPrefix": true},
+	"ListIdentifiers":     {"metadataPrefix": true, "from": false, "until": false, "set": false, "resumptionToken": false},
+	"ListRecords":         {"metadataPrefix": true, "from": false, "until": false, "set": false, "resumptionToken": false},
+}
+
+// Provider отвечает на запросы OAI-PMH по каталогу книг. Запись — книга с экземплярами,
+// наборы — читальные залы, где эти экземпляры стоят
+type Provider struct {
+	cfg  config.OAIConfig
+	repo *repository.LibraryRepository
+}
+
+// NewProvider создает провайдер OAI-PMH
+func NewProvider(cfg config.OAIConfig, repo *repository.LibraryRepository) *Provider {
+	return &Provider{cfg: cfg, repo: repo}
+}
+
+// Handle выполняет глагол OAI-PMH. Ошибки протокола возвращаются элементом error в ответе;
+// ошибка функции означает сбой базы данных или кодирования
+func (p *Provider) Handle(ctx context.Context, args url.Values, baseURL string) ([]byte, error) {
+	resp := &responseXML{
+		Xmlns:          namespace,
+		XmlnsXsi:       xsiNamespace,
+		SchemaLocation: schemaLocation,
+		ResponseDate:   datestamp(time.Now()),
+		Request:        requestXML{URL: baseURL},
+	}
+
+	verb, err := validate(args)
+	if err != nil {
+		resp.Errors = []*Error{err.(*Error)}
+		return encode(resp)
+	}
+	// Аргументы повторяются в request только для корректного запроса
+	resp.Request = requestXML{
+		Verb:            verb,
+		Identifier:      args.Get("identifier"),
+		MetadataPrefix:  args.Get("metadataPrefix"),
+		From:            args.Get("from"),
+		Until:           args.Get("until"),
+		Set:             args.Get("set"),
+		ResumptionToken: args.Get("resumptionToken"),
+		URL:             baseURL,
+	}
+
+	switch verb {
+	case "Identify":
+		err = p.identify(ctx, resp, baseURL)
+	case "ListMetadataFormats":
+		err = p.listMetadataFormats(ctx, resp, args.Get("identifier"))
+	case "ListSets":
+		err = p.listSets(ctx, resp, args.Get("resumptionToken"))
+	case "GetRecord":
+		err = p.getRecord(ctx, resp, args.Get("identifier"), args.Get("metadataPrefix"))
+	case "ListIdentifiers", "ListRecords":
+		err = p.list(ctx, resp, verb, args)
+	}
+
+	var oaiErr *Error
+	if errors.As(err, &oaiErr) {
+		resp.Errors = []*Error{oaiErr}
+	} else if err != nil {
+		return nil, err
+	}
+	return encode(resp)
+}
+
+// validate проверяет глагол и набор аргументов: неизвестные, повторенные и недостающие дают badArgument
+func validate(args url.Values) (string, error) {
+	verbs := args["verb"]
+	if len(verbs) != 1 {
+		return "", oaiError(errBadVerb, "the verb argument is missing or repeated")
+	}
+	allowed, ok := arguments[verbs[0]]
+	if !ok {
+		return "", oaiError(errBadVerb, "illegal OAI verb")
+	}
+
+	for name, values := range args {
+		if name == "verb" {
+			continue
+		}
+		if _, ok := allowed[name]; !ok {
+			return "", oaiError(errBadArgument, "illegal argument "+name)
+		}
+		if len(values) != 1 {
+			return "", oaiError(errBadArgument, "repeated argument "+name)
+		}
+	}
+
+	// resumptionToken исключает остальные аргументы
+	if args.Has("resumptionToken") {
+		if len(args) != 2 {
+			return "", oaiError(errBadArgument, "resumptionToken is an exclusive argument")
+		}
+		return verbs[0], nil
+	}
+	for name, required := range allowed {
+		if required && !args.Has(name) {
+			return "", oaiError(errBadArgument, "missing argument "+name)
+		}
+	}
+	return verbs[0], nil
+}
+
+func (p *Provider) identify(ctx context.Context, resp *responseXML, baseURL string) error {
+	earliest, err := p.repo.GetOaiEarliestDatestamp(ctx)
+	if err != nil {
+		return err
+	}
+	resp.Identify = &identifyXML{
+		RepositoryName:    p.cfg.RepositoryName,
+		BaseURL:           baseURL,
+		ProtocolVersion:   "2.0",
+		AdminEmails:       p.cfg.AdminEmails,
+		EarliestDatestamp: datestamp(earliest),
+		// Следы удаленных книг не стираются
+		DeletedRecord: "persistent",
+		Granularity:   "YYYY-MM-DDThh:mm:ssZ",
+		Description: descriptionXML{Identifier: oaiIdentifierXML{
+			Xmlns:                identifierNamespace,
+			SchemaLocation:       identifierSchema,
+			Scheme:               "oai",
+			RepositoryIdentifier: p.cfg.RepositoryIdentifier,
+			Delimiter:            ":",
+			SampleIdentifier:     p.identifier(uuid.Nil),
+		}},
+	}
+	return nil
+}
+
+func (p *Provider) listMetadataFormats(ctx context.Context, resp *responseXML, identifier string) error {
+	if identifier != "" {
+		if _, err := p.record(ctx, identifier); err != nil {
+			return err
+		}
+	}
+	list := &listMetadataFormatsXML{}
+	for _, f := range formats {
+		list.Formats = append(list.Formats, metadataFormatXML{
+			MetadataPrefix:    f.prefix,
+			Schema:            f.schema,
+			MetadataNamespace: f.namespace,
+		})
+	}
+	resp.ListMetadataFormats = list
+	return nil
+}
+
+// listSets отдает залы одним ответом, поэтому любой токен продолжения недействителен
+func (p *Provider) listSets(ctx context.Context, resp *responseXML, token string) error {
+	if token != "" {
+		return oaiError(errBadResumptionToken, "the resumption token is invalid")
+	}
+	halls, err := p.repo.GetAllReadingHalls(ctx)
+	if err != nil {
+		return err
+	}
+	if len(halls) == 0 {
+		return oaiError(errNoSetHierarchy, "the repository has no reading halls")
+	}
+	list := &listSetsXML{}
+	for _, hall := range halls {
+		list.Sets = append(list.Sets, setXML{SetSpec: hall.ID.String(), SetName: hall.HallName})
+	}
+	resp.ListSets = list
+	return nil
+}
+
+func (p *Provider) getRecord(ctx context.Context, resp *responseXML, identifier, prefix string) error {
+	f, ok := findFormat(prefix)
+	if !ok {
+		return oaiError(errCannotDisseminateFormat, "unsupported metadata prefix "+prefix)
+	}
+	rec, err := p.record(ctx, identifier)
+	if err != nil {
+		return err
+	}
+	resp.GetRecord = &getRecordXML{Record: p.recordXML(f, oaiRecord(*rec))}
+	return nil
+}
+
+// record ищет книгу или след удаленной книги по идентификатору oai:<repository>:<id>
+func (p *Provider) record(ctx context.Context, identifier string) (*postgres.GetOaiRecordRow, error) {
+	notFound := oaiError(errIDDoesNotExist, "unknown identifier "+identifier)
+	local, ok := strings.CutPrefix(identifier, "oai:"+p.cfg.RepositoryIdentifier+":")
+	if !ok {
+		return nil, notFound
+	}
+	id, err := uuid.Parse(local)
+	if err != nil {
+		return nil, notFound
+	}
+	rec, err := p.repo.GetOaiRecord(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, notFound
+	}
+	return rec, err
+}
+
+// list выполняет ListIdentifiers и ListRecords: страница записей по возрастанию датировки
+// и токен продолжения, если записи остались
+func (p *Provider) list(ctx context.Context, resp *responseXML, verb string, args url.Values) error {
+	var (
+		h   harvest
+		err error
+	)
+	if token := args.Get("resumptionToken"); token != "" {
+		if h, err = parseToken(token); err != nil {
+			return err
+		}
+	} else if h, err = newHarvest(args); err != nil {
+		return err
+	}
+
+	f, ok := findFormat(h.prefix)
+	if !ok {
+		return oaiError(errCannotDisseminateFormat, "unsupported metadata prefix "+h.prefix)
+	}
+	if h.set != "" {
+		if _, err := uuid.Parse(h.set); err != nil {
+			return oaiError(errNoRecordsMatch, "unknown set "+h.set)
+		}
+	}
+
+	rows, err := p.repo.ListOaiRecords(ctx, postgres.ListOaiRecordsParams{
+		AfterTime:  h.afterTime,
+		AfterID:    h.afterID,
+		UntilTime:  h.until,
+		HallID:     h.set,
+		LimitCount: int32(p.cfg.PageSize + 1),
+	})
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		if h.cursor > 0 {
+			// Записи предыдущих страниц изменились и ушли в конец выборки
+			return oaiError(errBadResumptionToken, "the list has changed since the token was issued")
+		}
+		return oaiError(errNoRecordsMatch, "no records match the request")
+	}
+
+	var next *resumptionTokenXML
+	if len(rows) > p.cfg.PageSize {
+		rows = rows[:p.cfg.PageSize]
+		last := rows[len(rows)-1]
+		following := h
+		following.afterTime, following.afterID = last.UpdatedAt, last.ID
+		following.cursor += len(rows)
+		next = &resumptionTokenXML{Cursor: h.cursor, Token: following.token()}
+	} else if h.cursor > 0 {
+		// Пустой токен на последней странице сообщает, что список закончен
+		next = &resumptionTokenXML{Cursor: h.cursor}
+	}
+
+	if verb == "ListIdentifiers" {
+		list := &listIdentifiersXML{ResumptionToken: next}
+		for _, row := range rows {
+			list.Headers = append(list.Headers, p.header(oaiRecord(*row)))
+		}
+		resp.ListIdentifiers = list
+		return nil
+	}
+	list := &listRecordsXML{ResumptionToken: next}
+	for _, row := range rows {
+		list.Records = append(list.Records, p.recordXML(f, oaiRecord(*row)))
+	}
+	resp.ListRecords = list
+	return nil
+}
+
+// newHarvest разбирает from и until: обе границы включаются и должны быть одной точности
+func newHarvest(args url.Values) (harvest, error) {
+	h := harvest{
+		prefix:    args.Get("metadataPrefix"),
+		set:       args.Get("set"),
+		afterTime: time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC),
+		until:     time.Date(9999, 12, 31, 23, 59, 59, 999999000, time.UTC),
+	}
+
+	from, fromDay, err := parseDatestamp(args.Get("from"))
+	if err != nil {
+		return h, err
+	}
+	until, untilDay, err := parseDatestamp(args.Get("until"))
+	if err != nil {
+		return h, err
+	}
+	if !from.IsZero() && !until.IsZero() {
+		if fromDay != untilDay {
+			return h, oaiError(errBadArgument, "from and until must have the same granularity")
+		}
+		if from.After(until) {
+			return h, oaiError(errBadArgument, "from must not be later than until")
+		}
+	}
+	// Датировки хранятся с микросекундами: from начинает выборку сразу после предыдущей микросекунды,
+	// until включает всю свою секунду или день
+	if !from.IsZero() {
+		h.afterTime = from.Add(-time.Microsecond)
+		h.afterID = uuid.Max
+	}
+	if !until.IsZero() {
+		h.until = until.Add(time.Second - time.Microsecond)
+		if untilDay {
+			h.until = until.Add(24*time.Hour - time.Microsecond)
+		}
+	}
+	return h, nil
+}
+
+// parseDatestamp принимает дату или дату со временем в UTC; пустая строка дает нулевое время
+func parseDatestamp(value string) (time.Time, bool, error) {
+	if value == "" {
+		return time.Time{}, false, nil
+	}
+	if t, err := time.Parse(granularity, value); err == nil {
+		return t, false, nil
+	}
+	if t, err := time.Parse(dayLayout, value); err == nil {
+		return t, true, nil
+	}
+	return time.Time{}, false, oaiError(errBadArgument, "illegal datestamp "+value)
+}
+
+func (p *Provider) identifier(id uuid.UUID) string {
+	return "oai:" + p.cfg.RepositoryIdentifier + ":" + id.String()
+}
+
+func (p *Provider) header(r oaiRecord) headerXML {
+	h := headerXML{
+		Identifier: p.identifier(r.ID),
+		Datestamp:  datestamp(r.UpdatedAt),
+		SetSpecs:   r.HallIds,
+	}
+	if r.Deleted {
+		h.Status = "deleted"
+	}
+	return h
+}
+
+// recordXML у удаленной записи есть только заголовок
+func (p *Provider) recordXML(f format, r oaiRecord) recordXML {
+	rec := recordXML{Header: p.header(r)}
+	if !r.Deleted {
+		rec.Metadata = &metadataXML{Inner: f.render(biblio.Record{
+			ID:              r.ID,
+			Title:           r.Title,
+			Authors:         r.Authors,
+			Isbn:            r.Isbn,
+			Publisher:       r.Publisher,
+			PublicationYear: r.PublicationYear,
+		})}
+	}
+	return rec
+}
+
+// oaiRecord общая форма строк ListOaiRecords и GetOaiRecord, поля запросов совпадают
+type oaiRecord postgres.ListOaiRecordsRow
@@ -0,0 +1,162 @@
+package oai
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+const (
+	namespace      = "http://www.openarchives.org/OAI/2.0/"
+	schemaLocation = "http://www.openarchives.org/OAI/2.0/ http://www.openarchives.org/OAI/2.0/OAI-PMH.xsd"
+	xsiNamespace   = "http://www.w3.org/2001/XMLSchema-instance"
+
+	identifierNamespace = "http://www.openarchives.org/OAI/2.0/oai-identifier"
+	identifierSchema    = "http://www.openarchives.org/OAI/2.0/oai-identifier http://www.openarchives.org/OAI/2.0/oai-identifier.xsd"
+
+	// granularity точность датировок: секунды в UTC
+	granularity = "2006-01-02T15:04:05Z"
+	dayLayout   = "2006-01-02"
+)
+
+// Коды ошибок OAI-PMH
+const (
+	errBadArgument             = "badArgument"
+	errBadResumptionToken      = "badResumptionToken"
+	errBadVerb                 = "badVerb"
+	errCannotDisseminateFormat = "cannotDisseminateFormat"
+	errIDDoesNotExist          = "idDoesNotExist"
+	errNoRecordsMatch          = "noRecordsMatch"
+	errNoSetHierarchy          = "noSetHierarchy"
+)
+
+// Error ошибка запроса OAI-PMH; возвращается в ответе вместо результата глагола
+type Error struct {
+	Code    string `xml:"code,attr"`
+	Message string `xml:",chardata"`
+}
+
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+func oaiError(code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+type requestXML struct {
+	Verb            string `xml:"verb,attr,omitempty"`
+	Identifier      string `xml:"identifier,attr,omitempty"`
+	MetadataPrefix  string `xml:"metadataPrefix,attr,omitempty"`
+	From            string `xml:"from,attr,omitempty"`
+	Until           string `xml:"until,attr,omitempty"`
+	Set             string `xml:"set,attr,omitempty"`
+	ResumptionToken string `xml:"resumptionToken,attr,omitempty"`
+	URL             string `xml:",chardata"`
+}
+
+type responseXML struct {
+	XMLName        xml.Name   `xml:"OAI-PMH"`
+	Xmlns          string     `xml:"xmlns,attr"`
+	XmlnsXsi       string     `xml:"xmlns:xsi,attr"`
+	SchemaLocation string     `xml:"xsi:schemaLocation,attr"`
+	ResponseDate   string     `xml:"responseDate"`
+	Request        requestXML `xml:"request"`
+	Errors         []*Error   `xml:"error,omitempty"`
+
+	Identify            *identifyXML            `xml:"Identify,omitempty"`
+	ListMetadataFormats *listMetadataFormatsXML `xml:"ListMetadataFormats,omitempty"`
+	ListSets            *listSetsXML            `xml:"ListSets,omitempty"`
+	GetRecord           *getRecordXML           `xml:"GetRecord,omitempty"`
+	ListIdentifiers     *listIdentifiersXML     `xml:"ListIdentifiers,omitempty"`
+	ListRecords         *listRecordsXML         `xml:"ListRecords,omitempty"`
+}
+
+type identifyXML struct {
+	RepositoryName    string         `xml:"repositoryName"`
+	BaseURL           string         `xml:"baseURL"`
+	ProtocolVersion   string         `xml:"protocolVersion"`
+	AdminEmails       []string       `xml:"adminEmail"`
+	EarliestDatestamp string         `xml:"earliestDatestamp"`
+	DeletedRecord     string         `xml:"deletedRecord"`
+	Granularity       string         `xml:"granularity"`
+	Description       descriptionXML `xml:"description"`
+}
+
+type descriptionXML struct {
+	Identifier oaiIdentifierXML `xml:"oai-identifier"`
+}
+
+type oaiIdentifierXML struct {
+	Xmlns                string `xml:"xmlns,attr"`
+	SchemaLocation       string `xml:"xsi:schemaLocation,attr"`
+	Scheme               string `xml:"scheme"`
+	RepositoryIdentifier string `xml:"repositoryIdentifier"`
+	Delimiter            string `xml:"delimiter"`
+	SampleIdentifier     string `xml:"sampleIdentifier"`
+}
+
+type metadataFormatXML struct {
+	MetadataPrefix    string `xml:"metadataPrefix"`
+	Schema            string `xml:"schema"`
+	MetadataNamespace string `xml:"metadataNamespace"`
+}
+
+type listMetadataFormatsXML struct {
+	Formats []metadataFormatXML `xml:"metadataFormat"`
+}
+
+type setXML struct {
+	SetSpec string `xml:"setSpec"`
+	SetName string `xml:"setName"`
+}
+
+type listSetsXML struct {
+	Sets []setXML `xml:"set"`
+}
+
+type headerXML struct {
+	Status     string   `xml:"status,attr,omitempty"`
+	Identifier string   `xml:"identifier"`
+	Datestamp  string   `xml:"datestamp"`
+	SetSpecs   []string `xml:"setSpec"`
+}
+
+type metadataXML struct {
+	Inner string `xml:",innerxml"`
+}
+
+type recordXML struct {
+	Header   headerXML    `xml:"header"`
+	Metadata *metadataXML `xml:"metadata,omitempty"`
+}
+
+type getRecordXML struct {
+	Record recordXML `xml:"record"`
+}
+
+type resumptionTokenXML struct {
+	Cursor int    `xml:"cursor,attr"`
+	Token  string `xml:",chardata"`
+}
+
+type listIdentifiersXML struct {
+	Headers         []headerXML         `xml:"header"`
+	ResumptionToken *resumptionTokenXML `xml:"resumptionToken,omitempty"`
+}
+
+type listRecordsXML struct {
+	Records         []recordXML         `xml:"record"`
+	ResumptionToken *resumptionTokenXML `xml:"resumptionToken,omitempty"`
+}
+
+func datestamp(t time.Time) string {
+	return t.UTC().Format(granularity)
+}
+
+func encode(v any) ([]byte, error) {
+	body, err := xml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
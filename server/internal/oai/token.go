@@ -0,0 +1,61 @@
+package oai
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// harvest параметры выборочного сбора. Токен продолжения хранит их целиком вместе с ключом
+// последней отданной записи, поэтому сервер не держит состояния между запросами
+type harvest struct {
+	prefix string
+	set    string
+	until  time.Time
+	// afterTime и afterID ключ (updated_at, id), после которого начинается страница
+	afterTime time.Time
+	afterID   uuid.UUID
+	cursor    int
+}
+
+func (h harvest) token() string {
+	raw := strings.Join([]string{
+		h.prefix,
+		h.set,
+		strconv.FormatInt(h.until.UnixMicro(), 10),
+		strconv.FormatInt(h.afterTime.UnixMicro(), 10),
+		h.afterID.String(),
+		strconv.Itoa(h.cursor),
+	}, "|")
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func parseToken(token string) (harvest, error) {
+	bad := oaiError(errBadResumptionToken, "the resumption token is invalid")
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return harvest{}, bad
+	}
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 6 {
+		return harvest{}, bad
+	}
+	until, err1 := strconv.ParseInt(parts[2], 10, 64)
+	after, err2 := strconv.ParseInt(parts[3], 10, 64)
+	id, err3 := uuid.Parse(parts[4])
+	cursor, err4 := strconv.Atoi(parts[5])
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil || cursor < 0 {
+		return harvest{}, bad
+	}
+	return harvest{
+		prefix:    parts[0],
+		set:       parts[1],
+		until:     time.UnixMicro(until).UTC(),
+		afterTime: time.UnixMicro(after).UTC(),
+		afterID:   id,
+		cursor:    cursor,
+	}, nil
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+)
+
+// RefreshVisitRollups пересчитывает сеансы и агрегаты посещаемости залов,
+// начиная за сутки до последнего рассчитанного часа
+func (r *LibraryRepository) RefreshVisitRollups(ctx context.Context) error {
+	latest, err := r.GetLatestHourlyRollup(ctx)
+	if err != nil {
+		return fmt.Errorf("не удалось получить последний агрегат: %w", err)
+	}
+	since := latest.AddDate(0, 0, -1)
+
+	// Порядок важен: дневные агрегаты строятся из сеансов и почасовых агрегатов
+	if _, err := r.RefreshHallVisitSessions(ctx, since); err != nil {
+		return fmt.Errorf("не удалось пересчитать сеансы посещений: %w", err)
+	}
+	if _, err := r.RefreshHallHourlyRollups(ctx, since); err != nil {
+		return fmt.Errorf("не удалось пересчитать почасовые агрегаты: %w", err)
+	}
+	if _, err := r.RefreshHallDailyRollups(ctx, since); err != nil {
+		return fmt.Errorf("не удалось пересчитать дневные агрегаты: %w", err)
+	}
+
+	return nil
+}
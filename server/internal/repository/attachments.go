@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/hnnsly/library-console/internal/repository/postgres"
+)
+
+// AddBookAttachment сохраняет запись о вложении. Новая обложка заменяет прежнюю:
+// замененные записи возвращаются, чтобы их файлы были удалены из хранилища
+func (r *LibraryRepository) AddBookAttachment(ctx context.Context, arg postgres.CreateBookAttachmentParams) (*postgres.BookAttachment, []*postgres.BookAttachment, error) {
+	var (
+		created  *postgres.BookAttachment
+		replaced []*postgres.BookAttachment
+	)
+	err := r.inTx(ctx, func(q *postgres.Queries) error {
+		if arg.Kind == postgres.AttachmentKindCover {
+			var err error
+			replaced, err = q.DeleteBookCovers(ctx, arg.BookID)
+			if err != nil {
+				return err
+			}
+		}
+
+		var err error
+		created, err = q.CreateBookAttachment(ctx, arg)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return created, replaced, nil
+}
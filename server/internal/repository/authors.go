@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hnnsly/library-console/internal/repository/postgres"
+)
+
+var (
+	// ErrMergeSameAuthor попытка объединить автора с самим собой
+	ErrMergeSameAuthor = errors.New("нельзя объединить автора с самим собой")
+	// ErrAuthorYearsConflict у объединяемых авторов указаны разные годы жизни
+	ErrAuthorYearsConflict = errors.New("годы жизни авторов не совпадают")
+)
+
+// AuthorMerge результат объединения дубля с основной авторитетной записью
+type AuthorMerge struct {
+	Author     *postgres.Author `json:"author"`
+	MovedLinks int64            `json:"moved_links"`
+}
+
+// GetOrCreateAuthor находит автора по основной форме или варианту имени либо создает нового.
+// Одновременные запросы с одним именем не создают двух авторов
+func (r *LibraryRepository) GetOrCreateAuthor(ctx context.Context, fullName string) (*postgres.GetOrCreateAuthorRow, error) {
+	var author *postgres.GetOrCreateAuthorRow
+	err := r.inTx(ctx, func(q *postgres.Queries) error {
+		var err error
+		author, err = getOrCreateAuthor(ctx, q, fullName)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return author, nil
+}
+
+// getOrCreateAuthor ищет или создает автора в текущей транзакции. Запрос выполняется после
+// блокировки, поэтому его снимок уже видит автора, созданного конкурирующей транзакцией
+func getOrCreateAuthor(ctx context.Context, q *postgres.Queries, fullName string) (*postgres.GetOrCreateAuthorRow, error) {
+	if err := q.LockAuthorName(ctx, fullName); err != nil {
+		return nil, fmt.Errorf("блокировка имени автора: %w", err)
+	}
+	return q.GetOrCreateAuthor(ctx, fullName)
+}
+
+// MergeAuthors переносит связи с книгами и варианты имени дубля на основную запись,
+// дополняет ее годами жизни и формой для сортировки и удаляет дубль.
+// Имя дубля сохраняется вариантом, поэтому GetOrCreateAuthor и дальше находит основную запись
+func (r *LibraryRepository) MergeAuthors(ctx context.Context, survivingID, duplicateID uuid.UUID) (*AuthorMerge, error) {
+	if survivingID == duplicateID {
+		return nil, ErrMergeSameAuthor
+	}
+
+	var merge AuthorMerge
+	err := r.inTx(ctx, func(q *postgres.Queries) error {
+		// Блокировки берутся в порядке id, чтобы встречные объединения не взаимоблокировались
+		ids := []uuid.UUID{survivingID, duplicateID}
+		if bytes.Compare(ids[0][:], ids[1][:]) > 0 {
+			ids[0], ids[1] = ids[1], ids[0]
+		}
+		locked := make(map[uuid.UUID]*postgres.Author, len(ids))
+		for _, id := range ids {
+			author, err := q.LockAuthor(ctx, id)
+			if err != nil {
+				return err
+			}
+			locked[id] = author
+		}
+		if yearsConflict(locked[survivingID].BirthYear, locked[duplicateID].BirthYear) ||
+			yearsConflict(locked[survivingID].DeathYear, locked[duplicateID].DeathYear) {
+			return ErrAuthorYearsConflict
+		}
+
+		move := postgres.CopyAuthorBookLinksParams{TargetID: survivingID, SourceID: duplicateID}
+		links, err := q.CopyAuthorBookLinks(ctx, move)
+		if err != nil {
+			return fmt.Errorf("перенос связей с книгами: %w", err)
+		}
+		if err := q.CopyAuthorNameVariants(ctx, postgres.CopyAuthorNameVariantsParams(move)); err != nil {
+			return fmt.Errorf("перенос вариантов имени: %w", err)
+		}
+		author, err := q.FillAuthorAuthorityData(ctx, postgres.FillAuthorAuthorityDataParams(move))
+		if err != nil {
+			return err
+		}
+
+		// Связи дубля удаляются каскадно, триггеры обновляют даты изменения его книг
+		if err := q.DeleteMergedAuthor(ctx, duplicateID); err != nil {
+			return err
+		}
+
+		merge = AuthorMerge{Author: author, MovedLinks: links}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &merge, nil
+}
+
+// yearsConflict сравнивает годы, известные у обеих записей
+func yearsConflict(a, b *int) bool {
+	return a != nil && b != nil && *a != *b
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hnnsly/library-console/internal/repository/postgres"
+)
+
+var (
+	// ErrSeatMapTooLarge мест в схеме стало бы больше, чем мест в зале
+	ErrSeatMapTooLarge = errors.New("схема мест больше числа мест в зале")
+	// ErrHallFull в зале без схемы мест на это время заняты все места
+	ErrHallFull = errors.New("нет свободных мест на это время")
+)
+
+// DuplicateSeatError место с таким номером уже есть в зале
+type DuplicateSeatError struct {
+	SeatNumber string
+}
+
+func (e *DuplicateSeatError) Error() string {
+	return fmt.Sprintf("место %s уже есть в зале", e.SeatNumber)
+}
+
+// CreateHallSeats добавляет места в схему зала целиком или не добавляет ни одного.
+// Строка зала блокируется, чтобы параллельные запросы не превысили число мест
+func (r *LibraryRepository) CreateHallSeats(ctx context.Context, hallID uuid.UUID, seatNumbers []string) ([]*postgres.CreateHallSeatRow, error) {
+	var seats []*postgres.CreateHallSeatRow
+	err := r.inTx(ctx, func(q *postgres.Queries) error {
+		totalSeats, err := q.LockReadingHall(ctx, hallID)
+		if err != nil {
+			return err
+		}
+		seatsCount, err := q.CountActiveHallSeats(ctx, hallID)
+		if err != nil {
+			return err
+		}
+		if int(seatsCount)+len(seatNumbers) > totalSeats {
+			return ErrSeatMapTooLarge
+		}
+
+		seats = make([]*postgres.CreateHallSeatRow, 0, len(seatNumbers))
+		for _, seatNumber := range seatNumbers {
+			seat, err := q.CreateHallSeat(ctx, postgres.CreateHallSeatParams{
+				HallID:     hallID,
+				SeatNumber: seatNumber,
+			})
+			if err != nil {
+				if strings.Contains(err.Error(), "duplicate") {
+					return &DuplicateSeatError{SeatNumber: seatNumber}
+				}
+				return fmt.Errorf("место %s: %w", seatNumber, err)
+			}
+			seats = append(seats, seat)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return seats, nil
+}
+
+// CreateHallBooking бронирует место в зале без схемы мест. Занятость проверяется под блокировкой
+// строки зала, поэтому параллельные бронирования не превышают число мест
+func (r *LibraryRepository) CreateHallBooking(ctx context.Context, arg postgres.CreateSeatBookingParams) (*postgres.CreateSeatBookingRow, error) {
+	var booking *postgres.CreateSeatBookingRow
+	err := r.inTx(ctx, func(q *postgres.Queries) error {
+		totalSeats, err := q.LockReadingHall(ctx, arg.HallID)
+		if err != nil {
+			return err
+		}
+		booked, err := q.CountOverlappingHallBookings(ctx, postgres.CountOverlappingHallBookingsParams{
+			HallID:    arg.HallID,
+			StartTime: arg.StartTime,
+			EndTime:   arg.EndTime,
+		})
+		if err != nil {
+			return err
+		}
+		if int(booked) >= totalSeats {
+			return ErrHallFull
+		}
+
+		booking, err = q.CreateSeatBooking(ctx, arg)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return booking, nil
+}
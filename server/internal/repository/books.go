@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hnnsly/library-console/internal/repository/postgres"
+)
+
+// CreateBookWithCopies создает книгу вместе с copies экземплярами в одной транзакции.
+// Счетчики книги пересчитываются триггером по book_copies
+func (r *LibraryRepository) CreateBookWithCopies(ctx context.Context, arg postgres.CreateBookParams, copies int, hallID *uuid.UUID) (*postgres.GetBookByIdRow, error) {
+	var book *postgres.GetBookByIdRow
+	err := r.inTx(ctx, func(q *postgres.Queries) error {
+		created, err := q.CreateBook(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		for n := 1; n <= copies; n++ {
+			code, err := r.nextCopyCode(ctx, q, hallID)
+			if err != nil {
+				return err
+			}
+
+			_, err = q.CreateBookCopy(ctx, postgres.CreateBookCopyParams{
+				BookID:   created.ID,
+				CopyCode: code,
+				HallID:   hallID,
+			})
+			if err != nil {
+				return fmt.Errorf("не удалось создать экземпляр %d: %w", n, err)
+			}
+		}
+
+		book, err = q.GetBookById(ctx, created.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return book, nil
+}
+
+// GenerateCopyCode выдает следующий шифр экземпляра для указанного зала
+func (r *LibraryRepository) GenerateCopyCode(ctx context.Context, hallID *uuid.UUID) (string, error) {
+	return r.nextCopyCode(ctx, &r.Queries, hallID)
+}
+
+// nextCopyCode берет номер из последовательности copy_code_seq и код зала, если он задан
+func (r *LibraryRepository) nextCopyCode(ctx context.Context, q *postgres.Queries, hallID *uuid.UUID) (string, error) {
+	sequence, err := q.NextCopyCodeSequence(ctx)
+	if err != nil {
+		return "", fmt.Errorf("не удалось получить номер шифра: %w", err)
+	}
+
+	var hallCode string
+	if hallID != nil {
+		hall, err := q.GetReadingHallById(ctx, *hallID)
+		if err != nil {
+			return "", fmt.Errorf("не удалось получить зал: %w", err)
+		}
+		if hall.HallCode != nil {
+			hallCode = *hall.HallCode
+		}
+	}
+
+	return r.copyCodes.Format(sequence, hallCode), nil
+}
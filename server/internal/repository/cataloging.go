@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/hnnsly/library-console/internal/repository/postgres"
+)
+
+// ImportMarcRecords сохраняет пачку записей из дампа MARC одной транзакцией;
+// запись с уже загруженным ISBN заменяется
+func (r *LibraryRepository) ImportMarcRecords(ctx context.Context, records []postgres.UpsertMarcRecordParams) error {
+	return r.inTx(ctx, func(q *postgres.Queries) error {
+		for _, rec := range records {
+			if err := q.UpsertMarcRecord(ctx, rec); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hnnsly/library-console/internal/repository/postgres"
+)
+
+var (
+	// ErrInvalidCopyTransition переход между статусами экземпляра не разрешён
+	ErrInvalidCopyTransition = errors.New("недопустимый переход статуса экземпляра")
+	// ErrCirculationOnly статус «выдан» устанавливается только выдачей, а снимается возвратом или потерей
+	ErrCirculationOnly = errors.New("статус выдачи меняется только при выдаче, возврате или потере")
+)
+
+// copyTransitions допустимые переходы между статусами экземпляров
+var copyTransitions = map[postgres.BookStatus][]postgres.BookStatus{
+	postgres.BookStatusAvailable: {
+		postgres.BookStatusIssued,
+		postgres.BookStatusReserved,
+		postgres.BookStatusInRepair,
+		postgres.BookStatusLost,
+		postgres.BookStatusDamaged,
+		postgres.BookStatusWithdrawn,
+		postgres.BookStatusInTransit,
+	},
+	postgres.BookStatusIssued: {
+		postgres.BookStatusAvailable,
+		// читатель сообщил о потере; открытая выдача закрывается вместе со сменой статуса
+		postgres.BookStatusLost,
+	},
+	postgres.BookStatusReserved: {
+		postgres.BookStatusAvailable,
+		postgres.BookStatusIssued,
+		postgres.BookStatusInTransit,
+		postgres.BookStatusLost,
+	},
+	postgres.BookStatusInRepair: {
+		postgres.BookStatusAvailable,
+		postgres.BookStatusDamaged,
+		postgres.BookStatusWithdrawn,
+	},
+	postgres.BookStatusLost: {
+		postgres.BookStatusAvailable,
+		postgres.BookStatusWithdrawn,
+	},
+	postgres.BookStatusDamaged: {
+		postgres.BookStatusAvailable,
+		postgres.BookStatusInRepair,
+		postgres.BookStatusWithdrawn,
+	},
+	postgres.BookStatusInTransit: {
+		postgres.BookStatusAvailable,
+		postgres.BookStatusReserved,
+		postgres.BookStatusLost,
+	},
+	postgres.BookStatusWithdrawn: {},
+}
+
+// IsCopyStatus проверяет, что значение является известным статусом экземпляра
+func IsCopyStatus(status postgres.BookStatus) bool {
+	_, ok := copyTransitions[status]
+	return ok
+}
+
+// CanTransitionCopy проверяет, разрешён ли переход from → to
+func CanTransitionCopy(from, to postgres.BookStatus) bool {
+	for _, allowed := range copyTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// CopyTransition запрос на смену статуса экземпляра
+type CopyTransition struct {
+	CopyID    uuid.UUID
+	To        postgres.BookStatus
+	Reason    string
+	IssueID   *uuid.UUID // задаётся только выдачей и возвратом
+	ChangedBy *uuid.UUID
+	// HoldReader читатель, для которого экземпляр откладывается; только для статуса «зарезервирован»
+	HoldReader *uuid.UUID
+}
+
+// TransitionCopyStatus меняет статус экземпляра и записывает переход в историю.
+// Если выданный экземпляр объявлен потерянным, открытая выдача закрывается в той же транзакции
+func (r *LibraryRepository) TransitionCopyStatus(ctx context.Context, t CopyTransition) error {
+	return r.inTx(ctx, func(q *postgres.Queries) error {
+		current, err := q.LockBookCopyStatus(ctx, t.CopyID)
+		if err != nil {
+			return err
+		}
+		if current.Valid && current.BookStatus == postgres.BookStatusIssued && t.To == postgres.BookStatusLost && t.IssueID == nil {
+			// Потерянную книгу не возвращают: выдача закрывается без промежуточного статуса «доступен»
+			returned, err := q.ReturnBook(ctx, t.CopyID)
+			if err != nil {
+				return fmt.Errorf("закрытие выдачи потерянного экземпляра: %w", err)
+			}
+			if err := emitLoanWebhook(ctx, q, WebhookLoanReturned, returned.ID); err != nil {
+				return err
+			}
+			t.IssueID = &returned.ID
+		}
+		return transitionCopyStatus(ctx, q, t)
+	})
+}
+
+// IssueBookCopy оформляет выдачу, переводит экземпляр в статус «выдан» и публикует loan.issued в одной транзакции
+func (r *LibraryRepository) IssueBookCopy(ctx context.Context, arg postgres.IssueBookParams, reason string) (*postgres.IssueBookRow, error) {
+	var issue *postgres.IssueBookRow
+	err := r.inTx(ctx, func(q *postgres.Queries) error {
+		var err error
+		issue, err = q.IssueBook(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		err = transitionCopyStatus(ctx, q, CopyTransition{
+			CopyID:    arg.BookCopyID,
+			To:        postgres.BookStatusIssued,
+			Reason:    reason,
+			IssueID:   &issue.ID,
+			ChangedBy: arg.LibrarianID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return emitLoanWebhook(ctx, q, WebhookLoanIssued, issue.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return issue, nil
+}
+
+// ReturnBookCopy закрывает выдачу, возвращает экземпляр в статус «доступен» и публикует loan.returned в одной транзакции
+func (r *LibraryRepository) ReturnBookCopy(ctx context.Context, copyID uuid.UUID, librarianID *uuid.UUID, reason string) (*postgres.ReturnBookRow, error) {
+	var returned *postgres.ReturnBookRow
+	err := r.inTx(ctx, func(q *postgres.Queries) error {
+		var err error
+		returned, err = q.ReturnBook(ctx, copyID)
+		if err != nil {
+			return err
+		}
+
+		err = transitionCopyStatus(ctx, q, CopyTransition{
+			CopyID:    copyID,
+			To:        postgres.BookStatusAvailable,
+			Reason:    reason,
+			IssueID:   &returned.ID,
+			ChangedBy: librarianID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return emitLoanWebhook(ctx, q, WebhookLoanReturned, returned.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return returned, nil
+}
+
+// transitionCopyStatus выполняет переход внутри уже открытой транзакции
+func transitionCopyStatus(ctx context.Context, q *postgres.Queries, t CopyTransition) error {
+	current, err := q.LockBookCopyStatus(ctx, t.CopyID)
+	if err != nil {
+		return err
+	}
+
+	// Экземпляры без статуса считаются доступными, как и значение по умолчанию в схеме
+	from := postgres.BookStatusAvailable
+	if current.Valid {
+		from = current.BookStatus
+	}
+
+	if (from == postgres.BookStatusIssued || t.To == postgres.BookStatusIssued) && t.IssueID == nil {
+		return ErrCirculationOnly
+	}
+	if !CanTransitionCopy(from, t.To) {
+		return fmt.Errorf("%w: %s → %s", ErrInvalidCopyTransition, from, t.To)
+	}
+	if t.HoldReader != nil && t.To != postgres.BookStatusReserved {
+		return fmt.Errorf("%w: читатель указывается только для статуса %s", ErrInvalidCopyTransition, postgres.BookStatusReserved)
+	}
+
+	// Читатель брони хранится, пока экземпляр зарезервирован, и сбрасывается при любом другом переходе
+	err = q.UpdateBookCopyStatus(ctx, postgres.UpdateBookCopyStatusParams{
+		CopyID:       t.CopyID,
+		Status:       postgres.NullBookStatus{BookStatus: t.To, Valid: true},
+		HoldReaderID: t.HoldReader,
+	})
+	if err != nil {
+		return fmt.Errorf("не удалось обновить статус экземпляра: %w", err)
+	}
+
+	err = q.CreateCopyStatusHistory(ctx, postgres.CreateCopyStatusHistoryParams{
+		CopyID:     t.CopyID,
+		FromStatus: current,
+		ToStatus:   t.To,
+		Reason:     t.Reason,
+		IssueID:    t.IssueID,
+		ChangedBy:  t.ChangedBy,
+	})
+	if err != nil {
+		return fmt.Errorf("не удалось записать историю статуса: %w", err)
+	}
+
+	return nil
+}
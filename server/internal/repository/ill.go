@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/hnnsly/library-console/internal/repository/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+// IllCopy экземпляр библиотеки-партнера, полученный по межбиблиотечному абонементу
+type IllCopy struct {
+	OwnerLibrary    string
+	ExternalItemID  string
+	Title           string
+	Authors         []string
+	Isbn            *string
+	Publisher       *string
+	PublicationYear *int
+}
+
+// ReceiveIllCopy находит или заводит в фонде экземпляр партнера и возвращает его шифр.
+// Книга ищется по ISBN, а если ее нет в каталоге — создается по описанию партнера
+func (r *LibraryRepository) ReceiveIllCopy(ctx context.Context, c IllCopy) (string, error) {
+	var copyCode string
+	err := r.inTx(ctx, func(q *postgres.Queries) error {
+		var err error
+		copyCode, err = q.GetIllCopyByExternalId(ctx, postgres.GetIllCopyByExternalIdParams{
+			OwnerLibrary:   &c.OwnerLibrary,
+			ExternalItemID: &c.ExternalItemID,
+		})
+		if err == nil || !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+
+		bookID, err := illBook(ctx, q, c)
+		if err != nil {
+			return err
+		}
+
+		code, err := r.nextCopyCode(ctx, q, nil)
+		if err != nil {
+			return err
+		}
+		location := "МБА: " + c.OwnerLibrary
+		copyCode, err = q.CreateIllCopy(ctx, postgres.CreateIllCopyParams{
+			BookID:         bookID,
+			CopyCode:       code,
+			LocationInfo:   &location,
+			OwnerLibrary:   &c.OwnerLibrary,
+			ExternalItemID: &c.ExternalItemID,
+		})
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return copyCode, nil
+}
+
+// illBook возвращает книгу каталога для экземпляра партнера
+func illBook(ctx context.Context, q *postgres.Queries, c IllCopy) (uuid.UUID, error) {
+	if c.Isbn != nil {
+		id, err := q.GetBookIdByIsbn(ctx, c.Isbn)
+		if err == nil || !errors.Is(err, pgx.ErrNoRows) {
+			return id, err
+		}
+	}
+
+	book, err := q.CreateBook(ctx, postgres.CreateBookParams{
+		Title:           c.Title,
+		Isbn:            c.Isbn,
+		PublicationYear: c.PublicationYear,
+		Publisher:       c.Publisher,
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+	for _, name := range c.Authors {
+		author, err := getOrCreateAuthor(ctx, q, name)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		err = q.AddBookAuthor(ctx, postgres.AddBookAuthorParams{BookID: book.ID, AuthorID: author.ID, Role: postgres.ContributorRoleAuthor})
+		if err != nil {
+			return uuid.Nil, err
+		}
+	}
+
+	return book.ID, nil
+}
+
+// IllReservation запрос партнера отложить экземпляр для его читателя
+type IllReservation struct {
+	SourceLibrary     string
+	ExternalRequestID string
+	ExternalUserID    *string
+	CopyID            uuid.UUID
+}
+
+// ReserveIllCopy переводит экземпляр в статус «зарезервирован» и записывает запрос партнера в одной транзакции
+func (r *LibraryRepository) ReserveIllCopy(ctx context.Context, res IllReservation) (*postgres.IllRequest, error) {
+	var request *postgres.IllRequest
+	err := r.inTx(ctx, func(q *postgres.Queries) error {
+		err := transitionCopyStatus(ctx, q, CopyTransition{
+			CopyID: res.CopyID,
+			To:     postgres.BookStatusReserved,
+			Reason: "Запрос МБА " + res.SourceLibrary + " " + res.ExternalRequestID,
+		})
+		if err != nil {
+			return err
+		}
+
+		request, err = q.CreateIllRequest(ctx, postgres.CreateIllRequestParams{
+			SourceLibrary:     res.SourceLibrary,
+			ExternalRequestID: res.ExternalRequestID,
+			ExternalUserID:    res.ExternalUserID,
+			BookCopyID:        res.CopyID,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
@@ -0,0 +1,342 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hnnsly/library-console/internal/repository/postgres"
+)
+
+var (
+	// ErrInventoryNotOpen инвентаризация уже завершена или отменена
+	ErrInventoryNotOpen = errors.New("инвентаризация не ведется")
+	// ErrInventoryNotClosed списывать ненайденные экземпляры можно только по завершенной инвентаризации
+	ErrInventoryNotClosed = errors.New("инвентаризация не завершена")
+)
+
+// inventoryLostReason причина перехода в историю статусов при списании по итогам инвентаризации
+const inventoryLostReason = "Не найден при инвентаризации зала"
+
+// shelfStatuses статусы экземпляров, которые должны стоять на полке своего зала;
+// совпадают с условием в запросах inventory.sql
+var shelfStatuses = []postgres.BookStatus{
+	postgres.BookStatusAvailable,
+	postgres.BookStatusReserved,
+	postgres.BookStatusDamaged,
+}
+
+// ScanOutcome итог сканирования шифра при инвентаризации
+type ScanOutcome string
+
+const (
+	// ScanFound экземпляр зала стоит на полке
+	ScanFound ScanOutcome = "found"
+	// ScanWrongHall экземпляр числится в другом зале или ни в каком
+	ScanWrongHall ScanOutcome = "wrong_hall"
+	// ScanUnexpectedStatus по статусу экземпляра на полке быть не должно: выдан, утерян, списан, в ремонте или в пути
+	ScanUnexpectedStatus ScanOutcome = "unexpected_status"
+	// ScanUnknown шифра нет в фонде
+	ScanUnknown ScanOutcome = "unknown"
+)
+
+// InventoryScan отсканированный шифр и его сверка с фондом
+type InventoryScan struct {
+	CopyCode string               `json:"copy_code"`
+	CopyID   *uuid.UUID           `json:"copy_id"`
+	Title    *string              `json:"title"`
+	HallID   *uuid.UUID           `json:"hall_id"`
+	HallName *string              `json:"hall_name"`
+	Status   *postgres.BookStatus `json:"status"`
+	OnLoan   bool                 `json:"on_loan"`
+	Outcome  ScanOutcome          `json:"outcome"`
+	// AlreadyScanned шифр уже был отсканирован в этой инвентаризации, повтор не записывается
+	AlreadyScanned bool       `json:"already_scanned"`
+	ScannedAt      *time.Time `json:"scanned_at,omitempty"`
+}
+
+// InventoryMissingCopy экземпляр, не найденный на полке. Для завершенной инвентаризации статус —
+// статус на момент завершения, для идущей — текущий
+type InventoryMissingCopy struct {
+	CopyID        uuid.UUID            `json:"copy_id"`
+	CopyCode      string               `json:"copy_code"`
+	Title         string               `json:"title"`
+	LocationInfo  *string              `json:"location_info"`
+	Status        postgres.BookStatus  `json:"status"`
+	CurrentStatus *postgres.BookStatus `json:"current_status"`
+	MarkedLostAt  *time.Time           `json:"marked_lost_at"`
+}
+
+// InventoryReport итоги инвентаризации зала
+type InventoryReport struct {
+	Session *postgres.GetInventorySessionByIdRow `json:"session"`
+	// Missing экземпляры зала, которых нет среди отсканированных; пока инвентаризация идет, список предварительный
+	Missing []InventoryMissingCopy `json:"missing"`
+	// Unexpected отсканированные экземпляры чужих залов, неизвестные шифры и экземпляры с неподходящим статусом
+	Unexpected []InventoryScan `json:"unexpected"`
+	// LoanMismatches экземпляры зала, чей статус расходится с выдачами
+	LoanMismatches []*postgres.GetInventoryLoanMismatchesRow `json:"loan_mismatches"`
+}
+
+// InventoryLostResult итог списания ненайденных экземпляров
+type InventoryLostResult struct {
+	Marked  []uuid.UUID            `json:"marked"`
+	Skipped []InventorySkippedCopy `json:"skipped"`
+}
+
+// InventorySkippedCopy экземпляр, который не удалось перевести в утерянные
+type InventorySkippedCopy struct {
+	CopyID uuid.UUID `json:"copy_id"`
+	Reason string    `json:"reason"`
+}
+
+func bookStatusPtr(status postgres.NullBookStatus) *postgres.BookStatus {
+	if !status.Valid {
+		return nil
+	}
+	return &status.BookStatus
+}
+
+// classifyScan сверяет отсканированный экземпляр с залом инвентаризации
+func classifyScan(hallID uuid.UUID, copyID, copyHallID *uuid.UUID, status postgres.NullBookStatus) ScanOutcome {
+	if copyID == nil {
+		return ScanUnknown
+	}
+	if copyHallID == nil || *copyHallID != hallID {
+		return ScanWrongHall
+	}
+	// Экземпляры без статуса считаются доступными, как и значение по умолчанию в схеме
+	current := postgres.BookStatusAvailable
+	if status.Valid {
+		current = status.BookStatus
+	}
+	for _, s := range shelfStatuses {
+		if s == current {
+			return ScanFound
+		}
+	}
+	return ScanUnexpectedStatus
+}
+
+// RecordInventoryScans записывает пачку отсканированных шифров и сразу сообщает, что найдено.
+// Повторно отсканированные шифры не меняют время первого сканирования
+func (r *LibraryRepository) RecordInventoryScans(ctx context.Context, sessionID uuid.UUID, codes []string, scannedBy *uuid.UUID) ([]InventoryScan, error) {
+	var scans []InventoryScan
+	err := r.inTx(ctx, func(q *postgres.Queries) error {
+		// Разделяемая блокировка не мешает параллельным сканерам, но не дает завершить инвентаризацию посреди пачки
+		session, err := q.ShareLockInventorySession(ctx, sessionID)
+		if err != nil {
+			return err
+		}
+		if session.Status != postgres.InventorySessionStatusOpen {
+			return ErrInventoryNotOpen
+		}
+
+		rows, err := q.RecordInventoryScans(ctx, postgres.RecordInventoryScansParams{
+			CopyCodes: codes,
+			SessionID: sessionID,
+			ScannedBy: scannedBy,
+		})
+		if err != nil {
+			return fmt.Errorf("запись сканирований: %w", err)
+		}
+
+		scans = make([]InventoryScan, 0, len(rows))
+		for _, row := range rows {
+			scans = append(scans, InventoryScan{
+				CopyCode:       row.CopyCode,
+				CopyID:         row.CopyID,
+				Title:          row.Title,
+				HallID:         row.HallID,
+				HallName:       row.HallName,
+				Status:         bookStatusPtr(row.Status),
+				OnLoan:         row.OnLoan,
+				Outcome:        classifyScan(session.HallID, row.CopyID, row.HallID, row.Status),
+				AlreadyScanned: row.AlreadyScanned,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return scans, nil
+}
+
+// CloseInventory завершает инвентаризацию и фиксирует список ненайденных экземпляров,
+// чтобы отчет не менялся от последующих выдач и возвратов
+func (r *LibraryRepository) CloseInventory(ctx context.Context, sessionID uuid.UUID, closedBy *uuid.UUID) (*postgres.InventorySession, error) {
+	return r.finishInventory(ctx, sessionID, postgres.InventorySessionStatusClosed, closedBy)
+}
+
+// CancelInventory отменяет инвентаризацию без отчета о ненайденных
+func (r *LibraryRepository) CancelInventory(ctx context.Context, sessionID uuid.UUID, closedBy *uuid.UUID) (*postgres.InventorySession, error) {
+	return r.finishInventory(ctx, sessionID, postgres.InventorySessionStatusCancelled, closedBy)
+}
+
+func (r *LibraryRepository) finishInventory(ctx context.Context, sessionID uuid.UUID, status postgres.InventorySessionStatus, closedBy *uuid.UUID) (*postgres.InventorySession, error) {
+	var finished *postgres.InventorySession
+	err := r.inTx(ctx, func(q *postgres.Queries) error {
+		session, err := q.LockInventorySession(ctx, sessionID)
+		if err != nil {
+			return err
+		}
+		if session.Status != postgres.InventorySessionStatusOpen {
+			return ErrInventoryNotOpen
+		}
+
+		if status == postgres.InventorySessionStatusClosed {
+			if _, err := q.SnapshotInventoryMissing(ctx, sessionID); err != nil {
+				return fmt.Errorf("список ненайденных экземпляров: %w", err)
+			}
+		}
+
+		finished, err = q.FinishInventorySession(ctx, postgres.FinishInventorySessionParams{
+			Status:   status,
+			ClosedBy: closedBy,
+			ID:       sessionID,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return finished, nil
+}
+
+// InventoryReport собирает отчет: ненайденные, неожиданные на полках и расходящиеся с выдачами экземпляры
+func (r *LibraryRepository) InventoryReport(ctx context.Context, sessionID uuid.UUID) (*InventoryReport, error) {
+	session, err := r.GetInventorySessionById(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	report := &InventoryReport{Session: session}
+
+	if session.Status == postgres.InventorySessionStatusClosed {
+		missing, err := r.GetInventoryMissing(ctx, sessionID)
+		if err != nil {
+			return nil, fmt.Errorf("ненайденные экземпляры: %w", err)
+		}
+		report.Missing = make([]InventoryMissingCopy, 0, len(missing))
+		for _, m := range missing {
+			report.Missing = append(report.Missing, InventoryMissingCopy{
+				CopyID:        m.CopyID,
+				CopyCode:      m.CopyCode,
+				Title:         m.Title,
+				LocationInfo:  m.LocationInfo,
+				Status:        m.StatusAtClose,
+				CurrentStatus: bookStatusPtr(m.CurrentStatus),
+				MarkedLostAt:  m.MarkedLostAt,
+			})
+		}
+	} else {
+		unscanned, err := r.GetInventoryUnscanned(ctx, sessionID)
+		if err != nil {
+			return nil, fmt.Errorf("неотсканированные экземпляры: %w", err)
+		}
+		report.Missing = make([]InventoryMissingCopy, 0, len(unscanned))
+		for _, u := range unscanned {
+			status := postgres.BookStatusAvailable
+			if u.Status.Valid {
+				status = u.Status.BookStatus
+			}
+			report.Missing = append(report.Missing, InventoryMissingCopy{
+				CopyID:        u.CopyID,
+				CopyCode:      u.CopyCode,
+				Title:         u.Title,
+				LocationInfo:  u.LocationInfo,
+				Status:        status,
+				CurrentStatus: bookStatusPtr(u.Status),
+			})
+		}
+	}
+
+	unexpected, err := r.GetInventoryUnexpected(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("неожиданные экземпляры: %w", err)
+	}
+	report.Unexpected = make([]InventoryScan, 0, len(unexpected))
+	for _, u := range unexpected {
+		report.Unexpected = append(report.Unexpected, InventoryScan{
+			CopyCode:  u.CopyCode,
+			CopyID:    u.CopyID,
+			Title:     u.Title,
+			HallID:    u.HallID,
+			HallName:  u.HallName,
+			Status:    bookStatusPtr(u.Status),
+			OnLoan:    u.OnLoan,
+			Outcome:   classifyScan(session.HallID, u.CopyID, u.HallID, u.Status),
+			ScannedAt: &u.ScannedAt,
+		})
+	}
+
+	report.LoanMismatches, err = r.GetInventoryLoanMismatches(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("расхождения с выдачами: %w", err)
+	}
+
+	return report, nil
+}
+
+// MarkInventoryMissingLost переводит ненайденные экземпляры завершенной инвентаризации в утерянные.
+// Пустой copyIDs означает все еще не списанные. Экземпляры, чей статус с тех пор изменился так,
+// что переход в утерянные не разрешен, пропускаются с причиной
+func (r *LibraryRepository) MarkInventoryMissingLost(ctx context.Context, sessionID uuid.UUID, copyIDs []uuid.UUID, changedBy *uuid.UUID) (*InventoryLostResult, error) {
+	// nil передался бы как NULL, и cardinality не отличила бы его от непустого списка
+	if copyIDs == nil {
+		copyIDs = []uuid.UUID{}
+	}
+	result := &InventoryLostResult{Marked: []uuid.UUID{}, Skipped: []InventorySkippedCopy{}}
+	err := r.inTx(ctx, func(q *postgres.Queries) error {
+		session, err := q.LockInventorySession(ctx, sessionID)
+		if err != nil {
+			return err
+		}
+		if session.Status != postgres.InventorySessionStatusClosed {
+			return ErrInventoryNotClosed
+		}
+
+		ids, err := q.GetUnmarkedInventoryMissing(ctx, postgres.GetUnmarkedInventoryMissingParams{
+			SessionID: sessionID,
+			CopyIds:   copyIDs,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			err := transitionCopyStatus(ctx, q, CopyTransition{
+				CopyID:    id,
+				To:        postgres.BookStatusLost,
+				Reason:    inventoryLostReason,
+				ChangedBy: changedBy,
+			})
+			if errors.Is(err, ErrInvalidCopyTransition) || errors.Is(err, ErrCirculationOnly) {
+				result.Skipped = append(result.Skipped, InventorySkippedCopy{CopyID: id, Reason: err.Error()})
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			err = q.MarkInventoryMissingLost(ctx, postgres.MarkInventoryMissingLostParams{
+				SessionID: sessionID,
+				CopyID:    id,
+			})
+			if err != nil {
+				return err
+			}
+			result.Marked = append(result.Marked, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
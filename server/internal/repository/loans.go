@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hnnsly/library-console/internal/repository/postgres"
+)
+
+var (
+	// ErrLoanReturned выдача уже закрыта
+	ErrLoanReturned = errors.New("книга уже возвращена")
+	// ErrLoanOverdue просроченную выдачу продлевает только библиотекарь
+	ErrLoanOverdue = errors.New("просроченную выдачу нельзя продлить")
+	// ErrRenewalLimit исчерпано число продлений для категории читателя
+	ErrRenewalLimit = errors.New("достигнут лимит продлений")
+	// ErrRenewalPastMembership новый срок возврата выходит за срок членства
+	ErrRenewalPastMembership = errors.New("срок продления выходит за срок членства")
+)
+
+// LoanRenewal параметры продления выдачи читателем
+type LoanRenewal struct {
+	ReaderID            uuid.UUID
+	IssueID             uuid.UUID
+	LoanDays            int
+	MaxRenewals         int
+	MembershipExpiresAt *time.Time
+}
+
+// RenewLoan продлевает выдачу на срок категории читателя, не выходя за срок его членства
+func (r *LibraryRepository) RenewLoan(ctx context.Context, renewal LoanRenewal) (*postgres.RenewBookIssueRow, error) {
+	var renewed *postgres.RenewBookIssueRow
+	err := r.inTx(ctx, func(q *postgres.Queries) error {
+		issue, err := q.LockReaderBookIssue(ctx, postgres.LockReaderBookIssueParams{
+			ID:       renewal.IssueID,
+			ReaderID: renewal.ReaderID,
+		})
+		if err != nil {
+			return err
+		}
+
+		today := time.Now().Format(time.DateOnly)
+		switch {
+		case issue.ReturnDate != nil:
+			return ErrLoanReturned
+		case issue.DueDate.Format(time.DateOnly) < today:
+			return ErrLoanOverdue
+		case issue.RenewalCount >= renewal.MaxRenewals:
+			return ErrRenewalLimit
+		}
+
+		dueDate := issue.DueDate.AddDate(0, 0, renewal.LoanDays)
+		if expires := renewal.MembershipExpiresAt; expires != nil && dueDate.After(*expires) {
+			if !expires.After(issue.DueDate) {
+				return ErrRenewalPastMembership
+			}
+			dueDate = *expires
+		}
+
+		renewed, err = q.RenewBookIssue(ctx, postgres.RenewBookIssueParams{
+			DueDate: dueDate,
+			ID:      issue.ID,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return renewed, nil
+}
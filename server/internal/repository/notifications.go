@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hnnsly/library-console/internal/repository/postgres"
+)
+
+// NotificationPreferences язык и канал уведомлений и виды, от которых читатель отказался
+type NotificationPreferences struct {
+	Language string `json:"language"`
+	// Channel nil - email, а при его отсутствии SMS
+	Channel *postgres.NotificationChannel `json:"channel"`
+	OptOuts []postgres.NotificationKind   `json:"opt_outs"`
+}
+
+// ScheduleLoanReminders ставит в очередь напоминания о скором сроке возврата и о просрочке
+// только по подключенным каналам. Повторный вызов не создает дублей: у каждого напоминания свой ключ события
+func (r *LibraryRepository) ScheduleLoanReminders(ctx context.Context, dueSoonDays, overdueRepeatDays int, channels []string) (int64, error) {
+	dueSoon, err := r.EnqueueDueSoonNotifications(ctx, postgres.EnqueueDueSoonNotificationsParams{
+		Days:     dueSoonDays,
+		Channels: channels,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("напоминания о сроке: %w", err)
+	}
+	overdue, err := r.EnqueueOverdueNotifications(ctx, postgres.EnqueueOverdueNotificationsParams{
+		RepeatDays: overdueRepeatDays,
+		Channels:   channels,
+	})
+	if err != nil {
+		return dueSoon, fmt.Errorf("напоминания о просрочке: %w", err)
+	}
+	return dueSoon + overdue, nil
+}
+
+// GetNotificationPreferences возвращает настройки уведомлений читателя
+func (r *LibraryRepository) GetNotificationPreferences(ctx context.Context, readerID uuid.UUID) (*NotificationPreferences, error) {
+	settings, err := r.GetReaderNotificationSettings(ctx, readerID)
+	if err != nil {
+		return nil, err
+	}
+	optOuts, err := r.GetReaderNotificationOptOuts(ctx, readerID)
+	if err != nil {
+		return nil, err
+	}
+
+	prefs := &NotificationPreferences{Language: settings.Language, OptOuts: optOuts}
+	if settings.PreferredChannel.Valid {
+		prefs.Channel = &settings.PreferredChannel.NotificationChannel
+	}
+	return prefs, nil
+}
+
+// SetNotificationPreferences заменяет настройки уведомлений читателя целиком
+func (r *LibraryRepository) SetNotificationPreferences(ctx context.Context, readerID uuid.UUID, prefs NotificationPreferences) error {
+	var channel postgres.NullNotificationChannel
+	if prefs.Channel != nil {
+		channel = postgres.NullNotificationChannel{NotificationChannel: *prefs.Channel, Valid: true}
+	}
+
+	return r.inTx(ctx, func(q *postgres.Queries) error {
+		if err := q.SetReaderNotificationSettings(ctx, postgres.SetReaderNotificationSettingsParams{
+			Language:         prefs.Language,
+			PreferredChannel: channel,
+			ID:               readerID,
+		}); err != nil {
+			return err
+		}
+		if err := q.DeleteReaderNotificationOptOuts(ctx, readerID); err != nil {
+			return err
+		}
+		for _, kind := range prefs.OptOuts {
+			if err := q.CreateNotificationOptOut(ctx, postgres.CreateNotificationOptOutParams{
+				ReaderID: readerID,
+				Kind:     kind,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
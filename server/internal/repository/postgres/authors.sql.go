@@ -11,45 +11,256 @@ import (
 	"github.com/google/uuid"
 )
 
+const copyAuthorBookLinks = `-- name: CopyAuthorBookLinks :execrows
+INSERT INTO book_authors (book_id, author_id, role)
+SELECT book_id, $1, role
+FROM book_authors
+WHERE author_id = $2
+ON CONFLICT DO NOTHING
+`
+
+type CopyAuthorBookLinksParams struct {
+	TargetID uuid.UUID `json:"target_id"`
+	SourceID uuid.UUID `json:"source_id"`
+}
+
+func (q *Queries) CopyAuthorBookLinks(ctx context.Context, arg CopyAuthorBookLinksParams) (int64, error) {
+	result, err := q.db.Exec(ctx, copyAuthorBookLinks, arg.TargetID, arg.SourceID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const copyAuthorNameVariants = `-- name: CopyAuthorNameVariants :exec
+INSERT INTO author_name_variants (author_id, name, kind)
+SELECT $1, v.name, v.kind
+FROM (
+    SELECT name, kind FROM author_name_variants WHERE author_id = $2
+    UNION ALL
+    -- основная форма дубля остается вариантом имени
+    SELECT full_name, 'variant' FROM authors WHERE id = $2
+) v
+WHERE normalize_person_name(v.name) <> (SELECT normalize_person_name(full_name) FROM authors WHERE id = $1)
+ON CONFLICT DO NOTHING
+`
+
+type CopyAuthorNameVariantsParams struct {
+	TargetID uuid.UUID `json:"target_id"`
+	SourceID uuid.UUID `json:"source_id"`
+}
+
+func (q *Queries) CopyAuthorNameVariants(ctx context.Context, arg CopyAuthorNameVariantsParams) error {
+	_, err := q.db.Exec(ctx, copyAuthorNameVariants, arg.TargetID, arg.SourceID)
+	return err
+}
+
 const createAuthor = `-- name: CreateAuthor :one
-INSERT INTO authors (full_name)
-VALUES ($1)
-RETURNING id, full_name
+INSERT INTO authors (full_name, sort_name, birth_year, death_year)
+VALUES ($1, $2, $3, $4)
+RETURNING id, full_name, sort_name, birth_year, death_year, created_at
 `
 
-type CreateAuthorRow struct {
-	ID       uuid.UUID `json:"id"`
-	FullName string    `json:"full_name"`
+type CreateAuthorParams struct {
+	FullName  string  `json:"full_name"`
+	SortName  *string `json:"sort_name"`
+	BirthYear *int    `json:"birth_year"`
+	DeathYear *int    `json:"death_year"`
 }
 
-func (q *Queries) CreateAuthor(ctx context.Context, fullName string) (*CreateAuthorRow, error) {
-	row := q.db.QueryRow(ctx, createAuthor, fullName)
-	var i CreateAuthorRow
-	err := row.Scan(&i.ID, &i.FullName)
+func (q *Queries) CreateAuthor(ctx context.Context, arg CreateAuthorParams) (*Author, error) {
+	row := q.db.QueryRow(ctx, createAuthor,
+		arg.FullName,
+		arg.SortName,
+		arg.BirthYear,
+		arg.DeathYear,
+	)
+	var i Author
+	err := row.Scan(
+		&i.ID,
+		&i.FullName,
+		&i.SortName,
+		&i.BirthYear,
+		&i.DeathYear,
+		&i.CreatedAt,
+	)
 	return &i, err
 }
 
-const getAllAuthors = `-- name: GetAllAuthors :many
-SELECT id, full_name
-FROM authors
-ORDER BY full_name
+const deleteAuthor = `-- name: DeleteAuthor :execrows
+DELETE FROM authors a
+WHERE a.id = $1
+  AND NOT EXISTS (SELECT 1 FROM book_authors ba WHERE ba.author_id = a.id)
 `
 
-type GetAllAuthorsRow struct {
-	ID       uuid.UUID `json:"id"`
-	FullName string    `json:"full_name"`
+func (q *Queries) DeleteAuthor(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteAuthor, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteMergedAuthor = `-- name: DeleteMergedAuthor :exec
+DELETE FROM authors
+WHERE id = $1
+`
+
+func (q *Queries) DeleteMergedAuthor(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteMergedAuthor, id)
+	return err
+}
+
+const fillAuthorAuthorityData = `-- name: FillAuthorAuthorityData :one
+UPDATE authors t
+SET sort_name = COALESCE(t.sort_name, s.sort_name),
+    birth_year = COALESCE(t.birth_year, s.birth_year),
+    death_year = COALESCE(t.death_year, s.death_year)
+FROM authors s
+WHERE t.id = $1 AND s.id = $2
+RETURNING t.id, t.full_name, t.sort_name, t.birth_year, t.death_year, t.created_at
+`
+
+type FillAuthorAuthorityDataParams struct {
+	TargetID uuid.UUID `json:"target_id"`
+	SourceID uuid.UUID `json:"source_id"`
+}
+
+func (q *Queries) FillAuthorAuthorityData(ctx context.Context, arg FillAuthorAuthorityDataParams) (*Author, error) {
+	row := q.db.QueryRow(ctx, fillAuthorAuthorityData, arg.TargetID, arg.SourceID)
+	var i Author
+	err := row.Scan(
+		&i.ID,
+		&i.FullName,
+		&i.SortName,
+		&i.BirthYear,
+		&i.DeathYear,
+		&i.CreatedAt,
+	)
+	return &i, err
+}
+
+const findDuplicateAuthors = `-- name: FindDuplicateAuthors :many
+WITH forms AS (
+    -- основная форма и все варианты имени каждого автора
+    SELECT id as author_id, full_name as name FROM authors
+    UNION
+    SELECT author_id, name FROM author_name_variants
+), words AS (
+    SELECT DISTINCT f.author_id, w.word
+    FROM forms f, unnest(person_name_words(f.name)) as w(word)
+    WHERE length(w.word) >= 3
+), pairs AS (
+    SELECT DISTINCT a.author_id, b.author_id as candidate_id
+    FROM words a
+    JOIN words b ON b.word = a.word AND b.author_id <> a.author_id
+    WHERE ($1::uuid IS NULL AND a.author_id < b.author_id) OR a.author_id = $1::uuid
+), scored AS (
+    -- совпадающие инициалы («Толстой Л.Н.» и «Лев Николаевич Толстой») поднимают оценку выше половины
+    SELECT p.author_id, p.candidate_id,
+           MAX(CASE
+               WHEN person_name_initials(fa.name) <@ person_name_initials(fb.name)
+                 OR person_name_initials(fb.name) <@ person_name_initials(fa.name)
+               THEN 0.5 + 0.5 * similarity(normalize_person_name(fa.name), normalize_person_name(fb.name))
+               ELSE 0.5 * similarity(normalize_person_name(fa.name), normalize_person_name(fb.name))
+           END)::float8 as score
+    FROM pairs p
+    JOIN forms fa ON fa.author_id = p.author_id
+    JOIN forms fb ON fb.author_id = p.candidate_id
+    GROUP BY p.author_id, p.candidate_id
+)
+SELECT s.author_id, a.full_name, a.birth_year, a.death_year,
+       (SELECT COUNT(DISTINCT ba.book_id) FROM book_authors ba WHERE ba.author_id = s.author_id) as book_count,
+       s.candidate_id, b.full_name as candidate_full_name,
+       b.birth_year as candidate_birth_year, b.death_year as candidate_death_year,
+       (SELECT COUNT(DISTINCT ba.book_id) FROM book_authors ba WHERE ba.author_id = s.candidate_id) as candidate_book_count,
+       s.score
+FROM scored s
+JOIN authors a ON a.id = s.author_id
+JOIN authors b ON b.id = s.candidate_id
+WHERE s.score >= $2::float8
+  -- разные годы жизни означают разных людей
+  AND (a.birth_year IS NULL OR b.birth_year IS NULL OR a.birth_year = b.birth_year)
+  AND (a.death_year IS NULL OR b.death_year IS NULL OR a.death_year = b.death_year)
+ORDER BY s.score DESC, a.full_name
+LIMIT $3
+`
+
+type FindDuplicateAuthorsParams struct {
+	AuthorID   *uuid.UUID `json:"author_id"`
+	MinScore   float64    `json:"min_score"`
+	LimitCount int32      `json:"limit_count"`
+}
+
+type FindDuplicateAuthorsRow struct {
+	AuthorID           uuid.UUID `json:"author_id"`
+	FullName           string    `json:"full_name"`
+	BirthYear          *int      `json:"birth_year"`
+	DeathYear          *int      `json:"death_year"`
+	BookCount          int64     `json:"book_count"`
+	CandidateID        uuid.UUID `json:"candidate_id"`
+	CandidateFullName  string    `json:"candidate_full_name"`
+	CandidateBirthYear *int      `json:"candidate_birth_year"`
+	CandidateDeathYear *int      `json:"candidate_death_year"`
+	CandidateBookCount int64     `json:"candidate_book_count"`
+	Score              float64   `json:"score"`
+}
+
+func (q *Queries) FindDuplicateAuthors(ctx context.Context, arg FindDuplicateAuthorsParams) ([]*FindDuplicateAuthorsRow, error) {
+	rows, err := q.db.Query(ctx, findDuplicateAuthors, arg.AuthorID, arg.MinScore, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*FindDuplicateAuthorsRow{}
+	for rows.Next() {
+		var i FindDuplicateAuthorsRow
+		if err := rows.Scan(
+			&i.AuthorID,
+			&i.FullName,
+			&i.BirthYear,
+			&i.DeathYear,
+			&i.BookCount,
+			&i.CandidateID,
+			&i.CandidateFullName,
+			&i.CandidateBirthYear,
+			&i.CandidateDeathYear,
+			&i.CandidateBookCount,
+			&i.Score,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-func (q *Queries) GetAllAuthors(ctx context.Context) ([]*GetAllAuthorsRow, error) {
+const getAllAuthors = `-- name: GetAllAuthors :many
+SELECT id, full_name, sort_name, birth_year, death_year, created_at
+FROM authors
+ORDER BY COALESCE(sort_name, full_name)
+`
+
+func (q *Queries) GetAllAuthors(ctx context.Context) ([]*Author, error) {
 	rows, err := q.db.Query(ctx, getAllAuthors)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []*GetAllAuthorsRow{}
+	items := []*Author{}
 	for rows.Next() {
-		var i GetAllAuthorsRow
-		if err := rows.Scan(&i.ID, &i.FullName); err != nil {
+		var i Author
+		if err := rows.Scan(
+			&i.ID,
+			&i.FullName,
+			&i.SortName,
+			&i.BirthYear,
+			&i.DeathYear,
+			&i.CreatedAt,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, &i)
@@ -61,28 +272,50 @@ func (q *Queries) GetAllAuthors(ctx context.Context) ([]*GetAllAuthorsRow, error
 }
 
 const getAuthorById = `-- name: GetAuthorById :one
-SELECT id, full_name
+SELECT id, full_name, sort_name, birth_year, death_year, created_at
 FROM authors
 WHERE id = $1
 `
 
-type GetAuthorByIdRow struct {
-	ID       uuid.UUID `json:"id"`
-	FullName string    `json:"full_name"`
-}
-
-func (q *Queries) GetAuthorById(ctx context.Context, id uuid.UUID) (*GetAuthorByIdRow, error) {
+func (q *Queries) GetAuthorById(ctx context.Context, id uuid.UUID) (*Author, error) {
 	row := q.db.QueryRow(ctx, getAuthorById, id)
-	var i GetAuthorByIdRow
-	err := row.Scan(&i.ID, &i.FullName)
+	var i Author
+	err := row.Scan(
+		&i.ID,
+		&i.FullName,
+		&i.SortName,
+		&i.BirthYear,
+		&i.DeathYear,
+		&i.CreatedAt,
+	)
 	return &i, err
 }
 
 const getOrCreateAuthor = `-- name: GetOrCreateAuthor :one
-INSERT INTO authors (full_name)
-VALUES ($1)
-ON CONFLICT (full_name) DO UPDATE SET full_name = EXCLUDED.full_name
-RETURNING id, full_name
+WITH matched AS (
+    -- сначала совпадение основной формы имени, затем любого из вариантов
+    SELECT m.id, m.full_name
+    FROM (
+        SELECT a.id, a.full_name, 0 as rank, a.created_at
+        FROM authors a
+        WHERE normalize_person_name(a.full_name) = normalize_person_name($1)
+        UNION ALL
+        SELECT a.id, a.full_name, 1 as rank, a.created_at
+        FROM author_name_variants v
+        JOIN authors a ON a.id = v.author_id
+        WHERE normalize_person_name(v.name) = normalize_person_name($1)
+    ) m
+    ORDER BY m.rank, m.created_at
+    LIMIT 1
+), created AS (
+    INSERT INTO authors (full_name)
+    SELECT btrim($1)
+    WHERE NOT EXISTS (SELECT 1 FROM matched)
+    RETURNING id, full_name
+)
+SELECT id, full_name FROM matched
+UNION ALL
+SELECT id, full_name FROM created
 `
 
 type GetOrCreateAuthorRow struct {
@@ -97,28 +330,66 @@ func (q *Queries) GetOrCreateAuthor(ctx context.Context, fullName string) (*GetO
 	return &i, err
 }
 
-const searchAuthors = `-- name: SearchAuthors :many
-SELECT id, full_name
+const lockAuthor = `-- name: LockAuthor :one
+SELECT id, full_name, sort_name, birth_year, death_year, created_at
 FROM authors
-WHERE full_name ILIKE '%' || $1 || '%'
-ORDER BY full_name
+WHERE id = $1
+FOR UPDATE
 `
 
-type SearchAuthorsRow struct {
-	ID       uuid.UUID `json:"id"`
-	FullName string    `json:"full_name"`
+func (q *Queries) LockAuthor(ctx context.Context, id uuid.UUID) (*Author, error) {
+	row := q.db.QueryRow(ctx, lockAuthor, id)
+	var i Author
+	err := row.Scan(
+		&i.ID,
+		&i.FullName,
+		&i.SortName,
+		&i.BirthYear,
+		&i.DeathYear,
+		&i.CreatedAt,
+	)
+	return &i, err
+}
+
+const lockAuthorName = `-- name: LockAuthorName :exec
+-- Поиск и создание автора с одним именем выполняются по очереди до конца транзакции.
+-- Уникальный индекс по имени не подходит: однофамильцы с одинаковым именем допустимы
+SELECT pg_advisory_xact_lock(hashtext('author:' || normalize_person_name($1)))
+`
+
+func (q *Queries) LockAuthorName(ctx context.Context, fullName string) error {
+	_, err := q.db.Exec(ctx, lockAuthorName, fullName)
+	return err
 }
 
-func (q *Queries) SearchAuthors(ctx context.Context, searchTerm *string) ([]*SearchAuthorsRow, error) {
+const searchAuthors = `-- name: SearchAuthors :many
+SELECT a.id, a.full_name, a.sort_name, a.birth_year, a.death_year, a.created_at
+FROM authors a
+WHERE a.full_name ILIKE '%' || $1 || '%'
+   OR EXISTS (
+       SELECT 1 FROM author_name_variants v
+       WHERE v.author_id = a.id AND v.name ILIKE '%' || $1 || '%'
+   )
+ORDER BY COALESCE(a.sort_name, a.full_name)
+`
+
+func (q *Queries) SearchAuthors(ctx context.Context, searchTerm *string) ([]*Author, error) {
 	rows, err := q.db.Query(ctx, searchAuthors, searchTerm)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []*SearchAuthorsRow{}
+	items := []*Author{}
 	for rows.Next() {
-		var i SearchAuthorsRow
-		if err := rows.Scan(&i.ID, &i.FullName); err != nil {
+		var i Author
+		if err := rows.Scan(
+			&i.ID,
+			&i.FullName,
+			&i.SortName,
+			&i.BirthYear,
+			&i.DeathYear,
+			&i.CreatedAt,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, &i)
@@ -128,3 +399,41 @@ func (q *Queries) SearchAuthors(ctx context.Context, searchTerm *string) ([]*Sea
 	}
 	return items, nil
 }
+
+const updateAuthor = `-- name: UpdateAuthor :one
+UPDATE authors
+SET full_name = $1,
+    sort_name = $2,
+    birth_year = $3,
+    death_year = $4
+WHERE id = $5
+RETURNING id, full_name, sort_name, birth_year, death_year, created_at
+`
+
+type UpdateAuthorParams struct {
+	FullName  string    `json:"full_name"`
+	SortName  *string   `json:"sort_name"`
+	BirthYear *int      `json:"birth_year"`
+	DeathYear *int      `json:"death_year"`
+	ID        uuid.UUID `json:"id"`
+}
+
+func (q *Queries) UpdateAuthor(ctx context.Context, arg UpdateAuthorParams) (*Author, error) {
+	row := q.db.QueryRow(ctx, updateAuthor,
+		arg.FullName,
+		arg.SortName,
+		arg.BirthYear,
+		arg.DeathYear,
+		arg.ID,
+	)
+	var i Author
+	err := row.Scan(
+		&i.ID,
+		&i.FullName,
+		&i.SortName,
+		&i.BirthYear,
+		&i.DeathYear,
+		&i.CreatedAt,
+	)
+	return &i, err
+}
@@ -12,33 +12,35 @@ import (
 )
 
 const addBookAuthor = `-- name: AddBookAuthor :exec
-INSERT INTO book_authors (book_id, author_id)
-VALUES ($1, $2)
+INSERT INTO book_authors (book_id, author_id, role)
+VALUES ($1, $2, $3)
 `
 
 type AddBookAuthorParams struct {
-	BookID   uuid.UUID `json:"book_id"`
-	AuthorID uuid.UUID `json:"author_id"`
+	BookID   uuid.UUID       `json:"book_id"`
+	AuthorID uuid.UUID       `json:"author_id"`
+	Role     ContributorRole `json:"role"`
 }
 
 func (q *Queries) AddBookAuthor(ctx context.Context, arg AddBookAuthorParams) error {
-	_, err := q.db.Exec(ctx, addBookAuthor, arg.BookID, arg.AuthorID)
+	_, err := q.db.Exec(ctx, addBookAuthor, arg.BookID, arg.AuthorID, arg.Role)
 	return err
 }
 
 const getAuthorBooks = `-- name: GetAuthorBooks :many
-SELECT b.id, b.title, b.isbn, b.publication_year
+SELECT b.id, b.title, b.isbn, b.publication_year, ba.role
 FROM books b
 JOIN book_authors ba ON b.id = ba.book_id
 WHERE ba.author_id = $1
-ORDER BY b.title
+ORDER BY b.title, ba.role
 `
 
 type GetAuthorBooksRow struct {
-	ID              uuid.UUID `json:"id"`
-	Title           string    `json:"title"`
-	Isbn            *string   `json:"isbn"`
-	PublicationYear *int      `json:"publication_year"`
+	ID              uuid.UUID       `json:"id"`
+	Title           string          `json:"title"`
+	Isbn            *string         `json:"isbn"`
+	PublicationYear *int            `json:"publication_year"`
+	Role            ContributorRole `json:"role"`
 }
 
 func (q *Queries) GetAuthorBooks(ctx context.Context, authorID uuid.UUID) ([]*GetAuthorBooksRow, error) {
@@ -55,6 +57,7 @@ func (q *Queries) GetAuthorBooks(ctx context.Context, authorID uuid.UUID) ([]*Ge
 			&i.Title,
 			&i.Isbn,
 			&i.PublicationYear,
+			&i.Role,
 		); err != nil {
 			return nil, err
 		}
@@ -67,16 +70,17 @@ func (q *Queries) GetAuthorBooks(ctx context.Context, authorID uuid.UUID) ([]*Ge
 }
 
 const getBookAuthors = `-- name: GetBookAuthors :many
-SELECT a.id, a.full_name
+SELECT a.id, a.full_name, ba.role
 FROM authors a
 JOIN book_authors ba ON a.id = ba.author_id
 WHERE ba.book_id = $1
-ORDER BY a.full_name
+ORDER BY ba.role, a.full_name
 `
 
 type GetBookAuthorsRow struct {
-	ID       uuid.UUID `json:"id"`
-	FullName string    `json:"full_name"`
+	ID       uuid.UUID       `json:"id"`
+	FullName string          `json:"full_name"`
+	Role     ContributorRole `json:"role"`
 }
 
 func (q *Queries) GetBookAuthors(ctx context.Context, bookID uuid.UUID) ([]*GetBookAuthorsRow, error) {
@@ -88,7 +92,47 @@ func (q *Queries) GetBookAuthors(ctx context.Context, bookID uuid.UUID) ([]*GetB
 	items := []*GetBookAuthorsRow{}
 	for rows.Next() {
 		var i GetBookAuthorsRow
-		if err := rows.Scan(&i.ID, &i.FullName); err != nil {
+		if err := rows.Scan(&i.ID, &i.FullName, &i.Role); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getBooksContributors = `-- name: GetBooksContributors :many
+SELECT ba.book_id, a.id, a.full_name, ba.role
+FROM book_authors ba
+JOIN authors a ON a.id = ba.author_id
+WHERE ba.book_id = ANY($1::uuid[])
+ORDER BY ba.book_id, ba.role, a.full_name
+`
+
+type GetBooksContributorsRow struct {
+	BookID   uuid.UUID       `json:"book_id"`
+	ID       uuid.UUID       `json:"id"`
+	FullName string          `json:"full_name"`
+	Role     ContributorRole `json:"role"`
+}
+
+func (q *Queries) GetBooksContributors(ctx context.Context, bookIds []uuid.UUID) ([]*GetBooksContributorsRow, error) {
+	rows, err := q.db.Query(ctx, getBooksContributors, bookIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*GetBooksContributorsRow{}
+	for rows.Next() {
+		var i GetBooksContributorsRow
+		if err := rows.Scan(
+			&i.BookID,
+			&i.ID,
+			&i.FullName,
+			&i.Role,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, &i)
@@ -102,14 +146,16 @@ func (q *Queries) GetBookAuthors(ctx context.Context, bookID uuid.UUID) ([]*GetB
 const removeBookAuthor = `-- name: RemoveBookAuthor :exec
 DELETE FROM book_authors
 WHERE book_id = $1 AND author_id = $2
+  AND ($3::text = '' OR role::text = $3::text)
 `
 
 type RemoveBookAuthorParams struct {
 	BookID   uuid.UUID `json:"book_id"`
 	AuthorID uuid.UUID `json:"author_id"`
+	Role     string    `json:"role"`
 }
 
 func (q *Queries) RemoveBookAuthor(ctx context.Context, arg RemoveBookAuthorParams) error {
-	_, err := q.db.Exec(ctx, removeBookAuthor, arg.BookID, arg.AuthorID)
+	_, err := q.db.Exec(ctx, removeBookAuthor, arg.BookID, arg.AuthorID, arg.Role)
 	return err
 }
@@ -143,6 +143,60 @@ func (q *Queries) GetBookCopiesByHall(ctx context.Context, hallID *uuid.UUID) ([
 	return items, nil
 }
 
+const getBookCopiesForLabels = `-- name: GetBookCopiesForLabels :many
+SELECT
+    bc.id,
+    bc.copy_code,
+    bc.location_info,
+    b.title,
+    b.publication_year,
+    rh.hall_name,
+    rh.hall_code
+FROM book_copies bc
+JOIN books b ON bc.book_id = b.id
+LEFT JOIN reading_halls rh ON bc.hall_id = rh.id
+WHERE bc.id = ANY($1::uuid[])
+ORDER BY rh.hall_name, b.title, bc.copy_code
+`
+
+type GetBookCopiesForLabelsRow struct {
+	ID              uuid.UUID `json:"id"`
+	CopyCode        string    `json:"copy_code"`
+	LocationInfo    *string   `json:"location_info"`
+	Title           string    `json:"title"`
+	PublicationYear *int      `json:"publication_year"`
+	HallName        *string   `json:"hall_name"`
+	HallCode        *string   `json:"hall_code"`
+}
+
+func (q *Queries) GetBookCopiesForLabels(ctx context.Context, copyIds []uuid.UUID) ([]*GetBookCopiesForLabelsRow, error) {
+	rows, err := q.db.Query(ctx, getBookCopiesForLabels, copyIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*GetBookCopiesForLabelsRow{}
+	for rows.Next() {
+		var i GetBookCopiesForLabelsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CopyCode,
+			&i.LocationInfo,
+			&i.Title,
+			&i.PublicationYear,
+			&i.HallName,
+			&i.HallCode,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getBookCopyByCode = `-- name: GetBookCopyByCode :one
 SELECT bc.id, bc.copy_code, bc.status, b.id as book_id, b.title, bc.location_info
 FROM book_copies bc
@@ -204,18 +258,75 @@ func (q *Queries) GetBookCopyById(ctx context.Context, copyID uuid.UUID) (*GetBo
 	return &i, err
 }
 
+const getReservedBookCopy = `-- name: GetReservedBookCopy :one
+SELECT bc.id, bc.copy_code, bc.status, b.title, bc.location_info, bc.hold_reader_id
+FROM book_copies bc
+JOIN books b ON bc.book_id = b.id
+WHERE bc.copy_code = $1 AND bc.status = 'reserved'
+`
+
+type GetReservedBookCopyRow struct {
+	ID           uuid.UUID      `json:"id"`
+	CopyCode     string         `json:"copy_code"`
+	Status       NullBookStatus `json:"status"`
+	Title        string         `json:"title"`
+	LocationInfo *string        `json:"location_info"`
+	HoldReaderID *uuid.UUID     `json:"hold_reader_id"`
+}
+
+func (q *Queries) GetReservedBookCopy(ctx context.Context, copyCode string) (*GetReservedBookCopyRow, error) {
+	row := q.db.QueryRow(ctx, getReservedBookCopy, copyCode)
+	var i GetReservedBookCopyRow
+	err := row.Scan(
+		&i.ID,
+		&i.CopyCode,
+		&i.Status,
+		&i.Title,
+		&i.LocationInfo,
+		&i.HoldReaderID,
+	)
+	return &i, err
+}
+
+const lockBookCopyStatus = `-- name: LockBookCopyStatus :one
+SELECT status
+FROM book_copies
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) LockBookCopyStatus(ctx context.Context, copyID uuid.UUID) (NullBookStatus, error) {
+	row := q.db.QueryRow(ctx, lockBookCopyStatus, copyID)
+	var status NullBookStatus
+	err := row.Scan(&status)
+	return status, err
+}
+
+const nextCopyCodeSequence = `-- name: NextCopyCodeSequence :one
+SELECT nextval('copy_code_seq')::bigint as sequence
+`
+
+func (q *Queries) NextCopyCodeSequence(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, nextCopyCodeSequence)
+	var sequence int64
+	err := row.Scan(&sequence)
+	return sequence, err
+}
+
 const updateBookCopyStatus = `-- name: UpdateBookCopyStatus :exec
 UPDATE book_copies
-SET status = $1
-WHERE id = $2
+SET status = $1,
+    hold_reader_id = $2
+WHERE id = $3
 `
 
 type UpdateBookCopyStatusParams struct {
-	Status NullBookStatus `json:"status"`
-	CopyID uuid.UUID      `json:"copy_id"`
+	Status       NullBookStatus `json:"status"`
+	HoldReaderID *uuid.UUID     `json:"hold_reader_id"`
+	CopyID       uuid.UUID      `json:"copy_id"`
 }
 
 func (q *Queries) UpdateBookCopyStatus(ctx context.Context, arg UpdateBookCopyStatusParams) error {
-	_, err := q.db.Exec(ctx, updateBookCopyStatus, arg.Status, arg.CopyID)
+	_, err := q.db.Exec(ctx, updateBookCopyStatus, arg.Status, arg.HoldReaderID, arg.CopyID)
 	return err
 }
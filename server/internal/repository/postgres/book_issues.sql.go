@@ -12,6 +12,37 @@ import (
 	"github.com/google/uuid"
 )
 
+const getActiveIssueByCopyCode = `-- name: GetActiveIssueByCopyCode :one
+SELECT bi.id, bi.reader_id, bi.due_date, bi.renewal_count, b.title, bi.source_library
+FROM book_issues bi
+JOIN book_copies bc ON bi.book_copy_id = bc.id
+JOIN books b ON bc.book_id = b.id
+WHERE bc.copy_code = $1 AND bi.return_date IS NULL
+`
+
+type GetActiveIssueByCopyCodeRow struct {
+	ID            uuid.UUID `json:"id"`
+	ReaderID      uuid.UUID `json:"reader_id"`
+	DueDate       time.Time `json:"due_date"`
+	RenewalCount  int       `json:"renewal_count"`
+	Title         string    `json:"title"`
+	SourceLibrary *string   `json:"source_library"`
+}
+
+func (q *Queries) GetActiveIssueByCopyCode(ctx context.Context, copyCode string) (*GetActiveIssueByCopyCodeRow, error) {
+	row := q.db.QueryRow(ctx, getActiveIssueByCopyCode, copyCode)
+	var i GetActiveIssueByCopyCodeRow
+	err := row.Scan(
+		&i.ID,
+		&i.ReaderID,
+		&i.DueDate,
+		&i.RenewalCount,
+		&i.Title,
+		&i.SourceLibrary,
+	)
+	return &i, err
+}
+
 const getBooksToReturn = `-- name: GetBooksToReturn :many
 SELECT
     bi.id,
@@ -137,7 +168,8 @@ SELECT
     b.title,
     bc.copy_code,
     bi.issue_date,
-    bi.due_date
+    bi.due_date,
+    bi.renewal_count
 FROM book_issues bi
 JOIN book_copies bc ON bi.book_copy_id = bc.id
 JOIN books b ON bc.book_id = b.id
@@ -146,11 +178,12 @@ ORDER BY bi.due_date
 `
 
 type GetReaderActiveBooksRow struct {
-	ID        uuid.UUID  `json:"id"`
-	Title     string     `json:"title"`
-	CopyCode  string     `json:"copy_code"`
-	IssueDate *time.Time `json:"issue_date"`
-	DueDate   time.Time  `json:"due_date"`
+	ID           uuid.UUID  `json:"id"`
+	Title        string     `json:"title"`
+	CopyCode     string     `json:"copy_code"`
+	IssueDate    *time.Time `json:"issue_date"`
+	DueDate      time.Time  `json:"due_date"`
+	RenewalCount int        `json:"renewal_count"`
 }
 
 func (q *Queries) GetReaderActiveBooks(ctx context.Context, readerID uuid.UUID) ([]*GetReaderActiveBooksRow, error) {
@@ -168,6 +201,85 @@ func (q *Queries) GetReaderActiveBooks(ctx context.Context, readerID uuid.UUID)
 			&i.CopyCode,
 			&i.IssueDate,
 			&i.DueDate,
+			&i.RenewalCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getReaderIssueCopy = `-- name: GetReaderIssueCopy :one
+SELECT bc.copy_code, bi.return_date
+FROM book_issues bi
+JOIN book_copies bc ON bi.book_copy_id = bc.id
+WHERE bi.id = $1 AND bi.reader_id = $2
+`
+
+type GetReaderIssueCopyParams struct {
+	ID       uuid.UUID `json:"id"`
+	ReaderID uuid.UUID `json:"reader_id"`
+}
+
+type GetReaderIssueCopyRow struct {
+	CopyCode   string     `json:"copy_code"`
+	ReturnDate *time.Time `json:"return_date"`
+}
+
+func (q *Queries) GetReaderIssueCopy(ctx context.Context, arg GetReaderIssueCopyParams) (*GetReaderIssueCopyRow, error) {
+	row := q.db.QueryRow(ctx, getReaderIssueCopy, arg.ID, arg.ReaderID)
+	var i GetReaderIssueCopyRow
+	err := row.Scan(&i.CopyCode, &i.ReturnDate)
+	return &i, err
+}
+
+const getReaderIssueHistory = `-- name: GetReaderIssueHistory :many
+SELECT
+    bi.id,
+    b.title,
+    b.isbn,
+    bc.copy_code,
+    bi.issue_date,
+    bi.due_date,
+    bi.return_date
+FROM book_issues bi
+JOIN book_copies bc ON bi.book_copy_id = bc.id
+JOIN books b ON bc.book_id = b.id
+WHERE bi.reader_id = $1
+ORDER BY bi.issue_date DESC, bi.created_at DESC
+`
+
+type GetReaderIssueHistoryRow struct {
+	ID         uuid.UUID  `json:"id"`
+	Title      string     `json:"title"`
+	Isbn       *string    `json:"isbn"`
+	CopyCode   string     `json:"copy_code"`
+	IssueDate  *time.Time `json:"issue_date"`
+	DueDate    time.Time  `json:"due_date"`
+	ReturnDate *time.Time `json:"return_date"`
+}
+
+func (q *Queries) GetReaderIssueHistory(ctx context.Context, readerID uuid.UUID) ([]*GetReaderIssueHistoryRow, error) {
+	rows, err := q.db.Query(ctx, getReaderIssueHistory, readerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*GetReaderIssueHistoryRow{}
+	for rows.Next() {
+		var i GetReaderIssueHistoryRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Isbn,
+			&i.CopyCode,
+			&i.IssueDate,
+			&i.DueDate,
+			&i.ReturnDate,
 		); err != nil {
 			return nil, err
 		}
@@ -264,16 +376,18 @@ func (q *Queries) GetRecentBookOperations(ctx context.Context, arg GetRecentBook
 }
 
 const issueBook = `-- name: IssueBook :one
-INSERT INTO book_issues (reader_id, book_copy_id, due_date, librarian_id)
-VALUES ($1, $2, $3, $4)
+INSERT INTO book_issues (reader_id, book_copy_id, due_date, librarian_id, source_library, external_user_id)
+VALUES ($1, $2, $3, $4, $5, $6)
 RETURNING id, issue_date, due_date
 `
 
 type IssueBookParams struct {
-	ReaderID    uuid.UUID  `json:"reader_id"`
-	BookCopyID  uuid.UUID  `json:"book_copy_id"`
-	DueDate     time.Time  `json:"due_date"`
-	LibrarianID *uuid.UUID `json:"librarian_id"`
+	ReaderID       uuid.UUID  `json:"reader_id"`
+	BookCopyID     uuid.UUID  `json:"book_copy_id"`
+	DueDate        time.Time  `json:"due_date"`
+	LibrarianID    *uuid.UUID `json:"librarian_id"`
+	SourceLibrary  *string    `json:"source_library"`
+	ExternalUserID *string    `json:"external_user_id"`
 }
 
 type IssueBookRow struct {
@@ -288,12 +402,70 @@ func (q *Queries) IssueBook(ctx context.Context, arg IssueBookParams) (*IssueBoo
 		arg.BookCopyID,
 		arg.DueDate,
 		arg.LibrarianID,
+		arg.SourceLibrary,
+		arg.ExternalUserID,
 	)
 	var i IssueBookRow
 	err := row.Scan(&i.ID, &i.IssueDate, &i.DueDate)
 	return &i, err
 }
 
+const lockReaderBookIssue = `-- name: LockReaderBookIssue :one
+SELECT id, due_date, return_date, renewal_count
+FROM book_issues
+WHERE id = $1 AND reader_id = $2
+FOR UPDATE
+`
+
+type LockReaderBookIssueParams struct {
+	ID       uuid.UUID `json:"id"`
+	ReaderID uuid.UUID `json:"reader_id"`
+}
+
+type LockReaderBookIssueRow struct {
+	ID           uuid.UUID  `json:"id"`
+	DueDate      time.Time  `json:"due_date"`
+	ReturnDate   *time.Time `json:"return_date"`
+	RenewalCount int        `json:"renewal_count"`
+}
+
+func (q *Queries) LockReaderBookIssue(ctx context.Context, arg LockReaderBookIssueParams) (*LockReaderBookIssueRow, error) {
+	row := q.db.QueryRow(ctx, lockReaderBookIssue, arg.ID, arg.ReaderID)
+	var i LockReaderBookIssueRow
+	err := row.Scan(
+		&i.ID,
+		&i.DueDate,
+		&i.ReturnDate,
+		&i.RenewalCount,
+	)
+	return &i, err
+}
+
+const renewBookIssue = `-- name: RenewBookIssue :one
+UPDATE book_issues
+SET due_date = $1, renewal_count = renewal_count + 1
+WHERE id = $2
+RETURNING id, due_date, renewal_count
+`
+
+type RenewBookIssueParams struct {
+	DueDate time.Time `json:"due_date"`
+	ID      uuid.UUID `json:"id"`
+}
+
+type RenewBookIssueRow struct {
+	ID           uuid.UUID `json:"id"`
+	DueDate      time.Time `json:"due_date"`
+	RenewalCount int       `json:"renewal_count"`
+}
+
+func (q *Queries) RenewBookIssue(ctx context.Context, arg RenewBookIssueParams) (*RenewBookIssueRow, error) {
+	row := q.db.QueryRow(ctx, renewBookIssue, arg.DueDate, arg.ID)
+	var i RenewBookIssueRow
+	err := row.Scan(&i.ID, &i.DueDate, &i.RenewalCount)
+	return &i, err
+}
+
 const returnBook = `-- name: ReturnBook :one
 UPDATE book_issues
 SET return_date = CURRENT_DATE
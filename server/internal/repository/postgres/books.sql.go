@@ -12,27 +12,47 @@ import (
 )
 
 const createBook = `-- name: CreateBook :one
-INSERT INTO books (title, isbn, publication_year, publisher, total_copies, available_copies)
-VALUES ($1, $2, $3, $4, $5, $5)
-RETURNING id, title, isbn, publication_year, publisher, total_copies, available_copies
+INSERT INTO books (
+    title, isbn, publication_year, publisher,
+    work_id, series_id, series_volume, language, original_language, page_count, description
+)
+VALUES (
+    $1, $2, $3, $4,
+    $5, $6, $7, $8, $9, $10, $11
+)
+RETURNING id, title, isbn, publication_year, publisher, total_copies, available_copies,
+    work_id, series_id, series_volume, language, original_language, page_count, description
 `
 
 type CreateBookParams struct {
-	Title           string  `json:"title"`
-	Isbn            *string `json:"isbn"`
-	PublicationYear *int    `json:"publication_year"`
-	Publisher       *string `json:"publisher"`
-	TotalCopies     int     `json:"total_copies"`
+	Title            string     `json:"title"`
+	Isbn             *string    `json:"isbn"`
+	PublicationYear  *int       `json:"publication_year"`
+	Publisher        *string    `json:"publisher"`
+	WorkID           *uuid.UUID `json:"work_id"`
+	SeriesID         *uuid.UUID `json:"series_id"`
+	SeriesVolume     *int       `json:"series_volume"`
+	Language         *string    `json:"language"`
+	OriginalLanguage *string    `json:"original_language"`
+	PageCount        *int       `json:"page_count"`
+	Description      *string    `json:"description"`
 }
 
 type CreateBookRow struct {
-	ID              uuid.UUID `json:"id"`
-	Title           string    `json:"title"`
-	Isbn            *string   `json:"isbn"`
-	PublicationYear *int      `json:"publication_year"`
-	Publisher       *string   `json:"publisher"`
-	TotalCopies     int       `json:"total_copies"`
-	AvailableCopies int       `json:"available_copies"`
+	ID               uuid.UUID  `json:"id"`
+	Title            string     `json:"title"`
+	Isbn             *string    `json:"isbn"`
+	PublicationYear  *int       `json:"publication_year"`
+	Publisher        *string    `json:"publisher"`
+	TotalCopies      int        `json:"total_copies"`
+	AvailableCopies  int        `json:"available_copies"`
+	WorkID           *uuid.UUID `json:"work_id"`
+	SeriesID         *uuid.UUID `json:"series_id"`
+	SeriesVolume     *int       `json:"series_volume"`
+	Language         *string    `json:"language"`
+	OriginalLanguage *string    `json:"original_language"`
+	PageCount        *int       `json:"page_count"`
+	Description      *string    `json:"description"`
 }
 
 func (q *Queries) CreateBook(ctx context.Context, arg CreateBookParams) (*CreateBookRow, error) {
@@ -41,7 +61,13 @@ func (q *Queries) CreateBook(ctx context.Context, arg CreateBookParams) (*Create
 		arg.Isbn,
 		arg.PublicationYear,
 		arg.Publisher,
-		arg.TotalCopies,
+		arg.WorkID,
+		arg.SeriesID,
+		arg.SeriesVolume,
+		arg.Language,
+		arg.OriginalLanguage,
+		arg.PageCount,
+		arg.Description,
 	)
 	var i CreateBookRow
 	err := row.Scan(
@@ -52,6 +78,13 @@ func (q *Queries) CreateBook(ctx context.Context, arg CreateBookParams) (*Create
 		&i.Publisher,
 		&i.TotalCopies,
 		&i.AvailableCopies,
+		&i.WorkID,
+		&i.SeriesID,
+		&i.SeriesVolume,
+		&i.Language,
+		&i.OriginalLanguage,
+		&i.PageCount,
+		&i.Description,
 	)
 	return &i, err
 }
@@ -65,23 +98,38 @@ SELECT DISTINCT
     b.publisher,
     b.available_copies,
     b.total_copies,
-    STRING_AGG(a.full_name, ', ') as authors
+    b.work_id,
+    b.series_id,
+    sr.title as series_title,
+    b.series_volume,
+    b.language,
+    b.original_language,
+    b.page_count,
+    STRING_AGG(a.full_name, ', ') FILTER (WHERE ba.role = 'author') as authors
 FROM books b
+LEFT JOIN series sr ON b.series_id = sr.id
 LEFT JOIN book_authors ba ON b.id = ba.book_id
 LEFT JOIN authors a ON ba.author_id = a.id
-GROUP BY b.id, b.title, b.isbn, b.publication_year, b.publisher, b.available_copies, b.total_copies
+GROUP BY b.id, sr.title
 ORDER BY b.title
 `
 
 type GetAllBooksRow struct {
-	ID              uuid.UUID `json:"id"`
-	Title           string    `json:"title"`
-	Isbn            *string   `json:"isbn"`
-	PublicationYear *int      `json:"publication_year"`
-	Publisher       *string   `json:"publisher"`
-	AvailableCopies int       `json:"available_copies"`
-	TotalCopies     int       `json:"total_copies"`
-	Authors         []byte    `json:"authors"`
+	ID               uuid.UUID  `json:"id"`
+	Title            string     `json:"title"`
+	Isbn             *string    `json:"isbn"`
+	PublicationYear  *int       `json:"publication_year"`
+	Publisher        *string    `json:"publisher"`
+	AvailableCopies  int        `json:"available_copies"`
+	TotalCopies      int        `json:"total_copies"`
+	WorkID           *uuid.UUID `json:"work_id"`
+	SeriesID         *uuid.UUID `json:"series_id"`
+	SeriesTitle      *string    `json:"series_title"`
+	SeriesVolume     *int       `json:"series_volume"`
+	Language         *string    `json:"language"`
+	OriginalLanguage *string    `json:"original_language"`
+	PageCount        *int       `json:"page_count"`
+	Authors          []byte     `json:"authors"`
 }
 
 func (q *Queries) GetAllBooks(ctx context.Context) ([]*GetAllBooksRow, error) {
@@ -101,6 +149,13 @@ func (q *Queries) GetAllBooks(ctx context.Context) ([]*GetAllBooksRow, error) {
 			&i.Publisher,
 			&i.AvailableCopies,
 			&i.TotalCopies,
+			&i.WorkID,
+			&i.SeriesID,
+			&i.SeriesTitle,
+			&i.SeriesVolume,
+			&i.Language,
+			&i.OriginalLanguage,
+			&i.PageCount,
 			&i.Authors,
 		); err != nil {
 			return nil, err
@@ -114,19 +169,46 @@ func (q *Queries) GetAllBooks(ctx context.Context) ([]*GetAllBooksRow, error) {
 }
 
 const getBookById = `-- name: GetBookById :one
-SELECT id, title, isbn, publication_year, publisher, total_copies, available_copies
-FROM books
-WHERE id = $1
+SELECT
+    b.id,
+    b.title,
+    b.isbn,
+    b.publication_year,
+    b.publisher,
+    b.total_copies,
+    b.available_copies,
+    b.work_id,
+    w.title as work_title,
+    b.series_id,
+    sr.title as series_title,
+    b.series_volume,
+    b.language,
+    b.original_language,
+    b.page_count,
+    b.description
+FROM books b
+LEFT JOIN works w ON b.work_id = w.id
+LEFT JOIN series sr ON b.series_id = sr.id
+WHERE b.id = $1
 `
 
 type GetBookByIdRow struct {
-	ID              uuid.UUID `json:"id"`
-	Title           string    `json:"title"`
-	Isbn            *string   `json:"isbn"`
-	PublicationYear *int      `json:"publication_year"`
-	Publisher       *string   `json:"publisher"`
-	TotalCopies     int       `json:"total_copies"`
-	AvailableCopies int       `json:"available_copies"`
+	ID               uuid.UUID  `json:"id"`
+	Title            string     `json:"title"`
+	Isbn             *string    `json:"isbn"`
+	PublicationYear  *int       `json:"publication_year"`
+	Publisher        *string    `json:"publisher"`
+	TotalCopies      int        `json:"total_copies"`
+	AvailableCopies  int        `json:"available_copies"`
+	WorkID           *uuid.UUID `json:"work_id"`
+	WorkTitle        *string    `json:"work_title"`
+	SeriesID         *uuid.UUID `json:"series_id"`
+	SeriesTitle      *string    `json:"series_title"`
+	SeriesVolume     *int       `json:"series_volume"`
+	Language         *string    `json:"language"`
+	OriginalLanguage *string    `json:"original_language"`
+	PageCount        *int       `json:"page_count"`
+	Description      *string    `json:"description"`
 }
 
 func (q *Queries) GetBookById(ctx context.Context, id uuid.UUID) (*GetBookByIdRow, error) {
@@ -140,10 +222,122 @@ func (q *Queries) GetBookById(ctx context.Context, id uuid.UUID) (*GetBookByIdRo
 		&i.Publisher,
 		&i.TotalCopies,
 		&i.AvailableCopies,
+		&i.WorkID,
+		&i.WorkTitle,
+		&i.SeriesID,
+		&i.SeriesTitle,
+		&i.SeriesVolume,
+		&i.Language,
+		&i.OriginalLanguage,
+		&i.PageCount,
+		&i.Description,
 	)
 	return &i, err
 }
 
+const getBookByIsbns = `-- name: GetBookByIsbns :one
+SELECT id, title
+FROM books
+WHERE upper(replace(replace(COALESCE(isbn, ''), '-', ''), ' ', '')) = ANY($1::text[])
+ORDER BY created_at
+LIMIT 1
+`
+
+type GetBookByIsbnsRow struct {
+	ID    uuid.UUID `json:"id"`
+	Title string    `json:"title"`
+}
+
+func (q *Queries) GetBookByIsbns(ctx context.Context, isbns []string) (*GetBookByIsbnsRow, error) {
+	row := q.db.QueryRow(ctx, getBookByIsbns, isbns)
+	var i GetBookByIsbnsRow
+	err := row.Scan(&i.ID, &i.Title)
+	return &i, err
+}
+
+const getBookCopyCountMismatches = `-- name: GetBookCopyCountMismatches :many
+SELECT
+    b.id,
+    b.title,
+    b.total_copies,
+    b.available_copies,
+    COALESCE(c.actual_total, 0)::int as actual_total_copies,
+    COALESCE(c.actual_available, 0)::int as actual_available_copies
+FROM books b
+LEFT JOIN (
+    SELECT
+        book_id,
+        COUNT(*) FILTER (WHERE COALESCE(status, 'available') NOT IN ('withdrawn', 'lost')) as actual_total,
+        COUNT(*) FILTER (WHERE COALESCE(status, 'available') = 'available') as actual_available
+    FROM book_copies
+    GROUP BY book_id
+) c ON c.book_id = b.id
+WHERE b.total_copies <> COALESCE(c.actual_total, 0)
+   OR b.available_copies <> COALESCE(c.actual_available, 0)
+ORDER BY b.title
+`
+
+type GetBookCopyCountMismatchesRow struct {
+	ID                    uuid.UUID `json:"id"`
+	Title                 string    `json:"title"`
+	TotalCopies           int       `json:"total_copies"`
+	AvailableCopies       int       `json:"available_copies"`
+	ActualTotalCopies     int       `json:"actual_total_copies"`
+	ActualAvailableCopies int       `json:"actual_available_copies"`
+}
+
+func (q *Queries) GetBookCopyCountMismatches(ctx context.Context) ([]*GetBookCopyCountMismatchesRow, error) {
+	rows, err := q.db.Query(ctx, getBookCopyCountMismatches)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*GetBookCopyCountMismatchesRow{}
+	for rows.Next() {
+		var i GetBookCopyCountMismatchesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.TotalCopies,
+			&i.AvailableCopies,
+			&i.ActualTotalCopies,
+			&i.ActualAvailableCopies,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const recountAllBookCopies = `-- name: RecountAllBookCopies :execrows
+UPDATE books b
+SET total_copies = c.actual_total,
+    available_copies = c.actual_available
+FROM (
+    SELECT
+        bk.id as book_id,
+        COUNT(bc.id) FILTER (WHERE COALESCE(bc.status, 'available') NOT IN ('withdrawn', 'lost')) as actual_total,
+        COUNT(bc.id) FILTER (WHERE COALESCE(bc.status, 'available') = 'available') as actual_available
+    FROM books bk
+    LEFT JOIN book_copies bc ON bc.book_id = bk.id
+    GROUP BY bk.id
+) c
+WHERE b.id = c.book_id
+  AND (b.total_copies <> c.actual_total OR b.available_copies <> c.actual_available)
+`
+
+func (q *Queries) RecountAllBookCopies(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, recountAllBookCopies)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const searchBooks = `-- name: SearchBooks :many
 SELECT DISTINCT
     b.id,
@@ -153,37 +347,75 @@ SELECT DISTINCT
     b.publisher,
     b.available_copies,
     b.total_copies,
-    STRING_AGG(a.full_name, ', ') as authors
+    b.work_id,
+    b.series_id,
+    sr.title as series_title,
+    b.series_volume,
+    b.language,
+    b.original_language,
+    b.page_count,
+    STRING_AGG(a.full_name, ', ') FILTER (WHERE ba.role = 'author') as authors
 FROM books b
+LEFT JOIN series sr ON b.series_id = sr.id
 LEFT JOIN book_authors ba ON b.id = ba.book_id
 LEFT JOIN authors a ON ba.author_id = a.id
 WHERE
     ($1::text IS NULL OR b.title ILIKE '%' || $1 || '%') AND
     ($2::text IS NULL OR a.full_name ILIKE '%' || $2 || '%') AND
-    ($3::int IS NULL OR b.publication_year = $3)
-GROUP BY b.id, b.title, b.isbn, b.publication_year, b.publisher, b.available_copies, b.total_copies
-ORDER BY b.title
+    ($3::int IS NULL OR b.publication_year = $3) AND
+    ($4::text = '' OR b.id IN (
+        WITH RECURSIVE subtree AS (
+            SELECT s.id FROM subjects s WHERE s.id::text = $4::text
+            UNION ALL
+            SELECT s.id FROM subjects s JOIN subtree t ON s.parent_id = t.id
+        )
+        SELECT bs.book_id FROM book_subjects bs JOIN subtree t ON t.id = bs.subject_id
+    )) AND
+    ($5::text = '' OR b.language = $5::text) AND
+    ($6::text = '' OR b.series_id::text = $6::text) AND
+    ($7::text = '' OR b.work_id::text = $7::text)
+GROUP BY b.id, sr.title
+ORDER BY b.title, b.series_volume
 `
 
 type SearchBooksParams struct {
 	Title           string `json:"title"`
 	Author          string `json:"author"`
 	PublicationYear int    `json:"publication_year"`
+	SubjectID       string `json:"subject_id"`
+	Language        string `json:"language"`
+	SeriesID        string `json:"series_id"`
+	WorkID          string `json:"work_id"`
 }
 
 type SearchBooksRow struct {
-	ID              uuid.UUID `json:"id"`
-	Title           string    `json:"title"`
-	Isbn            *string   `json:"isbn"`
-	PublicationYear *int      `json:"publication_year"`
-	Publisher       *string   `json:"publisher"`
-	AvailableCopies int       `json:"available_copies"`
-	TotalCopies     int       `json:"total_copies"`
-	Authors         []byte    `json:"authors"`
+	ID               uuid.UUID  `json:"id"`
+	Title            string     `json:"title"`
+	Isbn             *string    `json:"isbn"`
+	PublicationYear  *int       `json:"publication_year"`
+	Publisher        *string    `json:"publisher"`
+	AvailableCopies  int        `json:"available_copies"`
+	TotalCopies      int        `json:"total_copies"`
+	WorkID           *uuid.UUID `json:"work_id"`
+	SeriesID         *uuid.UUID `json:"series_id"`
+	SeriesTitle      *string    `json:"series_title"`
+	SeriesVolume     *int       `json:"series_volume"`
+	Language         *string    `json:"language"`
+	OriginalLanguage *string    `json:"original_language"`
+	PageCount        *int       `json:"page_count"`
+	Authors          []byte     `json:"authors"`
 }
 
 func (q *Queries) SearchBooks(ctx context.Context, arg SearchBooksParams) ([]*SearchBooksRow, error) {
-	rows, err := q.db.Query(ctx, searchBooks, arg.Title, arg.Author, arg.PublicationYear)
+	rows, err := q.db.Query(ctx, searchBooks,
+		arg.Title,
+		arg.Author,
+		arg.PublicationYear,
+		arg.SubjectID,
+		arg.Language,
+		arg.SeriesID,
+		arg.WorkID,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -199,6 +431,13 @@ func (q *Queries) SearchBooks(ctx context.Context, arg SearchBooksParams) ([]*Se
 			&i.Publisher,
 			&i.AvailableCopies,
 			&i.TotalCopies,
+			&i.WorkID,
+			&i.SeriesID,
+			&i.SeriesTitle,
+			&i.SeriesVolume,
+			&i.Language,
+			&i.OriginalLanguage,
+			&i.PageCount,
 			&i.Authors,
 		); err != nil {
 			return nil, err
@@ -213,27 +452,45 @@ func (q *Queries) SearchBooks(ctx context.Context, arg SearchBooksParams) ([]*Se
 
 const updateBook = `-- name: UpdateBook :one
 UPDATE books
-SET title = $1, isbn = $2, publication_year = $3, publisher = $4
-WHERE id = $5
-RETURNING id, title, isbn, publication_year, publisher, total_copies, available_copies
+SET title = $1, isbn = $2, publication_year = $3, publisher = $4,
+    work_id = $5, series_id = $6, series_volume = $7,
+    language = $8, original_language = $9,
+    page_count = $10, description = $11
+WHERE id = $12
+RETURNING id, title, isbn, publication_year, publisher, total_copies, available_copies,
+    work_id, series_id, series_volume, language, original_language, page_count, description
 `
 
 type UpdateBookParams struct {
-	Title           string    `json:"title"`
-	Isbn            *string   `json:"isbn"`
-	PublicationYear *int      `json:"publication_year"`
-	Publisher       *string   `json:"publisher"`
-	ID              uuid.UUID `json:"id"`
+	Title            string     `json:"title"`
+	Isbn             *string    `json:"isbn"`
+	PublicationYear  *int       `json:"publication_year"`
+	Publisher        *string    `json:"publisher"`
+	WorkID           *uuid.UUID `json:"work_id"`
+	SeriesID         *uuid.UUID `json:"series_id"`
+	SeriesVolume     *int       `json:"series_volume"`
+	Language         *string    `json:"language"`
+	OriginalLanguage *string    `json:"original_language"`
+	PageCount        *int       `json:"page_count"`
+	Description      *string    `json:"description"`
+	ID               uuid.UUID  `json:"id"`
 }
 
 type UpdateBookRow struct {
-	ID              uuid.UUID `json:"id"`
-	Title           string    `json:"title"`
-	Isbn            *string   `json:"isbn"`
-	PublicationYear *int      `json:"publication_year"`
-	Publisher       *string   `json:"publisher"`
-	TotalCopies     int       `json:"total_copies"`
-	AvailableCopies int       `json:"available_copies"`
+	ID               uuid.UUID  `json:"id"`
+	Title            string     `json:"title"`
+	Isbn             *string    `json:"isbn"`
+	PublicationYear  *int       `json:"publication_year"`
+	Publisher        *string    `json:"publisher"`
+	TotalCopies      int        `json:"total_copies"`
+	AvailableCopies  int        `json:"available_copies"`
+	WorkID           *uuid.UUID `json:"work_id"`
+	SeriesID         *uuid.UUID `json:"series_id"`
+	SeriesVolume     *int       `json:"series_volume"`
+	Language         *string    `json:"language"`
+	OriginalLanguage *string    `json:"original_language"`
+	PageCount        *int       `json:"page_count"`
+	Description      *string    `json:"description"`
 }
 
 func (q *Queries) UpdateBook(ctx context.Context, arg UpdateBookParams) (*UpdateBookRow, error) {
@@ -242,6 +499,13 @@ func (q *Queries) UpdateBook(ctx context.Context, arg UpdateBookParams) (*Update
 		arg.Isbn,
 		arg.PublicationYear,
 		arg.Publisher,
+		arg.WorkID,
+		arg.SeriesID,
+		arg.SeriesVolume,
+		arg.Language,
+		arg.OriginalLanguage,
+		arg.PageCount,
+		arg.Description,
 		arg.ID,
 	)
 	var i UpdateBookRow
@@ -253,22 +517,13 @@ func (q *Queries) UpdateBook(ctx context.Context, arg UpdateBookParams) (*Update
 		&i.Publisher,
 		&i.TotalCopies,
 		&i.AvailableCopies,
+		&i.WorkID,
+		&i.SeriesID,
+		&i.SeriesVolume,
+		&i.Language,
+		&i.OriginalLanguage,
+		&i.PageCount,
+		&i.Description,
 	)
 	return &i, err
 }
-
-const updateBookCopies = `-- name: UpdateBookCopies :exec
-UPDATE books
-SET available_copies = available_copies + $1
-WHERE id = $2
-`
-
-type UpdateBookCopiesParams struct {
-	Change int       `json:"change"`
-	BookID uuid.UUID `json:"book_id"`
-}
-
-func (q *Queries) UpdateBookCopies(ctx context.Context, arg UpdateBookCopiesParams) error {
-	_, err := q.db.Exec(ctx, updateBookCopies, arg.Change, arg.BookID)
-	return err
-}
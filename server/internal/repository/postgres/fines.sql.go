@@ -50,6 +50,35 @@ func (q *Queries) CreateFine(ctx context.Context, arg CreateFineParams) (*Create
 	return &i, err
 }
 
+const getFineById = `-- name: GetFineById :one
+SELECT id, reader_id, amount, reason, fine_date, is_paid
+FROM fines
+WHERE id = $1
+`
+
+type GetFineByIdRow struct {
+	ID       uuid.UUID       `json:"id"`
+	ReaderID uuid.UUID       `json:"reader_id"`
+	Amount   decimal.Decimal `json:"amount"`
+	Reason   string          `json:"reason"`
+	FineDate *time.Time      `json:"fine_date"`
+	IsPaid   *bool           `json:"is_paid"`
+}
+
+func (q *Queries) GetFineById(ctx context.Context, id uuid.UUID) (*GetFineByIdRow, error) {
+	row := q.db.QueryRow(ctx, getFineById, id)
+	var i GetFineByIdRow
+	err := row.Scan(
+		&i.ID,
+		&i.ReaderID,
+		&i.Amount,
+		&i.Reason,
+		&i.FineDate,
+		&i.IsPaid,
+	)
+	return &i, err
+}
+
 const getReaderFines = `-- name: GetReaderFines :many
 SELECT id, amount, reason, fine_date, paid_date, is_paid
 FROM fines
@@ -209,7 +209,7 @@ func (q *Queries) GetRecentHallVisits(ctx context.Context, arg GetRecentHallVisi
 const registerHallEntry = `-- name: RegisterHallEntry :one
 INSERT INTO hall_visits (reader_id, hall_id, visit_type, librarian_id)
 VALUES (
-    (SELECT id FROM readers WHERE ticket_number = $1),
+    (SELECT id FROM readers WHERE ticket_number = $1 AND is_active = true),
     $2,
     'entry',
     $3
@@ -238,7 +238,7 @@ func (q *Queries) RegisterHallEntry(ctx context.Context, arg RegisterHallEntryPa
 const registerHallExit = `-- name: RegisterHallExit :one
 INSERT INTO hall_visits (reader_id, hall_id, visit_type, librarian_id)
 VALUES (
-    (SELECT id FROM readers WHERE ticket_number = $1),
+    (SELECT id FROM readers WHERE ticket_number = $1 AND is_active = true),
     $2,
     'exit',
     $3
@@ -6,6 +6,7 @@ package postgres
 
 import (
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -13,6 +14,90 @@ import (
 	"github.com/govalues/decimal"
 )
 
+type AttachmentKind string
+
+const (
+	AttachmentKindCover AttachmentKind = "cover"
+	AttachmentKindToc   AttachmentKind = "toc"
+)
+
+func (e *AttachmentKind) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = AttachmentKind(s)
+	case string:
+		*e = AttachmentKind(s)
+	default:
+		return fmt.Errorf("unsupported scan type for AttachmentKind: %T", src)
+	}
+	return nil
+}
+
+type NullAttachmentKind struct {
+	AttachmentKind AttachmentKind `json:"attachment_kind"`
+	Valid          bool           `json:"valid"` // Valid is true if AttachmentKind is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullAttachmentKind) Scan(value interface{}) error {
+	if value == nil {
+		ns.AttachmentKind, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.AttachmentKind.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullAttachmentKind) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.AttachmentKind), nil
+}
+
+type AuthorNameKind string
+
+const (
+	AuthorNameKindVariant         AuthorNameKind = "variant"
+	AuthorNameKindTransliteration AuthorNameKind = "transliteration"
+)
+
+func (e *AuthorNameKind) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = AuthorNameKind(s)
+	case string:
+		*e = AuthorNameKind(s)
+	default:
+		return fmt.Errorf("unsupported scan type for AuthorNameKind: %T", src)
+	}
+	return nil
+}
+
+type NullAuthorNameKind struct {
+	AuthorNameKind AuthorNameKind `json:"author_name_kind"`
+	Valid          bool           `json:"valid"` // Valid is true if AuthorNameKind is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullAuthorNameKind) Scan(value interface{}) error {
+	if value == nil {
+		ns.AuthorNameKind, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.AuthorNameKind.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullAuthorNameKind) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.AuthorNameKind), nil
+}
+
 type BookStatus string
 
 const (
@@ -21,6 +106,9 @@ const (
 	BookStatusReserved  BookStatus = "reserved"
 	BookStatusLost      BookStatus = "lost"
 	BookStatusDamaged   BookStatus = "damaged"
+	BookStatusInRepair  BookStatus = "in_repair"
+	BookStatusWithdrawn BookStatus = "withdrawn"
+	BookStatusInTransit BookStatus = "in_transit"
 )
 
 func (e *BookStatus) Scan(src interface{}) error {
@@ -58,11 +146,405 @@ func (ns NullBookStatus) Value() (driver.Value, error) {
 	return string(ns.BookStatus), nil
 }
 
+type BookingStatus string
+
+const (
+	BookingStatusBooked    BookingStatus = "booked"
+	BookingStatusCheckedIn BookingStatus = "checked_in"
+	BookingStatusCancelled BookingStatus = "cancelled"
+	BookingStatusNoShow    BookingStatus = "no_show"
+)
+
+func (e *BookingStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = BookingStatus(s)
+	case string:
+		*e = BookingStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for BookingStatus: %T", src)
+	}
+	return nil
+}
+
+type NullBookingStatus struct {
+	BookingStatus BookingStatus `json:"booking_status"`
+	Valid         bool          `json:"valid"` // Valid is true if BookingStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullBookingStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.BookingStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.BookingStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullBookingStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.BookingStatus), nil
+}
+
+type ContributorRole string
+
+const (
+	ContributorRoleAuthor      ContributorRole = "author"
+	ContributorRoleEditor      ContributorRole = "editor"
+	ContributorRoleTranslator  ContributorRole = "translator"
+	ContributorRoleIllustrator ContributorRole = "illustrator"
+)
+
+func (e *ContributorRole) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ContributorRole(s)
+	case string:
+		*e = ContributorRole(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ContributorRole: %T", src)
+	}
+	return nil
+}
+
+type NullContributorRole struct {
+	ContributorRole ContributorRole `json:"contributor_role"`
+	Valid           bool            `json:"valid"` // Valid is true if ContributorRole is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullContributorRole) Scan(value interface{}) error {
+	if value == nil {
+		ns.ContributorRole, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ContributorRole.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullContributorRole) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ContributorRole), nil
+}
+
+type IllRequestStatus string
+
+const (
+	IllRequestStatusActive    IllRequestStatus = "active"
+	IllRequestStatusFulfilled IllRequestStatus = "fulfilled"
+	IllRequestStatusCancelled IllRequestStatus = "cancelled"
+)
+
+func (e *IllRequestStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = IllRequestStatus(s)
+	case string:
+		*e = IllRequestStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for IllRequestStatus: %T", src)
+	}
+	return nil
+}
+
+type NullIllRequestStatus struct {
+	IllRequestStatus IllRequestStatus `json:"ill_request_status"`
+	Valid            bool             `json:"valid"` // Valid is true if IllRequestStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullIllRequestStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.IllRequestStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.IllRequestStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullIllRequestStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.IllRequestStatus), nil
+}
+
+type InventorySessionStatus string
+
+const (
+	InventorySessionStatusOpen      InventorySessionStatus = "open"
+	InventorySessionStatusClosed    InventorySessionStatus = "closed"
+	InventorySessionStatusCancelled InventorySessionStatus = "cancelled"
+)
+
+func (e *InventorySessionStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = InventorySessionStatus(s)
+	case string:
+		*e = InventorySessionStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for InventorySessionStatus: %T", src)
+	}
+	return nil
+}
+
+type NullInventorySessionStatus struct {
+	InventorySessionStatus InventorySessionStatus `json:"inventory_session_status"`
+	Valid                  bool                   `json:"valid"` // Valid is true if InventorySessionStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullInventorySessionStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.InventorySessionStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.InventorySessionStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullInventorySessionStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.InventorySessionStatus), nil
+}
+
+type NotificationChannel string
+
+const (
+	NotificationChannelEmail NotificationChannel = "email"
+	NotificationChannelSms   NotificationChannel = "sms"
+)
+
+func (e *NotificationChannel) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = NotificationChannel(s)
+	case string:
+		*e = NotificationChannel(s)
+	default:
+		return fmt.Errorf("unsupported scan type for NotificationChannel: %T", src)
+	}
+	return nil
+}
+
+type NullNotificationChannel struct {
+	NotificationChannel NotificationChannel `json:"notification_channel"`
+	Valid               bool                `json:"valid"` // Valid is true if NotificationChannel is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullNotificationChannel) Scan(value interface{}) error {
+	if value == nil {
+		ns.NotificationChannel, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.NotificationChannel.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullNotificationChannel) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.NotificationChannel), nil
+}
+
+type NotificationKind string
+
+const (
+	NotificationKindDueSoon       NotificationKind = "due_soon"
+	NotificationKindOverdue       NotificationKind = "overdue"
+	NotificationKindHoldAvailable NotificationKind = "hold_available"
+)
+
+func (e *NotificationKind) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = NotificationKind(s)
+	case string:
+		*e = NotificationKind(s)
+	default:
+		return fmt.Errorf("unsupported scan type for NotificationKind: %T", src)
+	}
+	return nil
+}
+
+type NullNotificationKind struct {
+	NotificationKind NotificationKind `json:"notification_kind"`
+	Valid            bool             `json:"valid"` // Valid is true if NotificationKind is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullNotificationKind) Scan(value interface{}) error {
+	if value == nil {
+		ns.NotificationKind, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.NotificationKind.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullNotificationKind) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.NotificationKind), nil
+}
+
+type NotificationStatus string
+
+const (
+	NotificationStatusPending   NotificationStatus = "pending"
+	NotificationStatusSent      NotificationStatus = "sent"
+	NotificationStatusFailed    NotificationStatus = "failed"
+	NotificationStatusCancelled NotificationStatus = "cancelled"
+)
+
+func (e *NotificationStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = NotificationStatus(s)
+	case string:
+		*e = NotificationStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for NotificationStatus: %T", src)
+	}
+	return nil
+}
+
+type NullNotificationStatus struct {
+	NotificationStatus NotificationStatus `json:"notification_status"`
+	Valid              bool               `json:"valid"` // Valid is true if NotificationStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullNotificationStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.NotificationStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.NotificationStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullNotificationStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.NotificationStatus), nil
+}
+
+type ReaderCategory string
+
+const (
+	ReaderCategoryStudent ReaderCategory = "student"
+	ReaderCategoryStaff   ReaderCategory = "staff"
+	ReaderCategoryGuest   ReaderCategory = "guest"
+	ReaderCategoryChild   ReaderCategory = "child"
+	ReaderCategoryGeneral ReaderCategory = "general"
+)
+
+func (e *ReaderCategory) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ReaderCategory(s)
+	case string:
+		*e = ReaderCategory(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ReaderCategory: %T", src)
+	}
+	return nil
+}
+
+type NullReaderCategory struct {
+	ReaderCategory ReaderCategory `json:"reader_category"`
+	Valid          bool           `json:"valid"` // Valid is true if ReaderCategory is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullReaderCategory) Scan(value interface{}) error {
+	if value == nil {
+		ns.ReaderCategory, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ReaderCategory.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullReaderCategory) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ReaderCategory), nil
+}
+
+type SubjectScheme string
+
+const (
+	SubjectSchemeUdc   SubjectScheme = "udc"
+	SubjectSchemeBbk   SubjectScheme = "bbk"
+	SubjectSchemeDdc   SubjectScheme = "ddc"
+	SubjectSchemeTopic SubjectScheme = "topic"
+	SubjectSchemeGenre SubjectScheme = "genre"
+)
+
+func (e *SubjectScheme) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = SubjectScheme(s)
+	case string:
+		*e = SubjectScheme(s)
+	default:
+		return fmt.Errorf("unsupported scan type for SubjectScheme: %T", src)
+	}
+	return nil
+}
+
+type NullSubjectScheme struct {
+	SubjectScheme SubjectScheme `json:"subject_scheme"`
+	Valid         bool          `json:"valid"` // Valid is true if SubjectScheme is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullSubjectScheme) Scan(value interface{}) error {
+	if value == nil {
+		ns.SubjectScheme, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.SubjectScheme.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullSubjectScheme) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.SubjectScheme), nil
+}
+
 type UserRole string
 
 const (
 	UserRoleAdministrator UserRole = "administrator"
 	UserRoleLibrarian     UserRole = "librarian"
+	UserRoleKiosk         UserRole = "kiosk"
 )
 
 func (e *UserRole) Scan(src interface{}) error {
@@ -142,47 +624,154 @@ func (ns NullVisitType) Value() (driver.Value, error) {
 	return string(ns.VisitType), nil
 }
 
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryStatusDelivered WebhookDeliveryStatus = "delivered"
+	WebhookDeliveryStatusFailed    WebhookDeliveryStatus = "failed"
+)
+
+func (e *WebhookDeliveryStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = WebhookDeliveryStatus(s)
+	case string:
+		*e = WebhookDeliveryStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for WebhookDeliveryStatus: %T", src)
+	}
+	return nil
+}
+
+type NullWebhookDeliveryStatus struct {
+	WebhookDeliveryStatus WebhookDeliveryStatus `json:"webhook_delivery_status"`
+	Valid                 bool                  `json:"valid"` // Valid is true if WebhookDeliveryStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullWebhookDeliveryStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.WebhookDeliveryStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.WebhookDeliveryStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullWebhookDeliveryStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.WebhookDeliveryStatus), nil
+}
+
 type Author struct {
 	ID        uuid.UUID  `json:"id"`
 	FullName  string     `json:"full_name"`
+	SortName  *string    `json:"sort_name"`
+	BirthYear *int       `json:"birth_year"`
+	DeathYear *int       `json:"death_year"`
 	CreatedAt *time.Time `json:"created_at"`
 }
 
+type AuthorNameVariant struct {
+	ID        uuid.UUID      `json:"id"`
+	AuthorID  uuid.UUID      `json:"author_id"`
+	Name      string         `json:"name"`
+	Kind      AuthorNameKind `json:"kind"`
+	CreatedAt *time.Time     `json:"created_at"`
+}
+
 type Book struct {
-	ID              uuid.UUID  `json:"id"`
-	Title           string     `json:"title"`
-	Isbn            *string    `json:"isbn"`
-	PublicationYear *int       `json:"publication_year"`
-	Publisher       *string    `json:"publisher"`
-	TotalCopies     int        `json:"total_copies"`
-	AvailableCopies int        `json:"available_copies"`
-	CreatedAt       *time.Time `json:"created_at"`
+	ID               uuid.UUID  `json:"id"`
+	Title            string     `json:"title"`
+	Isbn             *string    `json:"isbn"`
+	PublicationYear  *int       `json:"publication_year"`
+	Publisher        *string    `json:"publisher"`
+	WorkID           *uuid.UUID `json:"work_id"`
+	SeriesID         *uuid.UUID `json:"series_id"`
+	SeriesVolume     *int       `json:"series_volume"`
+	Language         *string    `json:"language"`
+	OriginalLanguage *string    `json:"original_language"`
+	PageCount        *int       `json:"page_count"`
+	Description      *string    `json:"description"`
+	TotalCopies      int        `json:"total_copies"`
+	AvailableCopies  int        `json:"available_copies"`
+	CreatedAt        *time.Time `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+type BookAttachment struct {
+	ID              uuid.UUID      `json:"id"`
+	BookID          uuid.UUID      `json:"book_id"`
+	Kind            AttachmentKind `json:"kind"`
+	StorageKey      string         `json:"storage_key"`
+	ContentType     string         `json:"content_type"`
+	SizeBytes       int64          `json:"size_bytes"`
+	Sha256          string         `json:"sha256"`
+	Width           *int           `json:"width"`
+	Height          *int           `json:"height"`
+	ThumbnailWidths []int          `json:"thumbnail_widths"`
+	FileName        *string        `json:"file_name"`
+	UploadedBy      *uuid.UUID     `json:"uploaded_by"`
+	CreatedAt       *time.Time     `json:"created_at"`
 }
 
 type BookAuthor struct {
-	BookID   uuid.UUID `json:"book_id"`
-	AuthorID uuid.UUID `json:"author_id"`
+	BookID   uuid.UUID       `json:"book_id"`
+	AuthorID uuid.UUID       `json:"author_id"`
+	Role     ContributorRole `json:"role"`
 }
 
 type BookCopy struct {
-	ID           uuid.UUID      `json:"id"`
-	BookID       uuid.UUID      `json:"book_id"`
-	CopyCode     string         `json:"copy_code"`
-	Status       NullBookStatus `json:"status"`
-	HallID       *uuid.UUID     `json:"hall_id"`
-	LocationInfo *string        `json:"location_info"`
-	CreatedAt    *time.Time     `json:"created_at"`
+	ID             uuid.UUID      `json:"id"`
+	BookID         uuid.UUID      `json:"book_id"`
+	CopyCode       string         `json:"copy_code"`
+	Status         NullBookStatus `json:"status"`
+	HallID         *uuid.UUID     `json:"hall_id"`
+	LocationInfo   *string        `json:"location_info"`
+	OwnerLibrary   *string        `json:"owner_library"`
+	ExternalItemID *string        `json:"external_item_id"`
+	HoldReaderID   *uuid.UUID     `json:"hold_reader_id"`
+	CreatedAt      *time.Time     `json:"created_at"`
 }
 
 type BookIssue struct {
-	ID          uuid.UUID  `json:"id"`
-	ReaderID    uuid.UUID  `json:"reader_id"`
-	BookCopyID  uuid.UUID  `json:"book_copy_id"`
-	IssueDate   *time.Time `json:"issue_date"`
-	DueDate     time.Time  `json:"due_date"`
-	ReturnDate  *time.Time `json:"return_date"`
-	LibrarianID *uuid.UUID `json:"librarian_id"`
-	CreatedAt   *time.Time `json:"created_at"`
+	ID             uuid.UUID  `json:"id"`
+	ReaderID       uuid.UUID  `json:"reader_id"`
+	BookCopyID     uuid.UUID  `json:"book_copy_id"`
+	IssueDate      *time.Time `json:"issue_date"`
+	DueDate        time.Time  `json:"due_date"`
+	ReturnDate     *time.Time `json:"return_date"`
+	RenewalCount   int        `json:"renewal_count"`
+	LibrarianID    *uuid.UUID `json:"librarian_id"`
+	SourceLibrary  *string    `json:"source_library"`
+	ExternalUserID *string    `json:"external_user_id"`
+	CreatedAt      *time.Time `json:"created_at"`
+}
+
+type BookSubject struct {
+	BookID    uuid.UUID `json:"book_id"`
+	SubjectID uuid.UUID `json:"subject_id"`
+}
+
+type BookTombstone struct {
+	BookID    uuid.UUID   `json:"book_id"`
+	HallIds   []uuid.UUID `json:"hall_ids"`
+	DeletedAt time.Time   `json:"deleted_at"`
+}
+
+type CopyStatusHistory struct {
+	ID         uuid.UUID      `json:"id"`
+	CopyID     uuid.UUID      `json:"copy_id"`
+	FromStatus NullBookStatus `json:"from_status"`
+	ToStatus   BookStatus     `json:"to_status"`
+	Reason     string         `json:"reason"`
+	IssueID    *uuid.UUID     `json:"issue_id"`
+	ChangedBy  *uuid.UUID     `json:"changed_by"`
+	ChangedAt  *time.Time     `json:"changed_at"`
 }
 
 type Fine struct {
@@ -197,6 +786,39 @@ type Fine struct {
 	CreatedAt   *time.Time      `json:"created_at"`
 }
 
+type HallDailyRollup struct {
+	HallID             uuid.UUID        `json:"hall_id"`
+	VisitDate          time.Time        `json:"visit_date"`
+	Entries            int              `json:"entries"`
+	UniqueVisitors     int              `json:"unique_visitors"`
+	NewVisitors        int              `json:"new_visitors"`
+	ReturningVisitors  int              `json:"returning_visitors"`
+	SessionsCount      int              `json:"sessions_count"`
+	AvgDwellMinutes    *decimal.Decimal `json:"avg_dwell_minutes"`
+	MedianDwellMinutes *decimal.Decimal `json:"median_dwell_minutes"`
+	PeakOccupancy      int              `json:"peak_occupancy"`
+	RefreshedAt        *time.Time       `json:"refreshed_at"`
+}
+
+type HallHourlyRollup struct {
+	HallID           uuid.UUID  `json:"hall_id"`
+	BucketStart      time.Time  `json:"bucket_start"`
+	Entries          int        `json:"entries"`
+	Exits            int        `json:"exits"`
+	UniqueVisitors   int        `json:"unique_visitors"`
+	PeakOccupancy    int        `json:"peak_occupancy"`
+	ClosingOccupancy int        `json:"closing_occupancy"`
+	RefreshedAt      *time.Time `json:"refreshed_at"`
+}
+
+type HallSeat struct {
+	ID         uuid.UUID  `json:"id"`
+	HallID     uuid.UUID  `json:"hall_id"`
+	SeatNumber string     `json:"seat_number"`
+	IsActive   bool       `json:"is_active"`
+	CreatedAt  *time.Time `json:"created_at"`
+}
+
 type HallVisit struct {
 	ID          uuid.UUID  `json:"id"`
 	ReaderID    uuid.UUID  `json:"reader_id"`
@@ -206,24 +828,160 @@ type HallVisit struct {
 	LibrarianID *uuid.UUID `json:"librarian_id"`
 }
 
+type HallVisitSession struct {
+	EntryID   uuid.UUID  `json:"entry_id"`
+	HallID    uuid.UUID  `json:"hall_id"`
+	ReaderID  uuid.UUID  `json:"reader_id"`
+	EntryTime time.Time  `json:"entry_time"`
+	ExitTime  *time.Time `json:"exit_time"`
+}
+
+type IllRequest struct {
+	ID                uuid.UUID        `json:"id"`
+	SourceLibrary     string           `json:"source_library"`
+	ExternalRequestID string           `json:"external_request_id"`
+	ExternalUserID    *string          `json:"external_user_id"`
+	BookCopyID        uuid.UUID        `json:"book_copy_id"`
+	Status            IllRequestStatus `json:"status"`
+	CreatedAt         *time.Time       `json:"created_at"`
+	UpdatedAt         *time.Time       `json:"updated_at"`
+}
+
+type InventoryMissing struct {
+	SessionID    uuid.UUID  `json:"session_id"`
+	CopyID       uuid.UUID  `json:"copy_id"`
+	Status       BookStatus `json:"status"`
+	MarkedLostAt *time.Time `json:"marked_lost_at"`
+}
+
+type InventoryScan struct {
+	SessionID uuid.UUID  `json:"session_id"`
+	CopyCode  string     `json:"copy_code"`
+	CopyID    *uuid.UUID `json:"copy_id"`
+	ScannedBy *uuid.UUID `json:"scanned_by"`
+	ScannedAt time.Time  `json:"scanned_at"`
+}
+
+type InventorySession struct {
+	ID        uuid.UUID              `json:"id"`
+	HallID    uuid.UUID              `json:"hall_id"`
+	Status    InventorySessionStatus `json:"status"`
+	Note      *string                `json:"note"`
+	StartedBy *uuid.UUID             `json:"started_by"`
+	StartedAt time.Time              `json:"started_at"`
+	ClosedBy  *uuid.UUID             `json:"closed_by"`
+	ClosedAt  *time.Time             `json:"closed_at"`
+}
+
+type MarcRecord struct {
+	Isbn            string    `json:"isbn"`
+	Title           string    `json:"title"`
+	Authors         []string  `json:"authors"`
+	Publisher       *string   `json:"publisher"`
+	PublicationYear *int      `json:"publication_year"`
+	Source          string    `json:"source"`
+	LoadedAt        time.Time `json:"loaded_at"`
+}
+
+type Notification struct {
+	ID            uuid.UUID           `json:"id"`
+	ReaderID      uuid.UUID           `json:"reader_id"`
+	Channel       NotificationChannel `json:"channel"`
+	Kind          NotificationKind    `json:"kind"`
+	IssueID       *uuid.UUID          `json:"issue_id"`
+	Recipient     string              `json:"recipient"`
+	Language      string              `json:"language"`
+	Payload       json.RawMessage     `json:"payload"`
+	DedupeKey     string              `json:"dedupe_key"`
+	Status        NotificationStatus  `json:"status"`
+	Attempts      int                 `json:"attempts"`
+	LastError     *string             `json:"last_error"`
+	NextAttemptAt time.Time           `json:"next_attempt_at"`
+	SentAt        *time.Time          `json:"sent_at"`
+	CreatedAt     *time.Time          `json:"created_at"`
+}
+
+type NotificationOptOut struct {
+	ReaderID  uuid.UUID        `json:"reader_id"`
+	Kind      NotificationKind `json:"kind"`
+	CreatedAt *time.Time       `json:"created_at"`
+}
+
+type OldTicketNumber struct {
+	TicketNumber string     `json:"ticket_number"`
+	ReaderID     uuid.UUID  `json:"reader_id"`
+	ReplacedBy   string     `json:"replaced_by"`
+	Reason       *string    `json:"reason"`
+	LibrarianID  *uuid.UUID `json:"librarian_id"`
+	ReplacedAt   *time.Time `json:"replaced_at"`
+}
+
 type Reader struct {
-	ID               uuid.UUID  `json:"id"`
-	TicketNumber     string     `json:"ticket_number"`
-	FullName         string     `json:"full_name"`
-	Email            *string    `json:"email"`
-	Phone            *string    `json:"phone"`
-	RegistrationDate *time.Time `json:"registration_date"`
-	IsActive         *bool      `json:"is_active"`
-	CreatedAt        *time.Time `json:"created_at"`
+	ID                  uuid.UUID      `json:"id"`
+	TicketNumber        string         `json:"ticket_number"`
+	FullName            string         `json:"full_name"`
+	Email               *string        `json:"email"`
+	Phone               *string        `json:"phone"`
+	RegistrationDate    *time.Time     `json:"registration_date"`
+	CardExpiresAt       *time.Time     `json:"card_expires_at"`
+	Category            ReaderCategory `json:"category"`
+	MembershipExpiresAt *time.Time     `json:"membership_expires_at"`
+	IsActive            *bool          `json:"is_active"`
+	CreatedAt           *time.Time     `json:"created_at"`
+}
+
+type ReaderMerge struct {
+	ID                 uuid.UUID  `json:"id"`
+	SurvivingReaderID  uuid.UUID  `json:"surviving_reader_id"`
+	MergedReaderID     uuid.UUID  `json:"merged_reader_id"`
+	MergedTicketNumber string     `json:"merged_ticket_number"`
+	MovedIssues        int        `json:"moved_issues"`
+	MovedFines         int        `json:"moved_fines"`
+	MovedVisits        int        `json:"moved_visits"`
+	Reason             *string    `json:"reason"`
+	LibrarianID        *uuid.UUID `json:"librarian_id"`
+	MergedAt           *time.Time `json:"merged_at"`
 }
 
 type ReadingHall struct {
-	ID              uuid.UUID  `json:"id"`
-	HallName        string     `json:"hall_name"`
-	Specialization  *string    `json:"specialization"`
-	TotalSeats      int        `json:"total_seats"`
-	CurrentVisitors *int       `json:"current_visitors"`
-	CreatedAt       *time.Time `json:"created_at"`
+	ID                 uuid.UUID  `json:"id"`
+	HallName           string     `json:"hall_name"`
+	HallCode           *string    `json:"hall_code"`
+	Specialization     *string    `json:"specialization"`
+	TotalSeats         int        `json:"total_seats"`
+	CurrentVisitors    *int       `json:"current_visitors"`
+	BookingSlotMinutes int        `json:"booking_slot_minutes"`
+	NoShowMinutes      int        `json:"no_show_minutes"`
+	CreatedAt          *time.Time `json:"created_at"`
+}
+
+type SeatBooking struct {
+	ID          uuid.UUID     `json:"id"`
+	ReaderID    uuid.UUID     `json:"reader_id"`
+	HallID      uuid.UUID     `json:"hall_id"`
+	SeatID      *uuid.UUID    `json:"seat_id"`
+	StartTime   time.Time     `json:"start_time"`
+	EndTime     time.Time     `json:"end_time"`
+	Status      BookingStatus `json:"status"`
+	VisitID     *uuid.UUID    `json:"visit_id"`
+	LibrarianID *uuid.UUID    `json:"librarian_id"`
+	CreatedAt   *time.Time    `json:"created_at"`
+}
+
+type Series struct {
+	ID        uuid.UUID  `json:"id"`
+	Title     string     `json:"title"`
+	Issn      *string    `json:"issn"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+type Subject struct {
+	ID        uuid.UUID     `json:"id"`
+	Scheme    SubjectScheme `json:"scheme"`
+	Code      *string       `json:"code"`
+	Name      string        `json:"name"`
+	ParentID  *uuid.UUID    `json:"parent_id"`
+	CreatedAt *time.Time    `json:"created_at"`
 }
 
 type User struct {
@@ -235,3 +993,44 @@ type User struct {
 	IsActive     *bool      `json:"is_active"`
 	CreatedAt    *time.Time `json:"created_at"`
 }
+
+type WebhookDeliveryAttempt struct {
+	ID             uuid.UUID  `json:"id"`
+	OutboxID       uuid.UUID  `json:"outbox_id"`
+	ResponseStatus *int       `json:"response_status"`
+	ResponseBody   *string    `json:"response_body"`
+	Error          *string    `json:"error"`
+	DurationMs     int        `json:"duration_ms"`
+	AttemptedAt    *time.Time `json:"attempted_at"`
+}
+
+type WebhookOutbox struct {
+	ID             uuid.UUID             `json:"id"`
+	SubscriptionID uuid.UUID             `json:"subscription_id"`
+	EventID        uuid.UUID             `json:"event_id"`
+	EventType      string                `json:"event_type"`
+	SubjectID      *uuid.UUID            `json:"subject_id"`
+	Payload        json.RawMessage       `json:"payload"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	NextAttemptAt  time.Time             `json:"next_attempt_at"`
+	DeliveredAt    *time.Time            `json:"delivered_at"`
+	CreatedAt      *time.Time            `json:"created_at"`
+}
+
+type WebhookSubscription struct {
+	ID          uuid.UUID  `json:"id"`
+	Url         string     `json:"url"`
+	Secret      string     `json:"secret"`
+	Events      []string   `json:"events"`
+	Description *string    `json:"description"`
+	IsActive    bool       `json:"is_active"`
+	CreatedBy   *uuid.UUID `json:"created_by"`
+	CreatedAt   *time.Time `json:"created_at"`
+}
+
+type Work struct {
+	ID        uuid.UUID  `json:"id"`
+	Title     string     `json:"title"`
+	CreatedAt *time.Time `json:"created_at"`
+}
@@ -6,70 +6,252 @@ package postgres
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type Querier interface {
+	AddAuthorNameVariant(ctx context.Context, arg AddAuthorNameVariantParams) (*AuthorNameVariant, error)
 	AddBookAuthor(ctx context.Context, arg AddBookAuthorParams) error
+	AddBookSubject(ctx context.Context, arg AddBookSubjectParams) error
+	AnonymizeMergedReaders(ctx context.Context, readerID uuid.UUID) ([]*AnonymizeMergedReadersRow, error)
+	AnonymizeReader(ctx context.Context, id uuid.UUID) (*AnonymizeReaderRow, error)
+	CancelSeatBooking(ctx context.Context, id uuid.UUID) (*CancelSeatBookingRow, error)
+	CancelStaleNotifications(ctx context.Context) (int64, error)
+	CheckInSeatBooking(ctx context.Context, arg CheckInSeatBookingParams) (*CheckInSeatBookingRow, error)
 	CheckReaderOverdueBooks(ctx context.Context, readerID uuid.UUID) (int64, error)
-	CreateAuthor(ctx context.Context, fullName string) (*CreateAuthorRow, error)
+	ClaimPendingNotifications(ctx context.Context, arg ClaimPendingNotificationsParams) ([]*Notification, error)
+	ClaimPendingWebhooks(ctx context.Context, arg ClaimPendingWebhooksParams) ([]*ClaimPendingWebhooksRow, error)
+	CompareHallsVisits(ctx context.Context, arg CompareHallsVisitsParams) ([]*CompareHallsVisitsRow, error)
+	CopyAuthorBookLinks(ctx context.Context, arg CopyAuthorBookLinksParams) (int64, error)
+	CopyAuthorNameVariants(ctx context.Context, arg CopyAuthorNameVariantsParams) error
+	CountActiveHallSeats(ctx context.Context, hallID uuid.UUID) (int64, error)
+	CountOverlappingHallBookings(ctx context.Context, arg CountOverlappingHallBookingsParams) (int64, error)
+	CountReaderOverlappingBookings(ctx context.Context, arg CountReaderOverlappingBookingsParams) (int64, error)
+	CreateAuthor(ctx context.Context, arg CreateAuthorParams) (*Author, error)
 	CreateBook(ctx context.Context, arg CreateBookParams) (*CreateBookRow, error)
+	CreateBookAttachment(ctx context.Context, arg CreateBookAttachmentParams) (*BookAttachment, error)
 	CreateBookCopy(ctx context.Context, arg CreateBookCopyParams) (*CreateBookCopyRow, error)
+	CreateCopyStatusHistory(ctx context.Context, arg CreateCopyStatusHistoryParams) error
 	CreateFine(ctx context.Context, arg CreateFineParams) (*CreateFineRow, error)
+	CreateHallSeat(ctx context.Context, arg CreateHallSeatParams) (*CreateHallSeatRow, error)
+	CreateHoldAvailableNotification(ctx context.Context, arg CreateHoldAvailableNotificationParams) (*Notification, error)
+	CreateIllCopy(ctx context.Context, arg CreateIllCopyParams) (string, error)
+	CreateIllRequest(ctx context.Context, arg CreateIllRequestParams) (*IllRequest, error)
+	CreateInventorySession(ctx context.Context, arg CreateInventorySessionParams) (*InventorySession, error)
+	CreateNotificationOptOut(ctx context.Context, arg CreateNotificationOptOutParams) error
+	CreateOldTicketNumber(ctx context.Context, arg CreateOldTicketNumberParams) error
 	CreateReader(ctx context.Context, arg CreateReaderParams) (*CreateReaderRow, error)
+	CreateReaderMerge(ctx context.Context, arg CreateReaderMergeParams) (*ReaderMerge, error)
 	CreateReadingHall(ctx context.Context, arg CreateReadingHallParams) (*CreateReadingHallRow, error)
+	CreateSeatBooking(ctx context.Context, arg CreateSeatBookingParams) (*CreateSeatBookingRow, error)
+	CreateSeries(ctx context.Context, arg CreateSeriesParams) (*Series, error)
+	CreateSubject(ctx context.Context, arg CreateSubjectParams) (*Subject, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (*CreateUserRow, error)
+	CreateWebhookOutboxEntries(ctx context.Context, arg CreateWebhookOutboxEntriesParams) (int64, error)
+	CreateWebhookSubscription(ctx context.Context, arg CreateWebhookSubscriptionParams) (*WebhookSubscription, error)
+	CreateWork(ctx context.Context, title string) (*Work, error)
 	DeactivateReader(ctx context.Context, id uuid.UUID) error
 	DeactivateUser(ctx context.Context, id uuid.UUID) error
+	DeleteAuthor(ctx context.Context, id uuid.UUID) (int64, error)
+	DeleteAuthorNameVariant(ctx context.Context, arg DeleteAuthorNameVariantParams) (int64, error)
+	DeleteBookAttachment(ctx context.Context, arg DeleteBookAttachmentParams) (*BookAttachment, error)
+	DeleteBookCovers(ctx context.Context, bookID uuid.UUID) ([]*BookAttachment, error)
+	DeleteMergedAuthor(ctx context.Context, id uuid.UUID) error
+	DeleteReaderNotificationOptOuts(ctx context.Context, readerID uuid.UUID) error
+	DeleteReaderNotifications(ctx context.Context, readerID uuid.UUID) error
+	DeleteReaderOldTicketNumbers(ctx context.Context, readerID uuid.UUID) error
+	DeleteReaderWebhookEvents(ctx context.Context, readerIds []uuid.UUID) error
+	DeleteSeries(ctx context.Context, id uuid.UUID) (int64, error)
+	DeleteSubject(ctx context.Context, id uuid.UUID) (int64, error)
+	DeleteWebhookSubscription(ctx context.Context, id uuid.UUID) (int64, error)
+	DeleteWork(ctx context.Context, id uuid.UUID) (int64, error)
+	EnqueueDueSoonNotifications(ctx context.Context, arg EnqueueDueSoonNotificationsParams) (int64, error)
+	EnqueueOverdueNotifications(ctx context.Context, arg EnqueueOverdueNotificationsParams) (int64, error)
+	FillAuthorAuthorityData(ctx context.Context, arg FillAuthorAuthorityDataParams) (*Author, error)
+	FindDuplicateAuthors(ctx context.Context, arg FindDuplicateAuthorsParams) ([]*FindDuplicateAuthorsRow, error)
+	FindDuplicateReaders(ctx context.Context, arg FindDuplicateReadersParams) ([]*FindDuplicateReadersRow, error)
+	FinishInventorySession(ctx context.Context, arg FinishInventorySessionParams) (*InventorySession, error)
+	GetActiveIllRequestForCopy(ctx context.Context, bookCopyID uuid.UUID) (*IllRequest, error)
+	GetActiveIssueByCopyCode(ctx context.Context, copyCode string) (*GetActiveIssueByCopyCodeRow, error)
 	GetActiveReaders(ctx context.Context) ([]*GetActiveReadersRow, error)
-	GetAllAuthors(ctx context.Context) ([]*GetAllAuthorsRow, error)
+	GetAllAuthors(ctx context.Context) ([]*Author, error)
 	GetAllBooks(ctx context.Context) ([]*GetAllBooksRow, error)
 	GetAllReadingHalls(ctx context.Context) ([]*GetAllReadingHallsRow, error)
 	GetAllUsers(ctx context.Context) ([]*GetAllUsersRow, error)
 	GetAuthorBooks(ctx context.Context, authorID uuid.UUID) ([]*GetAuthorBooksRow, error)
-	GetAuthorById(ctx context.Context, id uuid.UUID) (*GetAuthorByIdRow, error)
+	GetAuthorById(ctx context.Context, id uuid.UUID) (*Author, error)
+	GetAuthorNameVariants(ctx context.Context, authorID uuid.UUID) ([]*AuthorNameVariant, error)
 	GetAvailableBookCopy(ctx context.Context, copyCode string) (*GetAvailableBookCopyRow, error)
+	GetAvailableCopyByIsbn(ctx context.Context, isbn *string) (string, error)
+	GetBookAttachment(ctx context.Context, arg GetBookAttachmentParams) (*BookAttachment, error)
+	GetBookAttachments(ctx context.Context, bookID uuid.UUID) ([]*BookAttachment, error)
 	GetBookAuthors(ctx context.Context, bookID uuid.UUID) ([]*GetBookAuthorsRow, error)
 	GetBookById(ctx context.Context, id uuid.UUID) (*GetBookByIdRow, error)
+	GetBookByIsbns(ctx context.Context, isbns []string) (*GetBookByIsbnsRow, error)
 	GetBookCopiesByBookId(ctx context.Context, bookID uuid.UUID) ([]*GetBookCopiesByBookIdRow, error)
 	GetBookCopiesByHall(ctx context.Context, hallID *uuid.UUID) ([]*GetBookCopiesByHallRow, error)
+	GetBookCopiesForLabels(ctx context.Context, copyIds []uuid.UUID) ([]*GetBookCopiesForLabelsRow, error)
 	GetBookCopyByCode(ctx context.Context, copyCode string) (*GetBookCopyByCodeRow, error)
 	GetBookCopyById(ctx context.Context, copyID uuid.UUID) (*GetBookCopyByIdRow, error)
+	GetBookCopyCountMismatches(ctx context.Context) ([]*GetBookCopyCountMismatchesRow, error)
+	GetBookCover(ctx context.Context, bookID uuid.UUID) (*BookAttachment, error)
+	GetBookIdByIsbn(ctx context.Context, isbn *string) (uuid.UUID, error)
+	GetBookSubjects(ctx context.Context, bookID uuid.UUID) ([]*Subject, error)
+	GetBooksContributors(ctx context.Context, bookIds []uuid.UUID) ([]*GetBooksContributorsRow, error)
 	GetBooksToReturn(ctx context.Context) ([]*GetBooksToReturnRow, error)
+	GetCopyStatusHistory(ctx context.Context, copyID uuid.UUID) ([]*GetCopyStatusHistoryRow, error)
 	GetDailyVisitStats(ctx context.Context, arg GetDailyVisitStatsParams) ([]*GetDailyVisitStatsRow, error)
+	GetExpiringMemberships(ctx context.Context, untilDate time.Time) ([]*GetExpiringMembershipsRow, error)
+	GetFineById(ctx context.Context, id uuid.UUID) (*GetFineByIdRow, error)
+	GetFreeHallSeat(ctx context.Context, arg GetFreeHallSeatParams) (*GetFreeHallSeatRow, error)
+	GetHallBookings(ctx context.Context, arg GetHallBookingsParams) ([]*GetHallBookingsRow, error)
+	GetHallDwellStats(ctx context.Context, arg GetHallDwellStatsParams) (*GetHallDwellStatsRow, error)
+	GetHallOccupancySeries(ctx context.Context, arg GetHallOccupancySeriesParams) ([]*GetHallOccupancySeriesRow, error)
+	GetHallSeatByNumber(ctx context.Context, arg GetHallSeatByNumberParams) (*GetHallSeatByNumberRow, error)
+	GetHallSeats(ctx context.Context, hallID uuid.UUID) ([]*GetHallSeatsRow, error)
+	GetHallVisitHeatmap(ctx context.Context, arg GetHallVisitHeatmapParams) ([]*GetHallVisitHeatmapRow, error)
+	GetHallVisitorSplit(ctx context.Context, arg GetHallVisitorSplitParams) ([]*GetHallVisitorSplitRow, error)
 	GetHallsDashboard(ctx context.Context) ([]*GetHallsDashboardRow, error)
 	GetHourlyVisitStats(ctx context.Context, arg GetHourlyVisitStatsParams) ([]*GetHourlyVisitStatsRow, error)
+	GetIllCopyByExternalId(ctx context.Context, arg GetIllCopyByExternalIdParams) (string, error)
+	GetIllItem(ctx context.Context, copyCode string) (*GetIllItemRow, error)
+	GetIllRequest(ctx context.Context, arg GetIllRequestParams) (*IllRequest, error)
+	GetInventoryLoanMismatches(ctx context.Context, sessionID uuid.UUID) ([]*GetInventoryLoanMismatchesRow, error)
+	GetInventoryMissing(ctx context.Context, sessionID uuid.UUID) ([]*GetInventoryMissingRow, error)
+	GetInventorySessionById(ctx context.Context, id uuid.UUID) (*GetInventorySessionByIdRow, error)
+	GetInventorySessions(ctx context.Context, arg GetInventorySessionsParams) ([]*GetInventorySessionsRow, error)
+	GetInventoryUnexpected(ctx context.Context, sessionID uuid.UUID) ([]*GetInventoryUnexpectedRow, error)
+	GetInventoryUnscanned(ctx context.Context, sessionID uuid.UUID) ([]*GetInventoryUnscannedRow, error)
+	GetLatestHourlyRollup(ctx context.Context) (time.Time, error)
+	GetLoansForOverdueWebhook(ctx context.Context, limitCount int32) ([]uuid.UUID, error)
+	GetMarcRecordByIsbn(ctx context.Context, isbn string) (*MarcRecord, error)
+	GetOaiEarliestDatestamp(ctx context.Context) (time.Time, error)
+	GetOaiRecord(ctx context.Context, id uuid.UUID) (*GetOaiRecordRow, error)
+	GetOldTicketNumber(ctx context.Context, ticketNumber string) (*GetOldTicketNumberRow, error)
 	GetOrCreateAuthor(ctx context.Context, fullName string) (*GetOrCreateAuthorRow, error)
 	GetOverdueBooks(ctx context.Context) ([]*GetOverdueBooksRow, error)
+	GetPublicAuthorBooks(ctx context.Context, authorID uuid.UUID) ([]*GetPublicAuthorBooksRow, error)
+	GetPublicBook(ctx context.Context, id uuid.UUID) (*GetPublicBookRow, error)
+	GetPublicBookAvailability(ctx context.Context, bookID uuid.UUID) ([]*GetPublicBookAvailabilityRow, error)
+	GetPublicWorkEditions(ctx context.Context, arg GetPublicWorkEditionsParams) ([]*GetPublicWorkEditionsRow, error)
 	GetReaderActiveBooks(ctx context.Context, readerID uuid.UUID) ([]*GetReaderActiveBooksRow, error)
+	GetReaderBookings(ctx context.Context, readerID uuid.UUID) ([]*GetReaderBookingsRow, error)
 	GetReaderById(ctx context.Context, id uuid.UUID) (*GetReaderByIdRow, error)
 	GetReaderByTicketNumber(ctx context.Context, ticketNumber string) (*GetReaderByTicketNumberRow, error)
 	GetReaderFines(ctx context.Context, readerID uuid.UUID) ([]*GetReaderFinesRow, error)
+	GetReaderIssueCopy(ctx context.Context, arg GetReaderIssueCopyParams) (*GetReaderIssueCopyRow, error)
+	GetReaderIssueHistory(ctx context.Context, readerID uuid.UUID) ([]*GetReaderIssueHistoryRow, error)
+	GetReaderLoanStatus(ctx context.Context, id uuid.UUID) (*GetReaderLoanStatusRow, error)
+	GetReaderMerges(ctx context.Context, readerID uuid.UUID) ([]*GetReaderMergesRow, error)
+	GetReaderNotificationOptOuts(ctx context.Context, readerID uuid.UUID) ([]NotificationKind, error)
+	GetReaderNotificationSettings(ctx context.Context, id uuid.UUID) (*GetReaderNotificationSettingsRow, error)
+	GetReaderNotifications(ctx context.Context, arg GetReaderNotificationsParams) ([]*Notification, error)
+	GetReaderOldTicketNumbers(ctx context.Context, readerID uuid.UUID) ([]*GetReaderOldTicketNumbersRow, error)
+	GetReaderOpenObligations(ctx context.Context, readerID uuid.UUID) (*GetReaderOpenObligationsRow, error)
+	GetReaderPortalCredentials(ctx context.Context, ticketNumber string) (*GetReaderPortalCredentialsRow, error)
 	GetReaderUnpaidFinesTotal(ctx context.Context, readerID uuid.UUID) (interface{}, error)
 	GetReaderVisitHistory(ctx context.Context, readerID uuid.UUID) ([]*GetReaderVisitHistoryRow, error)
+	GetReadersForAnonymization(ctx context.Context, arg GetReadersForAnonymizationParams) ([]uuid.UUID, error)
 	GetReadingHallById(ctx context.Context, id uuid.UUID) (*GetReadingHallByIdRow, error)
 	GetRecentBookOperations(ctx context.Context, arg GetRecentBookOperationsParams) ([]*GetRecentBookOperationsRow, error)
 	GetRecentHallVisits(ctx context.Context, arg GetRecentHallVisitsParams) ([]*GetRecentHallVisitsRow, error)
+	GetReservedBookCopy(ctx context.Context, copyCode string) (*GetReservedBookCopyRow, error)
+	GetSeatBookingById(ctx context.Context, id uuid.UUID) (*GetSeatBookingByIdRow, error)
+	GetSeriesBooks(ctx context.Context, seriesID uuid.UUID) ([]*GetSeriesBooksRow, error)
+	GetSeriesById(ctx context.Context, id uuid.UUID) (*Series, error)
+	GetSubjectById(ctx context.Context, id uuid.UUID) (*Subject, error)
+	GetSubjectTree(ctx context.Context, arg GetSubjectTreeParams) ([]*GetSubjectTreeRow, error)
+	GetUnmarkedInventoryMissing(ctx context.Context, arg GetUnmarkedInventoryMissingParams) ([]uuid.UUID, error)
 	GetUnpaidFines(ctx context.Context) ([]*GetUnpaidFinesRow, error)
 	GetUserById(ctx context.Context, id uuid.UUID) (*GetUserByIdRow, error)
 	GetUserByUsername(ctx context.Context, username string) (*GetUserByUsernameRow, error)
+	GetWebhookDeliveries(ctx context.Context, arg GetWebhookDeliveriesParams) ([]*GetWebhookDeliveriesRow, error)
+	GetWebhookDelivery(ctx context.Context, id uuid.UUID) (*WebhookOutbox, error)
+	GetWebhookDeliveryAttempts(ctx context.Context, outboxID uuid.UUID) ([]*WebhookDeliveryAttempt, error)
+	GetWebhookFine(ctx context.Context, id uuid.UUID) (*GetWebhookFineRow, error)
+	GetWebhookHallVisit(ctx context.Context, id uuid.UUID) (*GetWebhookHallVisitRow, error)
+	GetWebhookLoan(ctx context.Context, id uuid.UUID) (*GetWebhookLoanRow, error)
+	GetWebhookReader(ctx context.Context, id uuid.UUID) (*GetWebhookReaderRow, error)
+	GetWebhookSubscription(ctx context.Context, id uuid.UUID) (*GetWebhookSubscriptionRow, error)
+	GetWebhookSubscriptions(ctx context.Context) ([]*GetWebhookSubscriptionsRow, error)
+	GetWorkById(ctx context.Context, id uuid.UUID) (*Work, error)
+	GetWorkEditions(ctx context.Context, workID uuid.UUID) ([]*GetWorkEditionsRow, error)
+	IsSubjectInSubtree(ctx context.Context, arg IsSubjectInSubtreeParams) (bool, error)
 	IssueBook(ctx context.Context, arg IssueBookParams) (*IssueBookRow, error)
+	ListOaiRecords(ctx context.Context, arg ListOaiRecordsParams) ([]*ListOaiRecordsRow, error)
+	LockAuthor(ctx context.Context, id uuid.UUID) (*Author, error)
+	LockAuthorName(ctx context.Context, fullName string) error
+	LockBookCopyStatus(ctx context.Context, copyID uuid.UUID) (NullBookStatus, error)
+	LockInventorySession(ctx context.Context, id uuid.UUID) (*InventorySession, error)
+	LockReader(ctx context.Context, id uuid.UUID) (*LockReaderRow, error)
+	LockReaderBookIssue(ctx context.Context, arg LockReaderBookIssueParams) (*LockReaderBookIssueRow, error)
+	LockReadingHall(ctx context.Context, id uuid.UUID) (int, error)
+	MarkInventoryMissingLost(ctx context.Context, arg MarkInventoryMissingLostParams) error
+	MarkNotificationFailed(ctx context.Context, arg MarkNotificationFailedParams) error
+	MarkNotificationSent(ctx context.Context, id uuid.UUID) error
+	MarkReaderMerged(ctx context.Context, id uuid.UUID) error
+	MarkWebhookDelivered(ctx context.Context, id uuid.UUID) error
+	MarkWebhookFailed(ctx context.Context, arg MarkWebhookFailedParams) error
+	MoveReaderBookIssues(ctx context.Context, arg MoveReaderBookIssuesParams) (int64, error)
+	MoveReaderFines(ctx context.Context, arg MoveReaderFinesParams) (int64, error)
+	MoveReaderHallVisits(ctx context.Context, arg MoveReaderHallVisitsParams) (int64, error)
+	MoveReaderNotificationOptOuts(ctx context.Context, arg MoveReaderNotificationOptOutsParams) error
+	MoveReaderOldTicketNumbers(ctx context.Context, arg MoveReaderOldTicketNumbersParams) error
+	MoveReaderPendingNotifications(ctx context.Context, arg MoveReaderPendingNotificationsParams) error
+	MoveReaderSeatBookings(ctx context.Context, arg MoveReaderSeatBookingsParams) error
+	MoveReaderVisitSessions(ctx context.Context, arg MoveReaderVisitSessionsParams) error
+	NextCopyCodeSequence(ctx context.Context) (int64, error)
+	NextTicketNumberSequence(ctx context.Context) (int64, error)
 	PayFine(ctx context.Context, fineID uuid.UUID) (*PayFineRow, error)
+	RecordInventoryScans(ctx context.Context, arg RecordInventoryScansParams) ([]*RecordInventoryScansRow, error)
+	RecordWebhookAttempt(ctx context.Context, arg RecordWebhookAttemptParams) error
+	RecountAllBookCopies(ctx context.Context) (int64, error)
+	RefreshHallDailyRollups(ctx context.Context, since time.Time) (int64, error)
+	RefreshHallHourlyRollups(ctx context.Context, since time.Time) (int64, error)
+	RefreshHallVisitSessions(ctx context.Context, since time.Time) (int64, error)
 	RegisterHallEntry(ctx context.Context, arg RegisterHallEntryParams) (*RegisterHallEntryRow, error)
 	RegisterHallExit(ctx context.Context, arg RegisterHallExitParams) (*RegisterHallExitRow, error)
+	ReissueReaderTicket(ctx context.Context, arg ReissueReaderTicketParams) (*ReissueReaderTicketRow, error)
+	ReleaseNoShowBookings(ctx context.Context) (int64, error)
 	RemoveBookAuthor(ctx context.Context, arg RemoveBookAuthorParams) error
+	RemoveBookSubject(ctx context.Context, arg RemoveBookSubjectParams) error
+	RenewBookIssue(ctx context.Context, arg RenewBookIssueParams) (*RenewBookIssueRow, error)
+	RenewReaderMembership(ctx context.Context, arg RenewReaderMembershipParams) (*RenewReaderMembershipRow, error)
+	RetryWebhookDelivery(ctx context.Context, id uuid.UUID) (*RetryWebhookDeliveryRow, error)
 	ReturnBook(ctx context.Context, bookCopyID uuid.UUID) (*ReturnBookRow, error)
-	SearchAuthors(ctx context.Context, searchTerm *string) ([]*SearchAuthorsRow, error)
+	RotateWebhookSecret(ctx context.Context, arg RotateWebhookSecretParams) (*RotateWebhookSecretRow, error)
+	ScrubMergedTicketNumbers(ctx context.Context, arg ScrubMergedTicketNumbersParams) error
+	SearchAuthors(ctx context.Context, searchTerm *string) ([]*Author, error)
 	SearchBooks(ctx context.Context, arg SearchBooksParams) ([]*SearchBooksRow, error)
+	SearchPublicAuthors(ctx context.Context, arg SearchPublicAuthorsParams) ([]*SearchPublicAuthorsRow, error)
+	SearchPublicBooks(ctx context.Context, arg SearchPublicBooksParams) ([]*SearchPublicBooksRow, error)
 	SearchReaders(ctx context.Context, arg SearchReadersParams) ([]*SearchReadersRow, error)
+	SearchSeries(ctx context.Context, query string) ([]*Series, error)
+	SearchSruBooks(ctx context.Context, arg SearchSruBooksParams) ([]*SearchSruBooksRow, error)
+	SearchSubjects(ctx context.Context, arg SearchSubjectsParams) ([]*Subject, error)
+	SearchWorks(ctx context.Context, query string) ([]*Work, error)
+	SetHallSeatActive(ctx context.Context, arg SetHallSeatActiveParams) (*SetHallSeatActiveRow, error)
+	SetIllRequestStatus(ctx context.Context, arg SetIllRequestStatusParams) error
+	SetReaderNotificationSettings(ctx context.Context, arg SetReaderNotificationSettingsParams) error
+	SetReaderPin(ctx context.Context, arg SetReaderPinParams) error
+	ShareLockInventorySession(ctx context.Context, id uuid.UUID) (*InventorySession, error)
+	SnapshotInventoryMissing(ctx context.Context, sessionID uuid.UUID) (int64, error)
+	SuggestHallsForBook(ctx context.Context, arg SuggestHallsForBookParams) ([]*SuggestHallsForBookRow, error)
+	UpdateAuthor(ctx context.Context, arg UpdateAuthorParams) (*Author, error)
 	UpdateBook(ctx context.Context, arg UpdateBookParams) (*UpdateBookRow, error)
-	UpdateBookCopies(ctx context.Context, arg UpdateBookCopiesParams) error
 	UpdateBookCopyStatus(ctx context.Context, arg UpdateBookCopyStatusParams) error
 	UpdateHallVisitorCount(ctx context.Context, arg UpdateHallVisitorCountParams) error
 	UpdateReader(ctx context.Context, arg UpdateReaderParams) (*UpdateReaderRow, error)
+	UpdateReaderContacts(ctx context.Context, arg UpdateReaderContactsParams) (*UpdateReaderContactsRow, error)
 	UpdateReadingHall(ctx context.Context, arg UpdateReadingHallParams) (*UpdateReadingHallRow, error)
+	UpdateSeries(ctx context.Context, arg UpdateSeriesParams) (*Series, error)
+	UpdateSubject(ctx context.Context, arg UpdateSubjectParams) (*Subject, error)
 	UpdateUser(ctx context.Context, arg UpdateUserParams) (*UpdateUserRow, error)
+	UpdateWebhookSubscription(ctx context.Context, arg UpdateWebhookSubscriptionParams) (*UpdateWebhookSubscriptionRow, error)
+	UpdateWork(ctx context.Context, arg UpdateWorkParams) (*Work, error)
+	UpsertMarcRecord(ctx context.Context, arg UpsertMarcRecordParams) error
 }
 
 var _ Querier = (*Queries)(nil)
@@ -12,6 +12,81 @@ import (
 	"github.com/google/uuid"
 )
 
+const anonymizeMergedReaders = `-- name: AnonymizeMergedReaders :many
+-- Дубли, объединенные с читателем, в том числе через цепочку объединений, хранят его прежние данные
+WITH RECURSIVE merged AS (
+    SELECT m.merged_reader_id AS id
+    FROM reader_merges m
+    WHERE m.surviving_reader_id = $1
+    UNION
+    SELECT m.merged_reader_id
+    FROM reader_merges m
+    JOIN merged ON m.surviving_reader_id = merged.id
+)
+UPDATE readers r
+SET ticket_number = 'ANON' || left(replace(r.id::text, '-', ''), 16),
+    full_name = 'Анонимный читатель',
+    email = NULL,
+    phone = NULL,
+    pin_hash = NULL,
+    is_active = false,
+    anonymized_at = CURRENT_TIMESTAMP
+FROM merged
+WHERE r.id = merged.id AND r.anonymized_at IS NULL
+RETURNING r.id, r.ticket_number
+`
+
+type AnonymizeMergedReadersRow struct {
+	ID           uuid.UUID `json:"id"`
+	TicketNumber string    `json:"ticket_number"`
+}
+
+func (q *Queries) AnonymizeMergedReaders(ctx context.Context, readerID uuid.UUID) ([]*AnonymizeMergedReadersRow, error) {
+	rows, err := q.db.Query(ctx, anonymizeMergedReaders, readerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*AnonymizeMergedReadersRow{}
+	for rows.Next() {
+		var i AnonymizeMergedReadersRow
+		if err := rows.Scan(&i.ID, &i.TicketNumber); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const anonymizeReader = `-- name: AnonymizeReader :one
+UPDATE readers
+SET ticket_number = 'ANON' || left(replace(id::text, '-', ''), 16),
+    full_name = 'Анонимный читатель',
+    email = NULL,
+    phone = NULL,
+    pin_hash = NULL,
+    is_active = false,
+    anonymized_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND anonymized_at IS NULL
+RETURNING id, ticket_number, anonymized_at
+`
+
+type AnonymizeReaderRow struct {
+	ID           uuid.UUID  `json:"id"`
+	TicketNumber string     `json:"ticket_number"`
+	AnonymizedAt *time.Time `json:"anonymized_at"`
+}
+
+func (q *Queries) AnonymizeReader(ctx context.Context, id uuid.UUID) (*AnonymizeReaderRow, error) {
+	row := q.db.QueryRow(ctx, anonymizeReader, id)
+	var i AnonymizeReaderRow
+	err := row.Scan(&i.ID, &i.TicketNumber, &i.AnonymizedAt)
+	return &i, err
+}
+
 const checkReaderOverdueBooks = `-- name: CheckReaderOverdueBooks :one
 SELECT COUNT(*) as overdue_books
 FROM book_issues bi
@@ -27,23 +102,53 @@ func (q *Queries) CheckReaderOverdueBooks(ctx context.Context, readerID uuid.UUI
 	return overdue_books, err
 }
 
+const createOldTicketNumber = `-- name: CreateOldTicketNumber :exec
+INSERT INTO old_ticket_numbers (ticket_number, reader_id, replaced_by, reason, librarian_id)
+VALUES ($1, $2, $3, $4, $5)
+`
+
+type CreateOldTicketNumberParams struct {
+	TicketNumber string     `json:"ticket_number"`
+	ReaderID     uuid.UUID  `json:"reader_id"`
+	ReplacedBy   string     `json:"replaced_by"`
+	Reason       *string    `json:"reason"`
+	LibrarianID  *uuid.UUID `json:"librarian_id"`
+}
+
+func (q *Queries) CreateOldTicketNumber(ctx context.Context, arg CreateOldTicketNumberParams) error {
+	_, err := q.db.Exec(ctx, createOldTicketNumber,
+		arg.TicketNumber,
+		arg.ReaderID,
+		arg.ReplacedBy,
+		arg.Reason,
+		arg.LibrarianID,
+	)
+	return err
+}
+
 const createReader = `-- name: CreateReader :one
-INSERT INTO readers (ticket_number, full_name, email, phone)
-VALUES ($1, $2, $3, $4)
-RETURNING id, ticket_number, created_at
+INSERT INTO readers (ticket_number, full_name, email, phone, card_expires_at, category, membership_expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, ticket_number, card_expires_at, category, membership_expires_at, created_at
 `
 
 type CreateReaderParams struct {
-	TicketNumber string  `json:"ticket_number"`
-	FullName     string  `json:"full_name"`
-	Email        *string `json:"email"`
-	Phone        *string `json:"phone"`
+	TicketNumber        string         `json:"ticket_number"`
+	FullName            string         `json:"full_name"`
+	Email               *string        `json:"email"`
+	Phone               *string        `json:"phone"`
+	CardExpiresAt       *time.Time     `json:"card_expires_at"`
+	Category            ReaderCategory `json:"category"`
+	MembershipExpiresAt *time.Time     `json:"membership_expires_at"`
 }
 
 type CreateReaderRow struct {
-	ID           uuid.UUID  `json:"id"`
-	TicketNumber string     `json:"ticket_number"`
-	CreatedAt    *time.Time `json:"created_at"`
+	ID                  uuid.UUID      `json:"id"`
+	TicketNumber        string         `json:"ticket_number"`
+	CardExpiresAt       *time.Time     `json:"card_expires_at"`
+	Category            ReaderCategory `json:"category"`
+	MembershipExpiresAt *time.Time     `json:"membership_expires_at"`
+	CreatedAt           *time.Time     `json:"created_at"`
 }
 
 func (q *Queries) CreateReader(ctx context.Context, arg CreateReaderParams) (*CreateReaderRow, error) {
@@ -52,9 +157,19 @@ func (q *Queries) CreateReader(ctx context.Context, arg CreateReaderParams) (*Cr
 		arg.FullName,
 		arg.Email,
 		arg.Phone,
+		arg.CardExpiresAt,
+		arg.Category,
+		arg.MembershipExpiresAt,
 	)
 	var i CreateReaderRow
-	err := row.Scan(&i.ID, &i.TicketNumber, &i.CreatedAt)
+	err := row.Scan(
+		&i.ID,
+		&i.TicketNumber,
+		&i.CardExpiresAt,
+		&i.Category,
+		&i.MembershipExpiresAt,
+		&i.CreatedAt,
+	)
 	return &i, err
 }
 
@@ -69,6 +184,107 @@ func (q *Queries) DeactivateReader(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const deleteReaderOldTicketNumbers = `-- name: DeleteReaderOldTicketNumbers :exec
+DELETE FROM old_ticket_numbers
+WHERE reader_id = $1
+`
+
+func (q *Queries) DeleteReaderOldTicketNumbers(ctx context.Context, readerID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteReaderOldTicketNumbers, readerID)
+	return err
+}
+
+const findDuplicateReaders = `-- name: FindDuplicateReaders :many
+WITH pairs AS (
+    SELECT a.id as reader_id, b.id as candidate_id,
+           similarity(normalize_person_name(a.full_name), normalize_person_name(b.full_name))::float8 as name_score,
+           COALESCE(lower(a.email) = lower(b.email), false) as email_match,
+           COALESCE(normalize_phone(a.phone) = normalize_phone(b.phone), false) as phone_match
+    FROM readers a
+    JOIN readers b ON b.id <> a.id
+        AND (normalize_person_name(a.full_name) % normalize_person_name(b.full_name)
+            OR lower(a.email) = lower(b.email)
+            OR normalize_phone(a.phone) = normalize_phone(b.phone))
+    WHERE a.is_active = true
+      AND b.is_active = true
+      AND (($1::uuid IS NULL AND a.id < b.id) OR a.id = $1::uuid)
+), scored AS (
+    SELECT p.*,
+           (p.name_score * 0.6
+               + CASE WHEN p.email_match THEN 0.25 ELSE 0 END
+               + CASE WHEN p.phone_match THEN 0.15 ELSE 0 END)::float8 as score
+    FROM pairs p
+)
+SELECT s.reader_id, a.ticket_number, a.full_name, a.email, a.phone,
+       s.candidate_id, b.ticket_number as candidate_ticket_number, b.full_name as candidate_full_name,
+       b.email as candidate_email, b.phone as candidate_phone,
+       s.name_score, s.email_match, s.phone_match, s.score
+FROM scored s
+JOIN readers a ON a.id = s.reader_id
+JOIN readers b ON b.id = s.candidate_id
+WHERE s.score >= $2::float8
+ORDER BY s.score DESC, a.full_name
+LIMIT $3
+`
+
+type FindDuplicateReadersParams struct {
+	ReaderID   *uuid.UUID `json:"reader_id"`
+	MinScore   float64    `json:"min_score"`
+	LimitCount int32      `json:"limit_count"`
+}
+
+type FindDuplicateReadersRow struct {
+	ReaderID              uuid.UUID `json:"reader_id"`
+	TicketNumber          string    `json:"ticket_number"`
+	FullName              string    `json:"full_name"`
+	Email                 *string   `json:"email"`
+	Phone                 *string   `json:"phone"`
+	CandidateID           uuid.UUID `json:"candidate_id"`
+	CandidateTicketNumber string    `json:"candidate_ticket_number"`
+	CandidateFullName     string    `json:"candidate_full_name"`
+	CandidateEmail        *string   `json:"candidate_email"`
+	CandidatePhone        *string   `json:"candidate_phone"`
+	NameScore             float64   `json:"name_score"`
+	EmailMatch            bool      `json:"email_match"`
+	PhoneMatch            bool      `json:"phone_match"`
+	Score                 float64   `json:"score"`
+}
+
+func (q *Queries) FindDuplicateReaders(ctx context.Context, arg FindDuplicateReadersParams) ([]*FindDuplicateReadersRow, error) {
+	rows, err := q.db.Query(ctx, findDuplicateReaders, arg.ReaderID, arg.MinScore, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*FindDuplicateReadersRow{}
+	for rows.Next() {
+		var i FindDuplicateReadersRow
+		if err := rows.Scan(
+			&i.ReaderID,
+			&i.TicketNumber,
+			&i.FullName,
+			&i.Email,
+			&i.Phone,
+			&i.CandidateID,
+			&i.CandidateTicketNumber,
+			&i.CandidateFullName,
+			&i.CandidateEmail,
+			&i.CandidatePhone,
+			&i.NameScore,
+			&i.EmailMatch,
+			&i.PhoneMatch,
+			&i.Score,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getActiveReaders = `-- name: GetActiveReaders :many
 SELECT id, ticket_number, full_name, email, phone, registration_date
 FROM readers
@@ -112,20 +328,99 @@ func (q *Queries) GetActiveReaders(ctx context.Context) ([]*GetActiveReadersRow,
 	return items, nil
 }
 
+const getExpiringMemberships = `-- name: GetExpiringMemberships :many
+SELECT id, ticket_number, full_name, email, phone, category, membership_expires_at,
+       (membership_expires_at - CURRENT_DATE)::int as days_left
+FROM readers
+WHERE is_active = true
+  AND membership_expires_at BETWEEN CURRENT_DATE AND $1::date
+ORDER BY membership_expires_at, full_name
+`
+
+type GetExpiringMembershipsRow struct {
+	ID                  uuid.UUID      `json:"id"`
+	TicketNumber        string         `json:"ticket_number"`
+	FullName            string         `json:"full_name"`
+	Email               *string        `json:"email"`
+	Phone               *string        `json:"phone"`
+	Category            ReaderCategory `json:"category"`
+	MembershipExpiresAt *time.Time     `json:"membership_expires_at"`
+	DaysLeft            int            `json:"days_left"`
+}
+
+func (q *Queries) GetExpiringMemberships(ctx context.Context, untilDate time.Time) ([]*GetExpiringMembershipsRow, error) {
+	rows, err := q.db.Query(ctx, getExpiringMemberships, untilDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*GetExpiringMembershipsRow{}
+	for rows.Next() {
+		var i GetExpiringMembershipsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TicketNumber,
+			&i.FullName,
+			&i.Email,
+			&i.Phone,
+			&i.Category,
+			&i.MembershipExpiresAt,
+			&i.DaysLeft,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOldTicketNumber = `-- name: GetOldTicketNumber :one
+SELECT ticket_number, reader_id, replaced_by, replaced_at
+FROM old_ticket_numbers
+WHERE ticket_number = $1
+`
+
+type GetOldTicketNumberRow struct {
+	TicketNumber string     `json:"ticket_number"`
+	ReaderID     uuid.UUID  `json:"reader_id"`
+	ReplacedBy   string     `json:"replaced_by"`
+	ReplacedAt   *time.Time `json:"replaced_at"`
+}
+
+func (q *Queries) GetOldTicketNumber(ctx context.Context, ticketNumber string) (*GetOldTicketNumberRow, error) {
+	row := q.db.QueryRow(ctx, getOldTicketNumber, ticketNumber)
+	var i GetOldTicketNumberRow
+	err := row.Scan(
+		&i.TicketNumber,
+		&i.ReaderID,
+		&i.ReplacedBy,
+		&i.ReplacedAt,
+	)
+	return &i, err
+}
+
 const getReaderById = `-- name: GetReaderById :one
-SELECT id, ticket_number, full_name, email, phone, is_active, registration_date
+SELECT id, ticket_number, full_name, email, phone, is_active, registration_date, card_expires_at,
+       category, membership_expires_at, language
 FROM readers
 WHERE id = $1
 `
 
 type GetReaderByIdRow struct {
-	ID               uuid.UUID  `json:"id"`
-	TicketNumber     string     `json:"ticket_number"`
-	FullName         string     `json:"full_name"`
-	Email            *string    `json:"email"`
-	Phone            *string    `json:"phone"`
-	IsActive         *bool      `json:"is_active"`
-	RegistrationDate *time.Time `json:"registration_date"`
+	ID                  uuid.UUID      `json:"id"`
+	TicketNumber        string         `json:"ticket_number"`
+	FullName            string         `json:"full_name"`
+	Email               *string        `json:"email"`
+	Phone               *string        `json:"phone"`
+	IsActive            *bool          `json:"is_active"`
+	RegistrationDate    *time.Time     `json:"registration_date"`
+	CardExpiresAt       *time.Time     `json:"card_expires_at"`
+	Category            ReaderCategory `json:"category"`
+	MembershipExpiresAt *time.Time     `json:"membership_expires_at"`
+	Language            string         `json:"language"`
 }
 
 func (q *Queries) GetReaderById(ctx context.Context, id uuid.UUID) (*GetReaderByIdRow, error) {
@@ -139,24 +434,33 @@ func (q *Queries) GetReaderById(ctx context.Context, id uuid.UUID) (*GetReaderBy
 		&i.Phone,
 		&i.IsActive,
 		&i.RegistrationDate,
+		&i.CardExpiresAt,
+		&i.Category,
+		&i.MembershipExpiresAt,
+		&i.Language,
 	)
 	return &i, err
 }
 
 const getReaderByTicketNumber = `-- name: GetReaderByTicketNumber :one
-SELECT id, ticket_number, full_name, email, phone, is_active, registration_date
+SELECT id, ticket_number, full_name, email, phone, is_active, registration_date, card_expires_at,
+       category, membership_expires_at, language
 FROM readers
 WHERE ticket_number = $1
 `
 
 type GetReaderByTicketNumberRow struct {
-	ID               uuid.UUID  `json:"id"`
-	TicketNumber     string     `json:"ticket_number"`
-	FullName         string     `json:"full_name"`
-	Email            *string    `json:"email"`
-	Phone            *string    `json:"phone"`
-	IsActive         *bool      `json:"is_active"`
-	RegistrationDate *time.Time `json:"registration_date"`
+	ID                  uuid.UUID      `json:"id"`
+	TicketNumber        string         `json:"ticket_number"`
+	FullName            string         `json:"full_name"`
+	Email               *string        `json:"email"`
+	Phone               *string        `json:"phone"`
+	IsActive            *bool          `json:"is_active"`
+	RegistrationDate    *time.Time     `json:"registration_date"`
+	CardExpiresAt       *time.Time     `json:"card_expires_at"`
+	Category            ReaderCategory `json:"category"`
+	MembershipExpiresAt *time.Time     `json:"membership_expires_at"`
+	Language            string         `json:"language"`
 }
 
 func (q *Queries) GetReaderByTicketNumber(ctx context.Context, ticketNumber string) (*GetReaderByTicketNumberRow, error) {
@@ -170,10 +474,319 @@ func (q *Queries) GetReaderByTicketNumber(ctx context.Context, ticketNumber stri
 		&i.Phone,
 		&i.IsActive,
 		&i.RegistrationDate,
+		&i.CardExpiresAt,
+		&i.Category,
+		&i.MembershipExpiresAt,
+		&i.Language,
 	)
 	return &i, err
 }
 
+const getReaderLoanStatus = `-- name: GetReaderLoanStatus :one
+SELECT r.id, r.is_active, r.category, r.membership_expires_at,
+       (SELECT COUNT(*) FROM book_issues bi
+        WHERE bi.reader_id = r.id AND bi.return_date IS NULL) as active_loans
+FROM readers r
+WHERE r.id = $1
+`
+
+type GetReaderLoanStatusRow struct {
+	ID                  uuid.UUID      `json:"id"`
+	IsActive            *bool          `json:"is_active"`
+	Category            ReaderCategory `json:"category"`
+	MembershipExpiresAt *time.Time     `json:"membership_expires_at"`
+	ActiveLoans         int64          `json:"active_loans"`
+}
+
+func (q *Queries) GetReaderLoanStatus(ctx context.Context, id uuid.UUID) (*GetReaderLoanStatusRow, error) {
+	row := q.db.QueryRow(ctx, getReaderLoanStatus, id)
+	var i GetReaderLoanStatusRow
+	err := row.Scan(
+		&i.ID,
+		&i.IsActive,
+		&i.Category,
+		&i.MembershipExpiresAt,
+		&i.ActiveLoans,
+	)
+	return &i, err
+}
+
+const getReaderNotificationSettings = `-- name: GetReaderNotificationSettings :one
+SELECT id, email, phone, language, preferred_channel
+FROM readers
+WHERE id = $1
+`
+
+type GetReaderNotificationSettingsRow struct {
+	ID               uuid.UUID               `json:"id"`
+	Email            *string                 `json:"email"`
+	Phone            *string                 `json:"phone"`
+	Language         string                  `json:"language"`
+	PreferredChannel NullNotificationChannel `json:"preferred_channel"`
+}
+
+func (q *Queries) GetReaderNotificationSettings(ctx context.Context, id uuid.UUID) (*GetReaderNotificationSettingsRow, error) {
+	row := q.db.QueryRow(ctx, getReaderNotificationSettings, id)
+	var i GetReaderNotificationSettingsRow
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.Phone,
+		&i.Language,
+		&i.PreferredChannel,
+	)
+	return &i, err
+}
+
+const getReaderOldTicketNumbers = `-- name: GetReaderOldTicketNumbers :many
+SELECT ticket_number, replaced_by, reason, librarian_id, replaced_at
+FROM old_ticket_numbers
+WHERE reader_id = $1
+ORDER BY replaced_at DESC
+`
+
+type GetReaderOldTicketNumbersRow struct {
+	TicketNumber string     `json:"ticket_number"`
+	ReplacedBy   string     `json:"replaced_by"`
+	Reason       *string    `json:"reason"`
+	LibrarianID  *uuid.UUID `json:"librarian_id"`
+	ReplacedAt   *time.Time `json:"replaced_at"`
+}
+
+func (q *Queries) GetReaderOldTicketNumbers(ctx context.Context, readerID uuid.UUID) ([]*GetReaderOldTicketNumbersRow, error) {
+	rows, err := q.db.Query(ctx, getReaderOldTicketNumbers, readerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*GetReaderOldTicketNumbersRow{}
+	for rows.Next() {
+		var i GetReaderOldTicketNumbersRow
+		if err := rows.Scan(
+			&i.TicketNumber,
+			&i.ReplacedBy,
+			&i.Reason,
+			&i.LibrarianID,
+			&i.ReplacedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getReaderOpenObligations = `-- name: GetReaderOpenObligations :one
+SELECT
+    (SELECT COUNT(*) FROM book_issues bi
+     WHERE bi.reader_id = $1 AND bi.return_date IS NULL) as open_loans,
+    (SELECT COUNT(*) FROM fines f
+     WHERE f.reader_id = $1 AND f.is_paid = false) as unpaid_fines
+`
+
+type GetReaderOpenObligationsRow struct {
+	OpenLoans   int64 `json:"open_loans"`
+	UnpaidFines int64 `json:"unpaid_fines"`
+}
+
+func (q *Queries) GetReaderOpenObligations(ctx context.Context, readerID uuid.UUID) (*GetReaderOpenObligationsRow, error) {
+	row := q.db.QueryRow(ctx, getReaderOpenObligations, readerID)
+	var i GetReaderOpenObligationsRow
+	err := row.Scan(&i.OpenLoans, &i.UnpaidFines)
+	return &i, err
+}
+
+const getReaderPortalCredentials = `-- name: GetReaderPortalCredentials :one
+SELECT id, pin_hash, is_active, membership_expires_at
+FROM readers
+WHERE ticket_number = $1
+`
+
+type GetReaderPortalCredentialsRow struct {
+	ID                  uuid.UUID  `json:"id"`
+	PinHash             *string    `json:"pin_hash"`
+	IsActive            *bool      `json:"is_active"`
+	MembershipExpiresAt *time.Time `json:"membership_expires_at"`
+}
+
+func (q *Queries) GetReaderPortalCredentials(ctx context.Context, ticketNumber string) (*GetReaderPortalCredentialsRow, error) {
+	row := q.db.QueryRow(ctx, getReaderPortalCredentials, ticketNumber)
+	var i GetReaderPortalCredentialsRow
+	err := row.Scan(
+		&i.ID,
+		&i.PinHash,
+		&i.IsActive,
+		&i.MembershipExpiresAt,
+	)
+	return &i, err
+}
+
+const getReadersForAnonymization = `-- name: GetReadersForAnonymization :many
+SELECT r.id
+FROM readers r
+WHERE r.anonymized_at IS NULL
+  AND COALESCE(r.created_at, r.registration_date) < $1::timestamp
+  -- бессрочное членство (NULL) не истекает, такие читатели не обезличиваются
+  AND r.membership_expires_at < $1::timestamp
+  AND NOT EXISTS (
+      SELECT 1 FROM book_issues bi
+      WHERE bi.reader_id = r.id
+        AND (bi.return_date IS NULL OR bi.return_date >= $1::timestamp OR bi.issue_date >= $1::timestamp)
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM fines f
+      WHERE f.reader_id = r.id AND (f.is_paid = false OR f.fine_date >= $1::timestamp)
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM hall_visits hv
+      WHERE hv.reader_id = r.id AND hv.visit_time >= $1::timestamp
+  )
+ORDER BY r.created_at
+LIMIT $2
+`
+
+type GetReadersForAnonymizationParams struct {
+	Cutoff     time.Time `json:"cutoff"`
+	LimitCount int32     `json:"limit_count"`
+}
+
+func (q *Queries) GetReadersForAnonymization(ctx context.Context, arg GetReadersForAnonymizationParams) ([]uuid.UUID, error) {
+	rows, err := q.db.Query(ctx, getReadersForAnonymization, arg.Cutoff, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markReaderMerged = `-- name: MarkReaderMerged :exec
+-- Номер билета дубля освобождается, чтобы поиск по нему находил прежний номер основной записи
+UPDATE readers
+SET ticket_number = 'MERGED' || left(replace(id::text, '-', ''), 14),
+    is_active = false
+WHERE id = $1
+`
+
+func (q *Queries) MarkReaderMerged(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, markReaderMerged, id)
+	return err
+}
+
+const nextTicketNumberSequence = `-- name: NextTicketNumberSequence :one
+SELECT nextval('reader_ticket_seq')::bigint as sequence
+`
+
+func (q *Queries) NextTicketNumberSequence(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, nextTicketNumberSequence)
+	var sequence int64
+	err := row.Scan(&sequence)
+	return sequence, err
+}
+
+const reissueReaderTicket = `-- name: ReissueReaderTicket :one
+UPDATE readers
+SET ticket_number = $1, card_expires_at = $2
+WHERE id = $3
+RETURNING id, ticket_number, full_name, card_expires_at
+`
+
+type ReissueReaderTicketParams struct {
+	NewTicketNumber string     `json:"new_ticket_number"`
+	CardExpiresAt   *time.Time `json:"card_expires_at"`
+	ID              uuid.UUID  `json:"id"`
+}
+
+type ReissueReaderTicketRow struct {
+	ID            uuid.UUID  `json:"id"`
+	TicketNumber  string     `json:"ticket_number"`
+	FullName      string     `json:"full_name"`
+	CardExpiresAt *time.Time `json:"card_expires_at"`
+}
+
+func (q *Queries) ReissueReaderTicket(ctx context.Context, arg ReissueReaderTicketParams) (*ReissueReaderTicketRow, error) {
+	row := q.db.QueryRow(ctx, reissueReaderTicket, arg.NewTicketNumber, arg.CardExpiresAt, arg.ID)
+	var i ReissueReaderTicketRow
+	err := row.Scan(
+		&i.ID,
+		&i.TicketNumber,
+		&i.FullName,
+		&i.CardExpiresAt,
+	)
+	return &i, err
+}
+
+const renewReaderMembership = `-- name: RenewReaderMembership :one
+-- Билет продлевается вместе с членством, чтобы напечатанный срок не истекал раньше него
+UPDATE readers
+SET category = $1,
+    membership_expires_at = (GREATEST(COALESCE(membership_expires_at, CURRENT_DATE), CURRENT_DATE)
+        + make_interval(months => $2::int))::date,
+    card_expires_at = GREATEST(card_expires_at,
+        (GREATEST(COALESCE(membership_expires_at, CURRENT_DATE), CURRENT_DATE)
+            + make_interval(months => $2::int))::date)
+WHERE id = $3
+RETURNING id, ticket_number, full_name, category, membership_expires_at, card_expires_at
+`
+
+type RenewReaderMembershipParams struct {
+	Category ReaderCategory `json:"category"`
+	Months   int            `json:"months"`
+	ID       uuid.UUID      `json:"id"`
+}
+
+type RenewReaderMembershipRow struct {
+	ID                  uuid.UUID      `json:"id"`
+	TicketNumber        string         `json:"ticket_number"`
+	FullName            string         `json:"full_name"`
+	Category            ReaderCategory `json:"category"`
+	MembershipExpiresAt *time.Time     `json:"membership_expires_at"`
+	CardExpiresAt       *time.Time     `json:"card_expires_at"`
+}
+
+func (q *Queries) RenewReaderMembership(ctx context.Context, arg RenewReaderMembershipParams) (*RenewReaderMembershipRow, error) {
+	row := q.db.QueryRow(ctx, renewReaderMembership, arg.Category, arg.Months, arg.ID)
+	var i RenewReaderMembershipRow
+	err := row.Scan(
+		&i.ID,
+		&i.TicketNumber,
+		&i.FullName,
+		&i.Category,
+		&i.MembershipExpiresAt,
+		&i.CardExpiresAt,
+	)
+	return &i, err
+}
+
+const scrubMergedTicketNumbers = `-- name: ScrubMergedTicketNumbers :exec
+UPDATE reader_merges
+SET merged_ticket_number = $1
+WHERE merged_reader_id = $2
+`
+
+type ScrubMergedTicketNumbersParams struct {
+	TicketNumber string    `json:"ticket_number"`
+	ReaderID     uuid.UUID `json:"reader_id"`
+}
+
+func (q *Queries) ScrubMergedTicketNumbers(ctx context.Context, arg ScrubMergedTicketNumbersParams) error {
+	_, err := q.db.Exec(ctx, scrubMergedTicketNumbers, arg.TicketNumber, arg.ReaderID)
+	return err
+}
+
 const searchReaders = `-- name: SearchReaders :many
 SELECT id, ticket_number, full_name, email, phone, is_active, registration_date
 FROM readers
@@ -226,6 +839,39 @@ func (q *Queries) SearchReaders(ctx context.Context, arg SearchReadersParams) ([
 	return items, nil
 }
 
+const setReaderNotificationSettings = `-- name: SetReaderNotificationSettings :exec
+UPDATE readers
+SET language = $1, preferred_channel = $2
+WHERE id = $3
+`
+
+type SetReaderNotificationSettingsParams struct {
+	Language         string                  `json:"language"`
+	PreferredChannel NullNotificationChannel `json:"preferred_channel"`
+	ID               uuid.UUID               `json:"id"`
+}
+
+func (q *Queries) SetReaderNotificationSettings(ctx context.Context, arg SetReaderNotificationSettingsParams) error {
+	_, err := q.db.Exec(ctx, setReaderNotificationSettings, arg.Language, arg.PreferredChannel, arg.ID)
+	return err
+}
+
+const setReaderPin = `-- name: SetReaderPin :exec
+UPDATE readers
+SET pin_hash = $1
+WHERE id = $2
+`
+
+type SetReaderPinParams struct {
+	PinHash *string   `json:"pin_hash"`
+	ID      uuid.UUID `json:"id"`
+}
+
+func (q *Queries) SetReaderPin(ctx context.Context, arg SetReaderPinParams) error {
+	_, err := q.db.Exec(ctx, setReaderPin, arg.PinHash, arg.ID)
+	return err
+}
+
 const updateReader = `-- name: UpdateReader :one
 UPDATE readers
 SET full_name = $1, email = $2, phone = $3
@@ -265,3 +911,37 @@ func (q *Queries) UpdateReader(ctx context.Context, arg UpdateReaderParams) (*Up
 	)
 	return &i, err
 }
+
+const updateReaderContacts = `-- name: UpdateReaderContacts :one
+UPDATE readers
+SET email = $1, phone = $2
+WHERE id = $3
+RETURNING id, ticket_number, full_name, email, phone
+`
+
+type UpdateReaderContactsParams struct {
+	Email *string   `json:"email"`
+	Phone *string   `json:"phone"`
+	ID    uuid.UUID `json:"id"`
+}
+
+type UpdateReaderContactsRow struct {
+	ID           uuid.UUID `json:"id"`
+	TicketNumber string    `json:"ticket_number"`
+	FullName     string    `json:"full_name"`
+	Email        *string   `json:"email"`
+	Phone        *string   `json:"phone"`
+}
+
+func (q *Queries) UpdateReaderContacts(ctx context.Context, arg UpdateReaderContactsParams) (*UpdateReaderContactsRow, error) {
+	row := q.db.QueryRow(ctx, updateReaderContacts, arg.Email, arg.Phone, arg.ID)
+	var i UpdateReaderContactsRow
+	err := row.Scan(
+		&i.ID,
+		&i.TicketNumber,
+		&i.FullName,
+		&i.Email,
+		&i.Phone,
+	)
+	return &i, err
+}
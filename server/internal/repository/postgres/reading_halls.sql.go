@@ -13,50 +13,69 @@ import (
 )
 
 const createReadingHall = `-- name: CreateReadingHall :one
-INSERT INTO reading_halls (hall_name, specialization, total_seats)
-VALUES ($1, $2, $3)
-RETURNING id, hall_name, specialization, total_seats, current_visitors
+INSERT INTO reading_halls (hall_name, hall_code, specialization, total_seats, booking_slot_minutes, no_show_minutes)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, hall_name, hall_code, specialization, total_seats, current_visitors, booking_slot_minutes, no_show_minutes
 `
 
 type CreateReadingHallParams struct {
-	HallName       string  `json:"hall_name"`
-	Specialization *string `json:"specialization"`
-	TotalSeats     int     `json:"total_seats"`
+	HallName           string  `json:"hall_name"`
+	HallCode           *string `json:"hall_code"`
+	Specialization     *string `json:"specialization"`
+	TotalSeats         int     `json:"total_seats"`
+	BookingSlotMinutes int     `json:"booking_slot_minutes"`
+	NoShowMinutes      int     `json:"no_show_minutes"`
 }
 
 type CreateReadingHallRow struct {
-	ID              uuid.UUID `json:"id"`
-	HallName        string    `json:"hall_name"`
-	Specialization  *string   `json:"specialization"`
-	TotalSeats      int       `json:"total_seats"`
-	CurrentVisitors *int      `json:"current_visitors"`
+	ID                 uuid.UUID `json:"id"`
+	HallName           string    `json:"hall_name"`
+	HallCode           *string   `json:"hall_code"`
+	Specialization     *string   `json:"specialization"`
+	TotalSeats         int       `json:"total_seats"`
+	CurrentVisitors    *int      `json:"current_visitors"`
+	BookingSlotMinutes int       `json:"booking_slot_minutes"`
+	NoShowMinutes      int       `json:"no_show_minutes"`
 }
 
 func (q *Queries) CreateReadingHall(ctx context.Context, arg CreateReadingHallParams) (*CreateReadingHallRow, error) {
-	row := q.db.QueryRow(ctx, createReadingHall, arg.HallName, arg.Specialization, arg.TotalSeats)
+	row := q.db.QueryRow(ctx, createReadingHall,
+		arg.HallName,
+		arg.HallCode,
+		arg.Specialization,
+		arg.TotalSeats,
+		arg.BookingSlotMinutes,
+		arg.NoShowMinutes,
+	)
 	var i CreateReadingHallRow
 	err := row.Scan(
 		&i.ID,
 		&i.HallName,
+		&i.HallCode,
 		&i.Specialization,
 		&i.TotalSeats,
 		&i.CurrentVisitors,
+		&i.BookingSlotMinutes,
+		&i.NoShowMinutes,
 	)
 	return &i, err
 }
 
 const getAllReadingHalls = `-- name: GetAllReadingHalls :many
-SELECT id, hall_name, specialization, total_seats, current_visitors
+SELECT id, hall_name, hall_code, specialization, total_seats, current_visitors, booking_slot_minutes, no_show_minutes
 FROM reading_halls
 ORDER BY hall_name
 `
 
 type GetAllReadingHallsRow struct {
-	ID              uuid.UUID `json:"id"`
-	HallName        string    `json:"hall_name"`
-	Specialization  *string   `json:"specialization"`
-	TotalSeats      int       `json:"total_seats"`
-	CurrentVisitors *int      `json:"current_visitors"`
+	ID                 uuid.UUID `json:"id"`
+	HallName           string    `json:"hall_name"`
+	HallCode           *string   `json:"hall_code"`
+	Specialization     *string   `json:"specialization"`
+	TotalSeats         int       `json:"total_seats"`
+	CurrentVisitors    *int      `json:"current_visitors"`
+	BookingSlotMinutes int       `json:"booking_slot_minutes"`
+	NoShowMinutes      int       `json:"no_show_minutes"`
 }
 
 func (q *Queries) GetAllReadingHalls(ctx context.Context) ([]*GetAllReadingHallsRow, error) {
@@ -71,9 +90,12 @@ func (q *Queries) GetAllReadingHalls(ctx context.Context) ([]*GetAllReadingHalls
 		if err := rows.Scan(
 			&i.ID,
 			&i.HallName,
+			&i.HallCode,
 			&i.Specialization,
 			&i.TotalSeats,
 			&i.CurrentVisitors,
+			&i.BookingSlotMinutes,
+			&i.NoShowMinutes,
 		); err != nil {
 			return nil, err
 		}
@@ -95,10 +117,22 @@ SELECT
     ROUND(
         (rh.current_visitors::numeric / rh.total_seats * 100), 2
     ) as occupancy_percentage,
-    (rh.total_seats - rh.current_visitors) as free_seats,
+    COALESCE(active_bookings.booked_seats, 0) as booked_seats,
+    GREATEST(rh.total_seats - rh.current_visitors - COALESCE(active_bookings.booked_seats, 0), 0)::int as free_seats,
     COALESCE(daily_stats.visits_today, 0) as visits_today,
     COALESCE(daily_stats.unique_visitors_today, 0) as unique_visitors_today
 FROM reading_halls rh
+LEFT JOIN (
+    -- Забронированные, но еще не занятые места в текущем слоте
+    SELECT
+        hall_id,
+        COUNT(*) as booked_seats
+    FROM seat_bookings
+    WHERE status = 'booked'
+      AND start_time <= CURRENT_TIMESTAMP
+      AND end_time > CURRENT_TIMESTAMP
+    GROUP BY hall_id
+) active_bookings ON rh.id = active_bookings.hall_id
 LEFT JOIN (
     SELECT
         hall_id,
@@ -118,7 +152,8 @@ type GetHallsDashboardRow struct {
 	TotalSeats          int             `json:"total_seats"`
 	CurrentVisitors     *int            `json:"current_visitors"`
 	OccupancyPercentage decimal.Decimal `json:"occupancy_percentage"`
-	FreeSeats           int32           `json:"free_seats"`
+	BookedSeats         int64           `json:"booked_seats"`
+	FreeSeats           int             `json:"free_seats"`
 	VisitsToday         int64           `json:"visits_today"`
 	UniqueVisitorsToday int64           `json:"unique_visitors_today"`
 }
@@ -139,6 +174,7 @@ func (q *Queries) GetHallsDashboard(ctx context.Context) ([]*GetHallsDashboardRo
 			&i.TotalSeats,
 			&i.CurrentVisitors,
 			&i.OccupancyPercentage,
+			&i.BookedSeats,
 			&i.FreeSeats,
 			&i.VisitsToday,
 			&i.UniqueVisitorsToday,
@@ -154,17 +190,20 @@ func (q *Queries) GetHallsDashboard(ctx context.Context) ([]*GetHallsDashboardRo
 }
 
 const getReadingHallById = `-- name: GetReadingHallById :one
-SELECT id, hall_name, specialization, total_seats, current_visitors
+SELECT id, hall_name, hall_code, specialization, total_seats, current_visitors, booking_slot_minutes, no_show_minutes
 FROM reading_halls
 WHERE id = $1
 `
 
 type GetReadingHallByIdRow struct {
-	ID              uuid.UUID `json:"id"`
-	HallName        string    `json:"hall_name"`
-	Specialization  *string   `json:"specialization"`
-	TotalSeats      int       `json:"total_seats"`
-	CurrentVisitors *int      `json:"current_visitors"`
+	ID                 uuid.UUID `json:"id"`
+	HallName           string    `json:"hall_name"`
+	HallCode           *string   `json:"hall_code"`
+	Specialization     *string   `json:"specialization"`
+	TotalSeats         int       `json:"total_seats"`
+	CurrentVisitors    *int      `json:"current_visitors"`
+	BookingSlotMinutes int       `json:"booking_slot_minutes"`
+	NoShowMinutes      int       `json:"no_show_minutes"`
 }
 
 func (q *Queries) GetReadingHallById(ctx context.Context, id uuid.UUID) (*GetReadingHallByIdRow, error) {
@@ -173,13 +212,30 @@ func (q *Queries) GetReadingHallById(ctx context.Context, id uuid.UUID) (*GetRea
 	err := row.Scan(
 		&i.ID,
 		&i.HallName,
+		&i.HallCode,
 		&i.Specialization,
 		&i.TotalSeats,
 		&i.CurrentVisitors,
+		&i.BookingSlotMinutes,
+		&i.NoShowMinutes,
 	)
 	return &i, err
 }
 
+const lockReadingHall = `-- name: LockReadingHall :one
+SELECT total_seats
+FROM reading_halls
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) LockReadingHall(ctx context.Context, id uuid.UUID) (int, error) {
+	row := q.db.QueryRow(ctx, lockReadingHall, id)
+	var total_seats int
+	err := row.Scan(&total_seats)
+	return total_seats, err
+}
+
 const updateHallVisitorCount = `-- name: UpdateHallVisitorCount :exec
 UPDATE reading_halls
 SET current_visitors = current_visitors + $1
@@ -198,40 +254,53 @@ func (q *Queries) UpdateHallVisitorCount(ctx context.Context, arg UpdateHallVisi
 
 const updateReadingHall = `-- name: UpdateReadingHall :one
 UPDATE reading_halls
-SET hall_name = $1, specialization = $2, total_seats = $3
-WHERE id = $4
-RETURNING id, hall_name, specialization, total_seats, current_visitors
+SET hall_name = $1, hall_code = $2, specialization = $3, total_seats = $4,
+    booking_slot_minutes = $5, no_show_minutes = $6
+WHERE id = $7
+RETURNING id, hall_name, hall_code, specialization, total_seats, current_visitors, booking_slot_minutes, no_show_minutes
 `
 
 type UpdateReadingHallParams struct {
-	HallName       string    `json:"hall_name"`
-	Specialization *string   `json:"specialization"`
-	TotalSeats     int       `json:"total_seats"`
-	ID             uuid.UUID `json:"id"`
+	HallName           string    `json:"hall_name"`
+	HallCode           *string   `json:"hall_code"`
+	Specialization     *string   `json:"specialization"`
+	TotalSeats         int       `json:"total_seats"`
+	BookingSlotMinutes int       `json:"booking_slot_minutes"`
+	NoShowMinutes      int       `json:"no_show_minutes"`
+	ID                 uuid.UUID `json:"id"`
 }
 
 type UpdateReadingHallRow struct {
-	ID              uuid.UUID `json:"id"`
-	HallName        string    `json:"hall_name"`
-	Specialization  *string   `json:"specialization"`
-	TotalSeats      int       `json:"total_seats"`
-	CurrentVisitors *int      `json:"current_visitors"`
+	ID                 uuid.UUID `json:"id"`
+	HallName           string    `json:"hall_name"`
+	HallCode           *string   `json:"hall_code"`
+	Specialization     *string   `json:"specialization"`
+	TotalSeats         int       `json:"total_seats"`
+	CurrentVisitors    *int      `json:"current_visitors"`
+	BookingSlotMinutes int       `json:"booking_slot_minutes"`
+	NoShowMinutes      int       `json:"no_show_minutes"`
 }
 
 func (q *Queries) UpdateReadingHall(ctx context.Context, arg UpdateReadingHallParams) (*UpdateReadingHallRow, error) {
 	row := q.db.QueryRow(ctx, updateReadingHall,
 		arg.HallName,
+		arg.HallCode,
 		arg.Specialization,
 		arg.TotalSeats,
+		arg.BookingSlotMinutes,
+		arg.NoShowMinutes,
 		arg.ID,
 	)
 	var i UpdateReadingHallRow
 	err := row.Scan(
 		&i.ID,
 		&i.HallName,
+		&i.HallCode,
 		&i.Specialization,
 		&i.TotalSeats,
 		&i.CurrentVisitors,
+		&i.BookingSlotMinutes,
+		&i.NoShowMinutes,
 	)
 	return &i, err
 }
@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hnnsly/library-console/internal/repository/postgres"
+)
+
+var (
+	// ErrReaderHasObligations у читателя есть невозвращенные книги или неоплаченные штрафы
+	ErrReaderHasObligations = errors.New("у читателя есть невозвращенные книги или неоплаченные штрафы")
+	// ErrReaderAnonymized персональные данные читателя уже удалены
+	ErrReaderAnonymized = errors.New("читатель уже анонимизирован")
+)
+
+// ReaderExport все данные, которые библиотека хранит о читателе
+type ReaderExport struct {
+	ExportedAt time.Time                                `json:"exported_at"`
+	Profile    *postgres.GetReaderByIdRow               `json:"profile"`
+	Loans      []*postgres.GetReaderIssueHistoryRow     `json:"loans"`
+	Fines      []*postgres.GetReaderFinesRow            `json:"fines"`
+	Visits     []*postgres.GetReaderVisitHistoryRow     `json:"hall_visits"`
+	Bookings   []*postgres.GetReaderBookingsRow         `json:"seat_bookings"`
+	OldTickets []*postgres.GetReaderOldTicketNumbersRow `json:"old_ticket_numbers"`
+	// Notifications включают адреса, на которые отправлялись сообщения
+	Notifications []*postgres.Notification `json:"notifications"`
+}
+
+// ExportReader собирает данные читателя для выгрузки по его запросу
+func (r *LibraryRepository) ExportReader(ctx context.Context, readerID uuid.UUID) (*ReaderExport, error) {
+	export := &ReaderExport{ExportedAt: time.Now()}
+
+	var err error
+	if export.Profile, err = r.GetReaderById(ctx, readerID); err != nil {
+		return nil, err
+	}
+	if export.Loans, err = r.GetReaderIssueHistory(ctx, readerID); err != nil {
+		return nil, fmt.Errorf("история выдач: %w", err)
+	}
+	if export.Fines, err = r.GetReaderFines(ctx, readerID); err != nil {
+		return nil, fmt.Errorf("штрафы: %w", err)
+	}
+	if export.Visits, err = r.GetReaderVisitHistory(ctx, readerID); err != nil {
+		return nil, fmt.Errorf("посещения: %w", err)
+	}
+	if export.Bookings, err = r.GetReaderBookings(ctx, readerID); err != nil {
+		return nil, fmt.Errorf("бронирования: %w", err)
+	}
+	if export.OldTickets, err = r.GetReaderOldTicketNumbers(ctx, readerID); err != nil {
+		return nil, fmt.Errorf("замененные билеты: %w", err)
+	}
+	if export.Notifications, err = r.GetReaderNotifications(ctx, postgres.GetReaderNotificationsParams{
+		ReaderID:   readerID,
+		LimitCount: math.MaxInt32,
+	}); err != nil {
+		return nil, fmt.Errorf("уведомления: %w", err)
+	}
+
+	return export, nil
+}
+
+// AnonymizeReader удаляет персональные данные читателя и публикует reader.deactivated. Выдачи, штрафы
+// и посещения остаются привязанными к обезличенной записи и продолжают учитываться в статистике
+func (r *LibraryRepository) AnonymizeReader(ctx context.Context, readerID uuid.UUID) (*postgres.AnonymizeReaderRow, error) {
+	var anonymized *postgres.AnonymizeReaderRow
+	err := r.inTx(ctx, func(q *postgres.Queries) error {
+		reader, err := q.LockReader(ctx, readerID)
+		if err != nil {
+			return err
+		}
+		if reader.AnonymizedAt != nil {
+			return ErrReaderAnonymized
+		}
+
+		obligations, err := q.GetReaderOpenObligations(ctx, readerID)
+		if err != nil {
+			return err
+		}
+		if obligations.OpenLoans > 0 || obligations.UnpaidFines > 0 {
+			return ErrReaderHasObligations
+		}
+
+		if anonymized, err = q.AnonymizeReader(ctx, readerID); err != nil {
+			return err
+		}
+		// Старые номера билетов тоже идентифицируют человека
+		if err := q.DeleteReaderOldTicketNumbers(ctx, readerID); err != nil {
+			return err
+		}
+		// Журнал уведомлений хранит адреса и имя в тексте шаблона
+		if err := q.DeleteReaderNotifications(ctx, readerID); err != nil {
+			return err
+		}
+		err = q.ScrubMergedTicketNumbers(ctx, postgres.ScrubMergedTicketNumbersParams{
+			TicketNumber: anonymized.TicketNumber,
+			ReaderID:     readerID,
+		})
+		if err != nil {
+			return err
+		}
+
+		// Записи, объединенные с читателем, хранят его прежние имя, контакты и номера билетов
+		merged, err := q.AnonymizeMergedReaders(ctx, readerID)
+		if err != nil {
+			return err
+		}
+		readerIDs := []uuid.UUID{readerID}
+		for _, m := range merged {
+			if err := q.DeleteReaderNotifications(ctx, m.ID); err != nil {
+				return err
+			}
+			err := q.ScrubMergedTicketNumbers(ctx, postgres.ScrubMergedTicketNumbersParams{
+				TicketNumber: m.TicketNumber,
+				ReaderID:     m.ID,
+			})
+			if err != nil {
+				return err
+			}
+			readerIDs = append(readerIDs, m.ID)
+		}
+
+		// Неотправленные и доставленные события содержат прежний номер билета
+		if err := q.DeleteReaderWebhookEvents(ctx, readerIDs); err != nil {
+			return err
+		}
+		// Сессии завершаются последним шагом: если Redis недоступен, транзакция откатывается
+		// и обезличивание можно повторить
+		if err := r.DeleteReaderSessions(ctx, readerIDs...); err != nil {
+			return err
+		}
+
+		// Событие публикуется после обезличивания, чтобы в outbox не попал прежний номер билета.
+		// О читателе, деактивированном раньше, подписчики уже знают
+		if reader.IsActive != nil && !*reader.IsActive {
+			return nil
+		}
+		return emitReaderDeactivatedWebhook(ctx, q, readerID, "anonymized", nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return anonymized, nil
+}
+
+// AnonymizeInactiveReaders анонимизирует читателей без активности с момента cutoff,
+// у которых нет невозвращенных книг и неоплаченных штрафов, а членство истекло до cutoff.
+// Читатели с бессрочным членством не анонимизируются автоматически
+func (r *LibraryRepository) AnonymizeInactiveReaders(ctx context.Context, cutoff time.Time) (int, error) {
+	const batchSize = 100
+
+	total := 0
+	for {
+		ids, err := r.GetReadersForAnonymization(ctx, postgres.GetReadersForAnonymizationParams{
+			Cutoff:     cutoff,
+			LimitCount: batchSize,
+		})
+		if err != nil {
+			return total, err
+		}
+
+		anonymized := 0
+		for _, id := range ids {
+			if _, err := r.AnonymizeReader(ctx, id); err != nil {
+				// Читатель мог взять книгу между выборкой и блокировкой
+				if errors.Is(err, ErrReaderHasObligations) || errors.Is(err, ErrReaderAnonymized) {
+					continue
+				}
+				return total + anonymized, fmt.Errorf("читатель %s: %w", id, err)
+			}
+			anonymized++
+		}
+		total += anonymized
+
+		if len(ids) < batchSize || anonymized == 0 {
+			return total, nil
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hnnsly/library-console/internal/repository/postgres"
+)
+
+var (
+	// ErrTicketChecksum контрольная цифра номера билета не сходится
+	ErrTicketChecksum = errors.New("неверная контрольная цифра номера билета")
+	// ErrMergeSameReader попытка объединить читателя с самим собой
+	ErrMergeSameReader = errors.New("нельзя объединить читателя с самим собой")
+	// ErrReaderInactive один из объединяемых читателей уже деактивирован
+	ErrReaderInactive = errors.New("читатель деактивирован")
+)
+
+// GenerateTicketNumber выдает следующий номер читательского билета
+func (r *LibraryRepository) GenerateTicketNumber(ctx context.Context) (string, error) {
+	sequence, err := r.NextTicketNumberSequence(ctx)
+	if err != nil {
+		return "", fmt.Errorf("не удалось получить номер билета: %w", err)
+	}
+	return r.tickets.Format(sequence), nil
+}
+
+// CardExpiry возвращает дату окончания действия билета, выданного в момент from
+func (r *LibraryRepository) CardExpiry(from time.Time) time.Time {
+	return from.AddDate(0, r.tickets.ValidityMonths(), 0)
+}
+
+// CheckTicketNumber проверяет контрольную цифру номера, считанного со сканера
+func (r *LibraryRepository) CheckTicketNumber(ticketNumber string) error {
+	if !r.tickets.Valid(ticketNumber) {
+		return ErrTicketChecksum
+	}
+	return nil
+}
+
+// ReissueReaderTicket выдает читателю новый билет. Старый номер сохраняется
+// в old_ticket_numbers, поэтому история читателя остается привязанной к нему
+func (r *LibraryRepository) ReissueReaderTicket(ctx context.Context, readerID uuid.UUID, reason *string, librarianID *uuid.UUID) (*postgres.ReissueReaderTicketRow, error) {
+	var reissued *postgres.ReissueReaderTicketRow
+	err := r.inTx(ctx, func(q *postgres.Queries) error {
+		reader, err := q.GetReaderById(ctx, readerID)
+		if err != nil {
+			return err
+		}
+
+		sequence, err := q.NextTicketNumberSequence(ctx)
+		if err != nil {
+			return fmt.Errorf("не удалось получить номер билета: %w", err)
+		}
+		expires := r.CardExpiry(time.Now())
+
+		reissued, err = q.ReissueReaderTicket(ctx, postgres.ReissueReaderTicketParams{
+			ID:              readerID,
+			NewTicketNumber: r.tickets.Format(sequence),
+			CardExpiresAt:   &expires,
+		})
+		if err != nil {
+			return err
+		}
+
+		return q.CreateOldTicketNumber(ctx, postgres.CreateOldTicketNumberParams{
+			TicketNumber: reader.TicketNumber,
+			ReaderID:     readerID,
+			ReplacedBy:   reissued.TicketNumber,
+			Reason:       reason,
+			LibrarianID:  librarianID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reissued, nil
+}
+
+// MembershipExpired проверяет, истекло ли членство к моменту now.
+// Членство действует до конца дня membership_expires_at, NULL означает бессрочное
+func MembershipExpired(expiresAt *time.Time, now time.Time) bool {
+	if expiresAt == nil {
+		return false
+	}
+	return now.Format(time.DateOnly) > expiresAt.Format(time.DateOnly)
+}
+
+// ReaderMerge параметры объединения дубля с основной записью читателя
+type ReaderMerge struct {
+	SurvivingID uuid.UUID
+	DuplicateID uuid.UUID
+	Reason      *string
+	LibrarianID *uuid.UUID
+}
+
+// MergeReaders переносит выдачи, штрафы, посещения, бронирования и уведомления дубля на основную запись,
+// сохраняет номер его билета как прежний номер основной записи, деактивирует дубль
+// и записывает объединение в журнал. Все изменения выполняются в одной транзакции
+func (r *LibraryRepository) MergeReaders(ctx context.Context, m ReaderMerge) (*postgres.ReaderMerge, error) {
+	if m.SurvivingID == m.DuplicateID {
+		return nil, ErrMergeSameReader
+	}
+
+	var merge *postgres.ReaderMerge
+	err := r.inTx(ctx, func(q *postgres.Queries) error {
+		// Блокировки берутся в порядке id, чтобы встречные объединения не взаимоблокировались
+		ids := []uuid.UUID{m.SurvivingID, m.DuplicateID}
+		if bytes.Compare(ids[0][:], ids[1][:]) > 0 {
+			ids[0], ids[1] = ids[1], ids[0]
+		}
+		locked := make(map[uuid.UUID]*postgres.LockReaderRow, len(ids))
+		for _, id := range ids {
+			reader, err := q.LockReader(ctx, id)
+			if err != nil {
+				return err
+			}
+			if reader.IsActive != nil && !*reader.IsActive {
+				return fmt.Errorf("%w: %s", ErrReaderInactive, reader.TicketNumber)
+			}
+			locked[id] = reader
+		}
+
+		move := postgres.MoveReaderBookIssuesParams{TargetID: m.SurvivingID, SourceID: m.DuplicateID}
+		issues, err := q.MoveReaderBookIssues(ctx, move)
+		if err != nil {
+			return fmt.Errorf("перенос выдач: %w", err)
+		}
+		fines, err := q.MoveReaderFines(ctx, postgres.MoveReaderFinesParams(move))
+		if err != nil {
+			return fmt.Errorf("перенос штрафов: %w", err)
+		}
+		visits, err := q.MoveReaderHallVisits(ctx, postgres.MoveReaderHallVisitsParams(move))
+		if err != nil {
+			return fmt.Errorf("перенос посещений: %w", err)
+		}
+		if err := q.MoveReaderVisitSessions(ctx, postgres.MoveReaderVisitSessionsParams(move)); err != nil {
+			return fmt.Errorf("перенос сеансов посещений: %w", err)
+		}
+		if err := q.MoveReaderSeatBookings(ctx, postgres.MoveReaderSeatBookingsParams(move)); err != nil {
+			return fmt.Errorf("перенос бронирований: %w", err)
+		}
+		if err := q.MoveReaderNotificationOptOuts(ctx, postgres.MoveReaderNotificationOptOutsParams{
+			SourceID: m.DuplicateID,
+			TargetID: m.SurvivingID,
+		}); err != nil {
+			return fmt.Errorf("перенос отказов от уведомлений: %w", err)
+		}
+		if err := q.MoveReaderPendingNotifications(ctx, postgres.MoveReaderPendingNotificationsParams(move)); err != nil {
+			return fmt.Errorf("перенос уведомлений: %w", err)
+		}
+
+		// Билет дубля становится прежним номером основной записи, как при перевыпуске карты
+		if err := q.MoveReaderOldTicketNumbers(ctx, postgres.MoveReaderOldTicketNumbersParams(move)); err != nil {
+			return fmt.Errorf("перенос прежних номеров билетов: %w", err)
+		}
+		if err := q.CreateOldTicketNumber(ctx, postgres.CreateOldTicketNumberParams{
+			TicketNumber: locked[m.DuplicateID].TicketNumber,
+			ReaderID:     m.SurvivingID,
+			ReplacedBy:   locked[m.SurvivingID].TicketNumber,
+			Reason:       m.Reason,
+			LibrarianID:  m.LibrarianID,
+		}); err != nil {
+			return fmt.Errorf("запись номера билета дубля: %w", err)
+		}
+
+		if err := q.MarkReaderMerged(ctx, m.DuplicateID); err != nil {
+			return err
+		}
+		if err := emitReaderDeactivatedWebhook(ctx, q, m.DuplicateID, "merged", &m.SurvivingID); err != nil {
+			return err
+		}
+
+		merge, err = q.CreateReaderMerge(ctx, postgres.CreateReaderMergeParams{
+			SurvivingReaderID:  m.SurvivingID,
+			MergedReaderID:     m.DuplicateID,
+			MergedTicketNumber: locked[m.DuplicateID].TicketNumber,
+			MovedIssues:        int(issues),
+			MovedFines:         int(fines),
+			MovedVisits:        int(visits),
+			Reason:             m.Reason,
+			LibrarianID:        m.LibrarianID,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return merge, nil
+}
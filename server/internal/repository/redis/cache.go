@@ -0,0 +1,71 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// Номер поколения каталога входит в ключи кэша, поэтому любое изменение
+	// каталога делает все ранее закэшированные ответы недостижимыми
+	catalogGenerationKey   = "opac:generation"
+	responseCacheKeyPrefix = "opac:response:"
+	rateLimitKeyPrefix     = "ratelimit:"
+)
+
+// CatalogGeneration возвращает текущее поколение публичного каталога
+func (r *Redis) CatalogGeneration(ctx context.Context) (int64, error) {
+	generation, err := r.conn.Get(ctx, catalogGenerationKey).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return 0, fmt.Errorf("ошибка при получении поколения каталога: %w", err)
+	}
+	return generation, nil
+}
+
+// InvalidateCatalog сбрасывает кэш публичного каталога после изменения фонда
+func (r *Redis) InvalidateCatalog(ctx context.Context) error {
+	if err := r.conn.Incr(ctx, catalogGenerationKey).Err(); err != nil {
+		return fmt.Errorf("не удалось сбросить кэш каталога: %w", err)
+	}
+	return nil
+}
+
+// GetCachedResponse возвращает закэшированное тело ответа
+func (r *Redis) GetCachedResponse(ctx context.Context, key string) ([]byte, bool, error) {
+	body, err := r.conn.Get(ctx, responseCacheKeyPrefix+key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("ошибка при чтении кэша ответа: %w", err)
+	}
+	return body, true, nil
+}
+
+// SetCachedResponse сохраняет тело ответа в кэше на ttl
+func (r *Redis) SetCachedResponse(ctx context.Context, key string, body []byte, ttl time.Duration) error {
+	if err := r.conn.Set(ctx, responseCacheKeyPrefix+key, body, ttl).Err(); err != nil {
+		return fmt.Errorf("не удалось сохранить ответ в кэше: %w", err)
+	}
+	return nil
+}
+
+// RateLimit учитывает запрос в окне фиксированной длины и возвращает
+// число запросов в окне и время до его сброса
+func (r *Redis) RateLimit(ctx context.Context, key string, window time.Duration) (int64, time.Duration, error) {
+	key = rateLimitKeyPrefix + key
+
+	pipe := r.conn.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.ExpireNX(ctx, key, window)
+	ttl := pipe.PTTL(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, 0, fmt.Errorf("ошибка ограничения частоты запросов: %w", err)
+	}
+
+	return incr.Val(), ttl.Val(), nil
+}
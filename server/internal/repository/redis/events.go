@@ -0,0 +1,103 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// eventsChannel канал pub/sub, через который реплики сервера обмениваются событиями
+const eventsChannel = "library:events"
+
+// Типы событий, рассылаемых клиентам в реальном времени
+const (
+	EventHallOccupancy = "hall.occupancy"
+	EventVisitEntry    = "visit.entry"
+	EventVisitExit     = "visit.exit"
+	EventBookIssued    = "issue.created"
+	EventBookReturned  = "issue.returned"
+)
+
+// Event событие, публикуемое в Redis и отправляемое подписчикам
+type Event struct {
+	Type    string          `json:"type"`
+	Time    time.Time       `json:"time"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// EventSubscription подписка на события всех реплик сервера
+type EventSubscription struct {
+	pubsub *redis.PubSub
+	events chan Event
+}
+
+// PublishEvent публикует событие для всех подписчиков
+func (r *Redis) PublishEvent(ctx context.Context, eventType string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации события: %w", err)
+	}
+
+	message, err := json.Marshal(Event{
+		Type:    eventType,
+		Time:    time.Now(),
+		Payload: data,
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации события: %w", err)
+	}
+
+	if err := r.conn.Publish(ctx, eventsChannel, message).Err(); err != nil {
+		return fmt.Errorf("не удалось опубликовать событие: %w", err)
+	}
+	return nil
+}
+
+// SubscribeEvents подписывается на события; подписку нужно закрыть через Close
+func (r *Redis) SubscribeEvents(ctx context.Context) (*EventSubscription, error) {
+	pubsub := r.conn.Subscribe(ctx, eventsChannel)
+
+	// Дожидаемся подтверждения подписки, чтобы не потерять первые события
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, fmt.Errorf("не удалось подписаться на события: %w", err)
+	}
+
+	sub := &EventSubscription{
+		pubsub: pubsub,
+		events: make(chan Event, 64),
+	}
+	go sub.forward()
+
+	return sub, nil
+}
+
+func (s *EventSubscription) forward() {
+	defer close(s.events)
+
+	for msg := range s.pubsub.Channel() {
+		var event Event
+		if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+			continue
+		}
+
+		select {
+		case s.events <- event:
+		default:
+			// Медленный клиент не должен блокировать получение событий
+		}
+	}
+}
+
+// Events возвращает канал событий; он закрывается после Close
+func (s *EventSubscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close завершает подписку
+func (s *EventSubscription) Close() error {
+	return s.pubsub.Close()
+}
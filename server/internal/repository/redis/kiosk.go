@@ -0,0 +1,42 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Неудачные входы киосков считаются по имени учетной записи, а не по соединению:
+// переподключение терминала не сбрасывает счетчик
+const kioskLoginFailuresKeyPrefix = "sip2:failures:"
+
+// RegisterKioskLoginFailure учитывает неудачный вход киоска и возвращает число попыток в текущем окне
+func (r *Redis) RegisterKioskLoginFailure(ctx context.Context, username string, window time.Duration) (int64, error) {
+	key := kioskLoginFailuresKeyPrefix + username
+
+	pipe := r.conn.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.ExpireNX(ctx, key, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("не удалось учесть попытку входа киоска: %w", err)
+	}
+
+	return incr.Val(), nil
+}
+
+// KioskLoginFailures возвращает число неудачных входов учетной записи киоска
+func (r *Redis) KioskLoginFailures(ctx context.Context, username string) (int64, error) {
+	count, err := r.conn.Get(ctx, kioskLoginFailuresKeyPrefix+username).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return 0, fmt.Errorf("ошибка при получении числа попыток входа киоска: %w", err)
+	}
+	return count, nil
+}
+
+// ResetKioskLoginFailures сбрасывает счетчик после успешного входа
+func (r *Redis) ResetKioskLoginFailures(ctx context.Context, username string) error {
+	return r.conn.Del(ctx, kioskLoginFailuresKeyPrefix+username).Err()
+}
@@ -0,0 +1,166 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Сессии читателей хранятся отдельно от сессий сотрудников,
+// поэтому токен портала не принимается служебными эндпоинтами и наоборот
+const (
+	readerSessionKeyPrefix = "portal:session:"
+	magicLinkKeyPrefix     = "portal:magic:"
+	loginFailuresKeyPrefix = "portal:failures:"
+)
+
+// ErrReaderSessionNotFound сессия читателя истекла или не существовала
+var ErrReaderSessionNotFound = errors.New("сессия читателя не найдена")
+
+// randomToken генерирует случайный токен для URL
+func randomToken() (string, error) {
+	randomBytes := make([]byte, 24)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", fmt.Errorf("ошибка генерации случайных данных: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(randomBytes), nil
+}
+
+// CreateReaderSession создает сессию портала читателя
+func (r *Redis) CreateReaderSession(ctx context.Context, readerID uuid.UUID, ttl time.Duration) (string, error) {
+	sessionID, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
+	if err := r.conn.Set(ctx, readerSessionKeyPrefix+sessionID, readerID.String(), ttl).Err(); err != nil {
+		return "", fmt.Errorf("не удалось создать сессию читателя: %w", err)
+	}
+
+	return sessionID, nil
+}
+
+// GetReaderSession возвращает ID читателя по сессии портала и продлевает ее
+func (r *Redis) GetReaderSession(ctx context.Context, sessionID string, ttl time.Duration) (uuid.UUID, error) {
+	value, err := r.conn.GetEx(ctx, readerSessionKeyPrefix+sessionID, ttl).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return uuid.Nil, ErrReaderSessionNotFound
+		}
+		return uuid.Nil, fmt.Errorf("ошибка при получении сессии читателя: %w", err)
+	}
+
+	readerID, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("ошибка парсинга ID читателя: %w", err)
+	}
+	return readerID, nil
+}
+
+// DeleteReaderSession завершает сессию портала
+func (r *Redis) DeleteReaderSession(ctx context.Context, sessionID string) error {
+	if err := r.conn.Del(ctx, readerSessionKeyPrefix+sessionID).Err(); err != nil {
+		return fmt.Errorf("ошибка при удалении сессии читателя: %w", err)
+	}
+	return nil
+}
+
+// DeleteReaderSessions завершает все сессии портала и неиспользованные ссылки входа указанных читателей
+func (r *Redis) DeleteReaderSessions(ctx context.Context, readerIDs ...uuid.UUID) error {
+	owners := make(map[string]struct{}, len(readerIDs))
+	for _, id := range readerIDs {
+		owners[id.String()] = struct{}{}
+	}
+
+	for _, prefix := range []string{readerSessionKeyPrefix, magicLinkKeyPrefix} {
+		iter := r.conn.Scan(ctx, 0, prefix+"*", 100).Iterator()
+		for iter.Next(ctx) {
+			key := iter.Val()
+			value, err := r.conn.Get(ctx, key).Result()
+			if err != nil {
+				// Ключ мог истечь между SCAN и GET
+				if errors.Is(err, redis.Nil) {
+					continue
+				}
+				return fmt.Errorf("ошибка при получении сессии читателя: %w", err)
+			}
+			if _, ok := owners[value]; !ok {
+				continue
+			}
+			if err := r.conn.Del(ctx, key).Err(); err != nil {
+				return fmt.Errorf("ошибка при удалении сессии читателя: %w", err)
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return fmt.Errorf("ошибка при получении списка сессий читателей: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// CreateMagicLinkToken создает одноразовый токен входа по ссылке
+func (r *Redis) CreateMagicLinkToken(ctx context.Context, readerID uuid.UUID, ttl time.Duration) (string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
+	if err := r.conn.Set(ctx, magicLinkKeyPrefix+token, readerID.String(), ttl).Err(); err != nil {
+		return "", fmt.Errorf("не удалось сохранить токен входа: %w", err)
+	}
+
+	return token, nil
+}
+
+// ConsumeMagicLinkToken возвращает ID читателя и удаляет токен, чтобы ссылкой нельзя было войти повторно
+func (r *Redis) ConsumeMagicLinkToken(ctx context.Context, token string) (uuid.UUID, error) {
+	value, err := r.conn.GetDel(ctx, magicLinkKeyPrefix+token).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return uuid.Nil, ErrReaderSessionNotFound
+		}
+		return uuid.Nil, fmt.Errorf("ошибка при проверке токена входа: %w", err)
+	}
+
+	readerID, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("ошибка парсинга ID читателя: %w", err)
+	}
+	return readerID, nil
+}
+
+// RegisterPortalLoginFailure учитывает неудачную попытку входа по номеру билета
+// и возвращает число попыток в текущем окне
+func (r *Redis) RegisterPortalLoginFailure(ctx context.Context, ticketNumber string, window time.Duration) (int64, error) {
+	key := loginFailuresKeyPrefix + ticketNumber
+
+	pipe := r.conn.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.ExpireNX(ctx, key, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("не удалось учесть попытку входа: %w", err)
+	}
+
+	return incr.Val(), nil
+}
+
+// PortalLoginFailures возвращает число неудачных попыток входа по номеру билета
+func (r *Redis) PortalLoginFailures(ctx context.Context, ticketNumber string) (int64, error) {
+	count, err := r.conn.Get(ctx, loginFailuresKeyPrefix+ticketNumber).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return 0, fmt.Errorf("ошибка при получении числа попыток входа: %w", err)
+	}
+	return count, nil
+}
+
+// ResetPortalLoginFailures сбрасывает счетчик после успешного входа
+func (r *Redis) ResetPortalLoginFailures(ctx context.Context, ticketNumber string) error {
+	return r.conn.Del(ctx, loginFailuresKeyPrefix+ticketNumber).Err()
+}
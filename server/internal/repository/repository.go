@@ -1,20 +1,48 @@
 package repository
 
 import (
+	"context"
+	"fmt"
+
+	"github.com/hnnsly/library-console/internal/copycode"
 	"github.com/hnnsly/library-console/internal/repository/postgres"
 	"github.com/hnnsly/library-console/internal/repository/redis"
+	"github.com/hnnsly/library-console/internal/ticket"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // LibraryRepository фасадный репозиторий для работы с БД и кешем
 type LibraryRepository struct {
 	postgres.Queries
 	redis.Redis
+
+	pool      *pgxpool.Pool
+	copyCodes *copycode.Generator
+	tickets   *ticket.Generator
 }
 
 // New создает новый LibraryRepository
-func New(pg *postgres.Queries, rd *redis.Redis) *LibraryRepository {
+func New(pool *pgxpool.Pool, rd *redis.Redis, copyCodes *copycode.Generator, tickets *ticket.Generator) *LibraryRepository {
 	return &LibraryRepository{
-		Queries: *pg,
-		Redis:   *rd,
+		Queries:   *postgres.New(pool),
+		Redis:     *rd,
+		pool:      pool,
+		copyCodes: copyCodes,
+		tickets:   tickets,
+	}
+}
+
+// inTx выполняет fn в транзакции, откатывая её при ошибке
+func (r *LibraryRepository) inTx(ctx context.Context, fn func(q *postgres.Queries) error) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(r.Queries.WithTx(tx)); err != nil {
+		return err
 	}
+
+	return tx.Commit(ctx)
 }
@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hnnsly/library-console/internal/repository/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+// WebhookEvent тип события, на которое можно подписаться
+type WebhookEvent string
+
+const (
+	WebhookLoanIssued        WebhookEvent = "loan.issued"
+	WebhookLoanReturned      WebhookEvent = "loan.returned"
+	WebhookLoanOverdue       WebhookEvent = "loan.overdue"
+	WebhookFineCreated       WebhookEvent = "fine.created"
+	WebhookFinePaid          WebhookEvent = "fine.paid"
+	WebhookReaderDeactivated WebhookEvent = "reader.deactivated"
+	WebhookHallEntry         WebhookEvent = "hall.entry"
+)
+
+// WebhookEvents все поддерживаемые события в порядке документации
+var WebhookEvents = []WebhookEvent{
+	WebhookLoanIssued,
+	WebhookLoanReturned,
+	WebhookLoanOverdue,
+	WebhookFineCreated,
+	WebhookFinePaid,
+	WebhookReaderDeactivated,
+	WebhookHallEntry,
+}
+
+// IsWebhookEvent проверяет, что событие поддерживается
+func IsWebhookEvent(event string) bool {
+	for _, e := range WebhookEvents {
+		if string(e) == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEnvelope тело запроса, которое получает подписчик
+type WebhookEnvelope struct {
+	ID         uuid.UUID    `json:"id"`
+	Type       WebhookEvent `json:"type"`
+	OccurredAt time.Time    `json:"occurred_at"`
+	Data       any          `json:"data"`
+}
+
+// overdueWebhookBatch сколько просроченных выдач обрабатывается за один проход
+const overdueWebhookBatch = 200
+
+// emitWebhook записывает событие в outbox для всех активных подписок в рамках текущей транзакции.
+// Если подписчиков нет, запрос ничего не вставляет
+func emitWebhook(ctx context.Context, q *postgres.Queries, event WebhookEvent, subjectID *uuid.UUID, data any) error {
+	envelope := WebhookEnvelope{
+		ID:         uuid.New(),
+		Type:       event,
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	}
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("сериализация события %s: %w", event, err)
+	}
+
+	_, err = q.CreateWebhookOutboxEntries(ctx, postgres.CreateWebhookOutboxEntriesParams{
+		EventID:   envelope.ID,
+		EventType: string(event),
+		SubjectID: subjectID,
+		Payload:   payload,
+	})
+	if err != nil {
+		return fmt.Errorf("запись события %s в outbox: %w", event, err)
+	}
+	return nil
+}
+
+// emitLoanWebhook публикует снимок выдачи
+func emitLoanWebhook(ctx context.Context, q *postgres.Queries, event WebhookEvent, issueID uuid.UUID) error {
+	loan, err := q.GetWebhookLoan(ctx, issueID)
+	if err != nil {
+		return err
+	}
+	return emitWebhook(ctx, q, event, &issueID, loan)
+}
+
+// emitFineWebhook публикует снимок штрафа
+func emitFineWebhook(ctx context.Context, q *postgres.Queries, event WebhookEvent, fineID uuid.UUID) error {
+	fine, err := q.GetWebhookFine(ctx, fineID)
+	if err != nil {
+		return err
+	}
+	return emitWebhook(ctx, q, event, &fineID, fine)
+}
+
+// emitReaderDeactivatedWebhook публикует деактивацию читателя с указанием причины
+func emitReaderDeactivatedWebhook(ctx context.Context, q *postgres.Queries, readerID uuid.UUID, reason string, mergedInto *uuid.UUID) error {
+	reader, err := q.GetWebhookReader(ctx, readerID)
+	if err != nil {
+		return err
+	}
+	return emitWebhook(ctx, q, WebhookReaderDeactivated, &readerID, struct {
+		*postgres.GetWebhookReaderRow
+		Reason     string     `json:"reason"`
+		MergedInto *uuid.UUID `json:"merged_into,omitempty"`
+	}{reader, reason, mergedInto})
+}
+
+// ChargeFine начисляет штраф и публикует событие fine.created
+func (r *LibraryRepository) ChargeFine(ctx context.Context, arg postgres.CreateFineParams) (*postgres.CreateFineRow, error) {
+	var fine *postgres.CreateFineRow
+	err := r.inTx(ctx, func(q *postgres.Queries) error {
+		var err error
+		fine, err = q.CreateFine(ctx, arg)
+		if err != nil {
+			return err
+		}
+		return emitFineWebhook(ctx, q, WebhookFineCreated, fine.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return fine, nil
+}
+
+// SettleFine отмечает штраф оплаченным и публикует событие fine.paid
+func (r *LibraryRepository) SettleFine(ctx context.Context, fineID uuid.UUID) (*postgres.PayFineRow, error) {
+	var fine *postgres.PayFineRow
+	err := r.inTx(ctx, func(q *postgres.Queries) error {
+		var err error
+		fine, err = q.PayFine(ctx, fineID)
+		if err != nil {
+			return err
+		}
+		return emitFineWebhook(ctx, q, WebhookFinePaid, fine.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return fine, nil
+}
+
+// CloseReaderAccount деактивирует читателя и публикует событие reader.deactivated
+func (r *LibraryRepository) CloseReaderAccount(ctx context.Context, readerID uuid.UUID) error {
+	return r.inTx(ctx, func(q *postgres.Queries) error {
+		if err := q.DeactivateReader(ctx, readerID); err != nil {
+			return err
+		}
+		return emitReaderDeactivatedWebhook(ctx, q, readerID, "deactivated", nil)
+	})
+}
+
+// EnterHall регистрирует вход в зал, отмечает приход по текущей брони читателя, если она есть,
+// и публикует событие hall.entry в одной транзакции
+func (r *LibraryRepository) EnterHall(ctx context.Context, arg postgres.RegisterHallEntryParams) (*postgres.RegisterHallEntryRow, *postgres.CheckInSeatBookingRow, error) {
+	var entry *postgres.RegisterHallEntryRow
+	var booking *postgres.CheckInSeatBookingRow
+	err := r.inTx(ctx, func(q *postgres.Queries) error {
+		var err error
+		entry, err = q.RegisterHallEntry(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		booking, err = q.CheckInSeatBooking(ctx, postgres.CheckInSeatBookingParams{
+			VisitID:      &entry.ID,
+			HallID:       arg.HallID,
+			TicketNumber: arg.TicketNumber,
+		})
+		if err != nil {
+			if !errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("отметка прихода по брони: %w", err)
+			}
+			booking = nil
+		}
+
+		visit, err := q.GetWebhookHallVisit(ctx, entry.ID)
+		if err != nil {
+			return err
+		}
+		return emitWebhook(ctx, q, WebhookHallEntry, &entry.ID, visit)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return entry, booking, nil
+}
+
+// EmitOverdueLoanWebhooks публикует loan.overdue один раз для каждой просроченной выдачи.
+// Возвращает число выдач, по которым событие записано в outbox
+func (r *LibraryRepository) EmitOverdueLoanWebhooks(ctx context.Context) (int, error) {
+	emitted := 0
+	for {
+		ids, err := r.GetLoansForOverdueWebhook(ctx, overdueWebhookBatch)
+		if err != nil {
+			return emitted, err
+		}
+
+		for _, id := range ids {
+			err := r.inTx(ctx, func(q *postgres.Queries) error {
+				return emitLoanWebhook(ctx, q, WebhookLoanOverdue, id)
+			})
+			if err != nil {
+				return emitted, fmt.Errorf("выдача %s: %w", id, err)
+			}
+			emitted++
+		}
+
+		if len(ids) < overdueWebhookBatch {
+			return emitted, nil
+		}
+	}
+}
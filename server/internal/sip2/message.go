@@ -0,0 +1,205 @@
+package sip2
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Коды сообщений SIP2, которые обрабатывает сервер
+const (
+	codeCheckin           = "09"
+	codeCheckout          = "11"
+	codePatronStatus      = "23"
+	codeRenew             = "29"
+	codeEndPatronSession  = "35"
+	codeFeePaid           = "37"
+	codePatronInformation = "63"
+	codeLogin             = "93"
+	codeRequestSCResend   = "96"
+	codeRequestACSResend  = "97"
+	codeSCStatus          = "99"
+)
+
+// fixedLengths длина фиксированной части запроса после кода сообщения
+var fixedLengths = map[string]int{
+	codeCheckin:           37,
+	codeCheckout:          38,
+	codePatronStatus:      21,
+	codeRenew:             38,
+	codeEndPatronSession:  18,
+	codeFeePaid:           25,
+	codePatronInformation: 31,
+	codeLogin:             2,
+	codeRequestACSResend:  0,
+	codeSCStatus:          8,
+}
+
+var (
+	// ErrChecksum контрольная сумма сообщения не совпала
+	ErrChecksum = errors.New("неверная контрольная сумма")
+	// ErrMalformed сообщение короче своей фиксированной части
+	ErrMalformed = errors.New("некорректное сообщение")
+)
+
+// Message разобранный запрос терминала
+type Message struct {
+	Code   string
+	Fixed  string
+	Fields map[string][]string
+	// Seq номер последовательности из поля AY, пустой без контроля ошибок
+	Seq string
+	// Checked запрос пришел с контрольной суммой AZ
+	Checked bool
+}
+
+// Field возвращает первое значение поля или пустую строку
+func (m *Message) Field(id string) string {
+	if values := m.Fields[id]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// FixedAt возвращает часть фиксированной области; выход за границы дает пустую строку
+func (m *Message) FixedAt(offset, length int) string {
+	if offset+length > len(m.Fixed) {
+		return ""
+	}
+	return m.Fixed[offset : offset+length]
+}
+
+// Parse разбирает строку запроса без завершающего CR и проверяет контрольную сумму, если она есть
+func Parse(line string) (*Message, error) {
+	m := &Message{Fields: map[string][]string{}}
+
+	body := line
+	if n := len(body); n >= 6 && body[n-6:n-4] == "AZ" {
+		if Checksum(body[:n-4]) != strings.ToUpper(body[n-4:]) {
+			return nil, ErrChecksum
+		}
+		m.Checked = true
+		body = body[:n-6]
+		// Номер последовательности стоит непосредственно перед AZ
+		if n := len(body); n >= 3 && body[n-3:n-1] == "AY" && body[n-1] >= '0' && body[n-1] <= '9' {
+			m.Seq = body[n-1:]
+			body = body[:n-3]
+		}
+	}
+
+	if len(body) < 2 {
+		return nil, ErrMalformed
+	}
+	m.Code = body[:2]
+	fixed := fixedLengths[m.Code]
+	if len(body) < 2+fixed {
+		return nil, fmt.Errorf("%w: %s", ErrMalformed, m.Code)
+	}
+	m.Fixed = body[2 : 2+fixed]
+
+	for _, f := range strings.Split(body[2+fixed:], "|") {
+		if len(f) < 2 {
+			continue
+		}
+		m.Fields[f[:2]] = append(m.Fields[f[:2]], f[2:])
+	}
+
+	return m, nil
+}
+
+// Checksum вычисляет контрольную сумму SIP2: дополнение до двух суммы байтов, включая «AZ»
+func Checksum(s string) string {
+	var sum uint16
+	for i := 0; i < len(s); i++ {
+		sum += uint16(s[i])
+	}
+	return fmt.Sprintf("%04X", -sum)
+}
+
+// response собирает ответ: код, фиксированные поля и поля переменной длины с разделителем «|»
+type response struct {
+	b strings.Builder
+}
+
+func newResponse(code string) *response {
+	r := &response{}
+	r.b.WriteString(code)
+	return r
+}
+
+// fixed добавляет значения фиксированной области в порядке спецификации
+func (r *response) fixed(values ...string) *response {
+	for _, v := range values {
+		r.b.WriteString(v)
+	}
+	return r
+}
+
+// field добавляет поле, даже пустое; разделитель «|» внутри значения недопустим
+func (r *response) field(id, value string) *response {
+	r.b.WriteString(id)
+	r.b.WriteString(strings.NewReplacer("|", "/", "\r", " ", "\n", " ").Replace(value))
+	r.b.WriteByte('|')
+	return r
+}
+
+// optional добавляет поле, только если значение не пустое
+func (r *response) optional(id, value string) *response {
+	if value != "" {
+		r.field(id, value)
+	}
+	return r
+}
+
+// encode завершает ответ; если запрос пришел с контролем ошибок, ответ получает тот же AY и свою AZ
+func (r *response) encode(req *Message) string {
+	s := r.b.String()
+	if req != nil && req.Checked {
+		if req.Seq != "" {
+			s += "AY" + req.Seq
+		}
+		s += "AZ"
+		s += Checksum(s)
+	}
+	return s + "\r"
+}
+
+// formatDate форматирует дату в виде YYYYMMDDZZZZHHMMSS с местной зоной (четыре пробела)
+func formatDate(t time.Time) string {
+	return t.Format("20060102") + "    " + t.Format("150405")
+}
+
+// yn переводит флаг в Y/N
+func yn(b bool) string {
+	if b {
+		return "Y"
+	}
+	return "N"
+}
+
+// ok переводит флаг в 1/0
+func ok(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+// count форматирует счетчик фиксированной области шириной 4 символа
+func count(n int) string {
+	return fmt.Sprintf("%04d", min(max(n, 0), 9999))
+}
+
+// itemRange читает BP/BQ — номера первого и последнего элемента списка, начиная с 1
+func itemRange(m *Message, total int) (from, to int) {
+	from, to = 1, total
+	if v, err := strconv.Atoi(m.Field("BP")); err == nil && v > 0 {
+		from = v
+	}
+	if v, err := strconv.Atoi(m.Field("BQ")); err == nil && v >= from {
+		to = min(v, total)
+	}
+	return from, to
+}
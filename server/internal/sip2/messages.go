@@ -0,0 +1,89 @@
+package sip2
+
+// Коды языков SIP2 для поддерживаемых языков читателей
+const (
+	languageUnknown = "000"
+	languageEnglish = "001"
+	languageRussian = "015"
+)
+
+// screenMessages тексты для экрана киоска (поле AF) на языках читателей
+var screenMessages = map[string]map[string]string{
+	"ru": {
+		"patron_unknown":        "Читательский билет не найден",
+		"pin_invalid":           "Неверный PIN-код",
+		"pin_locked":            "Слишком много попыток ввода PIN, попробуйте позже",
+		"inactive":              "Читательский билет заблокирован, обратитесь к библиотекарю",
+		"expired":               "Срок членства истёк, обратитесь к библиотекарю",
+		"loan_limit":            "Достигнут лимит выданных книг",
+		"overdue":               "Сначала верните просроченные книги",
+		"copy_unavailable":      "Этот экземпляр нельзя выдать, обратитесь к библиотекарю",
+		"copy_not_found":        "Экземпляр не найден",
+		"not_checked_out":       "Экземпляр не числится выданным",
+		"issued_to_other":       "Экземпляр выдан другому читателю",
+		"renewal_limit":         "Достигнут лимит продлений",
+		"renew_overdue":         "Просроченную книгу можно продлить только у библиотекаря",
+		"renew_past_membership": "Продлите членство, чтобы продлить книгу",
+		"fine_not_found":        "Штраф не найден",
+		"fine_amount":           "Сумма не совпадает с суммой штрафа",
+		"fine_paid":             "Штраф уже оплачен",
+		"currency":              "Неподдерживаемая валюта",
+		"checked_out":           "Книга выдана, вернуть до",
+		"renewed":               "Книга продлена до",
+		"returned":              "Книга возвращена, спасибо",
+		"paid":                  "Оплата принята",
+		"error":                 "Операция не выполнена, обратитесь к библиотекарю",
+	},
+	"en": {
+		"patron_unknown":        "Library card not found",
+		"pin_invalid":           "Invalid PIN",
+		"pin_locked":            "Too many PIN attempts, try again later",
+		"inactive":              "Library card is blocked, please see a librarian",
+		"expired":               "Membership has expired, please see a librarian",
+		"loan_limit":            "Loan limit reached",
+		"overdue":               "Please return overdue items first",
+		"copy_unavailable":      "This item cannot be checked out, please see a librarian",
+		"copy_not_found":        "Item not found",
+		"not_checked_out":       "Item is not checked out",
+		"issued_to_other":       "Item is checked out to another reader",
+		"renewal_limit":         "Renewal limit reached",
+		"renew_overdue":         "Overdue items can only be renewed at the desk",
+		"renew_past_membership": "Renew your membership to renew this item",
+		"fine_not_found":        "Fine not found",
+		"fine_amount":           "Amount does not match the fine",
+		"fine_paid":             "Fine is already paid",
+		"currency":              "Unsupported currency",
+		"checked_out":           "Checked out, due",
+		"renewed":               "Renewed, due",
+		"returned":              "Item returned, thank you",
+		"paid":                  "Payment accepted",
+		"error":                 "Transaction failed, please see a librarian",
+	},
+}
+
+// screen возвращает текст для экрана киоска; незнакомый язык заменяется русским
+func screen(lang, key string) string {
+	if messages, ok := screenMessages[lang]; ok {
+		return messages[key]
+	}
+	return screenMessages["ru"][key]
+}
+
+// requestLanguage переводит код языка SIP2 из запроса в язык читателя
+func requestLanguage(code string) string {
+	if code == languageEnglish {
+		return "en"
+	}
+	return "ru"
+}
+
+// sipLanguage переводит язык читателя в код языка SIP2
+func sipLanguage(lang string) string {
+	switch lang {
+	case "en":
+		return languageEnglish
+	case "ru":
+		return languageRussian
+	}
+	return languageUnknown
+}
@@ -0,0 +1,146 @@
+package sip2
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hnnsly/library-console/internal/circulation"
+	"github.com/hnnsly/library-console/internal/config"
+	"github.com/hnnsly/library-console/internal/repository"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// maxMessageLength длиннее сообщения SIP2 не бывают; защищает от бесконечной строки без CR
+	maxMessageLength = 4096
+	// requestTimeout ограничивает обработку одного сообщения
+	requestTimeout = 30 * time.Second
+	// writeTimeout ограничивает отправку ответа
+	writeTimeout = 10 * time.Second
+)
+
+// errCloseConnection обработчик требует закрыть соединение после ответа
+var errCloseConnection = errors.New("соединение закрывается")
+
+// Server TCP-сервер SIP2 для киосков самообслуживания и RFID-ворот
+type Server struct {
+	cfg  config.SIP2Config
+	repo *repository.LibraryRepository
+	desk *circulation.Desk
+
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+	wg    sync.WaitGroup
+}
+
+// NewServer создает сервер; выдача и возврат идут через те же правила, что и в REST API
+func NewServer(cfg config.SIP2Config, repo *repository.LibraryRepository, desk *circulation.Desk) *Server {
+	return &Server{
+		cfg:   cfg,
+		repo:  repo,
+		desk:  desk,
+		conns: map[net.Conn]struct{}{},
+	}
+}
+
+// ListenAndServe принимает соединения до отмены ctx, затем закрывает их и ждет обработчики
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", s.cfg.Port))
+	if err != nil {
+		return err
+	}
+	log.Info().Msgf("SIP2 server listening on %s", ln.Addr())
+
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+		s.mu.Lock()
+		for conn := range s.conns {
+			conn.Close()
+		}
+		s.mu.Unlock()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				s.wg.Wait()
+				return nil
+			}
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				continue
+			}
+			return err
+		}
+
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer func() {
+				s.mu.Lock()
+				delete(s.conns, conn)
+				s.mu.Unlock()
+				conn.Close()
+			}()
+			s.serve(ctx, conn)
+		}()
+	}
+}
+
+// serve читает сообщения, разделенные CR, и отвечает на каждое
+func (s *Server) serve(ctx context.Context, conn net.Conn) {
+	sess := &session{srv: s, remote: conn.RemoteAddr().String()}
+	reader := bufio.NewReaderSize(conn, maxMessageLength)
+	logger := log.With().Str("remote", sess.remote).Logger()
+	logger.Info().Msg("SIP2 connection opened")
+	defer logger.Info().Msg("SIP2 connection closed")
+
+	for {
+		if err := conn.SetReadDeadline(time.Now().Add(s.cfg.IdleTimeout)); err != nil {
+			return
+		}
+		raw, err := reader.ReadSlice('\r')
+		if err != nil {
+			if errors.Is(err, bufio.ErrBufferFull) {
+				logger.Warn().Msg("SIP2 message too long")
+			}
+			return
+		}
+		// Часть терминалов завершает сообщения CRLF, LF тогда оказывается в начале следующего
+		line := strings.Trim(string(raw), "\r\n")
+		if line == "" {
+			continue
+		}
+
+		reqCtx, cancel := context.WithTimeout(ctx, requestTimeout)
+		reply, err := sess.handle(reqCtx, line)
+		cancel()
+
+		if reply != "" {
+			if werr := conn.SetWriteDeadline(time.Now().Add(writeTimeout)); werr != nil {
+				return
+			}
+			if _, werr := conn.Write([]byte(reply)); werr != nil {
+				return
+			}
+		}
+		if err != nil {
+			if !errors.Is(err, errCloseConnection) {
+				logger.Warn().Err(err).Msg("SIP2 connection dropped")
+			}
+			return
+		}
+	}
+}
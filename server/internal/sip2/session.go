@@ -0,0 +1,244 @@
+package sip2
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hnnsly/library-console/internal/repository/postgres"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// pinFailureWindow столько помнятся неудачные попытки PIN; счетчик общий с порталом
+	pinFailureWindow = 15 * time.Minute
+	// loginFailureWindow столько помнятся неудачные входы учетной записи киоска
+	loginFailureWindow = 15 * time.Minute
+)
+
+// session состояние одного соединения терминала
+type session struct {
+	srv    *Server
+	remote string
+
+	// Учетная запись киоска после успешного Login (93); от ее имени оформляются выдачи
+	kioskID uuid.UUID
+	kiosk   string
+
+	// Последний запрос и ответ: повтор того же запроса и Request ACS Resend (97) получают
+	// сохраненный ответ, так что выдача не оформляется дважды
+	lastRequest  string
+	lastResponse string
+}
+
+// handle обрабатывает одну строку и возвращает ответ; ошибка означает, что соединение нужно закрыть
+func (s *session) handle(ctx context.Context, line string) (string, error) {
+	m, err := Parse(line)
+	if err != nil {
+		log.Warn().Err(err).Str("remote", s.remote).Msg("Invalid SIP2 message, requesting resend")
+		return codeRequestSCResend + "\r", nil
+	}
+	if s.srv.cfg.RequireChecksum && !m.Checked {
+		return codeRequestSCResend + "\r", nil
+	}
+	// Терминал повторяет запрос с тем же номером, если не дождался ответа
+	if m.Seq != "" && line == s.lastRequest {
+		return s.lastResponse, nil
+	}
+
+	var r *response
+	switch m.Code {
+	case codeRequestACSResend:
+		return s.lastResponse, nil
+	case codeLogin:
+		r, err = s.login(ctx, m)
+		return s.remember(line, m, r, err)
+	case codeSCStatus:
+		return s.remember(line, m, s.status(m), nil)
+	}
+
+	if s.kiosk == "" {
+		log.Warn().Str("remote", s.remote).Str("code", m.Code).Msg("SIP2 message before login")
+		return "", errCloseConnection
+	}
+
+	switch m.Code {
+	case codePatronStatus:
+		r = s.patronStatus(ctx, m)
+	case codePatronInformation:
+		r = s.patronInformation(ctx, m)
+	case codeCheckout:
+		r = s.checkout(ctx, m)
+	case codeCheckin:
+		r = s.checkin(ctx, m)
+	case codeRenew:
+		r = s.renew(ctx, m)
+	case codeFeePaid:
+		r = s.feePaid(ctx, m)
+	case codeEndPatronSession:
+		r = s.endPatronSession(m)
+	default:
+		log.Warn().Str("remote", s.remote).Str("code", m.Code).Msg("Unsupported SIP2 message")
+		return "", nil
+	}
+
+	return s.remember(line, m, r, nil)
+}
+
+func (s *session) remember(line string, m *Message, r *response, err error) (string, error) {
+	reply := r.encode(m)
+	s.lastRequest, s.lastResponse = line, reply
+	return reply, err
+}
+
+// login (93) проверяет служебную учетную запись киоска: роль kiosk, активна, пароль совпадает
+func (s *session) login(ctx context.Context, m *Message) (*response, error) {
+	username, password := m.Field("CN"), m.Field("CO")
+
+	// Подбор пароля ограничен на учетную запись, сколько бы соединений ни открывал терминал
+	failures, err := s.srv.repo.KioskLoginFailures(ctx, username)
+	if err != nil {
+		log.Error().Err(err).Str("remote", s.remote).Msg("Failed to check SIP2 login attempts")
+		return newResponse("94").fixed(ok(false)), nil
+	}
+	if failures >= int64(s.srv.cfg.MaxLoginAttempts) {
+		log.Warn().Str("remote", s.remote).Str("username", username).Msg("SIP2 login locked")
+		return newResponse("94").fixed(ok(false)), errCloseConnection
+	}
+
+	user, err := s.srv.repo.GetUserByUsername(ctx, username)
+	if err == nil && user.Role != postgres.UserRoleKiosk {
+		err = errors.New("учетная запись не является киоском")
+	}
+	if err == nil && user.IsActive != nil && !*user.IsActive {
+		err = errors.New("учетная запись деактивирована")
+	}
+	if err == nil {
+		err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("remote", s.remote).Str("username", username).Msg("SIP2 login failed")
+		failures, ferr := s.srv.repo.RegisterKioskLoginFailure(ctx, username, loginFailureWindow)
+		if ferr != nil {
+			log.Warn().Err(ferr).Msg("Failed to register SIP2 login failure")
+		}
+		if failures >= int64(s.srv.cfg.MaxLoginAttempts) {
+			return newResponse("94").fixed(ok(false)), errCloseConnection
+		}
+		return newResponse("94").fixed(ok(false)), nil
+	}
+
+	if err := s.srv.repo.ResetKioskLoginFailures(ctx, username); err != nil {
+		log.Warn().Err(err).Msg("Failed to reset SIP2 login failures")
+	}
+	s.kioskID, s.kiosk = user.ID, user.Username
+	log.Info().Str("remote", s.remote).Str("username", username).Msg("SIP2 kiosk logged in")
+	return newResponse("94").fixed(ok(true)), nil
+}
+
+// status (99) сообщает терминалу, какие операции доступны
+func (s *session) status(m *Message) *response {
+	// Порядок флагов BX: patron status, checkout, checkin, block patron, SC/ACS status,
+	// resend, login, patron information, end session, fee paid, item information,
+	// item status update, patron enable, hold, renew, renew all
+	const supported = "YYYNYYYYYYNNNNYN"
+	return newResponse("98").
+		fixed(
+			yn(true),  // online
+			yn(true),  // checkin ok
+			yn(true),  // checkout ok
+			yn(true),  // ACS renewal policy
+			yn(false), // status update ok
+			yn(false), // offline ok
+			"100",     // timeout, десятые доли секунды
+			"003",     // retries allowed
+			formatDate(time.Now()),
+			"2.00",
+		).
+		field("AO", s.srv.cfg.InstitutionID).
+		field("AM", s.srv.cfg.LibraryName).
+		field("BX", supported)
+}
+
+// patron читатель из запроса с результатом проверки PIN
+type patron struct {
+	reader *postgres.GetReaderByTicketNumberRow
+	lang   string
+	// authorized читатель найден и PIN проверен или не требуется
+	authorized bool
+	// verified PIN передан и проверен; только тогда киоску отдаются контакты и списки книг
+	verified bool
+	// pin результат проверки PIN для поля CQ; пустой, если PIN не передавался
+	pin string
+	// refusal ключ текста для экрана, если читатель не прошел проверку
+	refusal string
+}
+
+// langOrDefault язык читателя; без найденного читателя — язык по умолчанию
+func (p *patron) langOrDefault() string {
+	if p == nil {
+		return requestLanguage(languageUnknown)
+	}
+	return p.lang
+}
+
+// lookupPatron ищет читателя по AA и проверяет PIN из AD тем же счетчиком неудач, что и портал
+func (s *session) lookupPatron(ctx context.Context, m *Message, langCode string) (*patron, error) {
+	p := &patron{lang: requestLanguage(langCode)}
+	ticket := m.Field("AA")
+
+	reader, err := s.srv.repo.GetReaderByTicketNumber(ctx, ticket)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			p.refusal = "patron_unknown"
+			return p, nil
+		}
+		return nil, err
+	}
+	p.reader, p.lang = reader, reader.Language
+
+	pin := m.Field("AD")
+	if pin == "" {
+		p.authorized = !*s.srv.cfg.RequirePatronPin
+		if !p.authorized {
+			p.refusal = "pin_invalid"
+		}
+		return p, nil
+	}
+
+	failures, err := s.srv.repo.PortalLoginFailures(ctx, ticket)
+	if err != nil {
+		return nil, err
+	}
+	if failures >= int64(s.srv.cfg.MaxPinAttempts) {
+		p.pin, p.refusal = yn(false), "pin_locked"
+		return p, nil
+	}
+
+	creds, err := s.srv.repo.GetReaderPortalCredentials(ctx, ticket)
+	if err != nil {
+		return nil, err
+	}
+	if creds.PinHash == nil || bcrypt.CompareHashAndPassword([]byte(*creds.PinHash), []byte(pin)) != nil {
+		if _, err := s.srv.repo.RegisterPortalLoginFailure(ctx, ticket, pinFailureWindow); err != nil {
+			log.Warn().Err(err).Msg("Failed to register SIP2 PIN failure")
+		}
+		p.pin, p.refusal = yn(false), "pin_invalid"
+		return p, nil
+	}
+	if err := s.srv.repo.ResetPortalLoginFailures(ctx, ticket); err != nil {
+		log.Warn().Err(err).Msg("Failed to reset SIP2 PIN failures")
+	}
+
+	p.pin, p.authorized, p.verified = yn(true), true, true
+	return p, nil
+}
+
+// transactionFailed записывает сбой операции; терминал получает отказ с общим текстом
+func (s *session) transactionFailed(err error, m *Message) {
+	log.Error().Err(err).Str("remote", s.remote).Str("kiosk", s.kiosk).Str("code", m.Code).
+		Str("patron", m.Field("AA")).Str("item", m.Field("AB")).Msg("SIP2 transaction failed")
+}
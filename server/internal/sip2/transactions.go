@@ -0,0 +1,512 @@
+package sip2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/govalues/decimal"
+	"github.com/hnnsly/library-console/internal/circulation"
+	"github.com/hnnsly/library-console/internal/config"
+	"github.com/hnnsly/library-console/internal/repository"
+	"github.com/hnnsly/library-console/internal/repository/postgres"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// Позиции флагов в 14-символьном статусе читателя
+const (
+	flagChargeDenied = iota
+	flagRenewalDenied
+	flagRecallDenied
+	flagHoldDenied
+	_ // card reported lost
+	flagTooManyCharged
+	flagTooManyOverdue
+)
+
+// unknownPatronFlags статус для неизвестного читателя: все привилегии запрещены
+const unknownPatronFlags = "YYYY          "
+
+// account состояние читателя для ответов Patron Status (24) и Patron Information (64)
+type account struct {
+	flags   string
+	loans   []*postgres.GetReaderActiveBooksRow
+	fines   []*postgres.GetReaderFinesRow
+	owed    decimal.Decimal
+	policy  config.MembershipCategoryConfig
+	refusal string
+}
+
+// refusals тексты экрана для отказов по правилам выдачи, возврата и продления
+var refusals = []struct {
+	err error
+	key string
+}{
+	{circulation.ErrReaderNotFound, "patron_unknown"},
+	{circulation.ErrReaderInactive, "inactive"},
+	{circulation.ErrMembershipExpired, "expired"},
+	{circulation.ErrLoanLimit, "loan_limit"},
+	{circulation.ErrReaderOverdue, "overdue"},
+	{circulation.ErrCopyUnavailable, "copy_unavailable"},
+	{circulation.ErrCopyNotFound, "copy_not_found"},
+	{circulation.ErrNotCheckedOut, "not_checked_out"},
+	{circulation.ErrIssuedToOther, "issued_to_other"},
+	{repository.ErrLoanReturned, "not_checked_out"},
+	{repository.ErrLoanOverdue, "renew_overdue"},
+	{repository.ErrRenewalLimit, "renewal_limit"},
+	{repository.ErrRenewalPastMembership, "renew_past_membership"},
+}
+
+// refusal подбирает текст для ожидаемого отказа; false означает внутреннюю ошибку
+func refusal(err error) (string, bool) {
+	for _, r := range refusals {
+		if errors.Is(err, r.err) {
+			return r.key, true
+		}
+	}
+	return "", false
+}
+
+// displayDate дата для экрана киоска в привычном читателю виде
+func displayDate(lang string, t time.Time) string {
+	if lang == "en" {
+		return t.Format("2006-01-02")
+	}
+	return t.Format("02.01.2006")
+}
+
+// loadAccount собирает статус, выдачи и неоплаченные штрафы читателя
+func (s *session) loadAccount(ctx context.Context, p *patron) (*account, error) {
+	a := &account{flags: unknownPatronFlags, owed: decimal.Zero, refusal: p.refusal}
+	if p.reader == nil {
+		return a, nil
+	}
+
+	flags := []byte("              ")
+	policy, err := s.srv.desk.Eligibility(ctx, p.reader.ID)
+	a.policy = policy
+	switch {
+	case err == nil:
+	case errors.Is(err, circulation.ErrLoanLimit):
+		flags[flagChargeDenied], flags[flagTooManyCharged] = 'Y', 'Y'
+	case errors.Is(err, circulation.ErrReaderOverdue):
+		flags[flagChargeDenied], flags[flagTooManyOverdue] = 'Y', 'Y'
+	case errors.Is(err, circulation.ErrReaderInactive), errors.Is(err, circulation.ErrMembershipExpired),
+		errors.Is(err, circulation.ErrPolicyMissing):
+		for _, i := range []int{flagChargeDenied, flagRenewalDenied, flagRecallDenied, flagHoldDenied} {
+			flags[i] = 'Y'
+		}
+	default:
+		return nil, err
+	}
+	if key, ok := refusal(err); ok && a.refusal == "" {
+		a.refusal = key
+	}
+	a.flags = string(flags)
+
+	a.loans, err = s.srv.repo.GetReaderActiveBooks(ctx, p.reader.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	fines, err := s.srv.repo.GetReaderFines(ctx, p.reader.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range fines {
+		if f.IsPaid != nil && *f.IsPaid {
+			continue
+		}
+		a.fines = append(a.fines, f)
+		if a.owed, err = a.owed.Add(f.Amount); err != nil {
+			return nil, err
+		}
+	}
+
+	return a, nil
+}
+
+// overdueLoans выдачи со сроком возврата раньше сегодняшнего дня
+func (a *account) overdueLoans() []*postgres.GetReaderActiveBooksRow {
+	today := time.Now().Format(time.DateOnly)
+	var overdue []*postgres.GetReaderActiveBooksRow
+	for _, l := range a.loans {
+		if l.DueDate.Format(time.DateOnly) < today {
+			overdue = append(overdue, l)
+		}
+	}
+	return overdue
+}
+
+// patronStatus (23) статус читателя и сумма долга
+func (s *session) patronStatus(ctx context.Context, m *Message) *response {
+	now := time.Now()
+	p, err := s.lookupPatron(ctx, m, m.FixedAt(0, 3))
+	var a *account
+	if err == nil {
+		a, err = s.loadAccount(ctx, p)
+	}
+	if err != nil {
+		s.transactionFailed(err, m)
+		lang := requestLanguage(m.FixedAt(0, 3))
+		return newResponse("24").
+			fixed(unknownPatronFlags, sipLanguage(lang), formatDate(now)).
+			field("AO", s.srv.cfg.InstitutionID).
+			field("AA", m.Field("AA")).
+			field("AE", "").
+			field("BL", yn(false)).
+			field("AF", screen(lang, "error"))
+	}
+
+	return newResponse("24").
+		fixed(a.flags, sipLanguage(p.lang), formatDate(now)).
+		field("AO", s.srv.cfg.InstitutionID).
+		field("AA", m.Field("AA")).
+		field("AE", patronName(p)).
+		field("BL", yn(p.reader != nil)).
+		optional("CQ", p.pin).
+		field("BH", s.srv.cfg.Currency).
+		field("BV", a.owed.String()).
+		optional("AF", screen(p.lang, a.refusal))
+}
+
+// patronInformation (63) статус читателя со списками просроченных, выданных книг и штрафов
+func (s *session) patronInformation(ctx context.Context, m *Message) *response {
+	now := time.Now()
+	p, err := s.lookupPatron(ctx, m, m.FixedAt(0, 3))
+	var a *account
+	if err == nil {
+		a, err = s.loadAccount(ctx, p)
+	}
+	if err != nil {
+		s.transactionFailed(err, m)
+		lang := requestLanguage(m.FixedAt(0, 3))
+		return newResponse("64").
+			fixed(unknownPatronFlags, sipLanguage(lang), formatDate(now), count(0), count(0), count(0), count(0), count(0), count(0)).
+			field("AO", s.srv.cfg.InstitutionID).
+			field("AA", m.Field("AA")).
+			field("AE", "").
+			field("BL", yn(false)).
+			field("AF", screen(lang, "error"))
+	}
+
+	overdue := a.overdueLoans()
+	r := newResponse("64").
+		fixed(a.flags, sipLanguage(p.lang), formatDate(now),
+			count(0), // hold items
+			count(len(overdue)),
+			count(len(a.loans)),
+			count(len(a.fines)),
+			count(0), // recall items
+			count(0), // unavailable holds
+		).
+		field("AO", s.srv.cfg.InstitutionID).
+		field("AA", m.Field("AA")).
+		field("AE", patronName(p))
+	if p.reader != nil {
+		r.field("CB", count(a.policy.MaxLoans))
+	}
+	r.field("BL", yn(p.reader != nil)).
+		optional("CQ", p.pin).
+		field("BH", s.srv.cfg.Currency).
+		field("BV", a.owed.String())
+
+	// Списки и контакты выдаются, только если терминал передал верный PIN, даже когда PIN не обязателен
+	if p.verified {
+		summary := m.FixedAt(21, 10)
+		switch {
+		case len(summary) > 1 && summary[1] == 'Y':
+			from, to := itemRange(m, len(overdue))
+			for i := from; i <= to; i++ {
+				r.field("AT", overdue[i-1].CopyCode)
+			}
+		case len(summary) > 2 && summary[2] == 'Y':
+			from, to := itemRange(m, len(a.loans))
+			for i := from; i <= to; i++ {
+				r.field("AU", a.loans[i-1].CopyCode)
+			}
+		case len(summary) > 3 && summary[3] == 'Y':
+			// Идентификатор штрафа передается киоску обратно в поле CG при оплате
+			from, to := itemRange(m, len(a.fines))
+			for i := from; i <= to; i++ {
+				f := a.fines[i-1]
+				r.field("AV", fmt.Sprintf("%s %s %s", f.ID, f.Amount, f.Reason))
+			}
+		}
+		if p.reader.Email != nil {
+			r.field("BE", *p.reader.Email)
+		}
+		if p.reader.Phone != nil {
+			r.field("BF", *p.reader.Phone)
+		}
+	}
+
+	return r.optional("AF", screen(p.lang, a.refusal))
+}
+
+// checkout (11) выдача экземпляра. Если экземпляр уже у этого читателя и терминал разрешает
+// продление (SC renewal policy), выдача продлевается
+func (s *session) checkout(ctx context.Context, m *Message) *response {
+	now := time.Now()
+	item := m.Field("AB")
+	reply := func(success, renewed bool, title string, due *time.Time, message string) *response {
+		dueDate := ""
+		if due != nil {
+			dueDate = formatDate(*due)
+		}
+		return newResponse("12").
+			fixed(ok(success), yn(renewed), "U", yn(success), formatDate(now)).
+			field("AO", s.srv.cfg.InstitutionID).
+			field("AA", m.Field("AA")).
+			field("AB", item).
+			field("AJ", title).
+			field("AH", dueDate).
+			optional("AF", message)
+	}
+
+	p, err := s.lookupPatron(ctx, m, "")
+	if err != nil {
+		s.transactionFailed(err, m)
+		return reply(false, false, "", nil, screen(p.langOrDefault(), "error"))
+	}
+	if !p.authorized {
+		return reply(false, false, "", nil, screen(p.lang, p.refusal))
+	}
+
+	loan, err := s.srv.desk.Checkout(ctx, circulation.Checkout{
+		ReaderID:    p.reader.ID,
+		CopyCode:    item,
+		LibrarianID: &s.kioskID,
+		Reason:      "Self-checkout at kiosk " + s.kiosk,
+	})
+	if errors.Is(err, circulation.ErrCopyUnavailable) && m.FixedAt(0, 1) == "Y" {
+		issue, ierr := s.srv.repo.GetActiveIssueByCopyCode(ctx, item)
+		if ierr == nil && issue.ReaderID == p.reader.ID {
+			renewed, rerr := s.srv.desk.Renew(ctx, p.reader.ID, item)
+			if rerr != nil {
+				return s.refuse(rerr, m, func(key string) *response {
+					return reply(false, false, issue.Title, nil, screen(p.lang, key))
+				})
+			}
+			s.logTransaction(m, "renew")
+			return reply(true, true, issue.Title, &renewed.DueDate,
+				screen(p.lang, "renewed")+" "+displayDate(p.lang, renewed.DueDate))
+		}
+	}
+	if err != nil {
+		return s.refuse(err, m, func(key string) *response {
+			return reply(false, false, s.itemTitle(ctx, item), nil, screen(p.lang, key))
+		})
+	}
+
+	s.logTransaction(m, "checkout")
+	return reply(true, false, loan.Copy.Title, &loan.Issue.DueDate,
+		screen(p.lang, "checked_out")+" "+displayDate(p.lang, loan.Issue.DueDate))
+}
+
+// checkin (09) возврат экземпляра; читатель не указывается
+func (s *session) checkin(ctx context.Context, m *Message) *response {
+	now := time.Now()
+	item := m.Field("AB")
+	lang := requestLanguage("")
+	reply := func(success, resensitize, alert bool, title, location, message string) *response {
+		return newResponse("10").
+			fixed(ok(success), yn(resensitize), "U", yn(alert), formatDate(now)).
+			field("AO", s.srv.cfg.InstitutionID).
+			field("AB", item).
+			field("AQ", location).
+			field("AJ", title).
+			optional("AF", message)
+	}
+
+	returned, err := s.srv.desk.Checkin(ctx, item, &s.kioskID, "Returned at kiosk "+s.kiosk)
+	if err != nil {
+		switch {
+		// Не выданный экземпляр все равно должен остаться под охраной ворот
+		case errors.Is(err, circulation.ErrNotCheckedOut):
+			return reply(false, true, false, s.itemTitle(ctx, item), "", screen(lang, "not_checked_out"))
+		case errors.Is(err, circulation.ErrCopyNotFound):
+			return reply(false, false, true, "", "", screen(lang, "copy_not_found"))
+		}
+		s.transactionFailed(err, m)
+		return reply(false, false, true, s.itemTitle(ctx, item), "", screen(lang, "error"))
+	}
+
+	location := ""
+	if bookCopy, err := s.srv.repo.GetBookCopyById(ctx, returned.Copy.ID); err == nil && bookCopy.HallName != nil {
+		location = *bookCopy.HallName
+	}
+
+	s.logTransaction(m, "checkin")
+	return reply(true, true, false, returned.Copy.Title, location, screen(lang, "returned"))
+}
+
+// renew (29) продление выдачи по правилам категории читателя
+func (s *session) renew(ctx context.Context, m *Message) *response {
+	now := time.Now()
+	item := m.Field("AB")
+	reply := func(success bool, title string, due *time.Time, message string) *response {
+		dueDate := ""
+		if due != nil {
+			dueDate = formatDate(*due)
+		}
+		return newResponse("30").
+			fixed(ok(success), yn(success), "U", yn(success), formatDate(now)).
+			field("AO", s.srv.cfg.InstitutionID).
+			field("AA", m.Field("AA")).
+			field("AB", item).
+			field("AJ", title).
+			field("AH", dueDate).
+			optional("AF", message)
+	}
+
+	p, err := s.lookupPatron(ctx, m, "")
+	if err != nil {
+		s.transactionFailed(err, m)
+		return reply(false, "", nil, screen(p.langOrDefault(), "error"))
+	}
+	if !p.authorized {
+		return reply(false, "", nil, screen(p.lang, p.refusal))
+	}
+
+	title := s.itemTitle(ctx, item)
+	renewed, err := s.srv.desk.Renew(ctx, p.reader.ID, item)
+	if err != nil {
+		return s.refuse(err, m, func(key string) *response {
+			return reply(false, title, nil, screen(p.lang, key))
+		})
+	}
+
+	s.logTransaction(m, "renew")
+	return reply(true, title, &renewed.DueDate, screen(p.lang, "renewed")+" "+displayDate(p.lang, renewed.DueDate))
+}
+
+// feePaid (37) оплата штрафа целиком. Штраф указывается в CG; без него оплачивается
+// самый старый неоплаченный штраф с той же суммой
+func (s *session) feePaid(ctx context.Context, m *Message) *response {
+	now := time.Now()
+	reply := func(accepted bool, message string) *response {
+		return newResponse("38").
+			fixed(yn(accepted), formatDate(now)).
+			field("AO", s.srv.cfg.InstitutionID).
+			field("AA", m.Field("AA")).
+			optional("BK", m.Field("BK")).
+			optional("AF", message)
+	}
+
+	p, err := s.lookupPatron(ctx, m, "")
+	if err != nil {
+		s.transactionFailed(err, m)
+		return reply(false, screen(p.langOrDefault(), "error"))
+	}
+	if !p.authorized {
+		return reply(false, screen(p.lang, p.refusal))
+	}
+	if m.FixedAt(22, 3) != s.srv.cfg.Currency {
+		return reply(false, screen(p.lang, "currency"))
+	}
+	amount, err := decimal.Parse(m.Field("BV"))
+	if err != nil {
+		return reply(false, screen(p.lang, "fine_amount"))
+	}
+
+	fineID, key, err := s.findFine(ctx, p.reader.ID, m.Field("CG"), amount)
+	if err != nil {
+		s.transactionFailed(err, m)
+		return reply(false, screen(p.lang, "error"))
+	}
+	if key != "" {
+		return reply(false, screen(p.lang, key))
+	}
+
+	if _, err := s.srv.repo.SettleFine(ctx, fineID); err != nil {
+		s.transactionFailed(err, m)
+		return reply(false, screen(p.lang, "error"))
+	}
+
+	log.Info().Str("kiosk", s.kiosk).Str("patron", m.Field("AA")).Str("fineID", fineID.String()).
+		Str("amount", amount.String()).Str("transactionID", m.Field("BK")).Msg("SIP2 fee paid")
+	return reply(true, screen(p.lang, "paid"))
+}
+
+// findFine выбирает оплачиваемый штраф; непустой key — причина отказа для экрана
+func (s *session) findFine(ctx context.Context, readerID uuid.UUID, feeID string, amount decimal.Decimal) (uuid.UUID, string, error) {
+	if feeID != "" {
+		id, err := uuid.Parse(feeID)
+		if err != nil {
+			return uuid.Nil, "fine_not_found", nil
+		}
+		fine, err := s.srv.repo.GetFineById(ctx, id)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return uuid.Nil, "fine_not_found", nil
+			}
+			return uuid.Nil, "", err
+		}
+		switch {
+		case fine.ReaderID != readerID:
+			return uuid.Nil, "fine_not_found", nil
+		case fine.IsPaid != nil && *fine.IsPaid:
+			return uuid.Nil, "fine_paid", nil
+		case fine.Amount.Cmp(amount) != 0:
+			return uuid.Nil, "fine_amount", nil
+		}
+		return fine.ID, "", nil
+	}
+
+	fines, err := s.srv.repo.GetReaderFines(ctx, readerID)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+	// Штрафы отсортированы от новых к старым
+	for i := len(fines) - 1; i >= 0; i-- {
+		f := fines[i]
+		if (f.IsPaid == nil || !*f.IsPaid) && f.Amount.Cmp(amount) == 0 {
+			return f.ID, "", nil
+		}
+	}
+	return uuid.Nil, "fine_amount", nil
+}
+
+// endPatronSession (35) киоск закончил работу с читателем; состояние сессии не хранится
+func (s *session) endPatronSession(m *Message) *response {
+	return newResponse("36").
+		fixed(yn(true), formatDate(time.Now())).
+		field("AO", s.srv.cfg.InstitutionID).
+		field("AA", m.Field("AA"))
+}
+
+// refuse отвечает отказом с понятным читателю текстом, а непредвиденные ошибки записывает в журнал
+func (s *session) refuse(err error, m *Message, reply func(key string) *response) *response {
+	key, ok := refusal(err)
+	if !ok {
+		s.transactionFailed(err, m)
+		key = "error"
+	}
+	return reply(key)
+}
+
+// itemTitle название книги по шифру экземпляра для полей AJ; пустое, если экземпляр не найден
+func (s *session) itemTitle(ctx context.Context, copyCode string) string {
+	bookCopy, err := s.srv.repo.GetBookCopyByCode(ctx, copyCode)
+	if err != nil {
+		return ""
+	}
+	return bookCopy.Title
+}
+
+func (s *session) logTransaction(m *Message, op string) {
+	log.Info().Str("kiosk", s.kiosk).Str("patron", m.Field("AA")).Str("item", m.Field("AB")).
+		Msg("SIP2 " + op)
+}
+
+// patronName полное имя читателя для поля AE
+func patronName(p *patron) string {
+	if p.reader == nil {
+		return ""
+	}
+	return p.reader.FullName
+}
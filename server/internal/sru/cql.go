@@ -0,0 +1,214 @@
+package sru
+
+import (
+	"strings"
+	"unicode"
+)
+
+// node узел дерева запроса CQL: либо условие поиска, либо логическая операция над двумя узлами
+type node struct {
+	// Условие поиска: index relation term; без индекса и отношения — cql.serverChoice =
+	index    string
+	relation string
+	term     string
+
+	// Логическая операция: and, or, not, prox
+	op          string
+	left, right *node
+}
+
+// token лексема CQL; quoted отличает "and" в кавычках от оператора
+type token struct {
+	text   string
+	quoted bool
+}
+
+// parseCQL разбирает запрос CQL 1.2. Модификаторы отношений и логических операций
+// разбираются, но не учитываются; sortBy не поддерживается
+func parseCQL(query string) (*node, error) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, diagnostic(diagSyntax, "", "empty query")
+	}
+
+	p := &parser{tokens: tokens}
+	n, err := p.query()
+	if err != nil {
+		return nil, err
+	}
+	if t, ok := p.peek(); ok {
+		if strings.EqualFold(t.text, "sortby") && !t.quoted {
+			return nil, diagnostic(diagSortUnsupported, "", "sortBy is not supported")
+		}
+		return nil, diagnostic(diagSyntax, t.text, "unexpected token")
+	}
+	return n, nil
+}
+
+// tokenize разбивает запрос на слова, строки в кавычках, скобки, «/» и операторы сравнения
+func tokenize(query string) ([]token, error) {
+	var tokens []token
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')' || r == '/':
+			tokens = append(tokens, token{text: string(r)})
+			i++
+		case r == '=' || r == '<' || r == '>':
+			j := i + 1
+			if j < len(runes) && (runes[j] == '=' || (r == '<' && runes[j] == '>')) {
+				j++
+			}
+			tokens = append(tokens, token{text: string(runes[i:j])})
+			i = j
+		case r == '"':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(runes) && runes[j] != '"'; j++ {
+				// Экранированные символы остаются с обратной косой чертой: это маскирование CQL
+				if runes[j] == '\\' && j+1 < len(runes) {
+					if runes[j+1] == '"' || runes[j+1] == '\\' {
+						j++
+					} else {
+						b.WriteRune(runes[j])
+						j++
+					}
+				}
+				b.WriteRune(runes[j])
+			}
+			if j >= len(runes) {
+				return nil, diagnostic(diagSyntax, string(runes[i:]), "unterminated quoted string")
+			}
+			tokens = append(tokens, token{text: b.String(), quoted: true})
+			i = j + 1
+		default:
+			j := i
+			for j < len(runes) && !unicode.IsSpace(runes[j]) && !strings.ContainsRune("()/=<>\"", runes[j]) {
+				j++
+			}
+			tokens = append(tokens, token{text: string(runes[i:j])})
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *parser) next() (token, bool) {
+	t, ok := p.peek()
+	if ok {
+		p.pos++
+	}
+	return t, ok
+}
+
+// query: clause { boolean [modifiers] clause }, операции левоассоциативны и равноправны
+func (p *parser) query() (*node, error) {
+	left, err := p.clause()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.peek()
+		if !ok || t.quoted || !isBoolean(t.text) {
+			return left, nil
+		}
+		p.pos++
+		p.modifiers()
+		right, err := p.clause()
+		if err != nil {
+			return nil, err
+		}
+		left = &node{op: strings.ToLower(t.text), left: left, right: right}
+	}
+}
+
+// clause: "(" query ")" | index relation [modifiers] term | term
+func (p *parser) clause() (*node, error) {
+	t, ok := p.next()
+	if !ok {
+		return nil, diagnostic(diagSyntax, "", "unexpected end of query")
+	}
+	if t.text == "(" && !t.quoted {
+		n, err := p.query()
+		if err != nil {
+			return nil, err
+		}
+		if closing, ok := p.next(); !ok || closing.text != ")" || closing.quoted {
+			return nil, diagnostic(diagSyntax, "", "missing closing parenthesis")
+		}
+		return n, nil
+	}
+	if !t.quoted && (t.text == ")" || t.text == "/" || isComparison(t.text)) {
+		return nil, diagnostic(diagSyntax, t.text, "unexpected token")
+	}
+
+	rel, ok := p.peek()
+	if ok && !rel.quoted && (isComparison(rel.text) || isNamedRelation(rel.text)) && !isBoolean(t.text) {
+		p.pos++
+		p.modifiers()
+		term, ok := p.next()
+		if !ok || (!term.quoted && (term.text == "(" || term.text == ")" || term.text == "/" || isComparison(term.text))) {
+			return nil, diagnostic(diagSyntax, t.text, "missing search term")
+		}
+		return &node{index: strings.ToLower(t.text), relation: strings.ToLower(rel.text), term: term.text}, nil
+	}
+
+	return &node{index: "cql.serverchoice", relation: "=", term: t.text}, nil
+}
+
+// modifiers пропускает модификаторы вида /name или /name=value
+func (p *parser) modifiers() {
+	for {
+		t, ok := p.peek()
+		if !ok || t.text != "/" || t.quoted {
+			return
+		}
+		p.pos += 2
+		if t, ok := p.peek(); ok && !t.quoted && isComparison(t.text) {
+			p.pos += 2
+		}
+	}
+}
+
+func isBoolean(s string) bool {
+	switch strings.ToLower(s) {
+	case "and", "or", "not", "prox":
+		return true
+	}
+	return false
+}
+
+func isComparison(s string) bool {
+	switch s {
+	case "=", "==", "<>", "<", ">", "<=", ">=":
+		return true
+	}
+	return false
+}
+
+func isNamedRelation(s string) bool {
+	switch strings.ToLower(s) {
+	case "exact", "all", "any", "adj", "within", "encloses":
+		return true
+	}
+	// Отношения из других наборов, например cql.exact или bath.phrase
+	return strings.Contains(s, ".") && !strings.HasSuffix(s, ".")
+}
@@ -0,0 +1,83 @@
+package sru
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+// render записывает дерево запроса со скобками вокруг каждой логической операции
+func render(n *node) string {
+	if n.op != "" {
+		return "(" + render(n.left) + " " + n.op + " " + render(n.right) + ")"
+	}
+	return n.index + " " + n.relation + " " + strconv.Quote(n.term)
+}
+
+func TestParseCQL(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"bare term", "толстой", `cql.serverchoice = "толстой"`},
+		{"quoted phrase", `"война и мир"`, `cql.serverchoice = "война и мир"`},
+		{"index and relation", "dc.title = война", `dc.title = "война"`},
+		{"no spaces around relation", "dc.title=война", `dc.title = "война"`},
+		{"index is lowercased", "DC.Title any \"мир война\"", `dc.title any "мир война"`},
+		{"exact relation", "bath.isbn == 9785170906307", `bath.isbn == "9785170906307"`},
+		{"comparison", "dc.date >= 2000", `dc.date >= "2000"`},
+		{"not equal", "dc.date <> 2000", `dc.date <> "2000"`},
+		{"relation from another set", "dc.title cql.exact \"мир\"", `dc.title cql.exact "мир"`},
+		{"relation modifiers are skipped", "dc.title =/relevant/stem=true война", `dc.title = "война"`},
+		{"boolean is left-associative", "a and b or c", `((cql.serverchoice = "a" and cql.serverchoice = "b") or cql.serverchoice = "c")`},
+		{"parentheses", "a and (b or c)", `(cql.serverchoice = "a" and (cql.serverchoice = "b" or cql.serverchoice = "c"))`},
+		{"boolean is case-insensitive", "a NOT b", `(cql.serverchoice = "a" not cql.serverchoice = "b")`},
+		{"boolean modifiers are skipped", "a prox/distance=1 b", `(cql.serverchoice = "a" prox cql.serverchoice = "b")`},
+		{"quoted boolean is a term", `a and "or"`, `(cql.serverchoice = "a" and cql.serverchoice = "or")`},
+		{"escaped quote", `"say \"hi\""`, `cql.serverchoice = "say \"hi\""`},
+		{"masking backslash is kept", `"wor\*d"`, `cql.serverchoice = "wor\\*d"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := parseCQL(tt.query)
+			if err != nil {
+				t.Fatalf("parseCQL(%q) error = %v", tt.query, err)
+			}
+			if got := render(n); got != tt.want {
+				t.Errorf("parseCQL(%q) = %s, want %s", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCQLErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		code  int
+	}{
+		{"empty", "   ", diagSyntax},
+		{"unterminated quote", `"война`, diagSyntax},
+		{"missing closing parenthesis", "(a or b", diagSyntax},
+		{"unbalanced closing parenthesis", "a)", diagSyntax},
+		{"missing term", "dc.title =", diagSyntax},
+		{"relation without index", "= война", diagSyntax},
+		{"dangling boolean", "a and", diagSyntax},
+		{"two terms", "a b", diagSyntax},
+		{"boolean word as index", "and = x", diagSyntax},
+		{"sortBy", "dc.title = мир sortBy dc.date", diagSortUnsupported},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseCQL(tt.query)
+			var d *Diagnostic
+			if !errors.As(err, &d) {
+				t.Fatalf("parseCQL(%q) error = %v, want *Diagnostic", tt.query, err)
+			}
+			if d.Code != tt.code {
+				t.Errorf("parseCQL(%q) diagnostic %d, want %d", tt.query, d.Code, tt.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package sru
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+// Пространства имен ответов SRU 1.2 и 2.0
+const (
+	namespace12     = "http://www.loc.gov/zing/srw/"
+	namespace20     = "http://docs.oasis-open.org/ns/search-ws/sruResponse"
+	diagNamespace12 = "http://www.loc.gov/zing/srw/diagnostic/"
+	diagNamespace20 = "http://docs.oasis-open.org/ns/search-ws/diagnostic"
+	zeerexNamespace = "http://explain.z3950.org/dtd/2.0/"
+)
+
+// Коды диагностик SRU (info:srw/diagnostic/1/N), которые возвращает сервер
+const (
+	diagGeneral             = 1
+	diagUnsupportedOp       = 4
+	diagUnsupportedVersion  = 5
+	diagUnsupportedParam    = 6
+	diagMandatoryParam      = 7
+	diagSyntax              = 10
+	diagUnsupportedIndex    = 16
+	diagUnsupportedRelation = 19
+	diagEmptyTerm           = 27
+	diagInvalidTerm         = 36
+	diagUnsupportedBoolean  = 37
+	diagFirstRecordRange    = 61
+	diagUnknownSchema       = 66
+	diagUnsupportedPacking  = 71
+	diagSortUnsupported     = 80
+)
+
+// Diagnostic ошибка запроса SRU; возвращается в ответе вместо записей
+type Diagnostic struct {
+	Code    int
+	Details string
+	Message string
+}
+
+func (d *Diagnostic) Error() string {
+	return fmt.Sprintf("SRU diagnostic %d: %s (%s)", d.Code, d.Message, d.Details)
+}
+
+func diagnostic(code int, details, message string) *Diagnostic {
+	return &Diagnostic{Code: code, Details: details, Message: message}
+}
+
+type diagnosticXML struct {
+	XMLName xml.Name `xml:"diagnostic"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	URI     string   `xml:"uri"`
+	Details string   `xml:"details,omitempty"`
+	Message string   `xml:"message,omitempty"`
+}
+
+type diagnosticsXML struct {
+	Items []diagnosticXML `xml:"diagnostic"`
+}
+
+type recordDataXML struct {
+	// Запись как вложенный XML или, при recordPacking=string, как экранированный текст
+	Inner string `xml:",innerxml"`
+	Text  string `xml:",chardata"`
+}
+
+type recordXML struct {
+	RecordSchema      string        `xml:"recordSchema"`
+	RecordPacking     string        `xml:"recordPacking"`
+	RecordXMLEscaping string        `xml:"recordXMLEscaping,omitempty"`
+	RecordData        recordDataXML `xml:"recordData"`
+	RecordPosition    int           `xml:"recordPosition,omitempty"`
+}
+
+type recordsXML struct {
+	Items []recordXML `xml:"record"`
+}
+
+type searchRetrieveResponseXML struct {
+	XMLName            xml.Name        `xml:"searchRetrieveResponse"`
+	Xmlns              string          `xml:"xmlns,attr"`
+	Version            string          `xml:"version,omitempty"`
+	NumberOfRecords    int64           `xml:"numberOfRecords"`
+	Records            *recordsXML     `xml:"records,omitempty"`
+	NextRecordPosition int             `xml:"nextRecordPosition,omitempty"`
+	Diagnostics        *diagnosticsXML `xml:"diagnostics,omitempty"`
+}
+
+type explainResponseXML struct {
+	XMLName     xml.Name        `xml:"explainResponse"`
+	Xmlns       string          `xml:"xmlns,attr"`
+	Version     string          `xml:"version,omitempty"`
+	Record      *recordXML      `xml:"record,omitempty"`
+	Diagnostics *diagnosticsXML `xml:"diagnostics,omitempty"`
+}
+
+// packing описывает упаковку записей для версии протокола
+type packing struct {
+	version string
+	// escaped записи передаются строкой (recordPacking=string в 1.2, recordXMLEscaping=string в 2.0)
+	escaped bool
+}
+
+func (p packing) namespace() string {
+	if p.version == "2.0" {
+		return namespace20
+	}
+	return namespace12
+}
+
+// versionElement в SRU 2.0 элемента version в ответе нет
+func (p packing) versionElement() string {
+	if p.version == "2.0" {
+		return ""
+	}
+	return p.version
+}
+
+func (p packing) record(schema, data string, position int) recordXML {
+	r := recordXML{RecordSchema: schema, RecordPosition: position}
+	if p.escaped {
+		r.RecordData.Text = data
+	} else {
+		r.RecordData.Inner = data
+	}
+	mode := "xml"
+	if p.escaped {
+		mode = "string"
+	}
+	if p.version == "2.0" {
+		r.RecordPacking, r.RecordXMLEscaping = "packed", mode
+	} else {
+		r.RecordPacking = mode
+	}
+	return r
+}
+
+func (p packing) diagnostics(d *Diagnostic) *diagnosticsXML {
+	if d == nil {
+		return nil
+	}
+	ns := diagNamespace12
+	if p.version == "2.0" {
+		ns = diagNamespace20
+	}
+	return &diagnosticsXML{Items: []diagnosticXML{{
+		Xmlns:   ns,
+		URI:     fmt.Sprintf("info:srw/diagnostic/1/%d", d.Code),
+		Details: d.Details,
+		Message: d.Message,
+	}}}
+}
+
+func encode(v any) ([]byte, error) {
+	body, err := xml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
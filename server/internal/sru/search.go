@@ -0,0 +1,232 @@
+package sru
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Search условия поиска по каталогу, к которым сводится запрос CQL; все условия объединяются через AND
+type Search struct {
+	// Title слова заглавия, TitleExact — заглавие целиком
+	Title      []string
+	TitleExact string
+	// Authors каждая строка должна входить в имя одного из авторов
+	Authors []string
+	ISBNs   []string
+	// Terms каждое слово должно найтись в заглавии, авторах или ISBN (cql.serverChoice)
+	Terms []string
+	// YearFrom и YearTo границы года издания; 0 — без границы
+	YearFrom int
+	YearTo   int
+	// Contradictory условия исключают друг друга, например два разных точных заглавия
+	Contradictory bool
+}
+
+// Индексы, на которые отображаются имена из контекстных наборов dc, bath и cql
+const (
+	indexTitle  = "title"
+	indexAuthor = "author"
+	indexISBN   = "isbn"
+	indexYear   = "year"
+	indexAny    = "any"
+	indexAll    = "all"
+)
+
+// indexes имена индексов CQL без учета регистра
+var indexes = map[string]string{
+	"title":             indexTitle,
+	"dc.title":          indexTitle,
+	"bath.title":        indexTitle,
+	"author":            indexAuthor,
+	"creator":           indexAuthor,
+	"dc.creator":        indexAuthor,
+	"dc.contributor":    indexAuthor,
+	"bath.author":       indexAuthor,
+	"bath.name":         indexAuthor,
+	"bath.personalname": indexAuthor,
+	"isbn":              indexISBN,
+	"bath.isbn":         indexISBN,
+	"dc.identifier":     indexISBN,
+	"year":              indexYear,
+	"date":              indexYear,
+	"dc.date":           indexYear,
+	"cql.serverchoice":  indexAny,
+	"cql.anywhere":      indexAny,
+	"serverchoice":      indexAny,
+	"anywhere":          indexAny,
+	"cql.allrecords":    indexAll,
+}
+
+// ParseQuery разбирает запрос CQL и сводит его к условиям поиска.
+// Поддерживается только AND: OR, NOT и PROX дают диагностику 37
+func ParseQuery(query string) (*Search, error) {
+	root, err := parseCQL(query)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Search{}
+	if err := s.add(root); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Search) add(n *node) error {
+	if n.op != "" {
+		if n.op != "and" {
+			return diagnostic(diagUnsupportedBoolean, n.op, "only AND is supported")
+		}
+		if err := s.add(n.left); err != nil {
+			return err
+		}
+		return s.add(n.right)
+	}
+
+	index, ok := indexes[n.index]
+	if !ok {
+		return diagnostic(diagUnsupportedIndex, n.index, "unsupported index")
+	}
+	relation := strings.TrimPrefix(n.relation, "cql.")
+	term := unmask(n.term)
+	if index == indexAll {
+		return nil
+	}
+	if term == "" {
+		return diagnostic(diagEmptyTerm, n.index, "empty term")
+	}
+
+	switch index {
+	case indexTitle:
+		switch relation {
+		case "=", "all", "adj":
+			s.Title = append(s.Title, term)
+		case "any":
+			if len(strings.Fields(term)) > 1 {
+				return diagnostic(diagUnsupportedRelation, n.relation, "any with several words is not supported")
+			}
+			s.Title = append(s.Title, term)
+		case "==", "exact":
+			if s.TitleExact != "" && !strings.EqualFold(s.TitleExact, term) {
+				s.Contradictory = true
+			}
+			s.TitleExact = term
+		default:
+			return diagnostic(diagUnsupportedRelation, n.relation, "unsupported relation for title")
+		}
+
+	case indexAuthor:
+		switch relation {
+		case "=", "==", "exact", "adj":
+			s.Authors = append(s.Authors, term)
+		case "all":
+			s.Authors = append(s.Authors, strings.Fields(term)...)
+		case "any":
+			if len(strings.Fields(term)) > 1 {
+				return diagnostic(diagUnsupportedRelation, n.relation, "any with several words is not supported")
+			}
+			s.Authors = append(s.Authors, term)
+		default:
+			return diagnostic(diagUnsupportedRelation, n.relation, "unsupported relation for author")
+		}
+
+	case indexISBN:
+		switch relation {
+		case "=", "==", "exact", "adj", "all", "any":
+			s.ISBNs = append(s.ISBNs, normalizeISBN(term))
+		default:
+			return diagnostic(diagUnsupportedRelation, n.relation, "unsupported relation for isbn")
+		}
+
+	case indexYear:
+		return s.addYear(n, relation, term)
+
+	case indexAny:
+		switch relation {
+		case "=", "all", "adj":
+			s.Terms = append(s.Terms, strings.Fields(term)...)
+		case "any":
+			if len(strings.Fields(term)) > 1 {
+				return diagnostic(diagUnsupportedRelation, n.relation, "any with several words is not supported")
+			}
+			s.Terms = append(s.Terms, term)
+		default:
+			return diagnostic(diagUnsupportedRelation, n.relation, "unsupported relation for serverChoice")
+		}
+	}
+	return nil
+}
+
+// addYear сужает диапазон года издания; within принимает два года через пробел
+func (s *Search) addYear(n *node, relation, term string) error {
+	parse := func(v string) (int, error) {
+		year, err := strconv.Atoi(v)
+		if err != nil || year < 1 || year > 9999 {
+			return 0, diagnostic(diagInvalidTerm, term, "year must be a number")
+		}
+		return year, nil
+	}
+
+	from, to := 0, 0
+	switch relation {
+	case "within":
+		bounds := strings.Fields(term)
+		if len(bounds) != 2 {
+			return diagnostic(diagInvalidTerm, term, "within expects two years")
+		}
+		var err error
+		if from, err = parse(bounds[0]); err != nil {
+			return err
+		}
+		if to, err = parse(bounds[1]); err != nil {
+			return err
+		}
+	default:
+		year, err := parse(term)
+		if err != nil {
+			return err
+		}
+		switch relation {
+		case "=", "==", "exact", "adj", "all", "any":
+			from, to = year, year
+		case "<":
+			to = year - 1
+		case "<=":
+			to = year
+		case ">":
+			from = year + 1
+		case ">=":
+			from = year
+		default:
+			return diagnostic(diagUnsupportedRelation, n.relation, "unsupported relation for year")
+		}
+	}
+
+	if from != 0 && from > s.YearFrom {
+		s.YearFrom = from
+	}
+	if to != 0 && (s.YearTo == 0 || to < s.YearTo) {
+		s.YearTo = to
+	}
+	if s.YearTo != 0 && s.YearFrom > s.YearTo {
+		s.Contradictory = true
+	}
+	return nil
+}
+
+// unmask убирает маскирующие символы CQL: поиск по подстроке их и так подразумевает
+func unmask(term string) string {
+	term = strings.NewReplacer(`\*`, "*", `\?`, "?", `\^`, "^", "*", "", "?", "", "^", "").Replace(term)
+	return strings.TrimSpace(term)
+}
+
+// normalizeISBN убирает дефисы и пробелы, как в поиске по books.isbn
+func normalizeISBN(isbn string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || unicode.IsSpace(r) {
+			return -1
+		}
+		return unicode.ToUpper(r)
+	}, isbn)
+}
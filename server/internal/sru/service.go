@@ -0,0 +1,300 @@
+package sru
+
+import (
+	"context"
+	"encoding/xml"
+	"strconv"
+	"strings"
+
+	"github.com/hnnsly/library-console/internal/biblio"
+	"github.com/hnnsly/library-console/internal/config"
+	"github.com/hnnsly/library-console/internal/repository"
+	"github.com/hnnsly/library-console/internal/repository/postgres"
+	"github.com/rs/zerolog/log"
+)
+
+// schema формат записей, который можно запросить параметром recordSchema
+type schema struct {
+	uri    string
+	title  string
+	render func(biblio.Record) string
+}
+
+var (
+	schemaMARCXML = schema{uri: "info:srw/schema/1/marcxml-v1.1", title: "MARC 21 XML", render: biblio.MARCXML}
+	schemaDC      = schema{uri: "info:srw/schema/1/dc-v1.1", title: "Dublin Core", render: biblio.SRWDublinCore}
+)
+
+// schemas короткие имена и URI форматов записей
+var schemas = map[string]schema{
+	"marcxml":                          schemaMARCXML,
+	"marc21":                           schemaMARCXML,
+	"marcxml-v1.1":                     schemaMARCXML,
+	schemaMARCXML.uri:                  schemaMARCXML,
+	biblio.MARCXMLNamespace:            schemaMARCXML,
+	"dc":                               schemaDC,
+	"dc-v1.1":                          schemaDC,
+	schemaDC.uri:                       schemaDC,
+	"http://purl.org/dc/elements/1.1/": schemaDC,
+}
+
+// Endpoint адрес сервиса для описания в explain
+type Endpoint struct {
+	Host string
+	Port int
+	Path string
+}
+
+// Service отвечает на запросы SRU к каталогу: searchRetrieve и explain
+type Service struct {
+	cfg  config.SRUConfig
+	repo *repository.LibraryRepository
+}
+
+// NewService создает сервис SRU
+func NewService(cfg config.SRUConfig, repo *repository.LibraryRepository) *Service {
+	return &Service{cfg: cfg, repo: repo}
+}
+
+// Handle выполняет операцию по параметрам запроса и возвращает XML-ответ. Ошибки запроса
+// передаются диагностиками в теле ответа; ошибка возвращается, только если ответ не собрать
+func (s *Service) Handle(ctx context.Context, params map[string]string, endpoint Endpoint) ([]byte, error) {
+	operation := params["operation"]
+	version := params["version"]
+	// В SRU 2.0 параметр operation не передается: запрос с query — это searchRetrieve
+	if operation == "" {
+		if _, ok := params["query"]; ok {
+			operation = "searchRetrieve"
+		} else {
+			operation = "explain"
+		}
+		if version == "" {
+			version = "2.0"
+		}
+	}
+
+	p := packing{version: "1.2"}
+	switch version {
+	case "", "1.1", "1.2":
+	case "2.0":
+		p.version = "2.0"
+	default:
+		return s.explain(p, endpoint, diagnostic(diagUnsupportedVersion, "1.2", "unsupported version"))
+	}
+
+	escaping := params["recordPacking"]
+	if p.version == "2.0" {
+		escaping = params["recordXMLEscaping"]
+		if packed := params["recordPacking"]; packed != "" && packed != "packed" {
+			return s.searchError(p, diagnostic(diagUnsupportedPacking, packed, "only packed records are supported"))
+		}
+	}
+	switch escaping {
+	case "", "xml":
+	case "string":
+		p.escaped = true
+	default:
+		return s.searchError(p, diagnostic(diagUnsupportedPacking, escaping, "unsupported record packing"))
+	}
+
+	switch operation {
+	case "searchRetrieve":
+		return s.searchRetrieve(ctx, p, params)
+	case "explain":
+		return s.explain(p, endpoint, nil)
+	}
+	return s.explain(p, endpoint, diagnostic(diagUnsupportedOp, operation, "unsupported operation"))
+}
+
+// searchRetrieve ищет книги по запросу CQL и возвращает страницу записей
+func (s *Service) searchRetrieve(ctx context.Context, p packing, params map[string]string) ([]byte, error) {
+	query, ok := params["query"]
+	if !ok || strings.TrimSpace(query) == "" {
+		return s.searchError(p, diagnostic(diagMandatoryParam, "query", "mandatory parameter not supplied"))
+	}
+
+	start, err := positiveParam(params, "startRecord", 1, 1)
+	if err != nil {
+		return s.searchError(p, err.(*Diagnostic))
+	}
+	limit, err := positiveParam(params, "maximumRecords", s.cfg.DefaultRecords, 0)
+	if err != nil {
+		return s.searchError(p, err.(*Diagnostic))
+	}
+	limit = min(limit, s.cfg.MaxRecords)
+
+	format := schemaMARCXML
+	if name := params["recordSchema"]; name != "" {
+		if format, ok = schemas[strings.ToLower(name)]; !ok {
+			return s.searchError(p, diagnostic(diagUnknownSchema, name, "unknown schema for retrieval"))
+		}
+	}
+
+	search, err := ParseQuery(query)
+	if err != nil {
+		return s.searchError(p, err.(*Diagnostic))
+	}
+
+	var (
+		books []*postgres.SearchSruBooksRow
+		total int64
+	)
+	if !search.Contradictory {
+		books, total, err = s.search(ctx, search, limit, start-1)
+		if err != nil {
+			log.Error().Err(err).Str("query", query).Msg("SRU search failed")
+			return s.searchError(p, diagnostic(diagGeneral, "", "temporary system error"))
+		}
+	}
+	if total > 0 && int64(start) > total {
+		return s.searchError(p, diagnostic(diagFirstRecordRange, strconv.Itoa(start), "first record position out of range"))
+	}
+
+	resp := searchRetrieveResponseXML{
+		Xmlns:           p.namespace(),
+		Version:         p.versionElement(),
+		NumberOfRecords: total,
+	}
+	if len(books) > 0 {
+		resp.Records = &recordsXML{}
+		for i, b := range books {
+			record := biblio.Record{
+				ID:              b.ID,
+				Title:           b.Title,
+				Authors:         b.Authors,
+				Isbn:            b.Isbn,
+				Publisher:       b.Publisher,
+				PublicationYear: b.PublicationYear,
+			}
+			resp.Records.Items = append(resp.Records.Items, p.record(format.uri, format.render(record), start+i))
+		}
+	}
+	if next := start + len(books); int64(next) <= total && len(books) > 0 {
+		resp.NextRecordPosition = next
+	}
+
+	return encode(resp)
+}
+
+// search выполняет поиск; без строк на странице общее число узнается отдельным запросом
+func (s *Service) search(ctx context.Context, search *Search, limit, offset int) ([]*postgres.SearchSruBooksRow, int64, error) {
+	arg := postgres.SearchSruBooksParams{
+		Title:       strings.Join(search.Title, " "),
+		TitleExact:  search.TitleExact,
+		Authors:     append([]string{}, search.Authors...),
+		Isbns:       append([]string{}, search.ISBNs...),
+		Terms:       append([]string{}, search.Terms...),
+		YearFrom:    search.YearFrom,
+		YearTo:      search.YearTo,
+		LimitCount:  int32(limit),
+		OffsetCount: int32(offset),
+	}
+	books, err := s.repo.SearchSruBooks(ctx, arg)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(books) > 0 {
+		return books, books[0].TotalCount, nil
+	}
+	if limit > 0 && offset == 0 {
+		return books, 0, nil
+	}
+
+	arg.LimitCount, arg.OffsetCount = 1, 0
+	first, err := s.repo.SearchSruBooks(ctx, arg)
+	if err != nil || len(first) == 0 {
+		return books, 0, err
+	}
+	return books, first[0].TotalCount, nil
+}
+
+func (s *Service) searchError(p packing, d *Diagnostic) ([]byte, error) {
+	return encode(searchRetrieveResponseXML{
+		Xmlns:       p.namespace(),
+		Version:     p.versionElement(),
+		Diagnostics: p.diagnostics(d),
+	})
+}
+
+// explain описывает сервер в формате ZeeRex: адрес, индексы, отношения и форматы записей
+func (s *Service) explain(p packing, endpoint Endpoint, d *Diagnostic) ([]byte, error) {
+	var b strings.Builder
+	b.WriteString(`<explain xmlns="` + zeerexNamespace + `">`)
+	b.WriteString(`<serverInfo protocol="SRU" version="` + p.version + `">`)
+	element(&b, "host", endpoint.Host)
+	element(&b, "port", strconv.Itoa(endpoint.Port))
+	element(&b, "database", strings.TrimPrefix(endpoint.Path, "/"))
+	b.WriteString("</serverInfo>")
+
+	b.WriteString(`<databaseInfo>`)
+	element(&b, "title", s.cfg.Title)
+	if s.cfg.Description != "" {
+		element(&b, "description", s.cfg.Description)
+	}
+	b.WriteString(`</databaseInfo>`)
+
+	b.WriteString(`<indexInfo>`)
+	b.WriteString(`<set name="dc" identifier="info:srw/cql-context-set/1/dc-v1.1"/>`)
+	b.WriteString(`<set name="bath" identifier="http://zing.z3950.org/cql/bath/2.0/"/>`)
+	b.WriteString(`<set name="cql" identifier="info:srw/cql-context-set/1/cql-v1.2"/>`)
+	for _, idx := range []struct{ title, set, name string }{
+		{"Title", "dc", "title"},
+		{"Author", "dc", "creator"},
+		{"ISBN", "bath", "isbn"},
+		{"Publication year", "dc", "date"},
+		{"Any field", "cql", "serverChoice"},
+	} {
+		b.WriteString(`<index><title>` + idx.title + `</title><map><name set="` + idx.set + `">` + idx.name + `</name></map></index>`)
+	}
+	b.WriteString(`</indexInfo>`)
+
+	b.WriteString(`<schemaInfo>`)
+	for _, sc := range []struct {
+		schema
+		name string
+	}{{schemaMARCXML, "marcxml"}, {schemaDC, "dc"}} {
+		b.WriteString(`<schema identifier="` + sc.uri + `" name="` + sc.name + `" retrieve="true"><title>` + sc.title + `</title></schema>`)
+	}
+	b.WriteString(`</schemaInfo>`)
+
+	b.WriteString(`<configInfo>`)
+	b.WriteString(`<default type="numberOfRecords">` + strconv.Itoa(s.cfg.DefaultRecords) + `</default>`)
+	b.WriteString(`<setting type="maximumRecords">` + strconv.Itoa(s.cfg.MaxRecords) + `</setting>`)
+	b.WriteString(`<default type="retrieveSchema">marcxml</default>`)
+	b.WriteString(`<supports type="relation">=</supports>`)
+	b.WriteString(`<supports type="relation">exact</supports>`)
+	b.WriteString(`<supports type="relation">all</supports>`)
+	b.WriteString(`<supports type="relation">&lt;</supports>`)
+	b.WriteString(`<supports type="relation">&gt;</supports>`)
+	b.WriteString(`<supports type="relation">within</supports>`)
+	b.WriteString(`<supports type="booleanModifier">and</supports>`)
+	b.WriteString(`</configInfo>`)
+	b.WriteString(`</explain>`)
+
+	record := p.record(zeerexNamespace, b.String(), 0)
+	return encode(explainResponseXML{
+		Xmlns:       p.namespace(),
+		Version:     p.versionElement(),
+		Record:      &record,
+		Diagnostics: p.diagnostics(d),
+	})
+}
+
+func element(b *strings.Builder, name, value string) {
+	b.WriteString("<" + name + ">")
+	xml.EscapeText(b, []byte(value))
+	b.WriteString("</" + name + ">")
+}
+
+// positiveParam читает целый параметр не меньше minimum; отсутствие дает значение по умолчанию
+func positiveParam(params map[string]string, name string, def, minimum int) (int, error) {
+	raw, ok := params[name]
+	if !ok || raw == "" {
+		return def, nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil || v < minimum {
+		return 0, diagnostic(diagUnsupportedParam, name, "unsupported parameter value")
+	}
+	return v, nil
+}
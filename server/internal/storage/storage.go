@@ -0,0 +1,29 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/hnnsly/library-console/internal/config"
+)
+
+// ErrNotFound объекта с таким ключом нет в хранилище
+var ErrNotFound = errors.New("object not found")
+
+// Storage хранилище файлов вложений. Ключи — пути через «/», например books/<id>/<attachment id>
+type Storage interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Get открывает объект на чтение; если его нет, возвращает ErrNotFound
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete удаляет объект; отсутствие объекта ошибкой не считается
+	Delete(ctx context.Context, key string) error
+}
+
+// New создает хранилище, выбранное в конфигурации
+func New(cfg config.AttachmentsConfig) (Storage, error) {
+	if cfg.Storage == "s3" {
+		return NewS3(*cfg.S3)
+	}
+	return NewLocal(cfg.Path)
+}
@@ -0,0 +1,76 @@
+package ticket
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hnnsly/library-console/internal/config"
+)
+
+// Generator формирует номера читательских билетов с контрольной цифрой по алгоритму Луна
+type Generator struct {
+	cfg config.TicketConfig
+}
+
+// New создает генератор номеров билетов
+func New(cfg config.TicketConfig) *Generator {
+	return &Generator{cfg: cfg}
+}
+
+// Format формирует номер билета из значения последовательности
+func (g *Generator) Format(sequence int64) string {
+	digits := fmt.Sprintf("%0*d", g.cfg.Digits, sequence)
+	return g.cfg.Prefix + digits + string(luhnDigit(digits))
+}
+
+// Generated проверяет, похож ли номер на выданный генератором.
+// Без префикса сгенерированный номер не отличить от введенного вручную,
+// поэтому такие номера сгенерированными не считаются
+func (g *Generator) Generated(ticket string) bool {
+	if g.cfg.Prefix == "" {
+		return false
+	}
+	rest, ok := strings.CutPrefix(ticket, g.cfg.Prefix)
+	if !ok || len(rest) != g.cfg.Digits+1 {
+		return false
+	}
+	for _, r := range rest {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Valid проверяет контрольную цифру сгенерированного номера.
+// Номера без префикса генератора, в том числе введенные вручную, не проверяются
+func (g *Generator) Valid(ticket string) bool {
+	if !g.Generated(ticket) {
+		return true
+	}
+	rest := strings.TrimPrefix(ticket, g.cfg.Prefix)
+	return luhnDigit(rest[:len(rest)-1]) == rest[len(rest)-1]
+}
+
+// ValidityMonths срок действия билета в месяцах
+func (g *Generator) ValidityMonths() int {
+	return g.cfg.ValidityMonths
+}
+
+// luhnDigit считает контрольную цифру по алгоритму Луна
+func luhnDigit(digits string) byte {
+	sum := 0
+	double := true
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return byte('0' + (10-sum%10)%10)
+}
@@ -0,0 +1,62 @@
+package ticket
+
+import (
+	"testing"
+
+	"github.com/hnnsly/library-console/internal/config"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      config.TicketConfig
+		sequence int64
+		want     string
+	}{
+		{"padded sequence", config.TicketConfig{Prefix: "R", Digits: 8}, 1, "R000000018"},
+		{"reference Luhn number", config.TicketConfig{Prefix: "R", Digits: 10}, 7992739871, "R79927398713"},
+		{"zero check digit", config.TicketConfig{Prefix: "LIB-", Digits: 4}, 19, "LIB-00190"},
+		{"no prefix", config.TicketConfig{Digits: 6}, 42, "0000422"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := New(tt.cfg).Format(tt.sequence); got != tt.want {
+				t.Errorf("Format(%d) = %q, want %q", tt.sequence, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValid(t *testing.T) {
+	prefixed := config.TicketConfig{Prefix: "R", Digits: 8}
+	tests := []struct {
+		name   string
+		cfg    config.TicketConfig
+		ticket string
+		want   bool
+	}{
+		{"generated number", prefixed, "R000000018", true},
+		{"wrong check digit", prefixed, "R000000017", false},
+		{"transposed digits", prefixed, "R000000108", false},
+		{"manual number without prefix", prefixed, "000000017", true},
+		{"manual number of another length", prefixed, "R12345", true},
+		{"manual number with letters", prefixed, "R0000000A7", true},
+		{"no prefix is never checked", config.TicketConfig{Digits: 8}, "000000017", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := New(tt.cfg).Valid(tt.ticket); got != tt.want {
+				t.Errorf("Valid(%q) = %v, want %v", tt.ticket, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormattedNumbersAreValid(t *testing.T) {
+	g := New(config.TicketConfig{Prefix: "R", Digits: 6})
+	for sequence := int64(0); sequence < 2000; sequence++ {
+		if ticket := g.Format(sequence); !g.Valid(ticket) {
+			t.Fatalf("Valid(%q) = false for a generated number", ticket)
+		}
+	}
+}
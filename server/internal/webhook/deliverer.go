@@ -0,0 +1,167 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hnnsly/library-console/internal/repository"
+	"github.com/hnnsly/library-console/internal/repository/postgres"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// deliveryBatch сколько событий отправляется за проход
+	deliveryBatch = 50
+	// deliveryLease на это время взятые в работу события скрыты от других экземпляров сервера
+	deliveryLease = 10 * time.Minute
+	// baseRetryDelay пауза после первой неудачи, дальше удваивается
+	baseRetryDelay = 30 * time.Second
+	// maxRetryDelay верхняя граница экспоненциальной паузы между попытками
+	maxRetryDelay = 12 * time.Hour
+	// maxResponseBody сколько байт ответа подписчика сохраняется в журнале попыток
+	maxResponseBody = 2048
+)
+
+// Deliverer отправляет события из outbox подписчикам и ведет журнал попыток
+type Deliverer struct {
+	repo        *repository.LibraryRepository
+	client      *http.Client
+	maxAttempts int
+}
+
+// NewDeliverer создает отправителя. Редиректы не выполняются: подписчик должен указать конечный адрес
+func NewDeliverer(repo *repository.LibraryRepository, maxAttempts int, timeout time.Duration) *Deliverer {
+	return &Deliverer{
+		repo: repo,
+		client: &http.Client{
+			Timeout: timeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		maxAttempts: maxAttempts,
+	}
+}
+
+// attempt результат одной попытки доставки
+type attempt struct {
+	status   *int
+	body     *string
+	duration time.Duration
+	err      error
+}
+
+// DeliverPending отправляет очередную порцию событий. Ошибки доставки не прерывают проход,
+// возвращается только ошибка работы с базой
+func (d *Deliverer) DeliverPending(ctx context.Context) (delivered, failed int, err error) {
+	batch, err := d.repo.ClaimPendingWebhooks(ctx, postgres.ClaimPendingWebhooksParams{
+		LeaseSeconds: int(deliveryLease.Seconds()),
+		LimitCount:   deliveryBatch,
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("выборка событий: %w", err)
+	}
+
+	for _, w := range batch {
+		if ctx.Err() != nil {
+			return delivered, failed, ctx.Err()
+		}
+
+		a := d.post(ctx, w)
+		if err := d.record(ctx, w, a); err != nil {
+			return delivered, failed, err
+		}
+		if a.err != nil {
+			failed++
+			log.Warn().Err(a.err).Str("deliveryID", w.ID.String()).Str("event", w.EventType).
+				Int("attempt", w.Attempts+1).Msg("Webhook delivery failed")
+			continue
+		}
+		delivered++
+	}
+
+	return delivered, failed, nil
+}
+
+func (d *Deliverer) post(ctx context.Context, w *postgres.ClaimPendingWebhooksRow) attempt {
+	timestamp := time.Now().Unix()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.Url, bytes.NewReader(w.Payload))
+	if err != nil {
+		return attempt{err: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "library-console-webhooks/1")
+	req.Header.Set(HeaderID, w.EventID.String())
+	req.Header.Set(HeaderEvent, w.EventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(w.Secret, timestamp, w.Payload))
+
+	start := time.Now()
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return attempt{duration: time.Since(start), err: err}
+	}
+	defer resp.Body.Close()
+
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	// Дочитываем остаток, чтобы соединение вернулось в пул
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+
+	a := attempt{status: &resp.StatusCode, duration: time.Since(start)}
+	if len(raw) > 0 {
+		// TEXT в PostgreSQL не принимает нулевые байты и невалидный UTF-8
+		body := strings.ReplaceAll(string(bytes.ToValidUTF8(raw, []byte("?"))), "\x00", "")
+		a.body = &body
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		a.err = fmt.Errorf("подписчик ответил %s", resp.Status)
+	}
+	return a
+}
+
+// record пишет попытку в журнал и переводит событие в доставленные либо назначает повтор
+// через 30 секунд, минуту, две... но не реже раза в 12 часов
+func (d *Deliverer) record(ctx context.Context, w *postgres.ClaimPendingWebhooksRow, a attempt) error {
+	var errText *string
+	if a.err != nil {
+		text := a.err.Error()
+		// Таймаут клиента оборачивается длинной цепочкой, в журнале достаточно сути
+		if errors.Is(a.err, context.DeadlineExceeded) {
+			text = "превышено время ожидания ответа"
+		}
+		errText = &text
+	}
+
+	err := d.repo.RecordWebhookAttempt(ctx, postgres.RecordWebhookAttemptParams{
+		OutboxID:       w.ID,
+		ResponseStatus: a.status,
+		ResponseBody:   a.body,
+		Error:          errText,
+		DurationMs:     int(a.duration.Milliseconds()),
+	})
+	if err != nil {
+		return fmt.Errorf("журнал доставки %s: %w", w.ID, err)
+	}
+
+	if a.err == nil {
+		err = d.repo.MarkWebhookDelivered(ctx, w.ID)
+	} else {
+		delay := min(baseRetryDelay<<min(w.Attempts, 20), maxRetryDelay)
+		err = d.repo.MarkWebhookFailed(ctx, postgres.MarkWebhookFailedParams{
+			MaxAttempts:  d.maxAttempts,
+			RetrySeconds: int(delay.Seconds()),
+			ID:           w.ID,
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("событие %s: %w", w.ID, err)
+	}
+	return nil
+}
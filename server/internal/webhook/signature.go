@@ -0,0 +1,39 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+const (
+	// HeaderID идентификатор события; одинаков для всех повторов, по нему подписчик отбрасывает дубли
+	HeaderID = "X-Webhook-Id"
+	// HeaderEvent тип события, например loan.issued
+	HeaderEvent = "X-Webhook-Event"
+	// HeaderTimestamp время отправки в секундах Unix, входит в подпись
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	// HeaderSignature подпись вида sha256=<hex>
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Sign вычисляет HMAC-SHA256 от строки "<timestamp>.<тело>" на секрете подписки.
+// Метка времени в подписи не дает повторно использовать перехваченный запрос
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewSecret генерирует случайный секрет подписки
+func NewSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,57 @@
+// Package barcode encodes linear barcodes into bar/space modules.
+package barcode
+
+import "errors"
+
+// Symbology identifies a supported barcode type.
+type Symbology string
+
+const (
+	Code128 Symbology = "code128"
+	EAN13   Symbology = "ean13"
+)
+
+var (
+	ErrUnsupportedSymbology = errors.New("unsupported barcode symbology")
+	ErrInvalidData          = errors.New("data cannot be encoded with this symbology")
+)
+
+// Barcode is an encoded barcode: one entry per module, true for a bar.
+// Quiet zones are not included and must be added by the renderer.
+type Barcode struct {
+	Symbology Symbology
+	Text      string
+	Modules   []bool
+}
+
+// Encode encodes data with the given symbology.
+func Encode(symbology Symbology, data string) (*Barcode, error) {
+	switch symbology {
+	case Code128:
+		return EncodeCode128(data)
+	case EAN13:
+		return EncodeEAN13(data)
+	default:
+		return nil, ErrUnsupportedSymbology
+	}
+}
+
+// appendWidths appends alternating bar/space runs, starting with a bar.
+func appendWidths(modules []bool, widths string) []bool {
+	bar := true
+	for _, w := range widths {
+		for i := 0; i < int(w-'0'); i++ {
+			modules = append(modules, bar)
+		}
+		bar = !bar
+	}
+	return modules
+}
+
+// appendBits appends modules written as a string of '1' (bar) and '0' (space).
+func appendBits(modules []bool, bits string) []bool {
+	for _, b := range bits {
+		modules = append(modules, b == '1')
+	}
+	return modules
+}
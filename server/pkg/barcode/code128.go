@@ -0,0 +1,98 @@
+package barcode
+
+import "fmt"
+
+// code128Patterns holds bar/space widths for symbol values 0..106.
+var code128Patterns = [...]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+const (
+	code128CodeC  = 99
+	code128CodeB  = 100
+	code128StartB = 104
+	code128StartC = 105
+	code128Stop   = 106
+)
+
+// EncodeCode128 encodes printable ASCII using code sets B and C,
+// switching to C for runs of digits where it makes the symbol shorter.
+func EncodeCode128(data string) (*Barcode, error) {
+	if data == "" {
+		return nil, fmt.Errorf("%w: empty data", ErrInvalidData)
+	}
+	for i := 0; i < len(data); i++ {
+		if data[i] < 32 || data[i] > 126 {
+			return nil, fmt.Errorf("%w: Code 128 supports printable ASCII only", ErrInvalidData)
+		}
+	}
+
+	values := make([]int, 0, len(data)+4)
+	lead := digitRun(data, 0)
+	inC := lead >= 4 || lead == 2 && len(data) == 2
+	if inC {
+		values = append(values, code128StartC)
+	} else {
+		values = append(values, code128StartB)
+	}
+
+	for i := 0; i < len(data); {
+		run := digitRun(data, i)
+		if inC {
+			if run >= 2 {
+				values = append(values, int(data[i]-'0')*10+int(data[i+1]-'0'))
+				i += 2
+				continue
+			}
+			values = append(values, code128CodeB)
+			inC = false
+		}
+
+		// A run of digits is worth switching to C for when it saves symbols
+		atEnd := i+run == len(data)
+		if run >= 6 || atEnd && run >= 4 {
+			if run%2 == 1 {
+				values = append(values, int(data[i])-32)
+				i++
+			}
+			values = append(values, code128CodeC)
+			inC = true
+			continue
+		}
+
+		values = append(values, int(data[i])-32)
+		i++
+	}
+
+	checksum := values[0]
+	for i := 1; i < len(values); i++ {
+		checksum += i * values[i]
+	}
+	values = append(values, checksum%103, code128Stop)
+
+	modules := make([]bool, 0, len(values)*11+2)
+	for _, v := range values {
+		modules = appendWidths(modules, code128Patterns[v])
+	}
+
+	return &Barcode{Symbology: Code128, Text: data, Modules: modules}, nil
+}
+
+// digitRun returns the number of consecutive digits starting at i.
+func digitRun(data string, i int) int {
+	n := 0
+	for i+n < len(data) && data[i+n] >= '0' && data[i+n] <= '9' {
+		n++
+	}
+	return n
+}
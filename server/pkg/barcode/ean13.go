@@ -0,0 +1,74 @@
+package barcode
+
+import "fmt"
+
+var (
+	eanLeftOdd = [10]string{
+		"0001101", "0011001", "0010011", "0111101", "0100011",
+		"0110001", "0101111", "0111011", "0110111", "0001011",
+	}
+	eanLeftEven = [10]string{
+		"0100111", "0110011", "0011011", "0100001", "0011101",
+		"0111001", "0000101", "0010001", "0001001", "0010111",
+	}
+	eanRight = [10]string{
+		"1110010", "1100110", "1101100", "1000010", "1011100",
+		"1001110", "1010000", "1000100", "1001000", "1110100",
+	}
+	// eanParity selects odd (L) or even (G) encoding of the left half by the first digit.
+	eanParity = [10]string{
+		"LLLLLL", "LLGLGG", "LLGGLG", "LLGGGL", "LGLLGG",
+		"LGGLLG", "LGGGLL", "LGLGLG", "LGLGGL", "LGGLGL",
+	}
+)
+
+// EncodeEAN13 encodes 12 digits (the check digit is appended)
+// or 13 digits (the check digit is verified).
+func EncodeEAN13(data string) (*Barcode, error) {
+	if len(data) != 12 && len(data) != 13 {
+		return nil, fmt.Errorf("%w: EAN-13 needs 12 or 13 digits", ErrInvalidData)
+	}
+	for i := 0; i < len(data); i++ {
+		if data[i] < '0' || data[i] > '9' {
+			return nil, fmt.Errorf("%w: EAN-13 accepts digits only", ErrInvalidData)
+		}
+	}
+
+	check := EAN13CheckDigit(data[:12])
+	if len(data) == 13 && data[12] != check {
+		return nil, fmt.Errorf("%w: wrong EAN-13 check digit", ErrInvalidData)
+	}
+	text := data[:12] + string(check)
+
+	modules := make([]bool, 0, 95)
+	modules = appendBits(modules, "101")
+	parity := eanParity[text[0]-'0']
+	for i := 1; i <= 6; i++ {
+		digit := text[i] - '0'
+		if parity[i-1] == 'L' {
+			modules = appendBits(modules, eanLeftOdd[digit])
+		} else {
+			modules = appendBits(modules, eanLeftEven[digit])
+		}
+	}
+	modules = appendBits(modules, "01010")
+	for i := 7; i <= 12; i++ {
+		modules = appendBits(modules, eanRight[text[i]-'0'])
+	}
+	modules = appendBits(modules, "101")
+
+	return &Barcode{Symbology: EAN13, Text: text, Modules: modules}, nil
+}
+
+// EAN13CheckDigit computes the check digit for the first 12 digits.
+func EAN13CheckDigit(digits string) byte {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		d := int(digits[i] - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10)
+}
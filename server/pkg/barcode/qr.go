@@ -0,0 +1,355 @@
+package barcode
+
+import "fmt"
+
+// QRCode is an encoded QR code symbol without the quiet zone.
+type QRCode struct {
+	Text    string
+	Size    int
+	Modules [][]bool // Modules[y][x], true for a dark module
+}
+
+// qrVersion describes a symbol version at error correction level M.
+// All supported versions use blocks of equal length.
+type qrVersion struct {
+	dataCodewords int
+	ecPerBlock    int
+	blocks        int
+	alignment     int // position of the single alignment pattern, 0 if none
+}
+
+// qrVersions lists versions 1..6 at level M, which is enough for ticket numbers and short links.
+var qrVersions = [...]qrVersion{
+	{dataCodewords: 16, ecPerBlock: 10, blocks: 1},
+	{dataCodewords: 28, ecPerBlock: 16, blocks: 1, alignment: 18},
+	{dataCodewords: 44, ecPerBlock: 26, blocks: 1, alignment: 22},
+	{dataCodewords: 64, ecPerBlock: 18, blocks: 2, alignment: 26},
+	{dataCodewords: 86, ecPerBlock: 24, blocks: 2, alignment: 30},
+	{dataCodewords: 108, ecPerBlock: 16, blocks: 4, alignment: 34},
+}
+
+// EncodeQR encodes data in byte mode with error correction level M.
+func EncodeQR(data string) (*QRCode, error) {
+	version := -1
+	for i, v := range qrVersions {
+		// 4 bits of mode and 8 bits of length precede the data
+		if (v.dataCodewords*8-12)/8 >= len(data) {
+			version = i
+			break
+		}
+	}
+	if version < 0 {
+		return nil, fmt.Errorf("%w: too long for a QR code", ErrInvalidData)
+	}
+
+	v := qrVersions[version]
+	q := newQRMatrix(version+1, v.alignment)
+	q.drawCodewords(qrCodewords(data, v))
+
+	// The mask with the lowest penalty gives the most readable symbol
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		q.applyMask(mask)
+		q.drawFormatBits(mask)
+		if penalty := q.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		q.applyMask(mask)
+	}
+	q.applyMask(best)
+	q.drawFormatBits(best)
+
+	return &QRCode{Text: data, Size: q.size, Modules: q.modules}, nil
+}
+
+// qrCodewords builds data codewords, adds Reed-Solomon codewords and interleaves blocks.
+func qrCodewords(data string, v qrVersion) []byte {
+	bits := make([]bool, 0, v.dataCodewords*8)
+	appendValue := func(value, length int) {
+		for i := length - 1; i >= 0; i-- {
+			bits = append(bits, value>>i&1 == 1)
+		}
+	}
+	appendValue(0b0100, 4)
+	appendValue(len(data), 8)
+	for i := 0; i < len(data); i++ {
+		appendValue(int(data[i]), 8)
+	}
+	capacity := v.dataCodewords * 8
+	appendValue(0, min(4, capacity-len(bits)))
+	appendValue(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacity; pad ^= 0xEC ^ 0x11 {
+		appendValue(pad, 8)
+	}
+
+	codewords := make([]byte, v.dataCodewords)
+	for i, bit := range bits {
+		if bit {
+			codewords[i/8] |= 1 << (7 - i%8)
+		}
+	}
+
+	blockLen := v.dataCodewords / v.blocks
+	divisor := rsDivisor(v.ecPerBlock)
+	blocks := make([][]byte, v.blocks)
+	ecBlocks := make([][]byte, v.blocks)
+	for b := range blocks {
+		blocks[b] = codewords[b*blockLen : (b+1)*blockLen]
+		ecBlocks[b] = rsRemainder(blocks[b], divisor)
+	}
+
+	result := make([]byte, 0, v.dataCodewords+v.ecPerBlock*v.blocks)
+	for i := 0; i < blockLen; i++ {
+		for b := range blocks {
+			result = append(result, blocks[b][i])
+		}
+	}
+	for i := 0; i < v.ecPerBlock; i++ {
+		for b := range ecBlocks {
+			result = append(result, ecBlocks[b][i])
+		}
+	}
+	return result
+}
+
+type qrMatrix struct {
+	size       int
+	modules    [][]bool
+	isFunction [][]bool
+}
+
+func newQRMatrix(version, alignment int) *qrMatrix {
+	size := 17 + 4*version
+	q := &qrMatrix{size: size, modules: make([][]bool, size), isFunction: make([][]bool, size)}
+	for y := range q.modules {
+		q.modules[y] = make([]bool, size)
+		q.isFunction[y] = make([]bool, size)
+	}
+
+	for i := 0; i < size; i++ {
+		q.setFunction(6, i, i%2 == 0)
+		q.setFunction(i, 6, i%2 == 0)
+	}
+	q.drawFinder(3, 3)
+	q.drawFinder(size-4, 3)
+	q.drawFinder(3, size-4)
+	if alignment > 0 {
+		for dy := -2; dy <= 2; dy++ {
+			for dx := -2; dx <= 2; dx++ {
+				q.setFunction(alignment+dx, alignment+dy, max(abs(dx), abs(dy)) != 1)
+			}
+		}
+	}
+	// Reserve format areas; the real bits are drawn after masking
+	q.drawFormatBits(0)
+	return q
+}
+
+func (q *qrMatrix) setFunction(x, y int, dark bool) {
+	q.modules[y][x] = dark
+	q.isFunction[y][x] = true
+}
+
+func (q *qrMatrix) drawFinder(cx, cy int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			x, y := cx+dx, cy+dy
+			if x < 0 || y < 0 || x >= q.size || y >= q.size {
+				continue
+			}
+			dist := max(abs(dx), abs(dy))
+			q.setFunction(x, y, dist != 2 && dist != 4)
+		}
+	}
+}
+
+// drawFormatBits writes both copies of the format information for level M.
+func (q *qrMatrix) drawFormatBits(mask int) {
+	data := mask // level M is encoded as 00
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return bits>>i&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		q.setFunction(8, i, bit(i))
+	}
+	q.setFunction(8, 7, bit(6))
+	q.setFunction(8, 8, bit(7))
+	q.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		q.setFunction(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		q.setFunction(q.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.setFunction(8, q.size-15+i, bit(i))
+	}
+	q.setFunction(8, q.size-8, true)
+}
+
+// drawCodewords places data in the zigzag order, skipping function modules.
+func (q *qrMatrix) drawCodewords(data []byte) {
+	i := 0
+	for right := q.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < q.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = q.size - 1 - vert
+				}
+				if q.isFunction[y][x] || i >= len(data)*8 {
+					continue
+				}
+				q.modules[y][x] = data[i/8]>>(7-i%8)&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+// applyMask toggles data modules by the mask pattern; applying it twice undoes it.
+func (q *qrMatrix) applyMask(mask int) {
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			if q.isFunction[y][x] {
+				continue
+			}
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert {
+				q.modules[y][x] = !q.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores the symbol using the four rules of the QR specification.
+func (q *qrMatrix) penalty() int {
+	at := func(x, y int, vertical bool) bool {
+		if vertical {
+			return q.modules[x][y]
+		}
+		return q.modules[y][x]
+	}
+
+	score, dark := 0, 0
+	for _, vertical := range []bool{false, true} {
+		for y := 0; y < q.size; y++ {
+			run := 1
+			for x := 1; x <= q.size; x++ {
+				if x < q.size && at(x, y, vertical) == at(x-1, y, vertical) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					score += run - 2
+				}
+				run = 1
+			}
+			// Finder-like pattern 1011101 with four light modules on either side
+			for x := 0; x+11 <= q.size; x++ {
+				window := 0
+				for k := 0; k < 11; k++ {
+					window <<= 1
+					if at(x+k, y, vertical) {
+						window |= 1
+					}
+				}
+				if window == 0b10111010000 || window == 0b00001011101 {
+					score += 40
+				}
+			}
+		}
+	}
+
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			if q.modules[y][x] {
+				dark++
+			}
+			if x > 0 && y > 0 {
+				c := q.modules[y][x]
+				if q.modules[y-1][x] == c && q.modules[y][x-1] == c && q.modules[y-1][x-1] == c {
+					score += 3
+				}
+			}
+		}
+	}
+
+	total := q.size * q.size
+	deviation := abs(dark*20-total*10) / total
+	return score + deviation*10
+}
+
+// rsDivisor returns the Reed-Solomon generator polynomial of the given degree.
+func rsDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := 0; j < degree; j++ {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < degree {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i := range result {
+			result[i] ^= gfMultiply(divisor[i], factor)
+		}
+	}
+	return result
+}
+
+// gfMultiply multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1.
+func gfMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*0x11D
+		z ^= int(y>>i&1) * int(x)
+	}
+	return byte(z)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
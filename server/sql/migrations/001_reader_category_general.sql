@@ -0,0 +1,26 @@
+-- Категории читателей для баз, созданных до их появления или с категорией guest по умолчанию.
+-- Выполнять через psql без общей транзакции: новое значение перечисления должно быть
+-- зафиксировано до того, как его использует DEFAULT и UPDATE
+
+DO $$
+BEGIN
+    CREATE TYPE reader_category AS ENUM ('student', 'staff', 'guest', 'child', 'general');
+EXCEPTION
+    WHEN duplicate_object THEN NULL;
+END
+$$;
+
+ALTER TYPE reader_category ADD VALUE IF NOT EXISTS 'general';
+
+ALTER TABLE readers ADD COLUMN IF NOT EXISTS category reader_category NOT NULL DEFAULT 'general';
+ALTER TABLE readers ADD COLUMN IF NOT EXISTS membership_expires_at DATE;
+ALTER TABLE readers ALTER COLUMN category SET DEFAULT 'general';
+
+-- Читатели, получившие guest только по умолчанию столбца, не имеют срока членства:
+-- при регистрации через API срок всегда заполняется, так что явно назначенные гости не затрагиваются
+UPDATE readers
+SET category = 'general'
+WHERE category = 'guest'
+  AND membership_expires_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_readers_membership_expires_at ON readers(membership_expires_at) WHERE is_active = TRUE;
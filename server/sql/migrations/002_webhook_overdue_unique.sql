@@ -0,0 +1,12 @@
+-- Одно событие loan.overdue на выдачу для каждой подписки. Дубли, записанные пересекшимися
+-- проходами фоновой задачи до появления индекса, удаляются, кроме самой ранней записи
+
+DELETE FROM webhook_outbox o
+USING webhook_outbox earlier
+WHERE o.event_type = 'loan.overdue'
+  AND earlier.event_type = 'loan.overdue'
+  AND earlier.subscription_id = o.subscription_id
+  AND earlier.subject_id = o.subject_id
+  AND (earlier.created_at, earlier.id) < (o.created_at, o.id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_webhook_outbox_overdue ON webhook_outbox(subscription_id, event_type, subject_id) WHERE event_type = 'loan.overdue';
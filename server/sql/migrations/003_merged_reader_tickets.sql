@@ -0,0 +1,9 @@
+-- Дубли, объединенные до освобождения их номеров, сохранили прежний билет, и поиск по нему
+-- находил неактивную запись вместо основной. Анонимизированные записи уже получили номер ANON
+
+UPDATE readers r
+SET ticket_number = 'MERGED' || left(replace(r.id::text, '-', ''), 14)
+FROM reader_merges m
+WHERE m.merged_reader_id = r.id
+  AND r.anonymized_at IS NULL
+  AND r.ticket_number NOT LIKE 'MERGED%';
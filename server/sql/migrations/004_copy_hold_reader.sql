@@ -0,0 +1,4 @@
+-- Читатель, для которого отложен экземпляр. Экземпляры, зарезервированные до появления столбца,
+-- остаются без читателя и выдаются только по запросу партнера через NCIP, пока их не отложат заново
+
+ALTER TABLE book_copies ADD COLUMN IF NOT EXISTS hold_reader_id UUID REFERENCES readers(id) ON DELETE SET NULL;
@@ -0,0 +1,14 @@
+-- name: AddAuthorNameVariant :one
+INSERT INTO author_name_variants (author_id, name, kind)
+VALUES (@author_id, @name, @kind)
+RETURNING id, author_id, name, kind, created_at;
+
+-- name: GetAuthorNameVariants :many
+SELECT id, author_id, name, kind, created_at
+FROM author_name_variants
+WHERE author_id = @author_id
+ORDER BY kind, name;
+
+-- name: DeleteAuthorNameVariant :execrows
+DELETE FROM author_name_variants
+WHERE id = @id AND author_id = @author_id;
@@ -1,26 +1,152 @@
 -- name: CreateAuthor :one
-INSERT INTO authors (full_name)
-VALUES (@full_name)
-RETURNING id, full_name;
+INSERT INTO authors (full_name, sort_name, birth_year, death_year)
+VALUES (@full_name, @sort_name, @birth_year, @death_year)
+RETURNING id, full_name, sort_name, birth_year, death_year, created_at;
+
+-- name: UpdateAuthor :one
+UPDATE authors
+SET full_name = @full_name,
+    sort_name = @sort_name,
+    birth_year = @birth_year,
+    death_year = @death_year
+WHERE id = @id
+RETURNING id, full_name, sort_name, birth_year, death_year, created_at;
+
+-- name: LockAuthorName :exec
+-- Поиск и создание автора с одним именем выполняются по очереди до конца транзакции.
+-- Уникальный индекс по имени не подходит: однофамильцы с одинаковым именем допустимы
+SELECT pg_advisory_xact_lock(hashtext('author:' || normalize_person_name(@full_name)));
 
 -- name: GetOrCreateAuthor :one
-INSERT INTO authors (full_name)
-VALUES (@full_name)
-ON CONFLICT (full_name) DO UPDATE SET full_name = EXCLUDED.full_name
-RETURNING id, full_name;
+WITH matched AS (
+    -- сначала совпадение основной формы имени, затем любого из вариантов
+    SELECT m.id, m.full_name
+    FROM (
+        SELECT a.id, a.full_name, 0 as rank, a.created_at
+        FROM authors a
+        WHERE normalize_person_name(a.full_name) = normalize_person_name(@full_name)
+        UNION ALL
+        SELECT a.id, a.full_name, 1 as rank, a.created_at
+        FROM author_name_variants v
+        JOIN authors a ON a.id = v.author_id
+        WHERE normalize_person_name(v.name) = normalize_person_name(@full_name)
+    ) m
+    ORDER BY m.rank, m.created_at
+    LIMIT 1
+), created AS (
+    INSERT INTO authors (full_name)
+    SELECT btrim(@full_name)
+    WHERE NOT EXISTS (SELECT 1 FROM matched)
+    RETURNING id, full_name
+)
+SELECT id, full_name FROM matched
+UNION ALL
+SELECT id, full_name FROM created;
 
 -- name: GetAuthorById :one
-SELECT id, full_name
+SELECT id, full_name, sort_name, birth_year, death_year, created_at
 FROM authors
 WHERE id = @id;
 
 -- name: SearchAuthors :many
-SELECT id, full_name
-FROM authors
-WHERE full_name ILIKE '%' || @search_term || '%'
-ORDER BY full_name;
+SELECT a.id, a.full_name, a.sort_name, a.birth_year, a.death_year, a.created_at
+FROM authors a
+WHERE a.full_name ILIKE '%' || @search_term || '%'
+   OR EXISTS (
+       SELECT 1 FROM author_name_variants v
+       WHERE v.author_id = a.id AND v.name ILIKE '%' || @search_term || '%'
+   )
+ORDER BY COALESCE(a.sort_name, a.full_name);
 
 -- name: GetAllAuthors :many
-SELECT id, full_name
+SELECT id, full_name, sort_name, birth_year, death_year, created_at
+FROM authors
+ORDER BY COALESCE(sort_name, full_name);
+
+-- name: DeleteAuthor :execrows
+DELETE FROM authors a
+WHERE a.id = @id
+  AND NOT EXISTS (SELECT 1 FROM book_authors ba WHERE ba.author_id = a.id);
+
+-- name: FindDuplicateAuthors :many
+WITH forms AS (
+    -- основная форма и все варианты имени каждого автора
+    SELECT id as author_id, full_name as name FROM authors
+    UNION
+    SELECT author_id, name FROM author_name_variants
+), words AS (
+    SELECT DISTINCT f.author_id, w.word
+    FROM forms f, unnest(person_name_words(f.name)) as w(word)
+    WHERE length(w.word) >= 3
+), pairs AS (
+    SELECT DISTINCT a.author_id, b.author_id as candidate_id
+    FROM words a
+    JOIN words b ON b.word = a.word AND b.author_id <> a.author_id
+    WHERE (@author_id::uuid IS NULL AND a.author_id < b.author_id) OR a.author_id = @author_id::uuid
+), scored AS (
+    -- совпадающие инициалы («Толстой Л.Н.» и «Лев Николаевич Толстой») поднимают оценку выше половины
+    SELECT p.author_id, p.candidate_id,
+           MAX(CASE
+               WHEN person_name_initials(fa.name) <@ person_name_initials(fb.name)
+                 OR person_name_initials(fb.name) <@ person_name_initials(fa.name)
+               THEN 0.5 + 0.5 * similarity(normalize_person_name(fa.name), normalize_person_name(fb.name))
+               ELSE 0.5 * similarity(normalize_person_name(fa.name), normalize_person_name(fb.name))
+           END)::float8 as score
+    FROM pairs p
+    JOIN forms fa ON fa.author_id = p.author_id
+    JOIN forms fb ON fb.author_id = p.candidate_id
+    GROUP BY p.author_id, p.candidate_id
+)
+SELECT s.author_id, a.full_name, a.birth_year, a.death_year,
+       (SELECT COUNT(DISTINCT ba.book_id) FROM book_authors ba WHERE ba.author_id = s.author_id) as book_count,
+       s.candidate_id, b.full_name as candidate_full_name,
+       b.birth_year as candidate_birth_year, b.death_year as candidate_death_year,
+       (SELECT COUNT(DISTINCT ba.book_id) FROM book_authors ba WHERE ba.author_id = s.candidate_id) as candidate_book_count,
+       s.score
+FROM scored s
+JOIN authors a ON a.id = s.author_id
+JOIN authors b ON b.id = s.candidate_id
+WHERE s.score >= @min_score::float8
+  -- разные годы жизни означают разных людей
+  AND (a.birth_year IS NULL OR b.birth_year IS NULL OR a.birth_year = b.birth_year)
+  AND (a.death_year IS NULL OR b.death_year IS NULL OR a.death_year = b.death_year)
+ORDER BY s.score DESC, a.full_name
+LIMIT @limit_count;
+
+-- name: LockAuthor :one
+SELECT id, full_name, sort_name, birth_year, death_year, created_at
 FROM authors
-ORDER BY full_name;
+WHERE id = @id
+FOR UPDATE;
+
+-- name: CopyAuthorBookLinks :execrows
+INSERT INTO book_authors (book_id, author_id, role)
+SELECT book_id, @target_id, role
+FROM book_authors
+WHERE author_id = @source_id
+ON CONFLICT DO NOTHING;
+
+-- name: CopyAuthorNameVariants :exec
+INSERT INTO author_name_variants (author_id, name, kind)
+SELECT @target_id, v.name, v.kind
+FROM (
+    SELECT name, kind FROM author_name_variants WHERE author_id = @source_id
+    UNION ALL
+    -- основная форма дубля остается вариантом имени
+    SELECT full_name, 'variant' FROM authors WHERE id = @source_id
+) v
+WHERE normalize_person_name(v.name) <> (SELECT normalize_person_name(full_name) FROM authors WHERE id = @target_id)
+ON CONFLICT DO NOTHING;
+
+-- name: FillAuthorAuthorityData :one
+UPDATE authors t
+SET sort_name = COALESCE(t.sort_name, s.sort_name),
+    birth_year = COALESCE(t.birth_year, s.birth_year),
+    death_year = COALESCE(t.death_year, s.death_year)
+FROM authors s
+WHERE t.id = @target_id AND s.id = @source_id
+RETURNING t.id, t.full_name, t.sort_name, t.birth_year, t.death_year, t.created_at;
+
+-- name: DeleteMergedAuthor :exec
+DELETE FROM authors
+WHERE id = @id;
@@ -0,0 +1,36 @@
+-- name: CreateBookAttachment :one
+INSERT INTO book_attachments (
+    id, book_id, kind, storage_key, content_type, size_bytes, sha256,
+    width, height, thumbnail_widths, file_name, uploaded_by
+)
+VALUES (
+    @id, @book_id, @kind, @storage_key, @content_type, @size_bytes, @sha256,
+    @width, @height, @thumbnail_widths, @file_name, @uploaded_by
+)
+RETURNING id, book_id, kind, storage_key, content_type, size_bytes, sha256, width, height, thumbnail_widths, file_name, uploaded_by, created_at;
+
+-- name: GetBookAttachments :many
+SELECT id, book_id, kind, storage_key, content_type, size_bytes, sha256, width, height, thumbnail_widths, file_name, uploaded_by, created_at
+FROM book_attachments
+WHERE book_id = @book_id
+ORDER BY kind, created_at;
+
+-- name: GetBookAttachment :one
+SELECT id, book_id, kind, storage_key, content_type, size_bytes, sha256, width, height, thumbnail_widths, file_name, uploaded_by, created_at
+FROM book_attachments
+WHERE id = @id AND book_id = @book_id;
+
+-- name: GetBookCover :one
+SELECT id, book_id, kind, storage_key, content_type, size_bytes, sha256, width, height, thumbnail_widths, file_name, uploaded_by, created_at
+FROM book_attachments
+WHERE book_id = @book_id AND kind = 'cover';
+
+-- name: DeleteBookCovers :many
+DELETE FROM book_attachments
+WHERE book_id = @book_id AND kind = 'cover'
+RETURNING id, book_id, kind, storage_key, content_type, size_bytes, sha256, width, height, thumbnail_widths, file_name, uploaded_by, created_at;
+
+-- name: DeleteBookAttachment :one
+DELETE FROM book_attachments
+WHERE id = @id AND book_id = @book_id
+RETURNING id, book_id, kind, storage_key, content_type, size_bytes, sha256, width, height, thumbnail_widths, file_name, uploaded_by, created_at;
@@ -1,21 +1,29 @@
 -- name: AddBookAuthor :exec
-INSERT INTO book_authors (book_id, author_id)
-VALUES (@book_id, @author_id);
+INSERT INTO book_authors (book_id, author_id, role)
+VALUES (@book_id, @author_id, @role);
 
 -- name: RemoveBookAuthor :exec
 DELETE FROM book_authors
-WHERE book_id = @book_id AND author_id = @author_id;
+WHERE book_id = @book_id AND author_id = @author_id
+  AND (@role::text = '' OR role::text = @role::text);
 
 -- name: GetBookAuthors :many
-SELECT a.id, a.full_name
+SELECT a.id, a.full_name, ba.role
 FROM authors a
 JOIN book_authors ba ON a.id = ba.author_id
 WHERE ba.book_id = @book_id
-ORDER BY a.full_name;
+ORDER BY ba.role, a.full_name;
 
 -- name: GetAuthorBooks :many
-SELECT b.id, b.title, b.isbn, b.publication_year
+SELECT b.id, b.title, b.isbn, b.publication_year, ba.role
 FROM books b
 JOIN book_authors ba ON b.id = ba.book_id
 WHERE ba.author_id = @author_id
-ORDER BY b.title;
+ORDER BY b.title, ba.role;
+
+-- name: GetBooksContributors :many
+SELECT ba.book_id, a.id, a.full_name, ba.role
+FROM book_authors ba
+JOIN authors a ON a.id = ba.author_id
+WHERE ba.book_id = ANY(@book_ids::uuid[])
+ORDER BY ba.book_id, ba.role, a.full_name;
@@ -5,7 +5,8 @@ RETURNING id, copy_code, status;
 
 -- name: UpdateBookCopyStatus :exec
 UPDATE book_copies
-SET status = @status
+SET status = @status,
+    hold_reader_id = @hold_reader_id
 WHERE id = @copy_id;
 
 -- name: GetBookCopyById :one
@@ -27,6 +28,12 @@ FROM book_copies bc
 JOIN books b ON bc.book_id = b.id
 WHERE bc.copy_code = @copy_code AND bc.status = 'available';
 
+-- name: GetReservedBookCopy :one
+SELECT bc.id, bc.copy_code, bc.status, b.title, bc.location_info, bc.hold_reader_id
+FROM book_copies bc
+JOIN books b ON bc.book_id = b.id
+WHERE bc.copy_code = @copy_code AND bc.status = 'reserved';
+
 -- name: GetBookCopiesByBookId :many
 SELECT id, copy_code, status
 FROM book_copies
@@ -39,3 +46,27 @@ FROM book_copies bc
 JOIN books b ON bc.book_id = b.id
 WHERE bc.hall_id = @hall_id
 ORDER BY b.title, bc.copy_code;
+
+-- name: LockBookCopyStatus :one
+SELECT status
+FROM book_copies
+WHERE id = @copy_id
+FOR UPDATE;
+
+-- name: NextCopyCodeSequence :one
+SELECT nextval('copy_code_seq')::bigint as sequence;
+
+-- name: GetBookCopiesForLabels :many
+SELECT
+    bc.id,
+    bc.copy_code,
+    bc.location_info,
+    b.title,
+    b.publication_year,
+    rh.hall_name,
+    rh.hall_code
+FROM book_copies bc
+JOIN books b ON bc.book_id = b.id
+LEFT JOIN reading_halls rh ON bc.hall_id = rh.id
+WHERE bc.id = ANY(@copy_ids::uuid[])
+ORDER BY rh.hall_name, b.title, bc.copy_code;
@@ -1,6 +1,6 @@
 -- name: IssueBook :one
-INSERT INTO book_issues (reader_id, book_copy_id, due_date, librarian_id)
-VALUES (@reader_id, @book_copy_id, @due_date, @librarian_id)
+INSERT INTO book_issues (reader_id, book_copy_id, due_date, librarian_id, source_library, external_user_id)
+VALUES (@reader_id, @book_copy_id, @due_date, @librarian_id, @source_library, @external_user_id)
 RETURNING id, issue_date, due_date;
 
 -- name: ReturnBook :one
@@ -52,13 +52,29 @@ SELECT
     b.title,
     bc.copy_code,
     bi.issue_date,
-    bi.due_date
+    bi.due_date,
+    bi.renewal_count
 FROM book_issues bi
 JOIN book_copies bc ON bi.book_copy_id = bc.id
 JOIN books b ON bc.book_id = b.id
 WHERE bi.reader_id = @reader_id AND bi.return_date IS NULL
 ORDER BY bi.due_date;
 
+-- name: GetReaderIssueHistory :many
+SELECT
+    bi.id,
+    b.title,
+    b.isbn,
+    bc.copy_code,
+    bi.issue_date,
+    bi.due_date,
+    bi.return_date
+FROM book_issues bi
+JOIN book_copies bc ON bi.book_copy_id = bc.id
+JOIN books b ON bc.book_id = b.id
+WHERE bi.reader_id = @reader_id
+ORDER BY bi.issue_date DESC, bi.created_at DESC;
+
 -- name: GetRecentBookOperations :many
 SELECT
     'issue' as operation_type,
@@ -96,3 +112,28 @@ WHERE bi.return_date IS NOT NULL AND bi.updated_at >= @since_date
 
 ORDER BY operation_time DESC
 LIMIT @limit_count;
+
+-- name: LockReaderBookIssue :one
+SELECT id, due_date, return_date, renewal_count
+FROM book_issues
+WHERE id = @id AND reader_id = @reader_id
+FOR UPDATE;
+
+-- name: RenewBookIssue :one
+UPDATE book_issues
+SET due_date = @due_date, renewal_count = renewal_count + 1
+WHERE id = @id
+RETURNING id, due_date, renewal_count;
+
+-- name: GetReaderIssueCopy :one
+SELECT bc.copy_code, bi.return_date
+FROM book_issues bi
+JOIN book_copies bc ON bi.book_copy_id = bc.id
+WHERE bi.id = @id AND bi.reader_id = @reader_id;
+
+-- name: GetActiveIssueByCopyCode :one
+SELECT bi.id, bi.reader_id, bi.due_date, bi.renewal_count, b.title, bi.source_library
+FROM book_issues bi
+JOIN book_copies bc ON bi.book_copy_id = bc.id
+JOIN books b ON bc.book_id = b.id
+WHERE bc.copy_code = @copy_code AND bi.return_date IS NULL;
@@ -1,18 +1,47 @@
 -- name: CreateBook :one
-INSERT INTO books (title, isbn, publication_year, publisher, total_copies, available_copies)
-VALUES (@title, @isbn, @publication_year, @publisher, @total_copies, @total_copies)
-RETURNING id, title, isbn, publication_year, publisher, total_copies, available_copies;
+INSERT INTO books (
+    title, isbn, publication_year, publisher,
+    work_id, series_id, series_volume, language, original_language, page_count, description
+)
+VALUES (
+    @title, @isbn, @publication_year, @publisher,
+    @work_id, @series_id, @series_volume, @language, @original_language, @page_count, @description
+)
+RETURNING id, title, isbn, publication_year, publisher, total_copies, available_copies,
+    work_id, series_id, series_volume, language, original_language, page_count, description;
 
 -- name: UpdateBook :one
 UPDATE books
-SET title = @title, isbn = @isbn, publication_year = @publication_year, publisher = @publisher
+SET title = @title, isbn = @isbn, publication_year = @publication_year, publisher = @publisher,
+    work_id = @work_id, series_id = @series_id, series_volume = @series_volume,
+    language = @language, original_language = @original_language,
+    page_count = @page_count, description = @description
 WHERE id = @id
-RETURNING id, title, isbn, publication_year, publisher, total_copies, available_copies;
+RETURNING id, title, isbn, publication_year, publisher, total_copies, available_copies,
+    work_id, series_id, series_volume, language, original_language, page_count, description;
 
 -- name: GetBookById :one
-SELECT id, title, isbn, publication_year, publisher, total_copies, available_copies
-FROM books
-WHERE id = @id;
+SELECT
+    b.id,
+    b.title,
+    b.isbn,
+    b.publication_year,
+    b.publisher,
+    b.total_copies,
+    b.available_copies,
+    b.work_id,
+    w.title as work_title,
+    b.series_id,
+    sr.title as series_title,
+    b.series_volume,
+    b.language,
+    b.original_language,
+    b.page_count,
+    b.description
+FROM books b
+LEFT JOIN works w ON b.work_id = w.id
+LEFT JOIN series sr ON b.series_id = sr.id
+WHERE b.id = @id;
 
 -- name: SearchBooks :many
 SELECT DISTINCT
@@ -23,16 +52,35 @@ SELECT DISTINCT
     b.publisher,
     b.available_copies,
     b.total_copies,
-    STRING_AGG(a.full_name, ', ') as authors
+    b.work_id,
+    b.series_id,
+    sr.title as series_title,
+    b.series_volume,
+    b.language,
+    b.original_language,
+    b.page_count,
+    STRING_AGG(a.full_name, ', ') FILTER (WHERE ba.role = 'author') as authors
 FROM books b
+LEFT JOIN series sr ON b.series_id = sr.id
 LEFT JOIN book_authors ba ON b.id = ba.book_id
 LEFT JOIN authors a ON ba.author_id = a.id
 WHERE
     (@title::text IS NULL OR b.title ILIKE '%' || @title || '%') AND
     (@author::text IS NULL OR a.full_name ILIKE '%' || @author || '%') AND
-    (@publication_year::int IS NULL OR b.publication_year = @publication_year)
-GROUP BY b.id, b.title, b.isbn, b.publication_year, b.publisher, b.available_copies, b.total_copies
-ORDER BY b.title;
+    (@publication_year::int IS NULL OR b.publication_year = @publication_year) AND
+    (@subject_id::text = '' OR b.id IN (
+        WITH RECURSIVE subtree AS (
+            SELECT s.id FROM subjects s WHERE s.id::text = @subject_id::text
+            UNION ALL
+            SELECT s.id FROM subjects s JOIN subtree t ON s.parent_id = t.id
+        )
+        SELECT bs.book_id FROM book_subjects bs JOIN subtree t ON t.id = bs.subject_id
+    )) AND
+    (@language::text = '' OR b.language = @language::text) AND
+    (@series_id::text = '' OR b.series_id::text = @series_id::text) AND
+    (@work_id::text = '' OR b.work_id::text = @work_id::text)
+GROUP BY b.id, sr.title
+ORDER BY b.title, b.series_volume;
 
 -- name: GetAllBooks :many
 SELECT DISTINCT
@@ -43,14 +91,61 @@ SELECT DISTINCT
     b.publisher,
     b.available_copies,
     b.total_copies,
-    STRING_AGG(a.full_name, ', ') as authors
+    b.work_id,
+    b.series_id,
+    sr.title as series_title,
+    b.series_volume,
+    b.language,
+    b.original_language,
+    b.page_count,
+    STRING_AGG(a.full_name, ', ') FILTER (WHERE ba.role = 'author') as authors
 FROM books b
+LEFT JOIN series sr ON b.series_id = sr.id
 LEFT JOIN book_authors ba ON b.id = ba.book_id
 LEFT JOIN authors a ON ba.author_id = a.id
-GROUP BY b.id, b.title, b.isbn, b.publication_year, b.publisher, b.available_copies, b.total_copies
+GROUP BY b.id, sr.title
 ORDER BY b.title;
 
--- name: UpdateBookCopies :exec
-UPDATE books
-SET available_copies = available_copies + @change
-WHERE id = @book_id;
+-- name: GetBookCopyCountMismatches :many
+SELECT
+    b.id,
+    b.title,
+    b.total_copies,
+    b.available_copies,
+    COALESCE(c.actual_total, 0)::int as actual_total_copies,
+    COALESCE(c.actual_available, 0)::int as actual_available_copies
+FROM books b
+LEFT JOIN (
+    SELECT
+        book_id,
+        COUNT(*) FILTER (WHERE COALESCE(status, 'available') NOT IN ('withdrawn', 'lost')) as actual_total,
+        COUNT(*) FILTER (WHERE COALESCE(status, 'available') = 'available') as actual_available
+    FROM book_copies
+    GROUP BY book_id
+) c ON c.book_id = b.id
+WHERE b.total_copies <> COALESCE(c.actual_total, 0)
+   OR b.available_copies <> COALESCE(c.actual_available, 0)
+ORDER BY b.title;
+
+-- name: RecountAllBookCopies :execrows
+UPDATE books b
+SET total_copies = c.actual_total,
+    available_copies = c.actual_available
+FROM (
+    SELECT
+        bk.id as book_id,
+        COUNT(bc.id) FILTER (WHERE COALESCE(bc.status, 'available') NOT IN ('withdrawn', 'lost')) as actual_total,
+        COUNT(bc.id) FILTER (WHERE COALESCE(bc.status, 'available') = 'available') as actual_available
+    FROM books bk
+    LEFT JOIN book_copies bc ON bc.book_id = bk.id
+    GROUP BY bk.id
+) c
+WHERE b.id = c.book_id
+  AND (b.total_copies <> c.actual_total OR b.available_copies <> c.actual_available);
+
+-- name: GetBookByIsbns :one
+SELECT id, title
+FROM books
+WHERE upper(replace(replace(COALESCE(isbn, ''), '-', ''), ' ', '')) = ANY(@isbns::text[])
+ORDER BY created_at
+LIMIT 1;
@@ -0,0 +1,18 @@
+-- name: CreateCopyStatusHistory :exec
+INSERT INTO copy_status_history (copy_id, from_status, to_status, reason, issue_id, changed_by)
+VALUES (@copy_id, @from_status, @to_status, @reason, @issue_id, @changed_by);
+
+-- name: GetCopyStatusHistory :many
+SELECT
+    h.id,
+    h.from_status,
+    h.to_status,
+    h.reason,
+    h.issue_id,
+    h.changed_by,
+    u.username as changed_by_name,
+    h.changed_at
+FROM copy_status_history h
+LEFT JOIN users u ON h.changed_by = u.id
+WHERE h.copy_id = @copy_id
+ORDER BY h.changed_at DESC;
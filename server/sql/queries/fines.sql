@@ -32,3 +32,8 @@ ORDER BY f.fine_date;
 SELECT COALESCE(SUM(amount), 0) as total_unpaid
 FROM fines
 WHERE reader_id = @reader_id AND is_paid = false;
+
+-- name: GetFineById :one
+SELECT id, reader_id, amount, reason, fine_date, is_paid
+FROM fines
+WHERE id = @id;
@@ -0,0 +1,205 @@
+-- name: GetLatestHourlyRollup :one
+SELECT COALESCE(MAX(bucket_start), '0001-01-01'::timestamp)::timestamp as latest_bucket
+FROM hall_hourly_rollups;
+
+-- name: RefreshHallVisitSessions :execrows
+INSERT INTO hall_visit_sessions (entry_id, hall_id, reader_id, entry_time, exit_time)
+SELECT
+    ev.id,
+    ev.hall_id,
+    ev.reader_id,
+    ev.visit_time,
+    CASE
+        WHEN ev.next_type = 'exit' AND DATE(ev.next_time) = DATE(ev.visit_time) THEN ev.next_time
+    END
+FROM (
+    SELECT
+        hv.id,
+        hv.hall_id,
+        hv.reader_id,
+        hv.visit_type,
+        hv.visit_time,
+        LEAD(hv.visit_type) OVER w as next_type,
+        LEAD(hv.visit_time) OVER w as next_time
+    FROM hall_visits hv
+    WHERE hv.visit_time >= date_trunc('day', @since::timestamp)
+    WINDOW w AS (PARTITION BY hv.reader_id, hv.hall_id ORDER BY hv.visit_time, hv.id)
+) ev
+WHERE ev.visit_type = 'entry'
+ON CONFLICT (entry_id) DO UPDATE
+SET exit_time = EXCLUDED.exit_time;
+
+-- name: RefreshHallHourlyRollups :execrows
+INSERT INTO hall_hourly_rollups (
+    hall_id, bucket_start, entries, exits, unique_visitors,
+    peak_occupancy, closing_occupancy, refreshed_at
+)
+SELECT
+    ev.hall_id,
+    date_trunc('hour', ev.visit_time),
+    COUNT(*) FILTER (WHERE ev.visit_type = 'entry'),
+    COUNT(*) FILTER (WHERE ev.visit_type = 'exit'),
+    COUNT(DISTINCT ev.reader_id) FILTER (WHERE ev.visit_type = 'entry'),
+    GREATEST(MAX(ev.occupancy), 0),
+    GREATEST((ARRAY_AGG(ev.occupancy ORDER BY ev.visit_time DESC, ev.id DESC))[1], 0),
+    CURRENT_TIMESTAMP
+FROM (
+    -- Заполненность зала восстанавливается из потока входов и выходов,
+    -- считая, что к началу дня зал пуст
+    SELECT
+        hv.id,
+        hv.hall_id,
+        hv.reader_id,
+        hv.visit_type,
+        hv.visit_time,
+        SUM(CASE WHEN hv.visit_type = 'entry' THEN 1 ELSE -1 END) OVER (
+            PARTITION BY hv.hall_id, DATE(hv.visit_time)
+            ORDER BY hv.visit_time, hv.id
+            ROWS UNBOUNDED PRECEDING
+        ) as occupancy
+    FROM hall_visits hv
+    WHERE hv.visit_time >= date_trunc('day', @since::timestamp)
+) ev
+GROUP BY ev.hall_id, date_trunc('hour', ev.visit_time)
+ON CONFLICT (hall_id, bucket_start) DO UPDATE
+SET entries = EXCLUDED.entries,
+    exits = EXCLUDED.exits,
+    unique_visitors = EXCLUDED.unique_visitors,
+    peak_occupancy = EXCLUDED.peak_occupancy,
+    closing_occupancy = EXCLUDED.closing_occupancy,
+    refreshed_at = EXCLUDED.refreshed_at;
+
+-- name: RefreshHallDailyRollups :execrows
+INSERT INTO hall_daily_rollups (
+    hall_id, visit_date, entries, unique_visitors, new_visitors, returning_visitors,
+    sessions_count, avg_dwell_minutes, median_dwell_minutes, peak_occupancy, refreshed_at
+)
+SELECT
+    d.hall_id,
+    d.visit_date,
+    d.entries,
+    d.unique_visitors,
+    d.new_visitors,
+    d.unique_visitors - d.new_visitors,
+    COALESCE(s.sessions_count, 0),
+    s.avg_dwell_minutes,
+    s.median_dwell_minutes,
+    COALESCE(p.peak_occupancy, 0),
+    CURRENT_TIMESTAMP
+FROM (
+    SELECT
+        hv.hall_id,
+        DATE(hv.visit_time) as visit_date,
+        COUNT(*) as entries,
+        COUNT(DISTINCT hv.reader_id) as unique_visitors,
+        COUNT(DISTINCT hv.reader_id) FILTER (WHERE fv.first_visit = DATE(hv.visit_time)) as new_visitors
+    FROM hall_visits hv
+    JOIN (
+        SELECT hall_id, reader_id, MIN(DATE(visit_time)) as first_visit
+        FROM hall_visits
+        WHERE visit_type = 'entry'
+        GROUP BY hall_id, reader_id
+    ) fv ON fv.hall_id = hv.hall_id AND fv.reader_id = hv.reader_id
+    WHERE hv.visit_type = 'entry'
+      AND hv.visit_time >= date_trunc('day', @since::timestamp)
+    GROUP BY hv.hall_id, DATE(hv.visit_time)
+) d
+LEFT JOIN (
+    SELECT
+        hall_id,
+        DATE(entry_time) as visit_date,
+        COUNT(*) as sessions_count,
+        ROUND(AVG(EXTRACT(EPOCH FROM (exit_time - entry_time)) / 60), 1) as avg_dwell_minutes,
+        ROUND((PERCENTILE_CONT(0.5) WITHIN GROUP (
+            ORDER BY EXTRACT(EPOCH FROM (exit_time - entry_time)) / 60
+        ))::numeric, 1) as median_dwell_minutes
+    FROM hall_visit_sessions
+    WHERE exit_time IS NOT NULL
+      AND entry_time >= date_trunc('day', @since::timestamp)
+    GROUP BY hall_id, DATE(entry_time)
+) s ON s.hall_id = d.hall_id AND s.visit_date = d.visit_date
+LEFT JOIN (
+    SELECT
+        hall_id,
+        DATE(bucket_start) as visit_date,
+        MAX(peak_occupancy) as peak_occupancy
+    FROM hall_hourly_rollups
+    WHERE bucket_start >= date_trunc('day', @since::timestamp)
+    GROUP BY hall_id, DATE(bucket_start)
+) p ON p.hall_id = d.hall_id AND p.visit_date = d.visit_date
+ON CONFLICT (hall_id, visit_date) DO UPDATE
+SET entries = EXCLUDED.entries,
+    unique_visitors = EXCLUDED.unique_visitors,
+    new_visitors = EXCLUDED.new_visitors,
+    returning_visitors = EXCLUDED.returning_visitors,
+    sessions_count = EXCLUDED.sessions_count,
+    avg_dwell_minutes = EXCLUDED.avg_dwell_minutes,
+    median_dwell_minutes = EXCLUDED.median_dwell_minutes,
+    peak_occupancy = EXCLUDED.peak_occupancy,
+    refreshed_at = EXCLUDED.refreshed_at;
+
+-- name: GetHallDwellStats :one
+SELECT
+    COUNT(*) as sessions_count,
+    COALESCE(ROUND(AVG(EXTRACT(EPOCH FROM (exit_time - entry_time)) / 60), 1), 0)::numeric as avg_dwell_minutes,
+    COALESCE(ROUND((PERCENTILE_CONT(0.5) WITHIN GROUP (
+        ORDER BY EXTRACT(EPOCH FROM (exit_time - entry_time)) / 60
+    ))::numeric, 1), 0)::numeric as median_dwell_minutes,
+    COALESCE(ROUND((PERCENTILE_CONT(0.9) WITHIN GROUP (
+        ORDER BY EXTRACT(EPOCH FROM (exit_time - entry_time)) / 60
+    ))::numeric, 1), 0)::numeric as p90_dwell_minutes
+FROM hall_visit_sessions
+WHERE hall_id = @hall_id
+  AND exit_time IS NOT NULL
+  AND entry_time >= @start_date::date
+  AND entry_time < @end_date::date + 1;
+
+-- name: GetHallVisitHeatmap :many
+SELECT
+    EXTRACT(ISODOW FROM bucket_start)::int as weekday,
+    EXTRACT(HOUR FROM bucket_start)::int as hour,
+    SUM(entries)::int as total_entries,
+    SUM(peak_occupancy)::int as total_peak_occupancy
+FROM hall_hourly_rollups
+WHERE hall_id = @hall_id
+  AND bucket_start >= @start_date::date
+  AND bucket_start < @end_date::date + 1
+GROUP BY weekday, hour
+ORDER BY weekday, hour;
+
+-- name: GetHallOccupancySeries :many
+SELECT bucket_start, entries, exits, peak_occupancy, closing_occupancy
+FROM hall_hourly_rollups
+WHERE hall_id = @hall_id
+  AND bucket_start >= @start_date::date
+  AND bucket_start < @end_date::date + 1
+ORDER BY bucket_start;
+
+-- name: GetHallVisitorSplit :many
+SELECT visit_date, unique_visitors, new_visitors, returning_visitors
+FROM hall_daily_rollups
+WHERE hall_id = @hall_id
+  AND visit_date >= @start_date::date
+  AND visit_date <= @end_date::date
+ORDER BY visit_date;
+
+-- name: CompareHallsVisits :many
+SELECT
+    rh.id,
+    rh.hall_name,
+    rh.total_seats,
+    COALESCE(SUM(dr.entries), 0)::int as total_entries,
+    COALESCE(SUM(dr.new_visitors), 0)::int as new_visitors,
+    COALESCE(SUM(dr.returning_visitors), 0)::int as returning_visitors,
+    COALESCE(MAX(dr.peak_occupancy), 0)::int as peak_occupancy,
+    COALESCE(ROUND(
+        SUM(dr.avg_dwell_minutes * dr.sessions_count) / NULLIF(SUM(dr.sessions_count), 0), 1
+    ), 0)::numeric as avg_dwell_minutes,
+    ROUND(COALESCE(MAX(dr.peak_occupancy), 0)::numeric / rh.total_seats * 100, 2) as peak_utilization_percentage
+FROM reading_halls rh
+LEFT JOIN hall_daily_rollups dr
+    ON dr.hall_id = rh.id
+   AND dr.visit_date >= @start_date::date
+   AND dr.visit_date <= @end_date::date
+GROUP BY rh.id, rh.hall_name, rh.total_seats
+ORDER BY total_entries DESC, rh.hall_name;
@@ -0,0 +1,41 @@
+-- name: CreateHallSeat :one
+INSERT INTO hall_seats (hall_id, seat_number)
+VALUES (@hall_id, @seat_number)
+RETURNING id, hall_id, seat_number, is_active;
+
+-- name: GetHallSeats :many
+SELECT id, hall_id, seat_number, is_active
+FROM hall_seats
+WHERE hall_id = @hall_id
+ORDER BY seat_number;
+
+-- name: GetHallSeatByNumber :one
+SELECT id, hall_id, seat_number, is_active
+FROM hall_seats
+WHERE hall_id = @hall_id AND seat_number = @seat_number;
+
+-- name: SetHallSeatActive :one
+UPDATE hall_seats
+SET is_active = @is_active
+WHERE id = @id AND hall_id = @hall_id
+RETURNING id, hall_id, seat_number, is_active;
+
+-- name: CountActiveHallSeats :one
+SELECT COUNT(*) as seats_count
+FROM hall_seats
+WHERE hall_id = @hall_id AND is_active = true;
+
+-- name: GetFreeHallSeat :one
+SELECT hs.id, hs.hall_id, hs.seat_number, hs.is_active
+FROM hall_seats hs
+WHERE hs.hall_id = @hall_id
+  AND hs.is_active = true
+  AND NOT EXISTS (
+      SELECT 1
+      FROM seat_bookings sb
+      WHERE sb.seat_id = hs.id
+        AND sb.status IN ('booked', 'checked_in')
+        AND tsrange(sb.start_time, sb.end_time) && tsrange(@start_time::timestamp, @end_time::timestamp)
+  )
+ORDER BY hs.seat_number
+LIMIT 1;
@@ -1,7 +1,7 @@
 -- name: RegisterHallEntry :one
 INSERT INTO hall_visits (reader_id, hall_id, visit_type, librarian_id)
 VALUES (
-    (SELECT id FROM readers WHERE ticket_number = @ticket_number),
+    (SELECT id FROM readers WHERE ticket_number = @ticket_number AND is_active = true),
     @hall_id,
     'entry',
     @librarian_id
@@ -11,7 +11,7 @@ RETURNING id, visit_time;
 -- name: RegisterHallExit :one
 INSERT INTO hall_visits (reader_id, hall_id, visit_type, librarian_id)
 VALUES (
-    (SELECT id FROM readers WHERE ticket_number = @ticket_number),
+    (SELECT id FROM readers WHERE ticket_number = @ticket_number AND is_active = true),
     @hall_id,
     'exit',
     @librarian_id
@@ -0,0 +1,60 @@
+-- name: GetIllItem :one
+SELECT bc.id, bc.copy_code, bc.status, bc.owner_library, bc.external_item_id,
+       b.title, b.isbn, b.publication_year, b.publisher,
+       COALESCE((
+           SELECT string_agg(a.full_name, '; ' ORDER BY a.full_name)
+           FROM book_authors ba
+           JOIN authors a ON ba.author_id = a.id
+           WHERE ba.book_id = b.id AND ba.role = 'author'
+       ), '')::text as authors,
+       rh.hall_name, bi.due_date
+FROM book_copies bc
+JOIN books b ON bc.book_id = b.id
+LEFT JOIN reading_halls rh ON bc.hall_id = rh.id
+LEFT JOIN book_issues bi ON bi.book_copy_id = bc.id AND bi.return_date IS NULL
+WHERE bc.copy_code = @copy_code;
+
+-- name: GetIllCopyByExternalId :one
+SELECT bc.copy_code
+FROM book_copies bc
+WHERE bc.owner_library = @owner_library AND bc.external_item_id = @external_item_id;
+
+-- name: GetAvailableCopyByIsbn :one
+SELECT bc.copy_code
+FROM book_copies bc
+JOIN books b ON bc.book_id = b.id
+WHERE b.isbn = @isbn AND bc.status = 'available' AND bc.owner_library IS NULL
+ORDER BY bc.copy_code
+LIMIT 1;
+
+-- name: GetBookIdByIsbn :one
+SELECT id
+FROM books
+WHERE isbn = @isbn
+ORDER BY created_at
+LIMIT 1;
+
+-- name: CreateIllCopy :one
+INSERT INTO book_copies (book_id, copy_code, location_info, owner_library, external_item_id)
+VALUES (@book_id, @copy_code, @location_info, @owner_library, @external_item_id)
+RETURNING copy_code;
+
+-- name: CreateIllRequest :one
+INSERT INTO ill_requests (source_library, external_request_id, external_user_id, book_copy_id)
+VALUES (@source_library, @external_request_id, @external_user_id, @book_copy_id)
+RETURNING id, source_library, external_request_id, external_user_id, book_copy_id, status, created_at, updated_at;
+
+-- name: GetIllRequest :one
+SELECT ir.id, ir.source_library, ir.external_request_id, ir.external_user_id, ir.book_copy_id, ir.status, ir.created_at, ir.updated_at
+FROM ill_requests ir
+WHERE ir.source_library = @source_library AND ir.external_request_id = @external_request_id;
+
+-- name: GetActiveIllRequestForCopy :one
+SELECT ir.id, ir.source_library, ir.external_request_id, ir.external_user_id, ir.book_copy_id, ir.status, ir.created_at, ir.updated_at
+FROM ill_requests ir
+WHERE ir.book_copy_id = @book_copy_id AND ir.status = 'active';
+
+-- name: SetIllRequestStatus :exec
+UPDATE ill_requests
+SET status = @status, updated_at = CURRENT_TIMESTAMP
+WHERE id = @id;
@@ -0,0 +1,155 @@
+-- name: CreateInventorySession :one
+INSERT INTO inventory_sessions (hall_id, note, started_by)
+VALUES (@hall_id, @note, @started_by)
+RETURNING id, hall_id, status, note, started_by, started_at, closed_by, closed_at;
+
+-- name: GetInventorySessions :many
+SELECT s.id, s.hall_id, rh.hall_name, s.status, s.note, s.started_at, s.closed_at,
+       u.username as started_by_name,
+       (SELECT COUNT(*) FROM inventory_scans sc WHERE sc.session_id = s.id) as scanned_count
+FROM inventory_sessions s
+JOIN reading_halls rh ON rh.id = s.hall_id
+LEFT JOIN users u ON u.id = s.started_by
+WHERE (@hall_id::text = '' OR s.hall_id::text = @hall_id::text)
+  AND (@status::text = '' OR s.status::text = @status::text)
+ORDER BY s.started_at DESC;
+
+-- name: GetInventorySessionById :one
+SELECT s.id, s.hall_id, rh.hall_name, s.status, s.note, s.started_at, s.closed_at,
+       su.username as started_by_name, cu.username as closed_by_name,
+    -- экземпляры, которые должны стоять на полках зала
+    (SELECT COUNT(*) FROM book_copies bc
+     WHERE bc.hall_id = s.hall_id
+       AND COALESCE(bc.status, 'available') IN ('available', 'reserved', 'damaged')) as expected_count,
+    (SELECT COUNT(*) FROM inventory_scans sc WHERE sc.session_id = s.id) as scanned_count,
+    (SELECT COUNT(*) FROM inventory_scans sc
+     JOIN book_copies bc ON bc.id = sc.copy_id
+     WHERE sc.session_id = s.id AND bc.hall_id = s.hall_id) as found_count,
+    (SELECT COUNT(*) FROM inventory_missing m WHERE m.session_id = s.id) as missing_count
+FROM inventory_sessions s
+JOIN reading_halls rh ON rh.id = s.hall_id
+LEFT JOIN users su ON su.id = s.started_by
+LEFT JOIN users cu ON cu.id = s.closed_by
+WHERE s.id = @id;
+
+-- name: LockInventorySession :one
+SELECT id, hall_id, status, note, started_by, started_at, closed_by, closed_at
+FROM inventory_sessions
+WHERE id = @id
+FOR UPDATE;
+
+-- name: ShareLockInventorySession :one
+SELECT id, hall_id, status, note, started_by, started_at, closed_by, closed_at
+FROM inventory_sessions
+WHERE id = @id
+FOR SHARE;
+
+-- name: RecordInventoryScans :many
+WITH input AS (
+    SELECT DISTINCT ON (t.code) t.code, t.ord
+    FROM unnest(@copy_codes::text[]) WITH ORDINALITY as t(code, ord)
+    ORDER BY t.code, t.ord
+), inserted AS (
+    INSERT INTO inventory_scans (session_id, copy_code, copy_id, scanned_by)
+    SELECT @session_id, i.code, bc.id, @scanned_by
+    FROM input i
+    LEFT JOIN book_copies bc ON bc.copy_code = i.code
+    ON CONFLICT (session_id, copy_code) DO NOTHING
+    RETURNING copy_code
+)
+SELECT i.code as copy_code, bc.id as copy_id, b.title, bc.hall_id, rh.hall_name, bc.status,
+       EXISTS (
+           SELECT 1 FROM book_issues bi
+           WHERE bi.book_copy_id = bc.id AND bi.return_date IS NULL
+       ) as on_loan,
+       (ins.copy_code IS NULL) as already_scanned
+FROM input i
+LEFT JOIN inserted ins ON ins.copy_code = i.code
+LEFT JOIN book_copies bc ON bc.copy_code = i.code
+LEFT JOIN books b ON b.id = bc.book_id
+LEFT JOIN reading_halls rh ON rh.id = bc.hall_id
+ORDER BY i.ord;
+
+-- name: SnapshotInventoryMissing :execrows
+INSERT INTO inventory_missing (session_id, copy_id, status)
+SELECT s.id, bc.id, COALESCE(bc.status, 'available')
+FROM inventory_sessions s
+JOIN book_copies bc ON bc.hall_id = s.hall_id
+WHERE s.id = @session_id
+  AND COALESCE(bc.status, 'available') IN ('available', 'reserved', 'damaged')
+  AND NOT EXISTS (
+      SELECT 1 FROM inventory_scans sc
+      WHERE sc.session_id = s.id AND sc.copy_id = bc.id
+  );
+
+-- name: FinishInventorySession :one
+UPDATE inventory_sessions
+SET status = @status, closed_by = @closed_by, closed_at = CURRENT_TIMESTAMP
+WHERE id = @id AND status = 'open'
+RETURNING id, hall_id, status, note, started_by, started_at, closed_by, closed_at;
+
+-- name: GetInventoryMissing :many
+SELECT bc.id as copy_id, bc.copy_code, b.title, bc.location_info,
+       m.status as status_at_close, bc.status as current_status, m.marked_lost_at
+FROM inventory_missing m
+JOIN book_copies bc ON bc.id = m.copy_id
+JOIN books b ON b.id = bc.book_id
+WHERE m.session_id = @session_id
+ORDER BY b.title, bc.copy_code;
+
+-- name: GetInventoryUnscanned :many
+SELECT bc.id as copy_id, bc.copy_code, b.title, bc.location_info, bc.status
+FROM inventory_sessions s
+JOIN book_copies bc ON bc.hall_id = s.hall_id
+JOIN books b ON b.id = bc.book_id
+WHERE s.id = @session_id
+  AND COALESCE(bc.status, 'available') IN ('available', 'reserved', 'damaged')
+  AND NOT EXISTS (
+      SELECT 1 FROM inventory_scans sc
+      WHERE sc.session_id = s.id AND sc.copy_id = bc.id
+  )
+ORDER BY b.title, bc.copy_code;
+
+-- name: GetInventoryUnexpected :many
+SELECT sc.copy_code, bc.id as copy_id, b.title, bc.hall_id, rh.hall_name, bc.status,
+       EXISTS (
+           SELECT 1 FROM book_issues bi
+           WHERE bi.book_copy_id = bc.id AND bi.return_date IS NULL
+       ) as on_loan,
+       sc.scanned_at
+FROM inventory_scans sc
+JOIN inventory_sessions s ON s.id = sc.session_id
+LEFT JOIN book_copies bc ON bc.id = sc.copy_id
+LEFT JOIN books b ON b.id = bc.book_id
+LEFT JOIN reading_halls rh ON rh.id = bc.hall_id
+WHERE sc.session_id = @session_id
+  AND (bc.id IS NULL
+       OR bc.hall_id IS DISTINCT FROM s.hall_id
+       OR COALESCE(bc.status, 'available') NOT IN ('available', 'reserved', 'damaged'))
+ORDER BY sc.scanned_at, sc.copy_code;
+
+-- name: GetInventoryLoanMismatches :many
+SELECT bc.id as copy_id, bc.copy_code, b.title, bc.status,
+       bi.id as issue_id, r.ticket_number, bi.due_date
+FROM inventory_sessions s
+JOIN book_copies bc ON bc.hall_id = s.hall_id
+JOIN books b ON b.id = bc.book_id
+LEFT JOIN book_issues bi ON bi.book_copy_id = bc.id AND bi.return_date IS NULL
+LEFT JOIN readers r ON r.id = bi.reader_id
+WHERE s.id = @session_id
+  -- статус «выдан» без открытой выдачи или открытая выдача при другом статусе
+  AND (COALESCE(bc.status, 'available') = 'issued') <> (bi.id IS NOT NULL)
+ORDER BY b.title, bc.copy_code;
+
+-- name: GetUnmarkedInventoryMissing :many
+SELECT copy_id
+FROM inventory_missing
+WHERE session_id = @session_id
+  AND marked_lost_at IS NULL
+  AND (cardinality(@copy_ids::uuid[]) = 0 OR copy_id = ANY(@copy_ids::uuid[]))
+ORDER BY copy_id;
+
+-- name: MarkInventoryMissingLost :exec
+UPDATE inventory_missing
+SET marked_lost_at = CURRENT_TIMESTAMP
+WHERE session_id = @session_id AND copy_id = @copy_id;
@@ -0,0 +1,15 @@
+-- name: UpsertMarcRecord :exec
+INSERT INTO marc_records (isbn, title, authors, publisher, publication_year, source)
+VALUES (@isbn, @title, @authors, @publisher, @publication_year, @source)
+ON CONFLICT (isbn) DO UPDATE
+SET title = EXCLUDED.title,
+    authors = EXCLUDED.authors,
+    publisher = EXCLUDED.publisher,
+    publication_year = EXCLUDED.publication_year,
+    source = EXCLUDED.source,
+    loaded_at = CURRENT_TIMESTAMP;
+
+-- name: GetMarcRecordByIsbn :one
+SELECT isbn, title, authors, publisher, publication_year, source, loaded_at
+FROM marc_records
+WHERE isbn = @isbn;
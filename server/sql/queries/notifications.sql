@@ -0,0 +1,183 @@
+-- name: EnqueueDueSoonNotifications :execrows
+INSERT INTO notifications (reader_id, channel, kind, issue_id, recipient, language, payload, dedupe_key)
+SELECT r.id, ch.channel, 'due_soon', bi.id,
+       CASE WHEN ch.channel = 'sms' THEN r.phone ELSE r.email END, r.language,
+       jsonb_build_object(
+           'reader_name', r.full_name,
+           'title', b.title,
+           'copy_code', bc.copy_code,
+           'due_date', bi.due_date,
+           'days_left', bi.due_date - CURRENT_DATE
+       ),
+       'due_soon:' || bi.id || ':' || bi.due_date
+FROM book_issues bi
+JOIN readers r ON bi.reader_id = r.id
+JOIN book_copies bc ON bi.book_copy_id = bc.id
+JOIN books b ON bc.book_id = b.id
+CROSS JOIN LATERAL (
+    SELECT CASE
+        WHEN r.preferred_channel = 'sms' AND r.phone IS NOT NULL THEN 'sms'
+        WHEN r.email IS NOT NULL THEN 'email'
+        WHEN r.phone IS NOT NULL THEN 'sms'
+    END::notification_channel AS channel
+) ch
+WHERE bi.return_date IS NULL
+  AND bi.due_date BETWEEN CURRENT_DATE AND CURRENT_DATE + @days::int
+  AND ch.channel::text = ANY(@channels::text[])
+  AND r.is_active = true
+  AND r.anonymized_at IS NULL
+  AND NOT EXISTS (
+      SELECT 1 FROM notification_opt_outs o
+      WHERE o.reader_id = r.id AND o.kind = 'due_soon'
+  )
+ON CONFLICT (dedupe_key) DO NOTHING;
+
+-- name: EnqueueOverdueNotifications :execrows
+INSERT INTO notifications (reader_id, channel, kind, issue_id, recipient, language, payload, dedupe_key)
+SELECT r.id, ch.channel, 'overdue', bi.id,
+       CASE WHEN ch.channel = 'sms' THEN r.phone ELSE r.email END, r.language,
+       jsonb_build_object(
+           'reader_name', r.full_name,
+           'title', b.title,
+           'copy_code', bc.copy_code,
+           'due_date', bi.due_date,
+           'days_overdue', CURRENT_DATE - bi.due_date
+       ),
+       'overdue:' || bi.id || ':' || (CURRENT_DATE - bi.due_date - 1) / @repeat_days::int
+FROM book_issues bi
+JOIN readers r ON bi.reader_id = r.id
+JOIN book_copies bc ON bi.book_copy_id = bc.id
+JOIN books b ON bc.book_id = b.id
+CROSS JOIN LATERAL (
+    SELECT CASE
+        WHEN r.preferred_channel = 'sms' AND r.phone IS NOT NULL THEN 'sms'
+        WHEN r.email IS NOT NULL THEN 'email'
+        WHEN r.phone IS NOT NULL THEN 'sms'
+    END::notification_channel AS channel
+) ch
+WHERE bi.return_date IS NULL
+  AND bi.due_date < CURRENT_DATE
+  AND ch.channel::text = ANY(@channels::text[])
+  AND r.anonymized_at IS NULL
+  AND NOT EXISTS (
+      SELECT 1 FROM notification_opt_outs o
+      WHERE o.reader_id = r.id AND o.kind = 'overdue'
+  )
+ON CONFLICT (dedupe_key) DO NOTHING;
+
+-- name: CreateHoldAvailableNotification :one
+INSERT INTO notifications (reader_id, channel, kind, recipient, language, payload, dedupe_key)
+SELECT r.id, ch.channel, 'hold_available',
+       CASE WHEN ch.channel = 'sms' THEN r.phone ELSE r.email END, r.language,
+       jsonb_build_object(
+           'reader_name', r.full_name,
+           'title', b.title,
+           'copy_code', bc.copy_code,
+           'hall_name', rh.hall_name,
+           'pickup_until', @pickup_until::date
+       ),
+       'hold_available:' || r.id || ':' || bc.id || ':' || @pickup_until::date
+FROM readers r
+CROSS JOIN book_copies bc
+JOIN books b ON bc.book_id = b.id
+LEFT JOIN reading_halls rh ON bc.hall_id = rh.id
+CROSS JOIN LATERAL (
+    SELECT CASE
+        WHEN r.preferred_channel = 'sms' AND r.phone IS NOT NULL THEN 'sms'
+        WHEN r.email IS NOT NULL THEN 'email'
+        WHEN r.phone IS NOT NULL THEN 'sms'
+    END::notification_channel AS channel
+) ch
+WHERE r.id = @reader_id
+  AND bc.id = @copy_id
+  AND ch.channel::text = ANY(@channels::text[])
+  AND r.anonymized_at IS NULL
+  AND NOT EXISTS (
+      SELECT 1 FROM notification_opt_outs o
+      WHERE o.reader_id = r.id AND o.kind = 'hold_available'
+  )
+ON CONFLICT (dedupe_key) DO NOTHING
+RETURNING id, reader_id, channel, kind, issue_id, recipient, language, payload, dedupe_key,
+          status, attempts, last_error, next_attempt_at, sent_at, created_at;
+
+-- name: CancelStaleNotifications :execrows
+UPDATE notifications n
+SET status = 'cancelled'
+WHERE n.status = 'pending'
+  AND (
+      EXISTS (
+          SELECT 1 FROM book_issues bi
+          WHERE bi.id = n.issue_id AND bi.return_date IS NOT NULL
+      )
+      -- после продления или переноса срока напоминание о прежней дате уже неверно
+      OR (n.kind = 'due_soon' AND EXISTS (
+          SELECT 1 FROM book_issues bi
+          WHERE bi.id = n.issue_id AND bi.due_date <> (n.payload->>'due_date')::date
+      ))
+      OR (n.kind = 'overdue' AND EXISTS (
+          SELECT 1 FROM book_issues bi
+          WHERE bi.id = n.issue_id AND bi.due_date >= CURRENT_DATE
+      ))
+      OR EXISTS (
+          SELECT 1 FROM notification_opt_outs o
+          WHERE o.reader_id = n.reader_id AND o.kind = n.kind
+      )
+  );
+
+-- name: ClaimPendingNotifications :many
+UPDATE notifications
+SET next_attempt_at = CURRENT_TIMESTAMP + make_interval(secs => @lease_seconds::int)
+WHERE id IN (
+    SELECT p.id FROM notifications p
+    WHERE p.status = 'pending'
+      AND p.channel = @channel
+      AND p.next_attempt_at <= CURRENT_TIMESTAMP
+    ORDER BY p.next_attempt_at
+    LIMIT @limit_count
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, reader_id, channel, kind, issue_id, recipient, language, payload, dedupe_key,
+          status, attempts, last_error, next_attempt_at, sent_at, created_at;
+
+-- name: MarkNotificationSent :exec
+UPDATE notifications
+SET status = 'sent',
+    attempts = attempts + 1,
+    last_error = NULL,
+    sent_at = CURRENT_TIMESTAMP
+WHERE id = @id;
+
+-- name: MarkNotificationFailed :exec
+UPDATE notifications
+SET attempts = attempts + 1,
+    last_error = @last_error,
+    status = CASE WHEN attempts + 1 >= @max_attempts::int THEN 'failed' ELSE 'pending' END::notification_status,
+    next_attempt_at = CURRENT_TIMESTAMP + make_interval(secs => @retry_seconds::int)
+WHERE id = @id;
+
+-- name: GetReaderNotifications :many
+SELECT id, reader_id, channel, kind, issue_id, recipient, language, payload, dedupe_key,
+       status, attempts, last_error, next_attempt_at, sent_at, created_at
+FROM notifications
+WHERE reader_id = @reader_id
+ORDER BY created_at DESC
+LIMIT @limit_count;
+
+-- name: DeleteReaderNotifications :exec
+DELETE FROM notifications
+WHERE reader_id = @reader_id;
+
+-- name: GetReaderNotificationOptOuts :many
+SELECT kind
+FROM notification_opt_outs
+WHERE reader_id = @reader_id
+ORDER BY kind;
+
+-- name: DeleteReaderNotificationOptOuts :exec
+DELETE FROM notification_opt_outs
+WHERE reader_id = @reader_id;
+
+-- name: CreateNotificationOptOut :exec
+INSERT INTO notification_opt_outs (reader_id, kind)
+VALUES (@reader_id, @kind)
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,72 @@
+-- name: ListOaiRecords :many
+SELECT r.id, r.title, r.isbn, r.publication_year, r.publisher, r.authors, r.hall_ids, r.deleted, r.updated_at
+FROM (
+    -- Книга, все экземпляры которой списаны или утеряны, отдается как удаленная,
+    -- ее наборы — залы всех экземпляров; удаленные книги берутся из следов
+    SELECT
+        b.id,
+        b.title,
+        b.isbn,
+        b.publication_year,
+        b.publisher,
+        ARRAY(
+            SELECT a.full_name FROM book_authors ba
+            JOIN authors a ON a.id = ba.author_id
+            WHERE ba.book_id = b.id AND ba.role = 'author'
+            ORDER BY a.full_name
+        )::text[] as authors,
+        ARRAY(
+            SELECT DISTINCT bc.hall_id::text FROM book_copies bc
+            WHERE bc.book_id = b.id AND bc.hall_id IS NOT NULL
+              AND (b.total_copies = 0 OR COALESCE(bc.status, 'available') NOT IN ('withdrawn', 'lost'))
+        )::text[] as hall_ids,
+        b.total_copies = 0 as deleted,
+        b.updated_at
+    FROM books b
+    WHERE EXISTS (SELECT 1 FROM book_copies bc WHERE bc.book_id = b.id)
+    UNION ALL
+    SELECT t.book_id, '', NULL, NULL, NULL, '{}'::text[], t.hall_ids::text[], TRUE, t.deleted_at
+    FROM book_tombstones t
+) r
+WHERE (r.updated_at, r.id) > (@after_time::timestamp, @after_id::uuid)
+  AND r.updated_at <= @until_time::timestamp
+  AND (@hall_id::text = '' OR @hall_id = ANY(r.hall_ids))
+ORDER BY r.updated_at, r.id
+LIMIT @limit_count;
+
+-- name: GetOaiRecord :one
+SELECT r.id, r.title, r.isbn, r.publication_year, r.publisher, r.authors, r.hall_ids, r.deleted, r.updated_at
+FROM (
+    SELECT
+        b.id,
+        b.title,
+        b.isbn,
+        b.publication_year,
+        b.publisher,
+        ARRAY(
+            SELECT a.full_name FROM book_authors ba
+            JOIN authors a ON a.id = ba.author_id
+            WHERE ba.book_id = b.id AND ba.role = 'author'
+            ORDER BY a.full_name
+        )::text[] as authors,
+        ARRAY(
+            SELECT DISTINCT bc.hall_id::text FROM book_copies bc
+            WHERE bc.book_id = b.id AND bc.hall_id IS NOT NULL
+              AND (b.total_copies = 0 OR COALESCE(bc.status, 'available') NOT IN ('withdrawn', 'lost'))
+        )::text[] as hall_ids,
+        b.total_copies = 0 as deleted,
+        b.updated_at
+    FROM books b
+    WHERE b.id = @id AND EXISTS (SELECT 1 FROM book_copies bc WHERE bc.book_id = b.id)
+    UNION ALL
+    SELECT t.book_id, '', NULL, NULL, NULL, '{}'::text[], t.hall_ids::text[], TRUE, t.deleted_at
+    FROM book_tombstones t
+    WHERE t.book_id = @id
+) r
+LIMIT 1;
+
+-- name: GetOaiEarliestDatestamp :one
+SELECT COALESCE(LEAST(
+    (SELECT MIN(b.updated_at) FROM books b WHERE EXISTS (SELECT 1 FROM book_copies bc WHERE bc.book_id = b.id)),
+    (SELECT MIN(deleted_at) FROM book_tombstones)
+), CURRENT_TIMESTAMP)::timestamp as earliest;
@@ -0,0 +1,184 @@
+-- name: SearchPublicBooks :many
+SELECT
+    b.id,
+    b.title,
+    b.isbn,
+    b.publication_year,
+    b.publisher,
+    b.available_copies,
+    b.total_copies,
+    COALESCE((
+        SELECT STRING_AGG(a.full_name, ', ' ORDER BY a.full_name)
+        FROM book_authors ba
+        JOIN authors a ON ba.author_id = a.id
+        WHERE ba.book_id = b.id AND ba.role = 'author'
+    ), '')::text as authors,
+    b.work_id,
+    b.series_id,
+    sr.title as series_title,
+    b.series_volume,
+    b.language,
+    COUNT(*) OVER() as total_count
+FROM books b
+LEFT JOIN series sr ON b.series_id = sr.id
+WHERE b.total_copies > 0
+  AND (@query::text = '' OR b.title ILIKE '%' || @query::text || '%' OR b.isbn = @query::text)
+  AND (@author::text = '' OR EXISTS (
+      SELECT 1
+      FROM book_authors ba
+      JOIN authors a ON ba.author_id = a.id
+      WHERE ba.book_id = b.id AND a.full_name ILIKE '%' || @author::text || '%'
+  ))
+  AND (@publication_year::int = 0 OR b.publication_year = @publication_year::int)
+  AND (NOT @available_only::boolean OR b.available_copies > 0)
+  AND (@subject_id::text = '' OR b.id IN (
+      WITH RECURSIVE subtree AS (
+          SELECT s.id FROM subjects s WHERE s.id::text = @subject_id::text
+          UNION ALL
+          SELECT s.id FROM subjects s JOIN subtree t ON s.parent_id = t.id
+      )
+      SELECT bs.book_id FROM book_subjects bs JOIN subtree t ON t.id = bs.subject_id
+  ))
+  AND (@language::text = '' OR b.language = @language::text)
+  AND (@series_id::text = '' OR b.series_id::text = @series_id::text)
+ORDER BY b.title, b.series_volume, b.id
+LIMIT @limit_count OFFSET @offset_count;
+
+-- name: GetPublicBook :one
+SELECT
+    b.id,
+    b.title,
+    b.isbn,
+    b.publication_year,
+    b.publisher,
+    b.available_copies,
+    b.total_copies,
+    COALESCE((
+        SELECT STRING_AGG(a.full_name, ', ' ORDER BY a.full_name)
+        FROM book_authors ba
+        JOIN authors a ON ba.author_id = a.id
+        WHERE ba.book_id = b.id AND ba.role = 'author'
+    ), '')::text as authors,
+    b.work_id,
+    w.title as work_title,
+    b.series_id,
+    sr.title as series_title,
+    b.series_volume,
+    b.language,
+    b.original_language,
+    b.page_count,
+    b.description
+FROM books b
+LEFT JOIN works w ON b.work_id = w.id
+LEFT JOIN series sr ON b.series_id = sr.id
+WHERE b.id = @id AND b.total_copies > 0;
+
+-- name: GetPublicWorkEditions :many
+SELECT
+    b.id,
+    b.title,
+    b.publication_year,
+    b.publisher,
+    b.language,
+    b.available_copies
+FROM books b
+WHERE b.work_id = @work_id AND b.id <> @book_id AND b.total_copies > 0
+ORDER BY b.publication_year NULLS LAST, b.language, b.title;
+
+-- name: GetPublicBookAvailability :many
+SELECT
+    rh.hall_name,
+    rh.hall_code,
+    COUNT(*)::int as total_copies,
+    COUNT(*) FILTER (WHERE COALESCE(bc.status, 'available') = 'available')::int as available_copies,
+    COALESCE(STRING_AGG(DISTINCT bc.location_info, ', '), '')::text as locations
+FROM book_copies bc
+LEFT JOIN reading_halls rh ON bc.hall_id = rh.id
+WHERE bc.book_id = @book_id
+  AND COALESCE(bc.status, 'available') NOT IN ('withdrawn', 'lost')
+GROUP BY rh.id, rh.hall_name, rh.hall_code
+ORDER BY rh.hall_name NULLS LAST;
+
+-- name: SearchPublicAuthors :many
+SELECT
+    a.id,
+    a.full_name,
+    COUNT(DISTINCT b.id)::int as book_count,
+    COUNT(*) OVER() as total_count
+FROM authors a
+JOIN book_authors ba ON ba.author_id = a.id
+JOIN books b ON ba.book_id = b.id AND b.total_copies > 0
+WHERE @query::text = ''
+   OR a.full_name ILIKE '%' || @query::text || '%'
+   OR EXISTS (
+       SELECT 1 FROM author_name_variants v
+       WHERE v.author_id = a.id AND v.name ILIKE '%' || @query::text || '%'
+   )
+GROUP BY a.id, a.full_name
+ORDER BY COALESCE(a.sort_name, a.full_name), a.id
+LIMIT @limit_count OFFSET @offset_count;
+
+-- name: GetPublicAuthorBooks :many
+SELECT
+    b.id,
+    b.title,
+    b.isbn,
+    b.publication_year,
+    b.publisher,
+    b.available_copies,
+    b.total_copies
+FROM books b
+WHERE b.total_copies > 0
+  AND EXISTS (SELECT 1 FROM book_authors ba WHERE ba.book_id = b.id AND ba.author_id = @author_id)
+ORDER BY b.publication_year DESC NULLS LAST, b.title;
+
+-- name: SearchSruBooks :many
+SELECT
+    b.id,
+    b.title,
+    b.isbn,
+    b.publication_year,
+    b.publisher,
+    ARRAY(
+        SELECT a.full_name
+        FROM book_authors ba
+        JOIN authors a ON ba.author_id = a.id
+        WHERE ba.book_id = b.id AND ba.role = 'author'
+        ORDER BY a.full_name
+    )::text[] as authors,
+    COUNT(*) OVER() as total_count
+FROM books b
+WHERE b.total_copies > 0
+  AND (@title::text = ''
+      OR to_tsvector('russian', b.title) @@ plainto_tsquery('russian', @title::text)
+      OR b.title ILIKE '%' || @title::text || '%')
+  AND (@title_exact::text = '' OR lower(b.title) = lower(@title_exact::text))
+  AND NOT EXISTS (
+      SELECT 1
+      FROM unnest(@authors::text[]) AS author(name)
+      WHERE NOT EXISTS (
+          SELECT 1
+          FROM book_authors ba
+          JOIN authors a ON ba.author_id = a.id
+          WHERE ba.book_id = b.id AND a.full_name ILIKE '%' || author.name || '%'
+      )
+  )
+  AND upper(replace(COALESCE(b.isbn, ''), '-', '')) = ALL(@isbns::text[])
+  AND NOT EXISTS (
+      SELECT 1
+      FROM unnest(@terms::text[]) AS term(value)
+      WHERE NOT (
+          b.title ILIKE '%' || term.value || '%'
+          OR upper(replace(COALESCE(b.isbn, ''), '-', '')) = upper(term.value)
+          OR EXISTS (
+              SELECT 1
+              FROM book_authors ba
+              JOIN authors a ON ba.author_id = a.id
+              WHERE ba.book_id = b.id AND a.full_name ILIKE '%' || term.value || '%'
+          )
+      )
+  )
+  AND (@year_from::int = 0 OR b.publication_year >= @year_from::int)
+  AND (@year_to::int = 0 OR b.publication_year <= @year_to::int)
+ORDER BY b.title, b.id
+LIMIT @limit_count OFFSET @offset_count;
@@ -0,0 +1,72 @@
+-- name: LockReader :one
+SELECT id, ticket_number, full_name, is_active, anonymized_at
+FROM readers
+WHERE id = @id
+FOR UPDATE;
+
+-- name: MoveReaderBookIssues :execrows
+UPDATE book_issues
+SET reader_id = @target_id
+WHERE reader_id = @source_id;
+
+-- name: MoveReaderFines :execrows
+UPDATE fines
+SET reader_id = @target_id
+WHERE reader_id = @source_id;
+
+-- name: MoveReaderHallVisits :execrows
+UPDATE hall_visits
+SET reader_id = @target_id
+WHERE reader_id = @source_id;
+
+-- name: MoveReaderVisitSessions :exec
+UPDATE hall_visit_sessions
+SET reader_id = @target_id
+WHERE reader_id = @source_id;
+
+-- name: MoveReaderSeatBookings :exec
+UPDATE seat_bookings
+SET reader_id = @target_id
+WHERE reader_id = @source_id;
+
+-- name: MoveReaderNotificationOptOuts :exec
+WITH moved AS (
+    DELETE FROM notification_opt_outs
+    WHERE reader_id = @source_id
+    RETURNING kind, created_at
+)
+INSERT INTO notification_opt_outs (reader_id, kind, created_at)
+SELECT @target_id::uuid, kind, created_at
+FROM moved
+ON CONFLICT (reader_id, kind) DO NOTHING;
+
+-- name: MoveReaderPendingNotifications :exec
+UPDATE notifications
+SET reader_id = @target_id
+WHERE reader_id = @source_id AND status = 'pending';
+
+-- name: MoveReaderOldTicketNumbers :exec
+UPDATE old_ticket_numbers
+SET reader_id = @target_id
+WHERE reader_id = @source_id;
+
+-- name: CreateReaderMerge :one
+INSERT INTO reader_merges (
+    surviving_reader_id, merged_reader_id, merged_ticket_number,
+    moved_issues, moved_fines, moved_visits, reason, librarian_id
+)
+VALUES (
+    @surviving_reader_id, @merged_reader_id, @merged_ticket_number,
+    @moved_issues, @moved_fines, @moved_visits, @reason, @librarian_id
+)
+RETURNING *;
+
+-- name: GetReaderMerges :many
+SELECT rm.id, rm.merged_reader_id, rm.merged_ticket_number, r.full_name as merged_full_name,
+       rm.moved_issues, rm.moved_fines, rm.moved_visits, rm.reason,
+       u.username as librarian_name, rm.merged_at
+FROM reader_merges rm
+JOIN readers r ON r.id = rm.merged_reader_id
+LEFT JOIN users u ON u.id = rm.librarian_id
+WHERE rm.surviving_reader_id = @reader_id
+ORDER BY rm.merged_at DESC;
@@ -1,7 +1,7 @@
 -- name: CreateReader :one
-INSERT INTO readers (ticket_number, full_name, email, phone)
-VALUES (@ticket_number, @full_name, @email, @phone)
-RETURNING id, ticket_number, created_at;
+INSERT INTO readers (ticket_number, full_name, email, phone, card_expires_at, category, membership_expires_at)
+VALUES (@ticket_number, @full_name, @email, @phone, @card_expires_at, @category, @membership_expires_at)
+RETURNING id, ticket_number, card_expires_at, category, membership_expires_at, created_at;
 
 -- name: UpdateReader :one
 UPDATE readers
@@ -14,13 +14,22 @@ UPDATE readers
 SET is_active = false
 WHERE id = @id;
 
+-- name: MarkReaderMerged :exec
+-- Номер билета дубля освобождается, чтобы поиск по нему находил прежний номер основной записи
+UPDATE readers
+SET ticket_number = 'MERGED' || left(replace(id::text, '-', ''), 14),
+    is_active = false
+WHERE id = @id;
+
 -- name: GetReaderByTicketNumber :one
-SELECT id, ticket_number, full_name, email, phone, is_active, registration_date
+SELECT id, ticket_number, full_name, email, phone, is_active, registration_date, card_expires_at,
+       category, membership_expires_at, language
 FROM readers
 WHERE ticket_number = @ticket_number;
 
 -- name: GetReaderById :one
-SELECT id, ticket_number, full_name, email, phone, is_active, registration_date
+SELECT id, ticket_number, full_name, email, phone, is_active, registration_date, card_expires_at,
+       category, membership_expires_at, language
 FROM readers
 WHERE id = @id;
 
@@ -44,3 +53,186 @@ FROM book_issues bi
 WHERE bi.reader_id = @reader_id
   AND bi.return_date IS NULL
   AND bi.due_date < CURRENT_DATE;
+
+-- name: NextTicketNumberSequence :one
+SELECT nextval('reader_ticket_seq')::bigint as sequence;
+
+-- name: ReissueReaderTicket :one
+UPDATE readers
+SET ticket_number = @new_ticket_number, card_expires_at = @card_expires_at
+WHERE id = @id
+RETURNING id, ticket_number, full_name, card_expires_at;
+
+-- name: CreateOldTicketNumber :exec
+INSERT INTO old_ticket_numbers (ticket_number, reader_id, replaced_by, reason, librarian_id)
+VALUES (@ticket_number, @reader_id, @replaced_by, @reason, @librarian_id);
+
+-- name: GetOldTicketNumber :one
+SELECT ticket_number, reader_id, replaced_by, replaced_at
+FROM old_ticket_numbers
+WHERE ticket_number = @ticket_number;
+
+-- name: GetReaderOldTicketNumbers :many
+SELECT ticket_number, replaced_by, reason, librarian_id, replaced_at
+FROM old_ticket_numbers
+WHERE reader_id = @reader_id
+ORDER BY replaced_at DESC;
+
+-- name: GetReaderLoanStatus :one
+SELECT r.id, r.is_active, r.category, r.membership_expires_at,
+       (SELECT COUNT(*) FROM book_issues bi
+        WHERE bi.reader_id = r.id AND bi.return_date IS NULL) as active_loans
+FROM readers r
+WHERE r.id = @id;
+
+-- name: RenewReaderMembership :one
+-- Билет продлевается вместе с членством, чтобы напечатанный срок не истекал раньше него
+UPDATE readers
+SET category = @category,
+    membership_expires_at = (GREATEST(COALESCE(membership_expires_at, CURRENT_DATE), CURRENT_DATE)
+        + make_interval(months => @months::int))::date,
+    card_expires_at = GREATEST(card_expires_at,
+        (GREATEST(COALESCE(membership_expires_at, CURRENT_DATE), CURRENT_DATE)
+            + make_interval(months => @months::int))::date)
+WHERE id = @id
+RETURNING id, ticket_number, full_name, category, membership_expires_at, card_expires_at;
+
+-- name: GetExpiringMemberships :many
+SELECT id, ticket_number, full_name, email, phone, category, membership_expires_at,
+       (membership_expires_at - CURRENT_DATE)::int as days_left
+FROM readers
+WHERE is_active = true
+  AND membership_expires_at BETWEEN CURRENT_DATE AND @until_date::date
+ORDER BY membership_expires_at, full_name;
+
+-- name: FindDuplicateReaders :many
+WITH pairs AS (
+    SELECT a.id as reader_id, b.id as candidate_id,
+           similarity(normalize_person_name(a.full_name), normalize_person_name(b.full_name))::float8 as name_score,
+           COALESCE(lower(a.email) = lower(b.email), false) as email_match,
+           COALESCE(normalize_phone(a.phone) = normalize_phone(b.phone), false) as phone_match
+    FROM readers a
+    JOIN readers b ON b.id <> a.id
+        AND (normalize_person_name(a.full_name) % normalize_person_name(b.full_name)
+            OR lower(a.email) = lower(b.email)
+            OR normalize_phone(a.phone) = normalize_phone(b.phone))
+    WHERE a.is_active = true
+      AND b.is_active = true
+      AND ((@reader_id::uuid IS NULL AND a.id < b.id) OR a.id = @reader_id::uuid)
+), scored AS (
+    SELECT p.*,
+           (p.name_score * 0.6
+               + CASE WHEN p.email_match THEN 0.25 ELSE 0 END
+               + CASE WHEN p.phone_match THEN 0.15 ELSE 0 END)::float8 as score
+    FROM pairs p
+)
+SELECT s.reader_id, a.ticket_number, a.full_name, a.email, a.phone,
+       s.candidate_id, b.ticket_number as candidate_ticket_number, b.full_name as candidate_full_name,
+       b.email as candidate_email, b.phone as candidate_phone,
+       s.name_score, s.email_match, s.phone_match, s.score
+FROM scored s
+JOIN readers a ON a.id = s.reader_id
+JOIN readers b ON b.id = s.candidate_id
+WHERE s.score >= @min_score::float8
+ORDER BY s.score DESC, a.full_name
+LIMIT @limit_count;
+
+-- name: GetReaderOpenObligations :one
+SELECT
+    (SELECT COUNT(*) FROM book_issues bi
+     WHERE bi.reader_id = @reader_id AND bi.return_date IS NULL) as open_loans,
+    (SELECT COUNT(*) FROM fines f
+     WHERE f.reader_id = @reader_id AND f.is_paid = false) as unpaid_fines;
+
+-- name: AnonymizeReader :one
+UPDATE readers
+SET ticket_number = 'ANON' || left(replace(id::text, '-', ''), 16),
+    full_name = 'Анонимный читатель',
+    email = NULL,
+    phone = NULL,
+    pin_hash = NULL,
+    is_active = false,
+    anonymized_at = CURRENT_TIMESTAMP
+WHERE id = @id AND anonymized_at IS NULL
+RETURNING id, ticket_number, anonymized_at;
+
+-- name: AnonymizeMergedReaders :many
+-- Дубли, объединенные с читателем, в том числе через цепочку объединений, хранят его прежние данные
+WITH RECURSIVE merged AS (
+    SELECT m.merged_reader_id AS id
+    FROM reader_merges m
+    WHERE m.surviving_reader_id = @reader_id
+    UNION
+    SELECT m.merged_reader_id
+    FROM reader_merges m
+    JOIN merged ON m.surviving_reader_id = merged.id
+)
+UPDATE readers r
+SET ticket_number = 'ANON' || left(replace(r.id::text, '-', ''), 16),
+    full_name = 'Анонимный читатель',
+    email = NULL,
+    phone = NULL,
+    pin_hash = NULL,
+    is_active = false,
+    anonymized_at = CURRENT_TIMESTAMP
+FROM merged
+WHERE r.id = merged.id AND r.anonymized_at IS NULL
+RETURNING r.id, r.ticket_number;
+
+-- name: DeleteReaderOldTicketNumbers :exec
+DELETE FROM old_ticket_numbers
+WHERE reader_id = @reader_id;
+
+-- name: ScrubMergedTicketNumbers :exec
+UPDATE reader_merges
+SET merged_ticket_number = @ticket_number
+WHERE merged_reader_id = @reader_id;
+
+-- name: GetReadersForAnonymization :many
+SELECT r.id
+FROM readers r
+WHERE r.anonymized_at IS NULL
+  AND COALESCE(r.created_at, r.registration_date) < @cutoff::timestamp
+  -- бессрочное членство (NULL) не истекает, такие читатели не обезличиваются
+  AND r.membership_expires_at < @cutoff::timestamp
+  AND NOT EXISTS (
+      SELECT 1 FROM book_issues bi
+      WHERE bi.reader_id = r.id
+        AND (bi.return_date IS NULL OR bi.return_date >= @cutoff::timestamp OR bi.issue_date >= @cutoff::timestamp)
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM fines f
+      WHERE f.reader_id = r.id AND (f.is_paid = false OR f.fine_date >= @cutoff::timestamp)
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM hall_visits hv
+      WHERE hv.reader_id = r.id AND hv.visit_time >= @cutoff::timestamp
+  )
+ORDER BY r.created_at
+LIMIT @limit_count;
+
+-- name: GetReaderPortalCredentials :one
+SELECT id, pin_hash, is_active, membership_expires_at
+FROM readers
+WHERE ticket_number = @ticket_number;
+
+-- name: SetReaderPin :exec
+UPDATE readers
+SET pin_hash = @pin_hash
+WHERE id = @id;
+
+-- name: UpdateReaderContacts :one
+UPDATE readers
+SET email = @email, phone = @phone
+WHERE id = @id
+RETURNING id, ticket_number, full_name, email, phone;
+
+-- name: GetReaderNotificationSettings :one
+SELECT id, email, phone, language, preferred_channel
+FROM readers
+WHERE id = @id;
+
+-- name: SetReaderNotificationSettings :exec
+UPDATE readers
+SET language = @language, preferred_channel = @preferred_channel
+WHERE id = @id;
@@ -1,21 +1,28 @@
 -- name: CreateReadingHall :one
-INSERT INTO reading_halls (hall_name, specialization, total_seats)
-VALUES (@hall_name, @specialization, @total_seats)
-RETURNING id, hall_name, specialization, total_seats, current_visitors;
+INSERT INTO reading_halls (hall_name, hall_code, specialization, total_seats, booking_slot_minutes, no_show_minutes)
+VALUES (@hall_name, @hall_code, @specialization, @total_seats, @booking_slot_minutes, @no_show_minutes)
+RETURNING id, hall_name, hall_code, specialization, total_seats, current_visitors, booking_slot_minutes, no_show_minutes;
 
 -- name: UpdateReadingHall :one
 UPDATE reading_halls
-SET hall_name = @hall_name, specialization = @specialization, total_seats = @total_seats
+SET hall_name = @hall_name, hall_code = @hall_code, specialization = @specialization, total_seats = @total_seats,
+    booking_slot_minutes = @booking_slot_minutes, no_show_minutes = @no_show_minutes
 WHERE id = @id
-RETURNING id, hall_name, specialization, total_seats, current_visitors;
+RETURNING id, hall_name, hall_code, specialization, total_seats, current_visitors, booking_slot_minutes, no_show_minutes;
 
 -- name: GetReadingHallById :one
-SELECT id, hall_name, specialization, total_seats, current_visitors
+SELECT id, hall_name, hall_code, specialization, total_seats, current_visitors, booking_slot_minutes, no_show_minutes
 FROM reading_halls
 WHERE id = @id;
 
+-- name: LockReadingHall :one
+SELECT total_seats
+FROM reading_halls
+WHERE id = @id
+FOR UPDATE;
+
 -- name: GetAllReadingHalls :many
-SELECT id, hall_name, specialization, total_seats, current_visitors
+SELECT id, hall_name, hall_code, specialization, total_seats, current_visitors, booking_slot_minutes, no_show_minutes
 FROM reading_halls
 ORDER BY hall_name;
 
@@ -29,10 +36,22 @@ SELECT
     ROUND(
         (rh.current_visitors::numeric / rh.total_seats * 100), 2
     ) as occupancy_percentage,
-    (rh.total_seats - rh.current_visitors) as free_seats,
+    COALESCE(active_bookings.booked_seats, 0) as booked_seats,
+    GREATEST(rh.total_seats - rh.current_visitors - COALESCE(active_bookings.booked_seats, 0), 0)::int as free_seats,
     COALESCE(daily_stats.visits_today, 0) as visits_today,
     COALESCE(daily_stats.unique_visitors_today, 0) as unique_visitors_today
 FROM reading_halls rh
+LEFT JOIN (
+    -- Забронированные, но еще не занятые места в текущем слоте
+    SELECT
+        hall_id,
+        COUNT(*) as booked_seats
+    FROM seat_bookings
+    WHERE status = 'booked'
+      AND start_time <= CURRENT_TIMESTAMP
+      AND end_time > CURRENT_TIMESTAMP
+    GROUP BY hall_id
+) active_bookings ON rh.id = active_bookings.hall_id
 LEFT JOIN (
     SELECT
         hall_id,
@@ -0,0 +1,99 @@
+-- name: CreateSeatBooking :one
+INSERT INTO seat_bookings (reader_id, hall_id, seat_id, start_time, end_time, librarian_id)
+VALUES (@reader_id, @hall_id, @seat_id, @start_time, @end_time, @librarian_id)
+RETURNING id, reader_id, hall_id, seat_id, start_time, end_time, status, created_at;
+
+-- name: GetSeatBookingById :one
+SELECT
+    sb.id,
+    sb.reader_id,
+    r.ticket_number,
+    r.full_name as reader_name,
+    sb.hall_id,
+    rh.hall_name,
+    hs.seat_number,
+    sb.start_time,
+    sb.end_time,
+    sb.status,
+    sb.visit_id
+FROM seat_bookings sb
+JOIN readers r ON sb.reader_id = r.id
+JOIN reading_halls rh ON sb.hall_id = rh.id
+LEFT JOIN hall_seats hs ON sb.seat_id = hs.id
+WHERE sb.id = @id;
+
+-- name: CountOverlappingHallBookings :one
+SELECT COUNT(*) as bookings_count
+FROM seat_bookings
+WHERE hall_id = @hall_id
+  AND status IN ('booked', 'checked_in')
+  AND tsrange(start_time, end_time) && tsrange(@start_time::timestamp, @end_time::timestamp);
+
+-- name: CountReaderOverlappingBookings :one
+SELECT COUNT(*) as bookings_count
+FROM seat_bookings
+WHERE reader_id = @reader_id
+  AND status IN ('booked', 'checked_in')
+  AND tsrange(start_time, end_time) && tsrange(@start_time::timestamp, @end_time::timestamp);
+
+-- name: GetHallBookings :many
+SELECT
+    sb.id,
+    r.ticket_number,
+    r.full_name as reader_name,
+    hs.seat_number,
+    sb.start_time,
+    sb.end_time,
+    sb.status
+FROM seat_bookings sb
+JOIN readers r ON sb.reader_id = r.id
+LEFT JOIN hall_seats hs ON sb.seat_id = hs.id
+WHERE sb.hall_id = @hall_id
+  AND sb.start_time < @day_end
+  AND sb.end_time > @day_start
+ORDER BY sb.start_time, hs.seat_number;
+
+-- name: GetReaderBookings :many
+SELECT
+    sb.id,
+    rh.hall_name,
+    hs.seat_number,
+    sb.start_time,
+    sb.end_time,
+    sb.status
+FROM seat_bookings sb
+JOIN reading_halls rh ON sb.hall_id = rh.id
+LEFT JOIN hall_seats hs ON sb.seat_id = hs.id
+WHERE sb.reader_id = @reader_id
+ORDER BY sb.start_time DESC;
+
+-- name: CancelSeatBooking :one
+UPDATE seat_bookings
+SET status = 'cancelled'
+WHERE id = @id AND status = 'booked'
+RETURNING id, status;
+
+-- name: CheckInSeatBooking :one
+UPDATE seat_bookings
+SET status = 'checked_in', visit_id = @visit_id
+WHERE id = (
+    SELECT sb.id
+    FROM seat_bookings sb
+    JOIN reading_halls rh ON sb.hall_id = rh.id
+    WHERE sb.hall_id = @hall_id
+      AND sb.reader_id = (SELECT id FROM readers WHERE ticket_number = @ticket_number)
+      AND sb.status = 'booked'
+      AND CURRENT_TIMESTAMP >= sb.start_time - make_interval(mins => rh.no_show_minutes)
+      AND CURRENT_TIMESTAMP < sb.end_time
+    ORDER BY sb.start_time
+    LIMIT 1
+)
+RETURNING id, seat_id, start_time, end_time;
+
+-- name: ReleaseNoShowBookings :execrows
+UPDATE seat_bookings sb
+SET status = 'no_show'
+FROM reading_halls rh
+WHERE sb.hall_id = rh.id
+  AND sb.status = 'booked'
+  AND sb.start_time + make_interval(mins => rh.no_show_minutes) < CURRENT_TIMESTAMP;
@@ -0,0 +1,40 @@
+-- name: CreateSeries :one
+INSERT INTO series (title, issn)
+VALUES (@title, @issn)
+RETURNING id, title, issn, created_at;
+
+-- name: UpdateSeries :one
+UPDATE series
+SET title = @title, issn = @issn
+WHERE id = @id
+RETURNING id, title, issn, created_at;
+
+-- name: GetSeriesById :one
+SELECT id, title, issn, created_at
+FROM series
+WHERE id = @id;
+
+-- name: SearchSeries :many
+SELECT id, title, issn, created_at
+FROM series
+WHERE @query::text = '' OR title ILIKE '%' || @query::text || '%'
+ORDER BY title
+LIMIT 50;
+
+-- name: DeleteSeries :execrows
+DELETE FROM series
+WHERE id = @id;
+
+-- name: GetSeriesBooks :many
+SELECT
+    b.id,
+    b.title,
+    b.series_volume,
+    b.isbn,
+    b.publication_year,
+    b.language,
+    b.total_copies,
+    b.available_copies
+FROM books b
+WHERE b.series_id = @series_id
+ORDER BY b.series_volume NULLS LAST, b.publication_year, b.title;
@@ -0,0 +1,122 @@
+-- name: CreateSubject :one
+INSERT INTO subjects (scheme, code, name, parent_id)
+VALUES (@scheme, @code, @name, @parent_id)
+RETURNING id, scheme, code, name, parent_id, created_at;
+
+-- name: UpdateSubject :one
+UPDATE subjects
+SET code = @code, name = @name, parent_id = @parent_id
+WHERE id = @id
+RETURNING id, scheme, code, name, parent_id, created_at;
+
+-- name: DeleteSubject :execrows
+DELETE FROM subjects
+WHERE id = @id;
+
+-- name: GetSubjectById :one
+SELECT id, scheme, code, name, parent_id, created_at
+FROM subjects
+WHERE id = @id;
+
+-- name: IsSubjectInSubtree :one
+WITH RECURSIVE subtree AS (
+    SELECT s.id FROM subjects s WHERE s.id = @root_id
+    UNION ALL
+    SELECT s.id FROM subjects s JOIN subtree t ON s.parent_id = t.id
+)
+SELECT EXISTS (SELECT 1 FROM subtree WHERE id = @subject_id) as in_subtree;
+
+-- name: GetSubjectTree :many
+WITH RECURSIVE closure AS (
+    SELECT s.id as ancestor_id, s.id as descendant_id
+    FROM subjects s
+    WHERE s.scheme = @scheme
+    UNION ALL
+    SELECT c.ancestor_id, s.id
+    FROM closure c
+    JOIN subjects s ON s.parent_id = c.descendant_id
+)
+SELECT
+    s.id,
+    s.parent_id,
+    s.code,
+    s.name,
+    (
+        SELECT COUNT(*)
+        FROM book_subjects bs
+        JOIN books b ON b.id = bs.book_id
+        WHERE bs.subject_id = s.id AND (NOT @held_only::boolean OR b.total_copies > 0)
+    ) as book_count,
+    (
+        SELECT COUNT(DISTINCT bs.book_id)
+        FROM closure c
+        JOIN book_subjects bs ON bs.subject_id = c.descendant_id
+        JOIN books b ON b.id = bs.book_id
+        WHERE c.ancestor_id = s.id AND (NOT @held_only::boolean OR b.total_copies > 0)
+    ) as subtree_book_count
+FROM subjects s
+WHERE s.scheme = @scheme
+ORDER BY s.code NULLS LAST, s.name;
+
+-- name: SearchSubjects :many
+SELECT id, scheme, code, name, parent_id, created_at
+FROM subjects
+WHERE (@scheme::text = '' OR scheme::text = @scheme::text)
+  AND (lower(name) LIKE '%' || lower(@query::text) || '%' OR code ILIKE @query::text || '%')
+ORDER BY scheme, code NULLS LAST, name
+LIMIT 50;
+
+-- name: AddBookSubject :exec
+INSERT INTO book_subjects (book_id, subject_id)
+VALUES (@book_id, @subject_id)
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveBookSubject :exec
+DELETE FROM book_subjects
+WHERE book_id = @book_id AND subject_id = @subject_id;
+
+-- name: GetBookSubjects :many
+SELECT s.id, s.scheme, s.code, s.name, s.parent_id, s.created_at
+FROM subjects s
+JOIN book_subjects bs ON s.id = bs.subject_id
+WHERE bs.book_id = @book_id
+ORDER BY s.scheme, s.code NULLS LAST, s.name;
+
+-- name: SuggestHallsForBook :many
+WITH RECURSIVE lineage AS (
+    -- Рубрики книги и все их предки: зал обычно назван по общей рубрике
+    SELECT s.id, s.parent_id, s.name
+    FROM book_subjects bs
+    JOIN subjects s ON s.id = bs.subject_id
+    WHERE bs.book_id = @book_id
+    UNION
+    SELECT p.id, p.parent_id, p.name
+    FROM subjects p
+    JOIN lineage l ON p.id = l.parent_id
+),
+matches AS (
+    SELECT
+        rh.id as hall_id,
+        l.name as subject_name,
+        GREATEST(
+            word_similarity(lower(l.name), lower(rh.specialization)),
+            word_similarity(lower(rh.specialization), lower(l.name))
+        ) as score
+    FROM reading_halls rh
+    CROSS JOIN lineage l
+    WHERE rh.specialization IS NOT NULL AND rh.specialization <> ''
+)
+SELECT best.id, best.hall_name, best.specialization, best.subject_name, best.score
+FROM (
+    SELECT DISTINCT ON (rh.id)
+        rh.id,
+        rh.hall_name,
+        rh.specialization,
+        m.subject_name,
+        m.score::float8 as score
+    FROM matches m
+    JOIN reading_halls rh ON rh.id = m.hall_id
+    WHERE m.score >= @min_score::float8
+    ORDER BY rh.id, m.score DESC
+) best
+ORDER BY best.score DESC, best.hall_name;
@@ -0,0 +1,164 @@
+-- name: CreateWebhookSubscription :one
+INSERT INTO webhook_subscriptions (url, secret, events, description, created_by)
+VALUES (@url, @secret, @events, @description, @created_by)
+RETURNING id, url, secret, events, description, is_active, created_by, created_at;
+
+-- name: GetWebhookSubscriptions :many
+SELECT id, url, events, description, is_active, created_by, created_at
+FROM webhook_subscriptions
+ORDER BY created_at;
+
+-- name: GetWebhookSubscription :one
+SELECT id, url, events, description, is_active, created_by, created_at
+FROM webhook_subscriptions
+WHERE id = @id;
+
+-- name: UpdateWebhookSubscription :one
+UPDATE webhook_subscriptions
+SET url = @url, events = @events, description = @description, is_active = @is_active
+WHERE id = @id
+RETURNING id, url, events, description, is_active, created_by, created_at;
+
+-- name: RotateWebhookSecret :one
+UPDATE webhook_subscriptions
+SET secret = @secret
+WHERE id = @id
+RETURNING id, secret;
+
+-- name: DeleteWebhookSubscription :execrows
+DELETE FROM webhook_subscriptions
+WHERE id = @id;
+
+-- name: CreateWebhookOutboxEntries :execrows
+INSERT INTO webhook_outbox (subscription_id, event_id, event_type, subject_id, payload)
+SELECT s.id, @event_id, @event_type, @subject_id, @payload
+FROM webhook_subscriptions s
+WHERE s.is_active = true
+  AND @event_type::text = ANY(s.events)
+-- loan.overdue публикуется один раз на выдачу, даже если проходы фоновой задачи пересеклись
+ON CONFLICT (subscription_id, event_type, subject_id) WHERE event_type = 'loan.overdue' DO NOTHING;
+
+-- name: ClaimPendingWebhooks :many
+UPDATE webhook_outbox o
+SET next_attempt_at = CURRENT_TIMESTAMP + make_interval(secs => @lease_seconds::int)
+FROM webhook_subscriptions s
+WHERE o.subscription_id = s.id
+  AND o.id IN (
+      SELECT p.id FROM webhook_outbox p
+      JOIN webhook_subscriptions ps ON p.subscription_id = ps.id
+      WHERE p.status = 'pending'
+        AND p.next_attempt_at <= CURRENT_TIMESTAMP
+        AND ps.is_active = true
+      ORDER BY p.next_attempt_at
+      LIMIT @limit_count
+      FOR UPDATE OF p SKIP LOCKED
+  )
+RETURNING o.id, o.event_id, o.event_type, o.payload, o.attempts, s.url, s.secret;
+
+-- name: RecordWebhookAttempt :exec
+INSERT INTO webhook_delivery_attempts (outbox_id, response_status, response_body, error, duration_ms)
+VALUES (@outbox_id, @response_status, @response_body, @error, @duration_ms);
+
+-- name: MarkWebhookDelivered :exec
+UPDATE webhook_outbox
+SET status = 'delivered',
+    attempts = attempts + 1,
+    delivered_at = CURRENT_TIMESTAMP
+WHERE id = @id;
+
+-- name: MarkWebhookFailed :exec
+UPDATE webhook_outbox
+SET attempts = attempts + 1,
+    status = CASE WHEN attempts + 1 >= @max_attempts::int THEN 'failed' ELSE 'pending' END::webhook_delivery_status,
+    next_attempt_at = CURRENT_TIMESTAMP + make_interval(secs => @retry_seconds::int)
+WHERE id = @id;
+
+-- name: RetryWebhookDelivery :one
+UPDATE webhook_outbox
+SET status = 'pending', next_attempt_at = CURRENT_TIMESTAMP
+WHERE id = @id AND status <> 'pending'
+RETURNING id, status, next_attempt_at;
+
+-- name: GetWebhookDeliveries :many
+SELECT o.id, o.event_id, o.event_type, o.subject_id, o.status, o.attempts,
+       o.next_attempt_at, o.delivered_at, o.created_at,
+       la.response_status as last_response_status,
+       la.error as last_error,
+       la.attempted_at as last_attempted_at
+FROM webhook_outbox o
+LEFT JOIN LATERAL (
+    SELECT a.response_status, a.error, a.attempted_at
+    FROM webhook_delivery_attempts a
+    WHERE a.outbox_id = o.id
+    ORDER BY a.attempted_at DESC
+    LIMIT 1
+) la ON true
+WHERE o.subscription_id = @subscription_id
+  AND (@status::text = '' OR o.status::text = @status::text)
+ORDER BY o.created_at DESC
+LIMIT @limit_count OFFSET @offset_count;
+
+-- name: GetWebhookDelivery :one
+SELECT id, subscription_id, event_id, event_type, subject_id, payload, status, attempts,
+       next_attempt_at, delivered_at, created_at
+FROM webhook_outbox
+WHERE id = @id;
+
+-- name: GetWebhookDeliveryAttempts :many
+SELECT id, outbox_id, response_status, response_body, error, duration_ms, attempted_at
+FROM webhook_delivery_attempts
+WHERE outbox_id = @outbox_id
+ORDER BY attempted_at;
+
+-- name: GetWebhookLoan :one
+SELECT bi.id as issue_id, r.id as reader_id, r.ticket_number, r.category,
+       bc.id as copy_id, bc.copy_code, b.id as book_id, b.title, b.isbn,
+       bi.issue_date, bi.due_date, bi.return_date,
+       GREATEST(COALESCE(bi.return_date, CURRENT_DATE) - bi.due_date, 0)::int as days_overdue
+FROM book_issues bi
+JOIN readers r ON bi.reader_id = r.id
+JOIN book_copies bc ON bi.book_copy_id = bc.id
+JOIN books b ON bc.book_id = b.id
+WHERE bi.id = @id;
+
+-- name: GetWebhookFine :one
+SELECT f.id as fine_id, r.id as reader_id, r.ticket_number, r.category,
+       f.book_issue_id, f.amount, f.reason, f.fine_date, f.paid_date, f.is_paid
+FROM fines f
+JOIN readers r ON f.reader_id = r.id
+WHERE f.id = @id;
+
+-- name: GetWebhookReader :one
+SELECT id as reader_id, ticket_number, category, is_active
+FROM readers
+WHERE id = @id;
+
+-- name: GetWebhookHallVisit :one
+SELECT hv.id as visit_id, r.id as reader_id, r.ticket_number, r.category,
+       rh.id as hall_id, rh.hall_name, hv.visit_time
+FROM hall_visits hv
+JOIN readers r ON hv.reader_id = r.id
+JOIN reading_halls rh ON hv.hall_id = rh.id
+WHERE hv.id = @id;
+
+-- name: GetLoansForOverdueWebhook :many
+SELECT bi.id
+FROM book_issues bi
+WHERE bi.return_date IS NULL
+  AND bi.due_date < CURRENT_DATE
+  AND NOT EXISTS (
+      SELECT 1 FROM webhook_outbox o
+      WHERE o.event_type = 'loan.overdue' AND o.subject_id = bi.id
+  )
+  AND EXISTS (
+      SELECT 1 FROM webhook_subscriptions s
+      WHERE s.is_active = true AND 'loan.overdue' = ANY(s.events)
+  )
+ORDER BY bi.due_date
+LIMIT @limit_count;
+
+-- name: DeleteReaderWebhookEvents :exec
+-- События с номером билета читателя удаляются вместе с журналом попыток доставки
+DELETE FROM webhook_outbox
+WHERE subject_id = ANY(@reader_ids::uuid[])
+   OR (payload -> 'data' ->> 'reader_id')::uuid = ANY(@reader_ids::uuid[]);
@@ -0,0 +1,40 @@
+-- name: CreateWork :one
+INSERT INTO works (title)
+VALUES (@title)
+RETURNING id, title, created_at;
+
+-- name: UpdateWork :one
+UPDATE works
+SET title = @title
+WHERE id = @id
+RETURNING id, title, created_at;
+
+-- name: GetWorkById :one
+SELECT id, title, created_at
+FROM works
+WHERE id = @id;
+
+-- name: SearchWorks :many
+SELECT id, title, created_at
+FROM works
+WHERE @query::text = '' OR title ILIKE '%' || @query::text || '%'
+ORDER BY title
+LIMIT 50;
+
+-- name: DeleteWork :execrows
+DELETE FROM works
+WHERE id = @id;
+
+-- name: GetWorkEditions :many
+SELECT
+    b.id,
+    b.title,
+    b.isbn,
+    b.publication_year,
+    b.publisher,
+    b.language,
+    b.total_copies,
+    b.available_copies
+FROM books b
+WHERE b.work_id = @work_id
+ORDER BY b.publication_year NULLS LAST, b.language, b.title;
@@ -6,21 +6,95 @@ SET search_path TO library;
 
 -- Добавление модуля для UUID
 CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+-- Модуль для ограничений исключения по интервалам бронирования
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+-- Модуль триграммного сходства для поиска дублей читателей
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Нормализация ФИО: регистр, ё/е и лишние пробелы не влияют на сравнение
+CREATE OR REPLACE FUNCTION normalize_person_name(name TEXT)
+RETURNS TEXT AS $$
+    SELECT lower(regexp_replace(translate(btrim(name), 'Ёё', 'Ее'), '\s+', ' ', 'g'));
+$$ LANGUAGE sql IMMUTABLE;
+
+-- Нормализация телефона: только цифры, российский префикс 8 приводится к 7
+CREATE OR REPLACE FUNCTION normalize_phone(phone TEXT)
+RETURNS TEXT AS $$
+    SELECT CASE
+        WHEN digits = '' THEN NULL
+        WHEN length(digits) = 11 AND left(digits, 1) = '8' THEN '7' || substr(digits, 2)
+        ELSE digits
+    END
+    FROM (SELECT regexp_replace(phone, '\D', '', 'g') AS digits) d;
+$$ LANGUAGE sql IMMUTABLE;
+
+-- Слова имени автора без инициалов: «Толстой Л.Н.» и «Лев Николаевич Толстой» дают общее «толстой»
+CREATE OR REPLACE FUNCTION person_name_words(name TEXT)
+RETURNS TEXT[] AS $$
+    SELECT COALESCE(array_agg(DISTINCT w), '{}')
+    FROM regexp_split_to_table(normalize_person_name(regexp_replace(name, '[.,]', ' ', 'g')), ' ') w
+    WHERE length(w) > 1;
+$$ LANGUAGE sql IMMUTABLE;
+
+-- Первые буквы всех частей имени: у «Толстой Л.Н.» и «Лев Николаевич Толстой» они совпадают
+CREATE OR REPLACE FUNCTION person_name_initials(name TEXT)
+RETURNS TEXT[] AS $$
+    SELECT COALESCE(array_agg(DISTINCT left(w, 1)), '{}')
+    FROM regexp_split_to_table(normalize_person_name(regexp_replace(name, '[.,]', ' ', 'g')), ' ') w
+    WHERE w <> '';
+$$ LANGUAGE sql IMMUTABLE;
 
 -- Создание типов данных
-CREATE TYPE user_role AS ENUM ('administrator', 'librarian');
+CREATE TYPE user_role AS ENUM ('administrator', 'librarian', 'kiosk');
 
 CREATE TYPE book_status AS ENUM (
     'available',
     'issued',
     'reserved',
     'lost',
-    'damaged'
+    'damaged',
+    'in_repair',
+    'withdrawn',
+    'in_transit'
 );
 
 CREATE TYPE visit_type AS ENUM ('entry', 'exit');
 
--- 1. Таблица пользователей системы (только администраторы и библиотекари)
+CREATE TYPE reader_category AS ENUM ('student', 'staff', 'guest', 'child', 'general');
+
+CREATE TYPE booking_status AS ENUM (
+    'booked',
+    'checked_in',
+    'cancelled',
+    'no_show'
+);
+
+CREATE TYPE notification_channel AS ENUM ('email', 'sms');
+
+CREATE TYPE notification_kind AS ENUM ('due_soon', 'overdue', 'hold_available');
+
+CREATE TYPE notification_status AS ENUM ('pending', 'sent', 'failed', 'cancelled');
+
+CREATE TYPE webhook_delivery_status AS ENUM ('pending', 'delivered', 'failed');
+
+CREATE TYPE ill_request_status AS ENUM ('active', 'fulfilled', 'cancelled');
+
+-- Системы рубрикации: классификации УДК, ББК и ДДК, предметные рубрики и жанры
+CREATE TYPE subject_scheme AS ENUM ('udc', 'bbk', 'ddc', 'topic', 'genre');
+
+-- Роль участника в создании книги; порядок значений задает порядок вывода
+CREATE TYPE contributor_role AS ENUM ('author', 'editor', 'translator', 'illustrator');
+
+-- Вид варианта имени автора: другое написание или псевдоним либо транслитерация
+CREATE TYPE author_name_kind AS ENUM ('variant', 'transliteration');
+
+-- Вид вложения книги: изображение обложки или PDF с оглавлением
+CREATE TYPE attachment_kind AS ENUM ('cover', 'toc');
+
+-- Состояние инвентаризации зала: идет сканирование, завершена или отменена
+CREATE TYPE inventory_session_status AS ENUM ('open', 'closed', 'cancelled');
+
+-- 1. Таблица пользователей системы (администраторы, библиотекари и служебные учетные записи киосков)
 CREATE TABLE users (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     username VARCHAR(50) UNIQUE NOT NULL,
@@ -35,9 +109,12 @@ CREATE TABLE users (
 CREATE TABLE reading_halls (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     hall_name VARCHAR(100) NOT NULL,
+    hall_code VARCHAR(10) UNIQUE, -- короткий код зала для шифров экземпляров
     specialization VARCHAR(200),
     total_seats INTEGER NOT NULL CHECK (total_seats > 0),
     current_visitors INTEGER DEFAULT 0 CHECK (current_visitors >= 0),
+    booking_slot_minutes INTEGER NOT NULL DEFAULT 60 CHECK (booking_slot_minutes > 0),
+    no_show_minutes INTEGER NOT NULL DEFAULT 15 CHECK (no_show_minutes >= 0),
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     CONSTRAINT chk_current_visitors CHECK (current_visitors <= total_seats)
 );
@@ -50,10 +127,20 @@ CREATE TABLE readers (
     email VARCHAR(256), -- для уведомлений
     phone VARCHAR(20),
     registration_date DATE DEFAULT CURRENT_DATE,
+    card_expires_at DATE, -- срок действия читательского билета
+    category reader_category NOT NULL DEFAULT 'general', -- определяет лимиты выдачи
+    membership_expires_at DATE, -- NULL - бессрочное членство
+    anonymized_at TIMESTAMP, -- персональные данные удалены, статистика сохранена
+    pin_hash VARCHAR(255), -- bcrypt-хеш PIN для входа на портал читателя
+    language VARCHAR(2) NOT NULL DEFAULT 'ru' CHECK (language IN ('ru', 'en')), -- язык уведомлений
+    preferred_channel notification_channel, -- NULL - email, а при его отсутствии SMS
     is_active BOOLEAN DEFAULT TRUE,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
+-- Последовательность для генерации номеров читательских билетов
+CREATE SEQUENCE reader_ticket_seq START 1;
+
 -- 4. Таблица истории посещений залов
 CREATE TABLE hall_visits (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
@@ -64,36 +151,68 @@ CREATE TABLE hall_visits (
     librarian_id UUID REFERENCES users(id)
 );
 
--- 5. Таблица авторов
+-- 5. Таблица авторов: авторитетные записи с основной формой имени
 CREATE TABLE authors (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     full_name VARCHAR(200) NOT NULL,
+    sort_name VARCHAR(200), -- форма для сортировки: «Толстой, Лев Николаевич»
+    birth_year INTEGER,
+    death_year INTEGER,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_author_years CHECK (death_year >= birth_year)
+);
+
+-- 6. Произведения: объединяют издания одного текста, в том числе переводы и переиздания
+CREATE TABLE works (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    title VARCHAR(500) NOT NULL, -- заглавие на языке оригинала
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 7. Серии и многотомные издания
+CREATE TABLE series (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    title VARCHAR(500) NOT NULL,
+    issn VARCHAR(9),
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
--- 6. Таблица книг
+-- 8. Таблица книг (изданий)
 CREATE TABLE books (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     title VARCHAR(500) NOT NULL,
     isbn VARCHAR(17),
     publication_year INTEGER,
     publisher VARCHAR(200),
-    total_copies INTEGER NOT NULL DEFAULT 1 CHECK (total_copies > 0),
-    available_copies INTEGER NOT NULL DEFAULT 1,
+    work_id UUID REFERENCES works(id) ON DELETE SET NULL,
+    series_id UUID REFERENCES series(id) ON DELETE SET NULL,
+    series_volume INTEGER CHECK (series_volume > 0), -- номер тома или выпуска в серии
+    language VARCHAR(3), -- код ISO 639-2: rus, eng
+    original_language VARCHAR(3), -- язык оригинала для переводов
+    page_count INTEGER CHECK (page_count > 0),
+    description TEXT,
+    -- счетчики поддерживаются триггером по таблице book_copies:
+    -- в фонде все экземпляры, кроме списанных и утерянных
+    total_copies INTEGER NOT NULL DEFAULT 0 CHECK (total_copies >= 0),
+    available_copies INTEGER NOT NULL DEFAULT 0,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    -- время последнего изменения описания, авторов или наличия по залам (датировка OAI-PMH);
+    -- выдачи и возвраты его не меняют, см. триггеры ниже
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
     CONSTRAINT chk_available_copies CHECK (
         available_copies >= 0 AND available_copies <= total_copies
     )
 );
 
--- 7. Связующая таблица авторов и книг (многие ко многим)
+-- 9. Связующая таблица авторов и книг (многие ко многим); один человек может участвовать в нескольких ролях
 CREATE TABLE book_authors (
     book_id UUID REFERENCES books(id) ON DELETE CASCADE,
     author_id UUID REFERENCES authors(id) ON DELETE CASCADE,
-    PRIMARY KEY (book_id, author_id)
+    role contributor_role NOT NULL DEFAULT 'author',
+    PRIMARY KEY (book_id, author_id, role)
 );
 
--- 8. Таблица экземпляров книг
+-- 10. Таблица экземпляров книг
 CREATE TABLE book_copies (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     book_id UUID NOT NULL REFERENCES books(id) ON DELETE CASCADE,
@@ -101,10 +220,18 @@ CREATE TABLE book_copies (
     status book_status DEFAULT 'available',
     hall_id UUID REFERENCES reading_halls(id), -- в каком зале находится книга
     location_info TEXT,
+    -- экземпляры, полученные по МБА: агентство NCIP библиотеки-владельца и ее штрихкод
+    owner_library VARCHAR(100),
+    external_item_id VARCHAR(100),
+    -- читатель, для которого отложен экземпляр в статусе reserved; NULL для запросов партнеров по NCIP
+    hold_reader_id UUID REFERENCES readers(id) ON DELETE SET NULL,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
--- 9. Таблица выдач книг
+-- Последовательность для генерации шифров экземпляров
+CREATE SEQUENCE copy_code_seq START 1;
+
+-- 11. Таблица выдач книг
 CREATE TABLE book_issues (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     reader_id UUID NOT NULL REFERENCES readers(id),
@@ -112,7 +239,11 @@ CREATE TABLE book_issues (
     issue_date DATE DEFAULT CURRENT_DATE,
     due_date DATE NOT NULL,
     return_date DATE,
+    renewal_count INTEGER NOT NULL DEFAULT 0, -- продления самим читателем через портал или киоск
     librarian_id UUID REFERENCES users(id),
+    -- межбиблиотечный абонемент: агентство NCIP библиотеки-партнера и ее читатель
+    source_library VARCHAR(100),
+    external_user_id VARCHAR(100),
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     CONSTRAINT chk_dates CHECK (
         due_date >= issue_date AND
@@ -120,7 +251,7 @@ CREATE TABLE book_issues (
     )
 );
 
--- 10. Таблица штрафов
+-- 12. Таблица штрафов
 CREATE TABLE fines (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     reader_id UUID NOT NULL REFERENCES readers(id),
@@ -133,10 +264,300 @@ CREATE TABLE fines (
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
+-- 13. Таблица мест в читальных залах (схема зала)
+CREATE TABLE hall_seats (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    hall_id UUID NOT NULL REFERENCES reading_halls(id) ON DELETE CASCADE,
+    seat_number VARCHAR(20) NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_hall_seats_number UNIQUE (hall_id, seat_number)
+);
+
+-- 14. Таблица бронирований мест на временные слоты
+CREATE TABLE seat_bookings (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    reader_id UUID NOT NULL REFERENCES readers(id),
+    hall_id UUID NOT NULL REFERENCES reading_halls(id),
+    seat_id UUID REFERENCES hall_seats(id), -- NULL, если у зала нет схемы мест
+    start_time TIMESTAMP NOT NULL,
+    end_time TIMESTAMP NOT NULL,
+    status booking_status NOT NULL DEFAULT 'booked',
+    visit_id UUID REFERENCES hall_visits(id), -- вход, в который превратилась бронь
+    librarian_id UUID REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_booking_time CHECK (end_time > start_time),
+    CONSTRAINT excl_seat_bookings_overlap EXCLUDE USING gist (
+        seat_id WITH =,
+        tsrange(start_time, end_time) WITH &&
+    ) WHERE (seat_id IS NOT NULL AND status IN ('booked', 'checked_in'))
+);
+
+-- 15. Сеансы пребывания в зале (пары вход/выход), пересчитываются фоновой задачей
+CREATE TABLE hall_visit_sessions (
+    entry_id UUID PRIMARY KEY REFERENCES hall_visits(id) ON DELETE CASCADE,
+    hall_id UUID NOT NULL REFERENCES reading_halls(id),
+    reader_id UUID NOT NULL REFERENCES readers(id),
+    entry_time TIMESTAMP NOT NULL,
+    exit_time TIMESTAMP -- NULL, если выход не зарегистрирован
+);
+
+-- 16. Почасовые агрегаты посещаемости залов
+CREATE TABLE hall_hourly_rollups (
+    hall_id UUID NOT NULL REFERENCES reading_halls(id) ON DELETE CASCADE,
+    bucket_start TIMESTAMP NOT NULL,
+    entries INTEGER NOT NULL DEFAULT 0,
+    exits INTEGER NOT NULL DEFAULT 0,
+    unique_visitors INTEGER NOT NULL DEFAULT 0,
+    peak_occupancy INTEGER NOT NULL DEFAULT 0,
+    closing_occupancy INTEGER NOT NULL DEFAULT 0,
+    refreshed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (hall_id, bucket_start)
+);
+
+-- 17. Дневные агрегаты посещаемости залов
+CREATE TABLE hall_daily_rollups (
+    hall_id UUID NOT NULL REFERENCES reading_halls(id) ON DELETE CASCADE,
+    visit_date DATE NOT NULL,
+    entries INTEGER NOT NULL DEFAULT 0,
+    unique_visitors INTEGER NOT NULL DEFAULT 0,
+    new_visitors INTEGER NOT NULL DEFAULT 0,
+    returning_visitors INTEGER NOT NULL DEFAULT 0,
+    sessions_count INTEGER NOT NULL DEFAULT 0,
+    avg_dwell_minutes DECIMAL(8, 1),
+    median_dwell_minutes DECIMAL(8, 1),
+    peak_occupancy INTEGER NOT NULL DEFAULT 0,
+    refreshed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (hall_id, visit_date)
+);
+
+-- 18. История статусов экземпляров
+CREATE TABLE copy_status_history (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    copy_id UUID NOT NULL REFERENCES book_copies(id) ON DELETE CASCADE,
+    from_status book_status, -- NULL для первой записи
+    to_status book_status NOT NULL,
+    reason TEXT NOT NULL,
+    issue_id UUID REFERENCES book_issues(id), -- выдача, вызвавшая переход
+    changed_by UUID REFERENCES users(id),
+    changed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 19. Замененные читательские билеты
+CREATE TABLE old_ticket_numbers (
+    ticket_number VARCHAR(20) PRIMARY KEY,
+    reader_id UUID NOT NULL REFERENCES readers(id) ON DELETE CASCADE,
+    replaced_by VARCHAR(20) NOT NULL, -- номер, выданный взамен
+    reason TEXT,
+    librarian_id UUID REFERENCES users(id),
+    replaced_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 20. Журнал объединения дублей читателей
+CREATE TABLE reader_merges (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    surviving_reader_id UUID NOT NULL REFERENCES readers(id),
+    merged_reader_id UUID NOT NULL REFERENCES readers(id),
+    merged_ticket_number VARCHAR(20) NOT NULL, -- номер билета дубля на момент объединения
+    moved_issues INTEGER NOT NULL DEFAULT 0,
+    moved_fines INTEGER NOT NULL DEFAULT 0,
+    moved_visits INTEGER NOT NULL DEFAULT 0,
+    reason TEXT,
+    librarian_id UUID REFERENCES users(id),
+    merged_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_merge_distinct CHECK (surviving_reader_id <> merged_reader_id)
+);
+
+-- 21. Очередь и журнал уведомлений читателей
+CREATE TABLE notifications (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    reader_id UUID NOT NULL REFERENCES readers(id) ON DELETE CASCADE,
+    channel notification_channel NOT NULL,
+    kind notification_kind NOT NULL,
+    issue_id UUID REFERENCES book_issues(id) ON DELETE CASCADE, -- выдача, о которой напоминаем
+    recipient VARCHAR(256) NOT NULL, -- email или телефон на момент постановки в очередь
+    language VARCHAR(2) NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}', -- данные для шаблона сообщения
+    dedupe_key VARCHAR(200) UNIQUE NOT NULL, -- одно и то же событие не отправляется дважды
+    status notification_status NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    sent_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 22. Отказы читателей от видов уведомлений
+CREATE TABLE notification_opt_outs (
+    reader_id UUID NOT NULL REFERENCES readers(id) ON DELETE CASCADE,
+    kind notification_kind NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (reader_id, kind)
+);
+
+-- 23. Подписки внешних систем на события
+CREATE TABLE webhook_subscriptions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    url TEXT NOT NULL,
+    secret VARCHAR(128) NOT NULL, -- ключ HMAC-подписи тела запроса
+    events TEXT[] NOT NULL, -- типы событий, например loan.issued
+    description TEXT,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by UUID REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_webhook_events CHECK (cardinality(events) > 0)
+);
+
+-- 24. Исходящие события (outbox): пишутся в транзакции изменения, по строке на подписку
+CREATE TABLE webhook_outbox (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    subscription_id UUID NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+    event_id UUID NOT NULL, -- общий для всех подписок идентификатор события
+    event_type VARCHAR(50) NOT NULL,
+    subject_id UUID, -- выдача, штраф, читатель или посещение, к которому относится событие
+    payload JSONB NOT NULL,
+    status webhook_delivery_status NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (subscription_id, event_id)
+);
+
+-- 25. Журнал попыток доставки событий
+CREATE TABLE webhook_delivery_attempts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    outbox_id UUID NOT NULL REFERENCES webhook_outbox(id) ON DELETE CASCADE,
+    response_status INTEGER, -- NULL, если ответа не было
+    response_body TEXT, -- начало ответа для отладки
+    error TEXT,
+    duration_ms INTEGER NOT NULL,
+    attempted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 26. Запросы библиотек-партнеров по NCIP (RequestItem): экземпляр отложен до выдачи партнеру
+CREATE TABLE ill_requests (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    source_library VARCHAR(100) NOT NULL,
+    external_request_id VARCHAR(100) NOT NULL,
+    external_user_id VARCHAR(100),
+    book_copy_id UUID NOT NULL REFERENCES book_copies(id) ON DELETE CASCADE,
+    status ill_request_status NOT NULL DEFAULT 'active',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (source_library, external_request_id)
+);
+
+-- 27. Следы удаленных книг для OAI-PMH: сборщики видят удаление и залы, где книга была
+CREATE TABLE book_tombstones (
+    book_id UUID PRIMARY KEY,
+    hall_ids UUID[] NOT NULL DEFAULT '{}',
+    deleted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 28. Записи из загруженных дампов MARC для заимствованной каталогизации; одна строка на ISBN
+CREATE TABLE marc_records (
+    isbn VARCHAR(13) PRIMARY KEY, -- ISBN-13 без дефисов
+    title VARCHAR(500) NOT NULL,
+    authors TEXT[] NOT NULL DEFAULT '{}',
+    publisher VARCHAR(200),
+    publication_year INTEGER,
+    source VARCHAR(200) NOT NULL, -- имя файла дампа
+    loaded_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 29. Рубрики: индексы классификаций и предметные рубрики, дерево внутри одной системы
+CREATE TABLE subjects (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    scheme subject_scheme NOT NULL,
+    code VARCHAR(50), -- индекс классификации: 821.161.1, 84(2Рос=Рус)1, 891.73; у рубрик может отсутствовать
+    name VARCHAR(300) NOT NULL,
+    parent_id UUID REFERENCES subjects(id) ON DELETE RESTRICT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (scheme, code)
+);
+
+-- 30. Связующая таблица книг и рубрик (многие ко многим)
+CREATE TABLE book_subjects (
+    book_id UUID REFERENCES books(id) ON DELETE CASCADE,
+    subject_id UUID REFERENCES subjects(id) ON DELETE CASCADE,
+    PRIMARY KEY (book_id, subject_id)
+);
+
+-- 31. Варианты имени автора: другие написания, псевдонимы и транслитерации
+CREATE TABLE author_name_variants (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    author_id UUID NOT NULL REFERENCES authors(id) ON DELETE CASCADE,
+    name VARCHAR(200) NOT NULL,
+    kind author_name_kind NOT NULL DEFAULT 'variant',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 32. Вложения книг: обложки и оглавления; сами файлы лежат в хранилище под storage_key
+CREATE TABLE book_attachments (
+    id UUID PRIMARY KEY, -- задается приложением, из него строятся ключи в хранилище
+    book_id UUID NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    kind attachment_kind NOT NULL,
+    storage_key VARCHAR(300) NOT NULL,
+    content_type VARCHAR(100) NOT NULL, -- определяется по содержимому файла
+    size_bytes BIGINT NOT NULL,
+    sha256 CHAR(64) NOT NULL,
+    width INTEGER,
+    height INTEGER,
+    thumbnail_widths INTEGER[] NOT NULL DEFAULT '{}', -- ширины уменьшенных копий, у PDF пусто
+    file_name VARCHAR(255),
+    uploaded_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 33. Инвентаризации фонда зала; в зале одновременно идет не больше одной
+CREATE TABLE inventory_sessions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    hall_id UUID NOT NULL REFERENCES reading_halls(id),
+    status inventory_session_status NOT NULL DEFAULT 'open',
+    note TEXT,
+    started_by UUID REFERENCES users(id),
+    started_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    closed_by UUID REFERENCES users(id),
+    closed_at TIMESTAMP
+);
+
+-- 34. Отсканированные при инвентаризации шифры; шифр без экземпляра в фонде остается с пустым copy_id
+CREATE TABLE inventory_scans (
+    session_id UUID NOT NULL REFERENCES inventory_sessions(id) ON DELETE CASCADE,
+    copy_code VARCHAR(50) NOT NULL,
+    copy_id UUID REFERENCES book_copies(id) ON DELETE SET NULL,
+    scanned_by UUID REFERENCES users(id),
+    scanned_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (session_id, copy_code)
+);
+
+-- 35. Экземпляры, не найденные на полках к завершению инвентаризации; список фиксируется при завершении
+CREATE TABLE inventory_missing (
+    session_id UUID NOT NULL REFERENCES inventory_sessions(id) ON DELETE CASCADE,
+    copy_id UUID NOT NULL REFERENCES book_copies(id) ON DELETE CASCADE,
+    status book_status NOT NULL, -- статус экземпляра при завершении
+    marked_lost_at TIMESTAMP, -- когда экземпляр списан в утерянные по итогам инвентаризации
+    PRIMARY KEY (session_id, copy_id)
+);
+
 -- Создание основных индексов
 CREATE INDEX idx_users_username ON users(username);
 CREATE INDEX idx_readers_ticket_number ON readers(ticket_number);
 CREATE INDEX idx_readers_email ON readers(email);
+CREATE INDEX idx_readers_membership_expires_at ON readers(membership_expires_at) WHERE is_active = TRUE;
+CREATE INDEX idx_old_ticket_numbers_reader_id ON old_ticket_numbers(reader_id);
+CREATE INDEX idx_readers_name_trgm ON readers USING gin(normalize_person_name(full_name) gin_trgm_ops);
+CREATE INDEX idx_readers_email_lower ON readers(lower(email));
+CREATE INDEX idx_readers_phone_normalized ON readers(normalize_phone(phone));
+CREATE INDEX idx_reader_merges_surviving ON reader_merges(surviving_reader_id);
+CREATE INDEX idx_notifications_pending ON notifications(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_notifications_reader_id ON notifications(reader_id, created_at DESC);
+CREATE INDEX idx_webhook_outbox_pending ON webhook_outbox(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_webhook_outbox_subscription ON webhook_outbox(subscription_id, created_at DESC);
+CREATE INDEX idx_webhook_outbox_subject ON webhook_outbox(event_type, subject_id);
+CREATE UNIQUE INDEX uq_webhook_outbox_overdue ON webhook_outbox(subscription_id, event_type, subject_id) WHERE event_type = 'loan.overdue';
+CREATE INDEX idx_webhook_delivery_attempts_outbox ON webhook_delivery_attempts(outbox_id);
 
 -- Индексы для залов и посещений
 CREATE INDEX idx_reading_halls_specialization ON reading_halls(specialization);
@@ -144,19 +565,45 @@ CREATE INDEX idx_hall_visits_reader_id ON hall_visits(reader_id);
 CREATE INDEX idx_hall_visits_hall_id ON hall_visits(hall_id);
 CREATE INDEX idx_hall_visits_time ON hall_visits(visit_time);
 CREATE INDEX idx_hall_visits_date ON hall_visits(DATE(visit_time));
+CREATE INDEX idx_hall_visits_reader_hall_time ON hall_visits(reader_id, hall_id, visit_time);
+CREATE INDEX idx_hall_visit_sessions_hall_entry ON hall_visit_sessions(hall_id, entry_time);
+CREATE INDEX idx_seat_bookings_hall_time ON seat_bookings(hall_id, start_time);
+CREATE INDEX idx_seat_bookings_reader_id ON seat_bookings(reader_id);
+CREATE INDEX idx_seat_bookings_active ON seat_bookings(hall_id) WHERE status = 'booked';
 
 -- Индексы для книг
 CREATE INDEX idx_books_title ON books USING gin(to_tsvector('russian', title));
 CREATE INDEX idx_books_isbn ON books(isbn);
+CREATE INDEX idx_books_updated_at ON books(updated_at, id);
+CREATE INDEX idx_books_work_id ON books(work_id);
+CREATE INDEX idx_books_series_id ON books(series_id, series_volume);
+CREATE INDEX idx_book_authors_author_id ON book_authors(author_id);
+CREATE INDEX idx_authors_name_normalized ON authors(normalize_person_name(full_name));
+CREATE INDEX idx_authors_name_words ON authors USING gin(person_name_words(full_name));
+CREATE UNIQUE INDEX idx_author_name_variants_name ON author_name_variants(author_id, normalize_person_name(name));
+CREATE INDEX idx_author_name_variants_normalized ON author_name_variants(normalize_person_name(name));
+CREATE INDEX idx_subjects_parent_id ON subjects(parent_id);
+CREATE INDEX idx_subjects_name_trgm ON subjects USING gin(lower(name) gin_trgm_ops);
+CREATE INDEX idx_book_subjects_subject_id ON book_subjects(subject_id);
+CREATE INDEX idx_book_attachments_book_id ON book_attachments(book_id, kind);
+CREATE UNIQUE INDEX idx_book_attachments_cover ON book_attachments(book_id) WHERE kind = 'cover';
+CREATE UNIQUE INDEX idx_inventory_sessions_open ON inventory_sessions(hall_id) WHERE status = 'open';
+CREATE INDEX idx_inventory_sessions_hall ON inventory_sessions(hall_id, started_at DESC);
+CREATE INDEX idx_inventory_scans_copy_id ON inventory_scans(session_id, copy_id);
+CREATE INDEX idx_book_tombstones_deleted_at ON book_tombstones(deleted_at, book_id);
 CREATE INDEX idx_book_copies_code ON book_copies(copy_code);
 CREATE INDEX idx_book_copies_status ON book_copies(status);
 CREATE INDEX idx_book_copies_hall_id ON book_copies(hall_id);
+CREATE UNIQUE INDEX idx_book_copies_external_item ON book_copies(owner_library, external_item_id) WHERE owner_library IS NOT NULL;
+CREATE INDEX idx_copy_status_history_copy ON copy_status_history(copy_id, changed_at);
 
 -- Индексы для выдач и штрафов
 CREATE INDEX idx_book_issues_reader_id ON book_issues(reader_id);
 CREATE INDEX idx_book_issues_active ON book_issues(reader_id) WHERE return_date IS NULL;
 CREATE INDEX idx_fines_reader_id ON fines(reader_id);
 CREATE INDEX idx_fines_unpaid ON fines(reader_id) WHERE is_paid = FALSE;
+CREATE INDEX idx_book_issues_source_library ON book_issues(source_library) WHERE source_library IS NOT NULL;
+CREATE INDEX idx_ill_requests_active_copy ON ill_requests(book_copy_id) WHERE status = 'active';
 
 -- Триггер для автоматического обновления счетчика посетителей в залах
 CREATE OR REPLACE FUNCTION update_hall_visitors()
@@ -180,3 +627,149 @@ CREATE TRIGGER trigger_update_hall_visitors
     AFTER INSERT ON hall_visits
     FOR EACH ROW
     EXECUTE FUNCTION update_hall_visitors();
+
+-- Триггер для пересчета счетчиков экземпляров книги
+CREATE OR REPLACE FUNCTION recount_book_copies(p_book_id UUID)
+RETURNS VOID AS $$
+BEGIN
+    UPDATE books
+    SET total_copies = (
+            SELECT COUNT(*) FROM book_copies
+            WHERE book_id = p_book_id
+              AND COALESCE(status, 'available') NOT IN ('withdrawn', 'lost')
+        ),
+        available_copies = (
+            SELECT COUNT(*) FROM book_copies
+            WHERE book_id = p_book_id
+              AND COALESCE(status, 'available') = 'available'
+        )
+    WHERE id = p_book_id;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION update_book_copy_counters()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        PERFORM recount_book_copies(OLD.book_id);
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') AND (TG_OP = 'INSERT' OR NEW.book_id <> OLD.book_id) THEN
+        PERFORM recount_book_copies(NEW.book_id);
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trigger_update_book_copy_counters
+    AFTER INSERT OR DELETE OR UPDATE OF status, book_id ON book_copies
+    FOR EACH ROW
+    EXECUTE FUNCTION update_book_copy_counters();
+
+-- Триггеры датировки книг для OAI-PMH: updated_at меняется вместе с описанием,
+-- составом авторов, залами экземпляров и переходом книги из фонда в списанные и обратно
+CREATE OR REPLACE FUNCTION touch_book_updated_at()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.title IS DISTINCT FROM OLD.title
+        OR NEW.isbn IS DISTINCT FROM OLD.isbn
+        OR NEW.publication_year IS DISTINCT FROM OLD.publication_year
+        OR NEW.publisher IS DISTINCT FROM OLD.publisher
+        OR NEW.work_id IS DISTINCT FROM OLD.work_id
+        OR NEW.series_id IS DISTINCT FROM OLD.series_id
+        OR NEW.series_volume IS DISTINCT FROM OLD.series_volume
+        OR NEW.language IS DISTINCT FROM OLD.language
+        OR NEW.original_language IS DISTINCT FROM OLD.original_language
+        OR NEW.page_count IS DISTINCT FROM OLD.page_count
+        OR NEW.description IS DISTINCT FROM OLD.description
+        OR (NEW.total_copies = 0) <> (OLD.total_copies = 0) THEN
+        NEW.updated_at := CURRENT_TIMESTAMP;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trigger_touch_book_updated_at
+    BEFORE UPDATE ON books
+    FOR EACH ROW
+    EXECUTE FUNCTION touch_book_updated_at();
+
+CREATE OR REPLACE FUNCTION touch_book_on_authors_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE books SET updated_at = CURRENT_TIMESTAMP
+    WHERE id = CASE WHEN TG_OP = 'DELETE' THEN OLD.book_id ELSE NEW.book_id END;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trigger_touch_book_on_authors_change
+    AFTER INSERT OR DELETE ON book_authors
+    FOR EACH ROW
+    EXECUTE FUNCTION touch_book_on_authors_change();
+
+-- Исправленное имя автора меняет записи всех его книг
+CREATE OR REPLACE FUNCTION touch_books_on_author_rename()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.full_name IS DISTINCT FROM OLD.full_name THEN
+        UPDATE books SET updated_at = CURRENT_TIMESTAMP
+        WHERE id IN (SELECT book_id FROM book_authors WHERE author_id = NEW.id);
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trigger_touch_books_on_author_rename
+    AFTER UPDATE OF full_name ON authors
+    FOR EACH ROW
+    EXECUTE FUNCTION touch_books_on_author_rename();
+
+-- Залы экземпляров задают наборы OAI-PMH: новый экземпляр, перенос в другой зал,
+-- списание или находка утерянного меняют состав наборов книги
+CREATE OR REPLACE FUNCTION touch_book_on_copies_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        UPDATE books SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.book_id;
+    ELSIF TG_OP = 'DELETE' THEN
+        UPDATE books SET updated_at = CURRENT_TIMESTAMP WHERE id = OLD.book_id;
+    ELSIF NEW.hall_id IS DISTINCT FROM OLD.hall_id
+        OR NEW.book_id <> OLD.book_id
+        OR (COALESCE(NEW.status, 'available') IN ('withdrawn', 'lost'))
+            <> (COALESCE(OLD.status, 'available') IN ('withdrawn', 'lost')) THEN
+        UPDATE books SET updated_at = CURRENT_TIMESTAMP WHERE id IN (OLD.book_id, NEW.book_id);
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trigger_touch_book_on_copies_change
+    AFTER INSERT OR DELETE OR UPDATE OF status, hall_id, book_id ON book_copies
+    FOR EACH ROW
+    EXECUTE FUNCTION touch_book_on_copies_change();
+
+-- Удаленная книга оставляет след с залами своих экземпляров
+CREATE OR REPLACE FUNCTION record_book_tombstone()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO book_tombstones (book_id, hall_ids)
+    VALUES (
+        OLD.id,
+        ARRAY(SELECT DISTINCT hall_id FROM book_copies WHERE book_id = OLD.id AND hall_id IS NOT NULL)
+    )
+    ON CONFLICT (book_id) DO UPDATE
+    SET hall_ids = EXCLUDED.hall_ids, deleted_at = CURRENT_TIMESTAMP;
+
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trigger_record_book_tombstone
+    BEFORE DELETE ON books
+    FOR EACH ROW
+    EXECUTE FUNCTION record_book_tombstone();